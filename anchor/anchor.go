@@ -0,0 +1,160 @@
+// Package anchor pans the view of the host against head motion, so a virtual screen appears anchored in space
+// instead of following the glass, a basic take on the anchored screen of Nebula. The orientation comes from
+// package fusion and pans are emitted to a PanSink, e.g. a VirtualMouse driving a zoomed desktop.
+package anchor
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/internal/device"
+)
+
+const (
+	// DEFAULT_PIXELS_PER_DEGREE is a starting point, tune it so the view moves as far as the head turns
+	DEFAULT_PIXELS_PER_DEGREE = 40.0
+	// DEFAULT_DEADZONE_DEGREES ignores small head motion and sensor noise around the anchor
+	DEFAULT_DEADZONE_DEGREES = 0.5
+)
+
+// PanSink moves the view of the host, dx and dy are in pixels since the previous call, right and down positive.
+type PanSink interface {
+	Pan(dx, dy int) error
+}
+
+// Config tunes how head motion maps onto pans.
+type Config struct {
+	PixelsPerDegree float64
+	// Deadzone is in degrees around the anchor, motion within it is ignored
+	Deadzone float64
+	// MaxOffsetX and MaxOffsetY clamp the offset from the anchor in pixels, e.g. to half the virtual desktop,
+	// 0 for no limit
+	MaxOffsetX float64
+	MaxOffsetY float64
+	// InvertX and InvertY flip the pan direction of an axis, as the right direction depends on how the view is
+	// driven, e.g. whether the desktop moves with the pointer or against it
+	InvertX bool
+	InvertY bool
+}
+
+// DefaultConfig returns a Config with DEFAULT_PIXELS_PER_DEGREE and DEFAULT_DEADZONE_DEGREES and no limits.
+func DefaultConfig() Config {
+	return Config{PixelsPerDegree: DEFAULT_PIXELS_PER_DEGREE, Deadzone: DEFAULT_DEADZONE_DEGREES}
+}
+
+// Offset is how far the view is panned from the anchor, in pixels.
+type Offset struct {
+	X float64
+	Y float64
+}
+
+func (o Offset) String() string {
+	return fmt.Sprintf("(x,y)=(%.0f, %.0f) px", o.X, o.Y)
+}
+
+// Anchor turns orientations into pans keeping the view where the head pointed when anchored.
+type Anchor struct {
+	config Config
+	sink   PanSink
+
+	// mutex for thread safety
+	mutex sync.Mutex
+	// reference is the orientation the view is anchored at, taken from the next update if not anchored
+	reference fusion.Attitude
+	anchored  bool
+	// emittedX and emittedY are the whole pixels already sent to the sink since anchoring
+	emittedX int
+	emittedY int
+}
+
+// New creates an Anchor sending pans to sink, anchored at the orientation of the first update.
+func New(config Config, sink PanSink) *Anchor {
+	return &Anchor{config: config, sink: sink}
+}
+
+// Update pans the view for a new orientation and returns the offset from the anchor.
+func (a *Anchor) Update(attitude fusion.Attitude) (Offset, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if !a.anchored {
+		a.reference = attitude
+		a.anchored = true
+		a.emittedX, a.emittedY = 0, 0
+	}
+
+	yaw := degrees(wrapAngle(attitude.Yaw - a.reference.Yaw))
+	pitch := degrees(wrapAngle(attitude.Pitch - a.reference.Pitch))
+
+	offset := Offset{
+		X: clamp(applyDeadzone(yaw, a.config.Deadzone)*a.config.PixelsPerDegree, a.config.MaxOffsetX),
+		Y: clamp(-applyDeadzone(pitch, a.config.Deadzone)*a.config.PixelsPerDegree, a.config.MaxOffsetY),
+	}
+	if a.config.InvertX {
+		offset.X = -offset.X
+	}
+	if a.config.InvertY {
+		offset.Y = -offset.Y
+	}
+
+	// fractions carry over to later updates, so slow head motion still pans
+	dx := int(math.Round(offset.X)) - a.emittedX
+	dy := int(math.Round(offset.Y)) - a.emittedY
+	if dx == 0 && dy == 0 {
+		return offset, nil
+	}
+	if err := a.sink.Pan(dx, dy); err != nil {
+		return offset, fmt.Errorf("failed to pan by (%d, %d): %w", dx, dy, err)
+	}
+	a.emittedX += dx
+	a.emittedY += dy
+	return offset, nil
+}
+
+// Recenter anchors the view at the orientation of the next update, leaving the view where it is.
+func (a *Anchor) Recenter() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.anchored = false
+}
+
+// Attach feeds the IMU events of the glass through filter into the Anchor, and recenters on a key press.
+// Note that it replaces the IMU and key event handlers of the device.
+func (a *Anchor) Attach(d device.Device, filter *fusion.ComplementaryFilter) {
+	d.SetIMUEventHandler(func(imu *device.IMUEvent) {
+		if _, err := a.Update(filter.Update(imu)); err != nil {
+			slog.Debug(err.Error())
+		}
+	})
+	d.SetKeyEventHandler(func(device.KeyEvent) {
+		a.Recenter()
+	})
+}
+
+// applyDeadzone shrinks value towards 0 by deadzone, so the offset grows continuously from the edge of it.
+func applyDeadzone(value, deadzone float64) float64 {
+	if math.Abs(value) <= deadzone {
+		return 0
+	}
+	return value - math.Copysign(deadzone, value)
+}
+
+// clamp limits value to [-limit, limit], a limit of 0 means no limit.
+func clamp(value, limit float64) float64 {
+	if limit <= 0 {
+		return value
+	}
+	return min(max(value, -limit), limit)
+}
+
+// wrapAngle wraps radians into [-pi, pi), so turning across the yaw wrap around does not jump the view.
+func wrapAngle(angle float64) float64 {
+	return math.Mod(math.Mod(angle+math.Pi, 2*math.Pi)+2*math.Pi, 2*math.Pi) - math.Pi
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
@@ -0,0 +1,72 @@
+package anchor_test
+
+import (
+	"math"
+	"testing"
+
+	"xreal-light-xr-go/anchor"
+	"xreal-light-xr-go/fusion"
+)
+
+type recordingSink struct {
+	x, y int
+	pans int
+}
+
+func (s *recordingSink) Pan(dx, dy int) error {
+	s.x += dx
+	s.y += dy
+	s.pans++
+	return nil
+}
+
+func radians(d float64) float64 {
+	return d * math.Pi / 180
+}
+
+func TestAnchorUpdate(t *testing.T) {
+	sink := &recordingSink{}
+	a := anchor.New(anchor.Config{PixelsPerDegree: 10, Deadzone: 1, MaxOffsetX: 200}, sink)
+
+	// the first update anchors the view, wherever the head points
+	start := fusion.Attitude{Yaw: radians(170), Pitch: radians(5)}
+	if _, err := a.Update(start); err != nil || sink.pans != 0 {
+		t.Fatalf("Update(start) = %v with %d pans, want nil and no pan", err, sink.pans)
+	}
+
+	// within the deadzone
+	a.Update(fusion.Attitude{Yaw: radians(170.5), Pitch: radians(5)})
+	if sink.pans != 0 {
+		t.Errorf("pans within the deadzone = %d, want 0", sink.pans)
+	}
+
+	// turning across the yaw wrap around, 6 degrees minus the deadzone
+	offset, err := a.Update(fusion.Attitude{Yaw: radians(-184), Pitch: radians(3)})
+	if err != nil {
+		t.Fatalf("Update() = %v", err)
+	}
+	if sink.x != 50 || sink.y != 10 {
+		t.Errorf("panned to (%d, %d), want (50, 10)", sink.x, sink.y)
+	}
+	if math.Round(offset.X) != 50 || math.Round(offset.Y) != 10 {
+		t.Errorf("offset = %v, want (50, 10)", offset)
+	}
+
+	// clamped to MaxOffsetX
+	a.Update(fusion.Attitude{Yaw: radians(-150), Pitch: radians(3)})
+	if sink.x != 200 {
+		t.Errorf("panned to x %d, want 200", sink.x)
+	}
+
+	// recentering keeps the view where it is
+	a.Recenter()
+	pans := sink.pans
+	a.Update(fusion.Attitude{Yaw: radians(90)})
+	if sink.pans != pans {
+		t.Errorf("Recenter() panned")
+	}
+	a.Update(fusion.Attitude{Yaw: radians(93)})
+	if sink.x != 220 {
+		t.Errorf("panned to x %d after recentering, want 220", sink.x)
+	}
+}
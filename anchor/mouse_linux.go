@@ -0,0 +1,60 @@
+//go:build linux
+
+package anchor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"xreal-light-xr-go/internal/uhid"
+)
+
+const (
+	// virtualMouseVendorID and virtualMouseProductID are arbitrary, they only need to not match real glasses
+	virtualMouseVendorID  = 0x1209
+	virtualMouseProductID = 0x0001
+)
+
+// VirtualMouse is a PanSink moving the pointer of a virtual HID mouse, e.g. to drive the zoom of a desktop
+// that follows the pointer. It needs read/write access to /dev/uhid, usually root.
+type VirtualMouse struct {
+	device *uhid.Device
+}
+
+// NewVirtualMouse creates the virtual mouse, call Close to remove it.
+func NewVirtualMouse() (*VirtualMouse, error) {
+	device, err := uhid.Create(uhid.Config{
+		Name:             "xreal-light-xr-go anchor",
+		VendorID:         virtualMouseVendorID,
+		ProductID:        virtualMouseProductID,
+		ReportDescriptor: uhid.RelativeMouseReportDescriptor(),
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create virtual mouse: %w", err)
+	}
+	return &VirtualMouse{device: device}, nil
+}
+
+// Pan moves the pointer by (dx, dy) pixels, split into several reports if out of the int16 range.
+func (m *VirtualMouse) Pan(dx, dy int) error {
+	for dx != 0 || dy != 0 {
+		stepX := min(max(dx, -math.MaxInt16), math.MaxInt16)
+		stepY := min(max(dy, -math.MaxInt16), math.MaxInt16)
+
+		report := make([]byte, 5)
+		binary.LittleEndian.PutUint16(report[1:3], uint16(int16(stepX)))
+		binary.LittleEndian.PutUint16(report[3:5], uint16(int16(stepY)))
+		if err := m.device.Input(report); err != nil {
+			return fmt.Errorf("failed to move virtual mouse: %w", err)
+		}
+		dx -= stepX
+		dy -= stepY
+	}
+	return nil
+}
+
+// Close removes the virtual mouse.
+func (m *VirtualMouse) Close() error {
+	return m.device.Destroy()
+}
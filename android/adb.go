@@ -0,0 +1,88 @@
+package android
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	DEFAULT_ADB_REMOTE     = "localabstract:xreal-events"
+	DEFAULT_ADB_LOCAL_PORT = 27183
+)
+
+// adbWriteTimeout bounds writes to the forwarded socket, so a stalled app drops events instead of blocking the glass.
+const adbWriteTimeout = 100 * time.Millisecond
+
+// ADBOptions selects the phone and the socket of the app for NewADBBridge.
+type ADBOptions struct {
+	// Serial selects the phone as listed by `adb devices` when several are attached, empty for the only one
+	Serial string
+	// LocalPort is the host port forwarded to the phone, DEFAULT_ADB_LOCAL_PORT if 0
+	LocalPort int
+	// Remote is the socket the app listens on in `adb forward` syntax, DEFAULT_ADB_REMOTE if empty, e.g. tcp:9000 for
+	// a ServerSocket
+	Remote string
+	// ADBPath is the adb executable, adb from PATH if empty
+	ADBPath string
+}
+
+// NewADBBridge forwards a local port to the app with `adb forward` and sends the events through it. The forward is
+// removed on Close.
+func NewADBBridge(options ADBOptions) (*Bridge, error) {
+	if options.LocalPort == 0 {
+		options.LocalPort = DEFAULT_ADB_LOCAL_PORT
+	}
+	if options.Remote == "" {
+		options.Remote = DEFAULT_ADB_REMOTE
+	}
+	if options.ADBPath == "" {
+		options.ADBPath = "adb"
+	}
+
+	local := fmt.Sprintf("tcp:%d", options.LocalPort)
+	if err := runADB(options, "forward", local, options.Remote); err != nil {
+		return nil, err
+	}
+
+	address := fmt.Sprintf("127.0.0.1:%d", options.LocalPort)
+	return &Bridge{
+		dial: func() (io.WriteCloser, error) {
+			conn, err := net.DialTimeout("tcp", address, time.Second)
+			if err != nil {
+				return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+			}
+			return &deadlineWriter{conn}, nil
+		},
+		release: func() error {
+			return runADB(options, "forward", "--remove", local)
+		},
+	}, nil
+}
+
+// runADB runs adb with args on the phone of options.
+func runADB(options ADBOptions, args ...string) error {
+	if options.Serial != "" {
+		args = append([]string{"-s", options.Serial}, args...)
+	}
+	output, err := exec.Command(options.ADBPath, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to run adb %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// deadlineWriter sets a write deadline before every write.
+type deadlineWriter struct {
+	net.Conn
+}
+
+func (w *deadlineWriter) Write(p []byte) (int, error) {
+	if err := w.SetWriteDeadline(time.Now().Add(adbWriteTimeout)); err != nil {
+		return 0, err
+	}
+	return w.Conn.Write(p)
+}
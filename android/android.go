@@ -0,0 +1,146 @@
+// Package android forwards glass events to a companion app on an Android phone, for rigs where the glass hangs off a
+// Linux SBC and a phone tethered over USB consumes the tracking. Two transports reach the phone:
+//
+//   - ADB, see NewADBBridge: `adb forward` connects a local TCP port to a socket the app listens on, e.g. a
+//     LocalServerSocket named xreal-events. It needs USB debugging enabled on the phone.
+//   - AOA, see NewAOABridge: the Android Open Accessory protocol switches the phone into accessory mode and the app
+//     reads the events from the UsbAccessory. It needs no developer options, but the app must declare the accessory
+//     in its manifest, e.g. <usb-accessory manufacturer="xreal-xr-go" model="event-bridge" version="1" />.
+//
+// Protocol: every connection, or accessory session, starts with the ASCII bytes of STREAM_MAGIC, followed by events of
+// eventpb/events.proto, each prefixed by its length as varint, see eventpb.WriteDelimited. In Java or Kotlin they are
+// read with Event.parseDelimitedFrom after skipping STREAM_MAGIC. The host only writes, it never reads from the phone.
+// Events sent while the app is not connected are dropped, and a connection that fails is dropped and opened again, so
+// the app may come and go.
+package android
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"xreal-light-xr-go/eventpb"
+)
+
+// STREAM_MAGIC starts every stream, its last character is bumped on changes of the framing. Changes of the events
+// are told by eventpb.SCHEMA_VERSION instead.
+const STREAM_MAGIC = "XREALEV1"
+
+// RECONNECT_INTERVAL is how often the app is tried again while it is not connected.
+const RECONNECT_INTERVAL = time.Second
+
+// ErrNotConnected is returned by Bridge.Send when the event was dropped as the app is not connected.
+var ErrNotConnected = errors.New("android companion not connected")
+
+// Bridge sends events to the companion app, connecting to it in the background when needed. It is safe for
+// concurrent use.
+type Bridge struct {
+	// mutex for thread safety
+	mutex sync.Mutex
+	// waitgroup tracks the goroutine dialing the app, so Close waits for it
+	waitgroup sync.WaitGroup
+	// dial connects to the app
+	dial func() (io.WriteCloser, error)
+	// release undoes the setup of the transport on Close, e.g. removes the adb forward, nil if there is none
+	release func() error
+
+	// conn is nil while the app is not connected
+	conn     io.WriteCloser
+	lastDial time.Time
+	dialing  bool
+	closed   bool
+}
+
+// Send sends event to the app. While it is not connected, the event is dropped with ErrNotConnected, which is expected
+// while the app is not running, and the app is dialed in the background at most every RECONNECT_INTERVAL, so Send
+// never waits for it. Send fails with other errors if event is invalid.
+func (b *Bridge) Send(event *eventpb.Event) error {
+	// encoded first, so an invalid event is not mistaken for a broken connection
+	var message bytes.Buffer
+	if err := eventpb.WriteDelimited(&message, event); err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return fmt.Errorf("%w: bridge closed", ErrNotConnected)
+	}
+
+	if b.conn == nil {
+		if !b.dialing && time.Since(b.lastDial) >= RECONNECT_INTERVAL {
+			b.dialing = true
+			b.lastDial = time.Now()
+			b.waitgroup.Add(1)
+			go b.connect()
+		}
+		return ErrNotConnected
+	}
+
+	if _, err := b.conn.Write(message.Bytes()); err != nil {
+		// a partially written event breaks the framing, so the stream starts over
+		b.conn.Close()
+		b.conn = nil
+		slog.Warn(fmt.Sprintf("android companion disconnected: %v", err))
+		return fmt.Errorf("%w: %w", ErrNotConnected, err)
+	}
+	return nil
+}
+
+// connect dials the app and starts the stream, without holding the mutex so events are dropped rather than blocked
+// meanwhile.
+func (b *Bridge) connect() {
+	defer b.waitgroup.Done()
+
+	conn, err := b.dial()
+	if err == nil {
+		if _, err = io.WriteString(conn, STREAM_MAGIC); err != nil {
+			conn.Close()
+			err = fmt.Errorf("failed to start stream: %w", err)
+		}
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.dialing = false
+	if err != nil {
+		slog.Debug(fmt.Sprintf("android companion not connected: %v", err))
+		return
+	}
+	if b.closed {
+		conn.Close()
+		return
+	}
+	slog.Info("android companion connected")
+	b.conn = conn
+}
+
+// Close disconnects the app and undoes the setup of the transport.
+func (b *Bridge) Close() error {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return nil
+	}
+	b.closed = true
+
+	var errs []error
+	if b.conn != nil {
+		errs = append(errs, b.conn.Close())
+		b.conn = nil
+	}
+	b.mutex.Unlock()
+
+	// a connection dialed meanwhile is closed by connect, and the transport must outlive the dial
+	b.waitgroup.Wait()
+	if b.release != nil {
+		errs = append(errs, b.release())
+	}
+	return errors.Join(errs...)
+}
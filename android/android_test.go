@@ -0,0 +1,115 @@
+package android
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"xreal-light-xr-go/eventpb"
+)
+
+func TestADBBridge(t *testing.T) {
+	// the phone is played by a listener, and adb by a script logging its arguments
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	dir := t.TempDir()
+	log := filepath.Join(dir, "adb.log")
+	adb := filepath.Join(dir, "adb")
+	if err := os.WriteFile(adb, []byte("#!/bin/sh\necho \"$@\" >> "+log+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	bridge, err := NewADBBridge(ADBOptions{Serial: "phone", LocalPort: listener.Addr().(*net.TCPAddr).Port, ADBPath: adb})
+	if err != nil {
+		t.Fatalf("NewADBBridge() failed: %v", err)
+	}
+
+	// the app is dialed in the background, so events are dropped until it is connected
+	if err := bridge.Send(eventpb.NewAmbientLightEvent(time.Now(), 1)); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("Send() before connecting = %v, want ErrNotConnected", err)
+	}
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		err := bridge.Send(eventpb.NewAmbientLightEvent(time.Now(), 42))
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrNotConnected) || time.Now().After(deadline) {
+			t.Fatalf("Send() failed: %v", err)
+		}
+	}
+
+	reader := bufio.NewReader(conn)
+	magic := make([]byte, len(STREAM_MAGIC))
+	if _, err := io.ReadFull(reader, magic); err != nil || string(magic) != STREAM_MAGIC {
+		t.Fatalf("stream starts with %q, %v, want %s", magic, err, STREAM_MAGIC)
+	}
+	event, err := eventpb.ReadDelimited(reader)
+	if err != nil || event.AmbientLight == nil || *event.AmbientLight != 42 {
+		t.Fatalf("ReadDelimited() = %+v, %v, want ambient light 42", event, err)
+	}
+
+	if err := bridge.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if err := bridge.Send(eventpb.NewAmbientLightEvent(time.Now(), 42)); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("Send() after Close() = %v, want ErrNotConnected", err)
+	}
+
+	calls, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := strings.TrimPrefix(listener.Addr().String(), "127.0.0.1:")
+	want := "-s phone forward tcp:" + port + " " + DEFAULT_ADB_REMOTE + "\n-s phone forward --remove tcp:" + port + "\n"
+	if string(calls) != want {
+		t.Errorf("adb calls = %q, want %q", calls, want)
+	}
+}
+
+func TestBridgeDropsEventsWhileDisconnected(t *testing.T) {
+	var dials atomic.Int32
+	// the dial hangs until the test ends, which Send must not wait for
+	release := make(chan struct{})
+	bridge := &Bridge{dial: func() (io.WriteCloser, error) {
+		dials.Add(1)
+		<-release
+		return nil, errors.New("app not running")
+	}}
+
+	for i := 0; i < 3; i++ {
+		done := make(chan error, 1)
+		go func() { done <- bridge.Send(eventpb.NewAmbientLightEvent(time.Now(), 1)) }()
+		select {
+		case err := <-done:
+			if !errors.Is(err, ErrNotConnected) {
+				t.Errorf("Send() = %v, want ErrNotConnected", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Send() blocked on dialing")
+		}
+	}
+
+	close(release)
+	if err := bridge.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if count := dials.Load(); count != 1 {
+		t.Errorf("dialed %d times within RECONNECT_INTERVAL, want 1", count)
+	}
+}
@@ -0,0 +1,205 @@
+package android
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gotmc/libusb/v2"
+)
+
+// Accessory strings sent to the phone, the app declares the first three in its accessory filter.
+const (
+	AOA_MANUFACTURER = "xreal-xr-go"
+	AOA_MODEL        = "event-bridge"
+	AOA_VERSION      = "1"
+	AOA_DESCRIPTION  = "XREAL glass events"
+	// AOA_URI is offered by the phone when no app handles the accessory
+	AOA_URI = "https://github.com/HappyZ/xreal-xr-go"
+)
+
+// from the Android Open Accessory protocol
+const (
+	aoaVendorID = 0x18d1
+	// accessory mode, with adb, with audio, and with audio and adb; 0x2d02 and 0x2d03 are audio only
+	aoaProductAccessory         = 0x2d00
+	aoaProductAccessoryADB      = 0x2d01
+	aoaProductAccessoryAudio    = 0x2d04
+	aoaProductAccessoryAudioADB = 0x2d05
+
+	aoaRequestGetProtocol = 51
+	aoaRequestSendString  = 52
+	aoaRequestStart       = 53
+
+	// the accessory interface, with one bulk endpoint each way
+	aoaInterface = 0
+
+	aoaControlTimeoutMs  = 1000
+	aoaTransferTimeoutMs = 100
+)
+
+// AOAOptions selects the phone for NewAOABridge.
+type AOAOptions struct {
+	// VendorID and ProductID select the phone before it is in accessory mode, as listed by lsusb. They are required, as
+	// probing other USB devices sends them vendor requests they may not know, e.g. to the glasses.
+	VendorID  uint16
+	ProductID uint16
+}
+
+// NewAOABridge sends the events to the app over the Android Open Accessory protocol. Phones not in accessory mode yet
+// are switched when connecting, after which they reconnect to USB as an accessory, so the first events are dropped.
+func NewAOABridge(options AOAOptions) (*Bridge, error) {
+	if options.VendorID == 0 || options.ProductID == 0 {
+		return nil, fmt.Errorf("the vendor and product ID of the phone are required, got %04x:%04x", options.VendorID, options.ProductID)
+	}
+
+	ctx, err := libusb.NewContext()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize libusb: %w", err)
+	}
+	ctx.Close()
+
+	return &Bridge{
+		dial: func() (io.WriteCloser, error) {
+			return dialAccessory(options)
+		},
+	}, nil
+}
+
+// dialAccessory opens the phone in accessory mode, or switches a phone into it and fails, to be dialed again once it
+// reconnected.
+func dialAccessory(options AOAOptions) (io.WriteCloser, error) {
+	ctx, err := libusb.NewContext()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize libusb: %w", err)
+	}
+
+	devices, err := ctx.DeviceList()
+	if err != nil {
+		ctx.Close()
+		return nil, fmt.Errorf("failed to enumerate USB devices: %w", err)
+	}
+
+	for _, usbDevice := range devices {
+		descriptor, err := usbDevice.DeviceDescriptor()
+		if err != nil || descriptor.VendorID != aoaVendorID {
+			continue
+		}
+		switch descriptor.ProductID {
+		case aoaProductAccessory, aoaProductAccessoryADB, aoaProductAccessoryAudio, aoaProductAccessoryAudioADB:
+			conn, err := openAccessory(usbDevice)
+			if err != nil {
+				ctx.Close()
+				return nil, err
+			}
+			conn.ctx = ctx
+			return conn, nil
+		}
+	}
+
+	defer ctx.Close()
+	for _, usbDevice := range devices {
+		descriptor, err := usbDevice.DeviceDescriptor()
+		if err != nil {
+			continue
+		}
+		if descriptor.VendorID != options.VendorID || descriptor.ProductID != options.ProductID {
+			continue
+		}
+		if err := startAccessoryMode(usbDevice); err != nil {
+			return nil, fmt.Errorf("failed to switch USB device %04x:%04x to accessory mode: %w", descriptor.VendorID, descriptor.ProductID, err)
+		}
+		return nil, fmt.Errorf("switched USB device %04x:%04x to accessory mode, waiting for it to reconnect", descriptor.VendorID, descriptor.ProductID)
+	}
+	return nil, fmt.Errorf("no Android phone %04x:%04x found", options.VendorID, options.ProductID)
+}
+
+// startAccessoryMode identifies as an accessory to the phone and switches it to accessory mode.
+func startAccessoryMode(usbDevice *libusb.Device) error {
+	handle, err := usbDevice.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open: %w", err)
+	}
+	defer handle.Close()
+
+	version := make([]byte, 2)
+	if _, err := handle.ControlTransfer(0xc0, aoaRequestGetProtocol, 0, 0, version, len(version), aoaControlTimeoutMs); err != nil {
+		return fmt.Errorf("failed to get accessory protocol: %w", err)
+	}
+	if protocol := int(version[0]) | int(version[1])<<8; protocol < 1 {
+		return fmt.Errorf("accessory protocol %d unsupported", protocol)
+	}
+
+	for index, value := range []string{AOA_MANUFACTURER, AOA_MODEL, AOA_DESCRIPTION, AOA_VERSION, AOA_URI, ""} {
+		data := append([]byte(value), 0)
+		if _, err := handle.ControlTransfer(0x40, aoaRequestSendString, 0, uint16(index), data, len(data), aoaControlTimeoutMs); err != nil {
+			return fmt.Errorf("failed to send accessory string %d: %w", index, err)
+		}
+	}
+
+	// libusb takes no data for this request, but the binding needs a buffer
+	if _, err := handle.ControlTransfer(0x40, aoaRequestStart, 0, 0, make([]byte, 1), 0, aoaControlTimeoutMs); err != nil {
+		return fmt.Errorf("failed to start accessory mode: %w", err)
+	}
+	return nil
+}
+
+// accessoryConn writes to the bulk out endpoint of a phone in accessory mode.
+type accessoryConn struct {
+	ctx      *libusb.Context
+	handle   *libusb.DeviceHandle
+	endpoint *libusb.EndpointDescriptor
+}
+
+func openAccessory(usbDevice *libusb.Device) (*accessoryConn, error) {
+	config, err := usbDevice.ActiveConfigDescriptor()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accessory configuration: %w", err)
+	}
+	var endpoint *libusb.EndpointDescriptor
+	for _, supported := range config.SupportedInterfaces {
+		for _, descriptor := range supported.InterfaceDescriptors {
+			if descriptor.InterfaceNumber != aoaInterface {
+				continue
+			}
+			for _, candidate := range descriptor.EndpointDescriptors {
+				// bulk, host to device
+				if byte(candidate.Attributes)&0x03 == 0x02 && byte(candidate.EndpointAddress)&0x80 == 0 {
+					endpoint = candidate
+				}
+			}
+		}
+	}
+	if endpoint == nil {
+		return nil, fmt.Errorf("accessory has no bulk out endpoint")
+	}
+
+	handle, err := usbDevice.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open accessory: %w", err)
+	}
+	if err := handle.ClaimInterface(aoaInterface); err != nil {
+		handle.Close()
+		return nil, fmt.Errorf("failed to claim accessory interface: %w", err)
+	}
+	return &accessoryConn{handle: handle, endpoint: endpoint}, nil
+}
+
+func (c *accessoryConn) Write(p []byte) (int, error) {
+	count, err := c.handle.BulkTransfer(c.endpoint.EndpointAddress, p, len(p), aoaTransferTimeoutMs)
+	if err != nil {
+		return count, fmt.Errorf("failed to write to accessory: %w", err)
+	}
+	if count < len(p) {
+		return count, io.ErrShortWrite
+	}
+	return count, nil
+}
+
+func (c *accessoryConn) Close() error {
+	err := errors.Join(c.handle.ReleaseInterface(aoaInterface), c.handle.Close())
+	if c.ctx != nil {
+		c.ctx.Close()
+	}
+	return err
+}
@@ -0,0 +1,185 @@
+// Package auth authenticates clients of network frontends and checks the capabilities they are granted,
+// so exposing the glass on a LAN doesn't let anyone watch its cameras or sensors.
+//
+// Clients are identified by a bearer token, or by the common name of a verified client certificate when served
+// with mutual TLS (see ServerTLSConfig). Identities and their scopes are listed in a file, one per line:
+//
+//	# identity                    scopes
+//	3f9c2d0e8a7b                  read-sensors
+//	cn:desktop-applet             read-sensors,read-metrics
+package auth
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Scope is a capability granted to a client.
+type Scope string
+
+const (
+	// SCOPE_READ_SENSORS allows receiving sensor and orientation data
+	SCOPE_READ_SENSORS Scope = "read-sensors"
+	// SCOPE_READ_METRICS allows receiving the command statistics
+	SCOPE_READ_METRICS Scope = "read-metrics"
+)
+
+// commonNamePrefix marks an identity as the common name of a client certificate instead of a token.
+const commonNamePrefix = "cn:"
+
+var supportedScopes = map[Scope]struct{}{
+	SCOPE_READ_SENSORS: {},
+	SCOPE_READ_METRICS: {},
+}
+
+var (
+	// ErrUnauthenticated is returned when the client presents no known token or certificate
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is returned when the client is known but not granted the scope
+	ErrForbidden = errors.New("forbidden")
+)
+
+type identity struct {
+	name   string
+	scopes map[Scope]struct{}
+}
+
+// Authorizer checks the scopes granted to clients.
+type Authorizer struct {
+	tokens      []identity
+	commonNames map[string]identity
+}
+
+// LoadAuthorizer reads identities and their scopes from the file at path, see the package doc for the format.
+func LoadAuthorizer(path string) (*Authorizer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open auth file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	a := &Authorizer{commonNames: make(map[string]identity)}
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid auth file %s line %d: want `identity scope[,scope...]`", path, lineNumber)
+		}
+		id := identity{name: fields[0], scopes: make(map[Scope]struct{})}
+		for _, scope := range strings.Split(fields[1], ",") {
+			if _, ok := supportedScopes[Scope(scope)]; !ok {
+				return nil, fmt.Errorf("invalid auth file %s line %d: unknown scope %s", path, lineNumber, scope)
+			}
+			id.scopes[Scope(scope)] = struct{}{}
+		}
+
+		if commonName, ok := strings.CutPrefix(id.name, commonNamePrefix); ok {
+			a.commonNames[commonName] = id
+		} else {
+			a.tokens = append(a.tokens, id)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read auth file %s: %w", path, err)
+	}
+	return a, nil
+}
+
+// AuthorizeToken checks the token is granted the scope.
+func (a *Authorizer) AuthorizeToken(token string, scope Scope) error {
+	// compare against every token in constant time, so the response time doesn't tell how close a guess is
+	var found *identity
+	for i := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(a.tokens[i].name), []byte(token)) == 1 {
+			found = &a.tokens[i]
+		}
+	}
+	if token == "" || found == nil {
+		return ErrUnauthenticated
+	}
+	return checkScope(*found, scope)
+}
+
+// AuthorizeCertificate checks the verified client certificate is granted the scope.
+func (a *Authorizer) AuthorizeCertificate(cert *x509.Certificate, scope Scope) error {
+	id, ok := a.commonNames[cert.Subject.CommonName]
+	if !ok {
+		return ErrUnauthenticated
+	}
+	return checkScope(id, scope)
+}
+
+// AuthorizeRequest checks the client of the HTTP request is granted the scope, by its verified client certificate
+// if any, or by the `Authorization: Bearer <token>` header.
+func (a *Authorizer) AuthorizeRequest(r *http.Request, scope Scope) error {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return a.AuthorizeCertificate(r.TLS.VerifiedChains[0][0], scope)
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ErrUnauthenticated
+	}
+	return a.AuthorizeToken(token, scope)
+}
+
+// Require wraps the handler so it is only served to clients granted the scope.
+func (a *Authorizer) Require(scope Scope, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch err := a.AuthorizeRequest(r, scope); {
+		case errors.Is(err, ErrUnauthenticated):
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+func checkScope(id identity, scope Scope) error {
+	if _, ok := id.scopes[scope]; !ok {
+		return fmt.Errorf("%w: missing scope %s", ErrForbidden, scope)
+	}
+	return nil
+}
+
+// ServerTLSConfig loads the server certificate, and if clientCAPath is not empty, requires clients to present
+// a certificate signed by that CA (mutual TLS).
+func ServerTLSConfig(certPath, keyPath, clientCAPath string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAPath == "" {
+		return config, nil
+	}
+
+	pem, err := os.ReadFile(clientCAPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA %s: %w", clientCAPath, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in client CA %s", clientCAPath)
+	}
+	config.ClientCAs = pool
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	return config, nil
+}
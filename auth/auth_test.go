@@ -0,0 +1,77 @@
+package auth_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"xreal-light-xr-go/auth"
+)
+
+func loadTestAuthorizer(t *testing.T) *auth.Authorizer {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "auth")
+	content := "# test clients\nsensors-token read-sensors\nadmin-token read-sensors,read-metrics\ncn:applet read-metrics\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	authorizer, err := auth.LoadAuthorizer(path)
+	if err != nil {
+		t.Fatalf("LoadAuthorizer() failed: %v", err)
+	}
+	return authorizer
+}
+
+func TestAuthorizeToken(t *testing.T) {
+	authorizer := loadTestAuthorizer(t)
+
+	testCases := []struct {
+		token    string
+		scope    auth.Scope
+		expected error
+	}{
+		{"sensors-token", auth.SCOPE_READ_SENSORS, nil},
+		{"sensors-token", auth.SCOPE_READ_METRICS, auth.ErrForbidden},
+		{"admin-token", auth.SCOPE_READ_METRICS, nil},
+		{"unknown-token", auth.SCOPE_READ_SENSORS, auth.ErrUnauthenticated},
+		{"", auth.SCOPE_READ_SENSORS, auth.ErrUnauthenticated},
+		// certificate common names are not tokens
+		{"cn:applet", auth.SCOPE_READ_METRICS, auth.ErrUnauthenticated},
+	}
+
+	for _, tc := range testCases {
+		if err := authorizer.AuthorizeToken(tc.token, tc.scope); !errors.Is(err, tc.expected) {
+			t.Errorf("AuthorizeToken(%s, %s) = %v; expected %v", tc.token, tc.scope, err, tc.expected)
+		}
+	}
+}
+
+func TestRequire(t *testing.T) {
+	authorizer := loadTestAuthorizer(t)
+	handler := authorizer.Require(auth.SCOPE_READ_METRICS, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	testCases := []struct {
+		header   string
+		expected int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer sensors-token", http.StatusForbidden},
+		{"Bearer admin-token", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.header != "" {
+			request.Header.Set("Authorization", tc.header)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != tc.expected {
+			t.Errorf("got status %d for %q; expected %d", recorder.Code, tc.header, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"xreal-light-xr-go/constant"
+	"xreal-light-xr-go/controller"
+	"xreal-light-xr-go/dbus"
+	"xreal-light-xr-go/hooks"
+	"xreal-light-xr-go/internal/device"
+	"xreal-light-xr-go/messages"
+)
+
+const (
+	AUTO_CONNECT_LIGHT = "light"
+	AUTO_CONNECT_AIR   = "air"
+	AUTO_CONNECT_ANY   = "any"
+
+	// autoConnectRetryInterval is how long to wait before connecting an attached glass again after it failed
+	autoConnectRetryInterval = 10 * time.Second
+)
+
+// autoConnectFlag is the -auto flag, which may be given without a value for AUTO_CONNECT_ANY.
+type autoConnectFlag struct {
+	model *string
+}
+
+func (f autoConnectFlag) String() string {
+	if f.model == nil {
+		return ""
+	}
+	return *f.model
+}
+
+func (f autoConnectFlag) Set(value string) error {
+	switch strings.ToLower(value) {
+	case "true", AUTO_CONNECT_ANY:
+		*f.model = AUTO_CONNECT_ANY
+	case "false", "":
+		*f.model = ""
+	case AUTO_CONNECT_LIGHT, AUTO_CONNECT_AIR:
+		*f.model = strings.ToLower(value)
+	default:
+		return fmt.Errorf("invalid model %s: want %s, %s or %s", value, AUTO_CONNECT_LIGHT, AUTO_CONNECT_AIR, AUTO_CONNECT_ANY)
+	}
+	return nil
+}
+
+func (f autoConnectFlag) IsBoolFlag() bool {
+	return true
+}
+
+// matchesAutoConnect tells if the glass is of the model given to -auto.
+func matchesAutoConnect(model string, info *device.GlassInfo) bool {
+	switch model {
+	case AUTO_CONNECT_ANY:
+		return true
+	case AUTO_CONNECT_LIGHT:
+		return info.Model == constant.XREAL_LIGHT
+	case AUTO_CONNECT_AIR:
+		return info.Model != constant.XREAL_LIGHT && info.Model != constant.XREAL_ONE && info.Model != constant.XREAL_ONE_PRO
+	default:
+		return false
+	}
+}
+
+// connectGlass connects the attached glass.
+func connectGlass(info *device.GlassInfo) device.Device {
+	glassDevice, err := device.NewDevice(info)
+	if err == nil {
+		err = glassDevice.Connect()
+	}
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to connect %s: %v", info.Model, err))
+		return nil
+	}
+	return glassDevice
+}
+
+// glassSession holds the connected glass, shared by the prompt and the auto connect watcher.
+type glassSession struct {
+	config     constant.Config
+	auditLog   *controller.AuditLog
+	stateStore *controller.StateStore
+	// hooks run on the events of the glass, nil if none
+	hooks *hooks.Engine
+	// waitgroup tracks the autoConnect goroutine, so close does not race with it connecting a glass
+	waitgroup sync.WaitGroup
+
+	// mutex for thread safety
+	mutex       sync.Mutex
+	device      device.Device
+	dbusService *dbus.Service
+	// stopSchedule stops the brightness schedule, nil if not running
+	stopSchedule chan struct{}
+}
+
+func (s *glassSession) current() device.Device {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.device
+}
+
+// use makes d the glass of the session, filtering its events, restoring its state and starting the stream watchdog,
+// frame pipeline, capture policy, brightness schedule, hooks and D-Bus service for it if enabled. d may be nil if the
+// glass failed to connect or was detached. It returns the glass as used by the session, to be passed to drop.
+func (s *glassSession) use(d device.Device) device.Device {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if d != nil {
+		// filtered before the hooks and D-Bus service set their handlers, so they get the filtered events too
+		d = device.FilterEvents(d, eventFilters(s.config))
+	}
+	if d != nil && s.hooks != nil {
+		d = s.hooks.Wrap(d)
+		s.hooks.Fire(hooks.EVENT_CONNECTED, "")
+	}
+	s.device = d
+	restoreState(s.config, d, s.auditLog, s.stateStore)
+	startStreamWatchdog(s.config, d)
+	startFramePipeline(s.config, d)
+	startCapturePolicy(s.config, d)
+	s.stopSchedule = restartBrightnessSchedule(s.config, s.stopSchedule, d)
+	s.dbusService = restartDBusService(s.config, s.dbusService, d, s.auditLog, s.stateStore)
+	return d
+}
+
+// drop disconnects d and leaves the session without a glass, unless another glass was connected meanwhile.
+func (s *glassSession) drop(d device.Device) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.device != d {
+		return
+	}
+	s.stopSchedule = restartBrightnessSchedule(s.config, s.stopSchedule, nil)
+	d.Disconnect()
+	s.device = nil
+	s.dbusService = restartDBusService(s.config, s.dbusService, nil, s.auditLog, s.stateStore)
+}
+
+// close waits for autoConnect to stop, so its stop must be closed first, then disconnects the glass, stops the
+// brightness schedule and D-Bus service and waits for the hooks running.
+func (s *glassSession) close() {
+	s.waitgroup.Wait()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.stopSchedule = restartBrightnessSchedule(s.config, s.stopSchedule, nil)
+	if s.device != nil {
+		s.device.Disconnect()
+		s.device = nil
+	}
+	if s.dbusService != nil {
+		s.dbusService.Stop()
+		s.dbusService = nil
+	}
+	if s.hooks != nil {
+		s.hooks.Wait()
+	}
+}
+
+// startAutoConnect runs autoConnect in the background, close waits for it.
+func (s *glassSession) startAutoConnect(model string, stop <-chan struct{}) {
+	s.waitgroup.Add(1)
+	go func() {
+		defer s.waitgroup.Done()
+		s.autoConnect(model, stop)
+	}()
+}
+
+// autoConnect connects the first attached glass of the model as soon as it is attached, and again after it was
+// unplugged, until stop is closed. Glasses connected from the prompt are left alone.
+func (s *glassSession) autoConnect(model string, stop <-chan struct{}) {
+	events := device.WatchGlasses(device.DEFAULT_HOTPLUG_INTERVAL, stop)
+	retry := time.NewTicker(autoConnectRetryInterval)
+	defer retry.Stop()
+
+	// attached are the glasses of the model in the order they were attached
+	var attached []*device.GlassInfo
+	// connected is the glass connected here, nil if there is none
+	var connected *device.GlassInfo
+	var connectedDevice device.Device
+
+	connect := func() {
+		if connected != nil || s.current() != nil {
+			return
+		}
+		for _, info := range attached {
+			if d := connectGlass(info); d != nil {
+				slog.Info(messages.Text(messages.AUTO_CONNECTED, info.String()))
+				connected = info
+				connectedDevice = s.use(d)
+				return
+			}
+		}
+		if len(attached) > 0 {
+			slog.Info(fmt.Sprintf("retry in %v...", autoConnectRetryInterval))
+		}
+	}
+
+	slog.Info(messages.Text(messages.WAITING_FOR_GLASS, model))
+	for {
+		select {
+		case <-stop:
+			return
+		case <-retry.C:
+			connect()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if !matchesAutoConnect(model, event.Glass) {
+				continue
+			}
+			slog.Debug(fmt.Sprintf("hotplug: %s", event.String()))
+
+			switch event.Type {
+			case device.HOTPLUG_ATTACHED:
+				attached = append(attached, event.Glass)
+			case device.HOTPLUG_DETACHED:
+				for i, info := range attached {
+					if info.MCUPath == event.Glass.MCUPath {
+						attached = append(attached[:i], attached[i+1:]...)
+						break
+					}
+				}
+				if connected != nil && connected.MCUPath == event.Glass.MCUPath {
+					slog.Warn(messages.Text(messages.GLASS_UNPLUGGED, connected.Model))
+					s.drop(connectedDevice)
+					connected, connectedDevice = nil, nil
+				}
+			}
+			connect()
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+const defaultBenchDuration = 10 * time.Second
+
+// benchResult is a row of the summary table.
+type benchResult struct {
+	name  string
+	value string
+}
+
+// handleBenchCommand measures the IMU sample rate, command round-trip latency or camera frame rate over a while and
+// prints a summary table, e.g. to validate a setup. Use 'bench <imu|commands|camera> <optional:seconds>'.
+func handleBenchCommand(d device.Device, input string) {
+	parts := strings.Fields(input)
+	if len(parts) < 2 {
+		slog.Error("invalid command format: use 'bench <imu|commands|camera> <optional:seconds>'")
+		return
+	}
+	duration := defaultBenchDuration
+	if len(parts) > 2 {
+		seconds, err := time.ParseDuration(parts[2] + "s")
+		if err != nil || seconds <= 0 {
+			slog.Error(fmt.Sprintf("invalid duration %s, use 'bench <imu|commands|camera> <optional:seconds>'", parts[2]))
+			return
+		}
+		duration = seconds
+	}
+
+	var results []benchResult
+	var err error
+	switch parts[1] {
+	case "imu":
+		results, err = benchIMU(d, duration)
+	case "commands":
+		results, err = benchCommands(d, duration)
+	case "camera":
+		results, err = benchCamera(d, duration)
+	default:
+		slog.Error(fmt.Sprintf("unknown bench %s, use 'bench <imu|commands|camera> <optional:seconds>'", parts[1]))
+		return
+	}
+	if err != nil {
+		slog.Error(fmt.Sprintf("bench %s failed: %v", parts[1], err))
+		return
+	}
+
+	var table strings.Builder
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%s\n", result.name, result.value)
+	}
+	w.Flush()
+	slog.Info(fmt.Sprintf("bench %s over %v:\n%s", parts[1], duration, table.String()))
+}
+
+// benchIMU counts the IMU events delivered and their intervals as received by the host and as timestamped by the glass.
+func benchIMU(d device.Device, duration time.Duration) ([]benchResult, error) {
+	var mutex sync.Mutex
+	var arrivals []time.Time
+	var firstTimeSinceBoot, lastTimeSinceBoot uint64
+	restore := borrowIMU(d, func(imu *device.IMUEvent) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if len(arrivals) == 0 {
+			firstTimeSinceBoot = imu.TimeSinceBoot
+		}
+		lastTimeSinceBoot = imu.TimeSinceBoot
+		arrivals = append(arrivals, time.Now())
+	})
+
+	if err := d.EnableIMU(true); err != nil {
+		restore()
+		return nil, fmt.Errorf("failed to enable IMU stream: %w", err)
+	}
+	slog.Info(fmt.Sprintf("measuring the IMU stream for %v", duration))
+	time.Sleep(duration)
+	restore()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(arrivals) < 2 {
+		return nil, fmt.Errorf("received %d IMU events, is the glass streaming?", len(arrivals))
+	}
+
+	intervals := make([]time.Duration, 0, len(arrivals)-1)
+	for i := 1; i < len(arrivals); i++ {
+		intervals = append(intervals, arrivals[i].Sub(arrivals[i-1]))
+	}
+	received := arrivals[len(arrivals)-1].Sub(arrivals[0])
+	results := []benchResult{
+		{"samples", fmt.Sprintf("%d", len(arrivals))},
+		{"host rate", fmt.Sprintf("%.1f Hz", float64(len(arrivals)-1)/received.Seconds())},
+	}
+	if lastTimeSinceBoot > firstTimeSinceBoot {
+		deviceSeconds := float64(lastTimeSinceBoot-firstTimeSinceBoot) / 1000
+		results = append(results, benchResult{"glass rate", fmt.Sprintf("%.1f Hz", float64(len(arrivals)-1)/deviceSeconds)})
+	}
+	return append(results, durationResults("interval", intervals)...), nil
+}
+
+// benchCommands sends read-only brightness commands back to back and measures their round trips.
+func benchCommands(d device.Device, duration time.Duration) ([]benchResult, error) {
+	slog.Info(fmt.Sprintf("measuring command round trips for %v", duration))
+	var latencies []time.Duration
+	failures := 0
+	var lastErr error
+	start := time.Now()
+	for time.Since(start) < duration {
+		sent := time.Now()
+		if _, err := d.GetBrightnessLevel(); err != nil {
+			if failures == 0 && len(latencies) == 0 {
+				// fail fast instead of retrying e.g. an unsupported command for the whole duration
+				return nil, fmt.Errorf("first command failed: %w", err)
+			}
+			failures++
+			lastErr = err
+			continue
+		}
+		latencies = append(latencies, time.Since(sent))
+	}
+	if len(latencies) == 0 {
+		return nil, fmt.Errorf("all %d commands failed: %w", failures, lastErr)
+	}
+
+	results := []benchResult{
+		{"commands", fmt.Sprintf("%d", len(latencies))},
+		{"failures", fmt.Sprintf("%d", failures)},
+		{"rate", fmt.Sprintf("%.1f/s", float64(len(latencies))/duration.Seconds())},
+	}
+	return append(results, durationResults("round trip", latencies)...), nil
+}
+
+// benchCamera captures SLAM frames back to back and measures the achieved frame rate.
+func benchCamera(d device.Device, duration time.Duration) ([]benchResult, error) {
+	slog.Info(fmt.Sprintf("measuring SLAM camera frames for %v", duration))
+	var captures []time.Duration
+	failures := 0
+	var lastErr error
+	start := time.Now()
+	for time.Since(start) < duration {
+		requested := time.Now()
+		if _, err := d.GetSLAMFrameRaw(); err != nil {
+			if failures == 0 && len(captures) == 0 {
+				// fail fast instead of retrying e.g. an unsupported command for the whole duration
+				return nil, fmt.Errorf("first capture failed: %w", err)
+			}
+			failures++
+			lastErr = err
+			continue
+		}
+		captures = append(captures, time.Since(requested))
+	}
+	elapsed := time.Since(start)
+	if len(captures) == 0 {
+		return nil, fmt.Errorf("all %d captures failed: %w", failures, lastErr)
+	}
+
+	results := []benchResult{
+		{"frames", fmt.Sprintf("%d", len(captures))},
+		{"failures", fmt.Sprintf("%d", failures)},
+		{"frame rate", fmt.Sprintf("%.1f fps", float64(len(captures))/elapsed.Seconds())},
+	}
+	return append(results, durationResults("capture", captures)...), nil
+}
+
+// durationResults summarizes the distribution of values, which must not be empty.
+func durationResults(name string, values []time.Duration) []benchResult {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	var total time.Duration
+	for _, value := range sorted {
+		total += value
+	}
+	return []benchResult{
+		{name + " min", sorted[0].String()},
+		{name + " mean", (total / time.Duration(len(sorted))).String()},
+		{name + " p50", percentile(sorted, 0.5).String()},
+		{name + " p99", percentile(sorted, 0.99).String()},
+		{name + " max", sorted[len(sorted)-1].String()},
+	}
+}
+
+// percentile returns the nearest rank percentile p in [0, 1] of sorted values.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
@@ -0,0 +1,198 @@
+// Package camstream serves the SLAM cameras of a glass as an MJPEG stream over HTTP, which browsers and players like
+// VLC show live, e.g. to check how the glass is mounted or exposed. Frames are only captured while a client watches.
+package camstream
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+const (
+	// boundary separates the JPEG frames of the multipart stream
+	boundary = "frame"
+
+	// EYE_LEFT, EYE_RIGHT and EYE_BOTH select the SLAM camera images served, both side by side by default
+	EYE_LEFT  = "left"
+	EYE_RIGHT = "right"
+	EYE_BOTH  = "both"
+
+	// retryInterval is how long to wait before capturing again while no glass is connected or capturing fails
+	retryInterval = 1 * time.Second
+)
+
+// FrameSource captures SLAM camera frames, e.g. a device.Device.
+type FrameSource interface {
+	GetSLAMFrameRaw() (*device.SLAMFrame, error)
+}
+
+// Server serves the SLAM cameras at /slam, ?eye=left or ?eye=right for a single camera, and a page showing the
+// stream at /.
+type Server struct {
+	// source returns the glass to capture from, nil while none is connected
+	source  func() FrameSource
+	quality int
+
+	// mutex for thread safety
+	mutex sync.Mutex
+	// clients receive the latest frame, dropping frames they are too slow to encode
+	clients   map[chan *device.SLAMFrame]struct{}
+	capturing bool
+}
+
+// NewServer creates a server capturing from the glass returned by source, encoding JPEG images of the quality, see
+// device.DEFAULT_JPEG_QUALITY.
+func NewServer(source func() FrameSource, quality int) *Server {
+	return &Server{source: source, quality: quality, clients: map[chan *device.SLAMFrame]struct{}{}}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<!DOCTYPE html><html><head><title>SLAM cameras</title></head><body style="margin:0;background:#000"><img src="/slam" style="width:100%"></body></html>`)
+	case "/slam":
+		s.serveStream(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request) {
+	eye := r.URL.Query().Get("eye")
+	if eye == "" {
+		eye = EYE_BOTH
+	}
+	if eye != EYE_LEFT && eye != EYE_RIGHT && eye != EYE_BOTH {
+		http.Error(w, fmt.Sprintf("invalid eye %s: want %s, %s or %s", eye, EYE_LEFT, EYE_RIGHT, EYE_BOTH), http.StatusBadRequest)
+		return
+	}
+
+	frames := s.subscribe()
+	defer s.unsubscribe(frames)
+
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+boundary)
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher, _ := w.(http.Flusher)
+
+	var buffer bytes.Buffer
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case frame := <-frames:
+			img := frameImage(frame, eye)
+			if img == nil {
+				continue
+			}
+			buffer.Reset()
+			if err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: s.quality}); err != nil {
+				slog.Debug(fmt.Sprintf("failed to encode SLAM frame: %v", err))
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n", boundary, buffer.Len()); err != nil {
+				return
+			}
+			if _, err := w.Write(append(buffer.Bytes(), '\r', '\n')); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// subscribe registers a client, starting to capture for the first one.
+func (s *Server) subscribe() chan *device.SLAMFrame {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	frames := make(chan *device.SLAMFrame, 1)
+	s.clients[frames] = struct{}{}
+	if !s.capturing {
+		s.capturing = true
+		go s.capture()
+	}
+	return frames
+}
+
+func (s *Server) unsubscribe(frames chan *device.SLAMFrame) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.clients, frames)
+}
+
+// capture shares every frame with all clients until the last one left, as the cameras are read one frame at a time.
+func (s *Server) capture() {
+	for {
+		s.mutex.Lock()
+		if len(s.clients) == 0 {
+			s.capturing = false
+			s.mutex.Unlock()
+			return
+		}
+		s.mutex.Unlock()
+
+		source := s.source()
+		if source == nil {
+			time.Sleep(retryInterval)
+			continue
+		}
+		frame, err := source.GetSLAMFrameRaw()
+		if err != nil {
+			slog.Debug(fmt.Sprintf("failed to capture SLAM frame for streaming: %v", err))
+			time.Sleep(retryInterval)
+			continue
+		}
+		s.broadcast(frame)
+	}
+}
+
+// broadcast replaces the frame a client did not take yet, so slow clients get the latest one.
+func (s *Server) broadcast(frame *device.SLAMFrame) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for frames := range s.clients {
+		select {
+		case <-frames:
+		default:
+		}
+		frames <- frame
+	}
+}
+
+// frameImage returns the image of the eye, both side by side for EYE_BOTH, nil if the frame is incomplete.
+func frameImage(frame *device.SLAMFrame, eye string) image.Image {
+	left, right := frame.Images()
+	switch eye {
+	case EYE_LEFT:
+		return left
+	case EYE_RIGHT:
+		return right
+	}
+	if left == nil || right == nil {
+		return nil
+	}
+
+	width, height := left.Bounds().Dx(), left.Bounds().Dy()
+	both := image.NewGray(image.Rect(0, 0, 2*width, height))
+	leftGray, leftOK := left.(*image.Gray)
+	rightGray, rightOK := right.(*image.Gray)
+	if !leftOK || !rightOK {
+		return nil
+	}
+	for y := 0; y < height; y++ {
+		copy(both.Pix[y*both.Stride:], leftGray.Pix[y*leftGray.Stride:y*leftGray.Stride+width])
+		copy(both.Pix[y*both.Stride+width:], rightGray.Pix[y*rightGray.Stride:y*rightGray.Stride+width])
+	}
+	return both
+}
@@ -0,0 +1,77 @@
+package camstream
+
+import (
+	"bufio"
+	"context"
+	"image/jpeg"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+type fakeSource struct{}
+
+func (fakeSource) GetSLAMFrameRaw() (*device.SLAMFrame, error) {
+	time.Sleep(10 * time.Millisecond)
+	left := make([]byte, 640*480)
+	right := make([]byte, 640*480)
+	for i := range right {
+		right[i] = 0xff
+	}
+	return &device.SLAMFrame{Left: left, Right: right, Timestamp: time.Now()}, nil
+}
+
+func TestServeStream(t *testing.T) {
+	server := httptest.NewServer(NewServer(func() FrameSource { return fakeSource{} }, device.DEFAULT_JPEG_QUALITY))
+	defer server.Close()
+
+	testCases := []struct {
+		eye   string
+		width int
+	}{
+		{eye: "", width: 1280},
+		{eye: EYE_LEFT, width: 640},
+	}
+	for _, tc := range testCases {
+		ctx, cancel := context.WithCancel(context.Background())
+		request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/slam?eye="+tc.eye, nil)
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("GET /slam?eye=%s failed: %v", tc.eye, err)
+		}
+
+		mediaType, params, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/x-mixed-replace" {
+			t.Fatalf("Content-Type = %s, want multipart/x-mixed-replace", response.Header.Get("Content-Type"))
+		}
+		part, err := multipart.NewReader(bufio.NewReader(response.Body), params["boundary"]).NextPart()
+		if err != nil {
+			t.Fatalf("eye %s: failed to read frame: %v", tc.eye, err)
+		}
+		img, err := jpeg.Decode(part)
+		if err != nil {
+			t.Fatalf("eye %s: failed to decode frame: %v", tc.eye, err)
+		}
+		if img.Bounds().Dx() != tc.width || img.Bounds().Dy() != 480 {
+			t.Errorf("eye %s: frame is %v, want %dx480", tc.eye, img.Bounds(), tc.width)
+		}
+
+		cancel()
+		io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+	}
+}
+
+func TestServeStreamRejectsInvalidEye(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewServer(func() FrameSource { return nil }, 75).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/slam?eye=middle", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("GET /slam?eye=middle = %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+// #include <stdlib.h>
+// #include "xreal.h"
+//
+// // C function pointers cannot be called from Go directly
+// static inline void xr_call_imu(xr_imu_callback callback, void *user_data, const xr_imu_sample *sample) {
+// 	callback(user_data, sample);
+// }
+//
+// static inline void xr_call_key(xr_key_callback callback, void *user_data, const char *key) {
+// 	callback(user_data, key);
+// }
+import "C"
+
+import (
+	"unsafe"
+
+	"xreal-light-xr-go/pkg/xreal"
+)
+
+// imuHandler calls callback with every IMU sample.
+func imuHandler(callback C.xr_imu_callback, userData unsafe.Pointer) func(*xreal.IMUEvent) {
+	return func(imu *xreal.IMUEvent) {
+		sample := C.xr_imu_sample{time_since_boot_ms: C.uint64_t(imu.TimeSinceBoot)}
+		if imu.Gyroscope != nil {
+			sample.gyro_x, sample.gyro_y, sample.gyro_z = C.float(imu.Gyroscope.X), C.float(imu.Gyroscope.Y), C.float(imu.Gyroscope.Z)
+		}
+		if imu.Accelerometer != nil {
+			sample.accel_x, sample.accel_y, sample.accel_z = C.float(imu.Accelerometer.X), C.float(imu.Accelerometer.Y), C.float(imu.Accelerometer.Z)
+		}
+		C.xr_call_imu(callback, userData, &sample)
+	}
+}
+
+// keyHandler calls callback with every key pressed.
+func keyHandler(callback C.xr_key_callback, userData unsafe.Pointer) func(xreal.KeyEvent) {
+	return func(key xreal.KeyEvent) {
+		name := C.CString(key.String())
+		defer C.free(unsafe.Pointer(name))
+		C.xr_call_key(callback, userData, name)
+	}
+}
@@ -0,0 +1,242 @@
+// capi exports a C API of the driver, built as a shared library so C, C++, Python or Rust applications can use it
+// without the Go toolchain:
+//
+//	make capi
+//
+// builds build-bin/libxreal.so and its header libxreal.h, which includes xreal.h copied next to it. Glasses are
+// referred to by the handle xr_connect returns. Functions returning int return 0, or a handle, on success and -1 on
+// failure, see xr_last_error which keeps the message per thread. Functions are safe to call from any thread.
+//
+// Example:
+//
+//	int glass = xr_connect(NULL);
+//	if (glass < 0) {
+//		fprintf(stderr, "%s\n", xr_last_error());
+//	}
+//	xr_set_brightness(glass, "5");
+//	xr_subscribe_imu(glass, on_imu, NULL);
+package main
+
+// #include <stdlib.h>
+// #include <string.h>
+// #include "xreal.h"
+//
+// // xr_error is the message of the last failure of each thread, like errno, so threads do not free or overwrite
+// // what another one is reading
+// static _Thread_local char *xr_error;
+//
+// static void xr_set_error(char *message) {
+// 	free(xr_error);
+// 	xr_error = message;
+// }
+//
+// static const char *xr_get_error(void) {
+// 	return xr_error;
+// }
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"xreal-light-xr-go/pkg/xreal"
+)
+
+var (
+	// mutex for thread safety of the variables below
+	mutex      sync.Mutex
+	glasses    = map[C.int]xreal.Device{}
+	nextHandle = C.int(1)
+)
+
+// fail records err for xr_last_error and returns -1. Exported functions run on the thread of their C caller, so it
+// is recorded for that thread as long as fail is not called from another goroutine.
+func fail(err error) C.int {
+	C.xr_set_error(C.CString(err.Error()))
+	return -1
+}
+
+// result returns 0 if err is nil and fails with it otherwise.
+func result(err error) C.int {
+	if err != nil {
+		return fail(err)
+	}
+	return 0
+}
+
+func glass(handle C.int) (xreal.Device, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	d, ok := glasses[handle]
+	if !ok {
+		return nil, fmt.Errorf("invalid glass handle %d", handle)
+	}
+	return d, nil
+}
+
+// copyString copies value null terminated into buffer of size bytes.
+func copyString(value string, buffer *C.char, size C.int) C.int {
+	if buffer == nil || int(size) < len(value)+1 {
+		return fail(fmt.Errorf("buffer of %d bytes too small for %d bytes", size, len(value)+1))
+	}
+	cValue := C.CString(value)
+	defer C.free(unsafe.Pointer(cValue))
+	C.strncpy(buffer, cValue, C.size_t(size))
+	return 0
+}
+
+// xr_last_error returns the message of the last failure of the calling thread, NULL if none, valid until the next
+// failure of that thread. Failures of other threads do not change it.
+//
+//export xr_last_error
+func xr_last_error() *C.char {
+	return C.xr_get_error()
+}
+
+// xr_version returns the version of the driver, to be freed by the caller.
+//
+//export xr_version
+func xr_version() *C.char {
+	return C.CString(xreal.Version())
+}
+
+// xr_connect connects the XREAL Light of the given serial, or the first one if serial is NULL or empty, and returns
+// its handle.
+//
+//export xr_connect
+func xr_connect(serial *C.char) C.int {
+	var serialNumber *string
+	if serial != nil && *serial != 0 {
+		value := C.GoString(serial)
+		serialNumber = &value
+	}
+	d := xreal.NewLight(nil, serialNumber)
+	if err := d.Connect(); err != nil {
+		return fail(err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	handle := nextHandle
+	nextHandle++
+	glasses[handle] = d
+	return handle
+}
+
+// xr_disconnect disconnects the glass, its handle is invalid afterwards.
+//
+//export xr_disconnect
+func xr_disconnect(handle C.int) C.int {
+	d, err := glass(handle)
+	if err != nil {
+		return fail(err)
+	}
+	mutex.Lock()
+	delete(glasses, handle)
+	mutex.Unlock()
+	return result(d.Disconnect())
+}
+
+// xr_get_serial copies the serial number of the glass into buffer of size bytes.
+//
+//export xr_get_serial
+func xr_get_serial(handle C.int, buffer *C.char, size C.int) C.int {
+	d, err := glass(handle)
+	if err != nil {
+		return fail(err)
+	}
+	serial, err := d.GetSerial()
+	if err != nil {
+		return fail(err)
+	}
+	return copyString(serial, buffer, size)
+}
+
+// xr_get_brightness copies the brightness level of the display, e.g. "5", into buffer of size bytes.
+//
+//export xr_get_brightness
+func xr_get_brightness(handle C.int, buffer *C.char, size C.int) C.int {
+	d, err := glass(handle)
+	if err != nil {
+		return fail(err)
+	}
+	level, err := d.GetBrightnessLevel()
+	if err != nil {
+		return fail(err)
+	}
+	return copyString(level, buffer, size)
+}
+
+// xr_set_brightness sets the brightness level of the display, e.g. "5".
+//
+//export xr_set_brightness
+func xr_set_brightness(handle C.int, level *C.char) C.int {
+	d, err := glass(handle)
+	if err != nil {
+		return fail(err)
+	}
+	return result(d.SetBrightnessLevel(C.GoString(level)))
+}
+
+// xr_get_display_mode copies the display mode, e.g. "STEREO", into buffer of size bytes.
+//
+//export xr_get_display_mode
+func xr_get_display_mode(handle C.int, buffer *C.char, size C.int) C.int {
+	d, err := glass(handle)
+	if err != nil {
+		return fail(err)
+	}
+	mode, err := d.GetDisplayMode()
+	if err != nil {
+		return fail(err)
+	}
+	return copyString(string(mode), buffer, size)
+}
+
+// xr_set_display_mode sets the display mode: SAME_ON_BOTH, HALF_SBS, STEREO or HIGH_REFRESH_RATE.
+//
+//export xr_set_display_mode
+func xr_set_display_mode(handle C.int, mode *C.char) C.int {
+	d, err := glass(handle)
+	if err != nil {
+		return fail(err)
+	}
+	return result(d.SetDisplayMode(xreal.DisplayMode(C.GoString(mode))))
+}
+
+// xr_subscribe_imu enables the IMU and calls callback with every sample and user_data, or disables it if callback is
+// NULL.
+//
+//export xr_subscribe_imu
+func xr_subscribe_imu(handle C.int, callback C.xr_imu_callback, userData unsafe.Pointer) C.int {
+	d, err := glass(handle)
+	if err != nil {
+		return fail(err)
+	}
+	if callback == nil {
+		d.SetIMUEventHandler(func(imu *xreal.IMUEvent) {})
+		return result(d.EnableIMU(false))
+	}
+	d.SetIMUEventHandler(imuHandler(callback, userData))
+	return result(d.EnableIMU(true))
+}
+
+// xr_subscribe_keys calls callback with every key pressed on the glass and user_data, or stops if callback is NULL.
+//
+//export xr_subscribe_keys
+func xr_subscribe_keys(handle C.int, callback C.xr_key_callback, userData unsafe.Pointer) C.int {
+	d, err := glass(handle)
+	if err != nil {
+		return fail(err)
+	}
+	if callback == nil {
+		d.SetKeyEventHandler(func(key xreal.KeyEvent) {})
+		return 0
+	}
+	d.SetKeyEventHandler(keyHandler(callback, userData))
+	return 0
+}
+
+// main is required by -buildmode=c-shared, it is not run.
+func main() {}
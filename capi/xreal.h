@@ -0,0 +1,25 @@
+// Types of the C API of libxreal, see capi.go. The functions are declared in the libxreal.h generated next to the
+// library, which includes this header.
+#ifndef XREAL_H
+#define XREAL_H
+
+#include <stdint.h>
+
+// xr_imu_sample is an IMU reading, gyroscope in rad/s and accelerometer in m/s^2.
+typedef struct {
+	float gyro_x;
+	float gyro_y;
+	float gyro_z;
+	float accel_x;
+	float accel_y;
+	float accel_z;
+	uint64_t time_since_boot_ms;
+} xr_imu_sample;
+
+// xr_imu_callback receives every IMU sample on a thread of the driver, sample is only valid during the call.
+typedef void (*xr_imu_callback)(void *user_data, const xr_imu_sample *sample);
+
+// xr_key_callback receives the keys pressed on the glass, e.g. "UP", key is only valid during the call.
+typedef void (*xr_key_callback)(void *user_data, const char *key);
+
+#endif
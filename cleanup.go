@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+// exitOnSignal disconnects all glasses before exiting on SIGINT, SIGTERM or SIGHUP, as a process killed with the
+// cameras connected leaves their kernel drivers detached, see device.RepairCameras. beforeExit runs first, e.g. to
+// restore the terminal. Nothing can be done on SIGKILL.
+func exitOnSignal(beforeExit func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		received := <-signals
+		slog.Warn(fmt.Sprintf("got %v, disconnecting before exiting...", received))
+		beforeExit()
+		if err := device.DisconnectAll(); err != nil {
+			slog.Error(err.Error())
+		}
+		os.Exit(1)
+	}()
+}
+
+// handleRepairCommand fixes what a killed process left behind, use 'repair cameras'. It returns false on failure.
+func handleRepairCommand(d device.Device, input string) bool {
+	parts := strings.Fields(input)
+	if len(parts) != 2 || parts[1] != "cameras" {
+		slog.Error("invalid command format, use 'repair cameras'")
+		return false
+	}
+	if d != nil {
+		slog.Error("a glass is connected in this session, its cameras are in use, repair them before connecting")
+		return false
+	}
+
+	if err := device.RepairCameras(); err != nil {
+		slog.Error(fmt.Sprintf("failed to repair cameras: %v", err))
+		return false
+	}
+	slog.Info("cameras repaired")
+	return true
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"xreal-light-xr-go/internal/device"
+
+	"github.com/peterh/liner"
+)
+
+// confirmationPolicy decides whether a command needs an explicit user confirmation before being sent.
+type confirmationPolicy struct {
+	// line is the liner instance of the REPL, reused so we don't fight over the terminal
+	line *liner.State
+	// assumeYes skips prompting, allowing automation to run risky commands unattended
+	assumeYes bool
+}
+
+// confirm returns true if the command described is allowed to run given its danger level.
+func (p *confirmationPolicy) confirm(description string, level device.DangerLevel) bool {
+	if level == device.DANGER_LEVEL_SAFE {
+		return true
+	}
+
+	if p.assumeYes {
+		slog.Warn(fmt.Sprintf("running %s command '%s' without confirmation", level.String(), description))
+		return true
+	}
+
+	input, err := p.line.Prompt(fmt.Sprintf("'%s' is a %s command, please confirm if you want to continue? (y/N) ", description, level.String()))
+	if err != nil {
+		if err == liner.ErrPromptAborted {
+			slog.Warn("aborted, taking it as a NO")
+			return false
+		}
+		if err.Error() == "EOF" && input == "" {
+			slog.Warn("EOF, taking it as a NO")
+			return false
+		}
+		slog.Error(fmt.Sprintf("error reading input: %v", err))
+		return false
+	}
+
+	input = strings.TrimSpace(input)
+
+	if input != "y" && input != "Y" && input != "Yes" && input != "YES" {
+		return false
+	}
+	return true
+}
@@ -1,19 +1,98 @@
 package constant
 
+import "time"
+
 const (
 	XREAL_LIGHT          = "XREAL Light"
 	XREAL_AIR            = "XREAL Air"
 	XREAL_AIR_2          = "XREAL Air 2"
 	XREAL_AIR_2_PRO      = "XREAL Air 2 Pro"
 	XREAL_AIR_2_ULTRA    = "XREAL Air 2 Ultra"
+	XREAL_ONE            = "XREAL One"
+	XREAL_ONE_PRO        = "XREAL One Pro"
 	FIRMWARE_05_1_08_021 = "05.1.08.021_20221114"
 	FIRMWARE_05_5_08_059 = "05.5.08.059_20230518"
 )
 
 // Config holds configuration options for xrealxr
 type Config struct {
+	// Prints the version and exits
+	Version bool
 	// Enables debug logging output
 	Debug bool
-	// Immediately tries connect to a glass device at start
-	AutoConnect bool
+	// Log format, text or json
+	LogFormat string
+	// File to append logs to, empty for stderr
+	LogFilePath string
+	// JSON file translating the user-facing messages, empty for English
+	MessagesFilePath string
+	// Comma separated module=level pairs overriding the log level of the protocol, imu and camera logs
+	LogModuleLevels string
+	// Model of the glass to connect as soon as it is attached, one of light, air or any; empty to disable
+	AutoConnect string
+	// Assumes yes to all confirmations, e.g. before sending risky dev test commands
+	AssumeYes bool
+	// Exposes the connected glass on the D-Bus session bus
+	DBus bool
+	// Ambient light source driving the brightness level, one of none, glasses or host; requires DBus
+	BrightnessSource string
+	// Comma separated daily brightness levels as hh:mm=level, e.g. 07:00=6,22:30=2, empty to disable
+	BrightnessSchedule string
+	// How long scheduled brightness changes ramp over, 0 to jump
+	BrightnessRamp time.Duration
+	// How long a proximity state must hold before it is reported, 0 to disable
+	ProximityDebounce time.Duration
+	// Number of ambient light readings to average before reporting
+	AmbientLightWindow int
+	// Min change of the averaged ambient light to report
+	AmbientLightMinDelta uint
+	// Number of magnetometer readings to average before reporting
+	MagnetometerWindow int
+	// Min change in raw units on any axis of the averaged magnetometer reading to report
+	MagnetometerMinDelta float64
+	// File to persist command history across sessions, empty to disable
+	HistoryFilePath string
+	// File to append an audit trail of state-changing commands to, empty to disable
+	AuditLogPath string
+	// File to persist the last applied settings to, empty to disable
+	StateFilePath string
+	// Restores the settings persisted in StateFilePath once a glass is connected
+	RestoreState bool
+	// How long the IMU stream may stall before the watchdog recovers it, 0 to disable
+	IMUWatchdog time.Duration
+	// How long a SLAM frame request may go unanswered before the watchdog recovers the cameras, 0 to disable
+	CameraWatchdog time.Duration
+	// Comma separated recovery actions the watchdog escalates through on consecutive stalls
+	WatchdogRecovery string
+	// Address to serve command statistics at in the Prometheus text format, empty to disable
+	MetricsAddress string
+	// Address to serve the SLAM cameras at as an MJPEG stream, empty to disable
+	CameraStreamAddress string
+	// File of the clients allowed to use the network frontends and their scopes, empty to only serve them on loopback
+	AuthFilePath string
+	// Server certificate and key to serve the network frontends over TLS with, empty for plain HTTP
+	TLSCertPath string
+	TLSKeyPath  string
+	// CA of the client certificates required with TLSCertPath, empty to not require any
+	TLSClientCAPath string
+	// Rotation in degrees and optional translation in meters of the glass on a rig as roll,pitch,yaw[,x,y,z], empty to disable
+	MountingTransform string
+	// Hid interface of the Light MCU to open, as auto or interface=<number>,usagepage=<hex>
+	LightMCUInterface string
+	// Restart the read loops of the Light from scratch after a panic instead of carrying on with the next read
+	RestartOnPanic bool
+	// Detach the kernel driver holding the cameras of the Light, usually uvcvideo, while they are connected
+	DetachCameraDrivers bool
+	// Comma separated mapping of glass keys to virtual gamepad buttons, empty to disable; requires DBus
+	Gamepad string
+	// Comma separated processors applied to the SLAM frames, e.g. gamma=2.2,rotate=90, empty to disable
+	FramePipeline string
+	// Name template of the captured images, e.g. {serial}/{index}, empty for the timestamp
+	CaptureNameTemplate string
+	// Images kept by captures of a session, the oldest removed first, 0 to keep all
+	CaptureMaxFiles int
+	// Free space in megabytes captures leave on the disk, 0 to disable
+	CaptureMinFreeMB uint64
+	// File of rules running commands or webhooks on glass events, empty to disable
+	HooksFilePath string
 }
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	INITIATOR_CLI  = "cli"
+	INITIATOR_DBUS = "dbus"
+	// INITIATOR_RESTORE is for set commands replayed by RestoreLastKnownState
+	INITIATOR_RESTORE = "restore"
+)
+
+// AuditEntry records one state-changing command sent to the glass.
+type AuditEntry struct {
+	Time time.Time `json:"time"`
+	// Initiator tells which frontend sent the command, e.g. INITIATOR_CLI
+	Initiator string   `json:"initiator"`
+	Command   string   `json:"command"`
+	Args      []string `json:"args,omitempty"`
+	// OldValue and NewValue are read back from the glass if the command has a matching get command
+	OldValue string `json:"old_value,omitempty"`
+	NewValue string `json:"new_value,omitempty"`
+	// Error is set if the command failed
+	Error string `json:"error,omitempty"`
+}
+
+// AuditLog appends AuditEntry as JSON lines to a file. It is safe for concurrent use,
+// so the REPL and the D-Bus service can share one.
+type AuditLog struct {
+	// mutex for thread safety
+	mutex   sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// OpenAuditLog opens or creates the audit log file at path for appending.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+	return &AuditLog{file: f, encoder: json.NewEncoder(f)}, nil
+}
+
+// Record appends the entry to the audit log.
+func (a *AuditLog) Record(entry *AuditEntry) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if err := a.encoder.Encode(entry); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+func (a *AuditLog) Close() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.file.Close()
+}
+
+// auditedValueCommands are set commands whose value can be read back with the get command of the same name.
+var auditedValueCommands = map[string]struct{}{
+	"displaymode": {},
+	"brightness":  {},
+	"oled":        {},
+	"keyswitch":   {},
+	"default2d":   {},
+	"sleeptime":   {},
+	"orbit":       {},
+	"superactive": {},
+}
+
+// readAuditValue reads the current value for the audit log, empty if it cannot be read back.
+func (c *Controller) readAuditValue(command string, args []string) string {
+	var getArgs []string
+	if command == "config" {
+		// the key is the first arg of both get and set
+		if len(args) == 0 {
+			return ""
+		}
+		getArgs = args[:1]
+	} else if _, ok := auditedValueCommands[command]; !ok {
+		return ""
+	}
+	result, err := c.Get(command, getArgs)
+	if err != nil {
+		return ""
+	}
+	return result.Value
+}
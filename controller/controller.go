@@ -0,0 +1,587 @@
+// Package controller implements the get/set command semantics of the xrealxr REPL on top of device.Device,
+// returning typed results so other frontends can reuse them.
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+	"xreal-light-xr-go/storage"
+)
+
+// defaultControlTimeout is how long `set role controller` waits for the controlling process to release the glass
+const defaultControlTimeout = 10 * time.Second
+
+var (
+	// ErrUnknownCommand is returned when the command name is not recognized
+	ErrUnknownCommand = errors.New("unknown command")
+	// ErrInvalidArgument is returned when the command arguments fail validation
+	ErrInvalidArgument = errors.New("invalid argument")
+)
+
+// Result is the outcome of a command run through the Controller.
+type Result struct {
+	// Command is the command name as given, e.g. "brightness"
+	Command string
+	// Name is a human readable name of what the command reads or sets, e.g. "Brightness Level"
+	Name string
+	// Value is the value read by a get command, empty for set commands
+	Value string
+	// Files are paths written by the command, if any
+	Files []string
+}
+
+func (r *Result) String() string {
+	switch {
+	case len(r.Files) > 0:
+		return fmt.Sprintf("%s dumped to file location: %v", r.Name, r.Files)
+	case r.Value != "":
+		return fmt.Sprintf("%s: %s", r.Name, r.Value)
+	default:
+		return fmt.Sprintf("%s set successfully", r.Name)
+	}
+}
+
+// Controller runs get/set commands against a connected glass.
+type Controller struct {
+	device device.Device
+
+	// auditLog records set commands if not nil
+	auditLog *AuditLog
+	// initiator is recorded in the audit log, e.g. INITIATOR_CLI
+	initiator string
+	// stateStore persists successful set commands if not nil
+	stateStore *StateStore
+}
+
+// New creates a Controller for a connected glass.
+func New(d device.Device) *Controller {
+	return &Controller{device: d}
+}
+
+// WithAuditLog records every set command run through the Controller to auditLog on behalf of initiator.
+// A nil auditLog disables recording.
+func (c *Controller) WithAuditLog(auditLog *AuditLog, initiator string) *Controller {
+	c.auditLog = auditLog
+	c.initiator = initiator
+	return c
+}
+
+// WithStateStore persists every successful set command run through the Controller to stateStore.
+// A nil stateStore disables persisting.
+func (c *Controller) WithStateStore(stateStore *StateStore) *Controller {
+	c.stateStore = stateStore
+	return c
+}
+
+// RestoreLastKnownState replays the set commands persisted in the state store, e.g. right after connecting,
+// so the glass returns to the configuration it had before the process restarted. It carries on past failures
+// and returns them joined.
+func (c *Controller) RestoreLastKnownState() error {
+	if c.stateStore == nil {
+		return fmt.Errorf("no state store to restore from")
+	}
+
+	var errs []error
+	for _, command := range c.stateStore.commands() {
+		if _, err := c.Set(command[0], command[1:]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Controller) recordState(command string, args []string, err error) {
+	if c.stateStore == nil || err != nil {
+		return
+	}
+	if stateErr := c.stateStore.Record(command, args); stateErr != nil {
+		slog.Warn(stateErr.Error())
+	}
+}
+
+// Get reads a value from the glass, see the REPL `get` command for supported commands.
+func (c *Controller) Get(command string, args []string) (*Result, error) {
+	switch command {
+	case "serial":
+		serial, err := c.device.GetSerial()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get serial: %w", err)
+		}
+		return &Result{Command: command, Name: "Serial", Value: serial}, nil
+	case "firmware", "stockfirmware":
+		getters := map[string]func() (string, error){
+			"firmware":      c.device.GetFirmwareVersion,
+			"stockfirmware": c.device.GetStockFirmwareVersion,
+		}
+		firmware, err := getters[command]()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s version: %w", command, err)
+		}
+		value := firmware
+		if parsed, err := device.ParseFirmwareVersion(firmware); err == nil {
+			value = parsed.String()
+		}
+		if command == "firmware" && !device.IsKnownFirmware(firmware) {
+			value += " (untested by this driver)"
+		}
+		return &Result{Command: command, Name: "Firmware Version", Value: value}, nil
+	case "displaymode":
+		mode, err := c.device.GetDisplayMode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get display mode: %w", err)
+		}
+		return &Result{Command: command, Name: "Display Mode", Value: string(mode)}, nil
+	case "brightness":
+		brightness, err := c.device.GetBrightnessLevel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get brightness level: %w", err)
+		}
+		return &Result{Command: command, Name: "Brightness Level", Value: brightness}, nil
+	case "oled":
+		level, err := c.device.GetOLEDBrightnessLevel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get OLED brightness level: %w", err)
+		}
+		brit, err := c.device.GetOLEDBrightnessBrit()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get OLED brightness brit: %w", err)
+		}
+		return &Result{Command: command, Name: "OLED Brightness", Value: fmt.Sprintf("level %s, brit %s", level, brit)}, nil
+	case "keyswitch", "default2d":
+		getters := map[string]func() (bool, error){
+			"keyswitch": c.device.GetKeySwitchEnabled,
+			"default2d": c.device.GetDefault2DEnabled,
+		}
+		enabled, err := getters[command]()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s: %w", command, err)
+		}
+		return &Result{Command: command, Name: fmt.Sprintf("%s enabled", command), Value: fmt.Sprintf("%t", enabled)}, nil
+	case "config":
+		if len(args) == 0 {
+			var keys []string
+			for _, key := range device.GetConfigKeys() {
+				access := "r"
+				if key.Readable() && key.Writable() {
+					access = "rw"
+				} else if key.Writable() {
+					access = "w"
+				}
+				keys = append(keys, fmt.Sprintf("%s (%s): %s", key.Name, access, key.Description))
+			}
+			return &Result{Command: command, Name: "Config Keys", Value: strings.Join(keys, "; ")}, nil
+		}
+		value, err := c.device.GetConfigValue(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to get config %s: %w", args[0], err)
+		}
+		return &Result{Command: command, Name: args[0], Value: value}, nil
+	case "sleeptime":
+		seconds, err := c.device.GetSleepTime()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sleep time: %w", err)
+		}
+		return &Result{Command: command, Name: "Sleep Time (seconds)", Value: seconds}, nil
+	case "orbit":
+		state, err := c.device.GetOrbitFunction()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get orbit function: %w", err)
+		}
+		return &Result{Command: command, Name: "Orbit Function (experimental)", Value: state}, nil
+	case "superactive":
+		enabled, err := c.device.GetSuperActive()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get super active: %w", err)
+		}
+		return &Result{Command: command, Name: "Super Active (experimental)", Value: fmt.Sprintf("%t", enabled)}, nil
+	case "rgbcam":
+		enabled, err := c.device.GetRGBCameraEnabled()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get RGB camera state: %w", err)
+		}
+		return &Result{Command: command, Name: "RGB Camera enabled", Value: fmt.Sprintf("%t", enabled)}, nil
+	case "hwinfo":
+		// firmware dependent parts are reported as unsupported rather than failing the whole command
+		var parts []string
+		for _, part := range []struct {
+			name string
+			get  func() (string, error)
+		}{
+			{"serial", c.device.GetSerial},
+			{"firmware", c.device.GetFirmwareVersion},
+			{"stock firmware", c.device.GetStockFirmwareVersion},
+			{"display firmware", func() (string, error) {
+				version, err := c.device.GetDisplayFirmware()
+				if err != nil {
+					return "", err
+				}
+				return version.String(), nil
+			}},
+			{"display HDCP", func() (string, error) {
+				version, err := c.device.GetDisplayHDCPVersion()
+				if err != nil {
+					return "", err
+				}
+				return version.String(), nil
+			}},
+			{"OV580", func() (string, error) {
+				info, err := c.device.GetOV580Info()
+				if err != nil {
+					return "", err
+				}
+				return info.String(), nil
+			}},
+		} {
+			value, err := part.get()
+			switch {
+			case errors.Is(err, device.ErrUnsupportedByFirmware):
+				value = "unsupported by firmware"
+			case err != nil:
+				return nil, fmt.Errorf("failed to get %s: %w", part.name, err)
+			}
+			parts = append(parts, fmt.Sprintf("%s: %s", part.name, value))
+		}
+		return &Result{Command: command, Name: "Hardware Info", Value: strings.Join(parts, ", ")}, nil
+	case "ov580info":
+		info, err := c.device.GetOV580Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get OV580 info: %w", err)
+		}
+		return &Result{Command: command, Name: "OV580", Value: info.String()}, nil
+	case "connections":
+		mcu, err := c.device.GetMCUInfo()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get MCU connection: %w", err)
+		}
+		sensor, err := c.device.GetSensorInfo()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get OV580 connection: %w", err)
+		}
+		cameras, err := c.device.GetCameraInfo()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get camera connections: %w", err)
+		}
+		connections := []string{mcu.String(), sensor.String()}
+		for _, camera := range cameras {
+			connections = append(connections, camera.String())
+		}
+		return &Result{Command: command, Name: "Connections", Value: strings.Join(connections, "; ")}, nil
+	case "clock":
+		clock, err := c.device.GetClockSync()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get clock sync: %w", err)
+		}
+		estimate, err := clock.Estimate()
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate MCU clock: %w", err)
+		}
+		return &Result{Command: command, Name: "MCU Clock", Value: estimate.String()}, nil
+	case "role":
+		role, err := c.device.GetRole()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get role: %w", err)
+		}
+		return &Result{Command: command, Name: "Role", Value: string(role)}, nil
+	case "capabilities":
+		capabilities, err := c.device.GetCapabilities()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get capabilities: %w", err)
+		}
+		return &Result{Command: command, Name: "Capabilities", Value: capabilities.String()}, nil
+	case "commandstats":
+		return &Result{Command: command, Name: "Command Statistics", Value: device.GetCommandStats().String()}, nil
+	case "streamformats":
+		if len(args) == 0 || (args[0] != "slam" && args[0] != "rgb") {
+			return nil, fmt.Errorf("%w: please specify slam or rgb camera", ErrInvalidArgument)
+		}
+		getFormats := c.device.GetSLAMStreamFormats
+		if args[0] == "rgb" {
+			getFormats = c.device.GetRGBStreamFormats
+		}
+		formats, err := getFormats()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s stream formats: %w", args[0], err)
+		}
+		return &Result{Command: command, Name: fmt.Sprintf("%s Stream Formats", args[0]), Value: fmt.Sprintf("%v", formats)}, nil
+	case "framestats":
+		frame, err := c.device.GetSLAMFrameRaw()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get frame: %w", err)
+		}
+		if frame.Statistics == nil {
+			return nil, fmt.Errorf("frame has no statistics")
+		}
+		return &Result{Command: command, Name: "SLAM frame statistics", Value: frame.Statistics.String()}, nil
+	case "image", "images":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%w: want an existing folder or storage URL, got %v", ErrInvalidArgument, args)
+		}
+		store, err := storage.Open(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidArgument, err)
+		}
+		if len(args) == 1 {
+			locations, err := c.device.CaptureImages(store)
+			if err != nil {
+				return nil, fmt.Errorf("failed to dump images: %w", err)
+			}
+			return &Result{Command: command, Name: "Images", Files: locations}, nil
+		}
+		encoder, err := device.NewImageEncoder(args[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidArgument, err)
+		}
+		frame, err := c.device.GetSLAMFrameRaw()
+		if err != nil {
+			return nil, fmt.Errorf("failed to dump images: %w", err)
+		}
+		locations, err := frame.Store(store, fmt.Sprintf("%d", frame.Timestamp.UnixMilli()), encoder)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dump images: %w", err)
+		}
+		return &Result{Command: command, Name: "Images", Files: locations}, nil
+	default:
+		return nil, fmt.Errorf("%w: get %s", ErrUnknownCommand, command)
+	}
+}
+
+// eventReportingInstructions maps set commands to the event reporting they toggle.
+var eventReportingInstructions = map[string]device.CommandInstruction{
+	"vsync":        device.CMD_ENABLE_VSYNC,
+	"ambientlight": device.CMD_ENABLE_AMBIENT_LIGHT,
+	"magnetometer": device.CMD_ENABLE_MAGNETOMETER,
+	"temperature":  device.CMD_ENABLE_TEMPERATURE,
+	"rgbcam":       device.CMD_ENABLE_RGB_CAMERA,
+	"imu":          device.OV580_ENABLE_IMU_STREAM,
+}
+
+// Set changes a setting of the glass, see the REPL `set` command for supported commands.
+func (c *Controller) Set(command string, args []string) (*Result, error) {
+	if c.auditLog == nil {
+		result, err := c.set(command, args)
+		c.recordState(command, args, err)
+		return result, err
+	}
+
+	entry := &AuditEntry{
+		Time:      time.Now(),
+		Initiator: c.initiator,
+		Command:   command,
+		Args:      args,
+		OldValue:  c.readAuditValue(command, args),
+	}
+
+	result, err := c.set(command, args)
+	c.recordState(command, args, err)
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.NewValue = c.readAuditValue(command, args)
+	}
+
+	if auditErr := c.auditLog.Record(entry); auditErr != nil {
+		slog.Warn(auditErr.Error())
+	}
+	return result, err
+}
+
+func (c *Controller) set(command string, args []string) (*Result, error) {
+	switch command {
+	case "displaymode":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%w: empty display mode input, please specify one of (%v)", ErrInvalidArgument, device.SupportedDisplayMode)
+		}
+		if _, ok := device.SupportedDisplayMode[args[0]]; !ok {
+			return nil, fmt.Errorf("%w: invalid display mode: got (%s) want one of (%v)", ErrInvalidArgument, args[0], device.SupportedDisplayMode)
+		}
+		if len(args) > 1 {
+			// fades the brightness out and back in around the switch
+			fade, err := time.ParseDuration(args[1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid fade duration %s: %w", ErrInvalidArgument, args[1], err)
+			}
+			if err := device.FadeDisplayMode(c.device, device.DisplayMode(args[0]), fade); err != nil {
+				return nil, fmt.Errorf("failed to fade display mode: %w", err)
+			}
+			return &Result{Command: command, Name: "Display mode"}, nil
+		}
+		if err := c.device.SetDisplayMode(device.DisplayMode(args[0])); err != nil {
+			return nil, fmt.Errorf("failed to set display mode: %w", err)
+		}
+		return &Result{Command: command, Name: "Display mode"}, nil
+	case "brightness":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%w: empty brightness level input, please specify a number", ErrInvalidArgument)
+		}
+		if len(args) > 1 {
+			ramp, err := time.ParseDuration(args[1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid ramp duration %s: %w", ErrInvalidArgument, args[1], err)
+			}
+			if err := device.RampBrightness(c.device, args[0], ramp); err != nil {
+				return nil, fmt.Errorf("failed to ramp brightness level: %w", err)
+			}
+			return &Result{Command: command, Name: "Brightness level"}, nil
+		}
+		if err := c.device.SetBrightnessLevel(args[0]); err != nil {
+			return nil, fmt.Errorf("failed to set brightness level: %w", err)
+		}
+		return &Result{Command: command, Name: "Brightness level"}, nil
+	case "oled":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%w: empty OLED brightness level input, please specify 0 or 1", ErrInvalidArgument)
+		}
+		if err := c.device.SetOLEDBrightnessLevel(args[0]); err != nil {
+			return nil, fmt.Errorf("failed to set OLED brightness level: %w", err)
+		}
+		return &Result{Command: command, Name: "OLED brightness level"}, nil
+	case "keyswitch", "default2d":
+		if len(args) == 0 || (args[0] != "0" && args[0] != "1") {
+			return nil, fmt.Errorf("%w: empty input, please specify 0 (disable) or 1 (enable)", ErrInvalidArgument)
+		}
+		setters := map[string]func(bool) error{
+			"keyswitch": c.device.SetKeySwitchEnabled,
+			"default2d": c.device.SetDefault2DEnabled,
+		}
+		if err := setters[command](args[0] == "1"); err != nil {
+			return nil, fmt.Errorf("failed to set %s: %w", command, err)
+		}
+		return &Result{Command: command, Name: command}, nil
+	case "config":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%w: please specify 'config <key> <value>', see 'get config' for keys", ErrInvalidArgument)
+		}
+		if err := c.device.SetConfigValue(args[0], args[1]); err != nil {
+			return nil, fmt.Errorf("failed to set config %s: %w", args[0], err)
+		}
+		return &Result{Command: command, Name: args[0]}, nil
+	case "sbs":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%w: empty input, please specify a duration (e.g. 30m) or off", ErrInvalidArgument)
+		}
+		if args[0] == "off" {
+			if err := c.device.ExitSBS(); err != nil {
+				return nil, fmt.Errorf("failed to exit SBS: %w", err)
+			}
+			return &Result{Command: command, Name: "SBS off"}, nil
+		}
+		duration, err := time.ParseDuration(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid duration %s: %w", ErrInvalidArgument, args[0], err)
+		}
+		if err := c.device.EnterSBS(duration); err != nil {
+			return nil, fmt.Errorf("failed to enter SBS: %w", err)
+		}
+		return &Result{Command: command, Name: fmt.Sprintf("SBS for %v", duration)}, nil
+	case "sleeptime":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%w: empty sleep time input, please specify seconds larger than %d", ErrInvalidArgument, device.MIN_SLEEP_TIME_SECONDS)
+		}
+		if err := c.device.SetSleepTime(args[0]); err != nil {
+			return nil, fmt.Errorf("failed to set sleep time: %w", err)
+		}
+		return &Result{Command: command, Name: "Sleep time"}, nil
+	case "powerprofile":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%w: empty power profile input, please specify one of %v", ErrInvalidArgument, device.SupportedPowerProfiles())
+		}
+		if _, err := device.GetPowerSettings(device.PowerProfile(args[0])); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidArgument, err)
+		}
+		if err := device.ApplyPowerProfile(c.device, device.PowerProfile(args[0])); err != nil {
+			return nil, err
+		}
+		return &Result{Command: command, Name: fmt.Sprintf("Power profile %s", args[0])}, nil
+	case "orbit", "superactive":
+		if len(args) == 0 || (args[0] != "0" && args[0] != "1") {
+			return nil, fmt.Errorf("%w: empty input, please specify 0 (close/disable) or 1 (open/enable)", ErrInvalidArgument)
+		}
+		setters := map[string]func(bool) error{
+			"orbit":       c.device.SetOrbitFunction,
+			"superactive": c.device.SetSuperActive,
+		}
+		if err := setters[command](args[0] == "1"); err != nil {
+			return nil, fmt.Errorf("failed to set %s: %w", command, err)
+		}
+		return &Result{Command: command, Name: command}, nil
+	case "resetsensors":
+		if err := c.device.ResetSensors(); err != nil {
+			return nil, fmt.Errorf("failed to reset sensors: %w", err)
+		}
+		return &Result{Command: command, Name: "Sensors reset"}, nil
+	case "role":
+		if len(args) == 0 || (args[0] != string(device.ROLE_CONTROLLER) && args[0] != string(device.ROLE_OBSERVER)) {
+			return nil, fmt.Errorf("%w: please specify 'controller [timeout]' to take over or 'observer' to release", ErrInvalidArgument)
+		}
+		if args[0] == string(device.ROLE_OBSERVER) {
+			if err := c.device.ReleaseControl(); err != nil {
+				return nil, fmt.Errorf("failed to release control: %w", err)
+			}
+			return &Result{Command: command, Name: "Role observer"}, nil
+		}
+		timeout := defaultControlTimeout
+		if len(args) > 1 {
+			var err error
+			if timeout, err = time.ParseDuration(args[1]); err != nil {
+				return nil, fmt.Errorf("%w: invalid timeout %s: %w", ErrInvalidArgument, args[1], err)
+			}
+		}
+		if err := c.device.RequestControl(timeout); err != nil {
+			return nil, fmt.Errorf("failed to take control: %w", err)
+		}
+		return &Result{Command: command, Name: "Role controller"}, nil
+	case "stream":
+		if len(args) != 3 || (args[0] != "slam" && args[0] != "rgb") {
+			return nil, fmt.Errorf("%w: please specify 'slam|rgb <width>x<height> <fps>'", ErrInvalidArgument)
+		}
+		var config device.StreamConfig
+		if _, err := fmt.Sscanf(args[1], "%dx%d", &config.Width, &config.Height); err != nil {
+			return nil, fmt.Errorf("%w: invalid resolution %s: %w", ErrInvalidArgument, args[1], err)
+		}
+		if _, err := fmt.Sscanf(args[2], "%g", &config.FPS); err != nil || config.FPS <= 0 {
+			return nil, fmt.Errorf("%w: invalid fps %s", ErrInvalidArgument, args[2])
+		}
+		setConfig := c.device.SetSLAMStreamConfig
+		if args[0] == "rgb" {
+			setConfig = c.device.SetRGBStreamConfig
+		}
+		if err := setConfig(config); err != nil {
+			return nil, fmt.Errorf("failed to set %s stream config: %w", args[0], err)
+		}
+		return &Result{Command: command, Name: fmt.Sprintf("%s stream config", args[0])}, nil
+	case "display":
+		if len(args) == 0 || (args[0] != "off" && args[0] != "on") {
+			return nil, fmt.Errorf("%w: empty input, please specify off or on", ErrInvalidArgument)
+		}
+		var err error
+		if args[0] == "off" {
+			err = c.device.DisplayOff()
+		} else {
+			err = c.device.DisplayOn()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to turn display %s: %w", args[0], err)
+		}
+		return &Result{Command: command, Name: "Display state"}, nil
+	case "idle":
+		if err := c.device.Idle(); err != nil {
+			return nil, fmt.Errorf("failed to idle: %w", err)
+		}
+		return &Result{Command: command, Name: "Idle mode"}, nil
+	case "vsync", "ambientlight", "magnetometer", "temperature", "imu", "rgbcam":
+		if len(args) == 0 || (args[0] != "0" && args[0] != "1") {
+			return nil, fmt.Errorf("%w: empty input, please specify 0 (disable) or 1 (enable)", ErrInvalidArgument)
+		}
+		if err := c.device.EnableEventReporting(eventReportingInstructions[command], args[0]); err != nil {
+			return nil, fmt.Errorf("failed to set %s event: %w", command, err)
+		}
+		return &Result{Command: command, Name: fmt.Sprintf("%s event reporting", command)}, nil
+	default:
+		return nil, fmt.Errorf("%w: set %s", ErrUnknownCommand, command)
+	}
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// persistedCommands are set commands whose last applied args are kept by StateStore. Temporary states,
+// e.g. sbs and display off, are left out on purpose as they should not outlive the process.
+var persistedCommands = map[string]struct{}{
+	"displaymode": {},
+	"brightness":  {},
+	"oled":        {},
+	"keyswitch":   {},
+	"default2d":   {},
+	"sleeptime":   {},
+	"config":      {},
+}
+
+// StateStore persists the args of the last successful set commands to a JSON file, so the glass can be
+// returned to the expected configuration after the process dies, see Controller.RestoreLastKnownState.
+// It is safe for concurrent use, so the REPL and the D-Bus service can share one.
+type StateStore struct {
+	// mutex for thread safety
+	mutex sync.Mutex
+	path  string
+	// state maps the state key, e.g. "brightness" or "config sleep_time", to the set command args
+	state map[string][]string
+}
+
+// OpenStateStore loads the state file at path, which is created on the first record if missing.
+func OpenStateStore(path string) (*StateStore, error) {
+	s := &StateStore{path: path, state: make(map[string][]string)}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(content, &s.state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// stateKey tells under which key the set command is persisted, empty if it is not.
+// Config values are keyed by their config key as well, so each one is restored.
+func stateKey(command string, args []string) string {
+	if _, ok := persistedCommands[command]; !ok {
+		return ""
+	}
+	if command == "config" {
+		if len(args) == 0 {
+			return ""
+		}
+		return command + " " + args[0]
+	}
+	return command
+}
+
+// Record keeps args as the last applied value of the set command, if it is persisted.
+func (s *StateStore) Record(command string, args []string) error {
+	key := stateKey(command, args)
+	if key == "" {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.state[key] = append([]string(nil), args...)
+	return s.save()
+}
+
+// save writes the state to a temporary file renamed over the state file, so a crash mid-write
+// leaves the previous state intact.
+func (s *StateStore) save() error {
+	content, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", s.path, err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write state file %s: %w", s.path, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write state file %s: %w", s.path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", s.path, err)
+	}
+	if err := os.Rename(f.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// commands returns the persisted set commands in a stable order.
+func (s *StateStore) commands() [][]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	keys := make([]string, 0, len(s.state))
+	for key := range s.state {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	commands := make([][]string, 0, len(keys))
+	for _, key := range keys {
+		command, _, _ := strings.Cut(key, " ")
+		commands = append(commands, append([]string{command}, s.state[key]...))
+	}
+	return commands
+}
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStateStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store, err := OpenStateStore(path)
+	if err != nil {
+		t.Fatalf("OpenStateStore() failed: %v", err)
+	}
+	for _, command := range [][]string{
+		{"brightness", "3"},
+		{"brightness", "5"},
+		{"config", "sleep_time", "60"},
+		{"sbs", "10m"},
+		{"displaymode", "STEREO"},
+	} {
+		if err := store.Record(command[0], command[1:]); err != nil {
+			t.Fatalf("Record(%v) failed: %v", command, err)
+		}
+	}
+
+	reopened, err := OpenStateStore(path)
+	if err != nil {
+		t.Fatalf("OpenStateStore() failed to reopen: %v", err)
+	}
+	expected := [][]string{
+		{"brightness", "5"},
+		{"config", "sleep_time", "60"},
+		{"displaymode", "STEREO"},
+	}
+	if actual := reopened.commands(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got persisted commands %v; expected %v", actual, expected)
+	}
+}
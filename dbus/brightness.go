@@ -0,0 +1,182 @@
+package dbus
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync"
+
+	"xreal-light-xr-go/controller"
+
+	godbus "github.com/godbus/dbus/v5"
+)
+
+// BrightnessSource selects which ambient light source drives the glass brightness level.
+type BrightnessSource string
+
+const (
+	// BRIGHTNESS_SOURCE_NONE leaves the brightness level alone
+	BRIGHTNESS_SOURCE_NONE BrightnessSource = "none"
+	// BRIGHTNESS_SOURCE_GLASSES follows the ambient light sensor of the glass, whose raw readings are not lux
+	BRIGHTNESS_SOURCE_GLASSES BrightnessSource = "glasses"
+	// BRIGHTNESS_SOURCE_HOST follows the host ambient light sensor via iio-sensor-proxy
+	BRIGHTNESS_SOURCE_HOST BrightnessSource = "host"
+)
+
+var SupportedBrightnessSource = map[string]struct{}{
+	string(BRIGHTNESS_SOURCE_NONE):    {},
+	string(BRIGHTNESS_SOURCE_GLASSES): {},
+	string(BRIGHTNESS_SOURCE_HOST):    {},
+}
+
+const (
+	sensorProxyBusName       = "net.hadess.SensorProxy"
+	sensorProxyObjectPath    = godbus.ObjectPath("/net/hadess/SensorProxy")
+	sensorProxyInterfaceName = "net.hadess.SensorProxy"
+
+	// ambientLightMaxLux maps to the max brightness level, roughly indirect daylight
+	ambientLightMaxLux = 10000
+	// ambientLightMaxRaw maps to the max brightness level for the sensor of the glass, whose unit is not known, so its
+	// readings are spread over their whole range rather than taken as lux
+	ambientLightMaxRaw = math.MaxUint16
+	maxBrightnessLevel = 7
+)
+
+// brightnessSync adjusts the glass brightness level following ambient light readings.
+type brightnessSync struct {
+	controller *controller.Controller
+	source     BrightnessSource
+
+	// systemConn is only used with BRIGHTNESS_SOURCE_HOST
+	systemConn *godbus.Conn
+	// channel to receive iio-sensor-proxy property changes
+	signalChannel chan *godbus.Signal
+	// waitgroup to wait for the signal goroutine to stop
+	waitgroup sync.WaitGroup
+
+	// mutex for thread safety
+	mutex sync.Mutex
+	// lastLevel avoids sending the same brightness level repeatedly
+	lastLevel string
+}
+
+// brightnessLevelOnLogScale maps value from 1 to maxValue on a log scale since perceived brightness is roughly
+// logarithmic.
+func brightnessLevelOnLogScale(value, maxValue float64) string {
+	if value <= 1 {
+		return "0"
+	}
+	level := int(math.Round(math.Log10(value) / math.Log10(maxValue) * maxBrightnessLevel))
+	level = min(max(level, 0), maxBrightnessLevel)
+	return strconv.Itoa(level)
+}
+
+// onHostAmbientLight follows a reading of the host light sensor, which iio-sensor-proxy reports in lux.
+func (b *brightnessSync) onHostAmbientLight(lux float64) {
+	b.syncLevel(brightnessLevelOnLogScale(lux, ambientLightMaxLux), fmt.Sprintf("%.1f lux", lux))
+}
+
+// onGlassAmbientLight follows a raw reading of the sensor of the glass.
+func (b *brightnessSync) onGlassAmbientLight(raw uint16) {
+	b.syncLevel(brightnessLevelOnLogScale(float64(raw), ambientLightMaxRaw), fmt.Sprintf("%d (raw)", raw))
+}
+
+func (b *brightnessSync) syncLevel(level string, reading string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if level == b.lastLevel {
+		return
+	}
+	if _, err := b.controller.Set("brightness", []string{level}); err != nil {
+		slog.Debug(fmt.Sprintf("failed to sync brightness level to %s: %v", level, err))
+		return
+	}
+	slog.Debug(fmt.Sprintf("synced brightness level to %s for ambient light %s", level, reading))
+	b.lastLevel = level
+}
+
+// startHostAmbientLight claims the host light sensor from iio-sensor-proxy and follows its readings.
+func (b *brightnessSync) startHostAmbientLight() error {
+	conn, err := godbus.ConnectSystemBus()
+	if err != nil {
+		return fmt.Errorf("failed to connect to system bus: %w", err)
+	}
+
+	proxy := conn.Object(sensorProxyBusName, sensorProxyObjectPath)
+
+	hasAmbientLight, err := proxy.GetProperty(sensorProxyInterfaceName + ".HasAmbientLight")
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to query iio-sensor-proxy: %w", err)
+	}
+	if available, ok := hasAmbientLight.Value().(bool); !ok || !available {
+		conn.Close()
+		return fmt.Errorf("host has no ambient light sensor")
+	}
+
+	if call := proxy.Call(sensorProxyInterfaceName+".ClaimLight", 0); call.Err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to claim host light sensor: %w", call.Err)
+	}
+
+	if err := conn.AddMatchSignal(
+		godbus.WithMatchObjectPath(sensorProxyObjectPath),
+		godbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		godbus.WithMatchMember("PropertiesChanged"),
+	); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to watch host light sensor: %w", err)
+	}
+
+	b.systemConn = conn
+	b.signalChannel = make(chan *godbus.Signal, 16)
+	conn.Signal(b.signalChannel)
+
+	if lightLevel, err := proxy.GetProperty(sensorProxyInterfaceName + ".LightLevel"); err == nil {
+		if lux, ok := lightLevel.Value().(float64); ok {
+			b.onHostAmbientLight(lux)
+		}
+	}
+
+	b.waitgroup.Add(1)
+	go b.readHostAmbientLight()
+
+	return nil
+}
+
+// readHostAmbientLight is a goroutine method to follow iio-sensor-proxy LightLevel changes.
+// Note that LightLevelUnit may be "vendor" on some hosts, in which case the mapping is only approximate.
+func (b *brightnessSync) readHostAmbientLight() {
+	defer b.waitgroup.Done()
+
+	for signal := range b.signalChannel {
+		if len(signal.Body) < 2 {
+			continue
+		}
+		changed, ok := signal.Body[1].(map[string]godbus.Variant)
+		if !ok {
+			continue
+		}
+		if lightLevel, ok := changed["LightLevel"]; ok {
+			if lux, ok := lightLevel.Value().(float64); ok {
+				b.onHostAmbientLight(lux)
+			}
+		}
+	}
+}
+
+func (b *brightnessSync) stop() {
+	if b.systemConn == nil {
+		return
+	}
+
+	b.systemConn.Object(sensorProxyBusName, sensorProxyObjectPath).Call(sensorProxyInterfaceName+".ReleaseLight", 0)
+	b.systemConn.RemoveSignal(b.signalChannel)
+	close(b.signalChannel)
+	b.waitgroup.Wait()
+
+	b.systemConn.Close()
+	b.systemConn = nil
+}
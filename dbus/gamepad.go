@@ -0,0 +1,136 @@
+package dbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+const (
+	// DEFAULT_GAMEPAD_MAPPING maps the up and down keys of the glass to the first two gamepad buttons
+	DEFAULT_GAMEPAD_MAPPING = "up=1,down=2"
+	// GAMEPAD_BUTTONS is how many buttons the virtual gamepad has
+	GAMEPAD_BUTTONS = 16
+
+	// gamepadPressDuration is how long a button is held, as the glass only reports key presses and no releases
+	gamepadPressDuration = 100 * time.Millisecond
+)
+
+// GamepadMapping maps keys of the glass to virtual gamepad buttons, numbered from 1.
+type GamepadMapping map[device.KeyEvent]int
+
+// ParseGamepadMapping parses comma separated key=button pairs, e.g. DEFAULT_GAMEPAD_MAPPING.
+func ParseGamepadMapping(mapping string) (GamepadMapping, error) {
+	keys := map[string]device.KeyEvent{
+		strings.ToLower(device.KEY_UP_PRESSED.String()):   device.KEY_UP_PRESSED,
+		strings.ToLower(device.KEY_DOWN_PRESSED.String()): device.KEY_DOWN_PRESSED,
+	}
+
+	result := GamepadMapping{}
+	for _, pair := range strings.Split(mapping, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			return nil, fmt.Errorf("invalid gamepad mapping %s: want key=button", pair)
+		}
+		key, ok := keys[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("invalid key %s: want up or down", name)
+		}
+		button, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || button < 1 || button > GAMEPAD_BUTTONS {
+			return nil, fmt.Errorf("invalid button %s: want 1 to %d", value, GAMEPAD_BUTTONS)
+		}
+		result[key] = button
+	}
+	return result, nil
+}
+
+// gamepad presses virtual gamepad buttons for key events of the glass.
+type gamepad struct {
+	mapping GamepadMapping
+	// input sends a report of the button bit field
+	input func(report []byte) error
+	// destroy removes the virtual gamepad
+	destroy func() error
+
+	// mutex for thread safety
+	mutex sync.Mutex
+	// pressed is the bit field of the held buttons
+	pressed uint16
+	// releases are the pending button releases by button
+	releases map[int]*time.Timer
+	stopped  bool
+}
+
+func newGamepad(mapping GamepadMapping, input func(report []byte) error, destroy func() error) *gamepad {
+	return &gamepad{mapping: mapping, input: input, destroy: destroy, releases: map[int]*time.Timer{}}
+}
+
+// press holds the button mapped to key for gamepadPressDuration, pressing it again while held extends it.
+func (g *gamepad) press(key device.KeyEvent) {
+	button, ok := g.mapping[key]
+	if !ok {
+		return
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.stopped {
+		return
+	}
+	if release, ok := g.releases[button]; ok {
+		release.Reset(gamepadPressDuration)
+		return
+	}
+	g.pressed |= 1 << (button - 1)
+	g.sendReport()
+	g.releases[button] = time.AfterFunc(gamepadPressDuration, func() {
+		g.release(button)
+	})
+}
+
+func (g *gamepad) release(button int) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.stopped {
+		return
+	}
+	delete(g.releases, button)
+	g.pressed &^= 1 << (button - 1)
+	g.sendReport()
+}
+
+// sendReport must be called with the mutex held.
+func (g *gamepad) sendReport() {
+	report := binary.LittleEndian.AppendUint16(nil, g.pressed)
+	if err := g.input(report); err != nil {
+		slog.Debug(fmt.Sprintf("failed to send gamepad report: %v", err))
+	}
+}
+
+// stop releases all buttons and removes the virtual gamepad.
+func (g *gamepad) stop() error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.stopped {
+		return nil
+	}
+	for _, release := range g.releases {
+		release.Stop()
+	}
+	if g.pressed != 0 {
+		g.pressed = 0
+		g.sendReport()
+	}
+	g.stopped = true
+	return g.destroy()
+}
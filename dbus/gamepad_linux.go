@@ -0,0 +1,29 @@
+//go:build linux
+
+package dbus
+
+import (
+	"fmt"
+
+	"xreal-light-xr-go/internal/uhid"
+)
+
+const (
+	// gamepadVendorID and gamepadProductID are arbitrary, they only need to not match real glasses
+	gamepadVendorID  = 0x1209
+	gamepadProductID = 0x0002
+)
+
+// createGamepad creates a virtual HID gamepad via uhid, which needs read/write access to /dev/uhid.
+func createGamepad(mapping GamepadMapping) (*gamepad, error) {
+	d, err := uhid.Create(uhid.Config{
+		Name:             "xreal-light-xr-go gamepad",
+		VendorID:         gamepadVendorID,
+		ProductID:        gamepadProductID,
+		ReportDescriptor: uhid.GamepadReportDescriptor(),
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create virtual gamepad: %w", err)
+	}
+	return newGamepad(mapping, d.Input, d.Destroy), nil
+}
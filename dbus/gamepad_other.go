@@ -0,0 +1,12 @@
+//go:build !linux
+
+package dbus
+
+import (
+	"fmt"
+)
+
+// createGamepad fails as virtual HID devices are only supported on Linux yet.
+func createGamepad(mapping GamepadMapping) (*gamepad, error) {
+	return nil, fmt.Errorf("virtual gamepad is not supported on this platform")
+}
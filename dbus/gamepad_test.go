@@ -0,0 +1,59 @@
+package dbus
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestParseGamepadMapping(t *testing.T) {
+	mapping, err := ParseGamepadMapping(DEFAULT_GAMEPAD_MAPPING)
+	if err != nil {
+		t.Fatalf("ParseGamepadMapping(%s) = %v", DEFAULT_GAMEPAD_MAPPING, err)
+	}
+	if mapping[device.KEY_UP_PRESSED] != 1 || mapping[device.KEY_DOWN_PRESSED] != 2 {
+		t.Errorf("ParseGamepadMapping(%s) = %v", DEFAULT_GAMEPAD_MAPPING, mapping)
+	}
+
+	for _, invalid := range []string{"up", "left=1", "up=0", "down=17", "up=a"} {
+		if _, err := ParseGamepadMapping(invalid); err == nil {
+			t.Errorf("ParseGamepadMapping(%s) = nil, want error", invalid)
+		}
+	}
+}
+
+func TestGamepadPressReleases(t *testing.T) {
+	var mutex sync.Mutex
+	reports := [][]byte{}
+	input := func(report []byte) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		reports = append(reports, report)
+		return nil
+	}
+	destroyed := false
+	g := newGamepad(GamepadMapping{device.KEY_DOWN_PRESSED: 10}, input, func() error {
+		destroyed = true
+		return nil
+	})
+
+	g.press(device.KEY_UP_PRESSED)
+	g.press(device.KEY_DOWN_PRESSED)
+	time.Sleep(3 * gamepadPressDuration)
+
+	mutex.Lock()
+	if len(reports) != 2 || reports[0][0] != 0 || reports[0][1] != 0x02 || reports[1][1] != 0 {
+		t.Errorf("reports = %v, want press of button 10 then release", reports)
+	}
+	mutex.Unlock()
+
+	if err := g.stop(); err != nil || !destroyed {
+		t.Errorf("stop() = %v, destroyed %v; want nil, true", err, destroyed)
+	}
+	g.press(device.KEY_DOWN_PRESSED)
+	if len(reports) != 2 {
+		t.Errorf("press() after stop() sent a report")
+	}
+}
@@ -0,0 +1,27 @@
+// pygen writes the Python client of the D-Bus service to the file given as argument, run by go generate ./dbus.
+package main
+
+import (
+	"log"
+	"os"
+
+	"xreal-light-xr-go/dbus"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		log.Fatal("usage: pygen <output.py>")
+	}
+
+	f, err := os.Create(os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := dbus.GeneratePythonClient(f); err != nil {
+		f.Close()
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,133 @@
+package dbus
+
+//go:generate go run ./pygen ../python/xreal_glasses/_generated.py
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+// pythonTemplate renders the low level Python client of the interface, the pythonic wrapper around it is written by
+// hand in python/xreal_glasses.
+var pythonTemplate = template.Must(template.New("python").Parse(`# Code generated by go generate ./dbus from the introspection data of the D-Bus service; DO NOT EDIT.
+"""Low level client of the {{.Interface}} D-Bus interface, see package xreal_glasses for the pythonic one."""
+
+from jeepney import DBusAddress, new_method_call
+from jeepney.wrappers import unwrap_msg
+
+BUS_NAME = "{{.BusName}}"
+OBJECT_PATH = "{{.ObjectPath}}"
+INTERFACE = "{{.Interface}}"
+
+# signal name -> D-Bus signature of its arguments
+SIGNALS = {
+{{- range .Signals}}
+    "{{.Name}}": "{{.Signature}}",
+{{- end}}
+}
+
+
+class GlassesClient:
+    """Calls the methods of {{.Interface}} over a blocking jeepney connection."""
+
+    def __init__(self, connection):
+        self._connection = connection
+        self._address = DBusAddress(OBJECT_PATH, bus_name=BUS_NAME, interface=INTERFACE)
+
+    def _call(self, method, signature, *args):
+        message = new_method_call(self._address, method, signature, args)
+        return unwrap_msg(self._connection.send_and_get_reply(message))
+{{range .Methods}}
+    def {{.PythonName}}(self{{range .In}}, {{.}}{{end}}):
+        """Calls {{.Name}}{{if .Out}}, returns {{.Returns}}{{end}}."""
+        {{if .Out}}return {{end}}self._call("{{.Name}}", {{if .Signature}}"{{.Signature}}"{{else}}None{{end}}{{range .In}}, {{.}}{{end}}){{.Unpack}}
+{{end -}}
+`))
+
+type pythonMethod struct {
+	Name       string
+	PythonName string
+	// Signature of the in arguments, In their names and Out the names of the out ones
+	Signature string
+	In        []string
+	Out       []string
+}
+
+// Returns describes the return value.
+func (m pythonMethod) Returns() string {
+	if len(m.Out) == 1 {
+		return m.Out[0]
+	}
+	return "(" + strings.Join(m.Out, ", ") + ")"
+}
+
+// Unpack unwraps a single out argument from the body tuple.
+func (m pythonMethod) Unpack() string {
+	if len(m.Out) == 1 {
+		return "[0]"
+	}
+	return ""
+}
+
+type pythonSignal struct {
+	Name      string
+	Signature string
+}
+
+var upperCase = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// GeneratePythonClient writes the Python client of the interface exported by Service to w, generated from its
+// introspection data so it always matches the service. It needs the jeepney package at runtime.
+func GeneratePythonClient(w io.Writer) error {
+	var node introspect.Node
+	if err := xml.Unmarshal([]byte(introspectXML), &node); err != nil {
+		return fmt.Errorf("failed to parse introspection data: %w", err)
+	}
+
+	data := struct {
+		BusName    string
+		ObjectPath string
+		Interface  string
+		Methods    []pythonMethod
+		Signals    []pythonSignal
+	}{BusName: BusName, ObjectPath: string(ObjectPath), Interface: InterfaceName}
+
+	for _, iface := range node.Interfaces {
+		if iface.Name != InterfaceName {
+			continue
+		}
+		for _, method := range iface.Methods {
+			generated := pythonMethod{Name: method.Name, PythonName: strings.ToLower(upperCase.ReplaceAllString(method.Name, "${1}_${2}"))}
+			for _, arg := range method.Args {
+				if arg.Direction == "out" {
+					generated.Out = append(generated.Out, arg.Name)
+				} else {
+					generated.In = append(generated.In, arg.Name)
+					generated.Signature += arg.Type
+				}
+			}
+			data.Methods = append(data.Methods, generated)
+		}
+		for _, signal := range iface.Signals {
+			generated := pythonSignal{Name: signal.Name}
+			for _, arg := range signal.Args {
+				generated.Signature += arg.Type
+			}
+			data.Signals = append(data.Signals, generated)
+		}
+	}
+	if len(data.Methods) == 0 {
+		return fmt.Errorf("no methods of %s in introspection data", InterfaceName)
+	}
+
+	if err := pythonTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to generate python client: %w", err)
+	}
+	return nil
+}
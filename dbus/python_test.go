@@ -0,0 +1,22 @@
+package dbus
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestGeneratedPythonClientUpToDate(t *testing.T) {
+	var generated bytes.Buffer
+	if err := GeneratePythonClient(&generated); err != nil {
+		t.Fatalf("GeneratePythonClient() failed: %v", err)
+	}
+
+	committed, err := os.ReadFile("../python/xreal_glasses/_generated.py")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(generated.Bytes(), committed) {
+		t.Errorf("python/xreal_glasses/_generated.py is out of date with the introspection data, run go generate ./dbus")
+	}
+}
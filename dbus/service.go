@@ -0,0 +1,362 @@
+// Package dbus exposes a connected glass over the D-Bus session bus so desktop applets and scripts can integrate it.
+package dbus
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"xreal-light-xr-go/controller"
+	"xreal-light-xr-go/internal/device"
+	"xreal-light-xr-go/messages"
+
+	godbus "github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+const (
+	BusName       = "org.xreal.Glasses"
+	ObjectPath    = godbus.ObjectPath("/org/xreal/Glasses")
+	InterfaceName = "org.xreal.Glasses"
+)
+
+const (
+	WEAR_STATUS_UNKNOWN  = "UNKNOWN"
+	WEAR_STATUS_WORN     = "WORN"
+	WEAR_STATUS_NOT_WORN = "NOT_WORN"
+)
+
+const introspectXML = `
+<node>
+	<interface name="` + InterfaceName + `">
+		<method name="GetBrightness">
+			<arg name="level" direction="out" type="s"/>
+		</method>
+		<method name="SetBrightness">
+			<arg name="level" direction="in" type="s"/>
+		</method>
+		<method name="GetDisplayMode">
+			<arg name="mode" direction="out" type="s"/>
+		</method>
+		<method name="SetDisplayMode">
+			<arg name="mode" direction="in" type="s"/>
+		</method>
+		<method name="GetConfigValue">
+			<arg name="key" direction="in" type="s"/>
+			<arg name="value" direction="out" type="s"/>
+		</method>
+		<method name="SetConfigValue">
+			<arg name="key" direction="in" type="s"/>
+			<arg name="value" direction="in" type="s"/>
+		</method>
+		<method name="GetWearStatus">
+			<arg name="status" direction="out" type="s"/>
+		</method>
+		<signal name="KeyPressed">
+			<arg name="key" type="s"/>
+		</signal>
+		<signal name="ProximityChanged">
+			<arg name="proximity" type="s"/>
+		</signal>
+		<signal name="AmbientLightChanged">
+			<arg name="light" type="q"/>
+		</signal>
+	</interface>` + introspect.IntrospectDataString + `
+</node>`
+
+// Service owns the org.xreal.Glasses bus name for a connected glass.
+type Service struct {
+	conn   *godbus.Conn
+	device device.Device
+	object *glassesObject
+}
+
+// glassesObject is exported on the bus, every exported method of it becomes a D-Bus method.
+type glassesObject struct {
+	conn *godbus.Conn
+
+	// mutex for thread safety
+	mutex sync.Mutex
+	// controller runs the D-Bus methods, it is replaced rather than changed once exported, see getController
+	controller *controller.Controller
+	// wearStatus is derived from the latest proximity event
+	wearStatus string
+	// brightnessSync is set when the brightness level follows an ambient light source
+	brightnessSync *brightnessSync
+	// gamepad is set when key events are passed through to a virtual gamepad
+	gamepad *gamepad
+}
+
+// Start exports the glass on the session bus and forwards its key, proximity and ambient light events as signals,
+// and key events to the virtual gamepad if set, see SetGamepad.
+// Note that it replaces the key, proximity and ambient light event handlers of the device,
+// with proximity and ambient light events passed through the given filters.
+func Start(d device.Device) (*Service, error) {
+	conn, err := godbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to session bus: %w", err)
+	}
+
+	object := &glassesObject{
+		conn:       conn,
+		controller: controller.New(d),
+		wearStatus: WEAR_STATUS_UNKNOWN,
+	}
+
+	if err := conn.Export(object, ObjectPath, InterfaceName); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to export %s: %w", InterfaceName, err)
+	}
+	if err := conn.Export(introspect.Introspectable(introspectXML), ObjectPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to export introspection data: %w", err)
+	}
+
+	reply, err := conn.RequestName(BusName, godbus.NameFlagDoNotQueue)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to request name %s: %w", BusName, err)
+	}
+	if reply != godbus.RequestNameReplyPrimaryOwner {
+		conn.Close()
+		return nil, fmt.Errorf("name %s already taken", BusName)
+	}
+
+	d.SetKeyEventHandler(object.emitKeyPressed)
+	d.SetProximityEventHandler(object.emitProximityChanged)
+	d.SetAmbientLightEventHandler(object.emitAmbientLightChanged)
+
+	return &Service{conn: conn, device: d, object: object}, nil
+}
+
+// SetAuditLog records the set commands sent over D-Bus, including brightness sync, to auditLog.
+// It should be called before SetBrightnessSource.
+func (s *Service) SetAuditLog(auditLog *controller.AuditLog) {
+	s.object.mutex.Lock()
+	defer s.object.mutex.Unlock()
+
+	replaced := *s.object.controller
+	s.object.controller = replaced.WithAuditLog(auditLog, controller.INITIATOR_DBUS)
+}
+
+// SetStateStore persists the set commands sent over D-Bus, including brightness sync, to stateStore.
+// It should be called before SetBrightnessSource.
+func (s *Service) SetStateStore(stateStore *controller.StateStore) {
+	s.object.mutex.Lock()
+	defer s.object.mutex.Unlock()
+
+	replaced := *s.object.controller
+	s.object.controller = replaced.WithStateStore(stateStore)
+}
+
+// SetBrightnessSource selects which ambient light source drives the brightness level of the glass.
+// BRIGHTNESS_SOURCE_GLASSES enables ambient light reporting of the glass, and BRIGHTNESS_SOURCE_HOST
+// claims the host light sensor from iio-sensor-proxy on the system bus.
+func (s *Service) SetBrightnessSource(source BrightnessSource) error {
+	s.object.mutex.Lock()
+	previous := s.object.brightnessSync
+	s.object.brightnessSync = nil
+	s.object.mutex.Unlock()
+
+	if previous != nil {
+		previous.stop()
+		if previous.source == BRIGHTNESS_SOURCE_GLASSES && source != BRIGHTNESS_SOURCE_GLASSES {
+			if err := s.device.EnableAmbientLight(false); err != nil {
+				slog.Debug(fmt.Sprintf("failed to disable ambient light event reporting: %v", err))
+			}
+		}
+	}
+
+	brightness := &brightnessSync{controller: s.object.getController(), source: source}
+
+	switch source {
+	case BRIGHTNESS_SOURCE_NONE:
+		return nil
+	case BRIGHTNESS_SOURCE_GLASSES:
+		if err := s.device.EnableAmbientLight(true); err != nil {
+			return fmt.Errorf("failed to enable ambient light event reporting: %w", err)
+		}
+	case BRIGHTNESS_SOURCE_HOST:
+		if err := brightness.startHostAmbientLight(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid brightness source: got (%s) want one of (%v)", source, SupportedBrightnessSource)
+	}
+
+	s.object.mutex.Lock()
+	s.object.brightnessSync = brightness
+	s.object.mutex.Unlock()
+
+	return nil
+}
+
+// SetGamepad passes key events of the glass through to a virtual gamepad with the given mapping, so applications
+// expecting controller input can be driven by the keys of the glass. A nil mapping removes the gamepad.
+// It needs read/write access to /dev/uhid and is only supported on Linux.
+func (s *Service) SetGamepad(mapping GamepadMapping) error {
+	s.object.mutex.Lock()
+	previous := s.object.gamepad
+	s.object.gamepad = nil
+	s.object.mutex.Unlock()
+
+	if previous != nil {
+		if err := previous.stop(); err != nil {
+			slog.Debug(fmt.Sprintf("failed to remove virtual gamepad: %v", err))
+		}
+	}
+	if mapping == nil {
+		return nil
+	}
+
+	gamepad, err := createGamepad(mapping)
+	if err != nil {
+		return err
+	}
+
+	s.object.mutex.Lock()
+	s.object.gamepad = gamepad
+	s.object.mutex.Unlock()
+
+	return nil
+}
+
+// Stop removes the virtual gamepad if any, releases the bus name and closes the connection.
+func (s *Service) Stop() error {
+	s.object.mutex.Lock()
+	brightness := s.object.brightnessSync
+	s.object.brightnessSync = nil
+	gamepad := s.object.gamepad
+	s.object.gamepad = nil
+	s.object.mutex.Unlock()
+
+	if brightness != nil {
+		brightness.stop()
+	}
+	if gamepad != nil {
+		if err := gamepad.stop(); err != nil {
+			slog.Debug(fmt.Sprintf("failed to remove virtual gamepad: %v", err))
+		}
+	}
+
+	if _, err := s.conn.ReleaseName(BusName); err != nil {
+		slog.Debug(fmt.Sprintf("failed to release name %s: %v", BusName, err))
+	}
+	return s.conn.Close()
+}
+
+// getController returns the controller as of now, the mutex is not held while it runs, as commands wait for the glass
+// whose events need the mutex meanwhile.
+func (o *glassesObject) getController() *controller.Controller {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.controller
+}
+
+// ErrorName returns the D-Bus error name of an error code, e.g. org.xreal.Glasses.Error.XR_013 for XR-013, as D-Bus
+// names cannot contain dashes.
+func ErrorName(code device.ErrorCode) string {
+	return InterfaceName + ".Error." + strings.ReplaceAll(string(code), "-", "_")
+}
+
+// failed returns err as a D-Bus error named after its code, with the message xrealxr would print as the body.
+func failed(err error) *godbus.Error {
+	return godbus.NewError(ErrorName(messages.CodeOf(err)), []interface{}{messages.Error(err)})
+}
+
+func (o *glassesObject) GetBrightness() (string, *godbus.Error) {
+	result, err := o.getController().Get("brightness", nil)
+	if err != nil {
+		return "", failed(err)
+	}
+	return result.Value, nil
+}
+
+func (o *glassesObject) SetBrightness(level string) *godbus.Error {
+	if _, err := o.getController().Set("brightness", []string{level}); err != nil {
+		return failed(err)
+	}
+	return nil
+}
+
+func (o *glassesObject) GetDisplayMode() (string, *godbus.Error) {
+	result, err := o.getController().Get("displaymode", nil)
+	if err != nil {
+		return "", failed(err)
+	}
+	return result.Value, nil
+}
+
+func (o *glassesObject) SetDisplayMode(mode string) *godbus.Error {
+	if _, err := o.getController().Set("displaymode", []string{mode}); err != nil {
+		return failed(err)
+	}
+	return nil
+}
+
+func (o *glassesObject) GetConfigValue(key string) (string, *godbus.Error) {
+	result, err := o.getController().Get("config", []string{key})
+	if err != nil {
+		return "", failed(err)
+	}
+	return result.Value, nil
+}
+
+func (o *glassesObject) SetConfigValue(key string, value string) *godbus.Error {
+	if _, err := o.getController().Set("config", []string{key, value}); err != nil {
+		return failed(err)
+	}
+	return nil
+}
+
+func (o *glassesObject) GetWearStatus() (string, *godbus.Error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.wearStatus, nil
+}
+
+func (o *glassesObject) emitKeyPressed(key device.KeyEvent) {
+	o.mutex.Lock()
+	gamepad := o.gamepad
+	o.mutex.Unlock()
+
+	if gamepad != nil {
+		gamepad.press(key)
+	}
+
+	if err := o.conn.Emit(ObjectPath, InterfaceName+".KeyPressed", key.String()); err != nil {
+		slog.Debug(fmt.Sprintf("failed to emit KeyPressed: %v", err))
+	}
+}
+
+func (o *glassesObject) emitProximityChanged(proximity device.ProximityEvent) {
+	o.mutex.Lock()
+	switch proximity {
+	case device.PROXIMITY_NEAR:
+		o.wearStatus = WEAR_STATUS_WORN
+	case device.PROXIMITY_FAR:
+		o.wearStatus = WEAR_STATUS_NOT_WORN
+	default:
+		o.wearStatus = WEAR_STATUS_UNKNOWN
+	}
+	o.mutex.Unlock()
+
+	if err := o.conn.Emit(ObjectPath, InterfaceName+".ProximityChanged", proximity.String()); err != nil {
+		slog.Debug(fmt.Sprintf("failed to emit ProximityChanged: %v", err))
+	}
+}
+
+func (o *glassesObject) emitAmbientLightChanged(light uint16) {
+	o.mutex.Lock()
+	brightness := o.brightnessSync
+	o.mutex.Unlock()
+
+	if brightness != nil && brightness.source == BRIGHTNESS_SOURCE_GLASSES {
+		brightness.onGlassAmbientLight(light)
+	}
+
+	if err := o.conn.Emit(ObjectPath, InterfaceName+".AmbientLightChanged", light); err != nil {
+		slog.Debug(fmt.Sprintf("failed to emit AmbientLightChanged: %v", err))
+	}
+}
@@ -0,0 +1,125 @@
+package dbus
+
+import (
+	"bufio"
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+
+	"xreal-light-xr-go/internal/device"
+
+	godbus "github.com/godbus/dbus/v5"
+)
+
+// configDevice keeps config values in memory.
+type configDevice struct {
+	device.UnimplementedDevice
+
+	// mutex for thread safety
+	mutex  sync.Mutex
+	values map[string]string
+}
+
+func (d *configDevice) Name() string      { return "config device" }
+func (d *configDevice) PID() uint16       { return 0 }
+func (d *configDevice) VID() uint16       { return 0 }
+func (d *configDevice) Connect() error    { return nil }
+func (d *configDevice) Disconnect() error { return nil }
+
+func (d *configDevice) GetConfigValue(key string) (string, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.values[key], nil
+}
+
+func (d *configDevice) SetConfigValue(key string, value string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.values[key] = value
+	return nil
+}
+
+// startSessionBus runs a private session bus for the test, skipping it if dbus-daemon is not installed.
+func startSessionBus(t *testing.T) {
+	path, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skipf("dbus-daemon not found: %v", err)
+	}
+	daemon := exec.Command(path, "--session", "--nofork", "--print-address")
+	stdout, err := daemon.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := daemon.Start(); err != nil {
+		t.Fatalf("failed to start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		daemon.Process.Kill()
+		daemon.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read the bus address: %v", err)
+	}
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", strings.TrimSpace(address))
+}
+
+func TestConfigValueRoundTrip(t *testing.T) {
+	startSessionBus(t)
+
+	service, err := Start(&configDevice{values: map[string]string{}})
+	if err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	defer service.Stop()
+
+	conn, err := godbus.ConnectSessionBus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	glasses := conn.Object(BusName, ObjectPath)
+
+	if err := glasses.Call(InterfaceName+".SetConfigValue", 0, "sleeptime", "60").Err; err != nil {
+		t.Fatalf("SetConfigValue() = %v", err)
+	}
+	var value string
+	if err := glasses.Call(InterfaceName+".GetConfigValue", 0, "sleeptime").Store(&value); err != nil {
+		t.Fatalf("GetConfigValue() = %v", err)
+	}
+	if value != "60" {
+		t.Errorf("GetConfigValue() = %s, want 60", value)
+	}
+}
+
+func TestErrorNames(t *testing.T) {
+	startSessionBus(t)
+
+	service, err := Start(&configDevice{values: map[string]string{}})
+	if err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	defer service.Stop()
+
+	conn, err := godbus.ConnectSessionBus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	glasses := conn.Object(BusName, ObjectPath)
+
+	err = glasses.Call(InterfaceName+".SetDisplayMode", 0, "SIDEWAYS").Err
+	var dbusErr godbus.Error
+	if !errors.As(err, &dbusErr) {
+		t.Fatalf("SetDisplayMode(SIDEWAYS) = %v, want a D-Bus error", err)
+	}
+	if want := ErrorName("XR-102"); dbusErr.Name != want {
+		t.Errorf("SetDisplayMode(SIDEWAYS) error name = %s, want %s", dbusErr.Name, want)
+	}
+	if body, ok := dbusErr.Body[0].(string); !ok || !strings.HasPrefix(body, "XR-102: ") {
+		t.Errorf("SetDisplayMode(SIDEWAYS) error body = %v, want the message with its code", dbusErr.Body)
+	}
+}
@@ -0,0 +1,510 @@
+// Package eventpb encodes glass events in the Protocol Buffers wire format of events.proto, so the network outputs
+// and recordings share one compact, versioned format that any protobuf library can decode, e.g. after
+// `protoc --python_out=. events.proto`. The encoding is written by hand to keep the module free of the protobuf
+// runtime, which only covers the messages of events.proto.
+package eventpb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/internal/device"
+)
+
+// SCHEMA_VERSION is sent in every event, it is bumped on changes of events.proto consumers must handle; added fields
+// are skipped by older consumers and do not bump it.
+const SCHEMA_VERSION = 1
+
+// MAX_EVENT_SIZE bounds the length ReadDelimited accepts, events are far smaller.
+const MAX_EVENT_SIZE = 64 * 1024
+
+const (
+	CAMERA_SLAM = "slam"
+	CAMERA_RGB  = "rgb"
+)
+
+// field numbers of events.proto
+const (
+	eventVersion      = 1
+	eventTimestamp    = 2
+	eventIMU          = 10
+	eventMagnetometer = 11
+	eventKey          = 12
+	eventProximity    = 13
+	eventVSync        = 14
+	eventTemperature  = 15
+	eventAmbientLight = 16
+	eventFrame        = 17
+	eventOrientation  = 18
+)
+
+// wire types of the protobuf encoding
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("truncated event")
+
+// FrameMetadata describes a camera frame without its pixels.
+type FrameMetadata struct {
+	// Camera is CAMERA_SLAM or CAMERA_RGB
+	Camera string
+	Width  uint32
+	Height uint32
+}
+
+// Event is the Event message of events.proto, exactly one of the payloads is set.
+type Event struct {
+	// Version is the SCHEMA_VERSION of the sender, it is filled when decoding and ignored when encoding
+	Version uint32
+	// Timestamp is the host time the event was received at
+	Timestamp time.Time
+
+	IMU          *device.IMUEvent
+	Magnetometer *device.MagnetometerVector
+	Key          *device.KeyEvent
+	Proximity    *device.ProximityEvent
+	// VSync and Temperature are the raw payloads as reported by the glass
+	VSync        *string
+	Temperature  *string
+	AmbientLight *uint16
+	Frame        *FrameMetadata
+	Orientation  *fusion.Attitude
+}
+
+func NewIMUEvent(timestamp time.Time, imu *device.IMUEvent) *Event {
+	return &Event{Timestamp: timestamp, IMU: imu}
+}
+
+func NewMagnetometerEvent(timestamp time.Time, vector *device.MagnetometerVector) *Event {
+	return &Event{Timestamp: timestamp, Magnetometer: vector}
+}
+
+func NewKeyEvent(timestamp time.Time, key device.KeyEvent) *Event {
+	return &Event{Timestamp: timestamp, Key: &key}
+}
+
+func NewProximityEvent(timestamp time.Time, proximity device.ProximityEvent) *Event {
+	return &Event{Timestamp: timestamp, Proximity: &proximity}
+}
+
+func NewVSyncEvent(timestamp time.Time, vsync string) *Event {
+	return &Event{Timestamp: timestamp, VSync: &vsync}
+}
+
+func NewTemperatureEvent(timestamp time.Time, temperature string) *Event {
+	return &Event{Timestamp: timestamp, Temperature: &temperature}
+}
+
+func NewAmbientLightEvent(timestamp time.Time, ambientLight uint16) *Event {
+	return &Event{Timestamp: timestamp, AmbientLight: &ambientLight}
+}
+
+func NewFrameEvent(timestamp time.Time, frame FrameMetadata) *Event {
+	return &Event{Timestamp: timestamp, Frame: &frame}
+}
+
+func NewOrientationEvent(timestamp time.Time, attitude fusion.Attitude) *Event {
+	return &Event{Timestamp: timestamp, Orientation: &attitude}
+}
+
+// payloads counts the payloads set, which must be one to encode the event.
+func (e *Event) payloads() int {
+	count := 0
+	for _, set := range []bool{
+		e.IMU != nil, e.Magnetometer != nil, e.Key != nil, e.Proximity != nil, e.VSync != nil,
+		e.Temperature != nil, e.AmbientLight != nil, e.Frame != nil, e.Orientation != nil,
+	} {
+		if set {
+			count++
+		}
+	}
+	return count
+}
+
+// MarshalBinary encodes the event as the Event message of events.proto.
+func (e *Event) MarshalBinary() ([]byte, error) {
+	if count := e.payloads(); count != 1 {
+		return nil, fmt.Errorf("event must have exactly one payload, got %d", count)
+	}
+
+	b := appendVarintField(nil, eventVersion, SCHEMA_VERSION)
+	if !e.Timestamp.IsZero() {
+		b = appendVarintField(b, eventTimestamp, uint64(e.Timestamp.UnixNano()))
+	}
+
+	// payloads are members of a oneof, so they are written even if zero
+	switch {
+	case e.IMU != nil:
+		b = appendMessageField(b, eventIMU, marshalIMU(e.IMU))
+	case e.Magnetometer != nil:
+		b = appendMessageField(b, eventMagnetometer, marshalMagnetometer(e.Magnetometer))
+	case e.Key != nil:
+		b = appendTag(b, eventKey, wireVarint)
+		b = binary.AppendUvarint(b, uint64(*e.Key))
+	case e.Proximity != nil:
+		b = appendTag(b, eventProximity, wireVarint)
+		b = binary.AppendUvarint(b, uint64(*e.Proximity))
+	case e.VSync != nil:
+		b = appendMessageField(b, eventVSync, []byte(*e.VSync))
+	case e.Temperature != nil:
+		b = appendMessageField(b, eventTemperature, []byte(*e.Temperature))
+	case e.AmbientLight != nil:
+		b = appendTag(b, eventAmbientLight, wireVarint)
+		b = binary.AppendUvarint(b, uint64(*e.AmbientLight))
+	case e.Frame != nil:
+		var frame []byte
+		frame = appendStringField(frame, 1, e.Frame.Camera)
+		frame = appendVarintField(frame, 2, uint64(e.Frame.Width))
+		frame = appendVarintField(frame, 3, uint64(e.Frame.Height))
+		b = appendMessageField(b, eventFrame, frame)
+	case e.Orientation != nil:
+		var orientation []byte
+		orientation = appendDoubleField(orientation, 1, e.Orientation.Roll)
+		orientation = appendDoubleField(orientation, 2, e.Orientation.Pitch)
+		orientation = appendDoubleField(orientation, 3, e.Orientation.Yaw)
+		b = appendMessageField(b, eventOrientation, orientation)
+	}
+	return b, nil
+}
+
+func marshalIMU(imu *device.IMUEvent) []byte {
+	var b []byte
+	if imu.Gyroscope != nil {
+		b = appendMessageField(b, 1, marshalVector3f(imu.Gyroscope.X, imu.Gyroscope.Y, imu.Gyroscope.Z))
+	}
+	if imu.Accelerometer != nil {
+		b = appendMessageField(b, 2, marshalVector3f(imu.Accelerometer.X, imu.Accelerometer.Y, imu.Accelerometer.Z))
+	}
+	return appendVarintField(b, 3, imu.TimeSinceBoot)
+}
+
+func marshalMagnetometer(vector *device.MagnetometerVector) []byte {
+	var reading []byte
+	reading = appendDoubleField(reading, 1, vector.X)
+	reading = appendDoubleField(reading, 2, vector.Y)
+	reading = appendDoubleField(reading, 3, vector.Z)
+
+	b := appendMessageField(nil, 1, reading)
+	b = appendVarintField(b, 2, zigzag(int32(vector.RawX)))
+	b = appendVarintField(b, 3, zigzag(int32(vector.RawY)))
+	b = appendVarintField(b, 4, zigzag(int32(vector.RawZ)))
+	b = appendVarintField(b, 5, vector.DeviceTimestamp)
+	if vector.Valid {
+		b = appendVarintField(b, 6, 1)
+	}
+	return b
+}
+
+func marshalVector3f(x float32, y float32, z float32) []byte {
+	var b []byte
+	b = appendFloatField(b, 1, x)
+	b = appendFloatField(b, 2, y)
+	return appendFloatField(b, 3, z)
+}
+
+// UnmarshalBinary decodes an Event message, skipping fields unknown to this version of events.proto.
+func (e *Event) UnmarshalBinary(data []byte) error {
+	*e = Event{}
+	err := forEachField(data, func(field int, value uint64, bytes []byte) error {
+		switch field {
+		case eventVersion:
+			e.Version = uint32(value)
+		case eventTimestamp:
+			e.Timestamp = time.Unix(0, int64(value))
+		case eventIMU:
+			imu, err := unmarshalIMU(bytes)
+			if err != nil {
+				return err
+			}
+			e.clearPayload()
+			e.IMU = imu
+		case eventMagnetometer:
+			vector, err := unmarshalMagnetometer(bytes)
+			if err != nil {
+				return err
+			}
+			e.clearPayload()
+			e.Magnetometer = vector
+		case eventKey:
+			key := device.KeyEvent(value)
+			e.clearPayload()
+			e.Key = &key
+		case eventProximity:
+			proximity := device.ProximityEvent(value)
+			e.clearPayload()
+			e.Proximity = &proximity
+		case eventVSync:
+			vsync := string(bytes)
+			e.clearPayload()
+			e.VSync = &vsync
+		case eventTemperature:
+			temperature := string(bytes)
+			e.clearPayload()
+			e.Temperature = &temperature
+		case eventAmbientLight:
+			ambientLight := uint16(value)
+			e.clearPayload()
+			e.AmbientLight = &ambientLight
+		case eventFrame:
+			frame := &FrameMetadata{}
+			err := forEachField(bytes, func(field int, value uint64, bytes []byte) error {
+				switch field {
+				case 1:
+					frame.Camera = string(bytes)
+				case 2:
+					frame.Width = uint32(value)
+				case 3:
+					frame.Height = uint32(value)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			e.clearPayload()
+			e.Frame = frame
+		case eventOrientation:
+			x, y, z, err := unmarshalVector3d(bytes)
+			if err != nil {
+				return err
+			}
+			e.clearPayload()
+			e.Orientation = &fusion.Attitude{Roll: x, Pitch: y, Yaw: z}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to decode event: %w", err)
+	}
+	if e.payloads() == 0 {
+		return fmt.Errorf("failed to decode event: no payload known to schema version %d", SCHEMA_VERSION)
+	}
+	return nil
+}
+
+// clearPayload drops the payload decoded before, as the last member of a oneof wins.
+func (e *Event) clearPayload() {
+	*e = Event{Version: e.Version, Timestamp: e.Timestamp}
+}
+
+func unmarshalIMU(data []byte) (*device.IMUEvent, error) {
+	imu := &device.IMUEvent{}
+	err := forEachField(data, func(field int, value uint64, bytes []byte) error {
+		switch field {
+		case 1:
+			x, y, z, err := unmarshalVector3f(bytes)
+			if err != nil {
+				return err
+			}
+			imu.Gyroscope = &device.GyroscopeVector{X: x, Y: y, Z: z}
+		case 2:
+			x, y, z, err := unmarshalVector3f(bytes)
+			if err != nil {
+				return err
+			}
+			imu.Accelerometer = &device.AccelerometerVector{X: x, Y: y, Z: z}
+		case 3:
+			imu.TimeSinceBoot = value
+		}
+		return nil
+	})
+	return imu, err
+}
+
+func unmarshalMagnetometer(data []byte) (*device.MagnetometerVector, error) {
+	vector := &device.MagnetometerVector{}
+	err := forEachField(data, func(field int, value uint64, bytes []byte) error {
+		switch field {
+		case 1:
+			x, y, z, err := unmarshalVector3d(bytes)
+			if err != nil {
+				return err
+			}
+			vector.X, vector.Y, vector.Z = x, y, z
+		case 2:
+			vector.RawX = int(unzigzag(value))
+		case 3:
+			vector.RawY = int(unzigzag(value))
+		case 4:
+			vector.RawZ = int(unzigzag(value))
+		case 5:
+			vector.DeviceTimestamp = value
+		case 6:
+			vector.Valid = value != 0
+		}
+		return nil
+	})
+	return vector, err
+}
+
+func unmarshalVector3f(data []byte) (x float32, y float32, z float32, err error) {
+	err = forEachField(data, func(field int, value uint64, bytes []byte) error {
+		switch field {
+		case 1:
+			x = math.Float32frombits(uint32(value))
+		case 2:
+			y = math.Float32frombits(uint32(value))
+		case 3:
+			z = math.Float32frombits(uint32(value))
+		}
+		return nil
+	})
+	return x, y, z, err
+}
+
+func unmarshalVector3d(data []byte) (x float64, y float64, z float64, err error) {
+	err = forEachField(data, func(field int, value uint64, bytes []byte) error {
+		switch field {
+		case 1:
+			x = math.Float64frombits(value)
+		case 2:
+			y = math.Float64frombits(value)
+		case 3:
+			z = math.Float64frombits(value)
+		}
+		return nil
+	})
+	return x, y, z, err
+}
+
+// WriteDelimited writes the event prefixed by its length as varint, the framing protobuf libraries call delimited,
+// e.g. to record events to a file or send several over a stream.
+func WriteDelimited(w io.Writer, event *Event) error {
+	payload, err := event.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(append(binary.AppendUvarint(nil, uint64(len(payload))), payload...)); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
+
+// ReadDelimited reads an event written by WriteDelimited, returning io.EOF once the reader is exhausted.
+func ReadDelimited(r *bufio.Reader) (*Event, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read event length: %w", err)
+	}
+	if length > MAX_EVENT_SIZE {
+		return nil, fmt.Errorf("failed to read event: length %d exceeds %d bytes", length, MAX_EVENT_SIZE)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("failed to read event: %w", err)
+	}
+	event := &Event{}
+	if err := event.UnmarshalBinary(payload); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// forEachField calls handler with the value of every varint and fixed field, fixed ones as their bits, and the bytes
+// of every length delimited one.
+func forEachField(data []byte, handler func(field int, value uint64, bytes []byte) error) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errTruncated
+		}
+		data = data[n:]
+
+		var value uint64
+		var bytes []byte
+		switch tag & 0x7 {
+		case wireVarint:
+			value, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errTruncated
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return errTruncated
+			}
+			value = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case wireFixed32:
+			if len(data) < 4 {
+				return errTruncated
+			}
+			value = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return errTruncated
+			}
+			bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		default:
+			return fmt.Errorf("unsupported wire type %d", tag&0x7)
+		}
+
+		if err := handler(int(tag>>3), value, bytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendTag(b []byte, field int, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+// appendVarintField omits zero values like proto3 does for fields outside a oneof.
+func appendVarintField(b []byte, field int, value uint64) []byte {
+	if value == 0 {
+		return b
+	}
+	return binary.AppendUvarint(appendTag(b, field, wireVarint), value)
+}
+
+func appendFloatField(b []byte, field int, value float32) []byte {
+	if value == 0 && !math.Signbit(float64(value)) {
+		return b
+	}
+	return binary.LittleEndian.AppendUint32(appendTag(b, field, wireFixed32), math.Float32bits(value))
+}
+
+func appendDoubleField(b []byte, field int, value float64) []byte {
+	if value == 0 && !math.Signbit(value) {
+		return b
+	}
+	return binary.LittleEndian.AppendUint64(appendTag(b, field, wireFixed64), math.Float64bits(value))
+}
+
+func appendStringField(b []byte, field int, value string) []byte {
+	if value == "" {
+		return b
+	}
+	return appendMessageField(b, field, []byte(value))
+}
+
+// appendMessageField writes length delimited bytes, also for strings, even if empty.
+func appendMessageField(b []byte, field int, value []byte) []byte {
+	b = binary.AppendUvarint(appendTag(b, field, wireBytes), uint64(len(value)))
+	return append(b, value...)
+}
+
+func zigzag(value int32) uint64 {
+	return uint64(uint32((value << 1) ^ (value >> 31)))
+}
+
+func unzigzag(value uint64) int32 {
+	return int32(uint32(value)>>1) ^ -int32(uint32(value)&1)
+}
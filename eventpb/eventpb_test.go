@@ -0,0 +1,95 @@
+package eventpb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	// encoded by hand following events.proto: version 1, timestamp 1, key KEY_UP_PRESSED
+	got, err := NewKeyEvent(time.Unix(0, 1), device.KEY_UP_PRESSED).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() failed: %v", err)
+	}
+	if want := "080110016001"; hex.EncodeToString(got) != want {
+		t.Errorf("MarshalBinary() = %x, want %s", got, want)
+	}
+
+	// a oneof member is sent even if zero, sint32 raw readings are zigzag encoded
+	got, _ = NewMagnetometerEvent(time.Time{}, &device.MagnetometerVector{RawX: -1, RawY: 1}).MarshalBinary()
+	if want := "08015a060a0010011802"; hex.EncodeToString(got) != want {
+		t.Errorf("MarshalBinary() = %x, want %s", got, want)
+	}
+
+	if _, err := (&Event{}).MarshalBinary(); err == nil {
+		t.Errorf("MarshalBinary() without payload succeeded, want error")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	timestamp := time.Unix(1720000000, 123456789)
+	events := []*Event{
+		NewIMUEvent(timestamp, &device.IMUEvent{
+			Accelerometer: &device.AccelerometerVector{X: 0.1, Y: -9.81, Z: 0.2},
+			Gyroscope:     &device.GyroscopeVector{X: -0.01, Y: 0.02, Z: 0},
+			TimeSinceBoot: 123456,
+		}),
+		NewMagnetometerEvent(timestamp, &device.MagnetometerVector{X: 12.5, Y: -30, Z: 41.25, RawX: -125, RawY: 300, RawZ: 4125, DeviceTimestamp: 99, Valid: true}),
+		NewKeyEvent(timestamp, device.KEY_DOWN_PRESSED),
+		NewProximityEvent(timestamp, device.PROXIMITY_UKNOWN),
+		NewVSyncEvent(timestamp, "1234"),
+		NewTemperatureEvent(timestamp, ""),
+		NewAmbientLightEvent(timestamp, 512),
+		NewFrameEvent(timestamp, FrameMetadata{Camera: CAMERA_SLAM, Width: 640, Height: 480}),
+		NewOrientationEvent(timestamp, fusion.Attitude{Roll: 0.1, Pitch: -0.2, Yaw: 3.1}),
+	}
+
+	var buffer bytes.Buffer
+	for _, event := range events {
+		if err := WriteDelimited(&buffer, event); err != nil {
+			t.Fatalf("WriteDelimited() failed: %v", err)
+		}
+	}
+
+	reader := bufio.NewReader(&buffer)
+	for _, want := range events {
+		got, err := ReadDelimited(reader)
+		if err != nil {
+			t.Fatalf("ReadDelimited() failed: %v", err)
+		}
+		if got.Version != SCHEMA_VERSION || !got.Timestamp.Equal(want.Timestamp) {
+			t.Errorf("ReadDelimited() = version %d at %v, want %d at %v", got.Version, got.Timestamp, SCHEMA_VERSION, want.Timestamp)
+		}
+		got.Version, got.Timestamp = 0, want.Timestamp
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadDelimited() = %+v, want %+v", got, want)
+		}
+	}
+	if _, err := ReadDelimited(reader); err != io.EOF {
+		t.Errorf("ReadDelimited() at the end = %v, want io.EOF", err)
+	}
+}
+
+func TestUnmarshalBinarySkipsUnknownFields(t *testing.T) {
+	// an event of a later schema version: unknown field 3 as varint, fixed32 and bytes before the key
+	data, _ := hex.DecodeString("0802" + "1805" + "1d01020304" + "1a03616263" + "6002")
+	event := &Event{}
+	if err := event.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() failed: %v", err)
+	}
+	if event.Version != 2 || event.Key == nil || *event.Key != device.KEY_DOWN_PRESSED {
+		t.Errorf("UnmarshalBinary() = %+v, want version 2 with key DOWN", event)
+	}
+
+	if err := event.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Errorf("UnmarshalBinary() of a truncated event succeeded, want error")
+	}
+}
@@ -0,0 +1,108 @@
+// android-bridge forwards the IMU, magnetometer, key, proximity and ambient light events and the fused head
+// orientation of the first attached XREAL Light to a companion app on an Android phone, over adb forward or the
+// Android Open Accessory protocol. The stream format is described in package android.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"xreal-light-xr-go/android"
+	"xreal-light-xr-go/eventpb"
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/pkg/xreal"
+)
+
+func main() {
+	transport := flag.String("transport", "adb", "how to reach the phone, adb or aoa")
+	serial := flag.String("serial", "", "with adb, the phone as listed by `adb devices` when several are attached")
+	port := flag.Int("port", android.DEFAULT_ADB_LOCAL_PORT, "with adb, the host port forwarded to the phone")
+	remote := flag.String("remote", android.DEFAULT_ADB_REMOTE, "with adb, the socket the app listens on")
+	phone := flag.String("phone", "", "with aoa, the vendor:product ID of the phone as listed by lsusb")
+	rate := flag.Int("rate", 60, "orientation events per second")
+	flag.Parse()
+
+	var bridge *android.Bridge
+	var err error
+	switch *transport {
+	case "adb":
+		bridge, err = android.NewADBBridge(android.ADBOptions{Serial: *serial, LocalPort: *port, Remote: *remote})
+	case "aoa":
+		var options android.AOAOptions
+		if _, err = fmt.Sscanf(*phone, "%x:%x", &options.VendorID, &options.ProductID); err != nil {
+			err = fmt.Errorf("invalid phone %q, want vendor:product ID: %w", *phone, err)
+			break
+		}
+		bridge, err = android.NewAOABridge(options)
+	default:
+		err = errors.New("invalid transport " + *transport + ": want adb or aoa")
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer bridge.Close()
+
+	// events are dropped while the app is not connected, the bridge logs when it comes and goes
+	send := func(event *eventpb.Event) {
+		if err := bridge.Send(event); err != nil && !errors.Is(err, android.ErrNotConnected) {
+			log.Print(err)
+		}
+	}
+
+	glass := xreal.NewLight(nil, nil)
+	if err := glass.Connect(); err != nil {
+		log.Fatalf("failed to connect: %v", err)
+	}
+	defer glass.Disconnect()
+
+	filter := fusion.NewComplementaryFilter(fusion.DEFAULT_GYROSCOPE_WEIGHT)
+	glass.SetIMUEventHandler(func(imu *xreal.IMUEvent) {
+		filter.Update(imu)
+		send(eventpb.NewIMUEvent(time.Now(), imu))
+	})
+	glass.SetMagnetometerEventHandler(func(vector *xreal.MagnetometerVector) {
+		send(eventpb.NewMagnetometerEvent(time.Now(), vector))
+	})
+	glass.SetKeyEventHandler(func(key xreal.KeyEvent) {
+		send(eventpb.NewKeyEvent(time.Now(), key))
+	})
+	glass.SetProximityEventHandler(func(proximity xreal.ProximityEvent) {
+		send(eventpb.NewProximityEvent(time.Now(), proximity))
+	})
+	glass.SetAmbientLightEventHandler(func(light uint16) {
+		send(eventpb.NewAmbientLightEvent(time.Now(), light))
+	})
+
+	if err := glass.EnableIMU(true); err != nil {
+		log.Fatalf("failed to enable IMU stream: %v", err)
+	}
+	defer glass.EnableIMU(false)
+	if err := glass.EnableMagnetometer(true); err != nil {
+		log.Fatalf("failed to enable magnetometer: %v", err)
+	}
+	defer glass.EnableMagnetometer(false)
+	if err := glass.EnableAmbientLight(true); err != nil {
+		log.Fatalf("failed to enable ambient light: %v", err)
+	}
+	defer glass.EnableAmbientLight(false)
+
+	log.Printf("forwarding events to the Android companion over %s, press Ctrl+C to stop", *transport)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	ticker := time.NewTicker(time.Second / time.Duration(max(*rate, 1)))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			send(eventpb.NewOrientationEvent(time.Now(), filter.Attitude()))
+		case <-interrupt:
+			return
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// auto-brightness follows the ambient light sensor of the first attached XREAL Light to set its brightness level.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+
+	"xreal-light-xr-go/pkg/xreal"
+)
+
+func main() {
+	maxLight := flag.Uint("max", 1000, "ambient light reading mapped to the max brightness level 7")
+	window := flag.Int("window", 5, "number of ambient light readings to average")
+	flag.Parse()
+
+	glass := xreal.NewLight(nil, nil)
+	if err := glass.Connect(); err != nil {
+		log.Fatalf("failed to connect: %v", err)
+	}
+	defer glass.Disconnect()
+
+	filters := xreal.EventFilterConfig{AmbientLightWindow: *window, AmbientLightMinDelta: uint16(*maxLight / 16)}
+
+	lastLevel := ""
+	glass.SetAmbientLightEventHandler(filters.FilterAmbientLight(func(light uint16) {
+		level := strconv.Itoa(int(min(uint(light)*8/(*maxLight+1), 7)))
+		if level == lastLevel {
+			return
+		}
+		if err := glass.SetBrightnessLevel(level); err != nil {
+			log.Printf("failed to set brightness level %s: %v", level, err)
+			return
+		}
+		log.Printf("ambient light %d, brightness level %s", light, level)
+		lastLevel = level
+	}))
+
+	if err := glass.EnableAmbientLight(true); err != nil {
+		log.Fatalf("failed to enable ambient light reporting: %v", err)
+	}
+	defer glass.EnableAmbientLight(false)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+}
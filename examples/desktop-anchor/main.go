@@ -0,0 +1,51 @@
+//go:build linux
+
+// desktop-anchor pans a virtual mouse against the head motion of the first attached XREAL Light, so a desktop
+// zoom following the pointer, e.g. the GNOME or KWin magnifier, keeps its content anchored in space while the
+// head turns. Press any key on the glass to recenter. It needs access to /dev/uhid, usually root.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+
+	"xreal-light-xr-go/anchor"
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/pkg/xreal"
+)
+
+func main() {
+	config := anchor.DefaultConfig()
+	flag.Float64Var(&config.PixelsPerDegree, "pixels-per-degree", config.PixelsPerDegree, "pixels panned per degree of head motion")
+	flag.Float64Var(&config.Deadzone, "deadzone", config.Deadzone, "head motion in degrees ignored around the anchor")
+	flag.Float64Var(&config.MaxOffsetX, "max-x", 0, "max horizontal offset from the anchor in pixels, 0 for no limit")
+	flag.Float64Var(&config.MaxOffsetY, "max-y", 0, "max vertical offset from the anchor in pixels, 0 for no limit")
+	flag.BoolVar(&config.InvertX, "invert-x", false, "invert the horizontal pan")
+	flag.BoolVar(&config.InvertY, "invert-y", false, "invert the vertical pan")
+	flag.Parse()
+
+	mouse, err := anchor.NewVirtualMouse()
+	if err != nil {
+		log.Fatalf("failed to create virtual mouse: %v", err)
+	}
+	defer mouse.Close()
+
+	glass := xreal.NewLight(nil, nil)
+	if err := glass.Connect(); err != nil {
+		log.Fatalf("failed to connect: %v", err)
+	}
+	defer glass.Disconnect()
+
+	anchor.New(config, mouse).Attach(glass, fusion.NewComplementaryFilter(fusion.DEFAULT_GYROSCOPE_WEIGHT))
+	if err := glass.EnableIMU(true); err != nil {
+		log.Fatalf("failed to enable IMU stream: %v", err)
+	}
+
+	log.Printf("anchoring the view, press a key on the glass to recenter")
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+}
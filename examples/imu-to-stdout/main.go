@@ -0,0 +1,37 @@
+// imu-to-stdout prints the IMU readings of the first attached XREAL Light as CSV lines until interrupted.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"xreal-light-xr-go/pkg/xreal"
+)
+
+func main() {
+	glass := xreal.NewLight(nil, nil)
+	if err := glass.Connect(); err != nil {
+		log.Fatalf("failed to connect: %v", err)
+	}
+	defer glass.Disconnect()
+
+	fmt.Println("time_since_boot_ms,accel_x,accel_y,accel_z,gyro_x,gyro_y,gyro_z")
+	glass.SetIMUEventHandler(func(imu *xreal.IMUEvent) {
+		fmt.Printf(
+			"%d,%f,%f,%f,%f,%f,%f\n",
+			imu.TimeSinceBoot,
+			imu.Accelerometer.X, imu.Accelerometer.Y, imu.Accelerometer.Z,
+			imu.Gyroscope.X, imu.Gyroscope.Y, imu.Gyroscope.Z,
+		)
+	})
+
+	if err := glass.EnableIMU(true); err != nil {
+		log.Fatalf("failed to enable IMU stream: %v", err)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+}
@@ -0,0 +1,57 @@
+// lsl-outlet publishes the IMU, magnetometer, and key and proximity events of the first attached XREAL Light
+// as Lab Streaming Layer outlets, so they can be recorded in sync with other devices. It needs liblsl, run with
+//
+//	go run -tags lsl ./examples/lsl-outlet
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+
+	"xreal-light-xr-go/lsl"
+	"xreal-light-xr-go/pkg/xreal"
+)
+
+func main() {
+	glass := xreal.NewLight(nil, nil)
+	if err := glass.Connect(); err != nil {
+		log.Fatalf("failed to connect: %v", err)
+	}
+	defer glass.Disconnect()
+
+	serial, err := glass.GetSerial()
+	if err != nil {
+		log.Fatalf("failed to get serial: %v", err)
+	}
+
+	publisher, err := lsl.NewPublisher("xreal-" + serial)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer publisher.Close()
+
+	glass.SetIMUEventHandler(publisher.PushIMU)
+	glass.SetMagnetometerEventHandler(publisher.PushMagnetometer)
+	glass.SetKeyEventHandler(func(key xreal.KeyEvent) {
+		publisher.PushMarker("key:" + key.String())
+	})
+	glass.SetProximityEventHandler(func(proximity xreal.ProximityEvent) {
+		publisher.PushMarker("proximity:" + proximity.String())
+	})
+
+	if err := glass.EnableIMU(true); err != nil {
+		log.Fatalf("failed to enable IMU stream: %v", err)
+	}
+	defer glass.EnableIMU(false)
+	if err := glass.EnableMagnetometer(true); err != nil {
+		log.Fatalf("failed to enable magnetometer: %v", err)
+	}
+	defer glass.EnableMagnetometer(false)
+
+	log.Printf("publishing LSL streams for glass %s, press Ctrl+C to stop", serial)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+}
@@ -0,0 +1,68 @@
+// opentrack-udp sends the head orientation of the first attached XREAL Light to OpenTrack's "UDP over network"
+// input, so flight sims and games supporting OpenTrack can use the glass as a head tracker. Each datagram is
+// 6 little-endian float64: x, y, z (cm, always 0 as the position is not tracked), yaw, pitch, roll (degrees).
+// Axis inversion and centering are left to OpenTrack's own settings.
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"log"
+	"math"
+	"net"
+	"time"
+
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/pkg/xreal"
+)
+
+func main() {
+	address := flag.String("address", "127.0.0.1:4242", "address of the OpenTrack UDP over network input")
+	rate := flag.Int("rate", 60, "packets per second")
+	flag.Parse()
+
+	conn, err := net.Dial("udp", *address)
+	if err != nil {
+		log.Fatalf("failed to open UDP socket to %s: %v", *address, err)
+	}
+	defer conn.Close()
+
+	glass := xreal.NewLight(nil, nil)
+	if err := glass.Connect(); err != nil {
+		log.Fatalf("failed to connect: %v", err)
+	}
+	defer glass.Disconnect()
+
+	filter := fusion.NewComplementaryFilter(fusion.DEFAULT_GYROSCOPE_WEIGHT)
+	glass.SetIMUEventHandler(func(imu *xreal.IMUEvent) {
+		filter.Update(imu)
+	})
+	if err := glass.EnableIMU(true); err != nil {
+		log.Fatalf("failed to enable IMU stream: %v", err)
+	}
+
+	log.Printf("sending head orientation to opentrack at udp://%s", *address)
+
+	ticker := time.NewTicker(time.Second / time.Duration(max(*rate, 1)))
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := conn.Write(openTrackPacket(filter.Attitude())); err != nil {
+			// opentrack may not be listening yet, keep sending
+			log.Printf("failed to send: %v", err)
+		}
+	}
+}
+
+func openTrackPacket(attitude fusion.Attitude) []byte {
+	toDegrees := 180 / math.Pi
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, [6]float64{
+		0, 0, 0,
+		attitude.Yaw * toDegrees,
+		attitude.Pitch * toDegrees,
+		attitude.Roll * toDegrees,
+	})
+	return buf.Bytes()
+}
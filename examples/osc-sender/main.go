@@ -0,0 +1,70 @@
+// osc-sender sends the head orientation, ambient light and key presses of the first attached XREAL Light
+// as OSC messages, e.g. to a TouchDesigner OSC In CHOP/DAT. Pass an empty address pattern to disable an event.
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/osc"
+	"xreal-light-xr-go/pkg/xreal"
+)
+
+func main() {
+	address := flag.String("address", "127.0.0.1:9000", "address of the OSC server, can be a broadcast address")
+	rate := flag.Int("rate", 60, "orientation messages per second")
+	orientation := flag.String("orientation", osc.DEFAULT_ADDRESS_PATTERNS.Orientation, "address for roll, pitch, yaw in degrees")
+	ambientLight := flag.String("ambient", osc.DEFAULT_ADDRESS_PATTERNS.AmbientLight, "address for the ambient light reading")
+	key := flag.String("key", osc.DEFAULT_ADDRESS_PATTERNS.Key, "address for key presses")
+	flag.Parse()
+
+	sender, err := osc.NewSender(*address, osc.AddressPatterns{Orientation: *orientation, AmbientLight: *ambientLight, Key: *key})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer sender.Close()
+
+	glass := xreal.NewLight(nil, nil)
+	if err := glass.Connect(); err != nil {
+		log.Fatalf("failed to connect: %v", err)
+	}
+	defer glass.Disconnect()
+
+	glass.SetKeyEventHandler(func(key xreal.KeyEvent) {
+		if err := sender.SendKey(key); err != nil {
+			log.Print(err)
+		}
+	})
+	if *ambientLight != "" {
+		glass.SetAmbientLightEventHandler(func(light uint16) {
+			if err := sender.SendAmbientLight(light); err != nil {
+				log.Print(err)
+			}
+		})
+		if err := glass.EnableAmbientLight(true); err != nil {
+			log.Fatalf("failed to enable ambient light: %v", err)
+		}
+		defer glass.EnableAmbientLight(false)
+	}
+
+	filter := fusion.NewComplementaryFilter(fusion.DEFAULT_GYROSCOPE_WEIGHT)
+	glass.SetIMUEventHandler(func(imu *xreal.IMUEvent) {
+		filter.Update(imu)
+	})
+	if err := glass.EnableIMU(true); err != nil {
+		log.Fatalf("failed to enable IMU stream: %v", err)
+	}
+
+	log.Printf("sending OSC messages to %s", *address)
+
+	ticker := time.NewTicker(time.Second / time.Duration(max(*rate, 1)))
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := sender.SendOrientation(filter.Attitude()); err != nil {
+			log.Print(err)
+		}
+	}
+}
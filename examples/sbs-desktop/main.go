@@ -0,0 +1,88 @@
+// sbs-desktop captures the host screen and shows it as a virtual screen at an adjustable distance and size on the
+// first attached XREAL Light in half SBS mode, a minimal standalone AR desktop viewer. It captures X11 with ffmpeg,
+// or a PipeWire screen cast node with GStreamer if -pipewire is given, and shows the side-by-side frames with
+// ffplay, which has to be moved to the output of the glass.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"xreal-light-xr-go/pkg/xreal"
+	"xreal-light-xr-go/sbs"
+)
+
+func main() {
+	config := sbs.DefaultConfig()
+	display := flag.String("display", os.Getenv("DISPLAY"), "X11 display to capture")
+	pipewire := flag.String("pipewire", "", "PipeWire node to capture instead of X11")
+	width := flag.Int("width", 1920, "width of the captured screen")
+	height := flag.Int("height", 1080, "height of the captured screen")
+	rate := flag.Int("rate", 60, "frames per second to capture")
+	flag.Float64Var(&config.ScreenDistance, "distance", config.ScreenDistance, "distance of the virtual screen in meters")
+	flag.Float64Var(&config.ScreenWidth, "size", config.ScreenWidth, "width of the virtual screen in meters")
+	flag.Float64Var(&config.EyeSeparation, "ipd", config.EyeSeparation, "interpupillary distance in meters")
+	flag.Float64Var(&config.FieldOfView, "fov", config.FieldOfView, "horizontal field of view of one eye in degrees")
+	flag.Parse()
+
+	compositor, err := sbs.NewCompositor(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	name, args := sbs.X11GrabCommand(*display, *width, *height, *rate)
+	if *pipewire != "" {
+		name, args = sbs.PipeWireCommand(*pipewire, *width, *height)
+	}
+	source, err := sbs.NewCommandSource(*width, *height, name, args...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer source.Close()
+
+	name, args = sbs.PlayerCommand(config.OutputWidth, config.OutputHeight)
+	sink, err := sbs.NewCommandSink(name, args...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer sink.Close()
+
+	glass := xreal.NewLight(nil, nil)
+	if err := glass.Connect(); err != nil {
+		log.Fatalf("failed to connect: %v", err)
+	}
+	defer glass.Disconnect()
+
+	// the glass reverts to the previous display mode when taken off or on exit
+	if err := glass.EnterSBS(24 * time.Hour); err != nil {
+		log.Fatalf("failed to enter SBS: %v", err)
+	}
+	defer glass.ExitSBS()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	frames := make(chan error, 1)
+	go func() {
+		for {
+			frame, err := source.Frame()
+			if err == nil {
+				err = sink.Write(compositor.Compose(frame))
+			}
+			if err != nil {
+				frames <- err
+				return
+			}
+		}
+	}()
+
+	log.Printf("showing %dx%d at %.1f m, %.2f m wide", *width, *height, config.ScreenDistance, config.ScreenWidth)
+	select {
+	case <-interrupt:
+	case err := <-frames:
+		log.Printf("stopped: %v", err)
+	}
+}
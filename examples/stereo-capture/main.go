@@ -0,0 +1,42 @@
+// stereo-capture saves the left/right SLAM camera images of the first attached XREAL Light into a folder, or
+// network storage like s3://bucket/prefix.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"xreal-light-xr-go/pkg/xreal"
+	"xreal-light-xr-go/storage"
+)
+
+func main() {
+	folder := flag.String("out", ".", "folder or storage URL to save the images to")
+	flag.Parse()
+
+	if !strings.Contains(*folder, "://") {
+		if err := os.MkdirAll(*folder, 0755); err != nil {
+			log.Fatalf("failed to create %s: %v", *folder, err)
+		}
+	}
+	store, err := storage.Open(*folder)
+	if err != nil {
+		log.Fatalf("failed to open %s: %v", *folder, err)
+	}
+
+	glass := xreal.NewLight(nil, nil)
+	if err := glass.Connect(); err != nil {
+		log.Fatalf("failed to connect: %v", err)
+	}
+	defer glass.Disconnect()
+
+	locations, err := glass.CaptureImages(store)
+	if err != nil {
+		log.Fatalf("failed to capture: %v", err)
+	}
+	for _, location := range locations {
+		log.Printf("saved %s", location)
+	}
+}
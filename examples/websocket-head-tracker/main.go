@@ -0,0 +1,156 @@
+// websocket-head-tracker serves the head orientation of the first attached XREAL Light over a WebSocket,
+// sending a JSON message like {"roll":0.1,"pitch":-0.2,"yaw":1.5} (radians) at a fixed rate to every client, or with
+// -format protobuf a binary message holding an Event of eventpb/events.proto.
+// When listening beyond localhost, pass -auth so only clients granted the read-sensors scope are served,
+// and -tls-cert/-tls-key (plus -tls-client-ca for mutual TLS) to encrypt the connection.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"xreal-light-xr-go/auth"
+	"xreal-light-xr-go/eventpb"
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/pkg/xreal"
+
+	"github.com/gorilla/websocket"
+)
+
+type orientation struct {
+	Roll  float64 `json:"roll"`
+	Pitch float64 `json:"pitch"`
+	Yaw   float64 `json:"yaw"`
+}
+
+func main() {
+	address := flag.String("address", "localhost:8080", "address to listen on, the WebSocket is served at /ws")
+	rate := flag.Int("rate", 60, "messages per second sent to each client")
+	authPath := flag.String("auth", "", "file listing client tokens or certificate common names and their scopes, see package auth")
+	tlsCert := flag.String("tls-cert", "", "server certificate to serve over TLS")
+	tlsKey := flag.String("tls-key", "", "server certificate key to serve over TLS")
+	tlsClientCA := flag.String("tls-client-ca", "", "if set with -tls-cert, require client certificates signed by this CA")
+	format := flag.String("format", "json", "message format, json or protobuf")
+	flag.Parse()
+
+	if *format != "json" && *format != "protobuf" {
+		log.Fatalf("invalid format %s: want json or protobuf", *format)
+	}
+
+	var authorizer *auth.Authorizer
+	if *authPath != "" {
+		var err error
+		if authorizer, err = auth.LoadAuthorizer(*authPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	glass := xreal.NewLight(nil, nil)
+	if err := glass.Connect(); err != nil {
+		log.Fatalf("failed to connect: %v", err)
+	}
+	defer glass.Disconnect()
+
+	filter := fusion.NewComplementaryFilter(fusion.DEFAULT_GYROSCOPE_WEIGHT)
+	glass.SetIMUEventHandler(func(imu *xreal.IMUEvent) {
+		filter.Update(imu)
+	})
+	if err := glass.EnableIMU(true); err != nil {
+		log.Fatalf("failed to enable IMU stream: %v", err)
+	}
+
+	// the default origin check only accepts pages served from the same host, so other sites opened in the browser
+	// cannot read the head orientation
+	upgrader := websocket.Upgrader{}
+
+	// done is closed on shutdown, as the server does not track the upgraded connections
+	done := make(chan struct{})
+	var clients sync.WaitGroup
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// counted before upgrading, while the server still tracks the request, so Shutdown returns after every Add
+		clients.Add(1)
+		defer clients.Done()
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Printf("failed to upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		ticker := time.NewTicker(time.Second / time.Duration(max(*rate, 1)))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(time.Second))
+				return
+			case <-ticker.C:
+			}
+
+			attitude := filter.Attitude()
+			var err error
+			if *format == "protobuf" {
+				var message []byte
+				if message, err = eventpb.NewOrientationEvent(time.Now(), attitude).MarshalBinary(); err == nil {
+					err = conn.WriteMessage(websocket.BinaryMessage, message)
+				}
+			} else {
+				err = conn.WriteJSON(orientation{Roll: attitude.Roll, Pitch: attitude.Pitch, Yaw: attitude.Yaw})
+			}
+			if err != nil {
+				log.Printf("client %s left: %v", r.RemoteAddr, err)
+				return
+			}
+		}
+	})
+	if authorizer != nil {
+		handler = authorizer.Require(auth.SCOPE_READ_SENSORS, handler)
+	}
+	http.Handle("/ws", handler)
+
+	server := &http.Server{Addr: *address}
+	if *tlsCert != "" {
+		var err error
+		if server.TLSConfig, err = auth.ServerTLSConfig(*tlsCert, *tlsKey, *tlsClientCA); err != nil {
+			log.Print(err)
+			return
+		}
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		if server.TLSConfig != nil {
+			log.Printf("serving head orientation at wss://%s/ws, press Ctrl+C to stop", *address)
+			served <- server.ListenAndServeTLS("", "")
+		} else {
+			log.Printf("serving head orientation at ws://%s/ws, press Ctrl+C to stop", *address)
+			served <- server.ListenAndServe()
+		}
+	}()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	select {
+	case err := <-served:
+		// the glass is still disconnected by the deferred calls
+		log.Printf("failed to serve: %v", err)
+		return
+	case <-interrupt:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("failed to shut down: %v", err)
+	}
+	close(done)
+	clients.Wait()
+}
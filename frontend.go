@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+
+	"xreal-light-xr-go/auth"
+	"xreal-light-xr-go/constant"
+)
+
+// networkFrontends protects the HTTP frontends of the tool, e.g. -camera-stream: with -auth, clients must be granted
+// the scope of the frontend, and with -tls-cert they are served over TLS. Without -auth, frontends are only served on
+// loopback addresses, so other hosts cannot reach the glass.
+type networkFrontends struct {
+	// authorizer is nil without -auth
+	authorizer *auth.Authorizer
+	// tlsConfig is nil without -tls-cert
+	tlsConfig *tls.Config
+}
+
+func newNetworkFrontends(config constant.Config) (*networkFrontends, error) {
+	frontends := &networkFrontends{}
+	if config.AuthFilePath != "" {
+		authorizer, err := auth.LoadAuthorizer(config.AuthFilePath)
+		if err != nil {
+			return nil, err
+		}
+		frontends.authorizer = authorizer
+	}
+	if config.TLSCertPath != "" {
+		tlsConfig, err := auth.ServerTLSConfig(config.TLSCertPath, config.TLSKeyPath, config.TLSClientCAPath)
+		if err != nil {
+			return nil, err
+		}
+		frontends.tlsConfig = tlsConfig
+	} else if config.TLSClientCAPath != "" {
+		return nil, fmt.Errorf("-tls-client-ca has no effect without -tls-cert")
+	}
+	return frontends, nil
+}
+
+// listen listens on address and returns the server of handler, only serving clients granted scope, and its base URL.
+// Without -auth, addresses other than loopback ones are refused.
+func (f *networkFrontends) listen(address string, scope auth.Scope, handler http.Handler) (*http.Server, net.Listener, string, error) {
+	if f.authorizer == nil && !isLoopbackAddress(address) {
+		return nil, nil, "", fmt.Errorf("refusing to serve at %s reachable by other hosts without -auth, use e.g. localhost:<port>", address)
+	}
+	if f.authorizer != nil {
+		handler = f.authorizer.Require(scope, handler)
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+	scheme := "http"
+	if f.tlsConfig != nil {
+		listener = tls.NewListener(listener, f.tlsConfig)
+		scheme = "https"
+	}
+	return &http.Server{Handler: handler}, listener, fmt.Sprintf("%s://%s", scheme, listener.Addr()), nil
+}
+
+// isLoopbackAddress tells if the host of address only accepts connections from this host, e.g. localhost:8080.
+func isLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,236 @@
+package fusion
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+// Activity is the coarse activity of the wearer.
+type Activity string
+
+const (
+	ACTIVITY_UNKNOWN      Activity = "UNKNOWN"
+	ACTIVITY_STATIONARY   Activity = "STATIONARY"
+	ACTIVITY_WALKING      Activity = "WALKING"
+	ACTIVITY_HEAD_TURNING Activity = "HEAD_TURNING"
+)
+
+const (
+	// gravityTimeConstant is how slowly the gravity estimate follows the accelerometer magnitude, in seconds
+	gravityTimeConstant = 2.0
+	// motionTimeConstant smooths the gyroscope and linear acceleration magnitudes, in seconds
+	motionTimeConstant = 0.3
+	// stepTimeConstant smooths the linear acceleration before detecting steps, in seconds
+	stepTimeConstant = 0.05
+
+	// stepThreshold is the linear acceleration in m/s² a step peak must exceed, it is detected again once the
+	// acceleration fell below 0
+	stepThreshold = 1.2
+	// minStepIntervalMs and maxStepIntervalMs bound the time between steps of a walking cadence
+	minStepIntervalMs = 250
+	maxStepIntervalMs = 1500
+	// minWalkingSteps is how many steps in a cadence are needed before counting them, so nodding is no walking
+	minWalkingSteps = 3
+
+	// headTurningRate is the smoothed gyroscope magnitude in rad/s above which the head is turning
+	headTurningRate = 0.5
+	// stationaryRate and stationaryAcceleration are the smoothed gyroscope magnitude in rad/s and linear
+	// acceleration magnitude in m/s² below which the wearer is stationary
+	stationaryRate         = 0.15
+	stationaryAcceleration = 0.3
+)
+
+// ActivityEvent is emitted when the activity changes or a step is counted.
+type ActivityEvent struct {
+	Activity Activity
+	// Steps is the total number of steps counted since the classifier was created or reset
+	Steps uint64
+	// TimeSinceBoot is of the IMU event the activity is derived from, in miliseconds
+	TimeSinceBoot uint64
+}
+
+func (e ActivityEvent) String() string {
+	return fmt.Sprintf("%s, %d steps at %d ms since boot", e.Activity, e.Steps, e.TimeSinceBoot)
+}
+
+type ActivityEventHandler func(*ActivityEvent)
+
+// ActivityClassifier classifies the coarse activity of the wearer and counts steps from the IMU stream, e.g. for
+// fitness or comfort features without full visual inertial odometry. Steps are peaks of the acceleration beyond
+// gravity in a walking cadence, head turning is a sustained gyroscope rate.
+type ActivityClassifier struct {
+	// mutex for thread safety
+	mutex   sync.Mutex
+	handler ActivityEventHandler
+
+	activity Activity
+	steps    uint64
+
+	initialized bool
+	// lastTimeSinceBoot is of the last IMU event in miliseconds
+	lastTimeSinceBoot uint64
+	// gravity is the estimated magnitude of gravity in m/s²
+	gravity float64
+	// stepAcceleration is the linear acceleration smoothed for step detection in m/s²
+	stepAcceleration float64
+	// rotationRate and linearAcceleration are the smoothed magnitudes to classify the activity
+	rotationRate       float64
+	linearAcceleration float64
+
+	// stepArmed is set once the acceleration fell below 0 since the last step peak
+	stepArmed bool
+	// lastStepMs is when the last step peak was detected, in miliseconds since boot
+	lastStepMs uint64
+	// cadenceSteps is how many steps are in the current cadence, only counted once it reaches minWalkingSteps
+	cadenceSteps uint64
+}
+
+// NewActivityClassifier creates an ActivityClassifier logging its events until a handler is set.
+func NewActivityClassifier() *ActivityClassifier {
+	return &ActivityClassifier{
+		activity: ACTIVITY_UNKNOWN,
+		handler: func(event *ActivityEvent) {
+			slog.Info(fmt.Sprintf("Activity: %s", event.String()))
+		},
+	}
+}
+
+func (c *ActivityClassifier) SetActivityEventHandler(handler ActivityEventHandler) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.handler = handler
+}
+
+// Attach feeds the IMU events of the glass into the ActivityClassifier.
+// Note that it replaces the IMU event handler of the device.
+func (c *ActivityClassifier) Attach(d device.Device) {
+	d.SetIMUEventHandler(c.HandleIMU)
+}
+
+// Activity returns the current activity.
+func (c *ActivityClassifier) Activity() Activity {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.activity
+}
+
+// Steps returns the number of steps counted so far.
+func (c *ActivityClassifier) Steps() uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.steps
+}
+
+// Reset forgets the activity and the step count.
+func (c *ActivityClassifier) Reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.activity = ACTIVITY_UNKNOWN
+	c.steps = 0
+	c.initialized = false
+}
+
+// HandleIMU updates the activity and step count for an IMU event. It can be used as a device.IMUEventHandler.
+func (c *ActivityClassifier) HandleIMU(imu *device.IMUEvent) {
+	if imu == nil || imu.Accelerometer == nil || imu.Gyroscope == nil {
+		return
+	}
+
+	accel, gyro := imu.Accelerometer, imu.Gyroscope
+	magnitude := math.Sqrt(float64(accel.X*accel.X + accel.Y*accel.Y + accel.Z*accel.Z))
+	rate := math.Sqrt(float64(gyro.X*gyro.X + gyro.Y*gyro.Y + gyro.Z*gyro.Z))
+
+	c.mutex.Lock()
+	event := c.update(imu.TimeSinceBoot, magnitude, rate)
+	handler := c.handler
+	c.mutex.Unlock()
+
+	if event != nil && handler != nil {
+		handler(event)
+	}
+}
+
+// update must be called with the mutex held, it returns an event if the activity changed or a step was counted.
+func (c *ActivityClassifier) update(timeSinceBoot uint64, magnitude, rate float64) *ActivityEvent {
+	dt := float64(timeSinceBoot-c.lastTimeSinceBoot) / 1000
+	if !c.initialized || timeSinceBoot <= c.lastTimeSinceBoot || dt > maxUpdateIntervalSeconds {
+		// start over across stream gaps, keeping the activity and step count
+		c.initialized = true
+		c.lastTimeSinceBoot = timeSinceBoot
+		c.gravity = magnitude
+		c.stepAcceleration, c.rotationRate, c.linearAcceleration = 0, rate, 0
+		c.stepArmed, c.cadenceSteps = false, 0
+		return nil
+	}
+	c.lastTimeSinceBoot = timeSinceBoot
+
+	c.gravity = smooth(c.gravity, magnitude, dt, gravityTimeConstant)
+	linear := magnitude - c.gravity
+	c.stepAcceleration = smooth(c.stepAcceleration, linear, dt, stepTimeConstant)
+	c.rotationRate = smooth(c.rotationRate, rate, dt, motionTimeConstant)
+	c.linearAcceleration = smooth(c.linearAcceleration, math.Abs(linear), dt, motionTimeConstant)
+
+	stepped := c.detectStep(timeSinceBoot)
+
+	activity := c.activity
+	switch {
+	case c.cadenceSteps >= minWalkingSteps && timeSinceBoot-c.lastStepMs <= maxStepIntervalMs:
+		activity = ACTIVITY_WALKING
+	case c.rotationRate > headTurningRate:
+		activity = ACTIVITY_HEAD_TURNING
+	case c.rotationRate < stationaryRate && c.linearAcceleration < stationaryAcceleration:
+		activity = ACTIVITY_STATIONARY
+	case c.activity == ACTIVITY_WALKING:
+		// the cadence ended without settling down
+		activity = ACTIVITY_UNKNOWN
+	}
+
+	if activity == c.activity && !stepped {
+		return nil
+	}
+	c.activity = activity
+	return &ActivityEvent{Activity: activity, Steps: c.steps, TimeSinceBoot: timeSinceBoot}
+}
+
+// detectStep must be called with the mutex held, it returns true if steps were counted.
+func (c *ActivityClassifier) detectStep(timeSinceBoot uint64) bool {
+	if c.stepAcceleration < 0 {
+		c.stepArmed = true
+	}
+	if !c.stepArmed || c.stepAcceleration < stepThreshold {
+		return false
+	}
+	c.stepArmed = false
+
+	interval := timeSinceBoot - c.lastStepMs
+	if c.cadenceSteps > 0 && interval < minStepIntervalMs {
+		// a second peak of the same step
+		return false
+	}
+	if c.cadenceSteps == 0 || interval > maxStepIntervalMs {
+		c.cadenceSteps = 0
+	}
+	c.lastStepMs = timeSinceBoot
+	c.cadenceSteps++
+
+	switch {
+	case c.cadenceSteps == minWalkingSteps:
+		c.steps += minWalkingSteps
+		return true
+	case c.cadenceSteps > minWalkingSteps:
+		c.steps++
+		return true
+	}
+	return false
+}
+
+// smooth is an exponential moving average of value over timeConstant seconds, for dt seconds since the last value.
+func smooth(average, value, dt, timeConstant float64) float64 {
+	alpha := dt / (timeConstant + dt)
+	return average + alpha*(value-average)
+}
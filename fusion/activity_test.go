@@ -0,0 +1,67 @@
+package fusion_test
+
+import (
+	"math"
+	"testing"
+
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestActivityClassifier(t *testing.T) {
+	classifier := fusion.NewActivityClassifier()
+	events := []fusion.ActivityEvent{}
+	classifier.SetActivityEventHandler(func(event *fusion.ActivityEvent) {
+		events = append(events, *event)
+	})
+
+	// 100 Hz IMU: standing still for 2 s, walking at 2 steps per second for 5 s, standing for 2 s, turning the head
+	timeMs := uint64(0)
+	feed := func(seconds float64, accel func(t float64) float32, gyro float32) {
+		for i := 0; i < int(seconds*100); i++ {
+			timeMs += 10
+			classifier.HandleIMU(&device.IMUEvent{
+				Accelerometer: &device.AccelerometerVector{Z: 9.81 + accel(float64(i)/100)},
+				Gyroscope:     &device.GyroscopeVector{Z: gyro},
+				TimeSinceBoot: timeMs,
+			})
+		}
+	}
+	still := func(float64) float32 { return 0 }
+	feed(2, still, 0)
+	if classifier.Activity() != fusion.ACTIVITY_STATIONARY {
+		t.Errorf("Activity() standing = %s, want STATIONARY", classifier.Activity())
+	}
+	feed(5, func(t float64) float32 { return float32(3 * math.Sin(2*math.Pi*2*t)) }, 0)
+	if classifier.Activity() != fusion.ACTIVITY_WALKING {
+		t.Errorf("Activity() walking = %s, want WALKING", classifier.Activity())
+	}
+	if steps := classifier.Steps(); steps < 9 || steps > 10 {
+		t.Errorf("Steps() = %d, want 10", steps)
+	}
+	feed(2, still, 0)
+	if classifier.Activity() != fusion.ACTIVITY_STATIONARY {
+		t.Errorf("Activity() after walking = %s, want STATIONARY", classifier.Activity())
+	}
+	feed(1, still, 2)
+	if classifier.Activity() != fusion.ACTIVITY_HEAD_TURNING {
+		t.Errorf("Activity() turning = %s, want HEAD_TURNING", classifier.Activity())
+	}
+
+	activities := []fusion.Activity{}
+	for _, event := range events {
+		if len(activities) == 0 || activities[len(activities)-1] != event.Activity {
+			activities = append(activities, event.Activity)
+		}
+	}
+	want := []fusion.Activity{fusion.ACTIVITY_STATIONARY, fusion.ACTIVITY_WALKING, fusion.ACTIVITY_STATIONARY, fusion.ACTIVITY_HEAD_TURNING}
+	if len(activities) != len(want) {
+		t.Fatalf("activities = %v, want %v", activities, want)
+	}
+	for i := range want {
+		if activities[i] != want[i] {
+			t.Errorf("activities = %v, want %v", activities, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package fusion
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+// Heading is where the glass faces, in degrees clockwise from north in [0, 360).
+type Heading struct {
+	// Magnetic is relative to the magnetic north
+	Magnetic float64
+	// True is relative to the geographic north, i.e. Magnetic corrected by the declination
+	True float64
+	// Timestamp is of the magnetometer reading the heading is derived from
+	Timestamp time.Time
+}
+
+func (h Heading) String() string {
+	return fmt.Sprintf("%.1f deg (magnetic %.1f deg) at %v", h.True, h.Magnetic, h.Timestamp)
+}
+
+type HeadingEventHandler func(*Heading)
+
+// Compass derives a tilt compensated Heading from magnetometer readings and the attitude of a ComplementaryFilter.
+type Compass struct {
+	filter *ComplementaryFilter
+
+	// mutex for thread safety
+	mutex sync.Mutex
+	// declination is in degrees, east positive
+	declination float64
+	// hardIronOffset is subtracted from magnetometer readings, in their raw unit
+	hardIronOffset [3]float64
+	handler        HeadingEventHandler
+}
+
+// NewCompass creates a Compass using the attitude of filter and the local magnetic declination in degrees, east positive.
+func NewCompass(filter *ComplementaryFilter, declination float64) *Compass {
+	return &Compass{
+		filter:      filter,
+		declination: declination,
+		handler: func(heading *Heading) {
+			slog.Info(fmt.Sprintf("Heading: %s", heading.String()))
+		},
+	}
+}
+
+// SetDeclination sets the local magnetic declination in degrees, east positive.
+func (c *Compass) SetDeclination(declination float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.declination = declination
+}
+
+// SetHardIronOffset sets the calibrated magnetometer offset in the raw unit of the readings to subtract from them.
+func (c *Compass) SetHardIronOffset(x, y, z float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.hardIronOffset = [3]float64{x, y, z}
+}
+
+func (c *Compass) SetHeadingEventHandler(handler HeadingEventHandler) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.handler = handler
+}
+
+// Attach feeds the IMU and magnetometer events of the glass into the Compass.
+// Note that it replaces the IMU and magnetometer event handlers of the device.
+func (c *Compass) Attach(d device.Device) {
+	d.SetIMUEventHandler(func(imu *device.IMUEvent) {
+		c.filter.Update(imu)
+	})
+	d.SetMagnetometerEventHandler(c.HandleMagnetometer)
+}
+
+// HandleMagnetometer computes the Heading for a magnetometer reading. It can be used as a device.MagnetometerEventHandler.
+func (c *Compass) HandleMagnetometer(vector *device.MagnetometerVector) {
+	if vector == nil || !vector.Valid {
+		return
+	}
+
+	c.mutex.Lock()
+	x := vector.X - c.hardIronOffset[0]
+	y := vector.Y - c.hardIronOffset[1]
+	z := vector.Z - c.hardIronOffset[2]
+	declination := c.declination
+	handler := c.handler
+	c.mutex.Unlock()
+
+	magnetic := TiltCompensatedHeading(c.filter.Attitude(), x, y, z)
+	// only corrects yaw if enabled by YawCorrection.MagnetometerWeight
+	c.filter.UpdateHeading(magnetic)
+	handler(&Heading{
+		Magnetic:  magnetic,
+		True:      wrapDegrees(magnetic + declination),
+		Timestamp: vector.Timestamp,
+	})
+}
+
+// TiltCompensatedHeading projects the magnetic field onto the horizontal plane using roll and pitch,
+// returning degrees clockwise from the magnetic north in [0, 360).
+func TiltCompensatedHeading(attitude Attitude, x, y, z float64) float64 {
+	sinRoll, cosRoll := math.Sincos(attitude.Roll)
+	sinPitch, cosPitch := math.Sincos(attitude.Pitch)
+
+	horizontalX := x*cosPitch + y*sinRoll*sinPitch + z*cosRoll*sinPitch
+	horizontalY := y*cosRoll - z*sinRoll
+
+	return wrapDegrees(degrees(math.Atan2(-horizontalY, horizontalX)))
+}
+
+// wrapDegrees wraps degrees into [0, 360).
+func wrapDegrees(angle float64) float64 {
+	return math.Mod(math.Mod(angle, 360)+360, 360)
+}
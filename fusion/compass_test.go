@@ -0,0 +1,48 @@
+package fusion_test
+
+import (
+	"math"
+	"testing"
+
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestTiltCompensatedHeading(t *testing.T) {
+	testCases := []struct {
+		attitude fusion.Attitude
+		x, y, z  float64
+		expected float64
+	}{
+		{fusion.Attitude{}, 20, 0, -40, 0},
+		{fusion.Attitude{}, 0, -20, -40, 90},
+		{fusion.Attitude{}, -20, 0, -40, 180},
+		{fusion.Attitude{}, 0, 20, -40, 270},
+		// pitched up 30 degrees facing north, the vertical field leaks into x without compensation
+		{fusion.Attitude{Pitch: math.Pi / 6}, 20*math.Cos(math.Pi/6) + 40*math.Sin(math.Pi/6), 0, 20*math.Sin(math.Pi/6) - 40*math.Cos(math.Pi/6), 0},
+	}
+
+	for _, tc := range testCases {
+		actual := fusion.TiltCompensatedHeading(tc.attitude, tc.x, tc.y, tc.z)
+		if math.Abs(actual-tc.expected) > 1e-6 && math.Abs(actual-tc.expected-360) > 1e-6 {
+			t.Errorf("TiltCompensatedHeading(%s, %f, %f, %f) = %f; expected %f", tc.attitude, tc.x, tc.y, tc.z, actual, tc.expected)
+		}
+	}
+}
+
+func TestCompassDeclination(t *testing.T) {
+	compass := fusion.NewCompass(fusion.NewComplementaryFilter(fusion.DEFAULT_GYROSCOPE_WEIGHT), -10)
+
+	var heading *fusion.Heading
+	compass.SetHeadingEventHandler(func(h *fusion.Heading) {
+		heading = h
+	})
+	compass.HandleMagnetometer(&device.MagnetometerVector{X: 20, Y: 0, Z: -40, Valid: true})
+
+	if heading == nil {
+		t.Fatalf("no heading reported")
+	}
+	if math.Abs(heading.True-350) > 1e-6 {
+		t.Errorf("heading.True = %f; expected 350", heading.True)
+	}
+}
@@ -0,0 +1,137 @@
+// Package fusion estimates the glass orientation from its IMU and magnetometer readings, and derives higher level
+// signals from them, e.g. the activity of the wearer.
+package fusion
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+// DEFAULT_GYROSCOPE_WEIGHT trusts the gyroscope for short term changes and the accelerometer for long term tilt.
+const DEFAULT_GYROSCOPE_WEIGHT = 0.98
+
+// maxUpdateIntervalSeconds drops integration steps across stream gaps, e.g. when the IMU stream is restarted.
+const maxUpdateIntervalSeconds = 1.0
+
+// Attitude is the orientation of the glass in radians.
+type Attitude struct {
+	Roll  float64
+	Pitch float64
+	Yaw   float64
+}
+
+func (a Attitude) String() string {
+	return fmt.Sprintf("(roll,pitch,yaw)=(%.1f, %.1f, %.1f) deg", degrees(a.Roll), degrees(a.Pitch), degrees(a.Yaw))
+}
+
+// Quaternion is a unit quaternion rotating from the glass frame to the world frame.
+type Quaternion struct {
+	W float64
+	X float64
+	Y float64
+	Z float64
+}
+
+// Quaternion converts the Attitude, applying yaw, then pitch, then roll.
+func (a Attitude) Quaternion() Quaternion {
+	cr, sr := math.Cos(a.Roll/2), math.Sin(a.Roll/2)
+	cp, sp := math.Cos(a.Pitch/2), math.Sin(a.Pitch/2)
+	cy, sy := math.Cos(a.Yaw/2), math.Sin(a.Yaw/2)
+	return Quaternion{
+		W: cr*cp*cy + sr*sp*sy,
+		X: sr*cp*cy - cr*sp*sy,
+		Y: cr*sp*cy + sr*cp*sy,
+		Z: cr*cp*sy - sr*sp*cy,
+	}
+}
+
+// ComplementaryFilter fuses gyroscope and accelerometer readings into an Attitude.
+// Roll and pitch are corrected by gravity while yaw is integrated from the gyroscope only and drifts over time, unless
+// corrected as set by SetYawCorrection.
+type ComplementaryFilter struct {
+	gyroscopeWeight float64
+
+	// mutex for thread safety
+	mutex    sync.Mutex
+	attitude Attitude
+	// lastTimeSinceBoot is of the last IMU event in miliseconds
+	lastTimeSinceBoot uint64
+	initialized       bool
+
+	yawCorrection YawCorrection
+	// yawDrift is the estimated yaw rate of the gyroscope at rest, stillFor how long the glass lies still in seconds
+	yawDrift float64
+	stillFor float64
+	// headingOffset turns magnetic headings into yaw relative to the direction at start
+	headingOffset      float64
+	headingInitialized bool
+}
+
+// NewComplementaryFilter creates a filter weighting the gyroscope by gyroscopeWeight in [0, 1].
+func NewComplementaryFilter(gyroscopeWeight float64) *ComplementaryFilter {
+	return &ComplementaryFilter{gyroscopeWeight: min(max(gyroscopeWeight, 0), 1)}
+}
+
+// Update feeds an IMU event and returns the updated Attitude. It can be used as a device.IMUEventHandler.
+func (f *ComplementaryFilter) Update(imu *device.IMUEvent) Attitude {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if imu == nil || imu.Accelerometer == nil || imu.Gyroscope == nil {
+		return f.attitude
+	}
+
+	accel := imu.Accelerometer
+	accelRoll := math.Atan2(float64(accel.Y), float64(accel.Z))
+	accelPitch := math.Atan2(-float64(accel.X), math.Hypot(float64(accel.Y), float64(accel.Z)))
+
+	if !f.initialized {
+		f.attitude = Attitude{Roll: accelRoll, Pitch: accelPitch}
+		f.lastTimeSinceBoot = imu.TimeSinceBoot
+		f.initialized = true
+		return f.attitude
+	}
+
+	dt := (float64(imu.TimeSinceBoot) - float64(f.lastTimeSinceBoot)) / 1000
+	f.lastTimeSinceBoot = imu.TimeSinceBoot
+	if dt <= 0 || dt > maxUpdateIntervalSeconds {
+		return f.attitude
+	}
+
+	gyro := imu.Gyroscope
+	f.attitude.Roll = f.gyroscopeWeight*(f.attitude.Roll+float64(gyro.X)*dt) + (1-f.gyroscopeWeight)*accelRoll
+	f.attitude.Pitch = f.gyroscopeWeight*(f.attitude.Pitch+float64(gyro.Y)*dt) + (1-f.gyroscopeWeight)*accelPitch
+	f.attitude.Yaw = wrapAngle(f.attitude.Yaw + f.correctYawRate(imu, dt)*dt)
+
+	return f.attitude
+}
+
+// Attitude returns the latest estimated Attitude.
+func (f *ComplementaryFilter) Attitude() Attitude {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.attitude
+}
+
+// Reset forgets the estimated Attitude, the next IMU event re-initializes it from gravity. The estimated yaw drift is
+// kept, as it belongs to the gyroscope.
+func (f *ComplementaryFilter) Reset() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.attitude = Attitude{}
+	f.initialized = false
+	f.stillFor = 0
+	f.headingInitialized = false
+}
+
+// wrapAngle wraps radians into [-pi, pi).
+func wrapAngle(angle float64) float64 {
+	return math.Mod(math.Mod(angle+math.Pi, 2*math.Pi)+2*math.Pi, 2*math.Pi) - math.Pi
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
@@ -0,0 +1,147 @@
+package fusion_test
+
+import (
+	"math"
+	"testing"
+
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestAttitudeQuaternion(t *testing.T) {
+	testCases := []struct {
+		attitude fusion.Attitude
+		expected fusion.Quaternion
+	}{
+		{fusion.Attitude{}, fusion.Quaternion{W: 1}},
+		{fusion.Attitude{Roll: math.Pi / 2}, fusion.Quaternion{W: math.Sqrt2 / 2, X: math.Sqrt2 / 2}},
+		{fusion.Attitude{Pitch: math.Pi / 2}, fusion.Quaternion{W: math.Sqrt2 / 2, Y: math.Sqrt2 / 2}},
+		{fusion.Attitude{Yaw: -math.Pi / 2}, fusion.Quaternion{W: math.Sqrt2 / 2, Z: -math.Sqrt2 / 2}},
+		{fusion.Attitude{Yaw: math.Pi}, fusion.Quaternion{Z: 1}},
+	}
+
+	for _, tc := range testCases {
+		actual := tc.attitude.Quaternion()
+		if math.Abs(actual.W-tc.expected.W) > 1e-9 || math.Abs(actual.X-tc.expected.X) > 1e-9 ||
+			math.Abs(actual.Y-tc.expected.Y) > 1e-9 || math.Abs(actual.Z-tc.expected.Z) > 1e-9 {
+			t.Errorf("%s.Quaternion() = %+v; expected %+v", tc.attitude, actual, tc.expected)
+		}
+	}
+
+	// any attitude gives a unit quaternion
+	q := fusion.Attitude{Roll: 0.3, Pitch: -1.1, Yaw: 2.5}.Quaternion()
+	if norm := q.W*q.W + q.X*q.X + q.Y*q.Y + q.Z*q.Z; math.Abs(norm-1) > 1e-9 {
+		t.Errorf("norm of %+v = %f; expected 1", q, norm)
+	}
+}
+
+// feedStill feeds seconds of 100 Hz samples of a glass lying level, its gyroscope reporting yawRate.
+func feedStill(filter *fusion.ComplementaryFilter, timeMs *uint64, seconds float64, yawRate float32) {
+	for i := 0; i < int(seconds*100); i++ {
+		*timeMs += 10
+		filter.Update(&device.IMUEvent{
+			Accelerometer: &device.AccelerometerVector{Z: 9.81},
+			Gyroscope:     &device.GyroscopeVector{Z: yawRate},
+			TimeSinceBoot: *timeMs,
+		})
+	}
+}
+
+func TestYawCorrection(t *testing.T) {
+	const drift = 0.01 // rad/s
+
+	testCases := []struct {
+		name       string
+		correction fusion.YawCorrection
+		// maxYaw is the largest yaw expected after 30 s at rest
+		maxYaw float64
+	}{
+		{"none", fusion.YawCorrection{}, math.Inf(1)},
+		{"dead band", fusion.YawCorrection{DeadBand: 2 * drift}, 1e-9},
+		// learning takes a few time constants, then yaw stops drifting
+		{"estimated drift", fusion.YawCorrection{EstimateDrift: true}, 0.1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter := fusion.NewComplementaryFilter(fusion.DEFAULT_GYROSCOPE_WEIGHT)
+			filter.SetYawCorrection(tc.correction)
+			timeMs := uint64(0)
+			feedStill(filter, &timeMs, 30, drift)
+
+			yaw := math.Abs(filter.Attitude().Yaw)
+			if yaw > tc.maxYaw {
+				t.Errorf("yaw after 30 s = %f; expected at most %f", yaw, tc.maxYaw)
+			}
+			if tc.correction == (fusion.YawCorrection{}) && math.Abs(yaw-30*drift) > 0.01 {
+				t.Errorf("uncorrected yaw after 30 s = %f; expected %f", yaw, 30*drift)
+			}
+		})
+	}
+
+	filter := fusion.NewComplementaryFilter(fusion.DEFAULT_GYROSCOPE_WEIGHT)
+	filter.SetYawCorrection(fusion.YawCorrection{EstimateDrift: true})
+	timeMs := uint64(0)
+	feedStill(filter, &timeMs, 60, drift)
+	if estimated := filter.YawDrift(); math.Abs(estimated-drift) > 0.001 {
+		t.Errorf("YawDrift() = %f; expected %f", estimated, drift)
+	}
+	// turning is not taken for drift
+	feedStill(filter, &timeMs, 1, 1)
+	if estimated := filter.YawDrift(); math.Abs(estimated-drift) > 0.001 {
+		t.Errorf("YawDrift() after turning = %f; expected %f", estimated, drift)
+	}
+}
+
+func TestRecenter(t *testing.T) {
+	filter := fusion.NewComplementaryFilter(fusion.DEFAULT_GYROSCOPE_WEIGHT)
+	timeMs := uint64(0)
+	feedStill(filter, &timeMs, 1, 0.5)
+
+	before := filter.Attitude()
+	if math.Abs(before.Yaw) < 0.4 {
+		t.Fatalf("yaw after turning = %f; expected about 0.5", before.Yaw)
+	}
+	filter.Recenter()
+	after := filter.Attitude()
+	if after.Yaw != 0 || after.Roll != before.Roll || after.Pitch != before.Pitch {
+		t.Errorf("Recenter() turned %s into %s; expected only yaw zeroed", before, after)
+	}
+}
+
+func TestUpdateHeading(t *testing.T) {
+	filter := fusion.NewComplementaryFilter(fusion.DEFAULT_GYROSCOPE_WEIGHT)
+	filter.SetYawCorrection(fusion.YawCorrection{MagnetometerWeight: 0.1})
+	timeMs := uint64(0)
+	feedStill(filter, &timeMs, 0.1, 0)
+
+	// the first heading sets the direction of yaw 0
+	filter.UpdateHeading(90)
+	if yaw := filter.Attitude().Yaw; yaw != 0 {
+		t.Fatalf("yaw after first heading = %f; expected 0", yaw)
+	}
+
+	// the gyroscope drifts while the heading stays, so yaw is pulled back
+	for i := 0; i < 100; i++ {
+		feedStill(filter, &timeMs, 0.1, 0.1)
+		filter.UpdateHeading(90)
+	}
+	if yaw := filter.Attitude().Yaw; math.Abs(yaw) > 0.1 {
+		t.Errorf("yaw with magnetometer correction = %f; expected about 0 instead of 1", yaw)
+	}
+
+	// turning 90 degrees clockwise is yaw -pi/2
+	for i := 0; i < 200; i++ {
+		filter.UpdateHeading(180)
+	}
+	if yaw := filter.Attitude().Yaw; math.Abs(yaw+math.Pi/2) > 0.01 {
+		t.Errorf("yaw at heading 180 = %f; expected %f", yaw, -math.Pi/2)
+	}
+
+	// recentering keeps the magnetic correction
+	filter.Recenter()
+	filter.UpdateHeading(180)
+	if yaw := filter.Attitude().Yaw; math.Abs(yaw) > 1e-9 {
+		t.Errorf("yaw after Recenter() = %f; expected 0", yaw)
+	}
+}
@@ -0,0 +1,201 @@
+package fusion
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+// DEFAULT_MOTION_THRESHOLD is the gyroscope magnitude in rad/s a head turn must exceed to count as a motion onset.
+const DEFAULT_MOTION_THRESHOLD = 1.0
+
+// ErrNotEnoughLatencySamples is returned by LatencyMeter.Report until both IMU and VSync events were recorded.
+var ErrNotEnoughLatencySamples = errors.New("not enough IMU and VSync events to estimate latency")
+
+// LatencyMeter estimates the parts of the motion-to-photon latency that can be observed from the host, by
+// correlating when IMU samples and VSync events arrive. It cannot see photons, so the time the application
+// takes to render and the constant part of the USB transport delay are not included, see LatencyReport.
+type LatencyMeter struct {
+	motionThreshold float64
+
+	// mutex for thread safety
+	mutex sync.Mutex
+	// imu are the IMU samples in the order received
+	imu []latencySample
+	// vsyncs are when VSync events were received
+	vsyncs []time.Time
+	// onsets are the indexes into imu of the motion onsets
+	onsets []int
+	moving bool
+}
+
+type latencySample struct {
+	// deviceMs is the IMU timestamp in miliseconds since boot
+	deviceMs uint64
+	received time.Time
+}
+
+// LatencyReport is the outcome of a LatencyMeter.
+type LatencyReport struct {
+	IMUSamples   int
+	VSyncs       int
+	MotionOnsets int
+	// IMURate is the IMU sample rate in Hz, from the IMU timestamps
+	IMURate float64
+	// IMUJitter is how much later than the least delayed sample the median sample arrives, a lower bound of the
+	// IMU transport delay. IMUJitterP95 is the same for the 95th percentile.
+	IMUJitter    time.Duration
+	IMUJitterP95 time.Duration
+	// VSyncPeriod is the median time between VSync events, the display refresh period
+	VSyncPeriod time.Duration
+	// VSyncWait is the median time from an IMU sample arriving to the next VSync, taken over the motion onsets
+	// if any were recorded, or over all samples otherwise
+	VSyncWait time.Duration
+	// Estimate is IMUJitter plus VSyncWait plus one VSyncPeriod to scan out the frame
+	Estimate time.Duration
+}
+
+func (r *LatencyReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "samples: %d IMU at %.0f Hz, %d VSync, %d motion onsets\n", r.IMUSamples, r.IMURate, r.VSyncs, r.MotionOnsets)
+	fmt.Fprintf(&b, "IMU delivery jitter: median %v, p95 %v\n", r.IMUJitter, r.IMUJitterP95)
+	fmt.Fprintf(&b, "VSync period: %v (%.1f Hz)\n", r.VSyncPeriod, float64(time.Second)/float64(r.VSyncPeriod))
+	fmt.Fprintf(&b, "IMU sample to next VSync: median %v\n", r.VSyncWait)
+	fmt.Fprintf(&b, "estimated motion-to-photon latency: %v\n", r.Estimate)
+	b.WriteString("not included: rendering time of the application, constant USB transport delay, panel response time\n")
+	return b.String()
+}
+
+// NewLatencyMeter creates a LatencyMeter counting gyroscope magnitudes above motionThreshold in rad/s as motion,
+// e.g. DEFAULT_MOTION_THRESHOLD.
+func NewLatencyMeter(motionThreshold float64) *LatencyMeter {
+	return &LatencyMeter{motionThreshold: motionThreshold}
+}
+
+// AddIMU records an IMU sample as received now. It can be used as a device.IMUEventHandler.
+func (m *LatencyMeter) AddIMU(imu *device.IMUEvent) {
+	m.AddIMUAt(imu, time.Now())
+}
+
+// AddIMUAt records an IMU sample received at the given time.
+func (m *LatencyMeter) AddIMUAt(imu *device.IMUEvent, received time.Time) {
+	if imu == nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.imu = append(m.imu, latencySample{deviceMs: imu.TimeSinceBoot, received: received})
+	if imu.Gyroscope == nil {
+		return
+	}
+	gyro := imu.Gyroscope
+	magnitude := math.Sqrt(float64(gyro.X*gyro.X + gyro.Y*gyro.Y + gyro.Z*gyro.Z))
+	if !m.moving && magnitude > m.motionThreshold {
+		m.moving = true
+		m.onsets = append(m.onsets, len(m.imu)-1)
+	} else if m.moving && magnitude < m.motionThreshold/2 {
+		m.moving = false
+	}
+}
+
+// AddVSync records a VSync event as received now. It can be used as a device.VSyncEventHandler.
+func (m *LatencyMeter) AddVSync(string) {
+	m.AddVSyncAt(time.Now())
+}
+
+// AddVSyncAt records a VSync event received at the given time.
+func (m *LatencyMeter) AddVSyncAt(received time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.vsyncs = append(m.vsyncs, received)
+}
+
+// Report estimates the latency from the events recorded so far.
+func (m *LatencyMeter) Report() (*LatencyReport, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if len(m.imu) < 2 || len(m.vsyncs) < 2 {
+		return nil, fmt.Errorf("%w: got %d IMU and %d VSync", ErrNotEnoughLatencySamples, len(m.imu), len(m.vsyncs))
+	}
+
+	report := &LatencyReport{IMUSamples: len(m.imu), VSyncs: len(m.vsyncs), MotionOnsets: len(m.onsets)}
+
+	first, last := m.imu[0], m.imu[len(m.imu)-1]
+	if last.deviceMs > first.deviceMs {
+		report.IMURate = float64(len(m.imu)-1) * 1000 / float64(last.deviceMs-first.deviceMs)
+	}
+
+	// the transport delay of each sample is its offset minus the unknown constant delay, so offsets relative to
+	// the least delayed sample tell the variable part
+	offsets := make([]time.Duration, len(m.imu))
+	for i, sample := range m.imu {
+		offsets[i] = time.Duration(sample.received.UnixNano()) - time.Duration(sample.deviceMs)*time.Millisecond
+	}
+	minOffset := slices.Min(offsets)
+	jitters := make([]time.Duration, len(offsets))
+	for i, offset := range offsets {
+		jitters[i] = offset - minOffset
+	}
+	report.IMUJitter = percentile(jitters, 0.5)
+	report.IMUJitterP95 = percentile(jitters, 0.95)
+
+	vsyncs := slices.Clone(m.vsyncs)
+	slices.SortFunc(vsyncs, func(a, b time.Time) int { return a.Compare(b) })
+	periods := make([]time.Duration, 0, len(vsyncs)-1)
+	for i := 1; i < len(vsyncs); i++ {
+		periods = append(periods, vsyncs[i].Sub(vsyncs[i-1]))
+	}
+	report.VSyncPeriod = percentile(periods, 0.5)
+
+	indexes := m.onsets
+	if len(indexes) == 0 {
+		indexes = make([]int, len(m.imu))
+		for i := range indexes {
+			indexes[i] = i
+		}
+	}
+	waits := []time.Duration{}
+	for _, i := range indexes {
+		received := m.imu[i].received
+		next, _ := slices.BinarySearchFunc(vsyncs, received, func(a, b time.Time) int { return a.Compare(b) })
+		if next < len(vsyncs) {
+			waits = append(waits, vsyncs[next].Sub(received))
+		}
+	}
+	if len(waits) == 0 {
+		return nil, fmt.Errorf("%w: no VSync after the IMU samples", ErrNotEnoughLatencySamples)
+	}
+	report.VSyncWait = percentile(waits, 0.5)
+
+	report.Estimate = report.IMUJitter + report.VSyncWait + report.VSyncPeriod
+	return report, nil
+}
+
+// Reset discards all recorded events.
+func (m *LatencyMeter) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.imu = nil
+	m.vsyncs = nil
+	m.onsets = nil
+	m.moving = false
+}
+
+// percentile returns the nearest rank percentile p in [0, 1] of values, which must not be empty.
+func percentile(values []time.Duration, p float64) time.Duration {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
@@ -0,0 +1,59 @@
+package fusion_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestLatencyMeterReport(t *testing.T) {
+	meter := fusion.NewLatencyMeter(fusion.DEFAULT_MOTION_THRESHOLD)
+	if _, err := meter.Report(); !errors.Is(err, fusion.ErrNotEnoughLatencySamples) {
+		t.Fatalf("Report() without events = %v, want ErrNotEnoughLatencySamples", err)
+	}
+
+	// 100 Hz IMU where every 10th sample is delayed by 2 ms, a head turn from sample 50 to 59, 62.5 Hz VSync
+	start := time.Unix(1700000000, 0)
+	for i := 0; i < 100; i++ {
+		deviceMs := uint64(10 * i)
+		received := start.Add(time.Duration(deviceMs) * time.Millisecond)
+		if i%10 == 0 {
+			received = received.Add(2 * time.Millisecond)
+		}
+		gyro := &device.GyroscopeVector{}
+		if i >= 50 && i < 60 {
+			gyro.Z = 3
+		}
+		meter.AddIMUAt(&device.IMUEvent{Gyroscope: gyro, Accelerometer: &device.AccelerometerVector{}, TimeSinceBoot: deviceMs}, received)
+	}
+	for vsync := start.Add(5 * time.Millisecond); vsync.Before(start.Add(time.Second)); vsync = vsync.Add(16 * time.Millisecond) {
+		meter.AddVSyncAt(vsync)
+	}
+
+	report, err := meter.Report()
+	if err != nil {
+		t.Fatalf("Report() = %v", err)
+	}
+	if report.MotionOnsets != 1 {
+		t.Errorf("MotionOnsets = %d, want 1", report.MotionOnsets)
+	}
+	if report.IMURate < 99 || report.IMURate > 101 {
+		t.Errorf("IMURate = %f, want 100", report.IMURate)
+	}
+	if report.IMUJitter != 0 || report.IMUJitterP95 != 2*time.Millisecond {
+		t.Errorf("IMUJitter = %v, p95 %v; want 0, 2ms", report.IMUJitter, report.IMUJitterP95)
+	}
+	if report.VSyncPeriod != 16*time.Millisecond {
+		t.Errorf("VSyncPeriod = %v, want 16ms", report.VSyncPeriod)
+	}
+	// the onset arrives at 502 ms, the next VSync is at 517 ms
+	if report.VSyncWait != 15*time.Millisecond {
+		t.Errorf("VSyncWait = %v, want 15ms", report.VSyncWait)
+	}
+	if report.Estimate != 31*time.Millisecond {
+		t.Errorf("Estimate = %v, want 31ms", report.Estimate)
+	}
+}
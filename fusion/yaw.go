@@ -0,0 +1,113 @@
+package fusion
+
+import (
+	"math"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+const (
+	// stillGyroscopeRate and stillAcceleration bound the rotation in radians per second and the deviation of the
+	// acceleration from gravity in m/s² while the glass counts as lying still
+	stillGyroscopeRate = 0.05
+	stillAcceleration  = 0.3
+	// stillSeconds is how long the glass must lie still before its yaw rate is taken for drift
+	stillSeconds = 1.0
+	// driftTimeConstant is how fast the estimated drift follows the yaw rate while still, in seconds
+	driftTimeConstant = 5.0
+
+	standardGravity = 9.81
+)
+
+// YawCorrection tells ComplementaryFilter how to keep yaw from drifting, as the gyroscope drifts on its own and the
+// magnetometer is disturbed by metal and electronics indoors. The zero value integrates the gyroscope as is. Yaw can
+// also be zeroed any time with ComplementaryFilter.Recenter.
+type YawCorrection struct {
+	// DeadBand ignores yaw rates below it in radians per second, so noise while the head is still does not add up,
+	// 0 to integrate every rate. Slow head turns below it are lost too.
+	DeadBand float64
+	// EstimateDrift learns the yaw rate the gyroscope reports while the glass lies still and subtracts it afterwards
+	EstimateDrift bool
+	// MagnetometerWeight pulls yaw towards the magnetic heading by this fraction on every heading fed with
+	// ComplementaryFilter.UpdateHeading, e.g. by a Compass, 0 to ignore them. Small values, e.g. 0.02, smooth out
+	// magnetic noise.
+	MagnetometerWeight float64
+}
+
+// SetYawCorrection sets how yaw is kept from drifting.
+func (f *ComplementaryFilter) SetYawCorrection(correction YawCorrection) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.yawCorrection = correction
+}
+
+// Recenter makes the current direction yaw 0, keeping roll and pitch.
+func (f *ComplementaryFilter) Recenter() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	// the magnetic heading keeps pointing at the same direction, now relative to the new center
+	f.headingOffset = wrapAngle(f.headingOffset - f.attitude.Yaw)
+	f.attitude.Yaw = 0
+}
+
+// YawDrift returns the drift of the gyroscope around yaw estimated with YawCorrection.EstimateDrift, in radians per
+// second.
+func (f *ComplementaryFilter) YawDrift() float64 {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.yawDrift
+}
+
+// UpdateHeading pulls yaw towards a magnetic heading in degrees clockwise from north, as computed by Compass, by
+// YawCorrection.MagnetometerWeight. Yaw stays relative to the direction at start: the first heading only sets where
+// that direction points.
+func (f *ComplementaryFilter) UpdateHeading(magnetic float64) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	weight := min(max(f.yawCorrection.MagnetometerWeight, 0), 1)
+	if weight == 0 || !f.initialized {
+		return
+	}
+
+	// yaw turns counterclockwise, headings clockwise
+	magneticYaw := -magnetic * math.Pi / 180
+	if !f.headingInitialized {
+		f.headingOffset = wrapAngle(f.attitude.Yaw - magneticYaw)
+		f.headingInitialized = true
+		return
+	}
+	target := wrapAngle(magneticYaw + f.headingOffset)
+	f.attitude.Yaw = wrapAngle(f.attitude.Yaw + weight*wrapAngle(target-f.attitude.Yaw))
+}
+
+// correctYawRate applies the YawCorrection to the yaw rate of imu, dt seconds after the previous one. The caller
+// holds the mutex.
+func (f *ComplementaryFilter) correctYawRate(imu *device.IMUEvent, dt float64) float64 {
+	rate := float64(imu.Gyroscope.Z)
+
+	if f.yawCorrection.EstimateDrift {
+		if isStill(imu) {
+			f.stillFor += dt
+		} else {
+			f.stillFor = 0
+		}
+		if f.stillFor >= stillSeconds {
+			f.yawDrift += (rate - f.yawDrift) * min(dt/driftTimeConstant, 1)
+		}
+		rate -= f.yawDrift
+	}
+
+	if math.Abs(rate) < f.yawCorrection.DeadBand {
+		return 0
+	}
+	return rate
+}
+
+// isStill tells whether the glass neither rotates nor accelerates beyond gravity.
+func isStill(imu *device.IMUEvent) bool {
+	gyro, accel := imu.Gyroscope, imu.Accelerometer
+	rotation := math.Sqrt(float64(gyro.X*gyro.X + gyro.Y*gyro.Y + gyro.Z*gyro.Z))
+	acceleration := math.Sqrt(float64(accel.X*accel.X + accel.Y*accel.Y + accel.Z*accel.Z))
+	return rotation < stillGyroscopeRate && math.Abs(acceleration-standardGravity) < stillAcceleration
+}
@@ -3,12 +3,20 @@ module xreal-light-xr-go
 go 1.22.2
 
 require (
+	github.com/godbus/dbus/v5 v5.1.0
+	github.com/gorilla/websocket v1.5.3
 	github.com/gotmc/libusb/v2 v2.3.1
 	github.com/peterh/liner v1.2.2
 	github.com/sstallion/go-hid v0.14.1
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/metric v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+	go.uber.org/goleak v1.3.0
 )
 
 require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/mattn/go-runewidth v0.0.3 // indirect
 	golang.org/x/sys v0.14.0 // indirect
 )
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/peterh/liner"
+)
+
+const historyFilename = ".xrealxr_history"
+
+func defaultHistoryFilePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, historyFilename)
+}
+
+// loadHistory reads command history persisted by previous sessions, if any.
+func loadHistory(line *liner.State, path string) {
+	if path == "" {
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn(fmt.Sprintf("failed to open history file %s: %v", path, err))
+		}
+		return
+	}
+	defer f.Close()
+
+	if _, err := line.ReadHistory(f); err != nil {
+		slog.Warn(fmt.Sprintf("failed to read history file %s: %v", path, err))
+	}
+}
+
+// saveHistory persists the command history so the next session can reuse it.
+func saveHistory(line *liner.State, path string) {
+	if path == "" {
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("failed to open history file %s: %v", path, err))
+		return
+	}
+	defer f.Close()
+
+	if _, err := line.WriteHistory(f); err != nil {
+		slog.Warn(fmt.Sprintf("failed to write history file %s: %v", path, err))
+	}
+}
+
+// handleHistoryCommand lists the history entries containing the optional pattern.
+// Use Ctrl-R at the prompt for interactive reverse search.
+func handleHistoryCommand(line *liner.State, input string) {
+	pattern := strings.TrimSpace(strings.TrimPrefix(input, "history"))
+
+	var buf bytes.Buffer
+	if _, err := line.WriteHistory(&buf); err != nil {
+		slog.Error(fmt.Sprintf("failed to get history: %v", err))
+		return
+	}
+
+	for i, entry := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if entry == "" || !strings.Contains(entry, pattern) {
+			continue
+		}
+		slog.Info(fmt.Sprintf("%4d  %s", i+1, entry))
+	}
+}
@@ -0,0 +1,79 @@
+package hooks
+
+import (
+	"fmt"
+	"log/slog"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+// Wrap returns d firing the events of its key, proximity and temperature handlers and of Disconnect. The handlers set
+// through the returned Device keep being called after the rules fired, until then the events are logged. Temperature
+// reporting is enabled if a rule waits for EVENT_OVERHEATING. EVENT_WORN and EVENT_REMOVED fire for the proximity
+// states d reports, wrap d with device.FilterEvents first so a flapping sensor does not run them over and over.
+func (e *Engine) Wrap(d device.Device) device.Device {
+	hooked := &hookedDevice{Device: d, engine: e}
+	hooked.SetKeyEventHandler(func(key device.KeyEvent) {
+		slog.Info(fmt.Sprintf("Key pressed: %s", key.String()))
+	})
+	hooked.SetProximityEventHandler(func(proximity device.ProximityEvent) {
+		slog.Info(fmt.Sprintf("Proximity: %s", proximity.String()))
+	})
+	hooked.SetTemperatureEventHandler(func(value string) {
+		slog.Info(fmt.Sprintf("Temperature: %s", value))
+	})
+
+	for _, rule := range e.rules {
+		if rule.Event == EVENT_OVERHEATING {
+			if err := d.EnableTemperature(true); err != nil {
+				slog.Warn(fmt.Sprintf("failed to enable temperature reporting for overheating hooks: %v", err))
+			}
+			break
+		}
+	}
+	return hooked
+}
+
+// hookedDevice overrides the methods of the wrapped Device whose events fire rules.
+type hookedDevice struct {
+	device.Device
+	engine *Engine
+}
+
+func (d *hookedDevice) Disconnect() error {
+	err := d.Device.Disconnect()
+	d.engine.Fire(EVENT_DISCONNECTED, "")
+	return err
+}
+
+func (d *hookedDevice) SetKeyEventHandler(handler device.KeyEventHandler) {
+	d.Device.SetKeyEventHandler(func(key device.KeyEvent) {
+		d.engine.Fire(EVENT_KEY, key.String())
+		if handler != nil {
+			handler(key)
+		}
+	})
+}
+
+func (d *hookedDevice) SetProximityEventHandler(handler device.ProximityEventHandler) {
+	d.Device.SetProximityEventHandler(func(proximity device.ProximityEvent) {
+		switch proximity {
+		case device.PROXIMITY_NEAR:
+			d.engine.Fire(EVENT_WORN, proximity.String())
+		case device.PROXIMITY_FAR:
+			d.engine.Fire(EVENT_REMOVED, proximity.String())
+		}
+		if handler != nil {
+			handler(proximity)
+		}
+	})
+}
+
+func (d *hookedDevice) SetTemperatureEventHandler(handler device.TemperatureEventHandlder) {
+	d.Device.SetTemperatureEventHandler(func(value string) {
+		d.engine.Fire(EVENT_OVERHEATING, value)
+		if handler != nil {
+			handler(value)
+		}
+	})
+}
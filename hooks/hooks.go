@@ -0,0 +1,284 @@
+// Package hooks runs user commands or webhooks on glass events, so the glass can drive automation, e.g. locking the
+// screen once it is taken off, without writing Go. Rules are listed in a file, one per line, as the event, the action
+// and its target:
+//
+//	# event          action    target
+//	worn             exec      loginctl unlock-session
+//	removed          exec      loginctl lock-session
+//	key:UP           exec      playerctl next
+//	overheating:45   webhook   http://localhost:8123/api/webhook/xreal
+//	disconnected     webhook   http://localhost:8123/api/webhook/xreal
+//
+// exec runs the target with sh -c, passing the event in the XREAL_EVENT, XREAL_VALUE and XREAL_TIME environment
+// variables. webhook POSTs the event as JSON, e.g. {"event":"key","value":"UP","time":"2024-06-12T13:45:00Z"}, to
+// the target URL. Actions run in the background and fail after ACTION_TIMEOUT, their errors are logged.
+package hooks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Event is what a rule waits for.
+type Event string
+
+const (
+	// EVENT_CONNECTED fires once a glass is connected
+	EVENT_CONNECTED Event = "connected"
+	// EVENT_DISCONNECTED fires once the glass is disconnected, e.g. as it was unplugged
+	EVENT_DISCONNECTED Event = "disconnected"
+	// EVENT_WORN fires when the proximity sensor reports the glass is put on
+	EVENT_WORN Event = "worn"
+	// EVENT_REMOVED fires when the proximity sensor reports the glass is taken off
+	EVENT_REMOVED Event = "removed"
+	// EVENT_KEY fires on key presses, key:UP and key:DOWN fire on the one key only
+	EVENT_KEY Event = "key"
+	// EVENT_OVERHEATING fires when a temperature event reaches the threshold given as overheating:<threshold>, and
+	// again only after a later one dropped below it
+	EVENT_OVERHEATING Event = "overheating"
+)
+
+var supportedEvents = map[Event]struct{}{
+	EVENT_CONNECTED:    {},
+	EVENT_DISCONNECTED: {},
+	EVENT_WORN:         {},
+	EVENT_REMOVED:      {},
+	EVENT_KEY:          {},
+	EVENT_OVERHEATING:  {},
+}
+
+// Action is what a rule does once its event fires.
+type Action string
+
+const (
+	// ACTION_EXEC runs the target as a shell command
+	ACTION_EXEC Action = "exec"
+	// ACTION_WEBHOOK POSTs the event as JSON to the target URL
+	ACTION_WEBHOOK Action = "webhook"
+)
+
+// ACTION_TIMEOUT is how long an action may run before it is stopped.
+const ACTION_TIMEOUT = 30 * time.Second
+
+// Rule runs Action on Target when Event fires.
+type Rule struct {
+	Event Event
+	// Key limits EVENT_KEY to the key of this name, e.g. UP, empty for every key
+	Key string
+	// Threshold is the temperature EVENT_OVERHEATING fires at
+	Threshold float64
+	Action    Action
+	Target    string
+}
+
+// Occurrence is an event that fired, sent to webhooks as JSON.
+type Occurrence struct {
+	Event Event `json:"event"`
+	// Value is the key, the proximity or the temperature as reported by the glass, empty for the other events
+	Value string    `json:"value"`
+	Time  time.Time `json:"time"`
+}
+
+// Engine runs the actions of the rules matching the events fired.
+type Engine struct {
+	rules  []Rule
+	client *http.Client
+
+	// mutex for thread safety
+	mutex sync.Mutex
+	// overheated tells which of the rules fired for a temperature that did not drop below the threshold since
+	overheated map[int]bool
+	running    sync.WaitGroup
+}
+
+// NewEngine creates an Engine running the rules.
+func NewEngine(rules []Rule) *Engine {
+	return &Engine{
+		rules:      rules,
+		client:     &http.Client{Timeout: ACTION_TIMEOUT},
+		overheated: make(map[int]bool),
+	}
+}
+
+// LoadEngine reads the rules from the file at path, see the package doc for the format.
+func LoadEngine(path string) (*Engine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hooks file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var rules []Rule
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule, err := ParseRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hooks file %s line %d: %w", path, lineNumber, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read hooks file %s: %w", path, err)
+	}
+	return NewEngine(rules), nil
+}
+
+// ParseRule parses a line of the hooks file, `event[:argument] action target`.
+func ParseRule(line string) (Rule, error) {
+	event, rest := cutField(line)
+	action, target := cutField(rest)
+	if event == "" || action == "" || target == "" {
+		return Rule{}, fmt.Errorf("want `event[:argument] action target`")
+	}
+
+	var rule Rule
+	name, argument, hasArgument := strings.Cut(event, ":")
+	rule.Event = Event(name)
+	if _, ok := supportedEvents[rule.Event]; !ok {
+		return Rule{}, fmt.Errorf("unknown event %s", name)
+	}
+	switch rule.Event {
+	case EVENT_KEY:
+		rule.Key = strings.ToUpper(argument)
+	case EVENT_OVERHEATING:
+		threshold, err := strconv.ParseFloat(argument, 64)
+		if err != nil {
+			return Rule{}, fmt.Errorf("want overheating:<threshold>, got %s", event)
+		}
+		rule.Threshold = threshold
+	default:
+		if hasArgument {
+			return Rule{}, fmt.Errorf("event %s takes no argument", name)
+		}
+	}
+
+	rule.Action = Action(action)
+	rule.Target = target
+	switch rule.Action {
+	case ACTION_EXEC:
+	case ACTION_WEBHOOK:
+		if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+			return Rule{}, fmt.Errorf("webhook target %s is not an http or https URL", target)
+		}
+	default:
+		return Rule{}, fmt.Errorf("unknown action %s: want %s or %s", action, ACTION_EXEC, ACTION_WEBHOOK)
+	}
+	return rule, nil
+}
+
+// cutField splits s into its first whitespace separated field and the rest, both trimmed.
+func cutField(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, unicode.IsSpace)
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+// Rules returns the rules run by e.
+func (e *Engine) Rules() []Rule {
+	return e.rules
+}
+
+// Fire runs the actions of the rules matching the event in the background. value is the key, the proximity or the
+// temperature as reported by the glass, empty for the other events.
+func (e *Engine) Fire(event Event, value string) {
+	occurrence := Occurrence{Event: event, Value: value, Time: time.Now()}
+	for i, rule := range e.rules {
+		if rule.Event != event || !e.matches(i, rule, value) {
+			continue
+		}
+		e.running.Add(1)
+		go func() {
+			defer e.running.Done()
+			if err := e.run(rule, occurrence); err != nil {
+				slog.Error(fmt.Sprintf("hook %s %s %s failed: %v", event, rule.Action, rule.Target, err))
+			}
+		}()
+	}
+}
+
+func (e *Engine) matches(i int, rule Rule, value string) bool {
+	switch rule.Event {
+	case EVENT_KEY:
+		return rule.Key == "" || rule.Key == value
+	case EVENT_OVERHEATING:
+		temperature, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			slog.Debug(fmt.Sprintf("hooks: ignoring temperature %q: %v", value, err))
+			return false
+		}
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		overheated := temperature >= rule.Threshold
+		fire := overheated && !e.overheated[i]
+		e.overheated[i] = overheated
+		return fire
+	default:
+		return true
+	}
+}
+
+func (e *Engine) run(rule Rule, occurrence Occurrence) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ACTION_TIMEOUT)
+	defer cancel()
+
+	switch rule.Action {
+	case ACTION_EXEC:
+		cmd := exec.CommandContext(ctx, "sh", "-c", rule.Target)
+		cmd.Env = append(os.Environ(),
+			"XREAL_EVENT="+string(occurrence.Event),
+			"XREAL_VALUE="+occurrence.Value,
+			"XREAL_TIME="+occurrence.Time.Format(time.RFC3339),
+		)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, bytes.TrimSpace(output))
+		}
+		return nil
+	case ACTION_WEBHOOK:
+		body, err := json.Marshal(occurrence)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost, rule.Target, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		request.Header.Set("Content-Type", "application/json")
+		response, err := e.client.Do(request)
+		if err != nil {
+			return err
+		}
+		response.Body.Close()
+		if response.StatusCode/100 != 2 {
+			return fmt.Errorf("unexpected status %s", response.Status)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown action %s", rule.Action)
+	}
+}
+
+// Wait blocks until the actions running are done.
+func (e *Engine) Wait() {
+	e.running.Wait()
+}
@@ -0,0 +1,120 @@
+package hooks_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"xreal-light-xr-go/hooks"
+)
+
+func TestParseRule(t *testing.T) {
+	testCases := []struct {
+		line string
+		want hooks.Rule
+	}{
+		{"worn exec loginctl unlock-session", hooks.Rule{Event: hooks.EVENT_WORN, Action: hooks.ACTION_EXEC, Target: "loginctl unlock-session"}},
+		{"key:up\texec  playerctl next", hooks.Rule{Event: hooks.EVENT_KEY, Key: "UP", Action: hooks.ACTION_EXEC, Target: "playerctl next"}},
+		{"overheating:45.5 webhook https://example.com/hook", hooks.Rule{Event: hooks.EVENT_OVERHEATING, Threshold: 45.5, Action: hooks.ACTION_WEBHOOK, Target: "https://example.com/hook"}},
+	}
+	for _, tc := range testCases {
+		got, err := hooks.ParseRule(tc.line)
+		if err != nil {
+			t.Errorf("ParseRule(%q) = %v", tc.line, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseRule(%q) = %+v, want %+v", tc.line, got, tc.want)
+		}
+	}
+
+	for _, line := range []string{
+		"worn exec",
+		"sneezed exec true",
+		"worn:1 exec true",
+		"overheating exec true",
+		"removed notify true",
+		"removed webhook ftp://example.com",
+	} {
+		if _, err := hooks.ParseRule(line); err == nil {
+			t.Errorf("ParseRule(%q) = nil, want error", line)
+		}
+	}
+}
+
+func TestLoadEngine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hooks")
+	content := "# event action target\n\nworn exec true\nkey:DOWN exec true\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	engine, err := hooks.LoadEngine(path)
+	if err != nil {
+		t.Fatalf("LoadEngine() = %v", err)
+	}
+	if len(engine.Rules()) != 2 {
+		t.Errorf("got %d rules, want 2", len(engine.Rules()))
+	}
+
+	if err := os.WriteFile(path, []byte("worn exec true\nworn\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hooks.LoadEngine(path); err == nil {
+		t.Errorf("LoadEngine() = nil, want error for line 2")
+	}
+}
+
+func TestFireExec(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "output")
+	engine := hooks.NewEngine([]hooks.Rule{
+		{Event: hooks.EVENT_KEY, Key: "UP", Action: hooks.ACTION_EXEC, Target: `echo "$XREAL_EVENT $XREAL_VALUE" >> ` + output},
+	})
+
+	engine.Fire(hooks.EVENT_KEY, "DOWN")
+	engine.Fire(hooks.EVENT_KEY, "UP")
+	engine.Fire(hooks.EVENT_WORN, "NEAR")
+	engine.Wait()
+
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "key UP\n" {
+		t.Errorf("output = %q, want the UP key only", got)
+	}
+}
+
+func TestFireWebhookOverheating(t *testing.T) {
+	var mutex sync.Mutex
+	var received []hooks.Occurrence
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var occurrence hooks.Occurrence
+		if err := json.NewDecoder(r.Body).Decode(&occurrence); err != nil {
+			t.Errorf("failed to decode webhook: %v", err)
+		}
+		mutex.Lock()
+		received = append(received, occurrence)
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	engine := hooks.NewEngine([]hooks.Rule{
+		{Event: hooks.EVENT_OVERHEATING, Threshold: 45, Action: hooks.ACTION_WEBHOOK, Target: server.URL},
+	})
+	// fires when reaching the threshold, and again only after dropping below it
+	for _, temperature := range []string{"40", "45", "47.5", "not a number", "44", "46"} {
+		engine.Fire(hooks.EVENT_OVERHEATING, temperature)
+		engine.Wait()
+	}
+
+	if len(received) != 2 || received[0].Value != "45" || received[1].Value != "46" {
+		t.Fatalf("received %+v, want 45 and 46", received)
+	}
+	if received[0].Event != hooks.EVENT_OVERHEATING || received[0].Time.IsZero() {
+		t.Errorf("received %+v, want the event and its time", received[0])
+	}
+}
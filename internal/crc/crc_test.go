@@ -3,7 +3,7 @@ package crc_test
 import (
 	"testing"
 
-	"xreal-light-xr-go/crc"
+	"xreal-light-xr-go/internal/crc"
 )
 
 func TestCRC32(t *testing.T) {
@@ -0,0 +1,451 @@
+package device
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+	"time"
+
+	"xreal-light-xr-go/constant"
+	"xreal-light-xr-go/storage"
+)
+
+type AirModel int
+
+const (
+	AIR_MODEL_UNKNOWN AirModel = iota
+	AIR_MODEL_AIR
+	AIR_MODEL_AIR_2
+	AIR_MODEL_AIR_2_PRO
+	AIR_MODEL_AIR_2_ULTRA
+)
+
+func (model AirModel) String() string {
+	switch model {
+	case AIR_MODEL_AIR:
+		return constant.XREAL_AIR
+	case AIR_MODEL_AIR_2:
+		return constant.XREAL_AIR_2
+	case AIR_MODEL_AIR_2_PRO:
+		return constant.XREAL_AIR_2_PRO
+	case AIR_MODEL_AIR_2_ULTRA:
+		return constant.XREAL_AIR_2_ULTRA
+	default:
+		return "unknown"
+	}
+}
+
+func (model AirModel) PID() uint16 {
+	switch model {
+	case AIR_MODEL_AIR:
+		return XREAL_AIR_MCU_PID
+	case AIR_MODEL_AIR_2:
+		return XREAL_AIR_2_MCU_PID
+	case AIR_MODEL_AIR_2_PRO:
+		return XREAL_AIR_2_PRO_MCU_PID
+	default:
+		return 0
+	}
+}
+
+func airModelFromPID(pid uint16) AirModel {
+	switch pid {
+	case XREAL_AIR_MCU_PID:
+		return AIR_MODEL_AIR
+	case XREAL_AIR_2_MCU_PID:
+		return AIR_MODEL_AIR_2
+	case XREAL_AIR_2_PRO_MCU_PID:
+		return AIR_MODEL_AIR_2_PRO
+	default:
+		return AIR_MODEL_UNKNOWN
+	}
+}
+
+type xrealAir struct {
+	model AirModel
+	mcu   *xrealAirMCU
+}
+
+func (a *xrealAir) Name() string {
+	return a.model.String()
+}
+
+func (a *xrealAir) PID() uint16 {
+	return a.model.PID()
+}
+
+func (a *xrealAir) VID() uint16 {
+	return XREAL_AIR_SERIES_MCU_VID
+}
+
+func (a *xrealAir) Disconnect() error {
+	return fmt.Errorf("unimplemneted")
+	// errMCU := a.mcu.disconnect()
+
+	// if errMCU != nil {
+	// 	return errMCU
+	// }
+	// return nil
+}
+
+func (a *xrealAir) Connect() error {
+	errMCU := a.mcu.connectAndInitialize(a.VID(), a.PID())
+
+	if errMCU != nil {
+		a.Disconnect()
+		return errMCU
+	}
+	return nil
+}
+
+func (a *xrealAir) SetRetryPolicy(policy RetryPolicy) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetRole() (Role, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) RequestControl(timeout time.Duration) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) ReleaseControl() error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetSerial() (string, error) {
+	return "", fmt.Errorf("unimplemneted")
+	// return a.mcu.getSerial()
+}
+
+func (a *xrealAir) GetFirmwareVersion() (string, error) {
+	return "", fmt.Errorf("unimplemneted")
+	// if a.mcu.device == nil {
+	// 	return "", fmt.Errorf("glass device is not connected yet")
+	// }
+	// return a.mcu.glassFirmware, nil
+}
+
+func (a *xrealAir) GetDisplayMode() (DisplayMode, error) {
+	return DISPLAY_MODE_UNKNOWN, fmt.Errorf("unimplemneted")
+	// return a.mcu.getDisplayMode()
+}
+
+func (a *xrealAir) SetDisplayMode(mode DisplayMode) error {
+	return fmt.Errorf("unimplemneted")
+	// return a.mcu.setDisplayMode(mode)
+}
+
+func (a *xrealAir) GetBrightnessLevel() (string, error) {
+	return "", fmt.Errorf("unimplemneted")
+	// return a.mcu.getBrightnessLevel()
+}
+
+func (a *xrealAir) SetBrightnessLevel(level string) error {
+	return fmt.Errorf("unimplemneted")
+	// return a.mcu.setBrightnessLevel(level)
+}
+
+func (a *xrealAir) GetOLEDBrightnessLevel() (string, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) SetOLEDBrightnessLevel(level string) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetOLEDBrightnessBrit() (string, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetKeySwitchEnabled() (bool, error) {
+	return false, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) SetKeySwitchEnabled(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetDefault2DEnabled() (bool, error) {
+	return false, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) SetDefault2DEnabled(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) EnterSBS(duration time.Duration) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) ExitSBS() error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetConfigValue(key string) (string, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) SetConfigValue(key string, value string) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetSleepTime() (string, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) SetSleepTime(seconds string) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetOrbitFunction() (string, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) SetOrbitFunction(open bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetSuperActive() (bool, error) {
+	return false, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) SetSuperActive(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetDisplayFirmware() (*DisplayVersion, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetDisplayHDCPVersion() (*DisplayVersion, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetStockFirmwareVersion() (string, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetOV580Info() (*OV580Info, error) {
+	return nil, unimplemented("GetOV580Info")
+}
+
+func (a *xrealAir) ReadOV580File(id uint8, options OV580FileOptions) (*OV580File, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) ResetSensors() error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) SetStreamWatchdog(policy *WatchdogPolicy) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetMCUInfo() (*ConnectionInfo, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetSensorInfo() (*ConnectionInfo, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetCameraInfo() ([]ConnectionInfo, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+// InvalidateInfoCache does nothing, as nothing is cached yet.
+func (a *xrealAir) InvalidateInfoCache() {}
+
+func (a *xrealAir) Idle() error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) SetFramePipeline(pipeline FramePipeline) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) SetCapturePolicy(policy CapturePolicy) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetRGBCameraEnabled() (bool, error) {
+	return false, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetClockSync() (*ClockSync, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetCapabilities() (*Capabilities, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) DisplayOff() error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) DisplayOn() error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetSLAMFrame() (image.Image, image.Image, time.Time, error) {
+	return nil, nil, time.Time{}, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetSLAMFrameRaw() (*SLAMFrame, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetSLAMStreamFormats() ([]StreamFormat, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) SetSLAMStreamConfig(config StreamConfig) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetRGBStreamFormats() ([]StreamFormat, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) SetRGBStreamConfig(config StreamConfig) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) EnableEventReporting(instruction CommandInstruction, enabled string) error {
+	return fmt.Errorf("unimplemneted")
+	// return a.mcu.enableEventReporting(instruction, enabled)
+}
+
+func (a *xrealAir) EnableVSync(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) EnableAmbientLight(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) EnableMagnetometer(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) EnableTemperature(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) EnableIMU(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) IsIMUEnabled() bool {
+	return false
+}
+
+func (a *xrealAir) ExecuteAsync(instruction CommandInstruction, payload []byte) *CommandFuture {
+	return resolvedCommandFuture(unimplemented("ExecuteAsync"))
+}
+
+func (a *xrealAir) SetAmbientLightEventHandler(handler AmbientLightEventHandler) {
+	a.mcu.deviceHandlers.AmbientLightEventHandler = handler
+}
+
+func (a *xrealAir) SetKeyEventHandler(handler KeyEventHandler) {
+	a.mcu.deviceHandlers.KeyEventHandler = handler
+}
+
+func (a *xrealAir) SetMagnetometerEventHandler(handler MagnetometerEventHandler) {
+	a.mcu.deviceHandlers.MagnetometerEventHandler = handler
+}
+
+func (a *xrealAir) SetProximityEventHandler(handler ProximityEventHandler) {
+	a.mcu.deviceHandlers.ProximityEventHandler = handler
+}
+
+func (a *xrealAir) SetTemperatureEventHandler(handler TemperatureEventHandlder) {
+	a.mcu.deviceHandlers.TemperatureEventHandlder = handler
+}
+
+func (a *xrealAir) SetVSyncEventHandler(handler VSyncEventHandler) {
+	a.mcu.deviceHandlers.VSyncEventHandler = handler
+}
+
+func (a *xrealAir) SetIMUEventHandler(handler IMUEventHandler) {
+	a.mcu.deviceHandlers.IMUEventHandler = handler
+}
+
+func (a *xrealAir) GetIMUEventHandler() IMUEventHandler {
+	return a.mcu.deviceHandlers.IMUEventHandler
+}
+
+func (a *xrealAir) SetResumedEventHandler(handler ResumedEventHandler) {
+	a.mcu.deviceHandlers.ResumedEventHandler = handler
+}
+
+func (a *xrealAir) SetErrorHandler(handler ErrorHandler) {
+	a.mcu.deviceHandlers.ErrorHandler = handler
+}
+
+func (a *xrealAir) ExecuteRaw(command Command, payload []byte) ([]byte, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) ExecuteRawOV580(command Command, value uint8) ([]byte, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) DevExecuteAndRead(device string, input []string) {
+	// if device == "mcu" {
+	// 	a.mcu.devExecuteAndRead(input)
+	// }
+}
+
+func (a *xrealAir) GetImages(folderpath string) ([]string, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) CaptureImages(store storage.Storage) ([]string, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (a *xrealAir) GetImagesDataDev(folderpath string) ([]string, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+// NewXREALAir creates a xrealAir instance initiating MCU connections.
+// TODO(happyz): Supports multiple glasses connected.
+func NewXREALAir() Device {
+	var a xrealAir
+
+	a.mcu = &xrealAirMCU{
+		deviceHandlers: &DeviceHandlers{
+			AmbientLightEventHandler: func(value uint16) {
+				slog.Info(fmt.Sprintf("Ambient light: %d", value))
+			},
+			KeyEventHandler: func(key KeyEvent) {
+				slog.Info(fmt.Sprintf("Key pressed: %s", key.String()))
+			},
+			MagnetometerEventHandler: func(vector *MagnetometerVector) {
+				slog.Info(fmt.Sprintf("Magnetometer: %s", vector.String()))
+			},
+			ProximityEventHandler: func(proximity ProximityEvent) {
+				slog.Info(fmt.Sprintf("Proximity: %s", proximity.String()))
+			},
+			TemperatureEventHandlder: func(value string) {
+				slog.Info(fmt.Sprintf("Temperature: %s", value))
+			},
+			VSyncEventHandler: func(value string) {
+				slog.Info(fmt.Sprintf("VSync: %s", value))
+			},
+			ResumedEventHandler: func() {
+				slog.Info("Resumed: glass reconnected")
+			},
+		},
+		packetResponseChannel:  make(chan *Packet),
+		stopHeartBeatChannel:   make(chan struct{}),
+		stopReadPacketsChannel: make(chan struct{}),
+	}
+
+	return &a
+}
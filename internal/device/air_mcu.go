@@ -21,6 +21,9 @@ const (
 	XREAL_AIR_2_MCU_PID      = uint16(0x0428)
 	XREAL_AIR_2_PRO_MCU_PID  = uint16(0x0432)
 	//TODO(happyz): Adds Ultra PID here
+
+	// XREAL_AIR_MCU_IF_NUM is the hid interface of the MCU, interface 3 is the IMU
+	XREAL_AIR_MCU_IF_NUM = 4
 )
 
 type xrealAirMCU struct {
@@ -54,7 +57,7 @@ func (a *xrealAirMCU) connectAndInitialize(vid uint16, pid uint16) error {
 
 	handle := C.hid_open(C.ushort(vid), C.ushort(pid), nil)
 	if handle == nil {
-		return fmt.Errorf("failed to open glass MCU")
+		return checkBeam(fmt.Errorf("failed to open glass MCU"))
 	}
 	defer C.hid_close(handle)
 
@@ -0,0 +1,214 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Role is how a process holds the glass. HID devices can be opened by several processes at once, but
+// concurrent writers corrupt the protocol, so only one process at a time is the controller.
+type Role string
+
+const (
+	// ROLE_CONTROLLER initializes the glass, sends heart beats and may run any command
+	ROLE_CONTROLLER = Role("controller")
+	// ROLE_OBSERVER only receives events, commands return ErrObserver
+	ROLE_OBSERVER = Role("observer")
+)
+
+// ErrObserver is returned by commands run while another process controls the glass, see RequestControl.
+var ErrObserver = errors.New("another process controls the glass, observers only receive events")
+
+const (
+	// controlPollFrequency is how often RequestControl checks whether the controller has released the glass
+	controlPollFrequency = 100 * time.Millisecond
+)
+
+// controlLock is an advisory file lock per glass deciding which process is the controller. The lock is dropped
+// by the OS when the process exits, so a crashed controller never blocks observers from taking over.
+type controlLock struct {
+	path string
+	// file is not nil while the lock is held
+	file *os.File
+}
+
+// controlLockDirs are the directories shared by all users to keep the control locks in, so processes of different
+// users see each other's lock. The first one writable by all users and sticky like /tmp is used, /run/lock is not on
+// every distribution and /tmp may be private to a systemd service; replaced in tests
+var controlLockDirs = []string{"/run/lock", os.TempDir()}
+
+func newControlLock(devicePath string) *controlLock {
+	dir := controlLockDirs[len(controlLockDirs)-1]
+	for _, candidate := range controlLockDirs {
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() && info.Mode().Perm()&0o002 != 0 && info.Mode()&os.ModeSticky != 0 {
+			dir = candidate
+			break
+		}
+	}
+	name := strings.Map(func(r rune) rune {
+		if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, devicePath)
+	return &controlLock{path: filepath.Join(dir, fmt.Sprintf("xreal-%s.lock", name))}
+}
+
+// calibrationPath is where the controller shares the OV580 calibration file, as observers cannot download it.
+func (c *controlLock) calibrationPath() string {
+	return strings.TrimSuffix(c.path, ".lock") + ".calibration"
+}
+
+// checkDir makes sure the directory of the lock is one where users cannot remove or replace the files of others,
+// i.e. only writable by its owner, or sticky like /tmp.
+func (c *controlLock) checkDir() error {
+	dir := filepath.Dir(c.path)
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check control lock directory %s: %w", dir, err)
+	}
+	if !info.IsDir() || (info.Mode().Perm()&0o022 != 0 && info.Mode()&os.ModeSticky == 0) {
+		return fmt.Errorf("control lock directory %s is not a directory protecting the files of each user", dir)
+	}
+	return nil
+}
+
+// tryAcquire takes the lock without blocking and tells whether it is now held.
+func (c *controlLock) tryAcquire() (bool, error) {
+	if c.file != nil {
+		return true, nil
+	}
+
+	if err := c.checkDir(); err != nil {
+		return false, err
+	}
+	// the lock file is only writable by the user creating it, others lock it read-only
+	file, err := openFileNoFollow(c.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err == nil {
+		// in spite of a restrictive umask, so other users can lock it
+		file.Chmod(0o644)
+	} else if errors.Is(err, os.ErrPermission) {
+		file, err = openFileNoFollow(c.path, os.O_RDONLY, 0)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open control lock %s: %w", c.path, err)
+	}
+
+	acquired, err := tryLockFile(file)
+	if err != nil || !acquired {
+		file.Close()
+		return false, err
+	}
+
+	// record who holds the lock so observers can tell, if the lock file is ours to write
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+
+	c.file = file
+	return true, nil
+}
+
+// acquire waits up to timeout for the lock.
+func (c *controlLock) acquire(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		acquired, err := c.tryAcquire()
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: still held by pid %s after %v", ErrObserver, c.holder(), timeout)
+		}
+		time.Sleep(controlPollFrequency)
+	}
+}
+
+func (c *controlLock) release() error {
+	if c.file == nil {
+		return nil
+	}
+
+	// the pid is cleared, so it is not shown for a controller of another user, which cannot write it
+	c.file.Truncate(0)
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+// holder returns the pid of the controller as recorded in the lock file, or "unknown".
+func (c *controlLock) holder() string {
+	content, err := os.ReadFile(c.path)
+	if err != nil || len(content) == 0 {
+		return "unknown"
+	}
+	return string(content)
+}
+
+// shareCalibration writes the OV580 calibration file for observers with best effort, renaming it into place so
+// observers never read a partial file.
+func (c *controlLock) shareCalibration(fileBytes []byte) {
+	if err := c.writeCalibration(fileBytes); err != nil {
+		slog.Debug(fmt.Sprintf("failed to share calibration file: %v", err))
+	}
+}
+
+func (c *controlLock) writeCalibration(fileBytes []byte) error {
+	if err := c.checkDir(); err != nil {
+		return err
+	}
+
+	path := c.calibrationPath()
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write calibration file %s: %w", path, err)
+	}
+	defer os.Remove(f.Name())
+
+	// readable by observers of other users, see sharedCalibration
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write calibration file %s: %w", path, err)
+	}
+	if _, err := f.Write(fileBytes); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write calibration file %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write calibration file %s: %w", path, err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("failed to write calibration file %s: %w", path, err)
+	}
+	return nil
+}
+
+// sharedCalibration reads the OV580 calibration file written by the controller. As any user can write to the lock
+// directory, it is only trusted from controllers running as the same user or root, and not writable by others.
+func (c *controlLock) sharedCalibration() ([]byte, error) {
+	path := c.calibrationPath()
+	f, err := openFileNoFollow(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check calibration file %s: %w", path, err)
+	}
+	if !info.Mode().IsRegular() || !ownedByCurrentUserOrRoot(info) || info.Mode().Perm()&0o022 != 0 {
+		return nil, fmt.Errorf("calibration file %s is not a file of the current user or root", path)
+	}
+	return io.ReadAll(f)
+}
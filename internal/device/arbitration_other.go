@@ -0,0 +1,23 @@
+//go:build !unix
+
+package device
+
+import (
+	"os"
+)
+
+// tryLockFile always succeeds as file locks are not supported on this platform yet,
+// so every process acts as the controller.
+func tryLockFile(file *os.File) (bool, error) {
+	return true, nil
+}
+
+// openFileNoFollow is os.OpenFile, as symbolic links are not refused on this platform yet.
+func openFileNoFollow(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+// ownedByCurrentUserOrRoot always succeeds as file owners are not checked on this platform yet.
+func ownedByCurrentUserOrRoot(info os.FileInfo) bool {
+	return true
+}
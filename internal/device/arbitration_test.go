@@ -0,0 +1,102 @@
+//go:build unix
+
+package device
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestControlLockSingleController(t *testing.T) {
+	useControlLockDir(t)
+
+	controller := newControlLock("/dev/hidraw0")
+	observer := newControlLock("/dev/hidraw0")
+
+	if acquired, err := controller.tryAcquire(); err != nil || !acquired {
+		t.Fatalf("controller tryAcquire() = %t, %v; want true, nil", acquired, err)
+	}
+	if acquired, err := observer.tryAcquire(); err != nil || acquired {
+		t.Fatalf("observer tryAcquire() = %t, %v; want false, nil", acquired, err)
+	}
+	if got, want := observer.holder(), strconv.Itoa(os.Getpid()); got != want {
+		t.Errorf("holder() = %s, want %s", got, want)
+	}
+	if err := observer.acquire(2 * controlPollFrequency); !errors.Is(err, ErrObserver) {
+		t.Errorf("acquire() while held = %v, want ErrObserver", err)
+	}
+
+	if err := controller.release(); err != nil {
+		t.Fatalf("release() failed: %v", err)
+	}
+	if err := observer.acquire(2 * controlPollFrequency); err != nil {
+		t.Errorf("acquire() after release failed: %v", err)
+	}
+	observer.release()
+}
+
+func TestControlLockSharedCalibration(t *testing.T) {
+	useControlLockDir(t)
+
+	lock := newControlLock("1-2:1.3")
+	if _, err := lock.sharedCalibration(); err == nil {
+		t.Errorf("sharedCalibration() before sharing succeeded, want error")
+	}
+
+	lock.shareCalibration([]byte("calibration"))
+	if got, err := lock.sharedCalibration(); err != nil || string(got) != "calibration" {
+		t.Errorf("sharedCalibration() = %q, %v; want calibration, nil", got, err)
+	}
+}
+
+func TestControlLockSharedByUsers(t *testing.T) {
+	dir := useControlLockDir(t)
+
+	lock := newControlLock("/dev/hidraw0")
+	if acquired, err := lock.tryAcquire(); err != nil || !acquired {
+		t.Fatalf("tryAcquire() = %t, %v; want true, nil", acquired, err)
+	}
+	lock.shareCalibration([]byte("calibration"))
+
+	if got := filepath.Dir(lock.path); got != dir {
+		t.Errorf("lock directory = %s, want the shared %s", got, dir)
+	}
+	for path, want := range map[string]os.FileMode{lock.path: 0o644, lock.calibrationPath(): 0o644} {
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != want {
+			t.Errorf("mode of %s = %v, %v; want %v", path, info.Mode().Perm(), err, want)
+		}
+	}
+	if err := lock.release(); err != nil {
+		t.Fatalf("release() failed: %v", err)
+	}
+	if got := lock.holder(); got != "unknown" {
+		t.Errorf("holder() after release = %s, want unknown", got)
+	}
+
+	// directories where other users could replace the lock are refused
+	if err := os.Chmod(dir, 0o777); err != nil {
+		t.Fatalf("Chmod() failed: %v", err)
+	}
+	if _, err := newControlLock("/dev/hidraw1").tryAcquire(); err == nil {
+		t.Errorf("tryAcquire() in a directory writable by all and not sticky = nil, want error")
+	}
+}
+
+func TestControlLockSharedCalibrationOfOtherUser(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("changing the owner of a file needs root")
+	}
+	useControlLockDir(t)
+
+	lock := newControlLock("1-2:1.3")
+	lock.shareCalibration([]byte("calibration"))
+	if err := os.Chown(lock.calibrationPath(), 4242, 4242); err != nil {
+		t.Fatalf("Chown() failed: %v", err)
+	}
+	if got, err := lock.sharedCalibration(); err == nil {
+		t.Errorf("sharedCalibration() of a file of another user = %q, want error", got)
+	}
+}
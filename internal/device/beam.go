@@ -0,0 +1,86 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	hid "github.com/sstallion/go-hid"
+)
+
+// ErrBeamUnsupported is returned on connecting when no glass is found but an XREAL Beam or Beam Pro is attached,
+// as glasses attached through a Beam are driven by it and not exposed to the host.
+var ErrBeamUnsupported = errors.New("glasses attached through an XREAL Beam are not supported")
+
+// BeamInfo describes an attached XREAL Beam or Beam Pro.
+type BeamInfo struct {
+	// Product is the USB product string, e.g. telling a Beam Pro apart
+	Product      string
+	SerialNumber string
+	// Path is the hid path of the first hid function found
+	Path string
+	VID  uint16
+	PID  uint16
+}
+
+func (info BeamInfo) String() string {
+	return fmt.Sprintf("%s (%s) - serialNumber: %s - path: %s", info.Product, usbID{VID: info.VID, PID: info.PID}, info.SerialNumber, info.Path)
+}
+
+// ListBeams enumerates the attached XREAL Beams. They are matched by product string as their USB IDs are not known
+// here, so a Beam exposing no hid function to the host, or naming itself otherwise, is not found.
+func ListBeams() ([]*BeamInfo, error) {
+	// 0 matches any vendor and product ID
+	devices, err := EnumerateDevices(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate hid devices: %w", err)
+	}
+	return beamsIn(devices), nil
+}
+
+// beamsIn picks the Beams of the hid devices, once for all their hid functions.
+func beamsIn(devices []*hid.DeviceInfo) []*BeamInfo {
+	var beams []*BeamInfo
+	seen := map[string]struct{}{}
+	for _, device := range devices {
+		if !isBeam(device) {
+			continue
+		}
+		key := fmt.Sprintf("%s/%s", usbID{VID: device.VendorID, PID: device.ProductID}, device.SerialNbr)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		beams = append(beams, &BeamInfo{
+			Product:      strings.TrimSpace(device.ProductStr),
+			SerialNumber: device.SerialNbr,
+			Path:         device.Path,
+			VID:          device.VendorID,
+			PID:          device.ProductID,
+		})
+	}
+	return beams
+}
+
+// isBeam matches a Beam by its product string, of the XREAL vendor IDs or manufacturer, which was Nreal before.
+func isBeam(device *hid.DeviceInfo) bool {
+	if !strings.Contains(strings.ToLower(device.ProductStr), "beam") {
+		return false
+	}
+	manufacturer := strings.ToLower(device.MfrStr)
+	return device.VendorID == XREAL_AIR_SERIES_MCU_VID || device.VendorID == XREAL_LIGHT_MCU_VID ||
+		strings.Contains(manufacturer, "xreal") || strings.Contains(manufacturer, "nreal")
+}
+
+// checkBeam wraps ErrBeamUnsupported into notFound, the error of finding no glass, if a Beam is attached.
+func checkBeam(notFound error) error {
+	beams, err := ListBeams()
+	if err != nil || len(beams) == 0 {
+		return notFound
+	}
+	found := make([]string, len(beams))
+	for i, beam := range beams {
+		found[i] = beam.Product
+	}
+	return fmt.Errorf("%w: %v, found %s, attach the glass to the host directly", ErrBeamUnsupported, notFound, strings.Join(found, ", "))
+}
@@ -0,0 +1,25 @@
+package device
+
+import (
+	"testing"
+
+	hid "github.com/sstallion/go-hid"
+)
+
+func TestBeamsIn(t *testing.T) {
+	devices := []*hid.DeviceInfo{
+		{VendorID: XREAL_LIGHT_MCU_VID, ProductID: XREAL_LIGHT_MCU_PID, ProductStr: "NR-7101", Path: "mcu"},
+		{VendorID: XREAL_AIR_SERIES_MCU_VID, ProductID: 0x1234, ProductStr: "XREAL Beam Pro", SerialNbr: "B1", Path: "beam-0", InterfaceNbr: 0},
+		{VendorID: XREAL_AIR_SERIES_MCU_VID, ProductID: 0x1234, ProductStr: "XREAL Beam Pro", SerialNbr: "B1", Path: "beam-1", InterfaceNbr: 1},
+		{VendorID: 0x1234, ProductID: 0x5678, ProductStr: "Beam", MfrStr: "XREAL", Path: "beam-2"},
+		{VendorID: 0x046d, ProductID: 0xc52b, ProductStr: "Laser Beam Mouse", MfrStr: "Logitech", Path: "mouse"},
+	}
+
+	beams := beamsIn(devices)
+	if len(beams) != 2 {
+		t.Fatalf("beamsIn() = %v, want the Beam Pro once and the Beam", beams)
+	}
+	if beams[0].Product != "XREAL Beam Pro" || beams[0].Path != "beam-0" || beams[1].Path != "beam-2" {
+		t.Errorf("beamsIn() = %v, %v", beams[0], beams[1])
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !developer
+
+package device
+
+// buildMode is BUILD_MODE_SAFE unless built with `-tags developer`.
+const buildMode = BUILD_MODE_SAFE
@@ -0,0 +1,5 @@
+//go:build developer
+
+package device
+
+const buildMode = BUILD_MODE_DEVELOPER
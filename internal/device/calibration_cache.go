@@ -0,0 +1,90 @@
+package device
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cachedCalibration is the OV580 calibration file as downloaded from a glass.
+type cachedCalibration struct {
+	// Length is the raw file length reported by OV580_GET_CALIBRATION_FILE_LENGTH
+	Length []byte `json:"length"`
+	// Checksum is the hex encoded SHA-256 of File, to catch a corrupted cache
+	Checksum string `json:"checksum"`
+	File     []byte `json:"file"`
+}
+
+func newCachedCalibration(length []byte, fileBytes []byte) *cachedCalibration {
+	return &cachedCalibration{Length: length, Checksum: calibrationChecksum(fileBytes), File: fileBytes}
+}
+
+func calibrationChecksum(fileBytes []byte) string {
+	sum := sha256.Sum256(fileBytes)
+	return hex.EncodeToString(sum[:])
+}
+
+// matches tells if the cached file is likely the one on the glass, by the length and first part read from it.
+func (c *cachedCalibration) matches(length []byte, firstPart []byte) bool {
+	return calibrationChecksum(c.File) == c.Checksum &&
+		bytes.Equal(c.Length, length) &&
+		bytes.HasPrefix(c.File, firstPart)
+}
+
+// calibrationCache keeps the OV580 calibration file per glass on disk, as downloading it over HID takes long.
+type calibrationCache struct {
+	dir string
+}
+
+// newCalibrationCache uses the user cache directory, nil if there is none so caching is skipped.
+func newCalibrationCache() *calibrationCache {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+	return &calibrationCache{dir: filepath.Join(dir, "xreal-xr-go")}
+}
+
+func (c *calibrationCache) path(serial string) string {
+	name := strings.Map(func(r rune) rune {
+		if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, serial)
+	return filepath.Join(c.dir, fmt.Sprintf("calibration-%s.json", name))
+}
+
+// load reads the calibration cached for the glass with the OV580 serial number.
+func (c *calibrationCache) load(serial string) (*cachedCalibration, error) {
+	content, err := os.ReadFile(c.path(serial))
+	if err != nil {
+		return nil, err
+	}
+	var calibration cachedCalibration
+	if err := json.Unmarshal(content, &calibration); err != nil {
+		return nil, fmt.Errorf("failed to parse cached calibration: %w", err)
+	}
+	return &calibration, nil
+}
+
+// store caches the calibration, renaming it into place so a concurrent load never reads a partial file.
+func (c *calibrationCache) store(serial string, calibration *cachedCalibration) error {
+	content, err := json.Marshal(calibration)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	tmpPath := c.path(serial) + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, c.path(serial))
+}
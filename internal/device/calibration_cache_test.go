@@ -0,0 +1,46 @@
+package device
+
+import (
+	"testing"
+)
+
+func TestCalibrationCache(t *testing.T) {
+	cache := &calibrationCache{dir: t.TempDir()}
+	length := []byte{0x00, 0x10, 0x00}
+	file := []byte(`<xml/>{"IMU":{}}`)
+
+	if _, err := cache.load("SN/1"); err == nil {
+		t.Errorf("load() before storing succeeded, want error")
+	}
+	if err := cache.store("SN/1", newCachedCalibration(length, file)); err != nil {
+		t.Fatalf("store() failed: %v", err)
+	}
+	cached, err := cache.load("SN/1")
+	if err != nil {
+		t.Fatalf("load() failed: %v", err)
+	}
+	if string(cached.File) != string(file) {
+		t.Errorf("load() file = %q, want %q", cached.File, file)
+	}
+
+	testCases := []struct {
+		name      string
+		length    []byte
+		firstPart []byte
+		want      bool
+	}{
+		{name: "unchanged", length: length, firstPart: file[:6], want: true},
+		{name: "length changed", length: []byte{0x00, 0x11, 0x00}, firstPart: file[:6], want: false},
+		{name: "first part changed", length: length, firstPart: []byte("<json/"), want: false},
+	}
+	for _, tc := range testCases {
+		if got := cached.matches(tc.length, tc.firstPart); got != tc.want {
+			t.Errorf("%s: matches() = %t, want %t", tc.name, got, tc.want)
+		}
+	}
+
+	cached.File = append(cached.File, '!')
+	if cached.matches(length, file[:6]) {
+		t.Errorf("matches() with a corrupted file = true, want false")
+	}
+}
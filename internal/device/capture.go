@@ -0,0 +1,137 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"xreal-light-xr-go/storage"
+)
+
+// Placeholders of CapturePolicy.NameTemplate.
+const (
+	CAPTURE_NAME_SERIAL    = "{serial}"
+	CAPTURE_NAME_TIMESTAMP = "{timestamp}"
+	CAPTURE_NAME_INDEX     = "{index}"
+
+	DEFAULT_CAPTURE_NAME_TEMPLATE = CAPTURE_NAME_TIMESTAMP
+)
+
+// CapturePolicy configures how CaptureImages names and keeps the images, e.g. for long captures on a small disk.
+type CapturePolicy struct {
+	// NameTemplate names each capture, followed by _left or _right and the extension of the images. It may contain
+	// slashes for sub folders and CAPTURE_NAME_SERIAL, CAPTURE_NAME_INDEX, which counts the captures since the
+	// policy was set from 0, and CAPTURE_NAME_TIMESTAMP in unix milliseconds, one of the latter two at least so captures
+	// do not overwrite each other. DEFAULT_CAPTURE_NAME_TEMPLATE if empty.
+	NameTemplate string
+	// MaxFiles removes the oldest images captured since the policy was set beyond this count, 0 keeps all. Storages
+	// not implementing storage.Remover keep all.
+	MaxFiles int
+	// MinFreeBytes refuses to capture with ErrLowDiskSpace when less space is left, 0 to disable. Storages not
+	// implementing storage.SpaceReporter, e.g. network storage, are not checked.
+	MinFreeBytes uint64
+}
+
+func (p CapturePolicy) validate() error {
+	if p.NameTemplate != "" && !strings.Contains(p.NameTemplate, CAPTURE_NAME_TIMESTAMP) && !strings.Contains(p.NameTemplate, CAPTURE_NAME_INDEX) {
+		return fmt.Errorf("invalid capture name template %s: want %s or %s in it", p.NameTemplate, CAPTURE_NAME_TIMESTAMP, CAPTURE_NAME_INDEX)
+	}
+	if p.MaxFiles < 0 {
+		return fmt.Errorf("invalid max files %d: want 0 or more", p.MaxFiles)
+	}
+	return nil
+}
+
+// capturedFile is an image stored by capturer, to be removed once rotated out.
+type capturedFile struct {
+	store storage.Storage
+	name  string
+}
+
+// capturer stores frames as the CapturePolicy says.
+type capturer struct {
+	// mutex for thread safety
+	mutex  sync.Mutex
+	policy CapturePolicy
+	// index is of the next capture
+	index int
+	// captured are the images stored since the policy was set, oldest first
+	captured []capturedFile
+}
+
+func (c *capturer) setPolicy(policy CapturePolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.policy = policy
+	c.index = 0
+	c.captured = nil
+	return nil
+}
+
+// capture stores the images of frame and returns their locations, serial fills CAPTURE_NAME_SERIAL.
+func (c *capturer) capture(store storage.Storage, frame *SLAMFrame, serial string) ([]string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.policy.MinFreeBytes > 0 {
+		if reporter, ok := store.(storage.SpaceReporter); ok {
+			free, err := reporter.FreeSpace()
+			if errors.Is(err, errors.ErrUnsupported) {
+				slog.Debug("cannot tell the free space of the storage on this platform, capturing anyway")
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to check free space: %w", err)
+			} else if free < c.policy.MinFreeBytes {
+				return nil, fmt.Errorf("%w: %d bytes free, want at least %d", ErrLowDiskSpace, free, c.policy.MinFreeBytes)
+			}
+		}
+	}
+
+	template := c.policy.NameTemplate
+	if template == "" {
+		template = DEFAULT_CAPTURE_NAME_TEMPLATE
+	}
+	prefix := strings.NewReplacer(
+		CAPTURE_NAME_SERIAL, serial,
+		CAPTURE_NAME_TIMESTAMP, strconv.FormatInt(frame.Timestamp.UnixMilli(), 10),
+		CAPTURE_NAME_INDEX, strconv.Itoa(c.index),
+	).Replace(template)
+
+	names, err := frame.store(store, prefix, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.index++
+
+	locations := make([]string, len(names))
+	for i, name := range names {
+		locations[i] = store.Location(name)
+		c.captured = append(c.captured, capturedFile{store: store, name: name})
+	}
+	c.rotate()
+	return locations, nil
+}
+
+// rotate removes the oldest images beyond MaxFiles, best effort.
+func (c *capturer) rotate() {
+	if c.policy.MaxFiles == 0 {
+		c.captured = nil
+		return
+	}
+	for len(c.captured) > c.policy.MaxFiles {
+		oldest := c.captured[0]
+		c.captured = c.captured[1:]
+		remover, ok := oldest.store.(storage.Remover)
+		if !ok {
+			continue
+		}
+		if err := remover.Remove(oldest.name); err != nil {
+			slog.Warn(fmt.Sprintf("failed to rotate out capture: %v", err))
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package device
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"xreal-light-xr-go/storage"
+)
+
+func TestCapturerNamesAndRotates(t *testing.T) {
+	var c capturer
+	if err := c.setPolicy(CapturePolicy{NameTemplate: "{serial}"}); err == nil {
+		t.Errorf("setPolicy() without timestamp or index succeeded, want error")
+	}
+	if err := c.setPolicy(CapturePolicy{NameTemplate: "{serial}/{index}", MaxFiles: 2}); err != nil {
+		t.Fatalf("setPolicy() failed: %v", err)
+	}
+
+	store := storage.NewMemory()
+	frame := &SLAMFrame{Timestamp: time.UnixMilli(1000), Left: []byte{1}, Right: []byte{2}, Width: 1, Height: 1}
+	locations, err := c.capture(store, frame, "SN1")
+	if err != nil {
+		t.Fatalf("capture() failed: %v", err)
+	}
+	if len(locations) != 2 || locations[0] != "mem://SN1/0_left.jpeg" {
+		t.Errorf("capture() = %v, want SN1/0_left.jpeg first", locations)
+	}
+
+	if _, err := c.capture(store, frame, "SN1"); err != nil {
+		t.Fatalf("capture() failed: %v", err)
+	}
+	if _, ok := store.Get("SN1/0_left.jpeg"); ok {
+		t.Errorf("SN1/0_left.jpeg kept beyond MaxFiles, want removed")
+	}
+	if _, ok := store.Get("SN1/1_right.jpeg"); !ok {
+		t.Errorf("SN1/1_right.jpeg missing, want kept")
+	}
+}
+
+// fullStorage reports no free space left.
+type fullStorage struct {
+	*storage.Memory
+}
+
+func (fullStorage) FreeSpace() (uint64, error) {
+	return 0, nil
+}
+
+func TestCapturerRefusesOnLowDiskSpace(t *testing.T) {
+	var c capturer
+	if err := c.setPolicy(CapturePolicy{MinFreeBytes: 1}); err != nil {
+		t.Fatalf("setPolicy() failed: %v", err)
+	}
+
+	_, err := c.capture(fullStorage{storage.NewMemory()}, &SLAMFrame{Left: []byte{1}, Width: 1, Height: 1}, "")
+	if !errors.Is(err, ErrLowDiskSpace) {
+		t.Errorf("capture() = %v, want ErrLowDiskSpace", err)
+	}
+}
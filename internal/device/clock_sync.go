@@ -0,0 +1,169 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// clockSyncWindow is how many recent samples the estimation uses, older ones are dropped to follow drift
+	clockSyncWindow = 512
+	// clockSyncBuckets is how many lower envelope points the drift is fitted on
+	clockSyncBuckets = 8
+	// mcuClockResolution is the resolution of the MCU timestamps
+	mcuClockResolution = time.Millisecond
+)
+
+// ErrNoClockSamples is returned by ClockSync before any MCU timestamp is received. Only MCU events carry one,
+// so at least one of them must be enabled, e.g. magnetometer.
+var ErrNoClockSamples = errors.New("no MCU timestamps received yet")
+
+// ClockSync estimates how the MCU millisecond clock maps onto the host clock, from the MCU timestamp of each event
+// and when the host received it. Each receive time is the send time plus an unknown transport delay, so the
+// estimation follows the lower envelope of (host - MCU) offsets, the samples with the least delay.
+type ClockSync struct {
+	mutex   sync.Mutex
+	samples []clockSample
+}
+
+type clockSample struct {
+	deviceMs uint64
+	// offset is the host receive time minus the MCU time, in nanoseconds
+	offset int64
+}
+
+// ClockEstimate maps MCU timestamps onto the host clock as host = MCU + Offset + drift since Reference.
+type ClockEstimate struct {
+	// Offset is the host unix time of MCU time 0 as of the Reference sample
+	Offset time.Duration
+	// DriftPPM is how much faster the host clock runs than the MCU clock in parts per million
+	DriftPPM float64
+	// Uncertainty is how far the fitted offsets deviate from the lower envelope plus the MCU clock resolution.
+	// The constant part of the transport delay cannot be observed and is not included.
+	Uncertainty time.Duration
+	// Reference is the MCU time in milliseconds of the latest sample
+	Reference uint64
+	// Samples is how many samples the estimate is based on
+	Samples int
+}
+
+// NewClockSync creates an empty ClockSync, see AddSample.
+func NewClockSync() *ClockSync {
+	return &ClockSync{}
+}
+
+// AddSample records an MCU timestamp in milliseconds together with when the host received it.
+// A timestamp going backwards means the glass restarted, which discards the previous samples.
+func (c *ClockSync) AddSample(deviceMs uint64, received time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if n := len(c.samples); n > 0 && deviceMs < c.samples[n-1].deviceMs {
+		c.samples = c.samples[:0]
+	}
+
+	c.samples = append(c.samples, clockSample{
+		deviceMs: deviceMs,
+		offset:   received.UnixNano() - int64(deviceMs)*int64(time.Millisecond),
+	})
+	if len(c.samples) > clockSyncWindow {
+		c.samples = append(c.samples[:0], c.samples[len(c.samples)-clockSyncWindow:]...)
+	}
+}
+
+// Reset discards all samples.
+func (c *ClockSync) Reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.samples = c.samples[:0]
+}
+
+// Estimate fits the offset and drift on the samples received so far.
+func (c *ClockSync) Estimate() (*ClockEstimate, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.samples) == 0 {
+		return nil, ErrNoClockSamples
+	}
+
+	envelope := lowerEnvelope(c.samples, clockSyncBuckets)
+	reference := c.samples[len(c.samples)-1].deviceMs
+	estimate := &ClockEstimate{Reference: reference, Samples: len(c.samples), Uncertainty: mcuClockResolution}
+
+	if len(envelope) == 1 {
+		estimate.Offset = time.Duration(envelope[0].offset)
+		return estimate, nil
+	}
+
+	// least squares fit of the offset over the MCU time relative to the reference, ns per ms is ppm
+	var sumX, sumY, sumXX, sumXY float64
+	for _, sample := range envelope {
+		x := float64(int64(sample.deviceMs) - int64(reference))
+		y := float64(sample.offset - envelope[0].offset)
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+	}
+	n := float64(len(envelope))
+	slope := 0.0
+	if denominator := n*sumXX - sumX*sumX; denominator != 0 {
+		slope = (n*sumXY - sumX*sumY) / denominator
+	}
+	intercept := (sumY - slope*sumX) / n
+
+	maxResidual := 0.0
+	for _, sample := range envelope {
+		x := float64(int64(sample.deviceMs) - int64(reference))
+		y := float64(sample.offset - envelope[0].offset)
+		maxResidual = math.Max(maxResidual, math.Abs(y-(intercept+slope*x)))
+	}
+
+	estimate.Offset = time.Duration(envelope[0].offset + int64(math.Round(intercept)))
+	estimate.DriftPPM = slope
+	estimate.Uncertainty += time.Duration(maxResidual)
+	return estimate, nil
+}
+
+// ToHost maps an MCU timestamp in milliseconds onto the host clock using the current estimate.
+func (c *ClockSync) ToHost(deviceMs uint64) (time.Time, error) {
+	estimate, err := c.Estimate()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return estimate.ToHost(deviceMs), nil
+}
+
+// ToHost maps an MCU timestamp in milliseconds onto the host clock.
+func (e *ClockEstimate) ToHost(deviceMs uint64) time.Time {
+	sinceReference := float64(int64(deviceMs) - int64(e.Reference))
+	offset := e.Offset + time.Duration(math.Round(e.DriftPPM*sinceReference))
+	return time.Unix(0, int64(deviceMs)*int64(time.Millisecond)+int64(offset))
+}
+
+func (e *ClockEstimate) String() string {
+	return fmt.Sprintf("MCU clock started at %s, drift %.2f ppm, uncertainty %v from %d samples",
+		time.Unix(0, int64(e.Offset)).Format(time.RFC3339Nano), e.DriftPPM, e.Uncertainty, e.Samples)
+}
+
+// lowerEnvelope splits samples into consecutive buckets and keeps the one with the least offset of each.
+func lowerEnvelope(samples []clockSample, buckets int) []clockSample {
+	size := (len(samples) + buckets - 1) / buckets
+	envelope := []clockSample{}
+	for start := 0; start < len(samples); start += size {
+		end := min(start+size, len(samples))
+		lowest := samples[start]
+		for _, sample := range samples[start+1 : end] {
+			if sample.offset < lowest.offset {
+				lowest = sample
+			}
+		}
+		envelope = append(envelope, lowest)
+	}
+	return envelope
+}
@@ -0,0 +1,60 @@
+package device_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestClockSyncEstimate(t *testing.T) {
+	clock := device.NewClockSync()
+	if _, err := clock.Estimate(); !errors.Is(err, device.ErrNoClockSamples) {
+		t.Fatalf("Estimate() without samples = %v, want ErrNoClockSamples", err)
+	}
+
+	// the MCU booted at start, the host clock runs 100 ppm faster and every 3rd sample is delayed by 4 ms
+	start := time.Unix(1700000000, 0)
+	for deviceMs := uint64(0); deviceMs < 10000; deviceMs += 50 {
+		received := start.Add(time.Duration(float64(deviceMs) * (1 + 100e-6) * float64(time.Millisecond)))
+		if deviceMs%150 == 0 {
+			received = received.Add(4 * time.Millisecond)
+		}
+		clock.AddSample(deviceMs, received)
+	}
+
+	estimate, err := clock.Estimate()
+	if err != nil {
+		t.Fatalf("Estimate() failed: %v", err)
+	}
+	if estimate.DriftPPM < 90 || estimate.DriftPPM > 110 {
+		t.Errorf("DriftPPM = %.2f, want about 100", estimate.DriftPPM)
+	}
+	if estimate.Uncertainty > 2*time.Millisecond {
+		t.Errorf("Uncertainty = %v, want at most 2ms", estimate.Uncertainty)
+	}
+
+	want := start.Add(time.Duration(5000 * (1 + 100e-6) * float64(time.Millisecond)))
+	if got := estimate.ToHost(5000); got.Sub(want).Abs() > time.Millisecond {
+		t.Errorf("ToHost(5000) = %v, want %v", got, want)
+	}
+}
+
+func TestClockSyncGlassRestart(t *testing.T) {
+	clock := device.NewClockSync()
+	start := time.Unix(1700000000, 0)
+	clock.AddSample(60000, start)
+	clock.AddSample(100, start.Add(time.Minute))
+
+	estimate, err := clock.Estimate()
+	if err != nil {
+		t.Fatalf("Estimate() failed: %v", err)
+	}
+	if estimate.Samples != 1 {
+		t.Errorf("Samples = %d, want 1 after the MCU clock went backwards", estimate.Samples)
+	}
+	if got, want := estimate.ToHost(100), start.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("ToHost(100) = %v, want %v", got, want)
+	}
+}
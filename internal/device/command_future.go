@@ -0,0 +1,158 @@
+package device
+
+import (
+	"sync"
+	"time"
+)
+
+// CommandFuture resolves with the response of a command sent by Device.ExecuteAsync.
+type CommandFuture struct {
+	done     chan struct{}
+	response string
+	err      error
+}
+
+func newCommandFuture() *CommandFuture {
+	return &CommandFuture{done: make(chan struct{})}
+}
+
+// resolvedCommandFuture returns a future that already failed, e.g. as the command could not be sent.
+func resolvedCommandFuture(err error) *CommandFuture {
+	future := newCommandFuture()
+	future.resolve(nil, err)
+	return future
+}
+
+func (f *CommandFuture) resolve(payload []byte, err error) {
+	f.response, f.err = string(payload), err
+	close(f.done)
+}
+
+// Done is closed once the response arrived or the command failed, e.g. to select on several futures.
+func (f *CommandFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the response arrived or the command failed.
+func (f *CommandFuture) Wait() (string, error) {
+	<-f.done
+	return f.response, f.err
+}
+
+// responseKey identifies the response to a command, which has the type of the command plus one and the same ID.
+type responseKey struct {
+	Type uint8
+	ID   uint8
+}
+
+func responseKeyOf(command *Command) responseKey {
+	return responseKey{Type: command.Type + 1, ID: command.ID}
+}
+
+// pendingCommands routes responses from the read loop to the commands waiting for them by the type and ID of the
+// response, so commands of different types or IDs can be in flight at the same time. Commands of the same type and
+// ID get their responses in the order they were sent. Delivering never blocks, so a late response to a command
+// that gave up waiting cannot stall event processing; it is dropped instead.
+type pendingCommands struct {
+	// mutex for thread safety
+	mutex   sync.Mutex
+	waiting map[responseKey][]chan *Packet
+	closed  bool
+}
+
+func newPendingCommands() *pendingCommands {
+	return &pendingCommands{waiting: map[responseKey][]chan *Packet{}}
+}
+
+// expect registers a command before it is sent, the returned channel receives its response.
+func (p *pendingCommands) expect(command *Command) (chan *Packet, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return nil, errResponseClosed
+	}
+	key := responseKeyOf(command)
+	responses := make(chan *Packet, 1)
+	p.waiting[key] = append(p.waiting[key], responses)
+	return responses, nil
+}
+
+// cancel unregisters a command which failed to send or gave up waiting.
+func (p *pendingCommands) cancel(command *Command, responses chan *Packet) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	key := responseKeyOf(command)
+	for i, waiting := range p.waiting[key] {
+		if waiting == responses {
+			p.waiting[key] = append(p.waiting[key][:i], p.waiting[key][i+1:]...)
+			break
+		}
+	}
+	if len(p.waiting[key]) == 0 {
+		delete(p.waiting, key)
+	}
+}
+
+// deliver hands the response to the oldest command waiting for it, it returns false if none is.
+func (p *pendingCommands) deliver(response *Packet) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	key := responseKey{Type: response.Command.Type, ID: response.Command.ID}
+	waiting := p.waiting[key]
+	if len(waiting) == 0 {
+		return false
+	}
+	waiting[0] <- response
+	if len(waiting) == 1 {
+		delete(p.waiting, key)
+	} else {
+		p.waiting[key] = waiting[1:]
+	}
+	return true
+}
+
+// close fails all waiting commands and those registered later with errResponseClosed.
+func (p *pendingCommands) close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+	for _, waiting := range p.waiting {
+		for _, responses := range waiting {
+			close(responses)
+		}
+	}
+	p.waiting = nil
+}
+
+// await waits up to timeout for the response on a channel returned by expect.
+func (p *pendingCommands) await(command *Command, responses chan *Packet, timeout time.Duration) (*Packet, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case response, ok := <-responses:
+		if !ok {
+			return nil, errResponseClosed
+		}
+		return response, nil
+	case <-timer.C:
+		p.cancel(command, responses)
+		// the response may have been delivered right before canceling
+		select {
+		case response, ok := <-responses:
+			if ok {
+				return response, nil
+			}
+			return nil, errResponseClosed
+		default:
+		}
+		return nil, errResponseTimeout
+	}
+}
@@ -0,0 +1,68 @@
+package device
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPendingCommandsRoutesResponses(t *testing.T) {
+	pending := newPendingCommands()
+	brightness := &Command{Type: 0x33, ID: 0x31}
+	displayMode := &Command{Type: 0x33, ID: 0x33}
+
+	first, _ := pending.expect(brightness)
+	second, _ := pending.expect(brightness)
+	mode, _ := pending.expect(displayMode)
+
+	// responses arrive out of order for different commands, and in order for the same command
+	for _, response := range []*Packet{
+		{Command: &Command{Type: 0x34, ID: 0x33}, Payload: []byte("1")},
+		{Command: &Command{Type: 0x34, ID: 0x31}, Payload: []byte("5")},
+		{Command: &Command{Type: 0x34, ID: 0x31}, Payload: []byte("6")},
+	} {
+		if !pending.deliver(response) {
+			t.Errorf("deliver(%v) = false, want true", response.Command)
+		}
+	}
+	if pending.deliver(&Packet{Command: &Command{Type: 0x34, ID: 0x31}, Payload: []byte("7")}) {
+		t.Errorf("deliver() without a waiting command = true, want false")
+	}
+
+	for _, tc := range []struct {
+		command   *Command
+		responses chan *Packet
+		want      string
+	}{
+		{brightness, first, "5"},
+		{brightness, second, "6"},
+		{displayMode, mode, "1"},
+	} {
+		response, err := pending.await(tc.command, tc.responses, time.Second)
+		if err != nil || string(response.Payload) != tc.want {
+			t.Errorf("await() = %v, %v; want %s", response, err, tc.want)
+		}
+	}
+}
+
+func TestPendingCommandsTimeoutAndClose(t *testing.T) {
+	pending := newPendingCommands()
+	command := &Command{Type: 0x33, ID: 0x31}
+
+	responses, _ := pending.expect(command)
+	if _, err := pending.await(command, responses, time.Millisecond); !errors.Is(err, errResponseTimeout) {
+		t.Errorf("await() without response = %v, want errResponseTimeout", err)
+	}
+	if pending.deliver(&Packet{Command: &Command{Type: 0x34, ID: 0x31}}) {
+		t.Errorf("deliver() after timeout = true, want the late response dropped")
+	}
+
+	responses, _ = pending.expect(command)
+	pending.close()
+	if _, err := pending.await(command, responses, time.Second); !errors.Is(err, errResponseClosed) {
+		t.Errorf("await() after close = %v, want errResponseClosed", err)
+	}
+	if _, err := pending.expect(command); !errors.Is(err, errResponseClosed) {
+		t.Errorf("expect() after close = %v, want errResponseClosed", err)
+	}
+}
@@ -0,0 +1,222 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// slowCommandThreshold is how long a command may wait for its response before it is logged as slow
+const slowCommandThreshold = waitForPacketTimeout / 2
+
+// COMMAND_LATENCY_BUCKETS are the upper bounds of the latency histogram buckets of CommandStat.
+var COMMAND_LATENCY_BUCKETS = []time.Duration{
+	1 * time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	2500 * time.Millisecond,
+}
+
+// CommandTracer is called when a command is sent and waits for its response, and the returned function once it
+// completes with the error if any, e.g. to start and end OpenTelemetry spans when debugging intermittent stalls.
+type CommandTracer func(component string, command string) func(err error)
+
+// CommandStat holds the statistics of one command of a component since start.
+type CommandStat struct {
+	// Component is one of the COMPONENT_ constants, e.g. COMPONENT_MCU
+	Component string
+	// Command is the command as described by its String, e.g. "get brightness level", or its type and ID if unknown
+	Command string
+	Count   uint64
+	// Timeouts counts commands without a response in time, Failures all other errors
+	Timeouts uint64
+	Failures uint64
+	// TotalLatency and MaxLatency are the time until the response or error
+	TotalLatency time.Duration
+	MaxLatency   time.Duration
+	// Buckets counts the commands by latency, Buckets[i] those up to COMMAND_LATENCY_BUCKETS[i] and the last
+	// one those above all bounds
+	Buckets []uint64
+}
+
+// TimeoutRate is the fraction of commands that timed out.
+func (s *CommandStat) TimeoutRate() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Timeouts) / float64(s.Count)
+}
+
+// MeanLatency is the average time until the response or error.
+func (s *CommandStat) MeanLatency() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Count)
+}
+
+func (s *CommandStat) String() string {
+	return fmt.Sprintf("%s %s: count %d, timeouts %d (%.1f%%), failures %d, mean %v, max %v", s.Component, s.Command, s.Count, s.Timeouts, 100*s.TimeoutRate(), s.Failures, s.MeanLatency(), s.MaxLatency)
+}
+
+// CommandStats is a snapshot of the statistics of all commands waiting for a response.
+type CommandStats []CommandStat
+
+func (s CommandStats) String() string {
+	if len(s) == 0 {
+		return "no commands"
+	}
+	lines := make([]string, len(s))
+	for i := range s {
+		lines[i] = s[i].String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WritePrometheus writes the statistics in the Prometheus text exposition format.
+func (s CommandStats) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# HELP xreal_command_latency_seconds Time until a command got its response or failed.\n")
+	b.WriteString("# TYPE xreal_command_latency_seconds histogram\n")
+	for _, stat := range s {
+		labels := fmt.Sprintf("component=%q,command=%q", stat.Component, stat.Command)
+		var cumulative uint64
+		for i, bound := range COMMAND_LATENCY_BUCKETS {
+			cumulative += stat.Buckets[i]
+			fmt.Fprintf(&b, "xreal_command_latency_seconds_bucket{%s,le=\"%g\"} %d\n", labels, bound.Seconds(), cumulative)
+		}
+		fmt.Fprintf(&b, "xreal_command_latency_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, stat.Count)
+		fmt.Fprintf(&b, "xreal_command_latency_seconds_sum{%s} %g\n", labels, stat.TotalLatency.Seconds())
+		fmt.Fprintf(&b, "xreal_command_latency_seconds_count{%s} %d\n", labels, stat.Count)
+	}
+	b.WriteString("# HELP xreal_command_timeouts_total Commands without a response in time.\n")
+	b.WriteString("# TYPE xreal_command_timeouts_total counter\n")
+	for _, stat := range s {
+		fmt.Fprintf(&b, "xreal_command_timeouts_total{component=%q,command=%q} %d\n", stat.Component, stat.Command, stat.Timeouts)
+	}
+	b.WriteString("# HELP xreal_command_failures_total Commands failed for other reasons than a timeout.\n")
+	b.WriteString("# TYPE xreal_command_failures_total counter\n")
+	for _, stat := range s {
+		fmt.Fprintf(&b, "xreal_command_failures_total{component=%q,command=%q} %d\n", stat.Component, stat.Command, stat.Failures)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type commandKey struct {
+	component string
+	command   string
+}
+
+// commandStats records every command waiting for a response.
+type commandStats struct {
+	// mutex for thread safety
+	mutex  sync.Mutex
+	stats  map[commandKey]*CommandStat
+	tracer CommandTracer
+}
+
+var commands = &commandStats{stats: map[commandKey]*CommandStat{}}
+
+// GetCommandStats returns the execution counts, latency histograms and timeouts of the commands sent to the MCU and
+// OV580 waiting for a response since start, sorted by component and command.
+func GetCommandStats() CommandStats {
+	return commands.snapshot()
+}
+
+// SetCommandTracer calls tracer around every command waiting for a response, nil to disable. Commands slower than
+// half the response timeout are also logged as warnings.
+func SetCommandTracer(tracer CommandTracer) {
+	commands.mutex.Lock()
+	defer commands.mutex.Unlock()
+	commands.tracer = tracer
+}
+
+// commandName describes cmd for the statistics, by its type and ID if it is unknown, e.g. sent by DevExecuteAndRead.
+func commandName(cmd Command) string {
+	if cmd.instruction == CMD_UKNOWN {
+		return fmt.Sprintf("0x%02x/0x%02x", cmd.Type, cmd.ID)
+	}
+	return cmd.String()
+}
+
+// start is called before sending a command, the returned function records it once it completed.
+func (c *commandStats) start(component string, cmd Command) func(err error) {
+	command := commandName(cmd)
+
+	c.mutex.Lock()
+	tracer := c.tracer
+	c.mutex.Unlock()
+
+	var end func(err error)
+	if tracer != nil {
+		end = tracer(component, command)
+	}
+	started := time.Now()
+
+	return func(err error) {
+		latency := time.Since(started)
+		if end != nil {
+			end(err)
+		}
+		if latency > slowCommandThreshold {
+			slog.Warn(fmt.Sprintf("%s command %s took %v: %v", component, command, latency, err))
+		}
+		c.record(component, command, latency, err)
+	}
+}
+
+func (c *commandStats) record(component string, command string, latency time.Duration, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := commandKey{component: component, command: command}
+	stat, ok := c.stats[key]
+	if !ok {
+		stat = &CommandStat{Component: component, Command: command, Buckets: make([]uint64, len(COMMAND_LATENCY_BUCKETS)+1)}
+		c.stats[key] = stat
+	}
+
+	stat.Count++
+	if errors.Is(err, errResponseTimeout) {
+		stat.Timeouts++
+	} else if err != nil {
+		stat.Failures++
+	}
+	stat.TotalLatency += latency
+	stat.MaxLatency = max(stat.MaxLatency, latency)
+	bucket, _ := slices.BinarySearch(COMMAND_LATENCY_BUCKETS, latency)
+	stat.Buckets[bucket]++
+}
+
+// snapshot copies the statistics sorted by component and command.
+func (c *commandStats) snapshot() CommandStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	result := make(CommandStats, 0, len(c.stats))
+	for _, stat := range c.stats {
+		copied := *stat
+		copied.Buckets = slices.Clone(stat.Buckets)
+		result = append(result, copied)
+	}
+	slices.SortFunc(result, func(a, b CommandStat) int {
+		if a.Component != b.Component {
+			return strings.Compare(a.Component, b.Component)
+		}
+		return strings.Compare(a.Command, b.Command)
+	})
+	return result
+}
@@ -0,0 +1,53 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandStats(t *testing.T) {
+	stats := &commandStats{stats: map[commandKey]*CommandStat{}}
+	traced := []string{}
+	stats.tracer = func(component string, command string) func(err error) {
+		return func(err error) {
+			traced = append(traced, fmt.Sprintf("%s %s %v", component, command, err))
+		}
+	}
+
+	command := Command{Type: 0x33, ID: 0x99}
+	stats.start(COMPONENT_MCU, command)(nil)
+	stats.record(COMPONENT_MCU, commandName(command), 3*time.Millisecond, fmt.Errorf("failed: %w", errResponseTimeout))
+	stats.record(COMPONENT_MCU, commandName(command), 10*time.Second, errors.New("write failed"))
+
+	snapshot := stats.snapshot()
+	if len(snapshot) != 1 {
+		t.Fatalf("snapshot() = %v, want one command", snapshot)
+	}
+	stat := snapshot[0]
+	if stat.Command != "0x33/0x99" || stat.Count != 3 || stat.Timeouts != 1 || stat.Failures != 1 {
+		t.Errorf("stat = %v, want 0x33/0x99 with 3 commands, 1 timeout and 1 failure", stat.String())
+	}
+	if stat.Buckets[2] != 1 || stat.Buckets[len(COMMAND_LATENCY_BUCKETS)] != 1 || stat.MaxLatency != 10*time.Second {
+		t.Errorf("Buckets = %v, MaxLatency = %v", stat.Buckets, stat.MaxLatency)
+	}
+	if len(traced) != 1 || traced[0] != "mcu 0x33/0x99 <nil>" {
+		t.Errorf("traced = %v, want the started command", traced)
+	}
+
+	var b strings.Builder
+	if err := snapshot.WritePrometheus(&b); err != nil {
+		t.Fatalf("WritePrometheus() = %v", err)
+	}
+	for _, want := range []string{
+		`xreal_command_latency_seconds_bucket{component="mcu",command="0x33/0x99",le="0.005"} 2`,
+		`xreal_command_latency_seconds_bucket{component="mcu",command="0x33/0x99",le="+Inf"} 3`,
+		`xreal_command_timeouts_total{component="mcu",command="0x33/0x99"} 1`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("WritePrometheus() is missing %s in:\n%s", want, b.String())
+		}
+	}
+}
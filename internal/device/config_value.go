@@ -0,0 +1,130 @@
+package device
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ConfigKey is a glass setting exposed by GetConfigValue and SetConfigValue, backed by the protocol table
+// in light_command.go, so every known tunable can be read and written the same way.
+type ConfigKey struct {
+	Name        string
+	Description string
+
+	// get and set are the commands reading and writing the value, CMD_UKNOWN if not supported
+	get CommandInstruction
+	set CommandInstruction
+	// validate checks the value before it is sent, nil accepts any value
+	validate func(value string) error
+}
+
+func (k ConfigKey) Readable() bool {
+	return k.get != CMD_UKNOWN
+}
+
+func (k ConfigKey) Writable() bool {
+	return k.set != CMD_UKNOWN
+}
+
+// Settings that cannot be read back from the glass, e.g. the hardware buttons, are left to their own methods
+// which remember what was set.
+var configKeys = []ConfigKey{
+	{Name: "brightness", Description: "brightness level 0-7", get: CMD_GET_BRIGHTNESS_LEVEL, set: CMD_SET_BRIGHTNESS_LEVEL, validate: validateIntRange(0, 7)},
+	{Name: "duty", Description: "display duty 0-100 on top of the brightness level", get: CMD_GET_DUTY, set: CMD_SET_DUTY, validate: validateIntRange(0, 100)},
+	{Name: "oled_brightness", Description: "OLED brightness level 0-1", get: CMD_GET_OLED_BRIGHTNESS_LEVEL, set: CMD_SET_OLED_BRIGHTNESS_LEVEL, validate: validateIntRange(0, 1)},
+	{Name: "oled_brightness_brit", Description: "OLED brightness reported by the panel", get: CMD_GET_OLED_BRIGHTNESS_BRIT},
+	{Name: "display_mode", Description: "1 same on both, 2 half SBS, 3 stereo, 4 high refresh rate", get: CMD_GET_DISPLAY_MODE, set: CMD_SET_DISPLAY_MODE, validate: validateIntRange(1, 4)},
+	{Name: "display_hdcp", Description: "display HDCP string", get: CMD_GET_DISPLAY_HDCP},
+	{Name: "display_firmware", Description: "display firmware version", get: CMD_GET_DISPLAY_FIRMWARE},
+	{Name: "stock_firmware", Description: "firmware version the glass shipped with", get: CMD_GET_STOCK_FIRMWARE_VERSION},
+	{Name: "sleep_time", Description: fmt.Sprintf("seconds before the glass sleeps, larger than %d", MIN_SLEEP_TIME_SECONDS), get: CMD_GET_SLEEP_TIME, set: CMD_SET_SLEEP_TIME, validate: validateIntRange(MIN_SLEEP_TIME_SECONDS+1, -1)},
+	{Name: "ambient_light", Description: "ambient light reporting 0/1", get: CMD_GET_AMBIENT_LIGHT_ENABLED, set: CMD_ENABLE_AMBIENT_LIGHT, validate: validateIntRange(0, 1)},
+	{Name: "magnetometer", Description: "magnetometer reporting 0/1", get: CMD_GET_MAGNETOMETER_ENABLED, set: CMD_ENABLE_MAGNETOMETER, validate: validateIntRange(0, 1)},
+	{Name: "vsync", Description: "v-sync reporting 0/1", get: CMD_GET_VSYNC_ENABLED, set: CMD_ENABLE_VSYNC, validate: validateIntRange(0, 1)},
+	{Name: "temperature", Description: "temperature reporting 0/1", get: CMD_GET_TEMPERATURE_ENABLED, set: CMD_ENABLE_TEMPERATURE, validate: validateIntRange(0, 1)},
+	{Name: "rgb_camera", Description: "RGB camera power 0/1", get: CMD_GET_RGB_CAMERA_ENABLED, set: CMD_ENABLE_RGB_CAMERA, validate: validateIntRange(0, 1)},
+	{Name: "sdk_works", Description: "tells the glass an SDK is running 0/1", set: CMD_SET_SDK_WORKS, validate: validateIntRange(0, 1)},
+	{Name: "activated", Description: "if the glass is activated", get: CMD_GET_GLASS_ACTIVATED},
+	{Name: "approach_ps", Description: "approach proximity sensor value (experimental)", get: CMD_GET_APPROACH_PS_VALUE},
+	{Name: "distance_ps", Description: "distance proximity sensor value (experimental)", get: CMD_GET_DISTANCE_PS_VALUE},
+	{Name: "activation_time", Description: "glass activation time (epoch, sec)", get: CMD_GET_GLASS_ACTIVATION_TIME},
+}
+
+// GetConfigKeys returns all keys accepted by GetConfigValue and SetConfigValue.
+func GetConfigKeys() []ConfigKey {
+	return append([]ConfigKey(nil), configKeys...)
+}
+
+func findConfigKey(name string) (*ConfigKey, error) {
+	for i := range configKeys {
+		if configKeys[i].Name == name {
+			return &configKeys[i], nil
+		}
+	}
+	names := make([]string, 0, len(configKeys))
+	for _, key := range configKeys {
+		names = append(names, key.Name)
+	}
+	return nil, fmt.Errorf("unknown config key: got (%s) want one of (%v)", name, names)
+}
+
+// validateIntRange accepts integers in [lower, upper], a negative upper means no upper bound.
+func validateIntRange(lower, upper int) func(string) error {
+	return func(value string) error {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < lower || (upper >= 0 && number > upper) {
+			if upper < 0 {
+				return fmt.Errorf("invalid value %s, must be integer no less than %d", value, lower)
+			}
+			return fmt.Errorf("invalid value %s, must be integer %d-%d", value, lower, upper)
+		}
+		return nil
+	}
+}
+
+func (l *xrealLightMCU) getConfigValue(name string) (string, error) {
+	key, err := findConfigKey(name)
+	if err != nil {
+		return "", err
+	}
+	if !key.Readable() {
+		return "", fmt.Errorf("config key %s cannot be read back from the glass", name)
+	}
+	packet, err := l.buildCommandPacket(key.get)
+	if err != nil {
+		return "", err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return "", fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	return string(response), nil
+}
+
+func (l *xrealLightMCU) setConfigValue(name string, value string) error {
+	key, err := findConfigKey(name)
+	if err != nil {
+		return err
+	}
+	if !key.Writable() {
+		return fmt.Errorf("config key %s is read-only", name)
+	}
+	if key.validate != nil {
+		if err := key.validate(value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", name, err)
+		}
+	}
+	packet, err := l.buildCommandPacket(key.set, []byte(value))
+	if err != nil {
+		return err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	// compare the prefix only, in case the echoed value is followed by more
+	if len(response) < len(value) || string(response[:len(value)]) != value {
+		return fmt.Errorf("failed to %s: want %s got %s", packet.String(), value, string(response))
+	}
+	return nil
+}
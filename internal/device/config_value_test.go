@@ -0,0 +1,24 @@
+package device_test
+
+import (
+	"testing"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestGetConfigKeys(t *testing.T) {
+	seen := make(map[string]struct{})
+	for _, key := range device.GetConfigKeys() {
+		if _, ok := seen[key.Name]; ok {
+			t.Errorf("duplicate config key %s", key.Name)
+		}
+		seen[key.Name] = struct{}{}
+
+		if !key.Readable() && !key.Writable() {
+			t.Errorf("config key %s is neither readable nor writable", key.Name)
+		}
+		if key.Description == "" {
+			t.Errorf("config key %s has no description", key.Name)
+		}
+	}
+}
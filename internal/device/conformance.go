@@ -0,0 +1,173 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"xreal-light-xr-go/constant"
+)
+
+// ConformanceStatus is the outcome of a ConformanceCheck.
+type ConformanceStatus string
+
+const (
+	// CONFORMANCE_PASS means the response matches the expected format
+	CONFORMANCE_PASS = ConformanceStatus("pass")
+	// CONFORMANCE_FAIL means the command failed or the response does not match the expected format
+	CONFORMANCE_FAIL = ConformanceStatus("fail")
+	// CONFORMANCE_UNSUPPORTED means the firmware does not have the command, which is expected
+	CONFORMANCE_UNSUPPORTED = ConformanceStatus("unsupported")
+	// CONFORMANCE_RECORDED means the format is not known yet, the response is only recorded
+	CONFORMANCE_RECORDED = ConformanceStatus("recorded")
+)
+
+// ConformanceCheck is one get command run by RunConformance.
+type ConformanceCheck struct {
+	Name   string
+	Value  string
+	Status ConformanceStatus
+	// Detail tells why a check failed, or what was expected
+	Detail string
+}
+
+// ConformanceReport is the outcome of RunConformance, to confirm a glass works with this driver and to share
+// the responses of untested firmware with maintainers.
+type ConformanceReport struct {
+	Device        string
+	Firmware      string
+	KnownFirmware bool
+	Started       time.Time
+	Duration      time.Duration
+	Checks        []ConformanceCheck
+}
+
+// Passed is false if any check failed.
+func (r *ConformanceReport) Passed() bool {
+	for _, check := range r.Checks {
+		if check.Status == CONFORMANCE_FAIL {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns how many checks have the status.
+func (r *ConformanceReport) Count(status ConformanceStatus) int {
+	count := 0
+	for _, check := range r.Checks {
+		if check.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *ConformanceReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "device: %s\n", r.Device)
+	fmt.Fprintf(&b, "firmware: %s (known=%t)\n", r.Firmware, r.KnownFirmware)
+	fmt.Fprintf(&b, "started: %s, took %v\n", r.Started.Format(time.RFC3339), r.Duration.Round(time.Millisecond))
+	for _, check := range r.Checks {
+		fmt.Fprintf(&b, "[%s] %s: %q", check.Status, check.Name, check.Value)
+		if check.Detail != "" {
+			fmt.Fprintf(&b, " (%s)", check.Detail)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(
+		&b, "summary: %d pass, %d fail, %d unsupported, %d recorded\n",
+		r.Count(CONFORMANCE_PASS), r.Count(CONFORMANCE_FAIL), r.Count(CONFORMANCE_UNSUPPORTED), r.Count(CONFORMANCE_RECORDED),
+	)
+	return b.String()
+}
+
+// conformanceFormats are the expected response formats of read-only config keys, writable ones are checked with
+// their validation instead. Keys without a known format are only recorded.
+var conformanceFormats = map[string]*regexp.Regexp{
+	"activation_time": regexp.MustCompile(`^\d+$`),
+}
+
+// conformanceFormatsPerFirmware override conformanceFormats where a firmware reports a hardcoded value.
+var conformanceFormatsPerFirmware = map[string]map[string]*regexp.Regexp{
+	constant.FIRMWARE_05_5_08_059: {
+		"display_firmware": regexp.MustCompile(`^ELLA2_0518_V017$`),
+	},
+}
+
+// RunConformance runs every safe get command of the connected glass and checks the responses against the formats
+// expected for its firmware. Nothing is written to the glass.
+func RunConformance(d Device) *ConformanceReport {
+	report := &ConformanceReport{Device: d.Name(), Started: time.Now()}
+
+	firmware, err := d.GetFirmwareVersion()
+	report.Firmware = firmware
+	report.KnownFirmware = IsKnownFirmware(firmware)
+	report.addValidated("firmware", firmware, err, func(value string) error {
+		_, err := ParseFirmwareVersion(value)
+		return err
+	})
+	if check := &report.Checks[0]; check.Status == CONFORMANCE_PASS && !report.KnownFirmware {
+		check.Status = CONFORMANCE_RECORDED
+		check.Detail = "untested by this driver, please share this report"
+	}
+
+	serial, err := d.GetSerial()
+	report.add("serial", serial, err, regexp.MustCompile(`^\S+$`))
+
+	stockFirmware, err := d.GetStockFirmwareVersion()
+	report.add("stock_firmware", stockFirmware, err, nil)
+
+	for _, key := range configKeys {
+		if !key.Readable() || key.Name == "stock_firmware" {
+			continue
+		}
+		value, err := d.GetConfigValue(key.Name)
+		pattern := conformanceFormats[key.Name]
+		if override, ok := conformanceFormatsPerFirmware[firmware][key.Name]; ok {
+			pattern = override
+		}
+		if pattern == nil && key.validate != nil {
+			report.addValidated(key.Name, value, err, key.validate)
+			continue
+		}
+		report.add(key.Name, value, err, pattern)
+	}
+
+	report.Duration = time.Since(report.Started)
+	return report
+}
+
+func (r *ConformanceReport) add(name string, value string, err error, pattern *regexp.Regexp) {
+	r.addValidated(name, value, err, func(value string) error {
+		if pattern == nil {
+			return nil
+		}
+		if !pattern.MatchString(value) {
+			return fmt.Errorf("want %s", pattern.String())
+		}
+		return nil
+	})
+	if err == nil && pattern == nil {
+		r.Checks[len(r.Checks)-1].Status = CONFORMANCE_RECORDED
+	}
+}
+
+func (r *ConformanceReport) addValidated(name string, value string, err error, validate func(string) error) {
+	check := ConformanceCheck{Name: name, Value: value, Status: CONFORMANCE_PASS}
+	switch {
+	case errors.Is(err, ErrUnsupportedByFirmware):
+		check.Status = CONFORMANCE_UNSUPPORTED
+	case err != nil:
+		check.Status = CONFORMANCE_FAIL
+		check.Detail = err.Error()
+	default:
+		if validateErr := validate(value); validateErr != nil {
+			check.Status = CONFORMANCE_FAIL
+			check.Detail = validateErr.Error()
+		}
+	}
+	r.Checks = append(r.Checks, check)
+}
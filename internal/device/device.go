@@ -0,0 +1,479 @@
+package device
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+	"slices"
+	"time"
+
+	"xreal-light-xr-go/storage"
+
+	hid "github.com/sstallion/go-hid"
+)
+
+const (
+	readDeviceTimeout   = 30 * time.Millisecond
+	readPacketFrequency = 10 * time.Millisecond
+
+	waitForPacketTimeout = 1 * time.Second
+	retryMaxAttempts     = 3
+
+	// ov580ReenumerationTimeout is how long ResetSensors waits for the OV580 to come back after a reset
+	ov580ReenumerationTimeout = 10 * time.Second
+	// connectTimeout is the deadline shared by the components connecting in parallel, after which they stop retrying
+	connectTimeout = 30 * time.Second
+
+	heartBeatTimeout = 500 * time.Millisecond
+	// heartBeatLostTimeout is how long the glass may not respond to heart beats before ErrHeartBeatLost is reported
+	heartBeatLostTimeout = 6 * heartBeatTimeout
+)
+
+// Device is an interface representing XREAL glasses.
+type Device interface {
+	Name() string
+	PID() uint16
+	VID() uint16
+
+	Connect() error
+	Disconnect() error
+
+	// Only one process at a time controls the glass, others connect as ROLE_OBSERVER and only receive events.
+	// RequestControl waits up to timeout for the controller to ReleaseControl or exit, then takes over.
+	GetRole() (Role, error)
+	RequestControl(timeout time.Duration) error
+	ReleaseControl() error
+
+	GetSerial() (string, error)
+	GetFirmwareVersion() (string, error)
+	// GetStockFirmwareVersion returns the firmware the glass shipped with, as the glass reports it
+	GetStockFirmwareVersion() (string, error)
+	// GetDisplayFirmware and GetDisplayHDCPVersion describe the display, wrapping ErrUnsupportedByFirmware if the
+	// firmware of the glass has no command for it
+	GetDisplayFirmware() (*DisplayVersion, error)
+	GetDisplayHDCPVersion() (*DisplayVersion, error)
+	// GetOV580Info describes the OV580 of the SLAM cameras and IMU
+	GetOV580Info() (*OV580Info, error)
+	// ReadOV580File reads a file from the OV580 with the chunked protocol of the calibration file, see
+	// OV580_CALIBRATION_FILE_ID. Transfers that lost parts start over, up to the attempts of the retry policy. The IMU
+	// stream is paused meanwhile.
+	ReadOV580File(id uint8, options OV580FileOptions) (*OV580File, error)
+	// GetMCUInfo, GetSensorInfo and GetCameraInfo tell which USB functions the MCU, the OV580 and the RGB and SLAM
+	// cameras are bound to, also while disconnected, e.g. to debug connection issues
+	GetMCUInfo() (*ConnectionInfo, error)
+	GetSensorInfo() (*ConnectionInfo, error)
+	GetCameraInfo() ([]ConnectionInfo, error)
+	// The serial number, stock firmware, display versions, OV580 info and capabilities are queried once per connection
+	// and cached, InvalidateInfoCache makes the next calls query the glass again, e.g. after changing it by raw commands
+	InvalidateInfoCache()
+	// ResetSensors resets the OV580, waits for it to re-enumerate, then reopens it and the SLAM camera and
+	// re-enables the IMU stream. It is a recovery path for when the IMU stream wedges.
+	ResetSensors() error
+	// SetRetryPolicy replaces DefaultRetryPolicy for the commands the MCU and OV580 drivers send again on failure
+	SetRetryPolicy(policy RetryPolicy) error
+	// SetStreamWatchdog watches the IMU stream while enabled and the SLAM camera while frames are requested, and
+	// runs the recovery actions of the policy when either stalls, reporting ErrStreamStalled through the
+	// ErrorHandler. Observers only report. nil stops watching.
+	SetStreamWatchdog(policy *WatchdogPolicy) error
+
+	GetBrightnessLevel() (string, error)
+	SetBrightnessLevel(level string) error
+
+	// OLED brightness is controlled by the display panel separately from the 0-7 brightness level,
+	// the level takes '0'/'1' and brit is a read-only value reported by the panel
+	GetOLEDBrightnessLevel() (string, error)
+	SetOLEDBrightnessLevel(level string) error
+	GetOLEDBrightnessBrit() (string, error)
+
+	// KeySwitch toggles the hardware buttons, e.g. disable them for kiosk mode, and Default2D toggles the default 2D function.
+	// The glass cannot report either toggle, so getters only return what was set in this session.
+	GetKeySwitchEnabled() (bool, error)
+	SetKeySwitchEnabled(enabled bool) error
+	GetDefault2DEnabled() (bool, error)
+	SetDefault2DEnabled(enabled bool) error
+
+	// OrbitFunction and SuperActive are experimental, their purpose is unknown and they are exposed to study their effects.
+	// GetOrbitFunction returns the raw state reported by the glass, and SuperActive cannot be read back like KeySwitch.
+	GetOrbitFunction() (string, error)
+	SetOrbitFunction(open bool) error
+	GetSuperActive() (bool, error)
+	SetSuperActive(enabled bool) error
+
+	// GetRGBCameraEnabled tells whether the RGB camera is powered, it only enumerates on USB while it is,
+	// see CMD_ENABLE_RGB_CAMERA
+	GetRGBCameraEnabled() (bool, error)
+
+	// GetClockSync returns the MCU clock estimation, e.g. to map MCU timestamps onto the host clock or measure latency
+	GetClockSync() (*ClockSync, error)
+
+	// Idle saves power by disabling all event reporting, suspending heart beats and polling the glass only every
+	// second. The next command, e.g. EnableIMU, or event handler set resumes full operation, and re-enables the
+	// event reporting, including the IMU stream, enabled before idling. Reporting never set since connecting is
+	// left off, as the glass cannot tell whether it was on.
+	Idle() error
+
+	// GetCapabilities tells which firmware dependent features the connected glass supports
+	GetCapabilities() (*Capabilities, error)
+
+	// GetConfigValue and SetConfigValue read and write any setting by key, see GetConfigKeys for the keys and values
+	GetConfigValue(key string) (string, error)
+	SetConfigValue(key string, value string) error
+
+	// ExecuteAsync sends an MCU command and returns right away, so independent commands can be pipelined instead of
+	// waiting for each response in turn, e.g. at startup. A nil payload sends the default one. OV580 commands are not
+	// supported as their responses carry no command to tell them apart.
+	ExecuteAsync(instruction CommandInstruction, payload []byte) *CommandFuture
+
+	// SleepTime is how long in seconds the glass waits before sleeping, must be larger than MIN_SLEEP_TIME_SECONDS
+	GetSleepTime() (string, error)
+	SetSleepTime(seconds string) error
+
+	// DisplayOff blanks the display without changing the brightness level, DisplayOn restores it
+	DisplayOff() error
+	DisplayOn() error
+
+	GetDisplayMode() (DisplayMode, error)
+	SetDisplayMode(mode DisplayMode) error
+	// EnterSBS switches to DISPLAY_MODE_HALF_SBS and reverts to the previous mode after the duration,
+	// when the glass is taken off (PROXIMITY_FAR), or on ExitSBS. Entering again while active extends it.
+	EnterSBS(duration time.Duration) error
+	ExitSBS() error
+
+	GetImages(folderpath string) ([]string, error)
+	// CaptureImages writes the left and right SLAM camera images to store as JPEG, e.g. network storage, and
+	// returns their locations
+	CaptureImages(store storage.Storage) ([]string, error)
+	// GetSLAMFrame returns the left and right SLAM camera images, and when they are received
+	GetSLAMFrame() (image.Image, image.Image, time.Time, error)
+	// GetSLAMFrameRaw returns the raw grayscale pixels of the SLAM cameras
+	GetSLAMFrameRaw() (*SLAMFrame, error)
+	// SetFramePipeline processes every SLAM frame before it is returned or stored, e.g. to correct gamma or rotate the
+	// images of a glass mounted sideways, see ParseFramePipeline. An empty pipeline returns the frames as captured.
+	SetFramePipeline(pipeline FramePipeline) error
+	// SetCapturePolicy sets how CaptureImages and GetImages name the images, how many they keep and how much disk
+	// space they leave, e.g. for long captures on a small disk. The zero CapturePolicy names them by timestamp and
+	// keeps all of them.
+	SetCapturePolicy(policy CapturePolicy) error
+
+	// Stream formats are parsed from the UVC descriptors of the cameras, which must be connected
+	GetSLAMStreamFormats() ([]StreamFormat, error)
+	SetSLAMStreamConfig(config StreamConfig) error
+	GetRGBStreamFormats() ([]StreamFormat, error)
+	SetRGBStreamConfig(config StreamConfig) error
+
+	// EnableEventReporting toggles the reporting of one of the EVENT_REPORTING_INSTRUCTIONS with '0'/'1', prefer
+	// the typed methods below which don't need the protocol details.
+	EnableEventReporting(event CommandInstruction, enabled string) error
+	EnableVSync(enabled bool) error
+	EnableAmbientLight(enabled bool) error
+	EnableMagnetometer(enabled bool) error
+	EnableTemperature(enabled bool) error
+	EnableIMU(enabled bool) error
+	// IsIMUEnabled reports whether the IMU stream is enabled, so tools borrowing it can leave it as they found it.
+	IsIMUEnabled() bool
+
+	SetAmbientLightEventHandler(handler AmbientLightEventHandler)
+	SetKeyEventHandler(handler KeyEventHandler)
+	SetMagnetometerEventHandler(handler MagnetometerEventHandler)
+	SetProximityEventHandler(handler ProximityEventHandler)
+	SetTemperatureEventHandler(handler TemperatureEventHandlder)
+	SetVSyncEventHandler(handler VSyncEventHandler)
+	SetIMUEventHandler(handler IMUEventHandler)
+	// GetIMUEventHandler returns the handler last set with SetIMUEventHandler, so tools borrowing the IMU stream can
+	// put it back.
+	GetIMUEventHandler() IMUEventHandler
+	SetResumedEventHandler(handler ResumedEventHandler)
+	// SetErrorHandler receives errors from background goroutines, wrapping ErrReadFailed, ErrDeserializeFailed,
+	// ErrHeartBeatLost, ErrStreamStalled or ErrPanic, and ErrUntestedFirmware on connecting. Without a handler they
+	// are logged.
+	SetErrorHandler(handler ErrorHandler)
+
+	// ExecuteRaw sends an MCU command given by its type and ID, e.g. Command{Type: '3', ID: 'C'}, with payload and
+	// returns the payload of the response, so tools can send commands the driver has no method for. An empty payload
+	// sends the default one. It fails with ErrCommandNotAllowed unless the command is known to be safe, i.e. does not
+	// change the state of the glass, or the driver is built with `-tags developer`.
+	ExecuteRaw(command Command, payload []byte) ([]byte, error)
+	// ExecuteRawOV580 is ExecuteRaw for OV580 commands, which carry a single byte value.
+	ExecuteRawOV580(command Command, value uint8) ([]byte, error)
+
+	// For development testing only
+	DevExecuteAndRead(device string, intput []string)
+	GetImagesDataDev(folderpath string) ([]string, error)
+}
+
+// DisplayMode represents the display mode of AR glasses.
+type DisplayMode string
+
+const (
+	DISPLAY_MODE_UNKNOWN DisplayMode = "UNKNOWN"
+	// SAME_ON_BOTH indicates that the picture should be the same for both eyes (simple 2D 1080p at 60Hz).
+	DISPLAY_MODE_SAME_ON_BOTH DisplayMode = "SAME_ON_BOTH"
+	// HALF_SBS sets the display to half-SBS mode, which presents itself as 1920x1080 resolution,
+	// but actually scales down everything to 960x540 at 120Hz, then upscales to 3840x1080.
+	DISPLAY_MODE_HALF_SBS DisplayMode = "HALF_SBS"
+	// STEREO sets the display to 1080p on both eyes at 60Hz.
+	DISPLAY_MODE_STEREO DisplayMode = "STEREO"
+	// HIGH_REFRESH_RATE sets the display at 1080p at 72Hz high refresh rate mode.
+	DISPLAY_MODE_HIGH_REFRESH_RATE DisplayMode = "HIGH_REFRESH_RATE"
+)
+
+type DeviceHandlers struct {
+	AmbientLightEventHandler AmbientLightEventHandler
+	KeyEventHandler          KeyEventHandler
+	MagnetometerEventHandler MagnetometerEventHandler
+	ProximityEventHandler    ProximityEventHandler
+	TemperatureEventHandlder TemperatureEventHandlder
+	VSyncEventHandler        VSyncEventHandler
+	IMUEventHandler          IMUEventHandler
+	ResumedEventHandler      ResumedEventHandler
+	ErrorHandler             ErrorHandler
+}
+
+type AmbientLightEventHandler func(uint16)
+type VSyncEventHandler func(string)
+type TemperatureEventHandlder func(string)
+
+// ResumedEventHandler is called once the glass connections are re-established after the host resumed from sleep,
+// so applications can re-sync any state they pushed to the glass before.
+type ResumedEventHandler func()
+
+type MagnetometerEventHandler func(*MagnetometerVector)
+
+type MagnetometerVector struct {
+	// X, Y, Z are the readings in the raw unit of the sensor, which is not known, e.g. averaged or rotated into rig
+	// axes. They are not microtesla.
+	X float64
+	Y float64
+	Z float64
+	// RawX, RawY, RawZ are the integer readings as reported by the glass
+	RawX int
+	RawY int
+	RawZ int
+	// DeviceTimestamp is in miliseconds as reported by the glass
+	DeviceTimestamp uint64
+	// Valid is false if the payload failed to parse, in which case the readings must be ignored
+	Valid bool
+	// Timestamp is when the reading is received, normalized with the ClockSync of the glass when connected
+	Timestamp time.Time
+}
+
+func (mv MagnetometerVector) String() string {
+	if !mv.Valid {
+		return fmt.Sprintf("invalid at %v", mv.Timestamp)
+	}
+	return fmt.Sprintf("(x,y,z)=(%.2f, %.2f, %.2f) raw at %v (device %d ms)", mv.X, mv.Y, mv.Z, mv.Timestamp, mv.DeviceTimestamp)
+}
+
+type KeyEventHandler func(KeyEvent)
+type KeyEvent uint8
+
+func (e KeyEvent) String() string {
+	switch e {
+	case KEY_UP_PRESSED:
+		return "UP"
+	case KEY_DOWN_PRESSED:
+		return "DOWN"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+const (
+	KEY_UNKNOWN KeyEvent = iota
+	KEY_UP_PRESSED
+	KEY_DOWN_PRESSED
+)
+
+type ProximityEventHandler func(ProximityEvent)
+type ProximityEvent uint8
+
+func (e ProximityEvent) String() string {
+	switch e {
+	case PROXIMITY_NEAR:
+		return "NEAR"
+	case PROXIMITY_FAR:
+		return "FAR"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+const (
+	PROXIMITY_UKNOWN ProximityEvent = iota
+	PROXIMITY_NEAR
+	PROXIMITY_FAR
+)
+
+type IMUEventHandler func(*IMUEvent)
+type IMUEvent struct {
+	Accelerometer *AccelerometerVector
+	Gyroscope     *GyroscopeVector
+	// TimeSinceBoot is in miliseconds
+	TimeSinceBoot uint64
+}
+
+func (imu IMUEvent) String() string {
+	return fmt.Sprintf("accel: %s, gyro: %s, at %d ms since boot", imu.Accelerometer.String(), imu.Gyroscope.String(), imu.TimeSinceBoot)
+}
+
+type AccelerometerVector struct {
+	X float32
+	Y float32
+	Z float32
+}
+
+func (accel AccelerometerVector) String() string {
+	return fmt.Sprintf("(x,y,z)=(%f, %f, %f)", accel.X, accel.Y, accel.Z)
+}
+
+type GyroscopeVector struct {
+	X float32
+	Y float32
+	Z float32
+}
+
+func (gyro GyroscopeVector) String() string {
+	return fmt.Sprintf("(x,y,z)=(%f, %f, %f)", gyro.X, gyro.Y, gyro.Z)
+}
+
+// OV580Info describes the OV580 handling the SLAM cameras and IMU, to correlate behavior differences across
+// hardware revisions.
+type OV580Info struct {
+	Manufacturer string
+	Product      string
+	SerialNumber string
+	// Release is the device release number (bcdDevice) of the USB descriptor, which tracks the OV580 firmware
+	Release string
+	// HasCalibration tells if the IMU calibration file is loaded
+	HasCalibration bool
+}
+
+func (i OV580Info) String() string {
+	return fmt.Sprintf("%s %s release %s (calibration loaded=%t)", i.Manufacturer, i.Product, i.Release, i.HasCalibration)
+}
+
+// ConnectionInfo tells which USB function a component of the glass is bound to.
+type ConnectionInfo struct {
+	// Component is one of COMPONENT_MCU, COMPONENT_OV580, COMPONENT_RGB_CAMERA and COMPONENT_SLAM_CAMERA
+	Component string
+	// Path is the hid path, or the libusb location for cameras, empty until the component was found
+	Path string
+	// SerialNumber is taken from the USB descriptor, empty if unknown
+	SerialNumber string
+	// Interface is the USB interface number, -1 if unknown
+	Interface int
+	// UsagePage and Usage are of the hid interface, 0 if unknown
+	UsagePage uint16
+	Usage     uint16
+	// Open tells if the component is currently opened by this process
+	Open bool
+}
+
+func (i ConnectionInfo) String() string {
+	return fmt.Sprintf("%s: path %s, serial %s, interface %d (usage page 0x%04x, usage 0x%04x), open=%t", i.Component, i.Path, i.SerialNumber, i.Interface, i.UsagePage, i.Usage, i.Open)
+}
+
+// hidConnectionInfo describes a hid component, device is nil while it is not open.
+func hidConnectionInfo(component string, device hidDevice, devicePath *string) *ConnectionInfo {
+	info := &ConnectionInfo{Component: component, Interface: -1}
+	if devicePath != nil {
+		info.Path = *devicePath
+	}
+	if device == nil {
+		return info
+	}
+
+	info.Open = true
+	deviceInfo, err := device.GetDeviceInfo()
+	if err != nil {
+		slog.Debug(fmt.Sprintf("failed to get %s device info: %v", component, err))
+		return info
+	}
+	info.SerialNumber = deviceInfo.SerialNbr
+	info.Interface = deviceInfo.InterfaceNbr
+	info.UsagePage = deviceInfo.UsagePage
+	info.Usage = deviceInfo.Usage
+	return info
+}
+
+// Capabilities tells which firmware dependent features are supported, so the effects of experimental ones
+// can be compared across firmware versions.
+type Capabilities struct {
+	Firmware string
+	// KnownFirmware is false if the firmware is untested by this driver, see GetKnownFirmware
+	KnownFirmware bool
+	KeySwitch     bool
+	Default2D     bool
+	OrbitFunction bool
+	SuperActive   bool
+	// RGBCameraEnabled is the RGB camera power state when the capabilities were read, see GetRGBCameraEnabled
+	RGBCameraEnabled bool
+	// Cameras is false if the RGB and SLAM cameras cannot be used, e.g. as libusb is missing, see ErrCamerasUnavailable
+	Cameras bool
+	// OnboardTracking is true if the glass computes its 3DoF orientation on board, like the One series
+	OnboardTracking bool
+}
+
+func (c Capabilities) String() string {
+	return fmt.Sprintf("firmware %s (known=%t): keyswitch=%t default2d=%t orbit=%t superactive=%t rgbcam=%t cameras=%t onboardtracking=%t", c.Firmware, c.KnownFirmware, c.KeySwitch, c.Default2D, c.OrbitFunction, c.SuperActive, c.RGBCameraEnabled, c.Cameras, c.OnboardTracking)
+}
+
+var SupportedDisplayMode = map[string]struct{}{
+	string(DISPLAY_MODE_SAME_ON_BOTH):      {},
+	string(DISPLAY_MODE_HALF_SBS):          {},
+	string(DISPLAY_MODE_STEREO):            {},
+	string(DISPLAY_MODE_HIGH_REFRESH_RATE): {},
+}
+
+// pastDeadline tells if a deadline is set and has passed.
+func pastDeadline(deadline time.Time) bool {
+	return !deadline.IsZero() && time.Now().After(deadline)
+}
+
+// EVENT_REPORTING_INSTRUCTIONS are the instructions accepted by Device.EnableEventReporting.
+var EVENT_REPORTING_INSTRUCTIONS = []CommandInstruction{
+	CMD_ENABLE_AMBIENT_LIGHT,
+	CMD_ENABLE_MAGNETOMETER,
+	CMD_ENABLE_VSYNC,
+	CMD_ENABLE_TEMPERATURE,
+	CMD_ENABLE_RGB_CAMERA,
+	OV580_ENABLE_IMU_STREAM,
+}
+
+// validateEventReporting rejects instructions other than EVENT_REPORTING_INSTRUCTIONS, e.g. CMD_SET_SLEEP_TIME
+// which takes seconds, and values other than '0'/'1'.
+func validateEventReporting(instruction CommandInstruction, enabled string) error {
+	if !slices.Contains(EVENT_REPORTING_INSTRUCTIONS, instruction) {
+		return fmt.Errorf("invalid event reporting instruction: %s", Command{instruction: instruction}.String())
+	}
+	if enabled != "0" && enabled != "1" {
+		return fmt.Errorf("invalid value %s, must be '0' or '1'", enabled)
+	}
+	return nil
+}
+
+func eventReportingValue(enabled bool) string {
+	if enabled {
+		return "1"
+	}
+	return "0"
+}
+
+func EnumerateDevices(vid, pid uint16) ([]*hid.DeviceInfo, error) {
+	var devices []*hid.DeviceInfo
+	uniquePaths := make(map[string]struct{})
+	err := hid.Enumerate(vid, pid, func(info *hid.DeviceInfo) error {
+		if _, ok := uniquePaths[info.Path]; !ok {
+			uniquePaths[info.Path] = struct{}{}
+			devices = append(devices, info)
+		}
+		return nil
+	})
+	return devices, err
+}
+
+func getTimestampNow() []byte {
+	return []byte(fmt.Sprintf("%x", (time.Now().UnixMilli())))
+}
+
+// TODO(happyz): Adds hotplug detection once https://github.com/libusb/hidapi/pull/674 is resolved.
@@ -6,7 +6,7 @@ import (
 	"reflect"
 	"testing"
 
-	"xreal-light-xr-go/device"
+	"xreal-light-xr-go/internal/device"
 )
 
 func TestSerializeDeserializeCommandSuccessfully(t *testing.T) {
@@ -48,3 +48,15 @@ func TestSerializeDeserializeCommandSuccessfully(t *testing.T) {
 		}
 	}
 }
+
+func TestEnableEventReportingRejectsInvalidInput(t *testing.T) {
+	glass := device.NewXREALLight(nil, nil)
+
+	// rejected before anything is sent, so no glass is needed
+	if err := glass.EnableEventReporting(device.CMD_SET_SLEEP_TIME, "1"); err == nil {
+		t.Errorf("EnableEventReporting(CMD_SET_SLEEP_TIME) = nil, want error")
+	}
+	if err := glass.EnableEventReporting(device.CMD_ENABLE_VSYNC, "yes"); err == nil {
+		t.Errorf("EnableEventReporting(CMD_ENABLE_VSYNC, yes) = nil, want error")
+	}
+}
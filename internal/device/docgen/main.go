@@ -0,0 +1,27 @@
+// docgen writes the protocol reference of the Light to the file given as argument, run by go generate ./internal/device.
+package main
+
+import (
+	"log"
+	"os"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		log.Fatal("usage: docgen <output.md>")
+	}
+
+	f, err := os.Create(os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := device.GenerateProtocolDocs(f); err != nil {
+		f.Close()
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,121 @@
+package device
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sort"
+
+	"xreal-light-xr-go/storage"
+)
+
+const (
+	IMAGE_ENCODER_JPEG      = "jpeg"
+	IMAGE_ENCODER_PNG       = "png"
+	IMAGE_ENCODER_TURBOJPEG = "turbojpeg"
+	IMAGE_ENCODER_WEBP      = "webp"
+
+	DEFAULT_JPEG_QUALITY = jpeg.DefaultQuality
+	DEFAULT_WEBP_QUALITY = 75
+)
+
+// ImageEncoder writes camera frames to files in a specific image format.
+type ImageEncoder interface {
+	// Encode writes img to w
+	Encode(w io.Writer, img image.Image) error
+	// Extension is the file extension without dot, e.g. "jpeg"
+	Extension() string
+}
+
+// imageEncoders holds the constructors of available encoders, backends behind build tags register themselves here.
+var imageEncoders = map[string]func() ImageEncoder{
+	IMAGE_ENCODER_JPEG: func() ImageEncoder { return &JPEGEncoder{Quality: DEFAULT_JPEG_QUALITY} },
+	IMAGE_ENCODER_PNG:  func() ImageEncoder { return &PNGEncoder{CompressionLevel: png.BestSpeed} },
+}
+
+// SupportedImageEncoders returns the names of encoders compiled into this build.
+func SupportedImageEncoders() []string {
+	var names []string
+	for name := range imageEncoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewImageEncoder creates an encoder by name, see SupportedImageEncoders.
+func NewImageEncoder(name string) (ImageEncoder, error) {
+	newEncoder, ok := imageEncoders[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported image encoder: got (%s) want one of (%v)", name, SupportedImageEncoders())
+	}
+	return newEncoder(), nil
+}
+
+// JPEGEncoder uses the standard library JPEG encoder.
+type JPEGEncoder struct {
+	// Quality ranges from 1 to 100, higher is better
+	Quality int
+}
+
+func (e *JPEGEncoder) Encode(w io.Writer, img image.Image) error {
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: e.Quality})
+}
+
+func (e *JPEGEncoder) Extension() string {
+	return "jpeg"
+}
+
+// PNGEncoder uses the standard library PNG encoder, lossless but larger and slower than JPEG.
+// It is not safe for concurrent use.
+type PNGEncoder struct {
+	CompressionLevel png.CompressionLevel
+
+	// encoder reuses buffers across frames
+	encoder *png.Encoder
+	pool    pngBufferPool
+}
+
+func (e *PNGEncoder) Encode(w io.Writer, img image.Image) error {
+	if e.encoder == nil {
+		e.encoder = &png.Encoder{CompressionLevel: e.CompressionLevel, BufferPool: &e.pool}
+	}
+	return e.encoder.Encode(w, img)
+}
+
+func (e *PNGEncoder) Extension() string {
+	return "png"
+}
+
+// pngBufferPool keeps a single buffer since frames are encoded one at a time.
+type pngBufferPool struct {
+	buffer *png.EncoderBuffer
+}
+
+func (p *pngBufferPool) Get() *png.EncoderBuffer {
+	buffer := p.buffer
+	p.buffer = nil
+	return buffer
+}
+
+func (p *pngBufferPool) Put(buffer *png.EncoderBuffer) {
+	p.buffer = buffer
+}
+
+func imageToStorage(img image.Image, store storage.Storage, name string, encoder ImageEncoder) error {
+	w, err := store.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if err := encoder.Encode(w, img); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write image to %s: %w", store.Location(name), err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to write image to %s: %w", store.Location(name), err)
+	}
+	return nil
+}
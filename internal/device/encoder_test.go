@@ -0,0 +1,75 @@
+package device_test
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+// slamImage returns a 640x480 grayscale gradient, same size as a SLAM camera image.
+func slamImage() image.Image {
+	img := image.NewGray(image.Rect(0, 0, 640, 480))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i % 640 / 3)
+	}
+	return img
+}
+
+func TestImageEncoders(t *testing.T) {
+	decoders := map[string]func(io.Reader) (image.Image, error){
+		"jpeg": jpeg.Decode,
+		"png":  png.Decode,
+	}
+	for _, name := range device.SupportedImageEncoders() {
+		encoder, err := device.NewImageEncoder(name)
+		if err != nil {
+			t.Fatalf("NewImageEncoder(%s) failed: %v", name, err)
+		}
+		var buffer bytes.Buffer
+		if err := encoder.Encode(&buffer, slamImage()); err != nil {
+			t.Fatalf("%s: Encode() failed: %v", name, err)
+		}
+		decode, ok := decoders[encoder.Extension()]
+		if !ok {
+			// the standard library has no decoder for e.g. webp, so only the container is checked
+			if header := buffer.Bytes(); len(header) < 12 || string(header[:4]) != "RIFF" || string(header[8:12]) != "WEBP" {
+				t.Errorf("%s: encoded image is not %s", name, encoder.Extension())
+			}
+			continue
+		}
+		img, err := decode(&buffer)
+		if err != nil {
+			t.Fatalf("%s: failed to decode encoded image: %v", name, err)
+		}
+		if img.Bounds() != slamImage().Bounds() {
+			t.Errorf("%s: got bounds %v want %v", name, img.Bounds(), slamImage().Bounds())
+		}
+	}
+
+	if _, err := device.NewImageEncoder("bmp"); err == nil {
+		t.Errorf("NewImageEncoder(bmp) want error got nil")
+	}
+}
+
+func BenchmarkImageEncoders(b *testing.B) {
+	img := slamImage()
+	for _, name := range device.SupportedImageEncoders() {
+		b.Run(name, func(b *testing.B) {
+			encoder, err := device.NewImageEncoder(name)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := encoder.Encode(io.Discard, img); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
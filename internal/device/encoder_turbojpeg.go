@@ -0,0 +1,77 @@
+//go:build turbojpeg
+
+package device
+
+// #cgo LDFLAGS: -lturbojpeg
+// #include <stdlib.h>
+// #include <turbojpeg.h>
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"runtime"
+	"unsafe"
+)
+
+func init() {
+	imageEncoders[IMAGE_ENCODER_TURBOJPEG] = func() ImageEncoder { return &TurboJPEGEncoder{Quality: DEFAULT_JPEG_QUALITY} }
+}
+
+// TurboJPEGEncoder uses libjpeg-turbo, built with `-tags turbojpeg` and libturbojpeg installed.
+// It is not safe for concurrent use.
+type TurboJPEGEncoder struct {
+	// Quality ranges from 1 to 100, higher is better
+	Quality int
+
+	handle C.tjhandle
+}
+
+func (e *TurboJPEGEncoder) Encode(w io.Writer, img image.Image) error {
+	if e.handle == nil {
+		e.handle = C.tjInitCompress()
+		if e.handle == nil {
+			return fmt.Errorf("failed to init libjpeg-turbo compressor")
+		}
+		runtime.SetFinalizer(e, func(e *TurboJPEGEncoder) { C.tjDestroy(e.handle) })
+	}
+
+	var pix []uint8
+	var stride int
+	var pixelFormat, subsampling C.int
+
+	switch src := img.(type) {
+	case *image.Gray:
+		pix, stride = src.Pix, src.Stride
+		pixelFormat, subsampling = C.TJPF_GRAY, C.TJSAMP_GRAY
+	case *image.RGBA:
+		pix, stride = src.Pix, src.Stride
+		pixelFormat, subsampling = C.TJPF_RGBA, C.TJSAMP_420
+	default:
+		rgba := image.NewRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		pix, stride = rgba.Pix, rgba.Stride
+		pixelFormat, subsampling = C.TJPF_RGBA, C.TJSAMP_420
+	}
+	if len(pix) == 0 {
+		return fmt.Errorf("empty image")
+	}
+
+	bounds := img.Bounds()
+	var jpegBuf *C.uchar
+	var jpegSize C.ulong
+	if C.tjCompress2(e.handle, (*C.uchar)(unsafe.Pointer(&pix[0])), C.int(bounds.Dx()), C.int(stride), C.int(bounds.Dy()),
+		pixelFormat, &jpegBuf, &jpegSize, subsampling, C.int(e.Quality), C.TJFLAG_FASTDCT) != 0 {
+		return fmt.Errorf("failed to compress with libjpeg-turbo: %s", C.GoString(C.tjGetErrorStr2(e.handle)))
+	}
+	defer C.tjFree(jpegBuf)
+
+	_, err := w.Write(C.GoBytes(unsafe.Pointer(jpegBuf), C.int(jpegSize)))
+	return err
+}
+
+func (e *TurboJPEGEncoder) Extension() string {
+	return "jpeg"
+}
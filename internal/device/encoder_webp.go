@@ -0,0 +1,61 @@
+//go:build webp
+
+package device
+
+// #cgo LDFLAGS: -lwebp
+// #include <stdlib.h>
+// #include <webp/encode.h>
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"unsafe"
+)
+
+func init() {
+	imageEncoders[IMAGE_ENCODER_WEBP] = func() ImageEncoder { return &WebPEncoder{Quality: DEFAULT_WEBP_QUALITY} }
+}
+
+// WebPEncoder uses libwebp, built with `-tags webp` and libwebp 1.0 or later installed.
+type WebPEncoder struct {
+	// Quality ranges from 0 to 100, higher is better
+	Quality int
+	// Lossless ignores Quality and keeps the frame exact, like PNG but smaller
+	Lossless bool
+}
+
+func (e *WebPEncoder) Encode(w io.Writer, img image.Image) error {
+	// libwebp has no grayscale input, so SLAM frames are expanded as well
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
+	if len(rgba.Pix) == 0 {
+		return fmt.Errorf("empty image")
+	}
+
+	bounds := rgba.Bounds()
+	pix := (*C.uint8_t)(unsafe.Pointer(&rgba.Pix[0]))
+	var output *C.uint8_t
+	var size C.size_t
+	if e.Lossless {
+		size = C.WebPEncodeLosslessRGBA(pix, C.int(bounds.Dx()), C.int(bounds.Dy()), C.int(rgba.Stride), &output)
+	} else {
+		size = C.WebPEncodeRGBA(pix, C.int(bounds.Dx()), C.int(bounds.Dy()), C.int(rgba.Stride), C.float(e.Quality), &output)
+	}
+	if size == 0 {
+		return fmt.Errorf("failed to compress with libwebp")
+	}
+	defer C.WebPFree(unsafe.Pointer(output))
+
+	_, err := w.Write(C.GoBytes(unsafe.Pointer(output), C.int(size)))
+	return err
+}
+
+func (e *WebPEncoder) Extension() string {
+	return "webp"
+}
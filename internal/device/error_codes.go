@@ -0,0 +1,48 @@
+package device
+
+import "errors"
+
+// ErrorCode is a stable code of an error, e.g. XR-013, which users can reference in issues and scripts can match in
+// the output, unlike the error messages that may change.
+type ErrorCode string
+
+// errorCodes are the codes of the errors of this package. Codes are never reused or renumbered, errors wrapping others
+// come first so the most specific code is found.
+var errorCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{ErrReadFailed, "XR-001"},
+	{ErrDeserializeFailed, "XR-002"},
+	{ErrHeartBeatLost, "XR-003"},
+	{ErrIMUStreamRestarted, "XR-004"},
+	{ErrUnsupportedCommand, "XR-005"},
+	{ErrUnsupportedByFirmware, "XR-006"},
+	{ErrCamerasUnavailable, "XR-007"},
+	{ErrChecksumMismatch, "XR-008"},
+	{ErrLowDiskSpace, "XR-009"},
+	{ErrBeamUnsupported, "XR-010"},
+	{ErrPanic, "XR-011"},
+	{ErrUntestedFirmware, "XR-012"},
+	{errResponseTimeout, "XR-013"},
+	{errResponseClosed, "XR-014"},
+	{ErrObserver, "XR-015"},
+	{ErrCommandNotAllowed, "XR-016"},
+	{ErrNoClockSamples, "XR-017"},
+	{ErrOV580BadState, "XR-018"},
+	{ErrStreamStalled, "XR-019"},
+	{errFileIncomplete, "XR-020"},
+}
+
+// ErrorCodeOf returns the code of the error of this package err wraps, empty if none.
+func ErrorCodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+	for _, entry := range errorCodes {
+		if errors.Is(err, entry.err) {
+			return entry.code
+		}
+	}
+	return ""
+}
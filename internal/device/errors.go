@@ -0,0 +1,101 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+
+	hid "github.com/sstallion/go-hid"
+)
+
+// Errors from background goroutines passed to ErrorHandler wrap one of these, test with errors.Is.
+var (
+	// ErrReadFailed is reported when reading from the HID device fails for other reasons than a timeout,
+	// only once until a read succeeds again
+	ErrReadFailed = errors.New("failed to read from device")
+	// ErrDeserializeFailed is reported when a packet received from the glass cannot be parsed
+	ErrDeserializeFailed = errors.New("failed to deserialize packet")
+	// ErrHeartBeatLost is reported when the glass stops responding to heart beats, only once until it responds again
+	ErrHeartBeatLost = errors.New("heart beat lost")
+	// ErrIMUStreamRestarted is reported when the OV580 was reopened after consecutive read failures, and the IMU
+	// stream re-enabled if it was enabled, IMU samples may have been lost meanwhile
+	ErrIMUStreamRestarted = errors.New("imu stream restarted")
+)
+
+// ErrUnsupportedByFirmware is returned by commands the firmware of the connected glass does not have.
+var ErrUnsupportedByFirmware = errors.New("not supported by firmware")
+
+// ErrUnsupportedCommand is returned when no command of the protocol is known for an instruction on the firmware of the
+// connected glass, instead of sending a packet without one. Errors wrapping it also wrap ErrUnsupportedByFirmware.
+var ErrUnsupportedCommand = errors.New("unsupported command")
+
+// ErrCamerasUnavailable is returned by camera methods when libusb could not be initialized, e.g. as it is missing at
+// runtime. The glass connects without cameras then, see Capabilities.Cameras.
+var ErrCamerasUnavailable = errors.New("cameras unavailable")
+
+// ErrChecksumMismatch is returned by ReadOV580File with OV580FileOptions.Verify when two reads of a file differ.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrLowDiskSpace is returned by CaptureImages when the storage has less space left than CapturePolicy.MinFreeBytes.
+var ErrLowDiskSpace = errors.New("low disk space")
+
+// Components of a glass tagged by ComponentError.
+const (
+	COMPONENT_MCU         = "mcu"
+	COMPONENT_OV580       = "ov580"
+	COMPONENT_CAMERAS     = "cameras"
+	COMPONENT_RGB_CAMERA  = "rgb camera"
+	COMPONENT_SLAM_CAMERA = "slam camera"
+)
+
+// ComponentError tags an error with the component of the glass it comes from. Connect and Disconnect join one per
+// failed component, test with errors.As or list them with FailedComponents.
+type ComponentError struct {
+	Component string
+	Err       error
+}
+
+func (e *ComponentError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Component, e.Err)
+}
+
+func (e *ComponentError) Unwrap() error {
+	return e.Err
+}
+
+// componentError tags err with the component, nil if err is nil so results can be passed to errors.Join.
+func componentError(component string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ComponentError{Component: component, Err: err}
+}
+
+// FailedComponents lists the components tagged in err, including nested ones, e.g. COMPONENT_CAMERAS and
+// COMPONENT_RGB_CAMERA if only the RGB camera failed to connect. Errors before a camera is found, e.g. libusb failing
+// to initialize, are only tagged COMPONENT_CAMERAS.
+func FailedComponents(err error) []string {
+	components := []string{}
+	var walk func(error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case nil:
+		case *ComponentError:
+			components = append(components, e.Component)
+			walk(e.Err)
+		case interface{ Unwrap() []error }:
+			for _, inner := range e.Unwrap() {
+				walk(inner)
+			}
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		}
+	}
+	walk(err)
+	return components
+}
+
+// isTimeout tells if err is a read timeout, which is expected when the device has nothing to report. Other errors of
+// hidapi only carry its message, so they are read failures whatever they say.
+func isTimeout(err error) bool {
+	return errors.Is(err, hid.ErrTimeout)
+}
@@ -0,0 +1,55 @@
+package device_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestFailedComponents(t *testing.T) {
+	errRGB := errors.New("rgb camera not found")
+	cameras := errors.Join(&device.ComponentError{Component: device.COMPONENT_RGB_CAMERA, Err: errRGB})
+	err := errors.Join(
+		&device.ComponentError{Component: device.COMPONENT_OV580, Err: fmt.Errorf("failed to open: %w", errors.New("busy"))},
+		&device.ComponentError{Component: device.COMPONENT_CAMERAS, Err: cameras},
+	)
+
+	want := []string{device.COMPONENT_OV580, device.COMPONENT_CAMERAS, device.COMPONENT_RGB_CAMERA}
+	if got := device.FailedComponents(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("FailedComponents() = %v, want %v", got, want)
+	}
+	if !errors.Is(err, errRGB) {
+		t.Errorf("errors.Is() did not find the nested camera error")
+	}
+
+	var componentErr *device.ComponentError
+	if !errors.As(err, &componentErr) || componentErr.Component != device.COMPONENT_OV580 {
+		t.Errorf("errors.As() = %v, want the OV580 error first", componentErr)
+	}
+
+	if got := device.FailedComponents(nil); len(got) != 0 {
+		t.Errorf("FailedComponents(nil) = %v, want none", got)
+	}
+}
+
+func TestErrorCodeOf(t *testing.T) {
+	testCases := []struct {
+		err  error
+		code device.ErrorCode
+	}{
+		{fmt.Errorf("failed to read: %w", device.ErrReadFailed), "XR-001"},
+		// the more specific code of errors wrapping others
+		{fmt.Errorf("%w: %w", device.ErrUnsupportedCommand, device.ErrUnsupportedByFirmware), "XR-005"},
+		{device.ErrUnsupportedByFirmware, "XR-006"},
+		{errors.New("something else"), ""},
+		{nil, ""},
+	}
+	for _, tc := range testCases {
+		if code := device.ErrorCodeOf(tc.err); code != tc.code {
+			t.Errorf("ErrorCodeOf(%v) = %q, want %q", tc.err, code, tc.code)
+		}
+	}
+}
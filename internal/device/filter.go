@@ -0,0 +1,230 @@
+package device
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// EventFilterConfig configures the filters that wrap event handlers so consumers receive stable updates, see
+// FilterEvents. Zero values disable the corresponding filter.
+type EventFilterConfig struct {
+	// ProximityDebounce is how long a proximity state must hold before it is reported
+	ProximityDebounce time.Duration
+	// AmbientLightWindow is the number of readings in the ambient light moving average
+	AmbientLightWindow int
+	// AmbientLightMinDelta is the min change of the averaged ambient light to report
+	AmbientLightMinDelta uint16
+	// MagnetometerWindow is the number of readings in the magnetometer moving average
+	MagnetometerWindow int
+	// MagnetometerMinDelta is the min change in raw units on any axis of the averaged magnetometer reading to report
+	MagnetometerMinDelta float64
+}
+
+func (c EventFilterConfig) enabled() bool {
+	return c.ProximityDebounce > 0 || c.AmbientLightWindow > 1 || c.AmbientLightMinDelta != 0 ||
+		c.MagnetometerWindow > 1 || c.MagnetometerMinDelta != 0
+}
+
+// FilterEvents returns d delivering filtered proximity, ambient light and magnetometer events to every handler set
+// through it, e.g. by hooks, the D-Bus service or applications, or d itself if no filter is configured. Until a handler
+// is set the filtered events are logged.
+func FilterEvents(d Device, filters EventFilterConfig) Device {
+	if !filters.enabled() {
+		return d
+	}
+	filtered := &filteredDevice{Device: d, filters: filters}
+	filtered.SetProximityEventHandler(func(proximity ProximityEvent) {
+		slog.Info(fmt.Sprintf("Proximity: %s", proximity.String()))
+	})
+	filtered.SetAmbientLightEventHandler(func(value uint16) {
+		slog.Info(fmt.Sprintf("Ambient light: %d", value))
+	})
+	filtered.SetMagnetometerEventHandler(func(vector *MagnetometerVector) {
+		slog.Info(fmt.Sprintf("Magnetometer: %s", vector.String()))
+	})
+	return filtered
+}
+
+// filteredDevice overrides the methods of the wrapped Device setting the handlers of filtered events.
+type filteredDevice struct {
+	Device
+	filters EventFilterConfig
+}
+
+func (d *filteredDevice) SetProximityEventHandler(handler ProximityEventHandler) {
+	if handler == nil {
+		d.Device.SetProximityEventHandler(nil)
+		return
+	}
+	d.Device.SetProximityEventHandler(d.filters.FilterProximity(handler))
+}
+
+func (d *filteredDevice) SetAmbientLightEventHandler(handler AmbientLightEventHandler) {
+	if handler == nil {
+		d.Device.SetAmbientLightEventHandler(nil)
+		return
+	}
+	d.Device.SetAmbientLightEventHandler(d.filters.FilterAmbientLight(handler))
+}
+
+func (d *filteredDevice) SetMagnetometerEventHandler(handler MagnetometerEventHandler) {
+	if handler == nil {
+		d.Device.SetMagnetometerEventHandler(nil)
+		return
+	}
+	d.Device.SetMagnetometerEventHandler(d.filters.FilterMagnetometer(handler))
+}
+
+// FilterProximity wraps the handler with a debounce if configured.
+func (c EventFilterConfig) FilterProximity(handler ProximityEventHandler) ProximityEventHandler {
+	if c.ProximityDebounce <= 0 {
+		return handler
+	}
+	return DebounceProximity(c.ProximityDebounce, handler)
+}
+
+// FilterAmbientLight wraps the handler with a moving average and threshold if configured.
+func (c EventFilterConfig) FilterAmbientLight(handler AmbientLightEventHandler) AmbientLightEventHandler {
+	if c.AmbientLightWindow <= 1 && c.AmbientLightMinDelta == 0 {
+		return handler
+	}
+	return SmoothAmbientLight(c.AmbientLightWindow, c.AmbientLightMinDelta, handler)
+}
+
+// FilterMagnetometer wraps the handler with a moving average and threshold if configured.
+func (c EventFilterConfig) FilterMagnetometer(handler MagnetometerEventHandler) MagnetometerEventHandler {
+	if c.MagnetometerWindow <= 1 && c.MagnetometerMinDelta == 0 {
+		return handler
+	}
+	return SmoothMagnetometer(c.MagnetometerWindow, c.MagnetometerMinDelta, handler)
+}
+
+// DebounceProximity reports a proximity state only after it held for the window without flapping,
+// and only if it differs from the last reported state.
+func DebounceProximity(window time.Duration, handler ProximityEventHandler) ProximityEventHandler {
+	return debounceProximity(window, handler, systemAfterFunc)
+}
+
+// afterFunc calls f after d like time.AfterFunc, and returns a function cancelling the call.
+type afterFunc func(d time.Duration, f func()) (stop func() bool)
+
+func systemAfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+// debounceProximity is DebounceProximity waiting with after, replaced in tests.
+func debounceProximity(window time.Duration, handler ProximityEventHandler, after afterFunc) ProximityEventHandler {
+	var mutex sync.Mutex
+	var stop func() bool
+	reported := PROXIMITY_UKNOWN
+	reportedOnce := false
+
+	return func(event ProximityEvent) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if stop != nil {
+			stop()
+		}
+		stop = after(window, func() {
+			mutex.Lock()
+			if reportedOnce && reported == event {
+				mutex.Unlock()
+				return
+			}
+			reported = event
+			reportedOnce = true
+			mutex.Unlock()
+
+			handler(event)
+		})
+	}
+}
+
+// movingAverage keeps the mean of the last size values.
+type movingAverage struct {
+	values []float64
+	size   int
+	next   int
+	sum    float64
+}
+
+func newMovingAverage(size int) *movingAverage {
+	size = max(size, 1)
+	return &movingAverage{values: make([]float64, 0, size), size: size}
+}
+
+func (m *movingAverage) add(value float64) float64 {
+	if len(m.values) < m.size {
+		m.values = append(m.values, value)
+	} else {
+		m.sum -= m.values[m.next]
+		m.values[m.next] = value
+	}
+	m.sum += value
+	m.next = (m.next + 1) % m.size
+	return m.sum / float64(len(m.values))
+}
+
+// SmoothAmbientLight reports the moving average of the ambient light readings once it moved at least minDelta
+// from the last reported value.
+func SmoothAmbientLight(window int, minDelta uint16, handler AmbientLightEventHandler) AmbientLightEventHandler {
+	var mutex sync.Mutex
+	average := newMovingAverage(window)
+	var reported uint16
+	reportedOnce := false
+
+	return func(light uint16) {
+		mutex.Lock()
+		value := uint16(average.add(float64(light)) + 0.5)
+		if reportedOnce && absDiff(float64(value), float64(reported)) < float64(minDelta) {
+			mutex.Unlock()
+			return
+		}
+		reported = value
+		reportedOnce = true
+		mutex.Unlock()
+
+		handler(value)
+	}
+}
+
+// SmoothMagnetometer reports the moving average of the magnetometer readings once any axis moved at least
+// minDelta raw units from the last reported vector. Invalid readings are dropped.
+func SmoothMagnetometer(window int, minDelta float64, handler MagnetometerEventHandler) MagnetometerEventHandler {
+	var mutex sync.Mutex
+	averageX, averageY, averageZ := newMovingAverage(window), newMovingAverage(window), newMovingAverage(window)
+	var reported *MagnetometerVector
+
+	return func(vector *MagnetometerVector) {
+		if !vector.Valid {
+			return
+		}
+
+		mutex.Lock()
+		smoothed := *vector
+		smoothed.X = averageX.add(vector.X)
+		smoothed.Y = averageY.add(vector.Y)
+		smoothed.Z = averageZ.add(vector.Z)
+		if reported != nil &&
+			absDiff(smoothed.X, reported.X) < minDelta &&
+			absDiff(smoothed.Y, reported.Y) < minDelta &&
+			absDiff(smoothed.Z, reported.Z) < minDelta {
+			mutex.Unlock()
+			return
+		}
+		reported = &smoothed
+		mutex.Unlock()
+
+		handler(&smoothed)
+	}
+}
+
+func absDiff(a, b float64) float64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
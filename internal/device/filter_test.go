@@ -0,0 +1,63 @@
+package device_test
+
+import (
+	"reflect"
+	"testing"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestSmoothAmbientLight(t *testing.T) {
+	var reported []uint16
+	handler := device.SmoothAmbientLight(2, 10, func(light uint16) {
+		reported = append(reported, light)
+	})
+
+	for _, light := range []uint16{100, 104, 96, 130, 131, 60} {
+		handler(light)
+	}
+
+	// averages are 100, 102, 100, 113, 131, 96
+	expected := []uint16{100, 113, 131, 96}
+	if !reflect.DeepEqual(reported, expected) {
+		t.Errorf("SmoothAmbientLight reported %v; expected %v", reported, expected)
+	}
+}
+
+// ambientLightDevice keeps the ambient light handler set, to feed readings to it.
+type ambientLightDevice struct {
+	device.UnimplementedDevice
+	handler device.AmbientLightEventHandler
+}
+
+func (d *ambientLightDevice) Name() string      { return "ambient light device" }
+func (d *ambientLightDevice) PID() uint16       { return 0 }
+func (d *ambientLightDevice) VID() uint16       { return 0 }
+func (d *ambientLightDevice) Connect() error    { return nil }
+func (d *ambientLightDevice) Disconnect() error { return nil }
+
+func (d *ambientLightDevice) SetAmbientLightEventHandler(handler device.AmbientLightEventHandler) {
+	d.handler = handler
+}
+
+func TestFilterEvents(t *testing.T) {
+	unfiltered := &ambientLightDevice{}
+	if d := device.FilterEvents(unfiltered, device.EventFilterConfig{}); d != device.Device(unfiltered) {
+		t.Errorf("FilterEvents() without filters = %v, want the device itself", d)
+	}
+
+	d := device.FilterEvents(unfiltered, device.EventFilterConfig{AmbientLightWindow: 2, AmbientLightMinDelta: 10})
+	// every handler set through the filtered device gets filtered readings
+	for range 2 {
+		var reported []uint16
+		d.SetAmbientLightEventHandler(func(light uint16) {
+			reported = append(reported, light)
+		})
+		for _, light := range []uint16{100, 104, 96, 130, 131, 60} {
+			unfiltered.handler(light)
+		}
+		if expected := []uint16{100, 113, 131, 96}; !reflect.DeepEqual(reported, expected) {
+			t.Errorf("handler got %v; expected %v", reported, expected)
+		}
+	}
+}
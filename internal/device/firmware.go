@@ -0,0 +1,128 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"xreal-light-xr-go/constant"
+)
+
+// ErrUntestedFirmware is reported through the ErrorHandler when the connected glass runs a firmware which is not
+// in GetKnownFirmware. The glass still connects, but firmware dependent commands are likely unavailable.
+var ErrUntestedFirmware = errors.New("firmware is untested by this driver")
+
+// FirmwareVersion is a firmware string as reported by the glass, e.g. "05.5.08.059_20230518".
+type FirmwareVersion struct {
+	// Version is the part before the build date, e.g. "05.5.08.059"
+	Version string
+	// BuildDate is zero if the string carries none
+	BuildDate time.Time
+	// Raw is the string as reported
+	Raw string
+}
+
+// ParseFirmwareVersion splits a firmware string into the version and the build date.
+func ParseFirmwareVersion(raw string) (*FirmwareVersion, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("empty firmware version")
+	}
+
+	firmware := &FirmwareVersion{Version: raw, Raw: raw}
+	if version, date, found := strings.Cut(raw, "_"); found {
+		buildDate, err := time.Parse("20060102", date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid build date in firmware version %s: %w", raw, err)
+		}
+		firmware.Version = version
+		firmware.BuildDate = buildDate
+	}
+	return firmware, nil
+}
+
+func (f FirmwareVersion) String() string {
+	if f.BuildDate.IsZero() {
+		return f.Version
+	}
+	return fmt.Sprintf("%s (built %s)", f.Version, f.BuildDate.Format(time.DateOnly))
+}
+
+// DisplayVersion is a version string reported by the display of the glass, e.g. "ELLA2_0518_V017" for its firmware
+// or "ELLA2_1224_HDCP" for HDCP.
+type DisplayVersion struct {
+	// Panel is the part before the first underscore, e.g. "ELLA2"
+	Panel string
+	// Build is the part in between, e.g. "0518", empty if there is none
+	Build string
+	// Version is the part after the last underscore, e.g. "V017" or "HDCP", empty if there is none
+	Version string
+	// Raw is the string as reported
+	Raw string
+}
+
+// ParseDisplayVersion splits a display version string at underscores, strings of other formats are kept in Panel.
+func ParseDisplayVersion(raw string) (*DisplayVersion, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("empty display version")
+	}
+
+	version := &DisplayVersion{Panel: raw, Raw: raw}
+	parts := strings.Split(raw, "_")
+	switch len(parts) {
+	case 2:
+		version.Panel, version.Version = parts[0], parts[1]
+	case 3:
+		version.Panel, version.Build, version.Version = parts[0], parts[1], parts[2]
+	}
+	return version, nil
+}
+
+func (v DisplayVersion) String() string {
+	return v.Raw
+}
+
+// KnownFirmware is a firmware this driver is tested against.
+type KnownFirmware struct {
+	Version string
+	Notes   string
+}
+
+var knownFirmware = []KnownFirmware{
+	{Version: constant.FIRMWARE_05_1_08_021, Notes: "no display firmware command"},
+	{Version: constant.FIRMWARE_05_5_08_059, Notes: "all firmware dependent commands"},
+}
+
+// GetKnownFirmware returns the firmware this driver is tested against, oldest first.
+func GetKnownFirmware() []KnownFirmware {
+	return append([]KnownFirmware(nil), knownFirmware...)
+}
+
+// IsKnownFirmware tells if the firmware string reported by the glass is in GetKnownFirmware.
+func IsKnownFirmware(firmware string) bool {
+	for _, known := range knownFirmware {
+		if known.Version == firmware {
+			return true
+		}
+	}
+	return false
+}
+
+// checkKnownFirmware returns an error wrapping ErrUntestedFirmware if the firmware is not known.
+func checkKnownFirmware(firmware string) error {
+	if IsKnownFirmware(firmware) {
+		return nil
+	}
+
+	parsed, err := ParseFirmwareVersion(firmware)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUntestedFirmware, firmware)
+	}
+	latest, _ := ParseFirmwareVersion(knownFirmware[len(knownFirmware)-1].Version)
+	if parsed.BuildDate.After(latest.BuildDate) {
+		return fmt.Errorf("%w: %s is newer than the latest known %s", ErrUntestedFirmware, parsed, latest)
+	}
+	return fmt.Errorf("%w: %s", ErrUntestedFirmware, parsed)
+}
@@ -0,0 +1,61 @@
+package device_test
+
+import (
+	"testing"
+	"time"
+
+	"xreal-light-xr-go/constant"
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestParseFirmwareVersion(t *testing.T) {
+	firmware, err := device.ParseFirmwareVersion(constant.FIRMWARE_05_5_08_059)
+	if err != nil {
+		t.Fatalf("ParseFirmwareVersion() failed: %v", err)
+	}
+	if firmware.Version != "05.5.08.059" {
+		t.Errorf("Version = %s, want 05.5.08.059", firmware.Version)
+	}
+	if want := time.Date(2023, 5, 18, 0, 0, 0, 0, time.UTC); !firmware.BuildDate.Equal(want) {
+		t.Errorf("BuildDate = %v, want %v", firmware.BuildDate, want)
+	}
+
+	if firmware, err := device.ParseFirmwareVersion("05.6.00.001"); err != nil || !firmware.BuildDate.IsZero() {
+		t.Errorf("ParseFirmwareVersion() without build date = %v, %v; want zero build date", firmware, err)
+	}
+
+	for _, raw := range []string{"", "05.5.08.059_2023"} {
+		if _, err := device.ParseFirmwareVersion(raw); err == nil {
+			t.Errorf("ParseFirmwareVersion(%q) succeeded, want error", raw)
+		}
+	}
+}
+
+func TestParseDisplayVersion(t *testing.T) {
+	version, err := device.ParseDisplayVersion("ELLA2_0518_V017")
+	if err != nil {
+		t.Fatalf("ParseDisplayVersion() failed: %v", err)
+	}
+	want := device.DisplayVersion{Panel: "ELLA2", Build: "0518", Version: "V017", Raw: "ELLA2_0518_V017"}
+	if *version != want {
+		t.Errorf("ParseDisplayVersion() = %+v, want %+v", *version, want)
+	}
+
+	if version, err := device.ParseDisplayVersion("ELLA2"); err != nil || version.Panel != "ELLA2" {
+		t.Errorf("ParseDisplayVersion() without underscores = %v, %v; want it kept as panel", version, err)
+	}
+	if _, err := device.ParseDisplayVersion(" "); err == nil {
+		t.Errorf("ParseDisplayVersion() of empty string succeeded, want error")
+	}
+}
+
+func TestKnownFirmware(t *testing.T) {
+	for _, known := range device.GetKnownFirmware() {
+		if !device.IsKnownFirmware(known.Version) {
+			t.Errorf("IsKnownFirmware(%s) = false, want true", known.Version)
+		}
+	}
+	if device.IsKnownFirmware("05.6.00.001_20240101") {
+		t.Errorf("IsKnownFirmware() of an untested firmware = true, want false")
+	}
+}
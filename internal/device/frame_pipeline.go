@@ -0,0 +1,197 @@
+package device
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FrameProcessor processes one camera image, modifying it in place or returning a new one, e.g. of another size.
+type FrameProcessor func(img *image.Gray) (*image.Gray, error)
+
+// FramePipeline applies its processors in order to both images of every SLAM frame before it is returned by
+// GetSLAMFrameRaw, and so before CaptureImages stores it, see Device.SetFramePipeline.
+type FramePipeline []FrameProcessor
+
+// Process returns a new frame with the processed images, frame is left untouched.
+func (p FramePipeline) Process(frame *SLAMFrame) (*SLAMFrame, error) {
+	left, right := frame.Images()
+	processed := &SLAMFrame{Timestamp: frame.Timestamp, Statistics: frame.Statistics}
+	for i, img := range []image.Image{left, right} {
+		if img == nil {
+			continue
+		}
+		// a copy of the frame data already
+		gray := img.(*image.Gray)
+		for _, processor := range p {
+			var err error
+			if gray, err = processor(gray); err != nil {
+				return nil, fmt.Errorf("failed to process frame: %w", err)
+			}
+		}
+		// the images are stored without stride or offset
+		bounds := gray.Bounds()
+		pix := make([]byte, 0, bounds.Dx()*bounds.Dy())
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			offset := gray.PixOffset(bounds.Min.X, y)
+			pix = append(pix, gray.Pix[offset:offset+bounds.Dx()]...)
+		}
+		if i == 0 {
+			processed.Left = pix
+		} else {
+			processed.Right = pix
+		}
+		processed.Width, processed.Height = bounds.Dx(), bounds.Dy()
+	}
+	return processed, nil
+}
+
+// ParseFramePipeline parses comma separated processors, e.g. "gamma=2.2,flip=h,rotate=90,crop=0:0:320:240,downscale=2".
+func ParseFramePipeline(input string) (FramePipeline, error) {
+	pipeline := FramePipeline{}
+	for _, step := range strings.Split(input, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(step), "=")
+		var processor FrameProcessor
+		var err error
+		switch name {
+		case "":
+			continue
+		case "gamma":
+			var gamma float64
+			if gamma, err = strconv.ParseFloat(value, 64); err == nil {
+				processor, err = GammaCorrection(gamma)
+			}
+		case "flip":
+			processor, err = Flip(value)
+		case "rotate":
+			var degrees int
+			if degrees, err = strconv.Atoi(value); err == nil {
+				processor, err = Rotate(degrees)
+			}
+		case "crop":
+			var x, y, width, height int
+			if _, err = fmt.Sscanf(value, "%d:%d:%d:%d", &x, &y, &width, &height); err == nil {
+				processor = Crop(image.Rect(x, y, x+width, y+height))
+			}
+		case "downscale":
+			var factor int
+			if factor, err = strconv.Atoi(value); err == nil {
+				processor, err = Downscale(factor)
+			}
+		default:
+			return nil, fmt.Errorf("unknown frame processor: got (%s) want one of (gamma flip rotate crop downscale)", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid frame processor %s: %w", step, err)
+		}
+		pipeline = append(pipeline, processor)
+	}
+	return pipeline, nil
+}
+
+// GammaCorrection brightens dark images for gamma above 1, e.g. 2.2, and darkens them below.
+func GammaCorrection(gamma float64) (FrameProcessor, error) {
+	if gamma <= 0 {
+		return nil, fmt.Errorf("gamma must be positive, got %g", gamma)
+	}
+	var table [256]byte
+	for i := range table {
+		table[i] = byte(math.Round(255 * math.Pow(float64(i)/255, 1/gamma)))
+	}
+	return func(img *image.Gray) (*image.Gray, error) {
+		for i, value := range img.Pix {
+			img.Pix[i] = table[value]
+		}
+		return img, nil
+	}, nil
+}
+
+// Flip mirrors images horizontally ("h") or vertically ("v"), e.g. for a glass mounted upside down.
+func Flip(axis string) (FrameProcessor, error) {
+	if axis != "h" && axis != "v" {
+		return nil, fmt.Errorf("flip axis must be h or v, got %s", axis)
+	}
+	return func(img *image.Gray) (*image.Gray, error) {
+		bounds := img.Bounds()
+		flipped := image.NewGray(bounds)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				toX, toY := x, y
+				if axis == "h" {
+					toX = bounds.Max.X - 1 - (x - bounds.Min.X)
+				} else {
+					toY = bounds.Max.Y - 1 - (y - bounds.Min.Y)
+				}
+				flipped.SetGray(toX, toY, img.GrayAt(x, y))
+			}
+		}
+		return flipped, nil
+	}, nil
+}
+
+// Rotate rotates images clockwise by 90, 180 or 270 degrees, e.g. for a glass mounted sideways.
+func Rotate(degrees int) (FrameProcessor, error) {
+	if degrees != 90 && degrees != 180 && degrees != 270 {
+		return nil, fmt.Errorf("rotation must be 90, 180 or 270 degrees, got %d", degrees)
+	}
+	return func(img *image.Gray) (*image.Gray, error) {
+		bounds := img.Bounds()
+		width, height := bounds.Dx(), bounds.Dy()
+		rotated := image.NewGray(image.Rect(0, 0, width, height))
+		if degrees != 180 {
+			rotated = image.NewGray(image.Rect(0, 0, height, width))
+		}
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				toX, toY := width-1-x, height-1-y
+				switch degrees {
+				case 90:
+					toX, toY = height-1-y, x
+				case 270:
+					toX, toY = y, width-1-x
+				}
+				rotated.SetGray(toX, toY, img.GrayAt(bounds.Min.X+x, bounds.Min.Y+y))
+			}
+		}
+		return rotated, nil
+	}, nil
+}
+
+// Crop keeps the part of images within rect, failing if they do not overlap.
+func Crop(rect image.Rectangle) FrameProcessor {
+	return func(img *image.Gray) (*image.Gray, error) {
+		cropped := rect.Intersect(img.Bounds())
+		if cropped.Empty() {
+			return nil, fmt.Errorf("crop %v is outside of image %v", rect, img.Bounds())
+		}
+		return img.SubImage(cropped).(*image.Gray), nil
+	}
+}
+
+// Downscale shrinks images by an integer factor, averaging each factor x factor block, e.g. to save bandwidth.
+func Downscale(factor int) (FrameProcessor, error) {
+	if factor < 1 {
+		return nil, fmt.Errorf("downscale factor must be at least 1, got %d", factor)
+	}
+	return func(img *image.Gray) (*image.Gray, error) {
+		bounds := img.Bounds()
+		scaled := image.NewGray(image.Rect(0, 0, bounds.Dx()/factor, bounds.Dy()/factor))
+		if scaled.Bounds().Empty() {
+			return nil, fmt.Errorf("image %v is smaller than the downscale factor %d", bounds, factor)
+		}
+		for y := 0; y < scaled.Bounds().Dy(); y++ {
+			for x := 0; x < scaled.Bounds().Dx(); x++ {
+				sum := 0
+				for dy := 0; dy < factor; dy++ {
+					for dx := 0; dx < factor; dx++ {
+						sum += int(img.GrayAt(bounds.Min.X+x*factor+dx, bounds.Min.Y+y*factor+dy).Y)
+					}
+				}
+				scaled.Pix[scaled.PixOffset(x, y)] = byte(sum / (factor * factor))
+			}
+		}
+		return scaled, nil
+	}, nil
+}
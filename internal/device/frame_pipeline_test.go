@@ -0,0 +1,69 @@
+package device
+
+import (
+	"testing"
+)
+
+func TestFramePipeline(t *testing.T) {
+	// 4x2 images, the right one is the left one inverted
+	frame := &SLAMFrame{
+		Left:   []byte{0, 1, 2, 3, 4, 5, 6, 7},
+		Right:  []byte{255, 254, 253, 252, 251, 250, 249, 248},
+		Width:  4,
+		Height: 2,
+	}
+
+	testCases := []struct {
+		pipeline      string
+		width, height int
+		left          []byte
+	}{
+		{pipeline: "", width: 4, height: 2, left: []byte{0, 1, 2, 3, 4, 5, 6, 7}},
+		{pipeline: "flip=h", width: 4, height: 2, left: []byte{3, 2, 1, 0, 7, 6, 5, 4}},
+		{pipeline: "flip=v", width: 4, height: 2, left: []byte{4, 5, 6, 7, 0, 1, 2, 3}},
+		{pipeline: "rotate=90", width: 2, height: 4, left: []byte{4, 0, 5, 1, 6, 2, 7, 3}},
+		{pipeline: "rotate=180", width: 4, height: 2, left: []byte{7, 6, 5, 4, 3, 2, 1, 0}},
+		{pipeline: "rotate=270", width: 2, height: 4, left: []byte{3, 7, 2, 6, 1, 5, 0, 4}},
+		{pipeline: "crop=1:0:2:2", width: 2, height: 2, left: []byte{1, 2, 5, 6}},
+		{pipeline: "downscale=2", width: 2, height: 1, left: []byte{2, 4}},
+		{pipeline: "crop=1:0:2:2,flip=h", width: 2, height: 2, left: []byte{2, 1, 6, 5}},
+		{pipeline: "gamma=1", width: 4, height: 2, left: []byte{0, 1, 2, 3, 4, 5, 6, 7}},
+	}
+	for _, tc := range testCases {
+		pipeline, err := ParseFramePipeline(tc.pipeline)
+		if err != nil {
+			t.Fatalf("ParseFramePipeline(%s) failed: %v", tc.pipeline, err)
+		}
+		processed, err := pipeline.Process(frame)
+		if err != nil {
+			t.Fatalf("Process(%s) failed: %v", tc.pipeline, err)
+		}
+		if processed.Width != tc.width || processed.Height != tc.height || string(processed.Left) != string(tc.left) {
+			t.Errorf("Process(%s) = %dx%d %v, want %dx%d %v", tc.pipeline, processed.Width, processed.Height, processed.Left, tc.width, tc.height, tc.left)
+		}
+		if len(processed.Right) != tc.width*tc.height {
+			t.Errorf("Process(%s) right = %v, want it processed too", tc.pipeline, processed.Right)
+		}
+	}
+	if frame.Left[0] != 0 {
+		t.Errorf("Process() modified the frame, want it untouched")
+	}
+
+	if gamma, _ := ParseFramePipeline("gamma=2.2"); gamma != nil {
+		processed, _ := gamma.Process(frame)
+		if processed.Left[1] <= frame.Left[1] || processed.Left[0] != 0 || processed.Right[0] != 255 {
+			t.Errorf("gamma=2.2 = %v, want dark pixels brightened and the extremes kept", processed.Left)
+		}
+	}
+
+	for _, invalid := range []string{"sharpen=1", "rotate=45", "flip=x", "gamma=0", "downscale=0", "crop=1:2"} {
+		if _, err := ParseFramePipeline(invalid); err == nil {
+			t.Errorf("ParseFramePipeline(%s) succeeded, want error", invalid)
+		}
+	}
+	if pipeline, _ := ParseFramePipeline("crop=10:10:2:2"); pipeline != nil {
+		if _, err := pipeline.Process(frame); err == nil {
+			t.Errorf("Process() with a crop outside the image succeeded, want error")
+		}
+	}
+}
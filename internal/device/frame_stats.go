@@ -0,0 +1,55 @@
+package device
+
+import (
+	"fmt"
+)
+
+// ImageStatistics summarize the exposure of a grayscale camera image, e.g. to drive auto exposure on the host or to
+// reject badly exposed frames when capturing a dataset.
+type ImageStatistics struct {
+	// MeanIntensity is the average pixel value, 0-255
+	MeanIntensity float64
+	// Histogram counts the pixels of each value
+	Histogram [256]uint32
+	// SaturatedPercent is the share of pixels at 255, UnderexposedPercent of those at 0
+	SaturatedPercent    float64
+	UnderexposedPercent float64
+}
+
+func (s ImageStatistics) String() string {
+	return fmt.Sprintf("mean %.1f, saturated %.2f%%, underexposed %.2f%%", s.MeanIntensity, s.SaturatedPercent, s.UnderexposedPercent)
+}
+
+// FrameStatistics summarize both images of a SLAM frame.
+type FrameStatistics struct {
+	Left  ImageStatistics
+	Right ImageStatistics
+}
+
+func (s FrameStatistics) String() string {
+	return fmt.Sprintf("left: %s - right: %s", s.Left.String(), s.Right.String())
+}
+
+// NewImageStatistics computes the statistics of grayscale pixels, all zero if there are none.
+func NewImageStatistics(pixels []byte) ImageStatistics {
+	var stats ImageStatistics
+	if len(pixels) == 0 {
+		return stats
+	}
+
+	sum := 0
+	for _, value := range pixels {
+		stats.Histogram[value]++
+		sum += int(value)
+	}
+	total := float64(len(pixels))
+	stats.MeanIntensity = float64(sum) / total
+	stats.SaturatedPercent = 100 * float64(stats.Histogram[255]) / total
+	stats.UnderexposedPercent = 100 * float64(stats.Histogram[0]) / total
+	return stats
+}
+
+// NewFrameStatistics computes the statistics of both images of the frame.
+func NewFrameStatistics(frame *SLAMFrame) *FrameStatistics {
+	return &FrameStatistics{Left: NewImageStatistics(frame.Left), Right: NewImageStatistics(frame.Right)}
+}
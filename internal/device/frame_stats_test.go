@@ -0,0 +1,23 @@
+package device
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewFrameStatistics(t *testing.T) {
+	stats := NewFrameStatistics(&SLAMFrame{Left: []byte{0, 255, 255, 10}})
+
+	if math.Abs(stats.Left.MeanIntensity-130) > 1e-9 {
+		t.Errorf("MeanIntensity = %v, want 130", stats.Left.MeanIntensity)
+	}
+	if stats.Left.Histogram[255] != 2 || stats.Left.Histogram[10] != 1 || stats.Left.Histogram[0] != 1 {
+		t.Errorf("Histogram = %v, want the pixel counts", stats.Left.Histogram)
+	}
+	if stats.Left.SaturatedPercent != 50 || stats.Left.UnderexposedPercent != 25 {
+		t.Errorf("SaturatedPercent, UnderexposedPercent = %v, %v, want 50, 25", stats.Left.SaturatedPercent, stats.Left.UnderexposedPercent)
+	}
+	if stats.Right != (ImageStatistics{}) {
+		t.Errorf("Right = %s without pixels, want zero", stats.Right)
+	}
+}
@@ -0,0 +1,214 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"xreal-light-xr-go/constant"
+)
+
+// GlassInfo describes an attached glass and the USB functions that belong to it.
+type GlassInfo struct {
+	// Model is the model name, e.g. constant.XREAL_LIGHT
+	Model string
+	// SerialNumber is taken from the USB descriptor, or queried from the glass if connected
+	SerialNumber string
+	// FirmwareVersion is only obtainable when the glass is connected by this process
+	FirmwareVersion string
+	// Connected tells if the glass is currently connected by this process
+	Connected bool
+
+	// MCUPath is the hid path of the MCU
+	MCUPath string
+	// MCUInterfaces are all hid interfaces of the MCU, of which MCUPath is the one selected (XREAL Light only), see
+	// SetLightMCUInterface
+	MCUInterfaces []HIDInterface
+	// OV580Path is the hid path of the OV580 (XREAL Light only)
+	OV580Path string
+	// CameraPaths are the libusb locations of the cameras (XREAL Light only)
+	CameraPaths []string
+	// VID and PID are of the MCU, they select the factory of a model registered by RegisterModel
+	VID uint16
+	PID uint16
+}
+
+func (info GlassInfo) String() string {
+	state := "disconnected"
+	if info.Connected {
+		state = "connected"
+	}
+	description := fmt.Sprintf(
+		"%s (%s) - serialNumber: %s - firmware: %s - mcu: %s - ov580: %s - cameras: %v",
+		info.Model, state, info.SerialNumber, info.FirmwareVersion, info.MCUPath, info.OV580Path, info.CameraPaths,
+	)
+	if len(info.MCUInterfaces) > 1 {
+		description += fmt.Sprintf(" - mcu interfaces: %v", info.MCUInterfaces)
+	}
+	return description
+}
+
+var (
+	// connectedGlasses tracks glasses connected by this process, keyed by MCU hid path
+	connectedGlasses      = map[string]Device{}
+	connectedGlassesMutex sync.Mutex
+)
+
+func markGlassConnected(mcuPath string, d Device) {
+	connectedGlassesMutex.Lock()
+	defer connectedGlassesMutex.Unlock()
+	connectedGlasses[mcuPath] = d
+}
+
+func markGlassDisconnected(mcuPath string) {
+	connectedGlassesMutex.Lock()
+	defer connectedGlassesMutex.Unlock()
+	delete(connectedGlasses, mcuPath)
+}
+
+func getConnectedGlass(mcuPath string) Device {
+	connectedGlassesMutex.Lock()
+	defer connectedGlassesMutex.Unlock()
+	return connectedGlasses[mcuPath]
+}
+
+// DisconnectAll disconnects every glass connected by this process, e.g. before exiting on a signal, so the camera
+// interfaces are released and their kernel drivers re-attached.
+func DisconnectAll() error {
+	connectedGlassesMutex.Lock()
+	glasses := make([]Device, 0, len(connectedGlasses))
+	for _, d := range connectedGlasses {
+		glasses = append(glasses, d)
+	}
+	connectedGlassesMutex.Unlock()
+
+	var errs []error
+	for _, d := range glasses {
+		if err := d.Disconnect(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to disconnect %s: %w", d.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ListGlasses enumerates attached XREAL glasses and identifies their models.
+func ListGlasses() ([]*GlassInfo, error) {
+	glasses, err := enumerateGlasses()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range glasses {
+		fillFromConnectedGlass(info)
+	}
+
+	return glasses, nil
+}
+
+// enumerateGlasses lists the attached glasses without querying the connected ones.
+func enumerateGlasses() ([]*GlassInfo, error) {
+	var glasses []*GlassInfo
+
+	light, err := listLightGlasses()
+	if err != nil {
+		return nil, err
+	}
+	glasses = append(glasses, light...)
+
+	air, err := listAirGlasses()
+	if err != nil {
+		return nil, err
+	}
+	glasses = append(glasses, air...)
+
+	registered, err := listRegisteredGlasses()
+	if err != nil {
+		return nil, err
+	}
+	return append(withoutRegistered(glasses), registered...), nil
+}
+
+func listLightGlasses() ([]*GlassInfo, error) {
+	mcus, err := EnumerateDevices(XREAL_LIGHT_MCU_VID, XREAL_LIGHT_MCU_PID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate MCU hid devices: %w", err)
+	}
+
+	ov580s, err := EnumerateDevices(XREAL_LIGHT_OV580_VID, XREAL_LIGHT_OV580_PID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate OV580 hid devices: %w", err)
+	}
+
+	cameraPaths, err := enumerateLightCameraPaths()
+	if err != nil {
+		// cameras are optional for listing purposes
+		slog.Debug(fmt.Sprintf("failed to enumerate cameras: %v", err))
+	}
+
+	// a glass may expose its MCU on several interfaces, it is listed once with the selected one
+	selected := selectHIDInterfaces(mcus, GetLightMCUInterface())
+	var glasses []*GlassInfo
+	for i, mcu := range selected {
+		info := &GlassInfo{
+			Model:         constant.XREAL_LIGHT,
+			SerialNumber:  mcu.SerialNbr,
+			MCUPath:       mcu.Path,
+			MCUInterfaces: interfacesOf(mcus, mcu),
+			VID:           mcu.VendorID,
+			PID:           mcu.ProductID,
+		}
+		// There is no reliable way to tell which OV580 belongs to which MCU, so we pair them by enumeration order.
+		if i < len(ov580s) {
+			info.OV580Path = ov580s[i].Path
+		}
+		if len(selected) == 1 {
+			info.CameraPaths = cameraPaths
+		}
+		glasses = append(glasses, info)
+	}
+	return glasses, nil
+}
+
+func listAirGlasses() ([]*GlassInfo, error) {
+	devices, err := EnumerateDevices(XREAL_AIR_SERIES_MCU_VID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate Air hid devices: %w", err)
+	}
+
+	// each Air exposes several hid interfaces, we only list the MCU one
+	var glasses []*GlassInfo
+	for _, device := range devices {
+		model := airModelFromPID(device.ProductID)
+		if model == AIR_MODEL_UNKNOWN || device.InterfaceNbr != XREAL_AIR_MCU_IF_NUM {
+			continue
+		}
+		glasses = append(glasses, &GlassInfo{
+			Model:        model.String(),
+			SerialNumber: device.SerialNbr,
+			MCUPath:      device.Path,
+			VID:          device.VendorID,
+			PID:          device.ProductID,
+		})
+	}
+	return glasses, nil
+}
+
+func fillFromConnectedGlass(info *GlassInfo) {
+	d := getConnectedGlass(info.MCUPath)
+	if d == nil {
+		return
+	}
+
+	info.Connected = true
+
+	if firmware, err := d.GetFirmwareVersion(); err == nil {
+		info.FirmwareVersion = firmware
+	}
+
+	if info.SerialNumber == "" {
+		if serial, err := d.GetSerial(); err == nil {
+			info.SerialNumber = serial
+		}
+	}
+}
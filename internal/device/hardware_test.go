@@ -0,0 +1,350 @@
+//go:build hardware
+
+// Hardware-in-the-loop tests against a real glass attached to the host, run with
+//
+//	go test -tags hardware ./internal/device/ -hardware.results results.md
+//
+// Every test result is appended as a row of a firmware version x test matrix to the results file if given,
+// so runs on different firmware versions can be collected into one table.
+package device_test
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+var hardwareResultsPath = flag.String("hardware.results", "", "markdown file to append the firmware x test results matrix to")
+
+const hardwareEventTimeout = 5 * time.Second
+
+var (
+	// glass is nil when none is attached or connecting failed, see requireGlass
+	glass           device.Device
+	glassAttached   bool
+	glassErr        error
+	firmwareVersion string
+
+	// mutex for thread safety
+	resultsMutex sync.Mutex
+	results      []hardwareResult
+)
+
+type hardwareResult struct {
+	test   string
+	result string
+}
+
+// TestMain connects the glass for the hardware tests. The other tests of the package run as usual without one, the
+// hardware tests skip then, see requireGlass.
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	glasses, err := device.ListGlasses()
+	switch {
+	case err != nil:
+		glassErr = fmt.Errorf("no glass attached: %w", err)
+	case len(glasses) == 0:
+		glassErr = fmt.Errorf("no glass attached")
+	default:
+		glassAttached = true
+		light := device.NewXREALLight(nil, nil)
+		if err := light.Connect(); err != nil {
+			glassErr = fmt.Errorf("failed to connect to glass: %w", err)
+			break
+		}
+		glass = light
+		if firmwareVersion, err = glass.GetFirmwareVersion(); err != nil {
+			firmwareVersion = "unknown"
+		}
+	}
+
+	code := m.Run()
+
+	if glass != nil {
+		glass.Disconnect()
+		if err := writeHardwareResults(*hardwareResultsPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	os.Exit(code)
+}
+
+// requireGlass skips the test when no glass is attached, and fails it when the attached glass could not be connected.
+func requireGlass(t *testing.T) {
+	t.Helper()
+	if glass != nil {
+		return
+	}
+	if !glassAttached {
+		t.Skipf("skipping hardware test: %v", glassErr)
+	}
+	t.Fatal(glassErr)
+}
+
+// recordResult adds the outcome of the test to the results matrix once it finishes.
+func recordResult(t *testing.T) {
+	t.Cleanup(func() {
+		result := "pass"
+		switch {
+		case t.Failed():
+			result = "FAIL"
+		case t.Skipped():
+			result = "skip"
+		}
+
+		resultsMutex.Lock()
+		defer resultsMutex.Unlock()
+		results = append(results, hardwareResult{test: t.Name(), result: result})
+	})
+}
+
+func writeHardwareResults(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	_, statErr := os.Stat(path)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open results file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if os.IsNotExist(statErr) {
+		fmt.Fprintln(f, "| Firmware | Test | Result | Date |")
+		fmt.Fprintln(f, "|---|---|---|---|")
+	}
+	date := time.Now().Format(time.DateOnly)
+	for _, r := range results {
+		if _, err := fmt.Fprintf(f, "| %s | %s | %s | %s |\n", firmwareVersion, r.test, r.result, date); err != nil {
+			return fmt.Errorf("failed to write results file %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func TestHardwareGetSerial(t *testing.T) {
+	recordResult(t)
+	requireGlass(t)
+
+	serial, err := glass.GetSerial()
+	if err != nil {
+		t.Fatalf("GetSerial() failed: %v", err)
+	}
+	if serial == "" {
+		t.Errorf("GetSerial() returned empty serial")
+	}
+}
+
+func TestHardwareBrightnessRoundTrip(t *testing.T) {
+	recordResult(t)
+	requireGlass(t)
+
+	original, err := glass.GetBrightnessLevel()
+	if err != nil {
+		t.Fatalf("GetBrightnessLevel() failed: %v", err)
+	}
+	defer glass.SetBrightnessLevel(original)
+
+	want := "1"
+	if original == want {
+		want = "2"
+	}
+	if err := glass.SetBrightnessLevel(want); err != nil {
+		t.Fatalf("SetBrightnessLevel(%s) failed: %v", want, err)
+	}
+	if got, err := glass.GetBrightnessLevel(); err != nil || got != want {
+		t.Errorf("GetBrightnessLevel() = %s, %v; expected %s", got, err, want)
+	}
+}
+
+func TestHardwareDisplayModeRoundTrip(t *testing.T) {
+	recordResult(t)
+	requireGlass(t)
+
+	original, err := glass.GetDisplayMode()
+	if err != nil {
+		t.Fatalf("GetDisplayMode() failed: %v", err)
+	}
+	defer glass.SetDisplayMode(original)
+
+	want := device.DISPLAY_MODE_STEREO
+	if original == want {
+		want = device.DISPLAY_MODE_SAME_ON_BOTH
+	}
+	if err := glass.SetDisplayMode(want); err != nil {
+		t.Fatalf("SetDisplayMode(%s) failed: %v", want, err)
+	}
+	if got, err := glass.GetDisplayMode(); err != nil || got != want {
+		t.Errorf("GetDisplayMode() = %s, %v; expected %s", got, err, want)
+	}
+}
+
+func TestHardwareAmbientLightEvent(t *testing.T) {
+	recordResult(t)
+	requireGlass(t)
+
+	received := make(chan uint16, 1)
+	glass.SetAmbientLightEventHandler(func(light uint16) {
+		select {
+		case received <- light:
+		default:
+		}
+	})
+	defer glass.SetAmbientLightEventHandler(func(uint16) {})
+
+	if err := glass.EnableAmbientLight(true); err != nil {
+		t.Fatalf("failed to enable ambient light event reporting: %v", err)
+	}
+	defer glass.EnableAmbientLight(false)
+
+	select {
+	case <-received:
+	case <-time.After(hardwareEventTimeout):
+		t.Errorf("no ambient light event received in %v", hardwareEventTimeout)
+	}
+}
+
+func TestHardwareIMUEvent(t *testing.T) {
+	recordResult(t)
+	requireGlass(t)
+
+	received := make(chan *device.IMUEvent, 1)
+	glass.SetIMUEventHandler(func(event *device.IMUEvent) {
+		select {
+		case received <- event:
+		default:
+		}
+	})
+	defer glass.SetIMUEventHandler(func(*device.IMUEvent) {})
+
+	if err := glass.EnableIMU(true); err != nil {
+		t.Fatalf("failed to enable IMU stream: %v", err)
+	}
+	defer glass.EnableIMU(false)
+
+	select {
+	case <-received:
+	case <-time.After(hardwareEventTimeout):
+		t.Errorf("no IMU event received in %v", hardwareEventTimeout)
+	}
+}
+
+// TestHardwareMagnetometerMagnitude logs the mean magnitude of the raw magnetometer readings while the glass lies still,
+// to find their unknown unit from the field of the Earth, 25 to 65 microtesla anywhere on it. The hard iron offset of
+// the glass and metal nearby shift the readings too. It only fails if the readings are stuck at 0.
+func TestHardwareMagnetometerMagnitude(t *testing.T) {
+	recordResult(t)
+	requireGlass(t)
+
+	const samples = 20
+	received := make(chan *device.MagnetometerVector, samples)
+	glass.SetMagnetometerEventHandler(func(vector *device.MagnetometerVector) {
+		if !vector.Valid {
+			return
+		}
+		select {
+		case received <- vector:
+		default:
+		}
+	})
+	defer glass.SetMagnetometerEventHandler(func(*device.MagnetometerVector) {})
+
+	if err := glass.EnableMagnetometer(true); err != nil {
+		t.Fatalf("failed to enable magnetometer event reporting: %v", err)
+	}
+	defer glass.EnableMagnetometer(false)
+
+	var x, y, z float64
+	for i := 0; i < samples; i++ {
+		select {
+		case vector := <-received:
+			x, y, z = x+vector.X, y+vector.Y, z+vector.Z
+		case <-time.After(hardwareEventTimeout):
+			t.Fatalf("received %d of %d magnetometer readings in %v", i, samples, hardwareEventTimeout)
+		}
+	}
+	magnitude := math.Sqrt(x*x+y*y+z*z) / samples
+	if magnitude == 0 {
+		t.Fatalf("mean magnetometer magnitude = 0, want the field of the Earth")
+	}
+	// the scale the Earth implies, if nothing nearby disturbs the field
+	t.Logf("mean magnetometer magnitude %.0f raw, %.3f to %.3f microtesla per raw unit", magnitude, 25/magnitude, 65/magnitude)
+}
+
+func TestHardwareSLAMFrame(t *testing.T) {
+	recordResult(t)
+	requireGlass(t)
+
+	left, right, timestamp, err := glass.GetSLAMFrame()
+	if err != nil {
+		t.Fatalf("GetSLAMFrame() failed: %v", err)
+	}
+	if left == nil || right == nil {
+		t.Fatalf("GetSLAMFrame() returned nil images")
+	}
+	if left.Bounds().Dx() != 640 || left.Bounds().Dy() != 480 {
+		t.Errorf("got left image bounds %v; expected 640x480", left.Bounds())
+	}
+	if timestamp.IsZero() {
+		t.Errorf("GetSLAMFrame() returned zero timestamp")
+	}
+}
+
+func TestHardwareCapabilities(t *testing.T) {
+	recordResult(t)
+	requireGlass(t)
+
+	capabilities, err := glass.GetCapabilities()
+	if err != nil {
+		t.Fatalf("GetCapabilities() failed: %v", err)
+	}
+	if capabilities.Firmware != firmwareVersion {
+		t.Errorf("got capabilities for firmware %s; expected %s", capabilities.Firmware, firmwareVersion)
+	}
+	t.Logf("capabilities: %s", capabilities)
+
+	if !capabilities.OrbitFunction {
+		return
+	}
+	state, err := glass.GetOrbitFunction()
+	if err != nil {
+		t.Errorf("GetOrbitFunction() failed: %v", err)
+	}
+	t.Logf("orbit function state: %q", state)
+}
+
+func TestHardwareConformance(t *testing.T) {
+	recordResult(t)
+	requireGlass(t)
+
+	report := device.RunConformance(glass)
+	for _, check := range report.Checks {
+		if check.Status == device.CONFORMANCE_FAIL {
+			t.Errorf("%s: %q (%s)", check.Name, check.Value, check.Detail)
+		}
+	}
+	t.Logf("conformance report:\n%s", report)
+}
+
+func TestHardwareOV580Info(t *testing.T) {
+	recordResult(t)
+	requireGlass(t)
+
+	info, err := glass.GetOV580Info()
+	if err != nil {
+		t.Fatalf("GetOV580Info() failed: %v", err)
+	}
+	if !info.HasCalibration {
+		t.Errorf("GetOV580Info() reports no calibration loaded after connecting")
+	}
+	t.Logf("ov580: %s", info)
+}
@@ -0,0 +1,204 @@
+package device
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	hid "github.com/sstallion/go-hid"
+)
+
+// hidDevice is what the drivers use of an open hid device, so tests can stand in for the glass.
+type hidDevice interface {
+	Write(p []byte) (int, error)
+	ReadWithTimeout(p []byte, timeout time.Duration) (int, error)
+	GetDeviceInfo() (*hid.DeviceInfo, error)
+	Close() error
+}
+
+// HIDInterface is one of the hid interfaces a component of the glass exposes, as enumerated by hidapi.
+type HIDInterface struct {
+	Path string
+	// Interface is the USB interface number, -1 if unknown
+	Interface int
+	UsagePage uint16
+	Usage     uint16
+}
+
+func (i HIDInterface) String() string {
+	return fmt.Sprintf("interface %d (usage page 0x%04x, usage 0x%04x) at %s", i.Interface, i.UsagePage, i.Usage, i.Path)
+}
+
+func hidInterfaceOf(info *hid.DeviceInfo) HIDInterface {
+	return HIDInterface{Path: info.Path, Interface: info.InterfaceNbr, UsagePage: info.UsagePage, Usage: info.Usage}
+}
+
+// HIDInterfaceSelector selects the hid interface to open when a component exposes several, e.g. the Light MCU on
+// some units. Among the interfaces matching it, the one with the lowest interface number is selected, so the choice
+// does not depend on the enumeration order of the OS.
+type HIDInterfaceSelector struct {
+	// Interface is the USB interface number to select, HID_INTERFACE_ANY for any
+	Interface int
+	// UsagePage is the usage page to select, 0 for any
+	UsagePage uint16
+}
+
+// HID_INTERFACE_ANY is the Interface of a HIDInterfaceSelector matching any interface number.
+const HID_INTERFACE_ANY = -1
+
+// AUTO_HID_INTERFACE_SELECTOR selects the interface with the lowest number. This is not known to be the one carrying
+// the MCU features, it is the interface hid.OpenFirst opened before interfaces could be selected.
+var AUTO_HID_INTERFACE_SELECTOR = HIDInterfaceSelector{Interface: HID_INTERFACE_ANY}
+
+func (s HIDInterfaceSelector) String() string {
+	var parts []string
+	if s.Interface != HID_INTERFACE_ANY {
+		parts = append(parts, fmt.Sprintf("interface=%d", s.Interface))
+	}
+	if s.UsagePage != 0 {
+		parts = append(parts, fmt.Sprintf("usagepage=0x%04x", s.UsagePage))
+	}
+	if len(parts) == 0 {
+		return "auto"
+	}
+	return strings.Join(parts, ",")
+}
+
+func (s HIDInterfaceSelector) matches(info *hid.DeviceInfo) bool {
+	return (s.Interface == HID_INTERFACE_ANY || info.InterfaceNbr == s.Interface) &&
+		(s.UsagePage == 0 || info.UsagePage == s.UsagePage)
+}
+
+// ParseHIDInterfaceSelector parses "auto", or comma separated interface=<number> and usagepage=<hex>, e.g.
+// "interface=1" or "usagepage=0xff00".
+func ParseHIDInterfaceSelector(selector string) (HIDInterfaceSelector, error) {
+	parsed := AUTO_HID_INTERFACE_SELECTOR
+	if selector == "" || selector == "auto" {
+		return parsed, nil
+	}
+	for _, part := range strings.Split(selector, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return parsed, fmt.Errorf("invalid hid interface selector %s, want interface=<number> or usagepage=<hex>", part)
+		}
+		switch key {
+		case "interface":
+			number, err := strconv.Atoi(value)
+			if err != nil || number < 0 {
+				return parsed, fmt.Errorf("invalid hid interface number %s", value)
+			}
+			parsed.Interface = number
+		case "usagepage":
+			page, err := strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 16)
+			if err != nil || page == 0 {
+				return parsed, fmt.Errorf("invalid hid usage page %s, want hex e.g. 0xff00", value)
+			}
+			parsed.UsagePage = uint16(page)
+		default:
+			return parsed, fmt.Errorf("invalid hid interface selector %s, want interface=<number> or usagepage=<hex>", part)
+		}
+	}
+	return parsed, nil
+}
+
+// glassKey tells apart the hid interfaces of different glasses: by serial number, or for glasses without a readable
+// one by the USB device part of the hidapi-libusb path "<bus>-<ports>:<config>.<interface>". Other paths identify a
+// single interface, so such glasses are never merged, at the cost of listing each of their interfaces on its own.
+func glassKey(info *hid.DeviceInfo) string {
+	if info.SerialNbr != "" {
+		return info.SerialNbr
+	}
+	if usbDevice, usbInterface, ok := strings.Cut(info.Path, ":"); ok {
+		if config, number, ok := strings.Cut(usbInterface, "."); ok && isDecimal(config) && isDecimal(number) {
+			return "path:" + usbDevice
+		}
+	}
+	return "path:" + info.Path
+}
+
+func isDecimal(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// selectHIDInterfaces returns the interface the selector selects of each glass, told apart by glassKey, in the order
+// the glasses were enumerated.
+func selectHIDInterfaces(devices []*hid.DeviceInfo, selector HIDInterfaceSelector) []*hid.DeviceInfo {
+	var keys []string
+	candidates := map[string][]*hid.DeviceInfo{}
+	for _, device := range devices {
+		key := glassKey(device)
+		if _, ok := candidates[key]; !ok {
+			keys = append(keys, key)
+			candidates[key] = nil
+		}
+		if selector.matches(device) {
+			candidates[key] = append(candidates[key], device)
+		}
+	}
+
+	var selected []*hid.DeviceInfo
+	for _, key := range keys {
+		matching := candidates[key]
+		if len(matching) == 0 {
+			continue
+		}
+		sort.SliceStable(matching, func(i, j int) bool {
+			a, b := matching[i], matching[j]
+			if a.InterfaceNbr != b.InterfaceNbr {
+				return a.InterfaceNbr < b.InterfaceNbr
+			}
+			if a.UsagePage != b.UsagePage {
+				return a.UsagePage < b.UsagePage
+			}
+			if a.Usage != b.Usage {
+				return a.Usage < b.Usage
+			}
+			return a.Path < b.Path
+		})
+		if len(matching) > 1 {
+			slog.Debug(fmt.Sprintf("%d hid interfaces match %s, selected %s", len(matching), selector, hidInterfaceOf(matching[0])))
+		}
+		selected = append(selected, matching[0])
+	}
+	return selected
+}
+
+// interfacesOf returns the interfaces of the glass of info, see glassKey.
+func interfacesOf(devices []*hid.DeviceInfo, info *hid.DeviceInfo) []HIDInterface {
+	key := glassKey(info)
+	var interfaces []HIDInterface
+	for _, device := range devices {
+		if glassKey(device) == key {
+			interfaces = append(interfaces, hidInterfaceOf(device))
+		}
+	}
+	return interfaces
+}
+
+// lightMCUInterface is shared by all Light glasses of the process.
+var lightMCUInterface = struct {
+	// mutex for thread safety
+	mutex    sync.Mutex
+	selector HIDInterfaceSelector
+}{selector: AUTO_HID_INTERFACE_SELECTOR}
+
+// SetLightMCUInterface selects the hid interface of the Light MCU opened from now on when no hid path is given. Which
+// interface carries which MCU features is not documented, so this is for units whose lowest numbered interface does
+// not answer. GlassInfo.MCUInterfaces and `list` show the interfaces of attached glasses.
+func SetLightMCUInterface(selector HIDInterfaceSelector) {
+	lightMCUInterface.mutex.Lock()
+	defer lightMCUInterface.mutex.Unlock()
+	lightMCUInterface.selector = selector
+}
+
+// GetLightMCUInterface returns the selector set by SetLightMCUInterface, AUTO_HID_INTERFACE_SELECTOR by default.
+func GetLightMCUInterface() HIDInterfaceSelector {
+	lightMCUInterface.mutex.Lock()
+	defer lightMCUInterface.mutex.Unlock()
+	return lightMCUInterface.selector
+}
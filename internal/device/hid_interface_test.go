@@ -0,0 +1,175 @@
+package device
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	hid "github.com/sstallion/go-hid"
+)
+
+func TestParseHIDInterfaceSelector(t *testing.T) {
+	testCases := []struct {
+		selector string
+		want     HIDInterfaceSelector
+	}{
+		{"", AUTO_HID_INTERFACE_SELECTOR},
+		{"auto", AUTO_HID_INTERFACE_SELECTOR},
+		{"interface=1", HIDInterfaceSelector{Interface: 1}},
+		{"usagepage=0xff00", HIDInterfaceSelector{Interface: HID_INTERFACE_ANY, UsagePage: 0xff00}},
+		{"interface=0, usagepage=ff00", HIDInterfaceSelector{Interface: 0, UsagePage: 0xff00}},
+	}
+	for _, tc := range testCases {
+		got, err := ParseHIDInterfaceSelector(tc.selector)
+		if err != nil || got != tc.want {
+			t.Errorf("ParseHIDInterfaceSelector(%q) = %v, %v, want %v", tc.selector, got, err, tc.want)
+		}
+		if again, err := ParseHIDInterfaceSelector(got.String()); err != nil || again != got {
+			t.Errorf("ParseHIDInterfaceSelector(%q) = %v, %v, want %v back", got.String(), again, err, got)
+		}
+	}
+
+	for _, invalid := range []string{"1", "interface=-1", "interface=x", "usagepage=0", "usagepage=0x10000", "usage=1"} {
+		if _, err := ParseHIDInterfaceSelector(invalid); err == nil {
+			t.Errorf("ParseHIDInterfaceSelector(%q) = nil, want error", invalid)
+		}
+	}
+}
+
+func TestSelectHIDInterfaces(t *testing.T) {
+	// the interfaces of glass A are enumerated out of order
+	devices := []*hid.DeviceInfo{
+		{SerialNbr: "A", Path: "a-1", InterfaceNbr: 1, UsagePage: 0xff00},
+		{SerialNbr: "B", Path: "b-0", InterfaceNbr: 0, UsagePage: 0x0001},
+		{SerialNbr: "A", Path: "a-0", InterfaceNbr: 0, UsagePage: 0x0001},
+	}
+
+	paths := func(selected []*hid.DeviceInfo) []string {
+		var paths []string
+		for _, device := range selected {
+			paths = append(paths, device.Path)
+		}
+		return paths
+	}
+	testCases := []struct {
+		selector HIDInterfaceSelector
+		want     []string
+	}{
+		{AUTO_HID_INTERFACE_SELECTOR, []string{"a-0", "b-0"}},
+		{HIDInterfaceSelector{Interface: 1}, []string{"a-1"}},
+		{HIDInterfaceSelector{Interface: HID_INTERFACE_ANY, UsagePage: 0x0001}, []string{"a-0", "b-0"}},
+		{HIDInterfaceSelector{Interface: 2}, nil},
+	}
+	for _, tc := range testCases {
+		got := paths(selectHIDInterfaces(devices, tc.selector))
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("selectHIDInterfaces(%s) = %v, want %v", tc.selector, got, tc.want)
+		}
+	}
+
+	if interfaces := interfacesOf(devices, devices[2]); len(interfaces) != 2 || interfaces[0].Path != "a-1" {
+		t.Errorf("interfacesOf(A) = %v, want both interfaces in enumeration order", interfaces)
+	}
+}
+
+func TestSelectHIDInterfacesWithoutSerials(t *testing.T) {
+	// two glasses without readable serial numbers, the first one exposing two interfaces
+	devices := []*hid.DeviceInfo{
+		{Path: "1-2:1.1", InterfaceNbr: 1},
+		{Path: "1-2:1.0", InterfaceNbr: 0},
+		{Path: "1-3:1.0", InterfaceNbr: 0},
+	}
+	selected := selectHIDInterfaces(devices, AUTO_HID_INTERFACE_SELECTOR)
+	if len(selected) != 2 || selected[0].Path != "1-2:1.0" || selected[1].Path != "1-3:1.0" {
+		t.Errorf("selectHIDInterfaces() = %v, want one interface of each glass", selected)
+	}
+	if interfaces := interfacesOf(devices, devices[0]); len(interfaces) != 2 {
+		t.Errorf("interfacesOf(1-2) = %v, want both interfaces of the first glass", interfaces)
+	}
+
+	// paths of other backends identify a single interface, so nothing is merged
+	devices = []*hid.DeviceInfo{{Path: "/dev/hidraw1"}, {Path: "/dev/hidraw2"}}
+	if selected := selectHIDInterfaces(devices, AUTO_HID_INTERFACE_SELECTOR); len(selected) != 2 {
+		t.Errorf("selectHIDInterfaces() = %v, want both hidraw devices", selected)
+	}
+}
+
+func TestPairedOV580Path(t *testing.T) {
+	glasses := []*GlassInfo{
+		{MCUInterfaces: []HIDInterface{{Path: "mcu-a0"}, {Path: "mcu-a1"}}, OV580Path: "ov580-a"},
+		{MCUInterfaces: []HIDInterface{{Path: "mcu-b0"}}, OV580Path: "ov580-b"},
+	}
+	for mcuPath, want := range map[string]string{"mcu-a1": "ov580-a", "mcu-b0": "ov580-b", "mcu-c0": ""} {
+		if got := pairedOV580Path(glasses, mcuPath); got != want {
+			t.Errorf("pairedOV580Path(%s) = %s, want %s", mcuPath, got, want)
+		}
+	}
+}
+
+// fakeHIDDevice stands in for a hid device of the glass: reads return the queued reports in order, then fail with
+// readErr, hid.ErrTimeout if nil, like a glass sending nothing.
+type fakeHIDDevice struct {
+	// mutex for thread safety
+	mutex   sync.Mutex
+	reports [][]byte
+	readErr error
+	// respond returns the frame queued in reply to a written command, none if empty; set before use
+	respond func(command *Packet) string
+}
+
+// queue zero pads frames into 64 byte reports to be read.
+func (f *fakeHIDDevice) queue(frames ...string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, frame := range frames {
+		report := make([]byte, 64)
+		copy(report, frame)
+		f.reports = append(f.reports, report)
+	}
+}
+
+func (f *fakeHIDDevice) setReadError(err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.readErr = err
+}
+
+// String keeps errors formatting the device from reading its fields.
+func (f *fakeHIDDevice) String() string {
+	return "fake hid device"
+}
+
+func (f *fakeHIDDevice) Write(p []byte) (int, error) {
+	if f.respond != nil {
+		command := &Packet{}
+		if err := command.Deserialize(p); err == nil {
+			if frame := f.respond(command); frame != "" {
+				f.queue(frame)
+			}
+		}
+	}
+	return len(p), nil
+}
+
+func (f *fakeHIDDevice) ReadWithTimeout(p []byte, timeout time.Duration) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if len(f.reports) == 0 {
+		if f.readErr != nil {
+			return -1, f.readErr
+		}
+		return 0, hid.ErrTimeout
+	}
+	n := copy(p, f.reports[0])
+	f.reports = f.reports[1:]
+	return n, nil
+}
+
+func (f *fakeHIDDevice) GetDeviceInfo() (*hid.DeviceInfo, error) {
+	return &hid.DeviceInfo{Path: "fake", InterfaceNbr: -1}, nil
+}
+
+func (f *fakeHIDDevice) Close() error {
+	return nil
+}
@@ -0,0 +1,92 @@
+package device
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DEFAULT_HOTPLUG_INTERVAL is how often WatchGlasses enumerates the attached glasses.
+const DEFAULT_HOTPLUG_INTERVAL = 1 * time.Second
+
+type HotplugEventType string
+
+const (
+	HOTPLUG_ATTACHED HotplugEventType = "attached"
+	HOTPLUG_DETACHED HotplugEventType = "detached"
+)
+
+// HotplugEvent tells a glass was attached or detached, identified by its MCU hid path.
+type HotplugEvent struct {
+	Type  HotplugEventType
+	Glass *GlassInfo
+}
+
+func (e HotplugEvent) String() string {
+	return fmt.Sprintf("%s %s at %s", e.Glass.Model, e.Type, e.Glass.MCUPath)
+}
+
+// WatchGlasses sends an event for every glass attached or detached until stop is closed, starting with the glasses
+// already attached. hidapi has no hotplug callbacks yet, so the glasses are enumerated every interval, without
+// sending anything to the connected ones.
+func WatchGlasses(interval time.Duration, stop <-chan struct{}) <-chan HotplugEvent {
+	return watchGlasses(interval, stop, enumerateGlasses)
+}
+
+func watchGlasses(interval time.Duration, stop <-chan struct{}, enumerate func() ([]*GlassInfo, error)) <-chan HotplugEvent {
+	events := make(chan HotplugEvent)
+
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		attached := map[string]*GlassInfo{}
+		for {
+			glasses, err := enumerate()
+			if err != nil {
+				slog.Debug(fmt.Sprintf("failed to enumerate glasses: %v", err))
+			} else {
+				for _, event := range diffGlasses(attached, glasses) {
+					select {
+					case events <- event:
+					case <-stop:
+						return
+					}
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+// diffGlasses updates attached to the current glasses and returns the changes, detached ones first.
+func diffGlasses(attached map[string]*GlassInfo, current []*GlassInfo) []HotplugEvent {
+	var events []HotplugEvent
+
+	present := make(map[string]*GlassInfo, len(current))
+	for _, info := range current {
+		present[info.MCUPath] = info
+	}
+	for path, info := range attached {
+		if _, ok := present[path]; !ok {
+			delete(attached, path)
+			events = append(events, HotplugEvent{Type: HOTPLUG_DETACHED, Glass: info})
+		}
+	}
+	for _, info := range current {
+		if _, ok := attached[info.MCUPath]; !ok {
+			attached[info.MCUPath] = info
+			events = append(events, HotplugEvent{Type: HOTPLUG_ATTACHED, Glass: info})
+		}
+	}
+	return events
+}
@@ -0,0 +1,45 @@
+package device
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatchGlasses(t *testing.T) {
+	light := &GlassInfo{Model: "light", MCUPath: "/dev/hidraw0"}
+	air := &GlassInfo{Model: "air", MCUPath: "/dev/hidraw3"}
+	enumerations := [][]*GlassInfo{
+		{light},
+		{light},
+		{light, air},
+		{air},
+		{},
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	enumerated := 0
+	events := watchGlasses(time.Millisecond, stop, func() ([]*GlassInfo, error) {
+		glasses := enumerations[min(enumerated, len(enumerations)-1)]
+		enumerated++
+		return glasses, nil
+	})
+
+	want := []HotplugEvent{
+		{Type: HOTPLUG_ATTACHED, Glass: light},
+		{Type: HOTPLUG_ATTACHED, Glass: air},
+		{Type: HOTPLUG_DETACHED, Glass: light},
+		{Type: HOTPLUG_DETACHED, Glass: air},
+	}
+	for i := range want {
+		select {
+		case event := <-events:
+			if !reflect.DeepEqual(event, want[i]) {
+				t.Errorf("event %d = %s, want %s", i, event, want[i])
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event %d %s", i, want[i])
+		}
+	}
+}
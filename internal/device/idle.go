@@ -0,0 +1,97 @@
+package device
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// idleReadPacketInterval is how often the read loops poll the glass while idle, instead of every readPacketFrequency
+const idleReadPacketInterval = time.Second
+
+// idleMode is shared by the MCU and the OV580 of a glass, see Device.Idle. A nil idleMode is never idle.
+type idleMode struct {
+	active atomic.Bool
+	// restore re-enables the event reporting enabled before idling, called by wake, nil to leave it off
+	restore func(enabled []CommandInstruction)
+
+	// mutex for thread safety
+	mutex sync.Mutex
+	// reporting tells which of the EVENT_REPORTING_INSTRUCTIONS were last set to enabled, the glass has no command
+	// to read them back
+	reporting map[CommandInstruction]bool
+	// enabledBeforeIdle is what enter was told to restore on waking up
+	enabledBeforeIdle []CommandInstruction
+}
+
+// reported records that the reporting of instruction was set, called by the components once the glass confirmed it.
+func (m *idleMode) reported(instruction CommandInstruction, enabled bool) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.reporting == nil {
+		m.reporting = map[CommandInstruction]bool{}
+	}
+	m.reporting[instruction] = enabled
+}
+
+// enabledReporting returns which of instructions were last set to enabled, in their order.
+func (m *idleMode) enabledReporting(instructions []CommandInstruction) []CommandInstruction {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var enabled []CommandInstruction
+	for _, instruction := range instructions {
+		if m.reporting[instruction] {
+			enabled = append(enabled, instruction)
+		}
+	}
+	return enabled
+}
+
+// enter idles until the next wake, which re-enables the reporting of enabledBeforeIdle.
+func (m *idleMode) enter(enabledBeforeIdle []CommandInstruction) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	m.enabledBeforeIdle = enabledBeforeIdle
+	m.mutex.Unlock()
+	if !m.active.Swap(true) {
+		slog.Info("glass is idle until the next command")
+	}
+}
+
+// wake resumes full operation, called on every command and subscription.
+func (m *idleMode) wake() {
+	if m == nil || !m.active.Swap(false) {
+		return
+	}
+	slog.Info("glass woke up from idle")
+
+	m.mutex.Lock()
+	enabled := m.enabledBeforeIdle
+	m.enabledBeforeIdle = nil
+	m.mutex.Unlock()
+	// commands sent by restore wake up again, which does nothing as the glass is no longer idle
+	if len(enabled) > 0 && m.restore != nil {
+		m.restore(enabled)
+	}
+}
+
+func (m *idleMode) isActive() bool {
+	return m != nil && m.active.Load()
+}
+
+// skipRead tells a read loop to skip this tick, as it polled less than idleReadPacketInterval ago while idle.
+// lastRead is the time of its last poll, updated if not skipped.
+func (m *idleMode) skipRead(lastRead *time.Time) bool {
+	now := time.Now()
+	if m.isActive() && now.Sub(*lastRead) < idleReadPacketInterval {
+		return true
+	}
+	*lastRead = now
+	return false
+}
@@ -0,0 +1,60 @@
+package device
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestIdleModeSkipRead(t *testing.T) {
+	var lastRead time.Time
+	var never *idleMode
+	if never.skipRead(&lastRead) || never.isActive() {
+		t.Errorf("nil idleMode skipped a read, want it never idle")
+	}
+
+	idle := &idleMode{}
+	idle.enter(nil)
+	lastRead = time.Time{}
+	if idle.skipRead(&lastRead) {
+		t.Errorf("skipRead() = true for the first read, want false")
+	}
+	if !idle.skipRead(&lastRead) {
+		t.Errorf("skipRead() = false right after a read while idle, want true")
+	}
+	lastRead = lastRead.Add(-idleReadPacketInterval)
+	if idle.skipRead(&lastRead) {
+		t.Errorf("skipRead() = true %v after the last read, want false", idleReadPacketInterval)
+	}
+
+	idle.wake()
+	if idle.isActive() || idle.skipRead(&lastRead) {
+		t.Errorf("skipRead() = true after waking up, want false")
+	}
+}
+
+func TestIdleModeRestoresReporting(t *testing.T) {
+	idle := &idleMode{}
+	var restored [][]CommandInstruction
+	idle.restore = func(enabled []CommandInstruction) { restored = append(restored, enabled) }
+
+	idle.reported(CMD_ENABLE_AMBIENT_LIGHT, true)
+	idle.reported(CMD_ENABLE_VSYNC, true)
+	idle.reported(CMD_ENABLE_VSYNC, false)
+	idle.reported(OV580_ENABLE_IMU_STREAM, true)
+	enabled := idle.enabledReporting(EVENT_REPORTING_INSTRUCTIONS)
+	want := []CommandInstruction{CMD_ENABLE_AMBIENT_LIGHT, OV580_ENABLE_IMU_STREAM}
+	if !slices.Equal(enabled, want) {
+		t.Fatalf("enabledReporting() = %v, want %v", enabled, want)
+	}
+
+	// disabling the reporting to idle must not change what is restored
+	idle.reported(CMD_ENABLE_AMBIENT_LIGHT, false)
+	idle.reported(OV580_ENABLE_IMU_STREAM, false)
+	idle.enter(enabled)
+	idle.wake()
+	idle.wake()
+	if len(restored) != 1 || !slices.Equal(restored[0], want) {
+		t.Errorf("restored %v on waking up twice, want %v once", restored, want)
+	}
+}
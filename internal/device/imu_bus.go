@@ -0,0 +1,133 @@
+package device
+
+import (
+	"math"
+	"sync"
+)
+
+// GyroscopeUnit is the unit of GyroscopeVector delivered to a subscriber.
+type GyroscopeUnit int
+
+const (
+	// GYROSCOPE_UNIT_RAD_PER_SEC is what the driver reports, the default
+	GYROSCOPE_UNIT_RAD_PER_SEC GyroscopeUnit = iota
+	GYROSCOPE_UNIT_DEG_PER_SEC
+)
+
+// AccelerometerUnit is the unit of AccelerometerVector delivered to a subscriber.
+type AccelerometerUnit int
+
+const (
+	// ACCELEROMETER_UNIT_METER_PER_SEC2 is what the driver reports, the default
+	ACCELEROMETER_UNIT_METER_PER_SEC2 AccelerometerUnit = iota
+	ACCELEROMETER_UNIT_G
+)
+
+// standardGravity is the m/s² per g used by the driver to scale the accelerometer
+const standardGravity = 9.81
+
+// IMUSubscriptionOptions tailors the IMU stream to a subscriber, e.g. a UI at 10Hz in deg/s next to fusion at full rate.
+type IMUSubscriptionOptions struct {
+	// MaxRate is the max events per second delivered, 0 delivers every event
+	MaxRate           float64
+	GyroscopeUnit     GyroscopeUnit
+	AccelerometerUnit AccelerometerUnit
+}
+
+type imuSubscriber struct {
+	options IMUSubscriptionOptions
+	handler IMUEventHandler
+
+	// lastDelivered is the TimeSinceBoot of the last event delivered, to enforce MaxRate
+	lastDelivered uint64
+	delivered     bool
+}
+
+// IMUEventBus fans out IMU events to multiple subscribers, each with its own rate and units.
+// Install Dispatch as the IMU event handler of the glass, e.g. glass.SetIMUEventHandler(bus.Dispatch).
+type IMUEventBus struct {
+	// mutex for thread safety
+	mutex       sync.Mutex
+	subscribers map[int]*imuSubscriber
+	nextID      int
+}
+
+func NewIMUEventBus() *IMUEventBus {
+	return &IMUEventBus{subscribers: make(map[int]*imuSubscriber)}
+}
+
+// Subscribe delivers IMU events to handler according to options until unsubscribe is called.
+func (b *IMUEventBus) Subscribe(options IMUSubscriptionOptions, handler IMUEventHandler) (unsubscribe func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = &imuSubscriber{options: options, handler: handler}
+
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		delete(b.subscribers, id)
+	}
+}
+
+// Dispatch delivers the event to every subscriber due for one, converted to its units.
+// The rate is enforced on the glass timestamps, so it holds regardless of how events are buffered on the host.
+func (b *IMUEventBus) Dispatch(event *IMUEvent) {
+	if event == nil {
+		return
+	}
+
+	b.mutex.Lock()
+	var due []*imuSubscriber
+	for _, subscriber := range b.subscribers {
+		if subscriber.isDue(event.TimeSinceBoot) {
+			subscriber.lastDelivered = event.TimeSinceBoot
+			subscriber.delivered = true
+			due = append(due, subscriber)
+		}
+	}
+	b.mutex.Unlock()
+
+	// handlers are called without holding the mutex, so they can unsubscribe
+	for _, subscriber := range due {
+		subscriber.handler(convertIMUEvent(event, subscriber.options))
+	}
+}
+
+func (s *imuSubscriber) isDue(timeSinceBoot uint64) bool {
+	if s.options.MaxRate <= 0 || !s.delivered || timeSinceBoot < s.lastDelivered {
+		// the timestamp goes backwards when the glass restarts
+		return true
+	}
+	intervalMs := 1000 / s.options.MaxRate
+	return float64(timeSinceBoot-s.lastDelivered) >= intervalMs
+}
+
+// convertIMUEvent returns a copy of the event in the units of options, so subscribers don't share vectors.
+func convertIMUEvent(event *IMUEvent, options IMUSubscriptionOptions) *IMUEvent {
+	converted := &IMUEvent{TimeSinceBoot: event.TimeSinceBoot}
+
+	if event.Gyroscope != nil {
+		gyro := *event.Gyroscope
+		if options.GyroscopeUnit == GYROSCOPE_UNIT_DEG_PER_SEC {
+			gyro.X *= 180 / math.Pi
+			gyro.Y *= 180 / math.Pi
+			gyro.Z *= 180 / math.Pi
+		}
+		converted.Gyroscope = &gyro
+	}
+
+	if event.Accelerometer != nil {
+		accel := *event.Accelerometer
+		if options.AccelerometerUnit == ACCELEROMETER_UNIT_G {
+			accel.X /= standardGravity
+			accel.Y /= standardGravity
+			accel.Z /= standardGravity
+		}
+		converted.Accelerometer = &accel
+	}
+
+	return converted
+}
@@ -0,0 +1,52 @@
+package device_test
+
+import (
+	"math"
+	"testing"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestIMUEventBus(t *testing.T) {
+	bus := device.NewIMUEventBus()
+
+	var full, ui []*device.IMUEvent
+	bus.Subscribe(device.IMUSubscriptionOptions{}, func(event *device.IMUEvent) {
+		full = append(full, event)
+	})
+	unsubscribe := bus.Subscribe(device.IMUSubscriptionOptions{
+		MaxRate:           10,
+		GyroscopeUnit:     device.GYROSCOPE_UNIT_DEG_PER_SEC,
+		AccelerometerUnit: device.ACCELEROMETER_UNIT_G,
+	}, func(event *device.IMUEvent) {
+		ui = append(ui, event)
+	})
+
+	// one second of events at 1 kHz
+	for ms := uint64(0); ms < 1000; ms++ {
+		bus.Dispatch(&device.IMUEvent{
+			Gyroscope:     &device.GyroscopeVector{X: math.Pi},
+			Accelerometer: &device.AccelerometerVector{Y: 9.81},
+			TimeSinceBoot: ms,
+		})
+	}
+
+	if len(full) != 1000 {
+		t.Errorf("got %d events at full rate; expected 1000", len(full))
+	}
+	if len(ui) != 10 {
+		t.Errorf("got %d events at 10Hz; expected 10", len(ui))
+	}
+	if full[0].Gyroscope.X != math.Pi || full[0].Accelerometer.Y != 9.81 {
+		t.Errorf("got %s at full rate; expected unconverted", full[0])
+	}
+	if math.Abs(float64(ui[0].Gyroscope.X)-180) > 1e-3 || math.Abs(float64(ui[0].Accelerometer.Y)-1) > 1e-6 {
+		t.Errorf("got %s at 10Hz; expected 180 deg/s and 1 g", ui[0])
+	}
+
+	unsubscribe()
+	bus.Dispatch(&device.IMUEvent{TimeSinceBoot: 2000})
+	if len(ui) != 10 {
+		t.Errorf("got an event after unsubscribe")
+	}
+}
@@ -0,0 +1,76 @@
+package device
+
+import (
+	"sync"
+)
+
+const (
+	INFO_SERIAL           = "serial"
+	INFO_STOCK_FIRMWARE   = "stock_firmware"
+	INFO_DISPLAY_FIRMWARE = "display_firmware"
+	INFO_DISPLAY_HDCP     = "display_hdcp"
+	INFO_OV580            = "ov580"
+	INFO_CAPABILITIES     = "capabilities"
+)
+
+// infoCache keeps the answers to queries which do not change while connected, e.g. the serial number, so UIs
+// polling them do not wait for a round trip to the glass each time. Errors are not cached.
+type infoCache struct {
+	// mutex for thread safety
+	mutex  sync.Mutex
+	values map[string]any
+}
+
+func newInfoCache() *infoCache {
+	return &infoCache{values: map[string]any{}}
+}
+
+// invalidate drops the cached answers of the keys, or all of them if none are given.
+func (c *infoCache) invalidate(keys ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(keys) == 0 {
+		clear(c.values)
+		return
+	}
+	for _, key := range keys {
+		delete(c.values, key)
+	}
+}
+
+// cachedInfo returns the cached answer of key, or the one of query, caching it on success. Concurrent misses may
+// query more than once, which is harmless for the static values cached.
+func cachedInfo[T any](c *infoCache, key string, query func() (T, error)) (T, error) {
+	c.mutex.Lock()
+	value, ok := c.values[key]
+	c.mutex.Unlock()
+	if ok {
+		return value.(T), nil
+	}
+
+	result, err := query()
+	if err != nil {
+		return result, err
+	}
+	c.mutex.Lock()
+	c.values[key] = result
+	c.mutex.Unlock()
+	return result, nil
+}
+
+// cachedInfoCopy is cachedInfo for queries returning a struct, returning a copy of the cached one so callers may
+// modify it.
+func cachedInfoCopy[T any](c *infoCache, key string, query func() (*T, error)) (*T, error) {
+	value, err := cachedInfo(c, key, func() (T, error) {
+		result, err := query()
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		return *result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
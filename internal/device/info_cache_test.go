@@ -0,0 +1,39 @@
+package device
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestInfoCache(t *testing.T) {
+	cache := newInfoCache()
+	queries := 0
+	query := func() (*OV580Info, error) {
+		queries++
+		if queries == 1 {
+			return nil, fmt.Errorf("timeout")
+		}
+		return &OV580Info{SerialNumber: fmt.Sprintf("serial%d", queries)}, nil
+	}
+
+	if _, err := cachedInfoCopy(cache, INFO_OV580, query); err == nil {
+		t.Fatalf("cachedInfoCopy() succeeded, want the error of the query")
+	}
+	info, err := cachedInfoCopy(cache, INFO_OV580, query)
+	if err != nil || info.SerialNumber != "serial2" {
+		t.Fatalf("cachedInfoCopy() = %v, %v, want the errors not to be cached", info, err)
+	}
+	info.SerialNumber = "modified"
+	if info, _ := cachedInfoCopy(cache, INFO_OV580, query); info.SerialNumber != "serial2" || queries != 2 {
+		t.Errorf("cachedInfoCopy() = %v after %d queries, want the unmodified cached copy", info, queries)
+	}
+
+	cache.invalidate(INFO_SERIAL)
+	if cachedInfoCopy(cache, INFO_OV580, query); queries != 2 {
+		t.Errorf("invalidate(INFO_SERIAL) dropped INFO_OV580")
+	}
+	cache.invalidate()
+	if info, _ := cachedInfoCopy(cache, INFO_OV580, query); info.SerialNumber != "serial3" {
+		t.Errorf("cachedInfoCopy() = %v after invalidate(), want it queried again", info)
+	}
+}
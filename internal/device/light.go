@@ -0,0 +1,979 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"xreal-light-xr-go/constant"
+	"xreal-light-xr-go/logging"
+	"xreal-light-xr-go/storage"
+)
+
+type xrealLight struct {
+	mcu     *xrealLightMCU
+	ov580   *xrealLightOV580
+	cameras *xrealLightCamera
+
+	// deviceHandlers contains callback funcs for the events that concern the glass as a whole
+	deviceHandlers *DeviceHandlers
+
+	// resumeWatcher tears down and re-establishes connections after the host resumes from sleep
+	resumeWatcher *resumeWatcher
+	// streamWatchdog recovers stalled IMU and SLAM camera streams, see SetStreamWatchdog
+	streamWatchdog *streamWatchdog
+	// slamFrameActivity tracks unanswered SLAM frame requests for streamWatchdog
+	slamFrameActivity streamActivity
+	// framePipeline processes the SLAM frames, see SetFramePipeline
+	framePipeline atomic.Pointer[FramePipeline]
+	// capturer names and rotates the images of CaptureImages, see SetCapturePolicy
+	capturer capturer
+
+	// control arbitrates the glass between processes, nil until connected
+	control *controlLock
+	// role is decided on the first connection and kept across reconnects, empty until connected
+	role Role
+
+	// idle is shared with the MCU and the OV580, see Idle
+	idle *idleMode
+
+	// info caches the static info queried from the glass, cleared on every (re)connection, see InvalidateInfoCache
+	info *infoCache
+
+	// mutex to serialize connecting and disconnecting
+	mutex sync.Mutex
+}
+
+func (l *xrealLight) Name() string {
+	return constant.XREAL_LIGHT
+}
+
+func (l *xrealLight) PID() uint16 {
+	return XREAL_LIGHT_MCU_PID
+}
+
+func (l *xrealLight) VID() uint16 {
+	return XREAL_LIGHT_MCU_VID
+}
+
+func (l *xrealLight) Disconnect() error {
+	// stop watching first, as the watchers themselves may be reconnecting while holding the mutex
+	l.resumeWatcher.stop()
+	l.streamWatchdog.stop()
+
+	if err := l.mcu.exitSBS(); err != nil {
+		slog.Error(fmt.Sprintf("failed to end SBS session before disconnecting: %v", err))
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.mcu.devicePath != nil {
+		markGlassDisconnected(*l.mcu.devicePath)
+	}
+
+	err := l.disconnectComponents()
+
+	if l.control != nil {
+		if releaseErr := l.control.release(); releaseErr != nil {
+			slog.Debug(fmt.Sprintf("failed to release control lock: %v", releaseErr))
+		}
+		l.control = nil
+	}
+	l.role = ""
+
+	return err
+}
+
+func (l *xrealLight) Connect() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := l.connectComponents(); err != nil {
+		return err
+	}
+
+	markGlassConnected(*l.mcu.devicePath, l)
+	l.resumeWatcher.start()
+	l.streamWatchdog.start()
+	return nil
+}
+
+// pairOV580 selects the OV580 of the glass whose MCU is open when several glasses are attached, as paired by
+// ListGlasses, so `connect serial` and `connect path` pick the OV580 of the same glass, as far as the pairing by
+// enumeration order can tell.
+func (l *xrealLight) pairOV580() {
+	if l.ov580.devicePath != nil {
+		return
+	}
+	glasses, err := listLightGlasses()
+	if err != nil || len(glasses) < 2 {
+		return
+	}
+	if path := pairedOV580Path(glasses, *l.mcu.devicePath); path != "" {
+		slog.Warn(fmt.Sprintf("%d XREAL Light glasses attached, assuming OV580 %s belongs to MCU %s as they enumerate in the same order", len(glasses), path, *l.mcu.devicePath))
+		l.ov580.devicePath = &path
+	}
+}
+
+// pairedOV580Path returns the OV580 path of the glass listed with the MCU at mcuPath on any of its interfaces, empty
+// if none.
+func pairedOV580Path(glasses []*GlassInfo, mcuPath string) string {
+	for _, info := range glasses {
+		for _, mcu := range info.MCUInterfaces {
+			if mcu.Path == mcuPath {
+				return info.OV580Path
+			}
+		}
+	}
+	return ""
+}
+
+// connectComponents initializes the MCU and the OV580 in parallel, as both retry until the glass responds and the
+// OV580 downloads its calibration file. The cameras follow the MCU, as the RGB camera may only show up once the MCU
+// enabled it. All share a deadline of connectTimeout.
+func (l *xrealLight) connectComponents() error {
+	deadline := time.Now().Add(connectTimeout)
+	l.mcu.connectDeadline = deadline
+	l.ov580.connectDeadline = deadline
+
+	errMCU := l.mcu.open()
+	if errMCU == nil {
+		// the MCU device path identifies the glass, so arbitrate before anything is written to it
+		l.arbitrate()
+		l.pairOV580()
+	}
+
+	var errOV580 error
+	var waitgroup sync.WaitGroup
+	waitgroup.Add(1)
+	go func() {
+		defer waitgroup.Done()
+		errOV580 = l.ov580.connectAndInitialize()
+	}()
+
+	if errMCU == nil {
+		errMCU = l.mcu.initialize()
+	}
+	errCameras := l.connectCameras()
+	waitgroup.Wait()
+
+	if errors.Is(errOV580, ErrOV580BadState) && errMCU == nil && l.role == ROLE_CONTROLLER {
+		if errOV580 = l.recoverOV580(errOV580); errOV580 == nil && errCameras != nil {
+			// the SLAM camera is on the same USB device as the OV580
+			l.cameras.disconnect()
+			errCameras = l.connectCameras()
+		}
+	}
+
+	err := errors.Join(
+		componentError(COMPONENT_MCU, errMCU),
+		componentError(COMPONENT_OV580, errOV580),
+		componentError(COMPONENT_CAMERAS, errCameras),
+	)
+	if err != nil {
+		l.disconnectComponents()
+	}
+	return err
+}
+
+// recoverOV580 resets the OV580 through the MCU when it was found with one of knownAlternateOV580PIDs on connecting,
+// the only recovery known, which may not help every state.
+func (l *xrealLight) recoverOV580(errOV580 error) error {
+	slog.Warn(fmt.Sprintf("%v, resetting it...", errOV580))
+	if err := l.mcu.resetOV580(); err != nil {
+		return errors.Join(errOV580, err)
+	}
+	if err := l.waitForOV580(); err != nil {
+		return errors.Join(errOV580, err)
+	}
+	slog.Info("OV580 recovered after resetting it")
+	return nil
+}
+
+// waitForOV580 connects the OV580 after it was reset, waiting up to ov580ReenumerationTimeout for it to re-enumerate.
+func (l *xrealLight) waitForOV580() error {
+	// the hid path may change on re-enumeration, so the first OV580 found is used again like on connecting
+	l.ov580.devicePath = nil
+	deadline := time.Now().Add(ov580ReenumerationTimeout)
+	l.ov580.connectDeadline = deadline.Add(connectTimeout)
+	for {
+		// give the OV580 time to drop off the bus first, so we don't reopen it right before it resets
+		time.Sleep(waitForPacketTimeout)
+
+		err := l.ov580.connectAndInitialize()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("not back after %v: %w", ov580ReenumerationTimeout, err)
+		}
+		slog.Debug(fmt.Sprintf("waiting for the OV580 to re-enumerate: %v", err))
+	}
+}
+
+// connectCameras connects the cameras, which are optional: if libusb cannot be initialized, e.g. as it is missing at
+// runtime, the glass is used without them and Capabilities.Cameras tells so.
+func (l *xrealLight) connectCameras() error {
+	err := l.cameras.connectAndInitialize()
+	if errors.Is(err, ErrCamerasUnavailable) {
+		slog.Warn(fmt.Sprintf("continuing without cameras: %v", err))
+		return nil
+	}
+	return err
+}
+
+func (l *xrealLight) disconnectComponents() error {
+	// a different glass, or the same one with new firmware, may be connected next on the same path
+	l.info.invalidate()
+
+	errMCU := l.mcu.disconnect()
+	errOV580 := l.ov580.disconnect()
+	errCameras := l.cameras.disconnect()
+
+	return errors.Join(
+		componentError(COMPONENT_MCU, errMCU),
+		componentError(COMPONENT_OV580, errOV580),
+		componentError(COMPONENT_CAMERAS, errCameras),
+	)
+}
+
+// arbitrate decides the role on the first connection, by taking the control lock if no other process holds it.
+func (l *xrealLight) arbitrate() {
+	if l.role == "" {
+		l.control = newControlLock(*l.mcu.devicePath)
+		acquired, err := l.control.tryAcquire()
+		if err != nil {
+			slog.Warn(fmt.Sprintf("failed to arbitrate control, assuming no other process uses the glass: %v", err))
+			acquired = true
+		}
+
+		l.role = ROLE_CONTROLLER
+		if !acquired {
+			l.role = ROLE_OBSERVER
+			slog.Info(fmt.Sprintf("glass is controlled by pid %s, connecting as a read-only observer", l.control.holder()))
+		}
+	}
+
+	l.mcu.observer = l.role == ROLE_OBSERVER
+	l.ov580.observer = l.role == ROLE_OBSERVER
+	l.ov580.control = l.control
+}
+
+func (l *xrealLight) GetRole() (Role, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.role == "" {
+		return "", fmt.Errorf("glass device is not connected yet")
+	}
+	return l.role, nil
+}
+
+func (l *xrealLight) RequestControl(timeout time.Duration) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.role == "" {
+		return fmt.Errorf("glass device is not connected yet")
+	}
+	if l.role == ROLE_CONTROLLER {
+		return nil
+	}
+
+	if err := l.control.acquire(timeout); err != nil {
+		return err
+	}
+
+	// reconnect so the glass gets initialized and kept alive by us from now on
+	if err := l.disconnectComponents(); err != nil {
+		slog.Debug(fmt.Sprintf("failed to cleanly disconnect before taking control: %v", err))
+	}
+	l.role = ROLE_CONTROLLER
+	return l.connectComponents()
+}
+
+func (l *xrealLight) ReleaseControl() error {
+	if err := l.mcu.exitSBS(); err != nil {
+		slog.Error(fmt.Sprintf("failed to end SBS session before releasing control: %v", err))
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.role == "" {
+		return fmt.Errorf("glass device is not connected yet")
+	}
+	if l.role == ROLE_OBSERVER {
+		return nil
+	}
+
+	if err := l.disconnectComponents(); err != nil {
+		slog.Debug(fmt.Sprintf("failed to cleanly disconnect before releasing control: %v", err))
+	}
+	if err := l.control.release(); err != nil {
+		return fmt.Errorf("failed to release control lock: %w", err)
+	}
+	l.role = ROLE_OBSERVER
+	return l.connectComponents()
+}
+
+// reconnectAfterResume is called by resumeWatcher since HID handles go stale after the host sleeps.
+func (l *xrealLight) reconnectAfterResume(asleepFor time.Duration) {
+	if err := l.reconnect("after resume"); err != nil {
+		slog.Error(err.Error())
+		return
+	}
+	l.deviceHandlers.ResumedEventHandler()
+}
+
+// reconnect tears down and re-establishes all connections, retrying until the glass is back.
+func (l *xrealLight) reconnect(reason string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := l.disconnectComponents(); err != nil {
+		slog.Debug(fmt.Sprintf("failed to cleanly disconnect %s: %v", reason, err))
+	}
+
+	for retry := 0; retry < retryMaxAttempts; retry++ {
+		err := l.connectComponents()
+		if err == nil {
+			return nil
+		}
+		slog.Debug(fmt.Sprintf("failed to reconnect %s, retry...: %v", reason, err))
+		time.Sleep(waitForPacketTimeout)
+	}
+	return fmt.Errorf("failed to reconnect %s: exceeds max retry attempts (%d)", reason, retryMaxAttempts)
+}
+
+func (l *xrealLight) SetRetryPolicy(policy RetryPolicy) error {
+	l.mcu.retryPolicy.Store(&policy)
+	l.ov580.retryPolicy.Store(&policy)
+	return nil
+}
+
+func (l *xrealLight) SetStreamWatchdog(policy *WatchdogPolicy) error {
+	l.streamWatchdog.stop()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if policy != nil {
+		copied := *policy
+		copied.Actions = append([]RecoveryAction(nil), policy.Actions...)
+		policy = &copied
+	}
+	l.streamWatchdog.policy = policy
+	if l.role != "" {
+		l.streamWatchdog.start()
+	}
+	return nil
+}
+
+// recoverStream is called by streamWatchdog when the IMU or SLAM camera stream stalls.
+func (l *xrealLight) recoverStream(stream string, action RecoveryAction) error {
+	l.mutex.Lock()
+	observer := l.role == ROLE_OBSERVER
+	l.mutex.Unlock()
+	if observer && action != RECOVERY_REPORT_ONLY {
+		// the controller owns the streams, it is up to its watchdog
+		return ErrObserver
+	}
+
+	switch action {
+	case RECOVERY_REPORT_ONLY:
+		return nil
+	case RECOVERY_REENABLE:
+		if stream == streamIMU {
+			return l.ov580.enableEventReporting(OV580_ENABLE_IMU_STREAM, "1")
+		}
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		if err := l.cameras.disconnect(); err != nil {
+			slog.Debug(fmt.Sprintf("failed to cleanly disconnect cameras before reopening: %v", err))
+		}
+		return componentError(COMPONENT_CAMERAS, l.connectCameras())
+	case RECOVERY_RESET_SENSORS:
+		return l.ResetSensors()
+	case RECOVERY_RECONNECT:
+		if err := l.reconnect(fmt.Sprintf("to recover the %s stream", stream)); err != nil {
+			return err
+		}
+		if stream == streamIMU {
+			// connecting disables the IMU stream to read the calibration
+			return l.ov580.enableEventReporting(OV580_ENABLE_IMU_STREAM, "1")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown recovery action %s", action)
+	}
+}
+
+func (l *xrealLight) GetSerial() (string, error) {
+	return cachedInfo(l.info, INFO_SERIAL, l.mcu.getSerial)
+}
+
+func (l *xrealLight) GetFirmwareVersion() (string, error) {
+	if l.mcu.device == nil {
+		return "", fmt.Errorf("glass device is not connected yet")
+	}
+	return l.mcu.glassFirmware, nil
+}
+
+func (l *xrealLight) GetStockFirmwareVersion() (string, error) {
+	return cachedInfo(l.info, INFO_STOCK_FIRMWARE, l.mcu.getStockFirmwareVersion)
+}
+
+func (l *xrealLight) GetDisplayFirmware() (*DisplayVersion, error) {
+	return cachedInfoCopy(l.info, INFO_DISPLAY_FIRMWARE, func() (*DisplayVersion, error) {
+		return l.mcu.getDisplayVersion(CMD_GET_DISPLAY_FIRMWARE)
+	})
+}
+
+func (l *xrealLight) GetDisplayHDCPVersion() (*DisplayVersion, error) {
+	return cachedInfoCopy(l.info, INFO_DISPLAY_HDCP, func() (*DisplayVersion, error) {
+		return l.mcu.getDisplayVersion(CMD_GET_DISPLAY_HDCP)
+	})
+}
+
+func (l *xrealLight) GetOV580Info() (*OV580Info, error) {
+	return cachedInfoCopy(l.info, INFO_OV580, l.ov580.getInfo)
+}
+
+func (l *xrealLight) ReadOV580File(id uint8, options OV580FileOptions) (*OV580File, error) {
+	l.idle.wake()
+	// IMU samples would share the reads with the parts, like when the calibration file is read on connect
+	streaming := l.ov580.imuActivity.isExpected()
+	if streaming {
+		if err := l.ov580.enableEventReporting(OV580_ENABLE_IMU_STREAM, "0"); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err := l.ov580.enableEventReporting(OV580_ENABLE_IMU_STREAM, "1"); err != nil {
+				slog.Warn(fmt.Sprintf("failed to resume IMU stream after reading OV580 file: %v", err))
+			}
+		}()
+	}
+	return l.ov580.readVerifiedFile(id, options)
+}
+
+func (l *xrealLight) GetMCUInfo() (*ConnectionInfo, error) {
+	return l.mcu.getConnectionInfo(), nil
+}
+
+func (l *xrealLight) GetSensorInfo() (*ConnectionInfo, error) {
+	return l.ov580.getConnectionInfo(), nil
+}
+
+func (l *xrealLight) GetCameraInfo() ([]ConnectionInfo, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.cameras.getConnectionInfo(), nil
+}
+
+func (l *xrealLight) ResetSensors() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.role == "" {
+		return fmt.Errorf("glass device is not connected yet")
+	}
+	if l.role == ROLE_OBSERVER {
+		return ErrObserver
+	}
+
+	// the handles go stale once the OV580 resets, and the SLAM camera is on the same USB device
+	errOV580 := l.ov580.disconnect()
+	errCameras := l.cameras.disconnect()
+	if err := errors.Join(componentError(COMPONENT_OV580, errOV580), componentError(COMPONENT_CAMERAS, errCameras)); err != nil {
+		slog.Debug(fmt.Sprintf("failed to cleanly disconnect before resetting sensors: %v", err))
+	}
+
+	errReset := l.mcu.resetOV580()
+	if errReset != nil {
+		slog.Warn(fmt.Sprintf("%v, reconnecting the sensors anyway", errReset))
+	}
+
+	if errOV580 = l.waitForOV580(); errOV580 != nil {
+		return errors.Join(errReset, componentError(COMPONENT_OV580, errOV580))
+	}
+
+	errIMU := l.ov580.enableEventReporting(OV580_ENABLE_IMU_STREAM, "1")
+	errCameras = l.connectCameras()
+	return errors.Join(errReset, componentError(COMPONENT_OV580, errIMU), componentError(COMPONENT_CAMERAS, errCameras))
+}
+
+func (l *xrealLight) GetDisplayMode() (DisplayMode, error) {
+	return l.mcu.getDisplayMode()
+}
+
+func (l *xrealLight) SetDisplayMode(mode DisplayMode) error {
+	return l.mcu.setDisplayMode(mode)
+}
+
+func (l *xrealLight) EnterSBS(duration time.Duration) error {
+	return l.mcu.enterSBS(duration)
+}
+
+func (l *xrealLight) ExitSBS() error {
+	return l.mcu.exitSBS()
+}
+
+func (l *xrealLight) GetBrightnessLevel() (string, error) {
+	return l.mcu.getBrightnessLevel()
+}
+
+func (l *xrealLight) SetBrightnessLevel(level string) error {
+	return l.mcu.setBrightnessLevel(level)
+}
+
+func (l *xrealLight) GetOLEDBrightnessLevel() (string, error) {
+	return l.mcu.getOLEDBrightnessLevel()
+}
+
+func (l *xrealLight) SetOLEDBrightnessLevel(level string) error {
+	return l.mcu.setOLEDBrightnessLevel(level)
+}
+
+func (l *xrealLight) GetOLEDBrightnessBrit() (string, error) {
+	return l.mcu.getOLEDBrightnessBrit()
+}
+
+func (l *xrealLight) GetKeySwitchEnabled() (bool, error) {
+	return l.mcu.getKeySwitchEnabled()
+}
+
+func (l *xrealLight) SetKeySwitchEnabled(enabled bool) error {
+	return l.mcu.setKeySwitchEnabled(enabled)
+}
+
+func (l *xrealLight) GetDefault2DEnabled() (bool, error) {
+	return l.mcu.getDefault2DEnabled()
+}
+
+func (l *xrealLight) SetDefault2DEnabled(enabled bool) error {
+	return l.mcu.setDefault2DEnabled(enabled)
+}
+
+func (l *xrealLight) GetConfigValue(key string) (string, error) {
+	return l.mcu.getConfigValue(key)
+}
+
+func (l *xrealLight) SetConfigValue(key string, value string) error {
+	// the cached capabilities include the RGB camera power state
+	defer l.info.invalidate(INFO_CAPABILITIES)
+	return l.mcu.setConfigValue(key, value)
+}
+
+func (l *xrealLight) GetSleepTime() (string, error) {
+	return l.mcu.getSleepTime()
+}
+
+func (l *xrealLight) SetSleepTime(seconds string) error {
+	return l.mcu.setSleepTime(seconds)
+}
+
+func (l *xrealLight) GetOrbitFunction() (string, error) {
+	return l.mcu.getOrbitFunction()
+}
+
+func (l *xrealLight) SetOrbitFunction(open bool) error {
+	return l.mcu.setOrbitFunction(open)
+}
+
+func (l *xrealLight) GetSuperActive() (bool, error) {
+	return l.mcu.getSuperActive()
+}
+
+func (l *xrealLight) SetSuperActive(enabled bool) error {
+	return l.mcu.setSuperActive(enabled)
+}
+
+func (l *xrealLight) GetRGBCameraEnabled() (bool, error) {
+	return l.mcu.getRGBCameraEnabled()
+}
+
+func (l *xrealLight) GetClockSync() (*ClockSync, error) {
+	if l.mcu.clock == nil {
+		return nil, fmt.Errorf("glass device is not connected yet")
+	}
+	return l.mcu.clock, nil
+}
+
+func (l *xrealLight) GetCapabilities() (*Capabilities, error) {
+	capabilities, err := cachedInfoCopy(l.info, INFO_CAPABILITIES, l.mcu.getCapabilities)
+	if err != nil {
+		return nil, err
+	}
+	capabilities.Cameras = l.cameras.checkConnected() == nil
+	return capabilities, nil
+}
+
+func (l *xrealLight) InvalidateInfoCache() {
+	l.info.invalidate()
+}
+
+func (l *xrealLight) DisplayOff() error {
+	return l.mcu.displayOff()
+}
+
+func (l *xrealLight) DisplayOn() error {
+	return l.mcu.displayOn()
+}
+
+func (l *xrealLight) Idle() error {
+	l.mutex.Lock()
+	role := l.role
+	l.mutex.Unlock()
+	if role == "" {
+		return fmt.Errorf("glass device is not connected yet")
+	}
+	if role == ROLE_OBSERVER {
+		return ErrObserver
+	}
+
+	// powering the camera is no event reporting, and it takes a while to enumerate again
+	instructions := slices.DeleteFunc(slices.Clone(EVENT_REPORTING_INSTRUCTIONS), func(instruction CommandInstruction) bool {
+		return instruction == CMD_ENABLE_RGB_CAMERA
+	})
+	enabled := l.idle.enabledReporting(instructions)
+
+	var errs []error
+	for _, instruction := range instructions {
+		if err := l.EnableEventReporting(instruction, "0"); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", Command{instruction: instruction}.String(), err))
+		}
+	}
+	// idle even if some reporting is left on, as suspending heart beats and polling saves the most
+	l.idle.enter(enabled)
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to disable event reporting: %w", err)
+	}
+	return nil
+}
+
+// restoreEventReporting re-enables the event reporting enabled before idling, called on waking up.
+func (l *xrealLight) restoreEventReporting(enabled []CommandInstruction) {
+	for _, instruction := range enabled {
+		if err := l.EnableEventReporting(instruction, "1"); err != nil {
+			l.deviceHandlers.reportError(fmt.Errorf("failed to re-enable %s after idle: %w", Command{instruction: instruction}.String(), err))
+		}
+	}
+}
+
+func (l *xrealLight) GetSLAMStreamFormats() ([]StreamFormat, error) {
+	return l.cameras.getSLAMStreamFormats()
+}
+
+func (l *xrealLight) SetSLAMStreamConfig(config StreamConfig) error {
+	return l.cameras.setSLAMStreamConfig(config)
+}
+
+func (l *xrealLight) SetFramePipeline(pipeline FramePipeline) error {
+	pipeline = append(FramePipeline(nil), pipeline...)
+	l.framePipeline.Store(&pipeline)
+	return nil
+}
+
+func (l *xrealLight) SetCapturePolicy(policy CapturePolicy) error {
+	return l.capturer.setPolicy(policy)
+}
+
+func (l *xrealLight) GetRGBStreamFormats() ([]StreamFormat, error) {
+	return l.cameras.getRGBStreamFormats()
+}
+
+func (l *xrealLight) SetRGBStreamConfig(config StreamConfig) error {
+	return l.cameras.setRGBStreamConfig(config)
+}
+
+func (l *xrealLight) EnableEventReporting(instruction CommandInstruction, enabled string) error {
+	if err := validateEventReporting(instruction, enabled); err != nil {
+		return err
+	}
+
+	switch instruction {
+	case OV580_ENABLE_IMU_STREAM:
+		return l.ov580.enableEventReporting(instruction, enabled)
+	case CMD_ENABLE_RGB_CAMERA:
+		defer l.info.invalidate(INFO_CAPABILITIES)
+		return l.mcu.enableEventReporting(instruction, enabled)
+	default:
+		return l.mcu.enableEventReporting(instruction, enabled)
+	}
+}
+
+func (l *xrealLight) EnableVSync(enabled bool) error {
+	return l.EnableEventReporting(CMD_ENABLE_VSYNC, eventReportingValue(enabled))
+}
+
+func (l *xrealLight) EnableAmbientLight(enabled bool) error {
+	return l.EnableEventReporting(CMD_ENABLE_AMBIENT_LIGHT, eventReportingValue(enabled))
+}
+
+func (l *xrealLight) EnableMagnetometer(enabled bool) error {
+	return l.EnableEventReporting(CMD_ENABLE_MAGNETOMETER, eventReportingValue(enabled))
+}
+
+func (l *xrealLight) EnableTemperature(enabled bool) error {
+	return l.EnableEventReporting(CMD_ENABLE_TEMPERATURE, eventReportingValue(enabled))
+}
+
+func (l *xrealLight) EnableIMU(enabled bool) error {
+	return l.EnableEventReporting(OV580_ENABLE_IMU_STREAM, eventReportingValue(enabled))
+}
+
+func (l *xrealLight) IsIMUEnabled() bool {
+	return l.ov580.imuActivity.isExpected()
+}
+
+func (l *xrealLight) ExecuteAsync(instruction CommandInstruction, payload []byte) *CommandFuture {
+	switch instruction {
+	case OV580_ENABLE_IMU_STREAM, OV580_GET_CALIBRATION_FILE_LENGTH, OV580_GET_CALIBRATION_FILE_PART:
+		return resolvedCommandFuture(fmt.Errorf("OV580 command %s cannot be executed asynchronously", Command{instruction: instruction}.String()))
+	case MCU_EVENT_AMBIENT_LIGHT, MCU_EVENT_KEY_PRESS, MCU_EVENT_MAGNETOMETER, MCU_EVENT_PROXIMITY, MCU_EVENT_TEMPERATURE_A, MCU_EVENT_TEMPERATURE_B, MCU_EVENT_VSYNC:
+		return resolvedCommandFuture(fmt.Errorf("%s is not a command", Command{instruction: instruction}.String()))
+	}
+	var packet *Packet
+	var err error
+	if payload == nil {
+		packet, err = l.mcu.buildCommandPacket(instruction)
+	} else {
+		packet, err = l.mcu.buildCommandPacket(instruction, payload)
+	}
+	if err != nil {
+		return resolvedCommandFuture(err)
+	}
+	return l.mcu.executeAsync(packet)
+}
+
+func (l *xrealLight) SetAmbientLightEventHandler(handler AmbientLightEventHandler) {
+	l.idle.wake()
+	l.mcu.deviceHandlers.AmbientLightEventHandler = handler
+}
+
+func (l *xrealLight) SetKeyEventHandler(handler KeyEventHandler) {
+	l.idle.wake()
+	l.mcu.deviceHandlers.KeyEventHandler = handler
+}
+
+func (l *xrealLight) SetMagnetometerEventHandler(handler MagnetometerEventHandler) {
+	l.idle.wake()
+	l.mcu.deviceHandlers.MagnetometerEventHandler = handler
+}
+
+func (l *xrealLight) SetProximityEventHandler(handler ProximityEventHandler) {
+	l.idle.wake()
+	l.mcu.deviceHandlers.ProximityEventHandler = handler
+}
+
+func (l *xrealLight) SetTemperatureEventHandler(handler TemperatureEventHandlder) {
+	l.idle.wake()
+	l.mcu.deviceHandlers.TemperatureEventHandlder = handler
+}
+
+func (l *xrealLight) SetVSyncEventHandler(handler VSyncEventHandler) {
+	l.idle.wake()
+	l.mcu.deviceHandlers.VSyncEventHandler = handler
+}
+
+func (l *xrealLight) SetIMUEventHandler(handler IMUEventHandler) {
+	l.idle.wake()
+	l.ov580.deviceHandlers.IMUEventHandler = handler
+}
+
+func (l *xrealLight) GetIMUEventHandler() IMUEventHandler {
+	return l.ov580.deviceHandlers.IMUEventHandler
+}
+
+func (l *xrealLight) SetResumedEventHandler(handler ResumedEventHandler) {
+	l.deviceHandlers.ResumedEventHandler = handler
+}
+
+func (l *xrealLight) SetErrorHandler(handler ErrorHandler) {
+	l.mcu.deviceHandlers.ErrorHandler = handler
+	l.ov580.deviceHandlers.ErrorHandler = handler
+	l.deviceHandlers.ErrorHandler = handler
+}
+
+func (l *xrealLight) ExecuteRaw(command Command, payload []byte) ([]byte, error) {
+	if err := CheckRawCommandAllowed("mcu", &command); err != nil {
+		return nil, err
+	}
+	// raw commands may change anything, e.g. the serial number
+	defer l.info.invalidate()
+	return l.mcu.executeRaw(command, payload)
+}
+
+func (l *xrealLight) ExecuteRawOV580(command Command, value uint8) ([]byte, error) {
+	if err := CheckRawCommandAllowed("ov580", &command); err != nil {
+		return nil, err
+	}
+	defer l.info.invalidate()
+	return l.ov580.executeAndWaitForResponse(&command, value)
+}
+
+func (l *xrealLight) DevExecuteAndRead(device string, input []string) {
+	// raw commands may change anything, e.g. the serial number
+	defer l.info.invalidate()
+
+	switch device {
+	case "mcu":
+		l.mcu.devExecuteAndRead(input)
+	case "ov580":
+		l.ov580.devExecuteAndRead(input)
+	default:
+		slog.Error(fmt.Sprintf("%s does not support %s commands, use mcu or ov580", l.Name(), device))
+	}
+}
+
+func (l *xrealLight) GetImagesDataDev(folderpath string) ([]string, error) {
+	data, err := l.cameras.getRawBytesFromSLAMCamera()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get slam images data: %w", err)
+	}
+	fpathSLAM := filepath.Join(folderpath, "cam_slam_dev.dat")
+	err = os.WriteFile(fpathSLAM, data, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write slam images: %w", err)
+	}
+
+	// data, err = l.cameras.getRawBytesFromRGBCamera()
+	// if err != nil {
+	// 	return nil, fmt.Errorf("failed to get rgb images data: %w", err)
+	// }
+	// fpathRGB := filepath.Join(folderpath, "cam_rgb_dev.dat")
+	// err = os.WriteFile(fpathRGB, data, 0644)
+	// if err != nil {
+	// 	return nil, fmt.Errorf("failed to write rgb images: %w", err)
+	// }
+
+	return []string{fpathSLAM}, nil
+}
+
+func (l *xrealLight) GetImages(folderpath string) ([]string, error) {
+	return l.CaptureImages(storage.NewLocal(folderpath))
+}
+
+func (l *xrealLight) CaptureImages(store storage.Storage) ([]string, error) {
+	slamCamFrame, err := l.GetSLAMFrameRaw()
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := l.GetSerial()
+	if err != nil {
+		// only needed by CAPTURE_NAME_SERIAL, not worth losing the frame for
+		slog.Debug(fmt.Sprintf("failed to get serial for capture name: %v", err))
+		serial = "unknown"
+	}
+
+	return l.capturer.capture(store, slamCamFrame, serial)
+}
+
+func (l *xrealLight) GetSLAMFrame() (image.Image, image.Image, time.Time, error) {
+	frame, err := l.GetSLAMFrameRaw()
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	left, right := frame.Images()
+	return left, right, frame.Timestamp, nil
+}
+
+func (l *xrealLight) GetSLAMFrameRaw() (*SLAMFrame, error) {
+	l.slamFrameActivity.expect()
+	for retry := 0; retry < retryMaxAttempts; retry++ {
+		frame, err := l.cameras.getFrameFromSLAMCamera()
+		if err == nil {
+			l.slamFrameActivity.deliver()
+			if pipeline := l.framePipeline.Load(); pipeline != nil && len(*pipeline) > 0 {
+				return pipeline.Process(frame)
+			}
+			return frame, nil
+		}
+		slog.Debug(fmt.Sprintf("failed to get images, retry...: %v", err))
+	}
+	return nil, fmt.Errorf("failed to get images, exceeds max retry attempts")
+}
+
+// NewXREALLight creates a xrealLight instance initiating MCU, OV580, and USB Camera connections.
+// devicePath and serialNumber are optional and select which MCU to use when multiple glasses are connected,
+// devicePath takes precedence. If neither is provided, the first found is used.
+func NewXREALLight(devicePath *string, serialNumber *string) Device {
+	var l xrealLight
+
+	l.mcu = &xrealLightMCU{
+		devicePath:   devicePath,
+		serialNumber: serialNumber,
+		deviceHandlers: &DeviceHandlers{
+			AmbientLightEventHandler: func(value uint16) {
+				slog.Info(fmt.Sprintf("Ambient light: %d", value))
+			},
+			KeyEventHandler: func(key KeyEvent) {
+				slog.Info(fmt.Sprintf("Key pressed: %s", key.String()))
+			},
+			MagnetometerEventHandler: func(vector *MagnetometerVector) {
+				slog.Info(fmt.Sprintf("Magnetometer: %s", vector.String()))
+			},
+			ProximityEventHandler: func(proximity ProximityEvent) {
+				slog.Info(fmt.Sprintf("Proximity: %s", proximity.String()))
+			},
+			TemperatureEventHandlder: func(value string) {
+				slog.Info(fmt.Sprintf("Temperature: %s", value))
+			},
+			VSyncEventHandler: func(value string) {
+				slog.Info(fmt.Sprintf("VSync: %s", value))
+			},
+		},
+	}
+
+	l.ov580 = &xrealLightOV580{
+		calibrationCache: newCalibrationCache(),
+		deviceHandlers: &DeviceHandlers{
+			IMUEventHandler: func(imu *IMUEvent) {
+				slog.Info(fmt.Sprintf("IMU: %s", imu.String()), logging.MODULE_KEY, logging.MODULE_IMU)
+			},
+		},
+	}
+
+	l.ov580.imuActivity.continuous = true
+
+	l.cameras = &xrealLightCamera{}
+
+	l.idle = &idleMode{}
+	l.idle.restore = l.restoreEventReporting
+	l.mcu.idle = l.idle
+	l.ov580.idle = l.idle
+
+	l.info = newInfoCache()
+
+	l.deviceHandlers = &DeviceHandlers{
+		ResumedEventHandler: func() {
+			slog.Info("Resumed: glass reconnected")
+		},
+	}
+
+	l.resumeWatcher = &resumeWatcher{
+		onResume:     l.reconnectAfterResume,
+		onError:      l.deviceHandlers.reportError,
+		staleChannel: make(chan struct{}, 1),
+	}
+	l.mcu.onStaleHandle = l.resumeWatcher.staleHandle
+	l.streamWatchdog = &streamWatchdog{
+		imu:     &l.ov580.imuActivity,
+		camera:  &l.slamFrameActivity,
+		recover: l.recoverStream,
+		onError: l.deviceHandlers.reportError,
+	}
+
+	return &l
+}
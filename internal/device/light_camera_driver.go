@@ -0,0 +1,181 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	libusb "github.com/gotmc/libusb/v2"
+)
+
+// detachCameraDrivers is shared by all glasses of the process.
+var detachCameraDrivers atomic.Bool
+
+// SetDetachCameraDrivers lets connecting the cameras of the Light detach the kernel driver holding their interfaces,
+// usually uvcvideo, taking the cameras away from other applications until they are disconnected, which re-attaches
+// it. Off by default, connecting the cameras then fails while a kernel driver holds them.
+func SetDetachCameraDrivers(detach bool) {
+	detachCameraDrivers.Store(detach)
+}
+
+// GetDetachCameraDrivers returns what SetDetachCameraDrivers set.
+func GetDetachCameraDrivers() bool {
+	return detachCameraDrivers.Load()
+}
+
+// claimCameraInterface claims the camera interface, detaching the kernel driver holding it (usually uvcvideo) if
+// enabled with SetDetachCameraDrivers. It returns whether the kernel driver was detached so it can be re-attached on
+// disconnect.
+func claimCameraInterface(name string, device *libusb.Device, handle *libusb.DeviceHandle, interfaceNumber int) (bool, error) {
+	detached := false
+
+	// not supported on all platforms, in which case we just try to claim
+	if active, err := handle.KernelDriverActive(interfaceNumber); err == nil && active {
+		driver := kernelDriverName(device, interfaceNumber)
+		if !GetDetachCameraDrivers() {
+			return false, fmt.Errorf(
+				"%s camera interface %d is held by kernel driver %s, enable detaching it with -detach-camera-drivers (SetDetachCameraDrivers in Go) or unload the driver with `sudo modprobe -r %s`",
+				name, interfaceNumber, driver, driver,
+			)
+		}
+		slog.Info(fmt.Sprintf("detaching kernel driver %s from %s camera interface %d, it is re-attached on disconnect", driver, name, interfaceNumber))
+		if err := handle.DetachKernelDriver(interfaceNumber); err != nil {
+			return false, fmt.Errorf(
+				"%s camera interface %d is held by kernel driver %s and detaching it failed, check the device permissions (e.g. a udev rule) or unload the driver with `sudo modprobe -r %s`: %w",
+				name, interfaceNumber, driver, driver, err,
+			)
+		}
+		detached = true
+	}
+
+	if err := handle.ClaimInterface(interfaceNumber); err != nil {
+		if detached {
+			handle.AttachKernelDriver(interfaceNumber)
+		}
+		return false, fmt.Errorf(
+			"failed to claim %s camera interface %d (kernel driver: %s), another process may be using the camera: %w",
+			name, interfaceNumber, kernelDriverName(device, interfaceNumber), err,
+		)
+	}
+
+	return detached, nil
+}
+
+// kernelDriverName looks up the kernel driver bound to the interface from sysfs, which only exists on Linux.
+func kernelDriverName(device *libusb.Device, interfaceNumber int) string {
+	if device == nil {
+		return "unknown"
+	}
+	bus, err := device.BusNumber()
+	if err != nil {
+		return "unknown"
+	}
+	address, err := device.DeviceAddress()
+	if err != nil {
+		return "unknown"
+	}
+
+	readNumber := func(path string) int {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return -1
+		}
+		value, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			return -1
+		}
+		return value
+	}
+
+	dirs, _ := filepath.Glob("/sys/bus/usb/devices/*")
+	for _, dir := range dirs {
+		if readNumber(filepath.Join(dir, "busnum")) != bus || readNumber(filepath.Join(dir, "devnum")) != address {
+			continue
+		}
+		interfaces, _ := filepath.Glob(fmt.Sprintf("%s/%s:*.%d", dir, filepath.Base(dir), interfaceNumber))
+		for _, intf := range interfaces {
+			link, err := os.Readlink(filepath.Join(intf, "driver"))
+			if err != nil {
+				return "none"
+			}
+			return filepath.Base(link)
+		}
+	}
+	return "unknown"
+}
+
+// RepairCameras re-attaches the kernel drivers of the attached XREAL Light cameras, e.g. after a process using them
+// was killed: the kernel releases the interfaces it claimed, but not the drivers it detached, which leaves the
+// cameras unusable by other applications until replugged. It must not run while this process has them connected.
+func RepairCameras() error {
+	ctx, err := libusb.NewContext()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCamerasUnavailable, err)
+	}
+	defer ctx.Close()
+
+	devices, err := ctx.DeviceList()
+	if err != nil {
+		return fmt.Errorf("failed to enumerate USB devices: %w", err)
+	}
+
+	var errs []error
+	found := 0
+	for _, device := range devices {
+		descriptor, err := device.DeviceDescriptor()
+		if err != nil {
+			continue
+		}
+		var name string
+		var interfaceNumber int
+		switch {
+		case (descriptor.VendorID == XREAL_LIGHT_RGB_CAM_VID) && (descriptor.ProductID == XREAL_LIGHT_RGB_CAM_PID):
+			name, interfaceNumber = "RGB", XREAL_LIGHT_RGB_CAM_IF_NUM
+		case (descriptor.VendorID == XREAL_LIGHT_SLAM_CAM_VID) && (descriptor.ProductID == XREAL_LIGHT_SLAM_CAM_PID):
+			name, interfaceNumber = "SLAM", XREAL_LIGHT_SLAM_CAM_IF_NUM
+		default:
+			continue
+		}
+		found++
+		if err := repairCameraInterface(name, device, interfaceNumber); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if found == 0 {
+		return fmt.Errorf("no XREAL Light glass cameras found")
+	}
+	return errors.Join(errs...)
+}
+
+// repairCameraInterface re-attaches the kernel driver of the camera interface if none is bound.
+func repairCameraInterface(name string, device *libusb.Device, interfaceNumber int) error {
+	handle, err := device.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s camera: %w", name, err)
+	}
+	defer handle.Close()
+
+	active, err := handle.KernelDriverActive(interfaceNumber)
+	if err != nil {
+		return fmt.Errorf("failed to check the kernel driver of %s camera interface %d: %w", name, interfaceNumber, err)
+	}
+	if active {
+		slog.Info(fmt.Sprintf("%s camera interface %d is bound to kernel driver %s, nothing to repair", name, interfaceNumber, kernelDriverName(device, interfaceNumber)))
+		return nil
+	}
+
+	if err := handle.AttachKernelDriver(interfaceNumber); err != nil {
+		return fmt.Errorf(
+			"failed to re-attach the kernel driver of %s camera interface %d, another process may be using the camera, or replug the glass: %w",
+			name, interfaceNumber, err,
+		)
+	}
+	slog.Info(fmt.Sprintf("re-attached kernel driver %s to %s camera interface %d", kernelDriverName(device, interfaceNumber), name, interfaceNumber))
+	return nil
+}
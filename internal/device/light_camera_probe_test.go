@@ -0,0 +1,58 @@
+package device
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+)
+
+// fakeUVCControls answers the probe control with settle applied to the values proposed, and records the commit.
+type fakeUVCControls struct {
+	settle    func(probe []byte)
+	probe     []byte
+	committed []byte
+}
+
+func (c *fakeUVCControls) ControlTransfer(requestType byte, request byte, value uint16, index uint16, data []byte, length int, timeout int) (int, error) {
+	switch {
+	case request == uvcSetCur && value == uvcVSProbeControl<<8:
+		c.probe = slices.Clone(data[:length])
+		c.settle(c.probe)
+	case request == uvcGetCur && value == uvcVSProbeControl<<8:
+		return copy(data, c.probe), nil
+	case request == uvcSetCur && value == uvcVSCommitControl<<8:
+		c.committed = slices.Clone(data[:length])
+	}
+	return length, nil
+}
+
+func TestNegotiateStreamingPacket(t *testing.T) {
+	// the camera picks another frame interval than proposed
+	controls := &fakeUVCControls{settle: func(probe []byte) {
+		binary.LittleEndian.PutUint32(probe[4:8], 666666)
+	}}
+	packet, err := negotiateStreamingPacket(controls, enableSLAMStreamingPacket, acceptSLAMStreamingPacket)
+	if err != nil {
+		t.Fatalf("negotiateStreamingPacket() = %v", err)
+	}
+	if interval := binary.LittleEndian.Uint32(controls.committed[4:8]); interval != 666666 {
+		t.Errorf("committed frame interval %d, want the 666666 the camera settled on", interval)
+	}
+	if !slices.Equal(packet, controls.committed) {
+		t.Errorf("negotiateStreamingPacket() = %v, want the committed %v", packet, controls.committed)
+	}
+	if interval := binary.LittleEndian.Uint32(enableSLAMStreamingPacket[4:8]); interval != 333333 {
+		t.Errorf("negotiateStreamingPacket() changed the template frame interval to %d", interval)
+	}
+
+	// the SLAM frame parsing needs the default frame size, so nothing is committed
+	controls = &fakeUVCControls{settle: func(probe []byte) {
+		binary.LittleEndian.PutUint32(probe[18:22], 1024)
+	}}
+	if _, err := negotiateStreamingPacket(controls, enableSLAMStreamingPacket, acceptSLAMStreamingPacket); err == nil {
+		t.Errorf("negotiateStreamingPacket() with another SLAM frame size = nil, want error")
+	}
+	if controls.committed != nil {
+		t.Errorf("committed %v, want nothing committed", controls.committed)
+	}
+}
@@ -0,0 +1,312 @@
+package device
+
+import (
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+
+	libusb "github.com/gotmc/libusb/v2"
+)
+
+const (
+	usbDescriptorTypeConfig    = 0x02
+	usbDescriptorTypeInterface = 0x04
+	uvcDescriptorTypeInterface = 0x24 // CS_INTERFACE
+
+	uvcVSFormatUncompressed = 0x04
+	uvcVSFrameUncompressed  = 0x05
+	uvcVSFormatMJPEG        = 0x06
+	uvcVSFrameMJPEG         = 0x07
+
+	// uvcFrameIntervalUnit is 100ns per unit
+	uvcFrameIntervalUnit = 1e7
+
+	// uvcStreamingInterface is the video streaming interface of both cameras, as used by the probe and commit controls
+	uvcStreamingInterface = 0x01
+
+	uvcSetCur          = 0x01
+	uvcGetCur          = 0x81
+	uvcVSProbeControl  = 0x01
+	uvcVSCommitControl = 0x02
+	// uvcMinProbeControlSize is the size of the UVC 1.0 probe control, later versions append fields
+	uvcMinProbeControlSize = 26
+)
+
+// StreamFormat is a frame format a camera advertises in its UVC descriptors.
+type StreamFormat struct {
+	FormatIndex uint8
+	FrameIndex  uint8
+	Compressed  bool
+	Width       int
+	Height      int
+	// MaxFrameSize is the max size of a frame in bytes
+	MaxFrameSize uint32
+	// FrameIntervals are the supported frame intervals in 100ns units
+	FrameIntervals []uint32
+}
+
+// FrameRates returns the supported frame rates in frames per second.
+func (f StreamFormat) FrameRates() []float64 {
+	var rates []float64
+	for _, interval := range f.FrameIntervals {
+		if interval != 0 {
+			rates = append(rates, uvcFrameIntervalUnit/float64(interval))
+		}
+	}
+	return rates
+}
+
+func (f StreamFormat) String() string {
+	return fmt.Sprintf("%dx%d @ %v fps (format %d, frame %d)", f.Width, f.Height, f.FrameRates(), f.FormatIndex, f.FrameIndex)
+}
+
+// StreamConfig selects the resolution and frame rate of a camera stream.
+type StreamConfig struct {
+	Width  int
+	Height int
+	FPS    float64
+}
+
+// ParseUVCStreamFormats extracts the frame formats of the video streaming interface from a raw configuration descriptor.
+func ParseUVCStreamFormats(descriptor []byte, interfaceNumber int) []StreamFormat {
+	var formats []StreamFormat
+
+	currentInterface := -1
+	var formatIndex uint8
+	for offset := 0; offset+2 < len(descriptor); {
+		length := int(descriptor[offset])
+		if length < 3 || offset+length > len(descriptor) {
+			break
+		}
+		d := descriptor[offset : offset+length]
+		offset += length
+
+		switch d[1] {
+		case usbDescriptorTypeInterface:
+			currentInterface = int(d[2])
+		case uvcDescriptorTypeInterface:
+			if currentInterface != interfaceNumber {
+				continue
+			}
+			switch d[2] {
+			case uvcVSFormatUncompressed, uvcVSFormatMJPEG:
+				if len(d) > 3 {
+					formatIndex = d[3]
+				}
+			case uvcVSFrameUncompressed, uvcVSFrameMJPEG:
+				if format, ok := parseUVCFrameDescriptor(d); ok {
+					format.FormatIndex = formatIndex
+					format.Compressed = d[2] == uvcVSFrameMJPEG
+					formats = append(formats, format)
+				}
+			}
+		}
+	}
+
+	return formats
+}
+
+func parseUVCFrameDescriptor(d []byte) (StreamFormat, bool) {
+	if len(d) < 26 {
+		return StreamFormat{}, false
+	}
+
+	format := StreamFormat{
+		FrameIndex:   d[3],
+		Width:        int(binary.LittleEndian.Uint16(d[5:7])),
+		Height:       int(binary.LittleEndian.Uint16(d[7:9])),
+		MaxFrameSize: binary.LittleEndian.Uint32(d[17:21]),
+	}
+
+	intervalType := int(d[25])
+	if intervalType == 0 {
+		// continuous: min, max, step
+		if len(d) < 38 {
+			return StreamFormat{}, false
+		}
+		minInterval := binary.LittleEndian.Uint32(d[26:30])
+		maxInterval := binary.LittleEndian.Uint32(d[30:34])
+		format.FrameIntervals = []uint32{minInterval, maxInterval}
+		return format, true
+	}
+
+	for i := 0; i < intervalType && 26+(i+1)*4 <= len(d); i++ {
+		format.FrameIntervals = append(format.FrameIntervals, binary.LittleEndian.Uint32(d[26+i*4:30+i*4]))
+	}
+	return format, true
+}
+
+// findStreamFormat picks the format matching the resolution and the frame interval closest to the frame rate.
+func findStreamFormat(formats []StreamFormat, config StreamConfig) (StreamFormat, uint32, error) {
+	for _, format := range formats {
+		if format.Width != config.Width || format.Height != config.Height || len(format.FrameIntervals) == 0 {
+			continue
+		}
+		want := uvcFrameIntervalUnit / config.FPS
+		best := slices.MinFunc(format.FrameIntervals, func(a, b uint32) int {
+			return cmp.Compare(math.Abs(float64(a)-want), math.Abs(float64(b)-want))
+		})
+		return format, best, nil
+	}
+	return StreamFormat{}, 0, fmt.Errorf("no stream format for %dx%d, supported: %v", config.Width, config.Height, formats)
+}
+
+// buildStreamingPacket patches the probe/commit template with the selected format.
+func buildStreamingPacket(template []byte, format StreamFormat, interval uint32) []byte {
+	packet := slices.Clone(template)
+	packet[2] = format.FormatIndex
+	packet[3] = format.FrameIndex
+	binary.LittleEndian.PutUint32(packet[4:8], interval)
+	binary.LittleEndian.PutUint32(packet[18:22], format.MaxFrameSize)
+	return packet
+}
+
+// readConfigDescriptor reads the raw configuration descriptor including the class specific UVC descriptors,
+// which libusb does not expose through its parsed descriptors.
+func readConfigDescriptor(handle *libusb.DeviceHandle) ([]byte, error) {
+	header := make([]byte, 9)
+	if _, err := handle.ControlTransfer(
+		0x80,                       // LIBUSB_ENDPOINT_IN | LIBUSB_REQUEST_TYPE_STANDARD | LIBUSB_RECIPIENT_DEVICE
+		0x06,                       // LIBUSB_REQUEST_GET_DESCRIPTOR
+		usbDescriptorTypeConfig<<8, // config descriptor 0
+		0,                          // language id
+		header,
+		len(header),
+		1000, // timeout, milliseconds
+	); err != nil {
+		return nil, fmt.Errorf("failed to read config descriptor header: %w", err)
+	}
+
+	descriptor := make([]byte, binary.LittleEndian.Uint16(header[2:4]))
+	count, err := handle.ControlTransfer(0x80, 0x06, usbDescriptorTypeConfig<<8, 0, descriptor, len(descriptor), 1000)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config descriptor: %w", err)
+	}
+	return descriptor[:count], nil
+}
+
+func getStreamFormats(handle *libusb.DeviceHandle) ([]StreamFormat, error) {
+	if handle == nil {
+		return nil, fmt.Errorf("camera is not connected yet")
+	}
+	descriptor, err := readConfigDescriptor(handle)
+	if err != nil {
+		return nil, err
+	}
+	return ParseUVCStreamFormats(descriptor, uvcStreamingInterface), nil
+}
+
+// controlTransferer is the part of a libusb device handle negotiating the stream, replaced in tests.
+type controlTransferer interface {
+	ControlTransfer(requestType byte, request byte, value uint16, index uint16, data []byte, length int, timeout int) (int, error)
+}
+
+// negotiateStreamingPacket proposes packet with the UVC probe control, reads back the values the camera settled on
+// and, if accept does not reject them, commits those to (re)start the stream. It returns the committed packet.
+// accept may be nil to take any values.
+func negotiateStreamingPacket(handle controlTransferer, packet []byte, accept func(probed []byte) error) ([]byte, error) {
+	if _, err := handle.ControlTransfer( // see libusb_control_transfer
+		0x21, // LIBUSB_REQUEST_TYPE_CLASS | LIBUSB_RECIPIENT_INTERFACE
+		uvcSetCur,
+		uvcVSProbeControl<<8,
+		uvcStreamingInterface,
+		slices.Clone(packet),
+		len(packet),
+		1000, // timeout, milliseconds
+	); err != nil {
+		return nil, fmt.Errorf("failed to set probe control: %w", err)
+	}
+
+	probed := make([]byte, len(packet))
+	count, err := handle.ControlTransfer(
+		0xa1, // LIBUSB_ENDPOINT_IN | LIBUSB_REQUEST_TYPE_CLASS | LIBUSB_RECIPIENT_INTERFACE
+		uvcGetCur,
+		uvcVSProbeControl<<8,
+		uvcStreamingInterface,
+		probed,
+		len(probed),
+		1000,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get probe control: %w", err)
+	}
+	if count < uvcMinProbeControlSize {
+		return nil, fmt.Errorf("failed to get probe control: got %d bytes, want at least %d", count, uvcMinProbeControlSize)
+	}
+	probed = probed[:count]
+	if accept != nil {
+		if err := accept(probed); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := handle.ControlTransfer(0x21, uvcSetCur, uvcVSCommitControl<<8, uvcStreamingInterface, slices.Clone(probed), len(probed), 1000); err != nil {
+		return nil, fmt.Errorf("failed to set commit control: %w", err)
+	}
+	return probed, nil
+}
+
+// acceptSLAMStreamingPacket rejects probed values changing the frame size, as the SLAM frame parsing relies on the
+// default one.
+func acceptSLAMStreamingPacket(probed []byte) error {
+	if size, want := binary.LittleEndian.Uint32(probed[18:22]), binary.LittleEndian.Uint32(enableSLAMStreamingPacket[18:22]); size != want {
+		return fmt.Errorf("SLAM camera settled on frame size %d: frame parsing only supports the default frame size %d", size, want)
+	}
+	return nil
+}
+
+func (l *xrealLightCamera) getSLAMStreamFormats() ([]StreamFormat, error) {
+	if err := l.checkConnected(); err != nil {
+		return nil, err
+	}
+	return getStreamFormats(l.slamCamera)
+}
+
+func (l *xrealLightCamera) getRGBStreamFormats() ([]StreamFormat, error) {
+	if err := l.checkConnected(); err != nil {
+		return nil, err
+	}
+	return getStreamFormats(l.rgbCamera)
+}
+
+// setSLAMStreamConfig only allows frame rate changes as the SLAM frame parsing relies on the default frame size.
+func (l *xrealLightCamera) setSLAMStreamConfig(config StreamConfig) error {
+	formats, err := l.getSLAMStreamFormats()
+	if err != nil {
+		return err
+	}
+	format, interval, err := findStreamFormat(formats, config)
+	if err != nil {
+		return err
+	}
+	if format.MaxFrameSize != binary.LittleEndian.Uint32(enableSLAMStreamingPacket[18:22]) {
+		return fmt.Errorf("unsupported SLAM stream format %s: frame parsing only supports the default frame size", format.String())
+	}
+
+	packet, err := negotiateStreamingPacket(l.slamCamera, buildStreamingPacket(enableSLAMStreamingPacket, format, interval), acceptSLAMStreamingPacket)
+	if err != nil {
+		return fmt.Errorf("failed to commit SLAM stream config %s: %w", format.String(), err)
+	}
+	l.slamStreamingPacket = packet
+	return nil
+}
+
+func (l *xrealLightCamera) setRGBStreamConfig(config StreamConfig) error {
+	formats, err := l.getRGBStreamFormats()
+	if err != nil {
+		return err
+	}
+	format, interval, err := findStreamFormat(formats, config)
+	if err != nil {
+		return err
+	}
+
+	packet, err := negotiateStreamingPacket(l.rgbCamera, buildStreamingPacket(enableRGBStreamingPacket, format, interval), nil)
+	if err != nil {
+		return fmt.Errorf("failed to commit RGB stream config %s: %w", format.String(), err)
+	}
+	l.rgbStreamingPacket = packet
+	return nil
+}
@@ -0,0 +1,44 @@
+package device_test
+
+import (
+	"reflect"
+	"testing"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestParseUVCStreamFormats(t *testing.T) {
+	descriptor := []byte{
+		// interface 1, alt 0
+		0x09, 0x04, 0x01, 0x00, 0x00, 0x0e, 0x02, 0x00, 0x00,
+		// VS_FORMAT_UNCOMPRESSED, format index 1 (truncated, only the index matters)
+		0x04, 0x24, 0x04, 0x01,
+		// VS_FRAME_UNCOMPRESSED, frame index 1, 640x480, 2 discrete intervals (30fps, 60fps)
+		0x22, 0x24, 0x05, 0x01, 0x00,
+		0x80, 0x02, 0xe0, 0x01, // 640x480
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // min/max bit rate
+		0x00, 0x65, 0x09, 0x00, // max frame size 615680
+		0x15, 0x16, 0x05, 0x00, // default interval 333333
+		0x02,
+		0x15, 0x16, 0x05, 0x00, // 333333
+		0x0a, 0x8b, 0x02, 0x00, // 166666
+		// interface 2 frames are ignored
+		0x09, 0x04, 0x02, 0x00, 0x00, 0x0e, 0x02, 0x00, 0x00,
+		0x1e, 0x24, 0x05, 0x01, 0x00,
+		0x80, 0x02, 0xe0, 0x01,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x65, 0x09, 0x00,
+		0x15, 0x16, 0x05, 0x00,
+		0x01,
+		0x15, 0x16, 0x05, 0x00,
+	}
+
+	expected := []device.StreamFormat{
+		{FormatIndex: 1, FrameIndex: 1, Width: 640, Height: 480, MaxFrameSize: 615680, FrameIntervals: []uint32{333333, 166666}},
+	}
+
+	actual := device.ParseUVCStreamFormats(descriptor, 1)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ParseUVCStreamFormats() = %v; expected %v", actual, expected)
+	}
+}
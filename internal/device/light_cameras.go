@@ -0,0 +1,555 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+	"time"
+
+	"xreal-light-xr-go/storage"
+
+	libusb "github.com/gotmc/libusb/v2"
+)
+
+const (
+	// XREAL Light SLAM Camera and IMU (should be the same as OV580)
+	XREAL_LIGHT_SLAM_CAM_VID    = uint16(0x05a9)
+	XREAL_LIGHT_SLAM_CAM_PID    = uint16(0x0680)
+	XREAL_LIGHT_SLAM_CAM_IF_NUM = 1
+
+	//XREAL Light RGB Camera
+	XREAL_LIGHT_RGB_CAM_VID    = uint16(0x0817)
+	XREAL_LIGHT_RGB_CAM_PID    = uint16(0x0909)
+	XREAL_LIGHT_RGB_CAM_IF_NUM = 0
+
+	// SLAM_FRAME_WIDTH and SLAM_FRAME_HEIGHT are the size of each SLAM camera image as captured
+	SLAM_FRAME_WIDTH  = 640
+	SLAM_FRAME_HEIGHT = 480
+
+	//XREAL Light Audio
+	XREAL_LIGHT_AUDIO_VID = uint16(0x0bda)
+	XREAL_LIGHT_AUDIO_PID = uint16(0x4b77)
+)
+
+// See https://github.com/badicsalex/ar-drivers-rs/blob/master/src/nreal_light.rs#L604
+var enableSLAMStreamingPacket = []byte{
+	0x01, 0x00, // bmHint
+	0x01,                   // bFormatIndex
+	0x01,                   // bFrameIndex
+	0x15, 0x16, 0x05, 0x00, // bFrameInterval (333333)
+	0x00, 0x00, // wKeyFrameRate
+	0x00, 0x00, // wPFrameRate
+	0x00, 0x00, // wCompQuality
+	0x00, 0x00, // wCompWindowSize
+	0x65, 0x00, // wDelay
+	0x00, 0x65, 0x09, 0x00, // dwMaxVideoFrameSize (615680)
+	0x00, 0x80, 0x00, 0x00, // dwMaxPayloadTransferSize
+	0x80, 0xd1, 0xf0, 0x08, // dwClockFrequency
+	0x08, // bmFramingInfo
+	0xf0, // bPreferredVersion
+	0xa9, // bMinVersion
+	0x18, // bMaxVersion
+}
+
+var enableRGBStreamingPacket = []byte{
+	0x01, 0x00, // bmHint
+	0x01,                   // bFormatIndex
+	0x01,                   // bFrameIndex
+	0x15, 0x16, 0x05, 0x00, // bFrameInterval (333333)
+	0x00, 0x00, // wKeyFrameRate
+	0x00, 0x00, // wPFrameRate
+	0x00, 0x00, // wCompQuality
+	0x00, 0x00, // wCompWindowSize
+	0x65, 0x00, // wDelay
+	0x00, 0xa9, 0xe6, 0x00, // dwMaxVideoFrameSize (15116544)
+	0x00, 0x80, 0x00, 0x00, // dwMaxPayloadTransferSize
+	0x80, 0xd1, 0xf0, 0x08, // dwClockFrequency
+	0x08, // bmFramingInfo
+	0xf0, // bPreferredVersion
+	0xa9, // bMinVersion
+	0x18, // bMaxVersion
+}
+
+// SLAMFrame is a stereo frame from the SLAM cameras.
+type SLAMFrame struct {
+	/// Left frame data (Width x Height grayscale pixels)
+	Left []byte
+	/// Right frame data (Width x Height grayscale pixels)
+	Right []byte
+	/// Timestamp is when the frame is received
+	Timestamp time.Time
+	// Width and Height are of each image, SLAM_FRAME_WIDTH x SLAM_FRAME_HEIGHT if zero, and change if a
+	// FramePipeline e.g. crops the images
+	Width  int
+	Height int
+	// Statistics are of the images as captured, before any FramePipeline, nil for frames not captured by a Device
+	Statistics *FrameStatistics
+}
+
+// Images converts the frame data to grayscale images without copying to files.
+func (frame *SLAMFrame) Images() (image.Image, image.Image) {
+	width, height := frame.Width, frame.Height
+	if width == 0 || height == 0 {
+		width, height = SLAM_FRAME_WIDTH, SLAM_FRAME_HEIGHT
+	}
+	left := bytesToImage(frame.Left, width, height, true /* isGray */)
+	right := bytesToImage(frame.Right, width, height, true /* isGray */)
+	return left, right
+}
+
+// WriteToFolder writes the left and right images with the given encoder, JPEG if encoder is nil.
+func (frame *SLAMFrame) WriteToFolder(folderpath string, prefixStr string, encoder ImageEncoder) ([]string, error) {
+	return frame.Store(storage.NewLocal(folderpath), prefixStr, encoder)
+}
+
+// Store writes the left and right images to store with the given encoder, JPEG if encoder is nil, and returns
+// their locations.
+func (frame *SLAMFrame) Store(store storage.Storage, prefixStr string, encoder ImageEncoder) ([]string, error) {
+	names, err := frame.store(store, prefixStr, encoder)
+	if err != nil {
+		return nil, err
+	}
+	locations := make([]string, len(names))
+	for i, name := range names {
+		locations[i] = store.Location(name)
+	}
+	return locations, nil
+}
+
+// store is Store returning the names of the images in store instead of their locations.
+func (frame *SLAMFrame) store(store storage.Storage, prefixStr string, encoder ImageEncoder) ([]string, error) {
+	var names []string
+
+	if encoder == nil {
+		encoder = &JPEGEncoder{Quality: DEFAULT_JPEG_QUALITY}
+	}
+
+	imageLeft, imageRight := frame.Images()
+	if imageLeft != nil {
+		name := fmt.Sprintf("%s_left.%s", prefixStr, encoder.Extension())
+		if err := imageToStorage(imageLeft, store, name, encoder); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	if imageRight != nil {
+		name := fmt.Sprintf("%s_right.%s", prefixStr, encoder.Extension())
+		if err := imageToStorage(imageRight, store, name, encoder); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// bytesToImage converts []byte to image.Image in greyscale
+func bytesToImage(data []byte, width, height int, isGray bool) image.Image {
+	if len(data) == 0 {
+		return nil
+	}
+
+	var img image.Image
+
+	if isGray {
+		grayImg := image.NewGray(image.Rect(0, 0, width, height))
+		copy(grayImg.Pix, data)
+		img = grayImg
+	} else {
+		rgbImg := image.NewRGBA(image.Rect(0, 0, width, height))
+		copy(rgbImg.Pix, data)
+		img = rgbImg
+	}
+
+	return img
+}
+
+type xrealLightRGBCameraFrame struct {
+	R []byte
+	G []byte
+	B []byte
+	/// Exact IMU timestamp when this frame was recorded
+	TimeSinceBoot uint64
+}
+
+type xrealLightCamera struct {
+	initialized bool
+	// unavailable wraps ErrCamerasUnavailable with the reason if libusb could not be initialized, nil otherwise
+	unavailable error
+
+	ctx *libusb.Context
+
+	rgbCamera       *libusb.DeviceHandle
+	rgbCameraDevice *libusb.Device
+	// rgbDriverDetached tells if the kernel driver of the RGB camera needs re-attaching on disconnect
+	rgbDriverDetached bool
+
+	slamCamera       *libusb.DeviceHandle
+	slamCameraDevice *libusb.Device
+	// slamDriverDetached tells if the kernel driver of the SLAM camera needs re-attaching on disconnect
+	slamDriverDetached bool
+
+	// slamStreamingPacket and rgbStreamingPacket are the selected stream formats, nil for the defaults
+	slamStreamingPacket []byte
+	rgbStreamingPacket  []byte
+}
+
+func (l *xrealLightCamera) connectAndInitialize() error {
+	ctx, err := libusb.NewContext()
+	if err != nil {
+		l.unavailable = fmt.Errorf("%w: %v, libusb cannot access the USB devices, e.g. /dev/bus/usb is not readable by this user or not mounted in a container", ErrCamerasUnavailable, err)
+		return l.unavailable
+	}
+	l.unavailable = nil
+	l.ctx = ctx
+
+	devices, err := ctx.DeviceList()
+	if err != nil {
+		return fmt.Errorf("failed to enumerate USB devices: %w", err)
+	}
+
+	if len(devices) == 0 {
+		return fmt.Errorf("no USB devices found: %v", devices)
+	}
+
+	rgbCameraDevices := []*libusb.Device{}
+	slamCameraDevices := []*libusb.Device{}
+	for _, device := range devices {
+		descriptor, err := device.DeviceDescriptor()
+		if err != nil {
+			slog.Warn(fmt.Sprintf("failed to get device descriptor for %v, skip: %v", device, err))
+			continue
+		}
+		if (descriptor.VendorID == XREAL_LIGHT_RGB_CAM_VID) && (descriptor.ProductID == XREAL_LIGHT_RGB_CAM_PID) {
+			rgbCameraDevices = append(rgbCameraDevices, device)
+		}
+		if (descriptor.VendorID == XREAL_LIGHT_SLAM_CAM_VID) && (descriptor.ProductID == XREAL_LIGHT_SLAM_CAM_PID) {
+			slamCameraDevices = append(slamCameraDevices, device)
+		}
+	}
+
+	slog.Debug(fmt.Sprintf("found rgb %v, slam %v", rgbCameraDevices, slamCameraDevices))
+
+	if len(rgbCameraDevices) == 0 {
+		return componentError(COMPONENT_RGB_CAMERA, fmt.Errorf("no XREAL Light glass RGB cameras found"))
+	}
+
+	if len(slamCameraDevices) == 0 {
+		return componentError(COMPONENT_SLAM_CAMERA, fmt.Errorf("no XREAL Light glass SLAM cameras found"))
+	}
+
+	for _, device := range rgbCameraDevices {
+		// if l.rgbCameraDevicePath == nil {
+		if len(rgbCameraDevices) > 1 {
+			slog.Warn(fmt.Sprintf("multiple XREAL Light glass RGB cameras found, assuming to use the first one: %v", device))
+		}
+		// 	// l.rgbCameraDevicePath = &devicePath
+		// }
+
+		// if *l.rgbCameraDevicePath != devicePath {
+		// 	continue
+		// }
+
+		deviceHandle, err := device.Open()
+		if err != nil {
+			return componentError(COMPONENT_RGB_CAMERA, fmt.Errorf("failed to open RGB camera: %w", err))
+		}
+		l.rgbCamera = deviceHandle
+		l.rgbCameraDevice = device
+	}
+
+	// if l.rgbCamera == nil {
+	// 	return fmt.Errorf("unable to match existing devices to device path %s", *l.rgbCameraDevicePath)
+	// }
+
+	for _, device := range slamCameraDevices {
+		// if l.slamCameraDevicePath == nil {
+		if len(slamCameraDevices) > 1 {
+			slog.Warn(fmt.Sprintf("multiple XREAL Light glass SLAM cameras found, assuming to use the first one: %v", device))
+		}
+		// 	// l.slamCameraDevicePath = &devicePath
+		// }
+
+		// if *l.slamCameraDevicePath != devicePath {
+		// 	continue
+		// }
+
+		deviceHandle, err := device.Open()
+		if err != nil {
+			return componentError(COMPONENT_SLAM_CAMERA, fmt.Errorf("failed to open SLAM camera: %w", err))
+		}
+		l.slamCamera = deviceHandle
+		l.slamCameraDevice = device
+	}
+
+	// if l.slamCamera == nil {
+	// 	return fmt.Errorf("unable to match existing devices to device path %s", *l.slamCameraDevicePath)
+	// }
+
+	return l.initialize()
+}
+
+// enumerateLightCameraPaths lists the locations of attached XREAL Light RGB and SLAM cameras.
+func enumerateLightCameraPaths() ([]string, error) {
+	ctx, err := libusb.NewContext()
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.Close()
+
+	devices, err := ctx.DeviceList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate USB devices: %w", err)
+	}
+
+	var paths []string
+	for _, device := range devices {
+		descriptor, err := device.DeviceDescriptor()
+		if err != nil {
+			continue
+		}
+		isRGBCamera := (descriptor.VendorID == XREAL_LIGHT_RGB_CAM_VID) && (descriptor.ProductID == XREAL_LIGHT_RGB_CAM_PID)
+		isSLAMCamera := (descriptor.VendorID == XREAL_LIGHT_SLAM_CAM_VID) && (descriptor.ProductID == XREAL_LIGHT_SLAM_CAM_PID)
+		if !isRGBCamera && !isSLAMCamera {
+			continue
+		}
+		path, err := usbDevicePath(device)
+		if err != nil {
+			slog.Debug(fmt.Sprintf("failed to get location of %v: %v", device, err))
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// usbDevicePath formats the libusb bus number and device address, similar to `lsusb`.
+func usbDevicePath(device *libusb.Device) (string, error) {
+	bus, err := device.BusNumber()
+	if err != nil {
+		return "", err
+	}
+	address, err := device.DeviceAddress()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("usb:%03d:%03d", bus, address), nil
+}
+
+func (l *xrealLightCamera) initialize() error {
+	detached, err := claimCameraInterface("SLAM", l.slamCameraDevice, l.slamCamera, XREAL_LIGHT_SLAM_CAM_IF_NUM)
+	if err != nil {
+		return componentError(COMPONENT_SLAM_CAMERA, err)
+	}
+	l.slamDriverDetached = detached
+
+	if l.slamStreamingPacket == nil {
+		l.slamStreamingPacket = enableSLAMStreamingPacket
+	}
+	packet, err := negotiateStreamingPacket(l.slamCamera, l.slamStreamingPacket, acceptSLAMStreamingPacket)
+	if err != nil {
+		return componentError(COMPONENT_SLAM_CAMERA, fmt.Errorf("failed to send control transfer message to SLAM cam: %w", err))
+	}
+	l.slamStreamingPacket = packet
+
+	detached, err = claimCameraInterface("RGB", l.rgbCameraDevice, l.rgbCamera, XREAL_LIGHT_RGB_CAM_IF_NUM)
+	if err != nil {
+		return componentError(COMPONENT_RGB_CAMERA, err)
+	}
+	l.rgbDriverDetached = detached
+
+	if l.rgbStreamingPacket == nil {
+		l.rgbStreamingPacket = enableRGBStreamingPacket
+	}
+	packet, err = negotiateStreamingPacket(l.rgbCamera, l.rgbStreamingPacket, nil)
+	if err != nil {
+		return componentError(COMPONENT_RGB_CAMERA, fmt.Errorf("failed to send control transfer message to RGB cam: %w", err))
+	}
+	l.rgbStreamingPacket = packet
+
+	l.initialized = true
+
+	return nil
+}
+
+// checkConnected tells why the cameras cannot be used, nil if they can.
+func (l *xrealLightCamera) checkConnected() error {
+	if l.unavailable != nil {
+		return l.unavailable
+	}
+	if !l.initialized {
+		return fmt.Errorf("cameras are not connected yet")
+	}
+	return nil
+}
+
+func (l *xrealLightCamera) getRawBytesFromSLAMCamera() ([]byte, error) {
+	if err := l.checkConnected(); err != nil {
+		return nil, err
+	}
+	data := make([]byte, 615908*2)
+	for {
+		receivedCount, err := l.slamCamera.BulkTransfer(0x81, data, len(data), 0 /* unlimited timeout */)
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive data from SLAM camera: %w", err)
+		}
+		if receivedCount == 615908 && data[0] != 0 {
+			data = data[:receivedCount]
+			break
+		}
+		slog.Warn(fmt.Sprintf("got data size %d, skip and try again", receivedCount))
+	}
+	return data, nil
+}
+
+func (l *xrealLightCamera) getRawBytesFromRGBCamera() ([]byte, error) {
+	if err := l.checkConnected(); err != nil {
+		return nil, err
+	}
+	data := make([]byte, 15116544*2)
+	for {
+		receivedCount, err := l.rgbCamera.BulkTransfer(0x81, data, len(data), 0 /* unlimited timeout */)
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive data from RGB camera: %w", err)
+		}
+		if receivedCount != 0 {
+			slog.Info(fmt.Sprintf("received %d bytes of data", receivedCount))
+			data = data[:receivedCount]
+			break
+		}
+		slog.Warn("got empty data, try again")
+	}
+	return data, nil
+}
+
+func (l *xrealLightCamera) getFrameFromSLAMCamera() (*SLAMFrame, error) {
+	data, err := l.getRawBytesFromSLAMCamera()
+	if err != nil {
+		return nil, err
+	}
+	frame, err := BuildSLAMCameraFrame(data)
+	if err != nil {
+		return nil, err
+	}
+	frame.Timestamp = time.Now()
+	frame.Statistics = NewFrameStatistics(frame)
+	return frame, nil
+}
+
+func BuildSLAMCameraFrame(data []byte) (*SLAMFrame, error) {
+	if len(data) != 615908 || data[0] == 0 {
+		return nil, fmt.Errorf("cannot handle received data that's different from size 615908")
+	}
+
+	// Remove headers occurring every 0x8000 bytes (max transfer size)
+	readIndex := 0
+	var dataCleaned []byte
+
+	for readIndex < len(data) {
+		headerSize := int(data[readIndex])
+
+		readIndex += headerSize
+
+		// Calculate length to copy and adjust indices
+		length := 0x8000 - (readIndex % 0x8000)
+		readEnd := readIndex + length
+		if readEnd > len(data) {
+			readEnd = len(data)
+		}
+
+		if headerSize == 12 {
+			dataCleaned = append(dataCleaned, data[readIndex:readEnd]...)
+		}
+
+		readIndex = readEnd
+	}
+
+	data = dataCleaned
+
+	// Process bulk data to extract left and right frames
+	var left, right []byte
+	for i := 0; i < SLAM_FRAME_HEIGHT; i++ {
+		left = append(left, data[(i*2)*SLAM_FRAME_WIDTH:(i*2+1)*SLAM_FRAME_WIDTH]...)
+		right = append(right, data[(i*2+1)*SLAM_FRAME_WIDTH:(i*2+2)*SLAM_FRAME_WIDTH]...)
+	}
+
+	return &SLAMFrame{
+		Left:   left,
+		Right:  right,
+		Width:  SLAM_FRAME_WIDTH,
+		Height: SLAM_FRAME_HEIGHT,
+	}, nil
+}
+
+// getConnectionInfo describes the RGB and SLAM cameras, the location is only known while they are open.
+func (l *xrealLightCamera) getConnectionInfo() []ConnectionInfo {
+	return []ConnectionInfo{
+		cameraConnectionInfo(COMPONENT_RGB_CAMERA, l.rgbCameraDevice, l.rgbCamera, XREAL_LIGHT_RGB_CAM_IF_NUM),
+		cameraConnectionInfo(COMPONENT_SLAM_CAMERA, l.slamCameraDevice, l.slamCamera, XREAL_LIGHT_SLAM_CAM_IF_NUM),
+	}
+}
+
+func cameraConnectionInfo(component string, device *libusb.Device, handle *libusb.DeviceHandle, interfaceNumber int) ConnectionInfo {
+	info := ConnectionInfo{Component: component, Interface: interfaceNumber}
+	if device == nil || handle == nil {
+		return info
+	}
+
+	info.Open = true
+	if path, err := usbDevicePath(device); err == nil {
+		info.Path = path
+	}
+	if descriptor, err := device.DeviceDescriptor(); err == nil && descriptor.SerialNumberIndex != 0 {
+		if serial, err := handle.StringDescriptorASCII(descriptor.SerialNumberIndex); err == nil {
+			info.SerialNumber = serial
+		}
+	}
+	return info
+}
+
+func (l *xrealLightCamera) disconnect() error {
+	l.initialized = false
+
+	var errRGB error
+	if l.rgbCamera != nil {
+		l.rgbCamera.SetInterfaceAltSetting(XREAL_LIGHT_RGB_CAM_IF_NUM, 0)
+		l.rgbCamera.ReleaseInterface(XREAL_LIGHT_RGB_CAM_IF_NUM)
+		if l.rgbDriverDetached {
+			l.rgbCamera.AttachKernelDriver(XREAL_LIGHT_RGB_CAM_IF_NUM)
+			l.rgbDriverDetached = false
+		}
+		errRGB = l.rgbCamera.Close()
+		if errRGB == nil {
+			l.rgbCamera = nil
+		}
+	}
+
+	var errSLAM error
+	if l.slamCamera != nil {
+		l.slamCamera.SetInterfaceAltSetting(XREAL_LIGHT_SLAM_CAM_IF_NUM, 0)
+		l.slamCamera.ReleaseInterface(XREAL_LIGHT_SLAM_CAM_IF_NUM)
+		if l.slamDriverDetached {
+			l.slamCamera.AttachKernelDriver(XREAL_LIGHT_SLAM_CAM_IF_NUM)
+			l.slamDriverDetached = false
+		}
+		errSLAM = l.slamCamera.Close()
+		if errSLAM == nil {
+			l.slamCamera = nil
+		}
+	}
+
+	if errRGB != nil || errSLAM != nil {
+		return errors.Join(componentError(COMPONENT_RGB_CAMERA, errRGB), componentError(COMPONENT_SLAM_CAMERA, errSLAM))
+	}
+
+	if l.ctx != nil {
+		if err := l.ctx.Close(); err != nil {
+			return fmt.Errorf("failed to close libusb context")
+		}
+		l.ctx = nil
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCamerasUnavailable(t *testing.T) {
+	cameras := &xrealLightCamera{}
+	if err := cameras.checkConnected(); err == nil || errors.Is(err, ErrCamerasUnavailable) {
+		t.Errorf("checkConnected() before connecting = %v, want not connected", err)
+	}
+
+	cameras.unavailable = fmt.Errorf("%w: libusb_init failed", ErrCamerasUnavailable)
+	if _, err := cameras.getSLAMStreamFormats(); !errors.Is(err, ErrCamerasUnavailable) {
+		t.Errorf("getSLAMStreamFormats() = %v, want ErrCamerasUnavailable", err)
+	}
+	if _, err := cameras.getRawBytesFromRGBCamera(); !errors.Is(err, ErrCamerasUnavailable) {
+		t.Errorf("getRawBytesFromRGBCamera() = %v, want ErrCamerasUnavailable", err)
+	}
+}
+
+func TestConnectionInfoWhileClosed(t *testing.T) {
+	path := "/dev/hidraw3"
+	if got, want := *hidConnectionInfo(COMPONENT_MCU, nil, &path), (ConnectionInfo{Component: COMPONENT_MCU, Path: path, Interface: -1}); got != want {
+		t.Errorf("hidConnectionInfo() = %v, want %v", got, want)
+	}
+
+	cameras := (&xrealLightCamera{}).getConnectionInfo()
+	if len(cameras) != 2 || cameras[0].Open || cameras[1].Open {
+		t.Errorf("getConnectionInfo() = %v, want RGB and SLAM cameras not open", cameras)
+	}
+	if cameras[1].Interface != XREAL_LIGHT_SLAM_CAM_IF_NUM {
+		t.Errorf("SLAM camera interface = %d, want %d", cameras[1].Interface, XREAL_LIGHT_SLAM_CAM_IF_NUM)
+	}
+}
@@ -1,6 +1,8 @@
 package device
 
 import (
+	"sync"
+
 	"xreal-light-xr-go/constant"
 )
 
@@ -11,6 +13,11 @@ const (
 
 	CMD_GET_BRIGHTNESS_LEVEL
 	CMD_SET_BRIGHTNESS_LEVEL
+	CMD_GET_DUTY
+	CMD_SET_DUTY
+	CMD_GET_OLED_BRIGHTNESS_LEVEL
+	CMD_SET_OLED_BRIGHTNESS_LEVEL
+	CMD_GET_OLED_BRIGHTNESS_BRIT
 
 	CMD_GET_DISPLAY_HDCP
 	CMD_GET_DISPLAY_MODE
@@ -24,12 +31,14 @@ const (
 	CMD_ENABLE_VSYNC
 	CMD_GET_TEMPERATURE_ENABLED
 	CMD_ENABLE_TEMPERATURE
+	CMD_GET_RGB_CAMERA_ENABLED
 	CMD_ENABLE_RGB_CAMERA
 
 	CMD_GET_GLASS_ACTIVATED
 	CMD_SET_GLASS_ACTIVATION
 	CMD_GET_GLASS_ACTIVATION_TIME
 
+	CMD_GET_SLEEP_TIME
 	CMD_SET_SLEEP_TIME
 
 	CMD_HEART_BEAT
@@ -40,6 +49,14 @@ const (
 	CMD_GET_STOCK_FIRMWARE_VERSION
 	CMD_SET_MAX_BRIGHTNESS_LEVEL
 	CMD_SET_SDK_WORKS
+	CMD_ENABLE_KEYSWITCH
+	CMD_ENABLE_DEFAULT_2D_FUNC
+	CMD_GET_ORBIT_FUNC
+	CMD_SET_ORBIT_FUNC
+	CMD_SET_SUPER_ACTIVE
+	CMD_GET_APPROACH_PS_VALUE
+	CMD_GET_DISTANCE_PS_VALUE
+	CMD_RESET_OV580
 
 	MCU_EVENT_AMBIENT_LIGHT
 	MCU_EVENT_KEY_PRESS
@@ -69,10 +86,26 @@ func (cmd Command) Equals(another *Command) bool {
 	return (cmd.Type == another.Type) && (cmd.ID == another.ID)
 }
 
+// EqualsInstruction tells whether cmd is the command of instruction. Commands decoded from packets do not know their
+// instruction, so they are compared with the firmware independent command of instruction and never match firmware
+// dependent ones.
+//
+// Deprecated: decoded commands of firmware dependent instructions never match, use EqualsInstructionOnFirmware.
 func (cmd Command) EqualsInstruction(instruction CommandInstruction) bool {
+	return cmd.equalsInstruction(instruction, GetFirmwareIndependentCommand)
+}
+
+// EqualsInstructionOnFirmware tells whether cmd is the command of instruction on the glass firmware version, e.g. of
+// Device.GetFirmwareVersion, so decoded commands also match firmware dependent instructions.
+func (cmd Command) EqualsInstructionOnFirmware(instruction CommandInstruction, firmwareVersion string) bool {
+	mcu := &xrealLightMCU{glassFirmware: firmwareVersion}
+	return cmd.equalsInstruction(instruction, mcu.getCommand)
+}
+
+// equalsInstruction is EqualsInstruction looking up the command of instruction with lookup.
+func (cmd Command) equalsInstruction(instruction CommandInstruction, lookup func(CommandInstruction) *Command) bool {
 	if cmd.instruction == CMD_UKNOWN {
-		foundCommand := GetFirmwareIndependentCommand(instruction)
-		return cmd.Equals(foundCommand)
+		return cmd.Equals(lookup(instruction))
 	}
 	return cmd.instruction == instruction
 }
@@ -87,16 +120,30 @@ func (cmd Command) String() string {
 		return "get brightness level"
 	case CMD_SET_MAX_BRIGHTNESS_LEVEL:
 		return "set max brightness level"
+	case CMD_GET_DUTY:
+		return "get display duty"
+	case CMD_SET_DUTY:
+		return "set display duty"
+	case CMD_GET_OLED_BRIGHTNESS_LEVEL:
+		return "get OLED brightness level"
+	case CMD_SET_OLED_BRIGHTNESS_LEVEL:
+		return "set OLED brightness level"
+	case CMD_GET_OLED_BRIGHTNESS_BRIT:
+		return "get OLED brightness brit"
 	case CMD_SET_DISPLAY_MODE:
 		return "set display mode"
 	case CMD_GET_DISPLAY_MODE:
 		return "get display mode"
 	case CMD_GET_DISPLAY_FIRMWARE:
 		return "get display firmware version"
+	case CMD_GET_DISPLAY_HDCP:
+		return "get display HDCP string"
 	case CMD_GET_FIRMWARE_VERSION:
 		return "get firmware version"
 	case CMD_GET_SERIAL_NUMBER:
 		return "get glass serial number"
+	case CMD_GET_SLEEP_TIME:
+		return "get glass sleep time"
 	case CMD_SET_SLEEP_TIME:
 		return "set glass sleep time"
 	case CMD_HEART_BEAT:
@@ -106,7 +153,7 @@ func (cmd Command) String() string {
 	case CMD_GET_AMBIENT_LIGHT_ENABLED:
 		return "get if ambient light reporting enabled"
 	case CMD_ENABLE_VSYNC:
-		return "eanble v-sync reporting"
+		return "enable v-sync reporting"
 	case CMD_GET_VSYNC_ENABLED:
 		return "get if v-sync reporting enabled"
 	case CMD_ENABLE_MAGNETOMETER:
@@ -117,6 +164,8 @@ func (cmd Command) String() string {
 		return "enable temperature reporting"
 	case CMD_ENABLE_RGB_CAMERA:
 		return "enable RGB camera"
+	case CMD_GET_RGB_CAMERA_ENABLED:
+		return "get if RGB camera enabled"
 	case CMD_GET_TEMPERATURE_ENABLED:
 		return "get if temperature reporting enabled"
 	case CMD_SET_GLASS_ACTIVATION:
@@ -129,6 +178,22 @@ func (cmd Command) String() string {
 		return "always returns hardcoded string `NrealFW`"
 	case CMD_SET_SDK_WORKS:
 		return "set or unset SDK works"
+	case CMD_ENABLE_KEYSWITCH:
+		return "enable hardware buttons"
+	case CMD_ENABLE_DEFAULT_2D_FUNC:
+		return "enable default 2D function"
+	case CMD_GET_ORBIT_FUNC:
+		return "get orbit function (experimental)"
+	case CMD_SET_ORBIT_FUNC:
+		return "set orbit function (experimental)"
+	case CMD_SET_SUPER_ACTIVE:
+		return "set super active (experimental)"
+	case CMD_GET_APPROACH_PS_VALUE:
+		return "get approach proximity sensor value (experimental)"
+	case CMD_GET_DISTANCE_PS_VALUE:
+		return "get distance proximity sensor value (experimental)"
+	case CMD_RESET_OV580:
+		return "reset OV580 (SLAM cameras and IMU)"
 	case MCU_EVENT_AMBIENT_LIGHT:
 		return "ambient light report event"
 	case MCU_EVENT_KEY_PRESS:
@@ -152,6 +217,161 @@ func (cmd Command) String() string {
 	}
 }
 
+// experimentalCommandNotes keeps what is known so far about commands of unknown purpose,
+// update them as their effects get studied on real glasses.
+var experimentalCommandNotes = map[CommandInstruction]string{
+	CMD_GET_ORBIT_FUNC:   "purpose unknown, returns the orbit function state",
+	CMD_SET_ORBIT_FUNC:   "input 0x0b opens the orbit function, any other input closes it; no visible effect documented yet",
+	CMD_SET_SUPER_ACTIVE: "input '0'/'1', purpose unknown; no known command to read it back",
+	// the proximity sensor only reports near/away events, these may be its thresholds rather than live readings
+	CMD_GET_APPROACH_PS_VALUE: "purpose unknown, returns an integer string, 130 by default on the glass it was found on",
+	CMD_GET_DISTANCE_PS_VALUE: "purpose unknown, returns an integer string, 110 by default on the glass it was found on",
+}
+
+// IsExperimental tells if the command is of unknown purpose, see Notes for what is known about it.
+func (cmd Command) IsExperimental() bool {
+	_, ok := experimentalCommandNotes[cmd.instruction]
+	return ok
+}
+
+// Notes returns what is known about an experimental command, empty for others.
+func (cmd Command) Notes() string {
+	return experimentalCommandNotes[cmd.instruction]
+}
+
+// DangerLevel tells how risky it is to send a command to the glass.
+type DangerLevel int
+
+const (
+	// DANGER_LEVEL_UNKNOWN is for commands not found in the protocol table, which should be treated with care
+	DANGER_LEVEL_UNKNOWN DangerLevel = iota
+	// DANGER_LEVEL_SAFE is for read-only commands
+	DANGER_LEVEL_SAFE
+	// DANGER_LEVEL_STATE_CHANGING is for commands changing glass settings that can be reverted
+	DANGER_LEVEL_STATE_CHANGING
+	// DANGER_LEVEL_DESTRUCTIVE is for commands that may brick the glass or wipe its stored data
+	DANGER_LEVEL_DESTRUCTIVE
+)
+
+func (level DangerLevel) String() string {
+	switch level {
+	case DANGER_LEVEL_SAFE:
+		return "safe"
+	case DANGER_LEVEL_STATE_CHANGING:
+		return "state changing"
+	case DANGER_LEVEL_DESTRUCTIVE:
+		return "destructive"
+	default:
+		return "unknown"
+	}
+}
+
+// destructiveMCUCommands are taken from the protocol notes at the bottom of this file.
+var destructiveMCUCommands = map[Command]struct{}{
+	{Type: 0x31, ID: 0x41}: {}, // clear EEPROM value
+	{Type: 0x31, ID: 0x47}: {}, // set EEPROM 0x27
+	{Type: 0x31, ID: 0x50}: {}, // set EEPROM 0x95
+	{Type: 0x31, ID: 0x52}: {}, // reboot glass
+	{Type: 0x31, ID: 0x58}: {}, // update display firmware, bricked dev glasses before
+	{Type: 0x40, ID: 0x38}: {}, // MCU B jump to A, for firmware update
+	{Type: 0x40, ID: 0x39}: {}, // MCU update firmware on A start
+	{Type: 0x40, ID: 0x41}: {}, // set EEPROM 0x43
+	{Type: 0x40, ID: 0x52}: {}, // MCU A jump to B, for firmware update
+	{Type: 0x40, ID: 0x53}: {}, // set EEPROM 0x110
+}
+
+// safeMCUCommands are read-only commands that don't share the 0x33 "get" command type.
+var safeMCUCommands = map[Command]struct{}{
+	{Type: 0x54, ID: 0x46}: {}, // get glass error num
+	{Type: 0x54, ID: 0x55}: {}, // get OLED brightness brit
+}
+
+// mappedMCUCommands are the commands GetFirmwareIndependentCommand and getCommand map an instruction to, on any known
+// firmware, the protocol table commands are checked against. Experimental instructions are left out, so their commands
+// stay DANGER_LEVEL_UNKNOWN until their purpose is known.
+var mappedMCUCommands = sync.OnceValue(func() map[Command]struct{} {
+	mapped := map[Command]struct{}{}
+	for instruction := CMD_GET_BRIGHTNESS_LEVEL; instruction < MCU_EVENT_AMBIENT_LIGHT; instruction++ {
+		if _, ok := experimentalCommandNotes[instruction]; ok {
+			continue
+		}
+		if command := GetFirmwareIndependentCommand(instruction); command != nil {
+			mapped[Command{Type: command.Type, ID: command.ID}] = struct{}{}
+		}
+		for _, firmware := range knownFirmware {
+			mcu := &xrealLightMCU{glassFirmware: firmware.Version}
+			if command := mcu.getCommand(instruction); command != nil {
+				mapped[Command{Type: command.Type, ID: command.ID}] = struct{}{}
+			}
+		}
+	}
+	return mapped
+})
+
+// GetMCUCommandDangerLevel looks up the command in the protocol table to tell how risky it is to send. Commands the
+// driver does not map an instruction to, or only to an experimental one, nor listed as safe or destructive, are
+// DANGER_LEVEL_UNKNOWN whatever their type.
+func GetMCUCommandDangerLevel(command *Command) DangerLevel {
+	key := Command{Type: command.Type, ID: command.ID}
+	if _, ok := destructiveMCUCommands[key]; ok {
+		return DANGER_LEVEL_DESTRUCTIVE
+	}
+	if _, ok := safeMCUCommands[key]; ok {
+		return DANGER_LEVEL_SAFE
+	}
+	if _, ok := mappedMCUCommands()[key]; !ok {
+		return DANGER_LEVEL_UNKNOWN
+	}
+	switch command.Type {
+	case 0x33:
+		return DANGER_LEVEL_SAFE
+	case 0x31, 0x40, 0x46, 0x54:
+		return DANGER_LEVEL_STATE_CHANGING
+	default:
+		return DANGER_LEVEL_UNKNOWN
+	}
+}
+
+// GetOV580CommandDangerLevel looks up the command in the protocol table to tell how risky it is to send.
+func GetOV580CommandDangerLevel(command *Command) DangerLevel {
+	switch {
+	case command.Equals(GetFirmwareIndependentCommand(OV580_GET_CALIBRATION_FILE_LENGTH)),
+		command.Equals(GetFirmwareIndependentCommand(OV580_GET_CALIBRATION_FILE_PART)):
+		return DANGER_LEVEL_SAFE
+	case command.Equals(GetFirmwareIndependentCommand(OV580_ENABLE_IMU_STREAM)):
+		return DANGER_LEVEL_STATE_CHANGING
+	default:
+		return DANGER_LEVEL_UNKNOWN
+	}
+}
+
+// GetDevCommandDangerLevel parses the input for DevExecuteAndRead the same way it does and tells how risky it is to send.
+func GetDevCommandDangerLevel(device string, input []string) DangerLevel {
+	if len(input) < 2 {
+		return DANGER_LEVEL_UNKNOWN
+	}
+
+	switch device {
+	case "mcu":
+		if len(input[0]) == 0 || len(input[1]) != 1 {
+			return DANGER_LEVEL_UNKNOWN
+		}
+		return GetMCUCommandDangerLevel(&Command{Type: input[0][0], ID: input[1][0]})
+	case "ov580":
+		commandType, err := hexStringToBytes(input[0])
+		if err != nil || len(commandType) == 0 {
+			return DANGER_LEVEL_UNKNOWN
+		}
+		commandID, err := hexStringToBytes(input[1])
+		if err != nil || len(commandID) == 0 {
+			return DANGER_LEVEL_UNKNOWN
+		}
+		return GetOV580CommandDangerLevel(&Command{Type: commandType[0], ID: commandID[0]})
+	default:
+		return DANGER_LEVEL_UNKNOWN
+	}
+}
+
 func GetFirmwareIndependentCommand(instruction CommandInstruction) *Command {
 	var command *Command
 
@@ -189,21 +409,37 @@ func GetFirmwareIndependentCommand(instruction CommandInstruction) *Command {
 		command = &Command{Type: 0x31, ID: 0x65}
 	case CMD_GET_GLASS_ACTIVATION_TIME:
 		command = &Command{Type: 0x33, ID: 0x66}
+	case CMD_GET_RGB_CAMERA_ENABLED:
+		command = &Command{Type: 0x33, ID: 0x68}
 	case CMD_ENABLE_RGB_CAMERA:
 		command = &Command{Type: 0x31, ID: 0x68}
-	case CMD_SET_SLEEP_TIME:
+	case CMD_GET_SLEEP_TIME:
+		command = &Command{Type: 0x33, ID: 0x51}
+	case CMD_SET_SLEEP_TIME: // input is integer that's larger than 20, in seconds
 		command = &Command{Type: 0x31, ID: 0x51}
 	case CMD_GET_BRIGHTNESS_LEVEL:
 		command = &Command{Type: 0x33, ID: 0x31}
 	case CMD_SET_BRIGHTNESS_LEVEL:
 		// another option is Command{Type: 0x31, ID: 0x59}, but upon testing it doesn't do what's expected in newer firmware, see https://github.com/badicsalex/ar-drivers-rs/issues/14#issuecomment-2148616976
 		command = &Command{Type: 0x31, ID: 0x31}
+	case CMD_GET_DUTY:
+		command = &Command{Type: 0x33, ID: 0x4d}
+	case CMD_SET_DUTY: // affects display brightness on top of the brightness level, input is integer 0-100
+		command = &Command{Type: 0x31, ID: 0x4d}
+	case CMD_GET_OLED_BRIGHTNESS_LEVEL:
+		command = &Command{Type: 0x33, ID: 0x62}
+	case CMD_SET_OLED_BRIGHTNESS_LEVEL: // input '0'/'1'
+		command = &Command{Type: 0x31, ID: 0x62}
+	case CMD_GET_OLED_BRIGHTNESS_BRIT:
+		command = &Command{Type: 0x54, ID: 0x55}
 	case CMD_GET_SERIAL_NUMBER:
 		command = &Command{Type: 0x33, ID: 0x43}
 	case CMD_GET_STOCK_FIRMWARE_VERSION:
 		command = &Command{Type: 0x33, ID: 0x30}
 	case CMD_SET_SDK_WORKS:
 		command = &Command{Type: 0x40, ID: 0x33}
+	case CMD_RESET_OV580: // untested on real glasses so far
+		command = &Command{Type: 0x31, ID: 0x54}
 	case MCU_EVENT_AMBIENT_LIGHT:
 		command = &Command{Type: 0x35, ID: 0x4c}
 	case MCU_EVENT_KEY_PRESS:
@@ -267,6 +503,48 @@ func (l *xrealLightMCU) getCommand(instruction CommandInstruction) *Command {
 			command = &Command{Type: 0x33, ID: 0x34}
 		default:
 		}
+	case CMD_ENABLE_KEYSWITCH: // input '0'/'1', there is no known command to read it back
+		switch firmwareVersion {
+		case constant.FIRMWARE_05_5_08_059, constant.FIRMWARE_05_1_08_021:
+			command = &Command{Type: 0x40, ID: 0x48}
+		default:
+		}
+	case CMD_ENABLE_DEFAULT_2D_FUNC: // input '0'/'1', there is no known command to read it back
+		switch firmwareVersion {
+		case constant.FIRMWARE_05_5_08_059, constant.FIRMWARE_05_1_08_021:
+			command = &Command{Type: 0x40, ID: 0x46}
+		default:
+		}
+	case CMD_GET_ORBIT_FUNC: // experimental, see experimentalCommandNotes
+		switch firmwareVersion {
+		case constant.FIRMWARE_05_5_08_059, constant.FIRMWARE_05_1_08_021:
+			command = &Command{Type: 0x33, ID: 0x37}
+		default:
+		}
+	case CMD_SET_ORBIT_FUNC: // experimental, see experimentalCommandNotes
+		switch firmwareVersion {
+		case constant.FIRMWARE_05_5_08_059, constant.FIRMWARE_05_1_08_021:
+			command = &Command{Type: 0x40, ID: 0x34}
+		default:
+		}
+	case CMD_SET_SUPER_ACTIVE: // experimental, see experimentalCommandNotes
+		switch firmwareVersion {
+		case constant.FIRMWARE_05_5_08_059, constant.FIRMWARE_05_1_08_021:
+			command = &Command{Type: 0x31, ID: 0x67}
+		default:
+		}
+	case CMD_GET_APPROACH_PS_VALUE: // experimental, see experimentalCommandNotes
+		switch firmwareVersion {
+		case constant.FIRMWARE_05_5_08_059, constant.FIRMWARE_05_1_08_021:
+			command = &Command{Type: 0x33, ID: 0x44}
+		default:
+		}
+	case CMD_GET_DISTANCE_PS_VALUE: // experimental, see experimentalCommandNotes
+		switch firmwareVersion {
+		case constant.FIRMWARE_05_5_08_059, constant.FIRMWARE_05_1_08_021:
+			command = &Command{Type: 0x33, ID: 0x45}
+		default:
+		}
 	default:
 	}
 
@@ -276,6 +554,18 @@ func (l *xrealLightMCU) getCommand(instruction CommandInstruction) *Command {
 	return command
 }
 
+// isInstruction is Command.EqualsInstructionOnFirmware on the firmware of the glass.
+func (l *xrealLightMCU) isInstruction(cmd *Command, instruction CommandInstruction) bool {
+	return cmd != nil && cmd.equalsInstruction(instruction, l.getCommand)
+}
+
+// isResponseTo tells whether cmd is the response to the command of instruction on the firmware of the glass, which
+// has the type of the command plus one and the same ID, e.g. 0x34/0x48 to 0x33/0x48.
+func (l *xrealLightMCU) isResponseTo(cmd *Command, instruction CommandInstruction) bool {
+	command := l.getCommand(instruction)
+	return cmd != nil && command != nil && cmd.Type == command.Type+1 && cmd.ID == command.ID
+}
+
 // var (
 // 	// FIRMWARE_05_1_08_021 only
 // 	// CMD_SET_MAX_BRIGHTNESS_LEVEL     = Command{Type: 0x33, ID: 0x32} // shouldn't do anything, static, does not take any input
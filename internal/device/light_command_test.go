@@ -0,0 +1,36 @@
+package device_test
+
+import (
+	"testing"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestGetDevCommandDangerLevel(t *testing.T) {
+	testCases := []struct {
+		device   string
+		input    []string
+		expected device.DangerLevel
+	}{
+		{"mcu", []string{"3", "C", " "}, device.DANGER_LEVEL_SAFE},              // get serial number
+		{"mcu", []string{"1", "1", "3"}, device.DANGER_LEVEL_STATE_CHANGING},    // set brightness level
+		{"mcu", []string{"1", "X", "1"}, device.DANGER_LEVEL_DESTRUCTIVE},       // update display firmware
+		{"mcu", []string{"@", "R", "1"}, device.DANGER_LEVEL_DESTRUCTIVE},       // MCU A jump to B
+		{"mcu", []string{"T", "U", " "}, device.DANGER_LEVEL_SAFE},              // get OLED brightness brit
+		{"mcu", []string{"9", "9", " "}, device.DANGER_LEVEL_UNKNOWN},           // not in protocol table
+		{"mcu", []string{"@", "4", "1"}, device.DANGER_LEVEL_UNKNOWN},           // set orbit function, experimental
+		{"mcu", []string{"1", "g", "1"}, device.DANGER_LEVEL_UNKNOWN},           // set super active, experimental
+		{"mcu", []string{"3", "D", " "}, device.DANGER_LEVEL_UNKNOWN},           // get approach PS value, experimental
+		{"mcu", []string{"3"}, device.DANGER_LEVEL_UNKNOWN},                     // malformed
+		{"ov580", []string{"02", "14", "00"}, device.DANGER_LEVEL_SAFE},         // get calibration file length
+		{"ov580", []string{"2", "19", "1"}, device.DANGER_LEVEL_STATE_CHANGING}, // enable IMU stream
+		{"ov580", []string{"zz", "19", "1"}, device.DANGER_LEVEL_UNKNOWN},       // malformed hex
+	}
+
+	for _, tc := range testCases {
+		actual := device.GetDevCommandDangerLevel(tc.device, tc.input)
+		if actual != tc.expected {
+			t.Errorf("GetDevCommandDangerLevel(%s, %v) = %s; expected %s", tc.device, tc.input, actual, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,1054 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	hid "github.com/sstallion/go-hid"
+)
+
+const (
+	// XREAL Light MCU
+	XREAL_LIGHT_MCU_VID = uint16(0x0486)
+	XREAL_LIGHT_MCU_PID = uint16(0x573c)
+)
+
+type xrealLightMCU struct {
+	// initialized is read by the background goroutines
+	initialized atomic.Bool
+
+	device hidDevice
+	// devicePath is optional and can be nil if not provided
+	devicePath *string
+	// serialNumber is optional and can be nil if not provided, only used when devicePath is nil
+	serialNumber *string
+
+	// deviceHandlers contains callback funcs for the events from the glass device
+	deviceHandlers *DeviceHandlers
+
+	// glassFirmware is obtained from mcuDevice and used to get the correct commands
+	glassFirmware string
+
+	// onStaleHandle is called from the read loop once staleHandleReadFailures consecutive reads failed
+	onStaleHandle func()
+
+	// keySwitchEnabled and default2DEnabled cache what was last set as the glass has no command to read them back,
+	// nil if unknown
+	keySwitchEnabled *bool
+	default2DEnabled *bool
+	// superActive caches what was last set for the same reason, nil if unknown
+	superActive *bool
+
+	// observer is set when another process controls the glass, so we only poll for events and never write
+	// anything else, see ROLE_OBSERVER
+	observer bool
+	// connectDeadline stops initialize retrying to reach the glass, zero to retry until it responds
+	connectDeadline time.Time
+
+	// sbs is the temporary SBS session of enterSBS
+	sbs sbsSession
+
+	// clock maps MCU timestamps onto the host clock, kept across reconnects as the glass may keep running
+	clock *ClockSync
+
+	// dutyBeforeDisplayOff keeps the display duty to restore on displayOn, empty if the display is on
+	dutyBeforeDisplayOff string
+
+	// retryPolicy is set by SetRetryPolicy, DefaultRetryPolicy if nil
+	retryPolicy atomic.Pointer[RetryPolicy]
+
+	// idle suspends heart beats and slows down polling while the glass is idle, nil if never idle
+	idle *idleMode
+
+	// mutex for thread safety
+	mutex sync.Mutex
+	// waitgroup to wait for multiple goroutines to stop
+	waitgroup sync.WaitGroup
+	// channel to signal heart beat to stop
+	stopHeartBeatChannel chan struct{}
+	// channel to signal packet reading to stop
+	stopReadPacketsChannel chan struct{}
+	// pendingCommands hands command responses from the read loop to the commands waiting for them, nil while
+	// disconnected; atomic as commands may run while disconnecting
+	pendingCommands atomic.Pointer[pendingCommands]
+	// lastHeartBeatResponse is the unix nano time of the last heart beat response, to detect heart beat loss
+	lastHeartBeatResponse atomic.Int64
+}
+
+func (l *xrealLightMCU) connectAndInitialize() error {
+	if err := l.open(); err != nil {
+		return err
+	}
+	return l.initialize()
+}
+
+func (l *xrealLightMCU) getConnectionInfo() *ConnectionInfo {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return hidConnectionInfo(COMPONENT_MCU, l.device, l.devicePath)
+}
+
+// open finds and opens the MCU hid device without writing to it.
+func (l *xrealLightMCU) open() error {
+	devices, err := EnumerateDevices(XREAL_LIGHT_MCU_VID, XREAL_LIGHT_MCU_PID)
+	if err != nil {
+		return fmt.Errorf("failed to enumerate MCU hid devices: %w", err)
+	}
+
+	if len(devices) == 0 {
+		return checkBeam(fmt.Errorf("no XREAL Light glass MCU found: %v", devices))
+	}
+	for _, device := range devices {
+		slog.Debug(fmt.Sprintf("found XREAL Light glass MCU %s", hidInterfaceOf(device)))
+	}
+
+	// a given path may be of any interface, otherwise one interface is selected per glass
+	if l.devicePath == nil {
+		selector := GetLightMCUInterface()
+		if devices = selectHIDInterfaces(devices, selector); len(devices) == 0 {
+			return fmt.Errorf("no XREAL Light glass MCU interface matches %s", selector)
+		}
+	}
+
+	for _, device := range devices {
+		if l.devicePath == nil {
+			if l.serialNumber != nil {
+				if *l.serialNumber != device.SerialNbr {
+					continue
+				}
+			} else if len(devices) > 1 {
+				slog.Warn(fmt.Sprintf("multiple XREAL Light glass MCUs found, assuming to use the first one: %s", device.Path))
+			}
+			l.devicePath = &device.Path
+		}
+
+		if *l.devicePath != device.Path {
+			continue
+		}
+
+		if device, err := hid.OpenPath(*l.devicePath); err != nil {
+			return fmt.Errorf("failed to open the device path %s: %w", *l.devicePath, err)
+		} else {
+			l.device = device
+		}
+	}
+
+	if l.devicePath == nil {
+		return fmt.Errorf("no XREAL Light glass MCU found with serial number %s", *l.serialNumber)
+	}
+
+	if l.device == nil {
+		return fmt.Errorf("unable to match existing devices to device path %s", *l.devicePath)
+	}
+
+	return nil
+}
+
+func (l *xrealLightMCU) initialize() error {
+	// channels are closed on disconnect, so each connection gets fresh ones
+	l.pendingCommands.Store(newPendingCommands())
+	l.stopHeartBeatChannel = make(chan struct{})
+	l.stopReadPacketsChannel = make(chan struct{})
+	l.lastHeartBeatResponse.Store(time.Now().UnixNano())
+	if l.clock == nil {
+		l.clock = NewClockSync()
+	}
+
+	if l.observer {
+		// the controller keeps the glass alive and configured, we only listen
+		l.waitgroup.Add(1)
+		go l.readPacketsPeriodically(l.stopReadPacketsChannel)
+
+		l.initialized.Store(true)
+		return nil
+	}
+
+	l.waitgroup.Add(1)
+	go l.sendHeartBeatPeriodically(l.stopHeartBeatChannel)
+
+	l.waitgroup.Add(1)
+	go l.readPacketsPeriodically(l.stopReadPacketsChannel)
+
+	// We must ensure we get the firmware version
+	for {
+		firmwareVersion, err := getFirmwareVersion(l)
+		if err == nil {
+			l.glassFirmware = firmwareVersion
+			break
+		}
+		if pastDeadline(l.connectDeadline) {
+			return fmt.Errorf("failed to get firmware version before the connect deadline: %w", err)
+		}
+	}
+
+	if err := checkKnownFirmware(l.glassFirmware); err != nil {
+		l.deviceHandlers.reportError(fmt.Errorf("mcu: %w", err))
+	}
+
+	// disable VSync event reporting by default with best effort
+	l.enableEventReporting(CMD_ENABLE_VSYNC, "0")
+
+	// ensure glass is activated
+	packet, err := l.buildCommandPacket(CMD_SET_GLASS_ACTIVATION, []byte("1"))
+	if err != nil {
+		return err
+	}
+	for {
+		_, err := l.executeAndWaitForResponse(packet)
+		if err == nil {
+			break
+		}
+		if pastDeadline(l.connectDeadline) {
+			return fmt.Errorf("failed to activate glass before the connect deadline: %w", err)
+		}
+	}
+
+	// ensure rgb camera is enabled
+	l.enableEventReporting(CMD_ENABLE_RGB_CAMERA, "1")
+
+	// set sleep time to be larger
+	l.enableEventReporting(CMD_SET_SLEEP_TIME, "300")
+
+	l.initialized.Store(true)
+
+	return nil
+}
+
+func getFirmwareVersion(l *xrealLightMCU) (string, error) {
+	packet, err := l.buildCommandPacket(CMD_GET_FIRMWARE_VERSION)
+	if err != nil {
+		return "", err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return "", fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	return string(response), nil
+}
+
+func (l *xrealLightMCU) sendHeartBeatPeriodically(stop <-chan struct{}) {
+	defer l.waitgroup.Done()
+
+	ticker := time.NewTicker(heartBeatTimeout)
+	defer ticker.Stop()
+
+	// heartBeatLost avoids reporting the same loss repeatedly
+	heartBeatLost := false
+
+	for {
+		select {
+		case <-ticker.C:
+			// don't do anything if not initialized
+			if !l.initialized.Load() {
+				continue
+			}
+			if l.idle.isActive() {
+				// not a loss, so the time without heart beats is not counted once awake
+				l.lastHeartBeatResponse.Store(time.Now().UnixNano())
+				continue
+			}
+			err := runRecovered("mcu heart beat", func() error {
+				packet, err := l.buildCommandPacket(CMD_HEART_BEAT)
+				if err != nil {
+					return err
+				}
+				return l.executeOnly(packet)
+			})
+			if errors.Is(err, ErrPanic) {
+				restart := restartAfterPanic(err)
+				l.deviceHandlers.reportError(err)
+				if restart {
+					l.waitgroup.Add(1)
+					go l.sendHeartBeatPeriodically(stop)
+					return
+				}
+			} else if err != nil {
+				slog.Debug(fmt.Sprintf("failed to send a heartbeat: %v", err))
+			}
+
+			sinceLastResponse := time.Since(time.Unix(0, l.lastHeartBeatResponse.Load()))
+			if sinceLastResponse < heartBeatLostTimeout {
+				heartBeatLost = false
+			} else if !heartBeatLost {
+				heartBeatLost = true
+				l.deviceHandlers.reportError(fmt.Errorf("mcu: %w: no response for %v", ErrHeartBeatLost, sinceLastResponse.Round(time.Millisecond)))
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// readPacketsPeriodically is a goroutine method to read info from XREAL Light MCU HID device
+func (l *xrealLightMCU) readPacketsPeriodically(stop <-chan struct{}) {
+	defer l.waitgroup.Done()
+
+	ticker := time.NewTicker(readPacketFrequency)
+	defer ticker.Stop()
+
+	// readFailing avoids reporting the same read failure every tick
+	readFailing := false
+	readFailures := 0
+	var lastRead time.Time
+
+	for {
+		select {
+		case <-ticker.C:
+			if l.idle.skipRead(&lastRead) {
+				continue
+			}
+			err := runRecovered("mcu read loop", l.readAndProcessPackets)
+			switch {
+			case err == nil:
+				readFailing = false
+				readFailures = 0
+			case errors.Is(err, ErrPanic):
+				restart := restartAfterPanic(err)
+				l.deviceHandlers.reportError(err)
+				if restart {
+					l.waitgroup.Add(1)
+					go l.readPacketsPeriodically(stop)
+					return
+				}
+			case isTimeout(err):
+			case errors.Is(err, ErrReadFailed):
+				if !readFailing {
+					readFailing = true
+					l.deviceHandlers.reportError(fmt.Errorf("mcu: %w", err))
+				}
+				if readFailures++; readFailures >= staleHandleReadFailures && l.onStaleHandle != nil {
+					readFailures = 0
+					l.onStaleHandle()
+				}
+			default:
+				slog.Debug(fmt.Sprintf("readAndProcessPackets(): %v", err))
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (l *xrealLightMCU) executeOnly(command *Packet) error {
+	l.mutex.Lock()
+
+	defer l.mutex.Unlock()
+
+	if l.device == nil {
+		return fmt.Errorf("not connected / initialized")
+	}
+
+	if err := CheckCommandAllowed("mcu", command.Command); err != nil {
+		return err
+	}
+
+	// polling is the only write observers need to receive events, and it does not change any state
+	if l.observer && !l.isInstruction(command.Command, CMD_GET_NREAL_FW_STRING) {
+		return ErrObserver
+	}
+
+	if serialized, err := command.Serialize(); err != nil {
+		return fmt.Errorf("failed to serialize command %v: %w", command, err)
+	} else {
+		if _, err := l.device.Write(serialized[:]); err != nil {
+			return fmt.Errorf("failed to execute on device %v: %w", l.device, err)
+		}
+	}
+
+	if !l.isInstruction(command.Command, CMD_GET_NREAL_FW_STRING) && !l.isInstruction(command.Command, CMD_HEART_BEAT) {
+		trace.record("tx", command)
+	}
+	return nil
+}
+
+// readAndProcessPackets sends a legit packet request to device and receives a set of packets to be processed.
+// This method should be called as frequently as possible to track the time of the packets more accurately.
+func (l *xrealLightMCU) readAndProcessPackets() error {
+	packet, err := l.buildCommandPacket(CMD_GET_NREAL_FW_STRING)
+	if err != nil {
+		return err
+	}
+	// we must send a packet to get all responses, which is a bit lame
+	if err := l.executeOnly(packet); err != nil {
+		return err
+	}
+	for i := 0; i < 32; i++ {
+		var buffer [64]byte
+		_, err := l.device.ReadWithTimeout(buffer[:], readDeviceTimeout)
+		if err != nil {
+			return fmt.Errorf("%w %v: %w", ErrReadFailed, l.device, err)
+		}
+		received := time.Now()
+
+		response := &Packet{}
+
+		if err := response.Deserialize(buffer[:]); err != nil {
+			l.deviceHandlers.reportError(fmt.Errorf("mcu: %w %v (%s): %w", ErrDeserializeFailed, buffer, string(buffer[:]), err))
+			continue
+		}
+
+		if response.Type == PACKET_TYPE_HEART_BEAT_RESPONSE {
+			l.lastHeartBeatResponse.Store(time.Now().UnixNano())
+		}
+
+		if response.Type == PACKET_TYPE_CRC_ERROR || response.Type == PACKET_TYPE_HEART_BEAT_RESPONSE {
+			// skip if CRC error packet or is a heart beat response
+			continue
+		}
+
+		if l.isResponseTo(response.Command, CMD_GET_NREAL_FW_STRING) {
+			// we ignore the legit response to our prior command as it's not useful for us
+			// but we stop here
+			return nil
+		}
+
+		trace.record("rx", response)
+
+		// handle response by checking the Type, we assume only one execution happens at a time
+		if response.Type == PACKET_TYPE_RESPONSE {
+			if l.observer {
+				// responses to the controller reach us too, but nobody here waits for them
+				continue
+			}
+			if !l.pendingCommands.Load().deliver(response) {
+				slog.Debug(fmt.Sprintf("dropped response nobody waits for: %v", response.Command))
+			}
+			continue
+		}
+
+		if response.Type == PACKET_TYPE_MCU && len(response.DeviceTimestamp) > 0 {
+			if deviceTimestamp, err := strconv.ParseUint(string(response.DeviceTimestamp), 16, 64); err == nil {
+				l.clock.AddSample(deviceTimestamp, received)
+			}
+		}
+
+		// handle MCU
+		if response.Type == PACKET_TYPE_MCU && l.initialized.Load() {
+			if l.isInstruction(response.Command, MCU_EVENT_KEY_PRESS) {
+				switch string(response.Payload) {
+				case "UP":
+					l.deviceHandlers.KeyEventHandler(KEY_UP_PRESSED)
+				case "DN":
+					l.deviceHandlers.KeyEventHandler(KEY_DOWN_PRESSED)
+				default:
+					slog.Debug(fmt.Sprintf("Key pressed unrecognized: %s", string(response.Payload)))
+					l.deviceHandlers.KeyEventHandler(KEY_UNKNOWN)
+				}
+			} else if l.isInstruction(response.Command, MCU_EVENT_PROXIMITY) {
+				switch string(response.Payload) {
+				case "away":
+					l.exitSBSOnProximityFar(PROXIMITY_FAR)
+					l.deviceHandlers.ProximityEventHandler(PROXIMITY_FAR)
+				case "near":
+					l.deviceHandlers.ProximityEventHandler(PROXIMITY_NEAR)
+				default:
+					slog.Info(fmt.Sprintf("Proximity unrecognized: %s", string(response.Payload)))
+					l.deviceHandlers.ProximityEventHandler(PROXIMITY_UKNOWN)
+				}
+			} else if l.isInstruction(response.Command, MCU_EVENT_AMBIENT_LIGHT) {
+				if value, err := strconv.ParseUint(string(response.Payload), 10, 16); err != nil {
+					slog.Debug(fmt.Sprintf("Ambient light failed to parse: %s", string(response.Payload)))
+				} else {
+					l.deviceHandlers.AmbientLightEventHandler(uint16(value))
+				}
+			} else if l.isInstruction(response.Command, MCU_EVENT_VSYNC) {
+				l.deviceHandlers.VSyncEventHandler(string(response.Payload))
+			} else if l.isInstruction(response.Command, MCU_EVENT_TEMPERATURE_A) || l.isInstruction(response.Command, MCU_EVENT_TEMPERATURE_B) {
+				l.deviceHandlers.TemperatureEventHandlder(string(response.Payload))
+			} else if l.isInstruction(response.Command, MCU_EVENT_MAGNETOMETER) {
+				vector := ParseMagnetometerVector(response)
+				if !vector.Valid {
+					slog.Debug(fmt.Sprintf("failed to parse magnetometer reading: %s", string(response.Payload)))
+				} else if timestamp, err := l.clock.ToHost(vector.DeviceTimestamp); err == nil {
+					// without the host read jitter
+					vector.Timestamp = timestamp
+				}
+				if transform := mounting.current(); transform != nil && vector.Valid {
+					transform.ApplyToMagnetometer(vector)
+				}
+				l.deviceHandlers.MagnetometerEventHandler(vector)
+			} else {
+				slog.Debug(fmt.Sprintf("got unhandled MCU packet: %v %s", response.Command, string(response.Payload)))
+			}
+			continue
+		}
+
+		slog.Debug(fmt.Sprintf("got unhandled packet: %v from %s", response, string(buffer[:])))
+	}
+
+	return nil
+}
+
+func (l *xrealLightMCU) executeAndWaitForResponse(command *Packet) ([]byte, error) {
+	response, err := l.executeAsync(command).Wait()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(response), nil
+}
+
+// executeAsync sends the command and returns right away, the future resolves once the response arrived.
+func (l *xrealLightMCU) executeAsync(command *Packet) *CommandFuture {
+	l.idle.wake()
+	pending := l.pendingCommands.Load()
+	if pending == nil {
+		return resolvedCommandFuture(fmt.Errorf("not connected / initialized"))
+	}
+
+	end := commands.start(COMPONENT_MCU, *command.Command)
+	responses, err := pending.expect(command.Command)
+	if err == nil {
+		if err = l.executeOnly(command); err != nil {
+			pending.cancel(command.Command, responses)
+		}
+	}
+	if err != nil {
+		end(err)
+		return resolvedCommandFuture(err)
+	}
+
+	future := newCommandFuture()
+	go func() {
+		response, err := pending.await(command.Command, responses, retryMaxAttempts*waitForPacketTimeout)
+		if err != nil {
+			err = fmt.Errorf("failed to get a response for %s: %w", command.String(), err)
+			end(err)
+			future.resolve(nil, err)
+			return
+		}
+		end(nil)
+		future.resolve(response.Payload, nil)
+	}()
+	return future
+}
+
+// buildCommandPacket builds the packet of instruction, failing with ErrUnsupportedCommand if the firmware of the glass
+// has no command for it.
+func (l *xrealLightMCU) buildCommandPacket(instruction CommandInstruction, payload ...[]byte) (*Packet, error) {
+	command := l.getCommand(instruction)
+	if command == nil {
+		return nil, fmt.Errorf("failed to %s: %w, %w %s", Command{instruction: instruction}.String(), ErrUnsupportedCommand, ErrUnsupportedByFirmware, l.glassFirmware)
+	}
+
+	defaultPayload := []byte{' '}
+	if len(payload) > 0 {
+		defaultPayload = payload[0]
+	}
+	return &Packet{
+		Type:      PACKET_TYPE_COMMAND,
+		Command:   command,
+		Payload:   defaultPayload,
+		Timestamp: getTimestampNow(),
+	}, nil
+}
+
+// resetOV580 asks the MCU to reset the OV580, which then re-enumerates on USB.
+func (l *xrealLightMCU) resetOV580() error {
+	packet, err := l.buildCommandPacket(CMD_RESET_OV580)
+	if err != nil {
+		return err
+	}
+	if _, err := l.executeAndWaitForResponse(packet); err != nil {
+		return fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	return nil
+}
+
+func (l *xrealLightMCU) getStockFirmwareVersion() (string, error) {
+	packet, err := l.buildCommandPacket(CMD_GET_STOCK_FIRMWARE_VERSION)
+	if err != nil {
+		return "", err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return "", fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	return string(response), nil
+}
+
+// getDisplayVersion reads CMD_GET_DISPLAY_FIRMWARE or CMD_GET_DISPLAY_HDCP, which are firmware dependent.
+func (l *xrealLightMCU) getDisplayVersion(instruction CommandInstruction) (*DisplayVersion, error) {
+	packet, err := l.buildCommandPacket(instruction)
+	if err != nil {
+		return nil, err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	version, err := ParseDisplayVersion(string(response))
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	return version, nil
+}
+
+func (l *xrealLightMCU) getSerial() (string, error) {
+	packet, err := l.buildCommandPacket(CMD_GET_SERIAL_NUMBER)
+	if err != nil {
+		return "", err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return "", fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	return string(response), nil
+}
+
+func (l *xrealLightMCU) getDisplayMode() (DisplayMode, error) {
+	packet, err := l.buildCommandPacket(CMD_GET_DISPLAY_MODE)
+	if err != nil {
+		return DISPLAY_MODE_UNKNOWN, err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return DISPLAY_MODE_UNKNOWN, fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	if response[0] == '1' {
+		// "1&2D_1080"
+		return DISPLAY_MODE_SAME_ON_BOTH, nil
+	} else if response[0] == '2' {
+		// "2&3D_540"
+		return DISPLAY_MODE_HALF_SBS, nil
+	} else if response[0] == '3' {
+		// "3&3D_1080"
+		return DISPLAY_MODE_STEREO, nil
+	} else if response[0] == '4' {
+		// "4&3D_1080#72"
+		return DISPLAY_MODE_HIGH_REFRESH_RATE, nil
+	}
+	return DISPLAY_MODE_UNKNOWN, fmt.Errorf("unrecognized response: %s", response)
+}
+
+func (l *xrealLightMCU) setDisplayMode(mode DisplayMode) error {
+	var displayMode uint8
+	if mode == DISPLAY_MODE_SAME_ON_BOTH {
+		displayMode = '1'
+	} else if mode == DISPLAY_MODE_HALF_SBS {
+		displayMode = '2'
+	} else if mode == DISPLAY_MODE_STEREO {
+		displayMode = '3'
+	} else if mode == DISPLAY_MODE_HIGH_REFRESH_RATE {
+		displayMode = '4'
+	} else {
+		return fmt.Errorf("unknown display mode: %v", mode)
+	}
+
+	packet, err := l.buildCommandPacket(CMD_SET_DISPLAY_MODE, []byte{displayMode})
+	if err != nil {
+		return err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	if response[0] != displayMode {
+		return fmt.Errorf("failed to %s: want %d got %d", packet.String(), displayMode, response[0])
+	}
+	return nil
+}
+
+func (l *xrealLightMCU) getBrightnessLevel() (string, error) {
+	packet, err := l.buildCommandPacket(CMD_GET_BRIGHTNESS_LEVEL)
+	if err != nil {
+		return "unknown", err
+	}
+	if response, err := l.executeAndWaitForResponse(packet); err != nil {
+		return "unknown", fmt.Errorf("failed to %s: %w", packet.String(), err)
+	} else {
+		return string(response), nil
+	}
+}
+
+func (l *xrealLightMCU) setBrightnessLevel(level string) error {
+	if (len(level) != 1) || (level[0] < '0') || (level[0] > '7') {
+		return fmt.Errorf("invalid level %s, must be single digit 0-7", level)
+	}
+
+	packet, err := l.buildCommandPacket(CMD_SET_BRIGHTNESS_LEVEL, []byte(level))
+	if err != nil {
+		return err
+	}
+	if response, err := l.executeAndWaitForResponse(packet); err != nil {
+		return fmt.Errorf("failed to set brightness level: %w", err)
+	} else if response[0] != level[0] {
+		return fmt.Errorf("failed to set brightness mode: want %s got %s", level, string(response))
+	}
+	return nil
+}
+
+func (l *xrealLightMCU) getSleepTime() (string, error) {
+	packet, err := l.buildCommandPacket(CMD_GET_SLEEP_TIME)
+	if err != nil {
+		return "", err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return "", fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	return string(response), nil
+}
+
+func (l *xrealLightMCU) setSleepTime(seconds string) error {
+	if value, err := strconv.Atoi(seconds); err != nil || value <= MIN_SLEEP_TIME_SECONDS {
+		return fmt.Errorf("invalid sleep time %s, must be integer larger than %d", seconds, MIN_SLEEP_TIME_SECONDS)
+	}
+
+	packet, err := l.buildCommandPacket(CMD_SET_SLEEP_TIME, []byte(seconds))
+	if err != nil {
+		return err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	if string(response) != seconds {
+		return fmt.Errorf("failed to %s: want %s got %s", packet.String(), seconds, string(response))
+	}
+	return nil
+}
+
+func (l *xrealLightMCU) getOLEDBrightnessLevel() (string, error) {
+	packet, err := l.buildCommandPacket(CMD_GET_OLED_BRIGHTNESS_LEVEL)
+	if err != nil {
+		return "", err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return "", fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	return string(response), nil
+}
+
+func (l *xrealLightMCU) setOLEDBrightnessLevel(level string) error {
+	if level != "0" && level != "1" {
+		return fmt.Errorf("invalid OLED brightness level %s, must be 0 or 1", level)
+	}
+
+	packet, err := l.buildCommandPacket(CMD_SET_OLED_BRIGHTNESS_LEVEL, []byte(level))
+	if err != nil {
+		return err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	if response[0] != level[0] {
+		return fmt.Errorf("failed to %s: want %s got %s", packet.String(), level, string(response))
+	}
+	return nil
+}
+
+func (l *xrealLightMCU) getOLEDBrightnessBrit() (string, error) {
+	packet, err := l.buildCommandPacket(CMD_GET_OLED_BRIGHTNESS_BRIT)
+	if err != nil {
+		return "", err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return "", fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	return string(response), nil
+}
+
+// setToggle sends a '0'/'1' toggle that not every firmware is known to support.
+func (l *xrealLightMCU) setToggle(instruction CommandInstruction, enabled bool) error {
+	value := []byte{'0'}
+	if enabled {
+		value[0] = '1'
+	}
+
+	packet, err := l.buildCommandPacket(instruction, value)
+	if err != nil {
+		return err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	if len(response) == 0 || response[0] != value[0] {
+		return fmt.Errorf("failed to %s: want %s got %s", packet.String(), string(value), string(response))
+	}
+	return nil
+}
+
+func (l *xrealLightMCU) setKeySwitchEnabled(enabled bool) error {
+	if err := l.setToggle(CMD_ENABLE_KEYSWITCH, enabled); err != nil {
+		return err
+	}
+	l.keySwitchEnabled = &enabled
+	return nil
+}
+
+func (l *xrealLightMCU) getKeySwitchEnabled() (bool, error) {
+	if l.keySwitchEnabled == nil {
+		return false, fmt.Errorf("hardware buttons state is unknown until set in this session")
+	}
+	return *l.keySwitchEnabled, nil
+}
+
+func (l *xrealLightMCU) setDefault2DEnabled(enabled bool) error {
+	if err := l.setToggle(CMD_ENABLE_DEFAULT_2D_FUNC, enabled); err != nil {
+		return err
+	}
+	l.default2DEnabled = &enabled
+	return nil
+}
+
+func (l *xrealLightMCU) getDefault2DEnabled() (bool, error) {
+	if l.default2DEnabled == nil {
+		return false, fmt.Errorf("default 2D function state is unknown until set in this session")
+	}
+	return *l.default2DEnabled, nil
+}
+
+// getOrbitFunction returns the raw response as the meaning of the orbit function state is not known yet.
+func (l *xrealLightMCU) getOrbitFunction() (string, error) {
+	packet, err := l.buildCommandPacket(CMD_GET_ORBIT_FUNC)
+	if err != nil {
+		return "", err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return "", fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	return string(response), nil
+}
+
+// setOrbitFunction sends 0x0b to open the orbit function and 0x00 to close it.
+// The response is only logged, as it is not known what the glass echoes back.
+func (l *xrealLightMCU) setOrbitFunction(open bool) error {
+	value := []byte{0x00}
+	if open {
+		value[0] = 0x0b
+	}
+
+	packet, err := l.buildCommandPacket(CMD_SET_ORBIT_FUNC, value)
+	if err != nil {
+		return err
+	}
+	slog.Warn(fmt.Sprintf("sending experimental command %s: %s", packet.Command.String(), packet.Command.Notes()))
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	slog.Debug(fmt.Sprintf("%s responded with %v", packet.Command.String(), response))
+	return nil
+}
+
+func (l *xrealLightMCU) setSuperActive(enabled bool) error {
+	slog.Warn(fmt.Sprintf("sending experimental command %s: %s", Command{instruction: CMD_SET_SUPER_ACTIVE}.String(), Command{instruction: CMD_SET_SUPER_ACTIVE}.Notes()))
+	if err := l.setToggle(CMD_SET_SUPER_ACTIVE, enabled); err != nil {
+		return err
+	}
+	l.superActive = &enabled
+	return nil
+}
+
+func (l *xrealLightMCU) getSuperActive() (bool, error) {
+	if l.superActive == nil {
+		return false, fmt.Errorf("super active state is unknown until set in this session")
+	}
+	return *l.superActive, nil
+}
+
+func (l *xrealLightMCU) getRGBCameraEnabled() (bool, error) {
+	packet, err := l.buildCommandPacket(CMD_GET_RGB_CAMERA_ENABLED)
+	if err != nil {
+		return false, err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return false, fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	switch string(response) {
+	case "0":
+		return false, nil
+	case "1":
+		return true, nil
+	default:
+		return false, fmt.Errorf("failed to %s: want 0 or 1 got %s", packet.String(), string(response))
+	}
+}
+
+// getCapabilities tells which firmware dependent commands are supported by the connected glass.
+func (l *xrealLightMCU) getCapabilities() (*Capabilities, error) {
+	if l.device == nil {
+		return nil, fmt.Errorf("glass device is not connected yet")
+	}
+	capabilities := &Capabilities{
+		Firmware:      l.glassFirmware,
+		KnownFirmware: IsKnownFirmware(l.glassFirmware),
+		KeySwitch:     l.getCommand(CMD_ENABLE_KEYSWITCH) != nil,
+		Default2D:     l.getCommand(CMD_ENABLE_DEFAULT_2D_FUNC) != nil,
+		OrbitFunction: l.getCommand(CMD_GET_ORBIT_FUNC) != nil && l.getCommand(CMD_SET_ORBIT_FUNC) != nil,
+		SuperActive:   l.getCommand(CMD_SET_SUPER_ACTIVE) != nil,
+	}
+
+	// best effort, e.g. observers cannot ask
+	if enabled, err := l.getRGBCameraEnabled(); err == nil {
+		capabilities.RGBCameraEnabled = enabled
+	} else {
+		slog.Debug(fmt.Sprintf("capabilities: %v", err))
+	}
+	return capabilities, nil
+}
+
+func (l *xrealLightMCU) getDuty() (string, error) {
+	packet, err := l.buildCommandPacket(CMD_GET_DUTY)
+	if err != nil {
+		return "", err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return "", fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	return string(response), nil
+}
+
+func (l *xrealLightMCU) setDuty(duty string) error {
+	if value, err := strconv.Atoi(duty); err != nil || value < 0 || value > 100 {
+		return fmt.Errorf("invalid duty %s, must be integer 0-100", duty)
+	}
+
+	packet, err := l.buildCommandPacket(CMD_SET_DUTY, []byte(duty))
+	if err != nil {
+		return err
+	}
+	response, err := l.executeAndWaitForResponse(packet)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", packet.String(), err)
+	}
+	if string(response) != duty {
+		return fmt.Errorf("failed to %s: want %s got %s", packet.String(), duty, string(response))
+	}
+	return nil
+}
+
+// displayOff blanks the display by setting duty to 0, so the brightness level stays untouched.
+func (l *xrealLightMCU) displayOff() error {
+	if l.dutyBeforeDisplayOff != "" {
+		return nil
+	}
+
+	duty, err := l.getDuty()
+	if err != nil {
+		return fmt.Errorf("failed to turn display off: %w", err)
+	}
+	if err := l.setDuty("0"); err != nil {
+		return fmt.Errorf("failed to turn display off: %w", err)
+	}
+	l.dutyBeforeDisplayOff = duty
+	return nil
+}
+
+// displayOn restores the duty saved by displayOff.
+func (l *xrealLightMCU) displayOn() error {
+	if l.dutyBeforeDisplayOff == "" {
+		return nil
+	}
+
+	if err := l.setDuty(l.dutyBeforeDisplayOff); err != nil {
+		return fmt.Errorf("failed to turn display on: %w", err)
+	}
+	l.dutyBeforeDisplayOff = ""
+	return nil
+}
+
+func (l *xrealLightMCU) enableEventReporting(instruction CommandInstruction, enabled string) error {
+	packet, err := l.buildCommandPacket(instruction, []byte(enabled))
+	if err != nil {
+		return err
+	}
+	var response []byte
+	err = getRetryPolicy(&l.retryPolicy).Do(func() (err error) {
+		response, err = l.executeAndWaitForResponse(packet)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set event reporting: %w", err)
+	}
+	if response[0] != enabled[0] {
+		return fmt.Errorf("failed to set event reporting: want %s got %s", enabled, string(response))
+	}
+	l.idle.reported(instruction, enabled == "1")
+	return nil
+}
+
+func (l *xrealLightMCU) disconnect() error {
+	l.initialized.Store(false)
+
+	// goroutines first, as they use the device and deliver responses; channels are nil if never initialized and
+	// reset once closed, so disconnecting again is safe
+	closeStopChannel(&l.stopHeartBeatChannel)
+	closeStopChannel(&l.stopReadPacketsChannel)
+
+	l.waitgroup.Wait()
+
+	if pending := l.pendingCommands.Swap(nil); pending != nil {
+		pending.close()
+	}
+
+	// commands may still be sent meanwhile, see executeOnly
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.device == nil {
+		return nil
+	}
+
+	err := l.device.Close()
+	if err == nil {
+		l.device = nil
+	}
+
+	// also cleans up whatever is initialized
+	l.glassFirmware = ""
+	l.dutyBeforeDisplayOff = ""
+	l.keySwitchEnabled = nil
+	l.default2DEnabled = nil
+	l.superActive = nil
+
+	return err
+}
+
+func (l *xrealLightMCU) devExecuteAndRead(input []string) {
+	if len(input) != 3 {
+		slog.Error(fmt.Sprintf("wrong input format: want [CommandType CommandID Payload] got %v", input))
+		return
+	}
+
+	if len(input[1]) != 1 {
+		slog.Error(fmt.Sprintf("wrong CommandID format: want ASCII char, got %s", input[1]))
+		return
+	}
+
+	command := Command{Type: input[0][0], ID: input[1][0]}
+	response, err := l.executeRaw(command, []byte(input[2]))
+	if err != nil {
+		slog.Error(fmt.Sprintf("%v : '%s' failed: %v", command, string(response), err))
+		return
+	}
+	slog.Info(fmt.Sprintf("%v : '%s'", command, string(response)))
+}
+
+// executeRaw sends command with payload, a space if empty as every command carries one, and returns the payload of
+// its response.
+func (l *xrealLightMCU) executeRaw(command Command, payload []byte) ([]byte, error) {
+	if len(payload) == 0 {
+		payload = []byte{' '}
+	}
+	packet := &Packet{
+		Type:      PACKET_TYPE_COMMAND,
+		Command:   &command,
+		Payload:   payload,
+		Timestamp: getTimestampNow(),
+	}
+	return l.executeAndWaitForResponse(packet)
+}
@@ -0,0 +1,153 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"xreal-light-xr-go/constant"
+
+	hid "github.com/sstallion/go-hid"
+	"go.uber.org/goleak"
+)
+
+func TestBuildCommandPacketUnsupportedCommand(t *testing.T) {
+	// the HDCP version has no command on firmware not known to the protocol table
+	mcu := &xrealLightMCU{glassFirmware: "unknown"}
+
+	packet, err := mcu.buildCommandPacket(CMD_GET_DISPLAY_HDCP)
+	if packet != nil || !errors.Is(err, ErrUnsupportedCommand) || !errors.Is(err, ErrUnsupportedByFirmware) {
+		t.Errorf("buildCommandPacket(CMD_GET_DISPLAY_HDCP) = %v, %v, want ErrUnsupportedCommand", packet, err)
+	}
+
+	if packet, err := mcu.buildCommandPacket(CMD_HEART_BEAT); err != nil || packet.Command == nil {
+		t.Errorf("buildCommandPacket(CMD_HEART_BEAT) = %v, %v, want a packet", packet, err)
+	}
+}
+
+func TestIsInstructionFirmwareDependent(t *testing.T) {
+	testCases := []struct {
+		firmware    string
+		command     Command
+		instruction CommandInstruction
+		expected    bool
+	}{
+		{constant.FIRMWARE_05_5_08_059, Command{Type: 0x33, ID: 0x48}, CMD_GET_DISPLAY_HDCP, true},
+		{constant.FIRMWARE_05_1_08_021, Command{Type: 0x33, ID: 0x34}, CMD_GET_DISPLAY_HDCP, true},
+		// the same command is the display firmware on the later firmware
+		{constant.FIRMWARE_05_5_08_059, Command{Type: 0x33, ID: 0x34}, CMD_GET_DISPLAY_HDCP, false},
+		{constant.FIRMWARE_05_5_08_059, Command{Type: 0x33, ID: 0x34}, CMD_GET_DISPLAY_FIRMWARE, true},
+		{"unknown", Command{Type: 0x33, ID: 0x48}, CMD_GET_DISPLAY_HDCP, false},
+		// firmware independent instructions match on any firmware
+		{"unknown", Command{Type: 0x35, ID: 0x4b}, MCU_EVENT_KEY_PRESS, true},
+		{constant.FIRMWARE_05_5_08_059, Command{Type: 0x35, ID: 0x4b}, MCU_EVENT_PROXIMITY, false},
+	}
+
+	for _, tc := range testCases {
+		mcu := &xrealLightMCU{glassFirmware: tc.firmware}
+		if actual := mcu.isInstruction(&tc.command, tc.instruction); actual != tc.expected {
+			t.Errorf("isInstruction(%v, %s) on %s = %t; expected %t", tc.command, Command{instruction: tc.instruction}, tc.firmware, actual, tc.expected)
+		}
+		if actual := tc.command.EqualsInstructionOnFirmware(tc.instruction, tc.firmware); actual != tc.expected {
+			t.Errorf("EqualsInstructionOnFirmware(%s, %s) of %v = %t; expected %t", Command{instruction: tc.instruction}, tc.firmware, tc.command, actual, tc.expected)
+		}
+	}
+
+	// commands built for an instruction know it, whatever their type and ID
+	mcu := &xrealLightMCU{glassFirmware: constant.FIRMWARE_05_5_08_059}
+	if command := mcu.getCommand(CMD_GET_DISPLAY_HDCP); !mcu.isInstruction(command, CMD_GET_DISPLAY_HDCP) || command.EqualsInstructionOnFirmware(CMD_GET_DISPLAY_FIRMWARE, constant.FIRMWARE_05_5_08_059) {
+		t.Errorf("built command %v does not match its instruction only", command)
+	}
+	if mcu.isInstruction(nil, CMD_GET_DISPLAY_HDCP) {
+		t.Errorf("isInstruction(nil) = true; expected false")
+	}
+}
+
+func TestIsResponseTo(t *testing.T) {
+	mcu := &xrealLightMCU{glassFirmware: constant.FIRMWARE_05_5_08_059}
+
+	if !mcu.isResponseTo(&Command{Type: 0x34, ID: 0x48}, CMD_GET_DISPLAY_HDCP) {
+		t.Errorf("isResponseTo(0x34/0x48, CMD_GET_DISPLAY_HDCP) = false; expected true")
+	}
+	if !mcu.isResponseTo(&Command{Type: 0x41, ID: 0x4b}, CMD_HEART_BEAT) {
+		t.Errorf("isResponseTo(0x41/0x4b, CMD_HEART_BEAT) = false; expected true")
+	}
+	if mcu.isResponseTo(&Command{Type: 0x33, ID: 0x48}, CMD_GET_DISPLAY_HDCP) {
+		t.Errorf("isResponseTo(0x33/0x48, CMD_GET_DISPLAY_HDCP) = true; expected false for the command itself")
+	}
+
+	mcu = &xrealLightMCU{glassFirmware: "unknown"}
+	if mcu.isResponseTo(&Command{Type: 0x34, ID: 0x48}, CMD_GET_DISPLAY_HDCP) {
+		t.Errorf("isResponseTo(0x34/0x48, CMD_GET_DISPLAY_HDCP) = true; expected false without a command on the firmware")
+	}
+}
+
+func TestIsTimeout(t *testing.T) {
+	testCases := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("%w fake: %w", ErrReadFailed, hid.ErrTimeout), true},
+		{fmt.Errorf("%w fake: %w", ErrReadFailed, errors.New("hid_read_timeout: error polling")), false},
+		{errors.New("failed to get response: timed out"), false},
+	}
+	for _, tc := range testCases {
+		if got := isTimeout(tc.err); got != tc.want {
+			t.Errorf("isTimeout(%v) = %t, want %t", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestMCUReportsBackgroundErrors(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	reported := make(chan error, 16)
+	light := NewXREALLight(nil, nil).(*xrealLight)
+	light.SetErrorHandler(func(err error) {
+		select {
+		case reported <- err:
+		default:
+		}
+	})
+
+	fake := &fakeHIDDevice{}
+	mcu := light.mcu
+	mcu.observer = true
+	mcu.device = fake
+	if err := mcu.initialize(); err != nil {
+		t.Fatalf("initialize() = %v", err)
+	}
+	defer mcu.disconnect()
+
+	waitFor := func(want error) {
+		t.Helper()
+		deadline := time.After(2 * time.Second)
+		for {
+			select {
+			case err := <-reported:
+				if errors.Is(err, want) {
+					return
+				}
+				if errors.Is(err, ErrReadFailed) {
+					t.Errorf("reported %v before %v, timeouts must not be reported", err, want)
+				}
+			case <-deadline:
+				t.Fatalf("no error wrapping %v reported", want)
+			}
+		}
+	}
+
+	// reads time out meanwhile, which is not reported
+	fake.queue("garbage")
+	waitFor(ErrDeserializeFailed)
+
+	fake.setReadError(errors.New("hid_read_timeout: error polling: No such device"))
+	waitFor(ErrReadFailed)
+
+	// the glass stops answering heart beats, observers do not send any so start it like a controller would
+	mcu.lastHeartBeatResponse.Store(time.Now().Add(-2 * heartBeatLostTimeout).UnixNano())
+	mcu.waitgroup.Add(1)
+	go mcu.sendHeartBeatPeriodically(mcu.stopHeartBeatChannel)
+	waitFor(ErrHeartBeatLost)
+}
@@ -0,0 +1,666 @@
+package device
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	hid "github.com/sstallion/go-hid"
+)
+
+const (
+	// XREAL Light SLAM Camera and IMU (should be the same as SLAM camera)
+	XREAL_LIGHT_OV580_VID = uint16(0x05a9)
+	XREAL_LIGHT_OV580_PID = uint16(0x0680)
+
+	// ov580RestartAfterReadFailures is how many consecutive reads may fail before the OV580 is reopened, about
+	// half a second at readPacketFrequency
+	ov580RestartAfterReadFailures = 50
+)
+
+type xrealLightOV580 struct {
+	// initialized is read by the background goroutines
+	initialized atomic.Bool
+
+	device hidDevice
+	// devicePath is optional and can be nil if not provided
+	devicePath *string
+
+	// deviceHandlers contains callback funcs for the events from the glass device
+	deviceHandlers *DeviceHandlers
+
+	// bias values for accelerometer and gyro
+	accelerometerBias *AccelerometerVector
+	gyroscopeBias     *GyroscopeVector
+
+	// observer is set when another process controls the glass, so we never write and instead read the calibration
+	// shared through control, see ROLE_OBSERVER
+	observer bool
+	// control is the lock of the glass, nil if the OV580 is used on its own
+	control *controlLock
+	// connectDeadline stops initialize retrying to read the calibration file, zero to retry until it succeeds
+	connectDeadline time.Time
+	// calibrationCache skips downloading an unchanged calibration file on reconnect, nil to always download it
+	calibrationCache *calibrationCache
+
+	// imuActivity tracks whether the IMU stream is enabled and when its last sample arrived, see streamWatchdog
+	imuActivity streamActivity
+
+	// retryPolicy is set by SetRetryPolicy, DefaultRetryPolicy if nil
+	retryPolicy atomic.Pointer[RetryPolicy]
+
+	// idle slows down polling while the glass is idle, shared with the MCU, nil if never idle
+	idle *idleMode
+
+	// mutex for thread safety
+	mutex sync.Mutex
+	// fileMutex serializes file transfers, see readFile
+	fileMutex sync.Mutex
+	// commandMutex serializes commands from sending to receiving the response, as responses carry no command to tell
+	// which one they answer
+	commandMutex sync.Mutex
+	// commandResponses hands command responses from the read loop to executeAndWaitForResponse, nil while
+	// disconnected; atomic as commands may run while disconnecting
+	commandResponses atomic.Pointer[responseQueue[[]byte]]
+	// waitgroup to wait for multiple goroutines to stop
+	waitgroup sync.WaitGroup
+	// channel to signal data reading to stop
+	stopReadDataChannel chan struct{}
+}
+
+func (l *xrealLightOV580) connectAndInitialize() error {
+	devices, err := EnumerateDevices(XREAL_LIGHT_OV580_VID, XREAL_LIGHT_OV580_PID)
+	if err != nil {
+		return fmt.Errorf("failed to enumerate OV580 hid devices: %w", err)
+	}
+
+	if len(devices) == 0 {
+		return checkOV580State()
+	}
+
+	for _, device := range devices {
+		if l.devicePath == nil {
+			if len(devices) > 1 {
+				slog.Warn(fmt.Sprintf("multiple XREAL Light glass OV580s found, assuming to use the first one: %s", device.Path))
+			}
+			l.devicePath = &device.Path
+		}
+
+		if *l.devicePath != device.Path {
+			continue
+		}
+
+		if device, err := hid.OpenPath(*l.devicePath); err != nil {
+			return fmt.Errorf("failed to open the device path %s: %w", *l.devicePath, err)
+		} else {
+			l.device = device
+		}
+	}
+
+	return l.initialize()
+}
+
+func (l *xrealLightOV580) initialize() error {
+	// channels are closed on disconnect, so each connection gets fresh ones
+	l.commandResponses.Store(newResponseQueue[[]byte]())
+	l.stopReadDataChannel = make(chan struct{})
+
+	l.waitgroup.Add(1)
+	go l.readPacketsPeriodically(l.stopReadDataChannel)
+
+	if l.observer {
+		fileBytes, err := l.control.sharedCalibration()
+		if err == nil {
+			err = runRecovered("ov580 shared calibration", func() error { return l.parseCalibrationConfigs(fileBytes) })
+		}
+		if err != nil {
+			// IMU events are dropped until initialized
+			slog.Warn(fmt.Sprintf("no calibration shared by the controlling process, IMU events are unavailable: %v", err))
+			return nil
+		}
+		l.initialized.Store(true)
+		return nil
+	}
+
+	// ensure we get calibration file
+	for {
+		err := l.readAndParseCalibrationConfigs()
+		if err == nil {
+			break
+		}
+		if pastDeadline(l.connectDeadline) {
+			return fmt.Errorf("failed to read calibration file before the connect deadline: %w", err)
+		}
+		slog.Error(fmt.Sprintf("readAndParseCalibrationConfigs() failed, retrying: %v", err))
+	}
+
+	l.initialized.Store(true)
+	return nil
+}
+
+// getInfo reads the USB descriptor of the OV580, as no vendor command to query its versions is known yet.
+func (l *xrealLightOV580) getInfo() (*OV580Info, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.device == nil {
+		return nil, fmt.Errorf("not connected / initialized")
+	}
+
+	info, err := l.device.GetDeviceInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OV580 device info: %w", err)
+	}
+	return &OV580Info{
+		Manufacturer:   info.MfrStr,
+		Product:        info.ProductStr,
+		SerialNumber:   info.SerialNbr,
+		Release:        fmt.Sprintf("%x.%02x", info.ReleaseNbr>>8, info.ReleaseNbr&0xff),
+		HasCalibration: l.gyroscopeBias != nil && l.accelerometerBias != nil,
+	}, nil
+}
+
+// readAndParseCalibrationConfigs downloads the calibration file, unless the file cached for the glass has the same
+// length and first part.
+func (l *xrealLightOV580) getConnectionInfo() *ConnectionInfo {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return hidConnectionInfo(COMPONENT_OV580, l.device, l.devicePath)
+}
+
+func (l *xrealLightOV580) readAndParseCalibrationConfigs() error {
+	// disable IMU stream first to reduce noise
+	if err := l.enableEventReporting(OV580_ENABLE_IMU_STREAM, "0"); err != nil {
+		return err
+	}
+
+	serial := l.getSerial()
+	firstPart := true
+	file, err := l.readFile(OV580_CALIBRATION_FILE_ID, func(file *OV580File, total int) bool {
+		// stops after the first part if the cached file matches
+		cached := firstPart && l.loadCachedCalibration(serial, file.Length, file.Data)
+		firstPart = false
+		return cached
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read calibration file: %w", err)
+	}
+	if file == nil {
+		return nil
+	}
+	fileLength, fileBytes := file.Length, file.Data
+
+	// enable IMU stream
+	// if err := l.enableEventReporting(OV580_ENABLE_IMU_STREAM, "1"); err != nil {
+	// 	return err
+	// }
+
+	if err := l.parseCalibrationConfigs(fileBytes); err != nil {
+		return err
+	}
+
+	if l.calibrationCache != nil && serial != "" {
+		if err := l.calibrationCache.store(serial, newCachedCalibration(fileLength, fileBytes)); err != nil {
+			slog.Debug(fmt.Sprintf("failed to cache calibration file: %v", err))
+		}
+	}
+	if l.control != nil {
+		l.control.shareCalibration(fileBytes)
+	}
+	return nil
+}
+
+// loadCachedCalibration parses the calibration cached for the glass if it matches the file length and first part
+// read from it, and tells whether it did.
+func (l *xrealLightOV580) loadCachedCalibration(serial string, fileLength []byte, firstPart []byte) bool {
+	if l.calibrationCache == nil || serial == "" {
+		return false
+	}
+	cached, err := l.calibrationCache.load(serial)
+	if err != nil {
+		slog.Debug(fmt.Sprintf("no cached calibration file: %v", err))
+		return false
+	}
+	if !cached.matches(fileLength, firstPart) {
+		slog.Debug("cached calibration file changed on the glass, downloading it again")
+		return false
+	}
+	if err := runRecovered("ov580 cached calibration", func() error { return l.parseCalibrationConfigs(cached.File) }); err != nil {
+		slog.Debug(fmt.Sprintf("failed to parse cached calibration file, downloading it again: %v", err))
+		return false
+	}
+
+	slog.Debug(fmt.Sprintf("using calibration file cached at %s", l.calibrationCache.path(serial)))
+	if l.control != nil {
+		l.control.shareCalibration(cached.File)
+	}
+	return true
+}
+
+// getSerial returns the USB serial number of the OV580 identifying the glass, empty if unknown.
+func (l *xrealLightOV580) getSerial() string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.device == nil {
+		return ""
+	}
+	info, err := l.device.GetDeviceInfo()
+	if err != nil {
+		slog.Debug(fmt.Sprintf("failed to get OV580 serial number: %v", err))
+		return ""
+	}
+	return info.SerialNbr
+}
+
+func (l *xrealLightOV580) parseCalibrationConfigs(fileBytes []byte) error {
+	content := string(fileBytes)
+
+	startIdx := strings.Index(content, "<")
+	endIdx := strings.LastIndex(content, ">")
+	xmlString := content[startIdx:(endIdx + 1)]
+	slog.Debug(fmt.Sprintf("xml content: %s", xmlString))
+
+	startIdx = strings.Index(content, "{")
+	endIdx = strings.LastIndex(content, "}")
+	jsonBytes := fileBytes[startIdx:(endIdx + 1)]
+	var jsonData map[string]interface{}
+	err := json.Unmarshal(jsonBytes, &jsonData)
+	if err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	slog.Debug(fmt.Sprintf("json content: %s", jsonData))
+
+	device1Data := jsonData["IMU"].(map[string]interface{})["device_1"].(map[string]interface{})
+
+	accelBias := device1Data["accel_bias"].([]interface{})
+	l.accelerometerBias = &AccelerometerVector{
+		X: float32(accelBias[0].(float64)),
+		Y: float32(accelBias[1].(float64)),
+		Z: float32(accelBias[2].(float64)),
+	}
+
+	gyroBias := device1Data["gyro_bias"].([]interface{})
+	l.gyroscopeBias = &GyroscopeVector{
+		X: float32(gyroBias[0].(float64)),
+		Y: float32(gyroBias[1].(float64)),
+		Z: float32(gyroBias[2].(float64)),
+	}
+
+	slog.Debug(fmt.Sprintf("remaining content: %s", content[(endIdx+1):]))
+
+	return nil
+}
+
+// readPacketsPeriodically is a goroutine method to read info from XREAL Light MCU HID device
+func (l *xrealLightOV580) readPacketsPeriodically(stop <-chan struct{}) {
+	defer l.waitgroup.Done()
+
+	ticker := time.NewTicker(readPacketFrequency)
+	defer ticker.Stop()
+
+	// readFailing avoids reporting the same read failure every tick
+	readFailing := false
+	// readFailures counts consecutive read failures until the OV580 is reopened
+	readFailures := 0
+	var lastRead time.Time
+
+	for {
+		select {
+		case <-ticker.C:
+			if l.idle.skipRead(&lastRead) {
+				continue
+			}
+			err := runRecovered("ov580 read loop", l.readAndProcessData)
+			switch {
+			case err == nil:
+				readFailing = false
+				readFailures = 0
+			case errors.Is(err, ErrPanic):
+				restart := restartAfterPanic(err)
+				l.deviceHandlers.reportError(err)
+				if restart {
+					l.waitgroup.Add(1)
+					go l.readPacketsPeriodically(stop)
+					return
+				}
+			case isTimeout(err):
+			case errors.Is(err, ErrReadFailed):
+				if !readFailing {
+					readFailing = true
+					l.deviceHandlers.reportError(fmt.Errorf("ov580: %w", err))
+				}
+				if readFailures++; readFailures >= ov580RestartAfterReadFailures {
+					readFailures = 0
+					l.restartAfterReadFailures()
+				}
+			default:
+				slog.Debug(fmt.Sprintf("readAndProcessData(): %v", err))
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// restartAfterReadFailures reopens the hid device and re-enables the IMU stream if it was enabled, as the IMU
+// stream silently stops otherwise. It runs on the read loop, which owns reading from the device.
+func (l *xrealLightOV580) restartAfterReadFailures() {
+	if err := l.reopen(); err != nil {
+		slog.Debug(fmt.Sprintf("failed to reopen OV580 after %d consecutive read failures: %v", ov580RestartAfterReadFailures, err))
+		return
+	}
+	if l.observer || !l.imuActivity.isExpected() {
+		l.deviceHandlers.reportError(fmt.Errorf("ov580: %w, reopened after %d consecutive read failures", ErrIMUStreamRestarted, ov580RestartAfterReadFailures))
+		return
+	}
+
+	// the response is delivered by the read loop, so it cannot wait for it
+	l.waitgroup.Add(1)
+	go func() {
+		defer l.waitgroup.Done()
+
+		if err := l.enableEventReporting(OV580_ENABLE_IMU_STREAM, "1"); err != nil {
+			l.deviceHandlers.reportError(fmt.Errorf("ov580: reopened after %d consecutive read failures, but failed to re-enable the IMU stream: %w", ov580RestartAfterReadFailures, err))
+			return
+		}
+		l.deviceHandlers.reportError(fmt.Errorf("ov580: %w after %d consecutive read failures", ErrIMUStreamRestarted, ov580RestartAfterReadFailures))
+	}()
+}
+
+// reopen replaces the hid device with a new handle to the same path.
+func (l *xrealLightOV580) reopen() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.devicePath == nil {
+		return fmt.Errorf("not connected / initialized")
+	}
+	device, err := hid.OpenPath(*l.devicePath)
+	if err != nil {
+		return fmt.Errorf("failed to open the device path %s: %w", *l.devicePath, err)
+	}
+	if l.device != nil {
+		l.device.Close()
+	}
+	l.device = device
+	return nil
+}
+
+func (l *xrealLightOV580) executeAndWaitForResponse(command *Command, value uint8) (response []byte, err error) {
+	l.idle.wake()
+	l.commandMutex.Lock()
+	defer l.commandMutex.Unlock()
+
+	responses := l.commandResponses.Load()
+	if responses == nil {
+		return nil, fmt.Errorf("not connected / initialized")
+	}
+	// responses carry no command, so a late one to an earlier command would be taken for the response to this one
+	if discarded := responses.discard(); discarded > 0 {
+		slog.Debug(fmt.Sprintf("discarded %d stale responses before %s", discarded, command.String()))
+	}
+
+	end := commands.start(COMPONENT_OV580, *command)
+	defer func() { end(err) }()
+
+	if err := l.executeOnly(command, value); err != nil {
+		return nil, err
+	}
+	response, err = responses.receive(retryMaxAttempts * waitForPacketTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get response for %s: %w", command.String(), err)
+	}
+	return response, nil
+}
+
+func (l *xrealLightOV580) executeOnly(command *Command, value uint8) error {
+	l.mutex.Lock()
+
+	defer l.mutex.Unlock()
+
+	if l.device == nil {
+		return fmt.Errorf("not connected / initialized")
+	}
+
+	if err := CheckCommandAllowed("ov580", command); err != nil {
+		return err
+	}
+
+	if l.observer {
+		return ErrObserver
+	}
+
+	_, err := l.device.Write([]byte{command.Type, command.ID, value, 0, 0, 0, 0})
+	if err != nil {
+		return fmt.Errorf("failed to execute on device %v: %w", l.device, err)
+	}
+	return nil
+}
+
+// readAndProcessData receives data piece from OV580 device to be processed.
+// This method should be called as frequently as possible to track the time of the packets more accurately.
+func (l *xrealLightOV580) readAndProcessData() error {
+	var buffer [128]byte
+	_, err := l.device.ReadWithTimeout(buffer[:], readDeviceTimeout)
+	if err != nil {
+		return fmt.Errorf("%w %v: %w", ErrReadFailed, l.device, err)
+	}
+
+	switch buffer[0] {
+	case 0x1: // IMU event
+		// don't do anything if not yet initialized
+		if !l.initialized.Load() {
+			return nil
+		}
+
+		reader := bytes.NewReader(buffer[0x2a:])
+
+		var temperature uint16
+		binary.Read(reader, binary.LittleEndian, &temperature)
+		slog.Debug(fmt.Sprintf("temperature: %d", temperature))
+
+		var gyroTimestamp uint64 // nanoseconds
+		binary.Read(reader, binary.LittleEndian, &gyroTimestamp)
+
+		var gyroMultiplierRaw uint32
+		binary.Read(reader, binary.LittleEndian, &gyroMultiplierRaw)
+		gyroMultiplier := float32(gyroMultiplierRaw)
+
+		var gyroDivisorRaw uint32
+		binary.Read(reader, binary.LittleEndian, &gyroDivisorRaw)
+		gyroDivisor := float32(gyroDivisorRaw)
+
+		var gyroXRaw int32
+		binary.Read(reader, binary.LittleEndian, &gyroXRaw)
+		gyroX := float32(gyroXRaw)
+
+		var gyroYRaw int32
+		binary.Read(reader, binary.LittleEndian, &gyroYRaw)
+		gyroY := float32(gyroYRaw)
+
+		var gyroZRaw int32
+		binary.Read(reader, binary.LittleEndian, &gyroZRaw)
+		gyroZ := float32(gyroZRaw)
+
+		gyro := &GyroscopeVector{
+			X: (gyroX*gyroMultiplier/gyroDivisor)*(math.Pi/180.0) - l.gyroscopeBias.X,
+			Y: -(gyroY*gyroMultiplier/gyroDivisor)*(math.Pi/180.0) + l.gyroscopeBias.Y,
+			Z: -(gyroZ*gyroMultiplier/gyroDivisor)*(math.Pi/180.0) + l.gyroscopeBias.Z,
+		}
+
+		var accelTimestamp uint64 // nanoseconds
+		binary.Read(reader, binary.LittleEndian, &accelTimestamp)
+
+		var accelMultiplierRaw uint32
+		binary.Read(reader, binary.LittleEndian, &accelMultiplierRaw)
+		accelMultiplier := float32(accelMultiplierRaw)
+
+		var accelDivisorRaw uint32
+		binary.Read(reader, binary.LittleEndian, &accelDivisorRaw)
+		accelDivisor := float32(accelDivisorRaw)
+
+		var accelXRaw int32
+		binary.Read(reader, binary.LittleEndian, &accelXRaw)
+		accelX := float32(accelXRaw)
+
+		var accelYRaw int32
+		binary.Read(reader, binary.LittleEndian, &accelYRaw)
+		accelY := float32(accelYRaw)
+
+		var accelZRaw int32
+		binary.Read(reader, binary.LittleEndian, &accelZRaw)
+		accelZ := float32(accelZRaw)
+
+		accel := &AccelerometerVector{
+			X: (accelX*accelMultiplier/accelDivisor)*9.81 - l.accelerometerBias.X,
+			Y: -(accelY*accelMultiplier/accelDivisor)*9.81 + l.accelerometerBias.Y,
+			Z: -(accelZ*accelMultiplier/accelDivisor)*9.81 + l.accelerometerBias.Z,
+		}
+
+		if gyroTimestamp != accelTimestamp {
+			slog.Warn(fmt.Sprintf("odd, found gyro and accel with different timestamp: %d vs %d nanoseconds", gyroTimestamp, accelTimestamp))
+		}
+
+		imu := &IMUEvent{
+			Gyroscope:     gyro,
+			Accelerometer: accel,
+			TimeSinceBoot: gyroTimestamp / 1000000, // miliseconds
+		}
+		if transform := mounting.current(); transform != nil {
+			transform.ApplyToIMU(imu)
+		}
+		l.imuActivity.deliver()
+		l.deviceHandlers.IMUEventHandler(imu)
+		return nil
+	case 0x2:
+		if l.observer {
+			// responses to the controller reach us too, but nobody here waits for them
+			return nil
+		}
+		// buffer[1] is 0x0 for the calibration file length, 0x1 while reading the calibration file and 0x3 at its
+		// end, 0x4 when acknowleging IMU enabled
+		if buffer[1] != 0x0 && buffer[1] != 0x1 && buffer[1] != 0x3 && buffer[1] != 0x4 {
+			slog.Debug(fmt.Sprintf("buffer[1] = %d", buffer[1]))
+		}
+		if !l.commandResponses.Load().deliver(buffer[:]) {
+			slog.Debug(fmt.Sprintf("dropped the oldest unclaimed response to make room for %v", buffer[:2]))
+		}
+		return nil
+	default:
+	}
+
+	slog.Debug(fmt.Sprintf("got unhandled readings: %v", buffer[:]))
+
+	return nil
+}
+
+func (l *xrealLightOV580) enableEventReporting(instruction CommandInstruction, enabled string) error {
+	command := GetFirmwareIndependentCommand(instruction)
+	value := uint8(0x0)
+	if enabled == "1" {
+		value = 0x1
+	}
+	var response []byte
+	err := getRetryPolicy(&l.retryPolicy).Do(func() (err error) {
+		response, err = l.executeAndWaitForResponse(command, value)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set event reporting: %w", err)
+	}
+	if (response[0] != 0x2) && (response[0] != 0x4) {
+		return fmt.Errorf("failed to set event reporting: want [0x2 0x4] got %v", response)
+	}
+	l.idle.reported(instruction, value == 0x1)
+	if instruction == OV580_ENABLE_IMU_STREAM {
+		if value == 0x1 {
+			l.imuActivity.expect()
+		} else {
+			l.imuActivity.stopExpecting()
+		}
+	}
+	return nil
+}
+
+func (l *xrealLightOV580) devExecuteAndRead(input []string) {
+	if len(input) != 3 {
+		slog.Error(fmt.Sprintf("wrong input format: want hex string for [CommandType CommandID Payload] got %v", input))
+		return
+	}
+
+	commandType, err := hexStringToBytes(input[0])
+	if err != nil {
+		slog.Error(err.Error())
+		return
+	}
+	commandID, err := hexStringToBytes(input[1])
+	if err != nil {
+		slog.Error(err.Error())
+		return
+	}
+	value, err := hexStringToBytes(input[2])
+	if err != nil {
+		slog.Error(err.Error())
+		return
+	}
+
+	command := Command{Type: commandType[0], ID: commandID[0]}
+	response, err := l.executeAndWaitForResponse(&command, value[0])
+	if err != nil {
+		slog.Error(fmt.Sprintf("%s : '%v' failed: %v", command.String(), response, err))
+		return
+	}
+	slog.Info(fmt.Sprintf("%s : '%v'", command.String(), response))
+}
+
+func hexStringToBytes(hexString string) ([]byte, error) {
+	if len(hexString)%2 != 0 {
+		hexString = "0" + hexString // Pad with '0' at the beginning
+	}
+
+	if byteArray, err := hex.DecodeString(hexString); err == nil {
+		return byteArray, nil
+	} else {
+		return nil, fmt.Errorf("failed to convert %s to hex: %w", hexString, err)
+	}
+}
+
+func (l *xrealLightOV580) disconnect() error {
+	l.initialized.Store(false)
+	l.imuActivity.stopExpecting()
+
+	// the read loop first, as it uses the device and delivers responses, see xrealLightMCU.disconnect
+	closeStopChannel(&l.stopReadDataChannel)
+
+	l.waitgroup.Wait()
+
+	if responses := l.commandResponses.Swap(nil); responses != nil {
+		responses.discard()
+		responses.close()
+	}
+
+	// commands may still be sent meanwhile, see executeOnly
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.device == nil {
+		return nil
+	}
+
+	err := l.device.Close()
+	if err == nil {
+		l.device = nil
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package device
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// sbsSession switches the display to DISPLAY_MODE_HALF_SBS temporarily, so the glass is not stuck in 3D
+// when the app that asked for it crashes.
+type sbsSession struct {
+	// mutex for thread safety
+	mutex sync.Mutex
+	// previousMode is the display mode to revert to, empty if no session is active
+	previousMode DisplayMode
+	// generation counts the sessions entered or extended, so a timer that already fired when the session was extended
+	// does not revert it early
+	generation uint64
+	// stopTimer cancels the timer reverting the display mode once the session expires
+	stopTimer func() bool
+	// after starts the timer, time.AfterFunc if nil; replaced in tests
+	after afterFunc
+}
+
+// enterSBS switches to DISPLAY_MODE_HALF_SBS for the duration, entering again while active extends the session.
+func (l *xrealLightMCU) enterSBS(duration time.Duration) error {
+	if duration <= 0 {
+		return fmt.Errorf("invalid SBS duration %v, must be positive", duration)
+	}
+
+	l.sbs.mutex.Lock()
+	defer l.sbs.mutex.Unlock()
+
+	if l.sbs.previousMode == "" {
+		mode, err := l.getDisplayMode()
+		if err != nil {
+			return fmt.Errorf("failed to enter SBS: %w", err)
+		}
+		if err := l.setDisplayMode(DISPLAY_MODE_HALF_SBS); err != nil {
+			return fmt.Errorf("failed to enter SBS: %w", err)
+		}
+		l.sbs.previousMode = mode
+	} else {
+		l.sbs.stopTimer()
+	}
+
+	l.sbs.generation++
+	generation := l.sbs.generation
+	after := l.sbs.after
+	if after == nil {
+		after = systemAfterFunc
+	}
+	l.sbs.stopTimer = after(duration, func() {
+		if err := runRecovered("sbs revert", func() error { return l.expireSBS(generation) }); err != nil {
+			l.deviceHandlers.reportError(err)
+		}
+	})
+	return nil
+}
+
+// expireSBS reverts the display mode once the timer of the session generation fires, unless the session was extended
+// or ended meanwhile.
+func (l *xrealLightMCU) expireSBS(generation uint64) error {
+	l.sbs.mutex.Lock()
+	defer l.sbs.mutex.Unlock()
+
+	if l.sbs.previousMode == "" || l.sbs.generation != generation {
+		return nil
+	}
+	slog.Info("SBS session expired, reverting display mode")
+	return l.revertSBS()
+}
+
+// exitSBS reverts the display mode saved by enterSBS, it does nothing if no session is active.
+// The session ends even if reverting fails, so a stale timer cannot switch the mode later.
+func (l *xrealLightMCU) exitSBS() error {
+	l.sbs.mutex.Lock()
+	defer l.sbs.mutex.Unlock()
+
+	if l.sbs.previousMode == "" {
+		return nil
+	}
+	return l.revertSBS()
+}
+
+// revertSBS ends the session and reverts the display mode, with the mutex held.
+func (l *xrealLightMCU) revertSBS() error {
+	l.sbs.stopTimer()
+	previousMode := l.sbs.previousMode
+	l.sbs.previousMode = ""
+
+	if err := l.setDisplayMode(previousMode); err != nil {
+		return fmt.Errorf("failed to revert display mode to %s after SBS: %w", previousMode, err)
+	}
+	return nil
+}
+
+// exitSBSOnProximityFar ends the SBS session once the glass is taken off. It is called from the read loop,
+// so reverting runs in its own goroutine as it waits for a response from that same loop.
+func (l *xrealLightMCU) exitSBSOnProximityFar(proximity ProximityEvent) {
+	if proximity != PROXIMITY_FAR {
+		return
+	}
+	go func() {
+		if err := runRecovered("sbs revert", l.exitSBS); err != nil {
+			l.deviceHandlers.reportError(err)
+		}
+	}()
+}
@@ -0,0 +1,73 @@
+package device
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDisplay plays the MCU answering the display mode commands.
+type fakeDisplay struct {
+	// mutex for thread safety
+	mutex sync.Mutex
+	mode  byte
+}
+
+func (d *fakeDisplay) respond(command *Packet) string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	switch {
+	case command.Command.Equals(GetFirmwareIndependentCommand(CMD_GET_DISPLAY_MODE)):
+		return fmt.Sprintf("\x02:4:3:%c&mode:18fd37a61db:00000000:\x03", d.mode)
+	case command.Command.Equals(GetFirmwareIndependentCommand(CMD_SET_DISPLAY_MODE)):
+		d.mode = command.Payload[0]
+		return fmt.Sprintf("\x02:2:3:%c:18fd37a61db:00000000:\x03", d.mode)
+	default:
+		return ""
+	}
+}
+
+func (d *fakeDisplay) get() byte {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.mode
+}
+
+func TestSBSIgnoresStaleTimer(t *testing.T) {
+	display := &fakeDisplay{mode: '1'}
+	mcu := NewXREALLight(nil, nil).(*xrealLight).mcu
+	mcu.device = &fakeHIDDevice{respond: display.respond}
+	mcu.pendingCommands.Store(newPendingCommands())
+	mcu.stopReadPacketsChannel = make(chan struct{})
+	mcu.waitgroup.Add(1)
+	go mcu.readPacketsPeriodically(mcu.stopReadPacketsChannel)
+	defer mcu.disconnect()
+
+	// the timers fire as the session is extended, so stopping them always comes too late
+	var expire []func()
+	mcu.sbs.after = func(d time.Duration, f func()) func() bool {
+		expire = append(expire, f)
+		return func() bool { return false }
+	}
+
+	if err := mcu.enterSBS(time.Minute); err != nil {
+		t.Fatalf("enterSBS() = %v", err)
+	}
+	if err := mcu.enterSBS(time.Minute); err != nil {
+		t.Fatalf("enterSBS() to extend = %v", err)
+	}
+	if mode := display.get(); mode != '2' {
+		t.Fatalf("display mode %c in SBS, want 2", mode)
+	}
+
+	expire[0]()
+	if mode := display.get(); mode != '2' {
+		t.Errorf("display mode %c after the timer of the extended session fired, want 2", mode)
+	}
+	expire[1]()
+	if mode := display.get(); mode != '1' {
+		t.Errorf("display mode %c after the session expired, want 1", mode)
+	}
+}
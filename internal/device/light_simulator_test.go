@@ -0,0 +1,243 @@
+//go:build linux && uhid
+
+// End-to-end tests of the MCU and OV580 drivers against the emulated glass from internal/simulator,
+// exposed as virtual HID devices. They need access to /dev/uhid, run with
+//
+//	sudo go test -tags uhid ./internal/device/ -run Simulated
+package device
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+
+	"xreal-light-xr-go/internal/simulator"
+)
+
+const simulatedEventTimeout = 2 * time.Second
+
+func startSimulatedLight(t *testing.T) *simulator.Light {
+	t.Helper()
+
+	light, err := simulator.StartLight(simulator.DEFAULT_SERIAL)
+	if err != nil {
+		t.Skipf("cannot create virtual HID devices: %v", err)
+	}
+	t.Cleanup(func() { light.Stop() })
+
+	// give udev a moment to create the hidraw nodes
+	time.Sleep(500 * time.Millisecond)
+	return light
+}
+
+func TestSimulatedLightMCU(t *testing.T) {
+	light := startSimulatedLight(t)
+
+	proximity := make(chan ProximityEvent, 1)
+	serial := simulator.DEFAULT_SERIAL
+	mcu := &xrealLightMCU{
+		serialNumber: &serial,
+		deviceHandlers: &DeviceHandlers{
+			ProximityEventHandler: func(event ProximityEvent) { proximity <- event },
+		},
+	}
+	if err := mcu.connectAndInitialize(); err != nil {
+		t.Fatalf("connectAndInitialize() failed: %v", err)
+	}
+	defer mcu.disconnect()
+
+	if got, err := mcu.getSerial(); err != nil || got != simulator.DEFAULT_SERIAL {
+		t.Errorf("getSerial() = %s, %v; expected %s", got, err, simulator.DEFAULT_SERIAL)
+	}
+	if got, err := mcu.executeRaw(Command{Type: '3', ID: 'C'}, nil); err != nil || string(got) != simulator.DEFAULT_SERIAL {
+		t.Errorf("executeRaw(3C) = %s, %v; expected %s", got, err, simulator.DEFAULT_SERIAL)
+	}
+
+	if err := mcu.setBrightnessLevel("6"); err != nil {
+		t.Fatalf("setBrightnessLevel(6) failed: %v", err)
+	}
+	if got, err := mcu.getBrightnessLevel(); err != nil || got != "6" {
+		t.Errorf("getBrightnessLevel() = %s, %v; expected 6", got, err)
+	}
+	if got := light.MCU.Value(0x31); got != "6" {
+		t.Errorf("simulated brightness level = %s; expected 6", got)
+	}
+
+	if err := mcu.setDisplayMode(DISPLAY_MODE_STEREO); err != nil {
+		t.Fatalf("setDisplayMode(STEREO) failed: %v", err)
+	}
+	if got, err := mcu.getDisplayMode(); err != nil || got != DISPLAY_MODE_STEREO {
+		t.Errorf("getDisplayMode() = %s, %v; expected %s", got, err, DISPLAY_MODE_STEREO)
+	}
+
+	if err := light.MCU.SendEvent(0x50, "near"); err != nil {
+		t.Fatalf("SendEvent() failed: %v", err)
+	}
+	select {
+	case event := <-proximity:
+		if event != PROXIMITY_NEAR {
+			t.Errorf("got proximity %s; expected %s", event.String(), PROXIMITY_NEAR.String())
+		}
+	case <-time.After(simulatedEventTimeout):
+		t.Errorf("no proximity event received in %v", simulatedEventTimeout)
+	}
+}
+
+func TestSimulatedLightOV580(t *testing.T) {
+	startSimulatedLight(t)
+
+	imu := make(chan *IMUEvent, 1)
+	ov580 := &xrealLightOV580{
+		deviceHandlers: &DeviceHandlers{
+			IMUEventHandler: func(event *IMUEvent) {
+				select {
+				case imu <- event:
+				default:
+				}
+			},
+		},
+	}
+	if err := ov580.connectAndInitialize(); err != nil {
+		t.Fatalf("connectAndInitialize() failed: %v", err)
+	}
+	defer ov580.disconnect()
+
+	if ov580.gyroscopeBias == nil || ov580.accelerometerBias == nil {
+		t.Fatalf("calibration file not parsed")
+	}
+
+	var read, total int
+	file, err := ov580.readVerifiedFile(OV580_CALIBRATION_FILE_ID, OV580FileOptions{
+		Progress: func(r int, t int) { read, total = r, t },
+		Verify:   true,
+	})
+	if err != nil {
+		t.Fatalf("readVerifiedFile() failed: %v", err)
+	}
+	if len(file.Data) == 0 || read != len(file.Data) || total != len(file.Data) {
+		t.Errorf("read %d bytes, progress %d of %d; expected all of them", len(file.Data), read, total)
+	}
+
+	if err := ov580.enableEventReporting(OV580_ENABLE_IMU_STREAM, "1"); err != nil {
+		t.Fatalf("failed to enable IMU stream: %v", err)
+	}
+	select {
+	case event := <-imu:
+		if event.Accelerometer.Y < 9 || event.Accelerometer.Y > 10 {
+			t.Errorf("got accelerometer %+v; expected gravity on Y", event.Accelerometer)
+		}
+	case <-time.After(simulatedEventTimeout):
+		t.Errorf("no IMU event received in %v", simulatedEventTimeout)
+	}
+}
+
+func TestSimulatedLightSBS(t *testing.T) {
+	light := startSimulatedLight(t)
+
+	serial := simulator.DEFAULT_SERIAL
+	mcu := &xrealLightMCU{
+		serialNumber: &serial,
+		deviceHandlers: &DeviceHandlers{
+			ProximityEventHandler: func(ProximityEvent) {},
+		},
+	}
+	if err := mcu.connectAndInitialize(); err != nil {
+		t.Fatalf("connectAndInitialize() failed: %v", err)
+	}
+	defer mcu.disconnect()
+
+	if err := mcu.setDisplayMode(DISPLAY_MODE_STEREO); err != nil {
+		t.Fatalf("setDisplayMode(STEREO) failed: %v", err)
+	}
+
+	waitForDisplayMode := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(simulatedEventTimeout)
+		for light.MCU.Value(0x33) != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("simulated display mode = %s; expected %s", light.MCU.Value(0x33), want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// reverts once expired
+	if err := mcu.enterSBS(200 * time.Millisecond); err != nil {
+		t.Fatalf("enterSBS() failed: %v", err)
+	}
+	waitForDisplayMode("2")
+	waitForDisplayMode("3")
+
+	// reverts once the glass is taken off
+	if err := mcu.enterSBS(time.Minute); err != nil {
+		t.Fatalf("enterSBS() failed: %v", err)
+	}
+	waitForDisplayMode("2")
+	if err := light.MCU.SendEvent(0x50, "away"); err != nil {
+		t.Fatalf("SendEvent() failed: %v", err)
+	}
+	waitForDisplayMode("3")
+}
+
+func TestSimulatedLightShutdown(t *testing.T) {
+	startSimulatedLight(t)
+	// the simulator keeps running until cleanup
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	serial := simulator.DEFAULT_SERIAL
+	mcu := &xrealLightMCU{serialNumber: &serial, deviceHandlers: &DeviceHandlers{}}
+	if err := mcu.connectAndInitialize(); err != nil {
+		t.Fatalf("mcu connectAndInitialize() failed: %v", err)
+	}
+	ov580 := &xrealLightOV580{deviceHandlers: &DeviceHandlers{IMUEventHandler: func(*IMUEvent) {}}}
+	if err := ov580.connectAndInitialize(); err != nil {
+		t.Fatalf("ov580 connectAndInitialize() failed: %v", err)
+	}
+	if err := ov580.enableEventReporting(OV580_ENABLE_IMU_STREAM, "1"); err != nil {
+		t.Fatalf("failed to enable IMU stream: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := mcu.disconnect(); err != nil {
+			t.Errorf("mcu disconnect() #%d failed: %v", i+1, err)
+		}
+		if err := ov580.disconnect(); err != nil {
+			t.Errorf("ov580 disconnect() #%d failed: %v", i+1, err)
+		}
+	}
+}
+
+func TestSimulatedLightOV580ConcurrentCommands(t *testing.T) {
+	startSimulatedLight(t)
+
+	ov580 := &xrealLightOV580{deviceHandlers: &DeviceHandlers{IMUEventHandler: func(*IMUEvent) {}}}
+	if err := ov580.connectAndInitialize(); err != nil {
+		t.Fatalf("connectAndInitialize() failed: %v", err)
+	}
+	defer ov580.disconnect()
+
+	length := GetFirmwareIndependentCommand(OV580_GET_CALIBRATION_FILE_LENGTH)
+	want, err := ov580.executeAndWaitForResponse(length, OV580_CALIBRATION_FILE_ID)
+	if err != nil {
+		t.Fatalf("executeAndWaitForResponse(%s) failed: %v", length.String(), err)
+	}
+
+	// the IMU stream toggled meanwhile must not answer the length
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			if err := ov580.enableEventReporting(OV580_ENABLE_IMU_STREAM, eventReportingValue(i%2 == 0)); err != nil {
+				t.Errorf("enableEventReporting() failed: %v", err)
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		got, err := ov580.executeAndWaitForResponse(length, OV580_CALIBRATION_FILE_ID)
+		if err != nil || string(got[:7]) != string(want[:7]) {
+			t.Errorf("executeAndWaitForResponse(%s) = %v, %v; expected %v", length.String(), got[:min(len(got), 7)], err, want[:7])
+		}
+	}
+	<-done
+}
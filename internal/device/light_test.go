@@ -0,0 +1,43 @@
+package device
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+// TestInitializeStopsAtConnectDeadline connects components that enumerate but never answer, e.g. an OV580 in a bad
+// state, which must fail once the deadline connectComponents shares passed instead of retrying forever.
+func TestInitializeStopsAtConnectDeadline(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	// one attempt per command, waiting up to retryMaxAttempts times waitForPacketTimeout for its response
+	policy := RetryPolicy{MaxAttempts: 1}
+	deadline := time.Now().Add(100 * time.Millisecond)
+	handlers := &DeviceHandlers{ErrorHandler: func(error) {}}
+
+	mcu := &xrealLightMCU{device: &fakeHIDDevice{}, deviceHandlers: handlers, connectDeadline: deadline}
+	mcu.retryPolicy.Store(&policy)
+	ov580 := &xrealLightOV580{device: &fakeHIDDevice{}, deviceHandlers: handlers, connectDeadline: deadline}
+	ov580.retryPolicy.Store(&policy)
+
+	errOV580 := make(chan error, 1)
+	go func() { errOV580 <- ov580.initialize() }()
+	errMCU := mcu.initialize()
+
+	// the attempt running when the deadline passes is finished first
+	limit := deadline.Add((retryMaxAttempts + 1) * waitForPacketTimeout)
+	for component, err := range map[string]error{COMPONENT_MCU: errMCU, COMPONENT_OV580: <-errOV580} {
+		if !errors.Is(err, errResponseTimeout) {
+			t.Errorf("%s initialize() = %v, want a timeout once past the connect deadline", component, err)
+		}
+	}
+	if time.Now().After(limit) {
+		t.Errorf("initialize() returned %v after the connect deadline, want at most one more attempt", time.Since(deadline))
+	}
+
+	mcu.disconnect()
+	ov580.disconnect()
+}
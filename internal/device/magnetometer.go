@@ -0,0 +1,53 @@
+package device
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseMagnetometerVector parses a magnetometer MCU packet with payload like "x-123y45z678".
+// The returned vector is never nil, but Valid is false if the payload is malformed.
+func ParseMagnetometerVector(pkt *Packet) *MagnetometerVector {
+	vector := &MagnetometerVector{Timestamp: pkt.DecodeTimestamp()}
+
+	if len(pkt.DeviceTimestamp) > 0 {
+		if deviceTimestamp, err := strconv.ParseUint(string(pkt.DeviceTimestamp), 16, 64); err == nil {
+			vector.DeviceTimestamp = deviceTimestamp
+		}
+	}
+
+	x, y, z, err := parseMagnetometerPayload(string(pkt.Payload))
+	if err != nil {
+		return vector
+	}
+
+	vector.RawX, vector.RawY, vector.RawZ = x, y, z
+	vector.X, vector.Y, vector.Z = float64(x), float64(y), float64(z)
+	vector.Valid = true
+	return vector
+}
+
+func parseMagnetometerPayload(reading string) (int, int, int, error) {
+	xIdx := strings.Index(reading, "x")
+	yIdx := strings.Index(reading, "y")
+	zIdx := strings.Index(reading, "z")
+
+	if xIdx != 0 || yIdx <= xIdx || zIdx <= yIdx {
+		return 0, 0, 0, fmt.Errorf("unexpected magnetometer payload layout: %q", reading)
+	}
+
+	x, err := strconv.Atoi(strings.TrimSpace(reading[xIdx+1 : yIdx]))
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to parse x of %q: %w", reading, err)
+	}
+	y, err := strconv.Atoi(strings.TrimSpace(reading[yIdx+1 : zIdx]))
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to parse y of %q: %w", reading, err)
+	}
+	z, err := strconv.Atoi(strings.TrimSpace(reading[zIdx+1:]))
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to parse z of %q: %w", reading, err)
+	}
+	return x, y, z, nil
+}
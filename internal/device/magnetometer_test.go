@@ -0,0 +1,49 @@
+package device_test
+
+import (
+	"testing"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestParseMagnetometerVector(t *testing.T) {
+	testCases := []struct {
+		payload  string
+		valid    bool
+		expected [3]int
+	}{
+		{"x-123y45z678", true, [3]int{-123, 45, 678}},
+		{"x0y0z0", true, [3]int{0, 0, 0}},
+		{"x1y2", false, [3]int{}},
+		{"y1x2z3", false, [3]int{}},
+		{"x1yz3", false, [3]int{}},
+		{"", false, [3]int{}},
+	}
+
+	for _, tc := range testCases {
+		packet := &device.Packet{
+			Type:            device.PACKET_TYPE_MCU,
+			Command:         device.GetFirmwareIndependentCommand(device.MCU_EVENT_MAGNETOMETER),
+			Payload:         []byte(tc.payload),
+			DeviceTimestamp: []byte("1a2b"),
+		}
+
+		vector := device.ParseMagnetometerVector(packet)
+		if vector.Valid != tc.valid {
+			t.Errorf("ParseMagnetometerVector(%q).Valid = %t; expected %t", tc.payload, vector.Valid, tc.valid)
+			continue
+		}
+		if vector.DeviceTimestamp != 0x1a2b {
+			t.Errorf("ParseMagnetometerVector(%q).DeviceTimestamp = %d; expected %d", tc.payload, vector.DeviceTimestamp, 0x1a2b)
+		}
+		if !tc.valid {
+			continue
+		}
+		if raw := [3]int{vector.RawX, vector.RawY, vector.RawZ}; raw != tc.expected {
+			t.Errorf("ParseMagnetometerVector(%q) raw = %v; expected %v", tc.payload, raw, tc.expected)
+		}
+		if xyz := [3]float64{vector.X, vector.Y, vector.Z}; xyz != [3]float64{float64(tc.expected[0]), float64(tc.expected[1]), float64(tc.expected[2])} {
+			t.Errorf("ParseMagnetometerVector(%q) (x,y,z) = %v; expected the raw %v", tc.payload, xyz, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package device
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// rotationTolerance is how far a rotation matrix may be off orthonormal, e.g. from rounding its entries.
+const rotationTolerance = 1e-6
+
+// MountingTransform maps the glass axes to the axes of a rig the glass is mounted on, e.g. a helmet or robot.
+type MountingTransform struct {
+	// Rotation is row-major, it turns a vector in glass axes into rig axes, e.g. {{0, 1, 0}, {-1, 0, 0}, {0, 0, 1}}
+	// remaps the glass Y axis to the rig X axis and the glass X axis to the rig -Y axis
+	Rotation [3][3]float64
+	// Translation is the position of the glass origin in rig axes in meters
+	Translation [3]float64
+}
+
+var IDENTITY_MOUNTING_TRANSFORM = MountingTransform{Rotation: [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}}
+
+func (m MountingTransform) String() string {
+	return fmt.Sprintf("rotation %v, translation %v m", m.Rotation, m.Translation)
+}
+
+// RotationFromEuler returns the rotation of the glass by roll about X, then pitch about Y, then yaw about Z, in
+// radians as in fusion.Attitude.
+func RotationFromEuler(roll float64, pitch float64, yaw float64) [3][3]float64 {
+	sr, cr := math.Sincos(roll)
+	sp, cp := math.Sincos(pitch)
+	sy, cy := math.Sincos(yaw)
+	rotation := [3][3]float64{
+		{cy * cp, cy*sp*sr - sy*cr, cy*sp*cr + sy*sr},
+		{sy * cp, sy*sp*sr + cy*cr, sy*sp*cr - cy*sr},
+		{-sp, cp * sr, cp * cr},
+	}
+	// quarter turns are exact axis remaps, without the rounding errors of sin and cos
+	for i := range rotation {
+		for j := range rotation[i] {
+			rotation[i][j] = snapToUnit(rotation[i][j])
+		}
+	}
+	return rotation
+}
+
+func snapToUnit(value float64) float64 {
+	for _, unit := range []float64{-1, 0, 1} {
+		if math.Abs(value-unit) < 1e-12 {
+			return unit
+		}
+	}
+	return value
+}
+
+// ParseMountingTransform parses "roll,pitch,yaw" in degrees as RotationFromEuler, optionally followed by ",x,y,z"
+// translation in meters, e.g. "0,0,90,0,0.1,0".
+func ParseMountingTransform(s string) (MountingTransform, error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != 3 && len(fields) != 6 {
+		return MountingTransform{}, fmt.Errorf("invalid mounting transform %s: want roll,pitch,yaw[,x,y,z]", s)
+	}
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return MountingTransform{}, fmt.Errorf("invalid mounting transform %s: %w", s, err)
+		}
+		values[i] = value
+	}
+
+	transform := MountingTransform{Rotation: RotationFromEuler(radians(values[0]), radians(values[1]), radians(values[2]))}
+	if len(values) == 6 {
+		transform.Translation = [3]float64{values[3], values[4], values[5]}
+	}
+	return transform, nil
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// validate checks that the rotation is orthonormal and keeps handedness, so it does not scale or mirror readings.
+func (m MountingTransform) validate() error {
+	r := m.Rotation
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			dot := r[i][0]*r[j][0] + r[i][1]*r[j][1] + r[i][2]*r[j][2]
+			want := 0.0
+			if i == j {
+				want = 1
+			}
+			if math.Abs(dot-want) > rotationTolerance {
+				return fmt.Errorf("invalid mounting rotation %v: rows are not orthonormal", r)
+			}
+		}
+	}
+	determinant := r[0][0]*(r[1][1]*r[2][2]-r[1][2]*r[2][1]) -
+		r[0][1]*(r[1][0]*r[2][2]-r[1][2]*r[2][0]) +
+		r[0][2]*(r[1][0]*r[2][1]-r[1][1]*r[2][0])
+	if math.Abs(determinant-1) > rotationTolerance {
+		return fmt.Errorf("invalid mounting rotation %v: it mirrors the axes", r)
+	}
+	return nil
+}
+
+func (m MountingTransform) rotate(x float64, y float64, z float64) (float64, float64, float64) {
+	r := m.Rotation
+	return r[0][0]*x + r[0][1]*y + r[0][2]*z,
+		r[1][0]*x + r[1][1]*y + r[1][2]*z,
+		r[2][0]*x + r[2][1]*y + r[2][2]*z
+}
+
+// ApplyToPoint turns a point in glass axes into rig axes.
+func (m MountingTransform) ApplyToPoint(point [3]float64) [3]float64 {
+	x, y, z := m.rotate(point[0], point[1], point[2])
+	return [3]float64{x + m.Translation[0], y + m.Translation[1], z + m.Translation[2]}
+}
+
+// ApplyToIMU rotates the readings into rig axes. Translation does not apply, the accelerometer is not corrected for
+// the lever arm between the glass and the rig origin while rotating.
+func (m MountingTransform) ApplyToIMU(imu *IMUEvent) {
+	if imu.Gyroscope != nil {
+		x, y, z := m.rotate(float64(imu.Gyroscope.X), float64(imu.Gyroscope.Y), float64(imu.Gyroscope.Z))
+		imu.Gyroscope = &GyroscopeVector{X: float32(x), Y: float32(y), Z: float32(z)}
+	}
+	if imu.Accelerometer != nil {
+		x, y, z := m.rotate(float64(imu.Accelerometer.X), float64(imu.Accelerometer.Y), float64(imu.Accelerometer.Z))
+		imu.Accelerometer = &AccelerometerVector{X: float32(x), Y: float32(y), Z: float32(z)}
+	}
+}
+
+// ApplyToMagnetometer rotates X, Y and Z into rig axes, the integer readings are kept as reported.
+func (m MountingTransform) ApplyToMagnetometer(vector *MagnetometerVector) {
+	vector.X, vector.Y, vector.Z = m.rotate(vector.X, vector.Y, vector.Z)
+}
+
+// mountingState is shared by all glasses of the process, as they are mounted on the same rig.
+type mountingState struct {
+	// mutex for thread safety
+	mutex     sync.Mutex
+	transform *MountingTransform
+}
+
+var mounting = &mountingState{}
+
+// SetMountingTransform applies the rotation to the IMU and magnetometer events of every glass from now on, so
+// orientations fused from them, e.g. by fusion.ComplementaryFilter, are of the rig. The translation is kept for
+// GetMountingTransform().ApplyToPoint. An invalid rotation, which would scale or mirror readings, is rejected.
+func SetMountingTransform(rotation [3][3]float64, translation [3]float64) error {
+	transform := MountingTransform{Rotation: rotation, Translation: translation}
+	if err := transform.validate(); err != nil {
+		return err
+	}
+
+	mounting.mutex.Lock()
+	defer mounting.mutex.Unlock()
+	if transform == IDENTITY_MOUNTING_TRANSFORM {
+		mounting.transform = nil
+	} else {
+		mounting.transform = &transform
+	}
+	return nil
+}
+
+// GetMountingTransform returns the transform set by SetMountingTransform, IDENTITY_MOUNTING_TRANSFORM by default.
+func GetMountingTransform() MountingTransform {
+	mounting.mutex.Lock()
+	defer mounting.mutex.Unlock()
+	if mounting.transform == nil {
+		return IDENTITY_MOUNTING_TRANSFORM
+	}
+	return *mounting.transform
+}
+
+// current returns the transform to apply to events, nil if the glass is not remapped.
+func (s *mountingState) current() *MountingTransform {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.transform
+}
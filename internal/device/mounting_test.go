@@ -0,0 +1,63 @@
+package device
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMountingTransform(t *testing.T) {
+	transform, err := ParseMountingTransform("0,0,90,0,0.1,0")
+	if err != nil {
+		t.Fatalf("ParseMountingTransform() failed: %v", err)
+	}
+	want := [3][3]float64{{0, -1, 0}, {1, 0, 0}, {0, 0, 1}}
+	if transform.Rotation != want || transform.Translation != [3]float64{0, 0.1, 0} {
+		t.Fatalf("ParseMountingTransform() = %v, want rotation %v", transform, want)
+	}
+
+	imu := &IMUEvent{
+		Gyroscope:     &GyroscopeVector{X: 1, Y: 2, Z: 3},
+		Accelerometer: &AccelerometerVector{X: 0, Y: 9.81, Z: 0},
+		TimeSinceBoot: 7,
+	}
+	transform.ApplyToIMU(imu)
+	if *imu.Gyroscope != (GyroscopeVector{X: -2, Y: 1, Z: 3}) || *imu.Accelerometer != (AccelerometerVector{X: -9.81}) || imu.TimeSinceBoot != 7 {
+		t.Errorf("ApplyToIMU() = %s, want gyro (-2, 1, 3) and accel (-9.81, 0, 0)", imu)
+	}
+
+	vector := &MagnetometerVector{X: 10, RawX: 100}
+	transform.ApplyToMagnetometer(vector)
+	if vector.X != 0 || vector.Y != 10 || vector.RawX != 100 {
+		t.Errorf("ApplyToMagnetometer() = %+v, want (0, 10, 0) keeping raw readings", vector)
+	}
+
+	if point := transform.ApplyToPoint([3]float64{1, 0, 0}); math.Abs(point[1]-1.1) > 1e-9 {
+		t.Errorf("ApplyToPoint() = %v, want (0, 1.1, 0)", point)
+	}
+}
+
+func TestSetMountingTransform(t *testing.T) {
+	defer SetMountingTransform(IDENTITY_MOUNTING_TRANSFORM.Rotation, [3]float64{})
+
+	mirrored := [3][3]float64{{-1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	if err := SetMountingTransform(mirrored, [3]float64{}); err == nil {
+		t.Errorf("SetMountingTransform() of a mirroring rotation succeeded, want error")
+	}
+	scaled := [3][3]float64{{2, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	if err := SetMountingTransform(scaled, [3]float64{}); err == nil {
+		t.Errorf("SetMountingTransform() of a scaling rotation succeeded, want error")
+	}
+
+	rotation := RotationFromEuler(math.Pi/6, 0, 0)
+	if err := SetMountingTransform(rotation, [3]float64{}); err != nil {
+		t.Fatalf("SetMountingTransform() failed: %v", err)
+	}
+	if GetMountingTransform().Rotation != rotation || mounting.current() == nil {
+		t.Errorf("GetMountingTransform() = %v, want rotation %v", GetMountingTransform(), rotation)
+	}
+
+	SetMountingTransform(IDENTITY_MOUNTING_TRANSFORM.Rotation, [3]float64{})
+	if mounting.current() != nil {
+		t.Errorf("identity transform is applied to events, want none")
+	}
+}
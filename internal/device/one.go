@@ -0,0 +1,477 @@
+package device
+
+import (
+	"encoding/hex"
+	"fmt"
+	"image"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"xreal-light-xr-go/constant"
+	"xreal-light-xr-go/logging"
+	"xreal-light-xr-go/storage"
+
+	hid "github.com/sstallion/go-hid"
+)
+
+// oneRawReadTimeout is how long DevExecuteAndRead waits for the glass to answer a raw report.
+const oneRawReadTimeout = 1 * time.Second
+
+// xrealOne is a skeleton of the One series, which computes its 3DoF orientation on board. Only connecting and
+// sending raw reports for studying the protocol are supported so far. Its VID and PIDs are not known here, so it is
+// not listed by ListGlasses and connects by hid path, or as a model registered with RegisterModel.
+type xrealOne struct {
+	// devicePath is the hid path of the glass
+	devicePath string
+
+	// deviceHandlers contains callback funcs for the events from the glass device
+	deviceHandlers *DeviceHandlers
+
+	// mutex for thread safety
+	mutex  sync.Mutex
+	device *hid.Device
+}
+
+// Name does not tell the One and One Pro apart, as the glass is not identified yet.
+func (o *xrealOne) Name() string {
+	return constant.XREAL_ONE
+}
+
+func (o *xrealOne) PID() uint16 {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if o.device == nil {
+		return 0
+	}
+	if info, err := o.device.GetDeviceInfo(); err == nil {
+		return info.ProductID
+	}
+	return 0
+}
+
+func (o *xrealOne) VID() uint16 {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if o.device == nil {
+		return 0
+	}
+	if info, err := o.device.GetDeviceInfo(); err == nil {
+		return info.VendorID
+	}
+	return 0
+}
+
+func (o *xrealOne) Connect() error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.devicePath == "" {
+		return fmt.Errorf("the XREAL One is not detected yet, connect it by its hid path")
+	}
+
+	device, err := hid.OpenPath(o.devicePath)
+	if err != nil {
+		return fmt.Errorf("failed to open the device path %s: %w", o.devicePath, err)
+	}
+	o.device = device
+	markGlassConnected(o.devicePath, o)
+	slog.Warn(fmt.Sprintf("%s support is a skeleton, only raw reports can be sent with 'test raw <hex>'", o.Name()))
+	return nil
+}
+
+func (o *xrealOne) Disconnect() error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.device == nil {
+		return nil
+	}
+	markGlassDisconnected(o.devicePath)
+	err := o.device.Close()
+	o.device = nil
+	if err != nil {
+		return fmt.Errorf("failed to close %s: %w", o.devicePath, err)
+	}
+	return nil
+}
+
+func (o *xrealOne) GetRole() (Role, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetSerial() (string, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if o.device == nil {
+		return "", fmt.Errorf("glass device is not connected yet")
+	}
+	info, err := o.device.GetDeviceInfo()
+	if err != nil {
+		return "", fmt.Errorf("failed to get serial: %w", err)
+	}
+	return info.SerialNbr, nil
+}
+
+func (o *xrealOne) GetMCUInfo() (*ConnectionInfo, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return hidConnectionInfo(COMPONENT_MCU, o.device, &o.devicePath), nil
+}
+
+func (o *xrealOne) GetSensorInfo() (*ConnectionInfo, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetCameraInfo() ([]ConnectionInfo, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+// InvalidateInfoCache does nothing, as nothing is cached yet.
+func (o *xrealOne) InvalidateInfoCache() {}
+
+func (o *xrealOne) Idle() error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetFramePipeline(pipeline FramePipeline) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetCapturePolicy(policy CapturePolicy) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetCapabilities() (*Capabilities, error) {
+	return &Capabilities{OnboardTracking: true}, nil
+}
+
+func (o *xrealOne) ExecuteAsync(instruction CommandInstruction, payload []byte) *CommandFuture {
+	return resolvedCommandFuture(unimplemented("ExecuteAsync"))
+}
+
+func (o *xrealOne) ExecuteRaw(command Command, payload []byte) ([]byte, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) ExecuteRawOV580(command Command, value uint8) ([]byte, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetAmbientLightEventHandler(handler AmbientLightEventHandler) {
+	o.deviceHandlers.AmbientLightEventHandler = handler
+}
+
+func (o *xrealOne) SetKeyEventHandler(handler KeyEventHandler) {
+	o.deviceHandlers.KeyEventHandler = handler
+}
+
+func (o *xrealOne) SetMagnetometerEventHandler(handler MagnetometerEventHandler) {
+	o.deviceHandlers.MagnetometerEventHandler = handler
+}
+
+func (o *xrealOne) SetProximityEventHandler(handler ProximityEventHandler) {
+	o.deviceHandlers.ProximityEventHandler = handler
+}
+
+func (o *xrealOne) SetTemperatureEventHandler(handler TemperatureEventHandlder) {
+	o.deviceHandlers.TemperatureEventHandlder = handler
+}
+
+func (o *xrealOne) SetVSyncEventHandler(handler VSyncEventHandler) {
+	o.deviceHandlers.VSyncEventHandler = handler
+}
+
+func (o *xrealOne) SetIMUEventHandler(handler IMUEventHandler) {
+	o.deviceHandlers.IMUEventHandler = handler
+}
+
+func (o *xrealOne) GetIMUEventHandler() IMUEventHandler {
+	return o.deviceHandlers.IMUEventHandler
+}
+
+func (o *xrealOne) SetResumedEventHandler(handler ResumedEventHandler) {
+	o.deviceHandlers.ResumedEventHandler = handler
+}
+
+func (o *xrealOne) SetErrorHandler(handler ErrorHandler) {
+	o.deviceHandlers.ErrorHandler = handler
+}
+
+// DevExecuteAndRead writes a raw hid report given as hex bytes, e.g. `test raw 02 aa 00`, and logs what the glass
+// answers within oneRawReadTimeout, to study the protocol.
+func (o *xrealOne) DevExecuteAndRead(device string, input []string) {
+	if device != "raw" {
+		slog.Error(fmt.Sprintf("%s only supports raw reports, use 'test raw <hex>'", o.Name()))
+		return
+	}
+	report, err := hex.DecodeString(strings.Join(input, ""))
+	if err != nil || len(report) == 0 {
+		slog.Error(fmt.Sprintf("wrong input format: want hex bytes, got %v", input))
+		return
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if o.device == nil {
+		slog.Error("glass device is not connected yet")
+		return
+	}
+	if _, err := o.device.Write(report); err != nil {
+		slog.Error(fmt.Sprintf("failed to write % x: %v", report, err))
+		return
+	}
+
+	var buffer [64]byte
+	n, err := o.device.ReadWithTimeout(buffer[:], oneRawReadTimeout)
+	if err != nil {
+		slog.Error(fmt.Sprintf("% x : failed to read: %v", report, err))
+		return
+	}
+	slog.Info(fmt.Sprintf("% x : % x", report, buffer[:n]))
+}
+
+func (o *xrealOne) RequestControl(timeout time.Duration) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) ReleaseControl() error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetFirmwareVersion() (string, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetStockFirmwareVersion() (string, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetDisplayFirmware() (*DisplayVersion, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetDisplayHDCPVersion() (*DisplayVersion, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetOV580Info() (*OV580Info, error) {
+	return nil, unimplemented("GetOV580Info")
+}
+
+func (o *xrealOne) ReadOV580File(id uint8, options OV580FileOptions) (*OV580File, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) ResetSensors() error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetRetryPolicy(policy RetryPolicy) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetStreamWatchdog(policy *WatchdogPolicy) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetBrightnessLevel() (string, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetBrightnessLevel(level string) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetOLEDBrightnessLevel() (string, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetOLEDBrightnessLevel(level string) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetOLEDBrightnessBrit() (string, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetKeySwitchEnabled() (bool, error) {
+	return false, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetKeySwitchEnabled(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetDefault2DEnabled() (bool, error) {
+	return false, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetDefault2DEnabled(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetOrbitFunction() (string, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetOrbitFunction(open bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetSuperActive() (bool, error) {
+	return false, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetSuperActive(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetRGBCameraEnabled() (bool, error) {
+	return false, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetClockSync() (*ClockSync, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetConfigValue(key string) (string, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetConfigValue(key string, value string) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetSleepTime() (string, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetSleepTime(seconds string) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) DisplayOff() error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) DisplayOn() error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetDisplayMode() (DisplayMode, error) {
+	return "", fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetDisplayMode(mode DisplayMode) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) EnterSBS(duration time.Duration) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) ExitSBS() error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetImages(folderpath string) ([]string, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) CaptureImages(store storage.Storage) ([]string, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetSLAMFrame() (image.Image, image.Image, time.Time, error) {
+	return nil, nil, time.Time{}, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetSLAMFrameRaw() (*SLAMFrame, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetSLAMStreamFormats() ([]StreamFormat, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetSLAMStreamConfig(config StreamConfig) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) GetRGBStreamFormats() ([]StreamFormat, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) SetRGBStreamConfig(config StreamConfig) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) EnableEventReporting(event CommandInstruction, enabled string) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) EnableVSync(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) EnableAmbientLight(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) EnableMagnetometer(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) EnableTemperature(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) EnableIMU(enabled bool) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (o *xrealOne) IsIMUEnabled() bool {
+	return false
+}
+
+func (o *xrealOne) GetImagesDataDev(folderpath string) ([]string, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+// NewXREALOne creates a xrealOne instance, devicePath is the hid path of the glass and required to connect.
+func NewXREALOne(devicePath *string) Device {
+	o := &xrealOne{
+		deviceHandlers: &DeviceHandlers{
+			AmbientLightEventHandler: func(value uint16) {
+				slog.Info(fmt.Sprintf("Ambient light: %d", value))
+			},
+			KeyEventHandler: func(key KeyEvent) {
+				slog.Info(fmt.Sprintf("Key pressed: %s", key.String()))
+			},
+			MagnetometerEventHandler: func(vector *MagnetometerVector) {
+				slog.Info(fmt.Sprintf("Magnetometer: %s", vector.String()))
+			},
+			ProximityEventHandler: func(proximity ProximityEvent) {
+				slog.Info(fmt.Sprintf("Proximity: %s", proximity.String()))
+			},
+			TemperatureEventHandlder: func(value string) {
+				slog.Info(fmt.Sprintf("Temperature: %s", value))
+			},
+			VSyncEventHandler: func(value string) {
+				slog.Info(fmt.Sprintf("VSync: %s", value))
+			},
+			IMUEventHandler: func(imu *IMUEvent) {
+				slog.Info(fmt.Sprintf("IMU: %s", imu.String()), logging.MODULE_KEY, logging.MODULE_IMU)
+			},
+			ResumedEventHandler: func() {
+				slog.Info("Resumed: glass reconnected")
+			},
+		},
+	}
+	if devicePath != nil {
+		o.devicePath = *devicePath
+	}
+	return o
+}
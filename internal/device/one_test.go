@@ -0,0 +1,10 @@
+package device
+
+import "testing"
+
+func TestOneCapabilities(t *testing.T) {
+	capabilities, _ := NewXREALOne(nil).GetCapabilities()
+	if !capabilities.OnboardTracking {
+		t.Errorf("GetCapabilities() = %s, want on board tracking", capabilities)
+	}
+}
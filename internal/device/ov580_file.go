@@ -0,0 +1,165 @@
+package device
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// OV580_CALIBRATION_FILE_ID is the file of the IMU and camera calibration, sent as the value of
+// OV580_GET_CALIBRATION_FILE_LENGTH and OV580_GET_CALIBRATION_FILE_PART. Other values may select other files, none is
+// known so far.
+const OV580_CALIBRATION_FILE_ID = uint8(0x1)
+
+// OV580FileOptions tune Device.ReadOV580File.
+type OV580FileOptions struct {
+	// Progress is called after every part with the bytes read so far and the length of the file, nil for none
+	Progress func(read int, total int)
+	// Verify reads the file twice and fails with ErrChecksumMismatch if the reads differ, as parts carry no checksum
+	Verify bool
+}
+
+// OV580File is a file read from the OV580, see Device.ReadOV580File.
+type OV580File struct {
+	ID uint8
+	// Length is the raw length reported by the OV580 before the transfer
+	Length []byte
+	// Checksum is the hex encoded SHA-256 of Data
+	Checksum string
+	Data     []byte
+}
+
+// errFileIncomplete is returned when the parts read do not add up to the length of the file, e.g. as the response to a
+// part was lost and the OV580 moved on to the next one, so the transfer has to start over.
+var errFileIncomplete = errors.New("file transfer incomplete")
+
+// readFile reads file id, starting over if parts went missing. stop is called after every part with the file read so
+// far and its length, returning true ends the transfer early and readFile returns nil without error.
+func (l *xrealLightOV580) readFile(id uint8, stop func(file *OV580File, total int) bool) (*OV580File, error) {
+	// a transfer is a sequence of commands the OV580 keeps the position of, so transfers must not interleave
+	l.fileMutex.Lock()
+	defer l.fileMutex.Unlock()
+
+	policy := getRetryPolicy(&l.retryPolicy)
+	var err error
+	for attempt := 1; ; attempt++ {
+		var file *OV580File
+		file, err = l.readFileOnce(id, stop)
+		if err == nil {
+			return file, nil
+		}
+		if attempt >= policy.MaxAttempts || !errors.Is(err, errFileIncomplete) {
+			return nil, err
+		}
+		slog.Debug(fmt.Sprintf("ov580 file %#x transfer failed, starting over: %v", id, err))
+	}
+}
+
+func (l *xrealLightOV580) readFileOnce(id uint8, stop func(file *OV580File, total int) bool) (*OV580File, error) {
+	policy := getRetryPolicy(&l.retryPolicy)
+
+	// asking for the length resets the position of the transfer
+	command := GetFirmwareIndependentCommand(OV580_GET_CALIBRATION_FILE_LENGTH)
+	var response []byte
+	err := policy.Do(func() (err error) {
+		response, err = l.executeAndWaitForResponse(command, id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s: %w", command.String(), err)
+	}
+	length, total, err := parseOV580FileLength(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s: %w", command.String(), err)
+	}
+	file := &OV580File{ID: id, Length: length}
+	slog.Debug(fmt.Sprintf("ov580 file %#x length: %d", id, total))
+
+	command = GetFirmwareIndependentCommand(OV580_GET_CALIBRATION_FILE_PART)
+	// every part but the last carries at least a byte, so a transfer of more parts went wrong
+	for parts := 0; ; parts++ {
+		if parts > total {
+			return nil, fmt.Errorf("%w: more than %d parts for %d bytes", errFileIncomplete, parts-1, total)
+		}
+		// a lost response is asked for again, if the OV580 moved on meanwhile the length check below catches it
+		err := policy.Do(func() (err error) {
+			response, err = l.executeAndWaitForResponse(command, id)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to %s: %w", command.String(), err)
+		}
+		part, last, err := parseOV580FilePart(response)
+		if err != nil {
+			return nil, err
+		}
+		if last {
+			break
+		}
+		file.Data = append(file.Data, part...)
+		if len(file.Data) > total {
+			return nil, fmt.Errorf("%w: read %d bytes of a file of %d", errFileIncomplete, len(file.Data), total)
+		}
+
+		if stop != nil && stop(file, total) {
+			// the rest is left unread, asking for the length first like every transfer is expected to start over
+			return nil, nil
+		}
+	}
+
+	if len(file.Data) != total {
+		return nil, fmt.Errorf("%w: read %d of %d bytes", errFileIncomplete, len(file.Data), total)
+	}
+	file.Checksum = calibrationChecksum(file.Data)
+	return file, nil
+}
+
+// parseOV580FileLength returns the raw length of the file in the response to OV580_GET_CALIBRATION_FILE_LENGTH, and
+// its value.
+func parseOV580FileLength(response []byte) ([]byte, int, error) {
+	if len(response) < 6 {
+		return nil, 0, fmt.Errorf("response of %d bytes is too short for a file length", len(response))
+	}
+	length := bytes.Clone(response[3:6])
+	return length, int(length[0]) | int(length[1])<<8 | int(length[2])<<16, nil
+}
+
+// parseOV580FilePart returns the data in the response to OV580_GET_CALIBRATION_FILE_PART, and whether it tells the
+// file was read entirely instead.
+func parseOV580FilePart(response []byte) ([]byte, bool, error) {
+	if len(response) < 3 {
+		return nil, false, fmt.Errorf("%w: response of %d bytes is too short for a part", errFileIncomplete, len(response))
+	}
+	if response[1] == 0x3 {
+		return nil, true, nil
+	}
+	end := 3 + int(response[2])
+	if end > len(response) {
+		return nil, false, fmt.Errorf("%w: part of %d bytes does not fit its response", errFileIncomplete, response[2])
+	}
+	return response[3:end], false, nil
+}
+
+// readVerifiedFile is Device.ReadOV580File.
+func (l *xrealLightOV580) readVerifiedFile(id uint8, options OV580FileOptions) (*OV580File, error) {
+	stop := func(file *OV580File, total int) bool {
+		if options.Progress != nil {
+			options.Progress(len(file.Data), total)
+		}
+		return false
+	}
+	file, err := l.readFile(id, stop)
+	if err != nil || !options.Verify {
+		return file, err
+	}
+
+	again, err := l.readFile(id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if again.Checksum != file.Checksum {
+		return nil, fmt.Errorf("%w: ov580 file %#x read as %s and %s", ErrChecksumMismatch, id, file.Checksum, again.Checksum)
+	}
+	return file, nil
+}
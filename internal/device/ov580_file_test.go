@@ -0,0 +1,37 @@
+package device
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseOV580FileLength(t *testing.T) {
+	length, total, err := parseOV580FileLength([]byte{0x02, 0x0, 0x0, 0x34, 0x12, 0x01, 0x0})
+	if err != nil || total != 0x011234 || len(length) != 3 {
+		t.Errorf("parseOV580FileLength() = %v, %d, %v; want 3 bytes of 0x011234", length, total, err)
+	}
+	if _, _, err := parseOV580FileLength([]byte{0x02, 0x0, 0x0, 0x34}); err == nil {
+		t.Errorf("parseOV580FileLength() of a short response = nil, want error")
+	}
+}
+
+func TestParseOV580FilePart(t *testing.T) {
+	testCases := []struct {
+		response []byte
+		part     string
+		last     bool
+		err      error
+	}{
+		{[]byte{0x02, 0x1, 0x2, 'a', 'b', 'c'}, "ab", false, nil},
+		{[]byte{0x02, 0x3, 0x0}, "", true, nil},
+		{[]byte{0x02, 0x1, 0x9, 'a'}, "", false, errFileIncomplete},
+		{[]byte{0x02, 0x1}, "", false, errFileIncomplete},
+	}
+
+	for _, tc := range testCases {
+		part, last, err := parseOV580FilePart(tc.response)
+		if string(part) != tc.part || last != tc.last || !errors.Is(err, tc.err) {
+			t.Errorf("parseOV580FilePart(%v) = %q, %t, %v; want %q, %t, %v", tc.response, part, last, err, tc.part, tc.last, tc.err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	libusb "github.com/gotmc/libusb/v2"
+)
+
+// ErrOV580BadState is returned on connecting when the OV580 is missing but enumerates with one of
+// knownAlternateOV580PIDs, which some units do while the OV580 is in a bad state.
+var ErrOV580BadState = errors.New("OV580 may be in a bad state")
+
+// knownAlternateOV580PIDs are the PIDs the OV580 is known to enumerate with while in a bad state. None is documented
+// yet, add them here once seen on a glass together with the state they go with.
+var knownAlternateOV580PIDs = map[uint16]struct{}{}
+
+// usbID is the vendor and product ID of a USB device.
+type usbID struct {
+	VID uint16
+	PID uint16
+}
+
+func (id usbID) String() string {
+	return fmt.Sprintf("%04x:%04x", id.VID, id.PID)
+}
+
+// alternateOV580s splits the OmniVision devices that are not an OV580 in its normal state into the ones with one of
+// knownAlternateOV580PIDs and the others, which may as well be unrelated, e.g. a laptop webcam.
+func alternateOV580s(devices []usbID) (known []usbID, others []usbID) {
+	for _, id := range devices {
+		if id.VID != XREAL_LIGHT_OV580_VID || id.PID == XREAL_LIGHT_OV580_PID {
+			continue
+		}
+		if _, ok := knownAlternateOV580PIDs[id.PID]; ok {
+			known = append(known, id)
+		} else {
+			others = append(others, id)
+		}
+	}
+	return known, others
+}
+
+// checkOV580State tells why no OV580 was found, wrapping ErrOV580BadState if it seems to be in a bad state. libusb
+// is used as the OV580 may not enumerate as a hid device then.
+func checkOV580State() error {
+	ctx, err := libusb.NewContext()
+	if err != nil {
+		return fmt.Errorf("no XREAL Light glass OV580 hid devices found")
+	}
+	defer ctx.Close()
+
+	devices, err := ctx.DeviceList()
+	if err != nil {
+		return fmt.Errorf("no XREAL Light glass OV580 hid devices found")
+	}
+	var ids []usbID
+	for _, device := range devices {
+		descriptor, err := device.DeviceDescriptor()
+		if err != nil {
+			continue
+		}
+		ids = append(ids, usbID{VID: descriptor.VendorID, PID: descriptor.ProductID})
+	}
+
+	known, others := alternateOV580s(ids)
+	if len(known) > 0 {
+		return fmt.Errorf(
+			"%w: no %s found but %s, replug the glass if resetting it does not help",
+			ErrOV580BadState, usbID{VID: XREAL_LIGHT_OV580_VID, PID: XREAL_LIGHT_OV580_PID}, joinUSBIDs(known),
+		)
+	}
+	if len(others) > 0 {
+		// not reset automatically, as these may not be the OV580 at all
+		return fmt.Errorf(
+			"no XREAL Light glass OV580 hid devices found, but OmniVision device %s is attached: if it is the OV580 in a bad state, replug the glass",
+			joinUSBIDs(others),
+		)
+	}
+	return fmt.Errorf("no XREAL Light glass OV580 hid devices found")
+}
+
+func joinUSBIDs(ids []usbID) string {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = id.String()
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,29 @@
+package device
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAlternateOV580s(t *testing.T) {
+	knownAlternateOV580PIDs[0x1234] = struct{}{}
+	defer delete(knownAlternateOV580PIDs, 0x1234)
+
+	devices := []usbID{
+		{VID: XREAL_LIGHT_MCU_VID, PID: XREAL_LIGHT_MCU_PID},
+		{VID: XREAL_LIGHT_OV580_VID, PID: XREAL_LIGHT_OV580_PID},
+		{VID: XREAL_LIGHT_OV580_VID, PID: 0x1234},
+		{VID: XREAL_LIGHT_OV580_VID, PID: 0x5678},
+		{VID: XREAL_LIGHT_RGB_CAM_VID, PID: XREAL_LIGHT_RGB_CAM_PID},
+	}
+	known, others := alternateOV580s(devices)
+	if want := []usbID{{VID: XREAL_LIGHT_OV580_VID, PID: 0x1234}}; !reflect.DeepEqual(known, want) {
+		t.Errorf("alternateOV580s() known = %v, want %v", known, want)
+	}
+	if want := []usbID{{VID: XREAL_LIGHT_OV580_VID, PID: 0x5678}}; !reflect.DeepEqual(others, want) {
+		t.Errorf("alternateOV580s() others = %v, want %v", others, want)
+	}
+	if known, others := alternateOV580s(devices[:2]); known != nil || others != nil {
+		t.Errorf("alternateOV580s() without other OmniVision devices = %v, %v, want nil", known, others)
+	}
+}
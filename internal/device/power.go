@@ -0,0 +1,115 @@
+package device
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+)
+
+// MIN_SLEEP_TIME_SECONDS is the sleep time the glass requires to be exceeded
+const MIN_SLEEP_TIME_SECONDS = 20
+
+// PowerProfile is a preset of settings trading display quality for power, e.g. when the glass is powered by a phone.
+type PowerProfile string
+
+const (
+	POWER_PROFILE_MAX_QUALITY PowerProfile = "max-quality"
+	POWER_PROFILE_BALANCED    PowerProfile = "balanced"
+	POWER_PROFILE_POWER_SAVER PowerProfile = "power-saver"
+)
+
+// PowerSettings are the settings applied by a PowerProfile. Empty fields are left untouched.
+type PowerSettings struct {
+	// BrightnessLevel is 0-7
+	BrightnessLevel string
+	DisplayMode     DisplayMode
+	// SleepTime is in seconds, see MIN_SLEEP_TIME_SECONDS
+	SleepTime string
+	// RGBCamera and IMUStream are '0'/'1', same as EnableEventReporting
+	RGBCamera string
+	IMUStream string
+}
+
+var powerProfiles = map[PowerProfile]PowerSettings{
+	POWER_PROFILE_MAX_QUALITY: {
+		BrightnessLevel: "7",
+		DisplayMode:     DISPLAY_MODE_HIGH_REFRESH_RATE,
+		SleepTime:       "300",
+	},
+	POWER_PROFILE_BALANCED: {
+		BrightnessLevel: "4",
+		DisplayMode:     DISPLAY_MODE_SAME_ON_BOTH,
+		SleepTime:       "60",
+		RGBCamera:       "0",
+	},
+	POWER_PROFILE_POWER_SAVER: {
+		BrightnessLevel: "1",
+		DisplayMode:     DISPLAY_MODE_SAME_ON_BOTH,
+		SleepTime:       "30",
+		RGBCamera:       "0",
+		IMUStream:       "0",
+	},
+}
+
+// SupportedPowerProfiles returns the names of all power profiles.
+func SupportedPowerProfiles() []string {
+	names := make([]string, 0, len(powerProfiles))
+	for profile := range powerProfiles {
+		names = append(names, string(profile))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetPowerSettings returns the settings the profile applies.
+func GetPowerSettings(profile PowerProfile) (PowerSettings, error) {
+	settings, ok := powerProfiles[profile]
+	if !ok {
+		return PowerSettings{}, fmt.Errorf("unknown power profile: got (%s) want one of (%v)", profile, SupportedPowerProfiles())
+	}
+	return settings, nil
+}
+
+// ApplyPowerProfile configures the glass with the settings of the profile in one call.
+// It stops at the first setting that fails, so the glass may be left partially configured.
+func ApplyPowerProfile(d Device, profile PowerProfile) error {
+	settings, err := GetPowerSettings(profile)
+	if err != nil {
+		return err
+	}
+	if err := ApplyPowerSettings(d, settings); err != nil {
+		return fmt.Errorf("failed to apply power profile %s: %w", profile, err)
+	}
+	slog.Info(fmt.Sprintf("applied power profile %s", profile))
+	return nil
+}
+
+// ApplyPowerSettings configures the glass with custom settings, e.g. a tweaked copy of GetPowerSettings.
+func ApplyPowerSettings(d Device, settings PowerSettings) error {
+	if settings.BrightnessLevel != "" {
+		if err := d.SetBrightnessLevel(settings.BrightnessLevel); err != nil {
+			return fmt.Errorf("failed to set brightness level: %w", err)
+		}
+	}
+	if settings.DisplayMode != "" {
+		if err := d.SetDisplayMode(settings.DisplayMode); err != nil {
+			return fmt.Errorf("failed to set display mode: %w", err)
+		}
+	}
+	if settings.SleepTime != "" {
+		if err := d.SetSleepTime(settings.SleepTime); err != nil {
+			return fmt.Errorf("failed to set sleep time: %w", err)
+		}
+	}
+	if settings.RGBCamera != "" {
+		if err := d.EnableEventReporting(CMD_ENABLE_RGB_CAMERA, settings.RGBCamera); err != nil {
+			return fmt.Errorf("failed to toggle RGB camera: %w", err)
+		}
+	}
+	if settings.IMUStream != "" {
+		if err := d.EnableEventReporting(OV580_ENABLE_IMU_STREAM, settings.IMUStream); err != nil {
+			return fmt.Errorf("failed to toggle IMU stream: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package device_test
+
+import (
+	"strconv"
+	"testing"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestPowerProfiles(t *testing.T) {
+	for _, name := range device.SupportedPowerProfiles() {
+		settings, err := device.GetPowerSettings(device.PowerProfile(name))
+		if err != nil {
+			t.Fatalf("GetPowerSettings(%s) failed: %v", name, err)
+		}
+		if level := settings.BrightnessLevel; len(level) != 1 || level[0] < '0' || level[0] > '7' {
+			t.Errorf("%s: got brightness level %s; expected single digit 0-7", name, level)
+		}
+		if _, ok := device.SupportedDisplayMode[string(settings.DisplayMode)]; !ok {
+			t.Errorf("%s: got unsupported display mode %s", name, settings.DisplayMode)
+		}
+		if seconds, err := strconv.Atoi(settings.SleepTime); err != nil || seconds <= device.MIN_SLEEP_TIME_SECONDS {
+			t.Errorf("%s: got sleep time %s; expected integer larger than %d", name, settings.SleepTime, device.MIN_SLEEP_TIME_SECONDS)
+		}
+	}
+
+	if _, err := device.GetPowerSettings("turbo"); err == nil {
+		t.Errorf("GetPowerSettings(turbo) succeeded; expected error")
+	}
+}
@@ -0,0 +1,252 @@
+package device
+
+//go:generate go run ./docgen ../../docs/protocol.md
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// protocolDocsTemplate renders the protocol reference of the Light, everything in it comes from the protocol table
+// and the packet code of this package so it never drifts from what the driver sends.
+var protocolDocsTemplate = template.Must(template.New("protocol").Parse(`<!-- Code generated by go generate ./internal/device from the protocol table of the driver; DO NOT EDIT. -->
+
+# XREAL Light protocol reference
+
+The commands the driver knows, as implemented in package ` + "`internal/device`" + `. Commands missing here are either
+unknown or only listed in the notes at the bottom of light_command.go, and are refused in safe builds.
+
+## MCU packets
+
+Packets are 64 byte HID reports, zero padded, of ` + "`\\x02:<type>:<id>:<payload>:<timestamp>:<crc>:\\x03`" + `: the type and ID
+are single bytes, the timestamp is the hex milliseconds since the epoch and the CRC is the hex CRC32 of everything
+before it. Commands without input carry a single space as payload. For example {{.Example.Name}}:
+
+    {{.Example.Packet}}
+
+| Type | Kind | Response type |
+|------|------|---------------|
+{{- range .Types}}
+| {{.Type}} | {{.Kind}} | {{.Response}} |
+{{- end}}
+
+Commands not listed below are of unknown danger level whatever their type, and refused in safe builds.
+
+## MCU commands
+
+| Command | Type | ID | Firmware | Danger level | Payload | Notes |
+|---------|------|----|----------|--------------|---------|-------|
+{{- range .Commands}}
+| {{.Name}} | {{.Type}} | {{.ID}} | {{.Firmware}} | {{.Danger}} | {{.Payload}} | {{.Notes}} |
+{{- end}}
+
+## MCU events
+
+Events are sent by the glass unprompted, in packets of the same format.
+
+| Event | Type | ID | Notes |
+|-------|------|----|-------|
+{{- range .Events}}
+| {{.Name}} | {{.Type}} | {{.ID}} | {{.Notes}} |
+{{- end}}
+
+## OV580 commands
+
+OV580 commands are written as ` + "`<type> <id> <value> 0 0 0 0`" + `, the value being the input of the command.
+
+| Command | Type | ID | Danger level |
+|---------|------|----|--------------|
+{{- range .OV580}}
+| {{.Name}} | {{.Type}} | {{.ID}} | {{.Danger}} |
+{{- end}}
+
+## Config keys
+
+Keys of ` + "`get config`" + ` and ` + "`set config`" + `, see Device.GetConfigValue.
+
+| Key | Get | Set | Value |
+|-----|-----|-----|-------|
+{{- range .ConfigKeys}}
+| {{.Name}} | {{.Get}} | {{.Set}} | {{.Description}} |
+{{- end}}
+
+## Known firmware
+
+| Firmware | Notes |
+|----------|-------|
+{{- range .Firmware}}
+| {{.Version}} | {{.Notes}} |
+{{- end}}
+`))
+
+type protocolDocsCommand struct {
+	Name     string
+	Type     string
+	ID       string
+	Firmware string
+	Danger   DangerLevel
+	Payload  string
+	Notes    string
+}
+
+type protocolDocsConfigKey struct {
+	Name        string
+	Get         string
+	Set         string
+	Description string
+}
+
+type protocolDocsType struct {
+	Type     string
+	Kind     string
+	Response string
+}
+
+// protocolDocsTypes are the MCU command types as Packet.Deserialize tells them apart.
+var protocolDocsTypes = []struct {
+	commandType uint8
+	kind        string
+	responds    bool
+}{
+	{0x31, "set", true},
+	{0x33, "get", true},
+	{0x40, "set", true},
+	{0x54, "get or set", true},
+	{0x35, "event", false},
+}
+
+// GenerateProtocolDocs writes the protocol reference of the Light as Markdown to w: the packet format, the commands
+// with the firmware they work on, their danger level and payload, the events, the config keys and the known firmware.
+func GenerateProtocolDocs(w io.Writer) error {
+	example := Packet{
+		Type:      PACKET_TYPE_COMMAND,
+		Command:   GetFirmwareIndependentCommand(CMD_GET_SERIAL_NUMBER),
+		Payload:   []byte{' '},
+		Timestamp: []byte("18fd37a61db"),
+	}
+	serialized, err := example.Serialize()
+	if err != nil {
+		return fmt.Errorf("failed to serialize example packet: %w", err)
+	}
+
+	data := struct {
+		Example struct {
+			Name   string
+			Packet string
+		}
+		Types      []protocolDocsType
+		Commands   []protocolDocsCommand
+		Events     []protocolDocsCommand
+		OV580      []protocolDocsCommand
+		ConfigKeys []protocolDocsConfigKey
+		Firmware   []KnownFirmware
+	}{Firmware: GetKnownFirmware()}
+	data.Example.Name = example.Command.String()
+	data.Example.Packet = strconv.Quote(string(bytes.TrimRight(serialized[:], "\x00")))
+
+	for _, t := range protocolDocsTypes {
+		docsType := protocolDocsType{
+			Type:     formatCommandByte(t.commandType),
+			Kind:     t.kind,
+			Response: "none",
+		}
+		if t.responds {
+			docsType.Response = formatCommandByte(t.commandType + 1)
+		}
+		data.Types = append(data.Types, docsType)
+	}
+
+	for instruction := CMD_GET_BRIGHTNESS_LEVEL; instruction <= OV580_GET_CALIBRATION_FILE_PART; instruction++ {
+		switch {
+		case instruction >= OV580_ENABLE_IMU_STREAM:
+			command := GetFirmwareIndependentCommand(instruction)
+			data.OV580 = append(data.OV580, protocolDocsCommand{
+				Name:   command.String(),
+				Type:   formatCommandByte(command.Type),
+				ID:     formatCommandByte(command.ID),
+				Danger: GetOV580CommandDangerLevel(command),
+			})
+		case instruction >= MCU_EVENT_AMBIENT_LIGHT:
+			command := GetFirmwareIndependentCommand(instruction)
+			data.Events = append(data.Events, protocolDocsCommand{
+				Name:  command.String(),
+				Type:  formatCommandByte(command.Type),
+				ID:    formatCommandByte(command.ID),
+				Notes: command.Notes(),
+			})
+		default:
+			data.Commands = append(data.Commands, protocolDocsMCUCommands(instruction)...)
+		}
+	}
+
+	for _, key := range configKeys {
+		data.ConfigKeys = append(data.ConfigKeys, protocolDocsConfigKey{
+			Name:        key.Name,
+			Get:         protocolDocsConfigCommand(key.get),
+			Set:         protocolDocsConfigCommand(key.set),
+			Description: key.Description,
+		})
+	}
+
+	if err := protocolDocsTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to generate protocol docs: %w", err)
+	}
+	return nil
+}
+
+// protocolDocsMCUCommands returns a row per distinct command of instruction across the known firmware.
+func protocolDocsMCUCommands(instruction CommandInstruction) []protocolDocsCommand {
+	var payload []string
+	for _, key := range configKeys {
+		if key.set == instruction {
+			payload = append(payload, key.Description)
+		}
+	}
+
+	var rows []protocolDocsCommand
+	row := func(command *Command, firmware string) {
+		for i := range rows {
+			if rows[i].Type == formatCommandByte(command.Type) && rows[i].ID == formatCommandByte(command.ID) {
+				rows[i].Firmware += ", " + firmware
+				return
+			}
+		}
+		rows = append(rows, protocolDocsCommand{
+			Name:     command.String(),
+			Type:     formatCommandByte(command.Type),
+			ID:       formatCommandByte(command.ID),
+			Firmware: firmware,
+			Danger:   GetMCUCommandDangerLevel(command),
+			Payload:  strings.Join(payload, "; "),
+			Notes:    command.Notes(),
+		})
+	}
+
+	if command := GetFirmwareIndependentCommand(instruction); command != nil {
+		row(command, "all")
+		return rows
+	}
+	for _, firmware := range knownFirmware {
+		mcu := &xrealLightMCU{glassFirmware: firmware.Version}
+		if command := mcu.getCommand(instruction); command != nil {
+			row(command, firmware.Version)
+		}
+	}
+	return rows
+}
+
+// protocolDocsConfigCommand names the command of a config key, "-" if the key has none.
+func protocolDocsConfigCommand(instruction CommandInstruction) string {
+	if instruction == CMD_UKNOWN {
+		return "-"
+	}
+	return Command{instruction: instruction}.String()
+}
+
+func formatCommandByte(b uint8) string {
+	return fmt.Sprintf("0x%02x", b)
+}
@@ -0,0 +1,24 @@
+package device_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestGeneratedProtocolDocsUpToDate(t *testing.T) {
+	var generated bytes.Buffer
+	if err := device.GenerateProtocolDocs(&generated); err != nil {
+		t.Fatalf("GenerateProtocolDocs() failed: %v", err)
+	}
+
+	committed, err := os.ReadFile("../../docs/protocol.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(generated.Bytes(), committed) {
+		t.Errorf("docs/protocol.md is out of date with the protocol table, run go generate ./internal/device")
+	}
+}
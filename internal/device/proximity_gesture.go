@@ -0,0 +1,123 @@
+package device
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DEFAULT_MAX_OCCLUSION is the longest time the sensor may be covered to count as a quick occlusion, e.g. a
+	// hand waved in front of it, longer ones are taken as putting the glass on
+	DEFAULT_MAX_OCCLUSION = 500 * time.Millisecond
+	// DEFAULT_OCCLUSION_GAP is the longest time between quick occlusions to count them as one gesture
+	DEFAULT_OCCLUSION_GAP = 600 * time.Millisecond
+)
+
+// ProximityGestureConfig configures the ProximityGestureDetector.
+type ProximityGestureConfig struct {
+	// MinOcclusion is the shortest time the sensor must be covered, shorter ones are dropped as noise
+	MinOcclusion time.Duration
+	// MaxOcclusion is the longest time the sensor may be covered to count as a quick occlusion
+	MaxOcclusion time.Duration
+	// Gap is the longest time from the end of a quick occlusion to the start of the next one of the same gesture
+	Gap time.Duration
+}
+
+// DefaultProximityGestureConfig counts occlusions up to DEFAULT_MAX_OCCLUSION, DEFAULT_OCCLUSION_GAP apart.
+func DefaultProximityGestureConfig() ProximityGestureConfig {
+	return ProximityGestureConfig{MaxOcclusion: DEFAULT_MAX_OCCLUSION, Gap: DEFAULT_OCCLUSION_GAP}
+}
+
+// ProximityGesture is a quick occlusion of the proximity sensor.
+type ProximityGesture struct {
+	// Occlusions counts the quick occlusions of the gesture so far, 1 for a single wave, 2 for a double wave, etc.
+	Occlusions int
+	// Duration is how long the sensor was covered by the last occlusion
+	Duration time.Duration
+}
+
+func (g ProximityGesture) String() string {
+	return fmt.Sprintf("%d occlusion(s), last one %v", g.Occlusions, g.Duration)
+}
+
+type ProximityGestureHandler func(ProximityGesture)
+
+// ProximityGestureDetector turns quick occlusions of the proximity sensor into gestures.
+//
+// The glass does not stream the raw proximity readings, it only reports the PROXIMITY_NEAR and PROXIMITY_FAR
+// transitions, so occlusions are told apart by how long the sensor stays covered. A gesture is reported at the end
+// of every quick occlusion with the number of occlusions so far, so consumers waiting for a double wave act once
+// Occlusions reaches 2.
+type ProximityGestureDetector struct {
+	config  ProximityGestureConfig
+	handler ProximityGestureHandler
+	// now is replaced in tests
+	now func() time.Time
+
+	// mutex for thread safety
+	mutex sync.Mutex
+	// nearSince is when the sensor got covered, zero if it is not
+	nearSince time.Time
+	// lastOcclusion is when the last quick occlusion ended
+	lastOcclusion time.Time
+	occlusions    int
+}
+
+// NewProximityGestureDetector creates a detector reporting gestures to handler.
+func NewProximityGestureDetector(config ProximityGestureConfig, handler ProximityGestureHandler) *ProximityGestureDetector {
+	return &ProximityGestureDetector{config: config, handler: handler, now: time.Now}
+}
+
+// HandleProximity tracks a proximity event. It can be used as a ProximityEventHandler, but not after
+// DebounceProximity which drops the quick transitions it looks for.
+func (d *ProximityGestureDetector) HandleProximity(event ProximityEvent) {
+	d.mutex.Lock()
+	gesture := d.update(event, d.now())
+	d.mutex.Unlock()
+
+	if gesture != nil && d.handler != nil {
+		d.handler(*gesture)
+	}
+}
+
+// update must be called with the mutex held, it returns a gesture if a quick occlusion ended.
+func (d *ProximityGestureDetector) update(event ProximityEvent, now time.Time) *ProximityGesture {
+	switch event {
+	case PROXIMITY_NEAR:
+		if d.nearSince.IsZero() {
+			d.nearSince = now
+		}
+		return nil
+	case PROXIMITY_FAR:
+		if d.nearSince.IsZero() {
+			return nil
+		}
+		duration := now.Sub(d.nearSince)
+		start := d.nearSince
+		d.nearSince = time.Time{}
+
+		if duration < d.config.MinOcclusion || duration > d.config.MaxOcclusion {
+			d.occlusions = 0
+			return nil
+		}
+		if d.occlusions > 0 && start.Sub(d.lastOcclusion) > d.config.Gap {
+			d.occlusions = 0
+		}
+		d.occlusions++
+		d.lastOcclusion = now
+		return &ProximityGesture{Occlusions: d.occlusions, Duration: duration}
+	default:
+		return nil
+	}
+}
+
+// Reset forgets a pending occlusion and gesture, e.g. after reconnecting.
+func (d *ProximityGestureDetector) Reset() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.nearSince = time.Time{}
+	d.lastOcclusion = time.Time{}
+	d.occlusions = 0
+}
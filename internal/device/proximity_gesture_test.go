@@ -0,0 +1,117 @@
+package device
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProximityGestureDetector(t *testing.T) {
+	var gestures []ProximityGesture
+	detector := NewProximityGestureDetector(ProximityGestureConfig{MinOcclusion: 50 * time.Millisecond, MaxOcclusion: 500 * time.Millisecond, Gap: 600 * time.Millisecond}, func(gesture ProximityGesture) {
+		gestures = append(gestures, gesture)
+	})
+	now := time.Unix(0, 0)
+	detector.now = func() time.Time { return now }
+
+	// each step waits for the duration before the event
+	steps := []struct {
+		wait  time.Duration
+		event ProximityEvent
+	}{
+		{0, PROXIMITY_FAR},                      // not covered yet
+		{time.Second, PROXIMITY_NEAR},           // double wave
+		{200 * time.Millisecond, PROXIMITY_FAR}, // 1st
+		{300 * time.Millisecond, PROXIMITY_NEAR},
+		{200 * time.Millisecond, PROXIMITY_FAR}, // 2nd
+		{time.Second, PROXIMITY_NEAR},           // too late for the same gesture
+		{100 * time.Millisecond, PROXIMITY_FAR}, // 1st again
+		{time.Second, PROXIMITY_NEAR},           // too short
+		{20 * time.Millisecond, PROXIMITY_FAR},
+		{time.Second, PROXIMITY_NEAR},           // putting the glass on
+		{10 * time.Millisecond, PROXIMITY_NEAR}, // repeated near keeps the start
+		{time.Minute, PROXIMITY_FAR},
+	}
+	for _, step := range steps {
+		now = now.Add(step.wait)
+		detector.HandleProximity(step.event)
+	}
+
+	want := []ProximityGesture{
+		{Occlusions: 1, Duration: 200 * time.Millisecond},
+		{Occlusions: 2, Duration: 200 * time.Millisecond},
+		{Occlusions: 1, Duration: 100 * time.Millisecond},
+	}
+	if len(gestures) != len(want) {
+		t.Fatalf("gestures = %v, want %v", gestures, want)
+	}
+	for i := range want {
+		if gestures[i] != want[i] {
+			t.Errorf("gestures[%d] = %v, want %v", i, gestures[i], want[i])
+		}
+	}
+}
+
+// fakeTimers calls the functions scheduled with after once advanced past their time.
+type fakeTimers struct {
+	now    time.Duration
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at   time.Duration
+	f    func()
+	done bool
+}
+
+func (c *fakeTimers) after(d time.Duration, f func()) func() bool {
+	timer := &fakeTimer{at: c.now + d, f: f}
+	c.timers = append(c.timers, timer)
+	return func() bool {
+		pending := !timer.done
+		timer.done = true
+		return pending
+	}
+}
+
+func (c *fakeTimers) advance(d time.Duration) {
+	c.now += d
+	for _, timer := range c.timers {
+		if !timer.done && timer.at <= c.now {
+			timer.done = true
+			timer.f()
+		}
+	}
+}
+
+func TestDebounceProximity(t *testing.T) {
+	var reported []ProximityEvent
+	timers := &fakeTimers{}
+	handler := debounceProximity(100*time.Millisecond, func(event ProximityEvent) {
+		reported = append(reported, event)
+	}, timers.after)
+
+	// each step waits for the duration after the event
+	steps := []struct {
+		event ProximityEvent
+		wait  time.Duration
+	}{
+		{PROXIMITY_NEAR, 50 * time.Millisecond}, // flaps before the window ends
+		{PROXIMITY_FAR, 99 * time.Millisecond},
+		{PROXIMITY_FAR, 100 * time.Millisecond}, // repeated far restarts the window, then held
+		{PROXIMITY_FAR, 100 * time.Millisecond}, // same state as reported
+		{PROXIMITY_NEAR, 99 * time.Millisecond},
+	}
+	for _, step := range steps {
+		handler(step.event)
+		timers.advance(step.wait)
+	}
+	if want := []ProximityEvent{PROXIMITY_FAR}; !reflect.DeepEqual(reported, want) {
+		t.Errorf("reported %v before near held, want %v", reported, want)
+	}
+
+	timers.advance(time.Millisecond)
+	if want := []ProximityEvent{PROXIMITY_FAR, PROXIMITY_NEAR}; !reflect.DeepEqual(reported, want) {
+		t.Errorf("reported %v, want %v", reported, want)
+	}
+}
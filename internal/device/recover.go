@@ -0,0 +1,103 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// ErrPanic is wrapped by ErrorEvent when a background goroutine recovers from a panic.
+var ErrPanic = errors.New("recovered from panic")
+
+// ErrorHandler receives errors from the background goroutines of a Device, e.g. the HID read loops.
+type ErrorHandler func(error)
+
+// ErrorEvent is a panic recovered in a background goroutine, e.g. raised by a user event handler or a parser.
+// The goroutine drops what it was processing and carries on with the next read, instead of dying silently, or
+// starts again from scratch if SetRestartOnPanic is set.
+type ErrorEvent struct {
+	// Source is the goroutine that panicked, e.g. "mcu read loop"
+	Source string
+	// Value is what was passed to panic()
+	Value any
+	// Stack is the stack trace of the panic
+	Stack []byte
+	// Restarted tells the goroutine was started again from scratch, see SetRestartOnPanic
+	Restarted bool
+}
+
+func (e *ErrorEvent) Error() string {
+	return fmt.Sprintf("%s: %v: %v", e.Source, ErrPanic, e.Value)
+}
+
+// Unwrap allows errors.Is(err, ErrPanic), and matching the panic value if it is an error itself.
+func (e *ErrorEvent) Unwrap() []error {
+	if err, ok := e.Value.(error); ok {
+		return []error{ErrPanic, err}
+	}
+	return []error{ErrPanic}
+}
+
+// runRecovered runs f converting a panic into an ErrorEvent.
+func runRecovered(source string, f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &ErrorEvent{Source: source, Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return f()
+}
+
+// restartOnPanic is shared by all glasses of the process.
+var restartOnPanic atomic.Bool
+
+// SetRestartOnPanic makes the read loops and heart beat of the Light end and start again from scratch once they
+// recovered from a panic, dropping what they keep across reads, e.g. failure counts, instead of carrying on with the
+// next read. The ErrorEvent tells which it did. Off by default.
+func SetRestartOnPanic(restart bool) {
+	restartOnPanic.Store(restart)
+}
+
+// GetRestartOnPanic returns what SetRestartOnPanic set.
+func GetRestartOnPanic() bool {
+	return restartOnPanic.Load()
+}
+
+// restartAfterPanic tells if a loop that recovered err should start again from scratch, and marks its ErrorEvent
+// so before it is reported.
+func restartAfterPanic(err error) bool {
+	var event *ErrorEvent
+	if !GetRestartOnPanic() || !errors.As(err, &event) {
+		return false
+	}
+	event.Restarted = true
+	return true
+}
+
+// reportError passes err to the ErrorHandler, or logs it if there is none.
+func (h *DeviceHandlers) reportError(err error) {
+	if h.ErrorHandler != nil {
+		h.ErrorHandler(err)
+		return
+	}
+	switch {
+	case errors.Is(err, ErrPanic):
+		slog.Error(err.Error())
+	case errors.Is(err, ErrHeartBeatLost), errors.Is(err, ErrUntestedFirmware), errors.Is(err, ErrStreamStalled):
+		slog.Warn(err.Error())
+	default:
+		slog.Debug(err.Error())
+	}
+}
+
+// closeStopChannel signals goroutines selecting on the channel to stop. The channel is reset to nil, so closing
+// again, or closing one that was never made because connecting failed early, does nothing.
+func closeStopChannel(channel *chan struct{}) {
+	if *channel == nil {
+		return
+	}
+	close(*channel)
+	*channel = nil
+}
@@ -0,0 +1,67 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+func TestReadLoopSurvivesPanickingHandler(t *testing.T) {
+	for _, restart := range []bool{false, true} {
+		t.Run(fmt.Sprintf("restart=%t", restart), func(t *testing.T) {
+			defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+			SetRestartOnPanic(restart)
+			defer SetRestartOnPanic(false)
+
+			events := make(chan *ErrorEvent, 1)
+			keys := make(chan KeyEvent, 1)
+			panicked := false
+			handlers := &DeviceHandlers{
+				KeyEventHandler: func(key KeyEvent) {
+					if !panicked {
+						panicked = true
+						panic("handler bug")
+					}
+					keys <- key
+				},
+				ErrorHandler: func(err error) {
+					var event *ErrorEvent
+					if errors.As(err, &event) {
+						events <- event
+					}
+				},
+			}
+
+			fake := &fakeHIDDevice{}
+			fake.queue("\x02:5:K:UP:18fd37a61db:f505ec70:\x03")
+			mcu := &xrealLightMCU{observer: true, device: fake, deviceHandlers: handlers}
+			if err := mcu.initialize(); err != nil {
+				t.Fatalf("initialize() = %v", err)
+			}
+			defer mcu.disconnect()
+
+			select {
+			case event := <-events:
+				if event.Source != "mcu read loop" || event.Value != "handler bug" || event.Restarted != restart {
+					t.Errorf("ErrorEvent = %+v, want the panic of the mcu read loop with Restarted %t", event, restart)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("no ErrorEvent reported after the handler panicked")
+			}
+
+			// the loop keeps reading
+			fake.queue("\x02:5:K:DN:18fd37a61db:a5b5c13b:\x03")
+			select {
+			case key := <-keys:
+				if key != KEY_DOWN_PRESSED {
+					t.Errorf("key = %v, want %v", key, KEY_DOWN_PRESSED)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("no key event after the panic, the read loop stopped")
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"xreal-light-xr-go/constant"
+)
+
+// ModelFactory creates the Device of an attached glass of a registered model, info.MCUPath is the first hid
+// function found for it.
+type ModelFactory func(info *GlassInfo) Device
+
+// modelRegistry holds the models registered by RegisterModel, keyed by VID and PID.
+type modelRegistry struct {
+	// mutex for thread safety
+	mutex     sync.Mutex
+	factories map[usbID]ModelFactory
+}
+
+var models = &modelRegistry{factories: map[usbID]ModelFactory{}}
+
+// RegisterModel makes glasses with the VID and PID listed by ListGlasses, watched by WatchGlasses and connected by
+// NewDevice and ConnectFirstAvailable using factory, e.g. to experiment with new hardware without forking this
+// package. A registered model takes precedence over a built-in one with the same IDs. The Device of factory can embed
+// UnimplementedDevice to only implement what the hardware supports.
+func RegisterModel(vid uint16, pid uint16, factory ModelFactory) error {
+	if factory == nil {
+		return fmt.Errorf("failed to register model %s: factory is nil", usbID{VID: vid, PID: pid})
+	}
+
+	models.mutex.Lock()
+	defer models.mutex.Unlock()
+
+	id := usbID{VID: vid, PID: pid}
+	if _, ok := models.factories[id]; ok {
+		return fmt.Errorf("failed to register model %s: already registered", id)
+	}
+	models.factories[id] = factory
+	return nil
+}
+
+// UnregisterModel reverts RegisterModel, e.g. to fall back to the built-in support.
+func UnregisterModel(vid uint16, pid uint16) {
+	models.mutex.Lock()
+	defer models.mutex.Unlock()
+	delete(models.factories, usbID{VID: vid, PID: pid})
+}
+
+func (r *modelRegistry) factory(id usbID) ModelFactory {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.factories[id]
+}
+
+func (r *modelRegistry) ids() []usbID {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	ids := make([]usbID, 0, len(r.factories))
+	for id := range r.factories {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// listRegisteredGlasses lists the attached glasses of registered models once, by their first hid function.
+func listRegisteredGlasses() ([]*GlassInfo, error) {
+	var glasses []*GlassInfo
+	for _, id := range models.ids() {
+		devices, err := EnumerateDevices(id.VID, id.PID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to enumerate %s hid devices: %w", id, err)
+		}
+		seen := map[string]struct{}{}
+		for _, device := range devices {
+			if _, ok := seen[device.SerialNbr]; ok {
+				continue
+			}
+			seen[device.SerialNbr] = struct{}{}
+			model := strings.TrimSpace(device.ProductStr)
+			if model == "" {
+				model = id.String()
+			}
+			glasses = append(glasses, &GlassInfo{
+				Model:        model,
+				SerialNumber: device.SerialNbr,
+				MCUPath:      device.Path,
+				VID:          id.VID,
+				PID:          id.PID,
+			})
+		}
+	}
+	return glasses, nil
+}
+
+// withoutRegistered drops the built-in glasses of models registered to be handled otherwise.
+func withoutRegistered(glasses []*GlassInfo) []*GlassInfo {
+	var kept []*GlassInfo
+	for _, info := range glasses {
+		if models.factory(usbID{VID: info.VID, PID: info.PID}) == nil {
+			kept = append(kept, info)
+		}
+	}
+	return kept
+}
+
+// NewDevice creates the Device of an attached glass listed by ListGlasses, by the factory of its registered model,
+// or the built-in support otherwise.
+func NewDevice(info *GlassInfo) (Device, error) {
+	if factory := models.factory(usbID{VID: info.VID, PID: info.PID}); factory != nil {
+		return factory(info), nil
+	}
+
+	switch info.Model {
+	case constant.XREAL_LIGHT:
+		return NewXREALLight(&info.MCUPath, nil), nil
+	case constant.XREAL_AIR, constant.XREAL_AIR_2, constant.XREAL_AIR_2_PRO, constant.XREAL_AIR_2_ULTRA:
+		// NewXREALAir connects the first Air found, it cannot select one by path yet
+		return NewXREALAir(), nil
+	default:
+		return nil, fmt.Errorf("no driver for %s (%s)", info.Model, usbID{VID: info.VID, PID: info.PID})
+	}
+}
+
+// ConnectFirstAvailable connects the first attached glass of a built-in or registered model which connects.
+func ConnectFirstAvailable() (Device, error) {
+	glasses, err := ListGlasses()
+	if err != nil {
+		return nil, err
+	}
+	if len(glasses) == 0 {
+		return nil, checkBeam(fmt.Errorf("no glass found"))
+	}
+
+	var errs []error
+	for _, info := range glasses {
+		if info.Connected {
+			continue
+		}
+		d, err := NewDevice(info)
+		if err == nil {
+			err = d.Connect()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to connect %s: %w", info.Model, err))
+			continue
+		}
+		return d, nil
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("all attached glasses are already connected")
+	}
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,65 @@
+package device
+
+import (
+	"errors"
+	"testing"
+
+	"xreal-light-xr-go/constant"
+)
+
+// prototypeGlass is a minimal Device of an out-of-tree model.
+type prototypeGlass struct {
+	UnimplementedDevice
+	path string
+}
+
+func (p *prototypeGlass) Name() string      { return "prototype" }
+func (p *prototypeGlass) PID() uint16       { return 0x5678 }
+func (p *prototypeGlass) VID() uint16       { return 0x1234 }
+func (p *prototypeGlass) Connect() error    { return nil }
+func (p *prototypeGlass) Disconnect() error { return nil }
+
+func (p *prototypeGlass) GetSerial() (string, error) {
+	return "prototype at " + p.path, nil
+}
+
+func TestRegisterModel(t *testing.T) {
+	const vid, pid = uint16(0x1234), uint16(0x5678)
+	var created *GlassInfo
+	factory := func(info *GlassInfo) Device {
+		created = info
+		return &prototypeGlass{path: info.MCUPath}
+	}
+
+	if err := RegisterModel(vid, pid, nil); err == nil {
+		t.Errorf("RegisterModel() without factory succeeded, want error")
+	}
+	if err := RegisterModel(vid, pid, factory); err != nil {
+		t.Fatalf("RegisterModel() failed: %v", err)
+	}
+	defer UnregisterModel(vid, pid)
+	if err := RegisterModel(vid, pid, factory); err == nil {
+		t.Errorf("RegisterModel() twice succeeded, want error")
+	}
+
+	info := &GlassInfo{Model: "prototype", MCUPath: "path", VID: vid, PID: pid}
+	d, err := NewDevice(info)
+	if err != nil || d == nil || created != info {
+		t.Fatalf("NewDevice() = %v, %v, want the registered factory to create it", d, err)
+	}
+	if serial, err := d.GetSerial(); err != nil || serial != "prototype at path" {
+		t.Errorf("GetSerial() = %s, %v, want the one of the prototype", serial, err)
+	}
+	if _, err := d.GetBrightnessLevel(); !errors.Is(err, ErrUnsupportedCommand) {
+		t.Errorf("GetBrightnessLevel() = %v, want ErrUnsupportedCommand as the prototype does not implement it", err)
+	}
+	if _, err := NewDevice(&GlassInfo{Model: "prototype", VID: vid, PID: pid + 1}); err == nil {
+		t.Errorf("NewDevice() of an unknown model succeeded, want error")
+	}
+
+	light := &GlassInfo{Model: constant.XREAL_LIGHT, VID: XREAL_LIGHT_MCU_VID, PID: XREAL_LIGHT_MCU_PID}
+	registered := &GlassInfo{Model: constant.XREAL_ONE, VID: vid, PID: pid}
+	if kept := withoutRegistered([]*GlassInfo{light, registered}); len(kept) != 1 || kept[0] != light {
+		t.Errorf("withoutRegistered() = %v, want only the built-in Light", kept)
+	}
+}
@@ -0,0 +1,96 @@
+package device
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// responseQueueSize is how many responses are buffered for executeAndWaitForResponse before the oldest is dropped
+	responseQueueSize = 8
+	// responseExpiry is how long a response may wait in the queue, older ones belong to a command nobody waits for
+	responseExpiry = retryMaxAttempts * waitForPacketTimeout
+)
+
+var (
+	errResponseTimeout = errors.New("timed out waiting for a response")
+	errResponseClosed  = errors.New("disconnected while waiting for a response")
+)
+
+type queuedResponse[T any] struct {
+	value    T
+	received time.Time
+}
+
+// responseQueue hands command responses from a read loop to executeAndWaitForResponse. Delivering never blocks,
+// so a response nobody waits for, e.g. to a command that already timed out, cannot stall event processing.
+// Only the read loop may deliver and close.
+type responseQueue[T any] struct {
+	channel chan queuedResponse[T]
+}
+
+func newResponseQueue[T any]() *responseQueue[T] {
+	return &responseQueue[T]{channel: make(chan queuedResponse[T], responseQueueSize)}
+}
+
+// deliver queues a response, dropping the oldest one if the queue is full. It returns false if one was dropped.
+func (q *responseQueue[T]) deliver(value T) bool {
+	response := queuedResponse[T]{value: value, received: time.Now()}
+	select {
+	case q.channel <- response:
+		return true
+	default:
+	}
+
+	select {
+	case <-q.channel:
+	default:
+	}
+	select {
+	case q.channel <- response:
+	default:
+	}
+	return false
+}
+
+// receive waits up to timeout for a response, skipping expired ones.
+func (q *responseQueue[T]) receive(timeout time.Duration) (T, error) {
+	var zero T
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case response, ok := <-q.channel:
+			if !ok {
+				return zero, errResponseClosed
+			}
+			if time.Since(response.received) > responseExpiry {
+				continue
+			}
+			return response.value, nil
+		case <-timer.C:
+			return zero, errResponseTimeout
+		}
+	}
+}
+
+// discard drops all queued responses, so a command only gets responses that arrive after it was sent.
+func (q *responseQueue[T]) discard() int {
+	discarded := 0
+	for {
+		select {
+		case _, ok := <-q.channel:
+			if !ok {
+				return discarded
+			}
+			discarded++
+		default:
+			return discarded
+		}
+	}
+}
+
+func (q *responseQueue[T]) close() {
+	close(q.channel)
+}
@@ -0,0 +1,55 @@
+package device
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResponseQueueDeliverNeverBlocks(t *testing.T) {
+	queue := newResponseQueue[int]()
+
+	// nobody waits, which used to block the read loop on the first response
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 3*responseQueueSize; i++ {
+			queue.deliver(i)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("deliver() blocked without a receiver")
+	}
+
+	// the oldest responses are dropped first
+	if got, err := queue.receive(time.Second); err != nil || got != 2*responseQueueSize {
+		t.Errorf("receive() = %d, %v; want %d, nil", got, err, 2*responseQueueSize)
+	}
+	if discarded := queue.discard(); discarded != responseQueueSize-1 {
+		t.Errorf("discard() = %d, want %d", discarded, responseQueueSize-1)
+	}
+	if _, err := queue.receive(10 * time.Millisecond); !errors.Is(err, errResponseTimeout) {
+		t.Errorf("receive() on an empty queue = %v, want errResponseTimeout", err)
+	}
+}
+
+func TestResponseQueueSkipsExpired(t *testing.T) {
+	queue := newResponseQueue[string]()
+	queue.channel <- queuedResponse[string]{value: "stale", received: time.Now().Add(-2 * responseExpiry)}
+	queue.deliver("fresh")
+
+	if got, err := queue.receive(time.Second); err != nil || got != "fresh" {
+		t.Errorf("receive() = %s, %v; want fresh, nil", got, err)
+	}
+}
+
+func TestResponseQueueClosed(t *testing.T) {
+	queue := newResponseQueue[int]()
+	queue.close()
+
+	if _, err := queue.receive(time.Second); !errors.Is(err, errResponseClosed) {
+		t.Errorf("receive() after close = %v, want errResponseClosed", err)
+	}
+}
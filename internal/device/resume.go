@@ -0,0 +1,129 @@
+package device
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	resumeCheckFrequency = 1 * time.Second
+	// resumeClockJumpThreshold is how much further the wall clock may advance compared to the monotonic clock
+	// between two checks before we consider the host to have been suspended.
+	resumeClockJumpThreshold = 3 * time.Second
+	// staleHandleReadFailures is how many consecutive reads of the MCU may fail before its HID handle is considered
+	// stale, for resumes the clocks do not tell, e.g. when the host only suspended the USB bus
+	staleHandleReadFailures = 50
+)
+
+// processStart is the reference of the monotonic clock of resumeWatcher.
+var processStart = time.Now()
+
+// systemClock returns the wall clock, and the monotonic clock which does not advance while the host is suspended.
+func systemClock() (time.Time, time.Duration) {
+	now := time.Now()
+	// Round(0) strips the monotonic clock reading
+	return now.Round(0), now.Sub(processStart)
+}
+
+// resumeWatcher detects host sleep/resume cycles. The monotonic clock does not advance while the host is
+// suspended but the wall clock does, so a jump between the two tells us the HID handles have likely gone stale. Read
+// loops tell about handles that went stale otherwise with staleHandle.
+type resumeWatcher struct {
+	// onResume is called from the watcher goroutine whenever a resume is detected
+	onResume func(asleepFor time.Duration)
+	// onError receives panics recovered from onResume
+	onError func(error)
+	// clock and checkFrequency are replaced in tests, systemClock and resumeCheckFrequency if not set
+	clock          func() (wall time.Time, monotonic time.Duration)
+	checkFrequency time.Duration
+
+	// staleChannel receives the stale handles reported by read loops, with a buffer of 1
+	staleChannel chan struct{}
+	// waitgroup to wait for the watcher goroutine to stop
+	waitgroup sync.WaitGroup
+	// channel to signal the watcher to stop
+	stopChannel chan struct{}
+}
+
+func (w *resumeWatcher) start() {
+	if w.stopChannel != nil {
+		return
+	}
+
+	if w.clock == nil {
+		w.clock = systemClock
+	}
+	if w.checkFrequency == 0 {
+		w.checkFrequency = resumeCheckFrequency
+	}
+	// handles reported stale before starting were stale before connecting
+	select {
+	case <-w.staleChannel:
+	default:
+	}
+	w.stopChannel = make(chan struct{})
+
+	w.waitgroup.Add(1)
+	go w.watchPeriodically()
+}
+
+func (w *resumeWatcher) stop() {
+	if w.stopChannel == nil {
+		return
+	}
+
+	close(w.stopChannel)
+	w.waitgroup.Wait()
+	w.stopChannel = nil
+}
+
+// staleHandle makes the watcher reconnect as if the host resumed, called by read loops whose reads keep failing. It
+// does not block, and is ignored if the watcher is already going to reconnect.
+func (w *resumeWatcher) staleHandle() {
+	select {
+	case w.staleChannel <- struct{}{}:
+	default:
+	}
+}
+
+// watchPeriodically is a goroutine method to compare wall clock against monotonic clock.
+func (w *resumeWatcher) watchPeriodically() {
+	defer w.waitgroup.Done()
+
+	ticker := time.NewTicker(w.checkFrequency)
+	defer ticker.Stop()
+
+	lastWall, lastMonotonic := w.clock()
+	for {
+		select {
+		case <-ticker.C:
+			wall, monotonic := w.clock()
+			asleepFor := wall.Sub(lastWall) - (monotonic - lastMonotonic)
+			lastWall, lastMonotonic = wall, monotonic
+			if asleepFor < resumeClockJumpThreshold {
+				continue
+			}
+			slog.Info(fmt.Sprintf("host resume detected, was asleep for about %v", asleepFor.Round(time.Second)))
+			w.resumed(asleepFor)
+		case <-w.staleChannel:
+			slog.Info("hid handles went stale, reconnecting")
+			w.resumed(0)
+		case <-w.stopChannel:
+			return
+		}
+		// reconnecting may take a while, don't count that as sleep
+		lastWall, lastMonotonic = w.clock()
+	}
+}
+
+func (w *resumeWatcher) resumed(asleepFor time.Duration) {
+	err := runRecovered("resume watcher", func() error {
+		w.onResume(asleepFor)
+		return nil
+	})
+	if err != nil {
+		w.onError(err)
+	}
+}
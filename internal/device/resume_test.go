@@ -0,0 +1,73 @@
+package device
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeResumeClock advances both clocks by step on every reading, and the wall clock only by asleepFor once sleep
+// is called, as when the host was suspended.
+type fakeResumeClock struct {
+	mutex     sync.Mutex
+	wall      time.Time
+	monotonic time.Duration
+	asleepFor time.Duration
+}
+
+func (c *fakeResumeClock) read() (time.Time, time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.wall = c.wall.Add(time.Millisecond + c.asleepFor)
+	c.monotonic += time.Millisecond
+	c.asleepFor = 0
+	return c.wall, c.monotonic
+}
+
+func (c *fakeResumeClock) sleep(asleepFor time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.asleepFor = asleepFor
+}
+
+func TestResumeWatcherWatchPeriodically(t *testing.T) {
+	clock := &fakeResumeClock{wall: time.Unix(1700000000, 0)}
+	resumes := make(chan time.Duration, 4)
+	watcher := &resumeWatcher{
+		onResume:       func(asleepFor time.Duration) { resumes <- asleepFor },
+		onError:        func(err error) { t.Errorf("onError(%v)", err) },
+		clock:          clock.read,
+		checkFrequency: time.Millisecond,
+		staleChannel:   make(chan struct{}, 1),
+	}
+	watcher.start()
+	// starting again must not start a second goroutine
+	watcher.start()
+	defer watcher.stop()
+
+	select {
+	case asleepFor := <-resumes:
+		t.Fatalf("onResume(%v) without sleeping", asleepFor)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	clock.sleep(time.Minute)
+	select {
+	case asleepFor := <-resumes:
+		if asleepFor != time.Minute {
+			t.Errorf("onResume(%v) after sleeping, want %v", asleepFor, time.Minute)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("onResume() not called within 1s of sleeping")
+	}
+
+	watcher.staleHandle()
+	select {
+	case asleepFor := <-resumes:
+		if asleepFor != 0 {
+			t.Errorf("onResume(%v) after a stale handle, want 0", asleepFor)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("onResume() not called within 1s of a stale handle")
+	}
+}
@@ -0,0 +1,90 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy decides how often and how fast the MCU and OV580 drivers send a failed command again, trading
+// latency for robustness, e.g. more attempts with backoff over a flaky USB hub.
+type RetryPolicy struct {
+	// MaxAttempts is how many times a command is sent at most, values below 1 send it once
+	MaxAttempts int
+	// Backoff is the wait before the second attempt, doubled before every further attempt up to MaxBackoff if set
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Jitter randomizes every wait by up to this fraction of it, e.g. 0.2 for ±20%
+	Jitter float64
+	// Retryable tells if a command failing with err is worth sending again, nil for IsRetryable
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy sends a command up to 3 times right after each other.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: retryMaxAttempts}
+}
+
+// getRetryPolicy returns the policy set on a driver, DefaultRetryPolicy if none is.
+func getRetryPolicy(policy *atomic.Pointer[RetryPolicy]) RetryPolicy {
+	if set := policy.Load(); set != nil {
+		return *set
+	}
+	return DefaultRetryPolicy()
+}
+
+// IsRetryable is the default RetryPolicy.Retryable, it gives up on errors sending again cannot fix: a disconnect
+// while waiting, the observer role and commands missing from the firmware or blocked in safe mode.
+func IsRetryable(err error) bool {
+	return !errors.Is(err, errResponseClosed) &&
+		!errors.Is(err, ErrObserver) &&
+		!errors.Is(err, ErrUnsupportedByFirmware) &&
+		!errors.Is(err, ErrCommandNotAllowed)
+}
+
+func (p RetryPolicy) String() string {
+	return fmt.Sprintf("max attempts %d, backoff %v (max %v), jitter %.2f", max(p.MaxAttempts, 1), p.Backoff, p.MaxBackoff, p.Jitter)
+}
+
+// Do runs operation until it succeeds, fails with an error that is not retryable or ran MaxAttempts times, and
+// returns the last error.
+func (p RetryPolicy) Do(operation func() error) error {
+	return p.do(operation, time.Sleep)
+}
+
+func (p RetryPolicy) do(operation func() error, sleep func(time.Duration)) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = operation(); err == nil {
+			return nil
+		}
+		if attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+		if delay := p.delay(attempt, rand.Float64()); delay > 0 {
+			sleep(delay)
+		}
+	}
+}
+
+// delay is the wait after the given attempt, counted from 1, for a random value in [0, 1).
+func (p RetryPolicy) delay(attempt int, random float64) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 {
+		delay = min(delay, p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay += time.Duration(float64(delay) * p.Jitter * (2*random - 1))
+	}
+	return delay
+}
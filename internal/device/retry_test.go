@@ -0,0 +1,97 @@
+package device
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDo(t *testing.T) {
+	failure := errors.New("failure")
+	testCases := []struct {
+		name     string
+		policy   RetryPolicy
+		failures []error
+		wantRuns int
+		wantErr  error
+		wantWait []time.Duration
+	}{
+		{
+			name:     "succeeds after retries",
+			policy:   RetryPolicy{MaxAttempts: 3, Backoff: 10 * time.Millisecond},
+			failures: []error{failure, failure},
+			wantRuns: 3,
+			wantWait: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond},
+		},
+		{
+			name:     "gives up after max attempts",
+			policy:   RetryPolicy{MaxAttempts: 2},
+			failures: []error{failure, failure, failure},
+			wantRuns: 2,
+			wantErr:  failure,
+		},
+		{
+			name:     "gives up on errors that are not retryable",
+			policy:   RetryPolicy{MaxAttempts: 3},
+			failures: []error{ErrObserver},
+			wantRuns: 1,
+			wantErr:  ErrObserver,
+		},
+		{
+			name:     "runs once without max attempts",
+			policy:   RetryPolicy{},
+			failures: []error{failure},
+			wantRuns: 1,
+			wantErr:  failure,
+		},
+		{
+			name:     "caps the backoff",
+			policy:   RetryPolicy{MaxAttempts: 4, Backoff: 10 * time.Millisecond, MaxBackoff: 15 * time.Millisecond},
+			failures: []error{failure, failure, failure},
+			wantRuns: 4,
+			wantWait: []time.Duration{10 * time.Millisecond, 15 * time.Millisecond, 15 * time.Millisecond},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			runs := 0
+			var waits []time.Duration
+			err := tc.policy.do(func() error {
+				runs++
+				if runs <= len(tc.failures) {
+					return tc.failures[runs-1]
+				}
+				return nil
+			}, func(wait time.Duration) {
+				waits = append(waits, wait)
+			})
+
+			if !errors.Is(err, tc.wantErr) || (tc.wantErr == nil && err != nil) {
+				t.Errorf("Do() = %v, want %v", err, tc.wantErr)
+			}
+			if runs != tc.wantRuns {
+				t.Errorf("runs = %d, want %d", runs, tc.wantRuns)
+			}
+			if len(waits) != len(tc.wantWait) {
+				t.Fatalf("waits = %v, want %v", waits, tc.wantWait)
+			}
+			for i := range waits {
+				if waits[i] != tc.wantWait[i] {
+					t.Errorf("waits = %v, want %v", waits, tc.wantWait)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicyJitter(t *testing.T) {
+	policy := RetryPolicy{Backoff: 100 * time.Millisecond, Jitter: 0.2}
+	if delay := policy.delay(1, 0); delay != 80*time.Millisecond {
+		t.Errorf("delay() with lowest jitter = %v, want 80ms", delay)
+	}
+	if delay := policy.delay(1, 0.5); delay != 100*time.Millisecond {
+		t.Errorf("delay() with middle jitter = %v, want 100ms", delay)
+	}
+}
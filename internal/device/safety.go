@@ -0,0 +1,81 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+)
+
+// BuildMode tells which commands can be sent to the glass, it is fixed at compile time.
+type BuildMode int
+
+const (
+	// BUILD_MODE_SAFE only sends commands known to be safe or revertible, the default
+	BUILD_MODE_SAFE BuildMode = iota
+	// BUILD_MODE_DEVELOPER sends any command, including destructive and unknown ones; built with `-tags developer`
+	BUILD_MODE_DEVELOPER
+)
+
+func (mode BuildMode) String() string {
+	switch mode {
+	case BUILD_MODE_DEVELOPER:
+		return "developer"
+	default:
+		return "safe"
+	}
+}
+
+// ErrCommandNotAllowed is returned when a command is blocked by the build mode.
+var ErrCommandNotAllowed = errors.New("command not allowed in safe build mode")
+
+// GetBuildMode returns the build mode this binary was compiled with.
+func GetBuildMode() BuildMode {
+	return buildMode
+}
+
+// safeModeAllowedLevels allowlists the danger levels that can be sent in BUILD_MODE_SAFE,
+// so anything destructive (the denylist) or missing from the protocol table is blocked.
+var safeModeAllowedLevels = map[DangerLevel]struct{}{
+	DANGER_LEVEL_SAFE:           {},
+	DANGER_LEVEL_STATE_CHANGING: {},
+}
+
+// commandDangerLevel looks up the danger level of the command of the "mcu" or "ov580", unknown for other devices.
+func commandDangerLevel(device string, command *Command) DangerLevel {
+	switch device {
+	case "mcu":
+		return GetMCUCommandDangerLevel(command)
+	case "ov580":
+		return GetOV580CommandDangerLevel(command)
+	default:
+		return DANGER_LEVEL_UNKNOWN
+	}
+}
+
+// CheckCommandAllowed tells if the command can be sent to the "mcu" or "ov580" of the glass in the current build mode.
+// It is enforced right before commands are written to the device, so it cannot be bypassed by API consumers.
+func CheckCommandAllowed(device string, command *Command) error {
+	if buildMode == BUILD_MODE_DEVELOPER {
+		return nil
+	}
+
+	level := commandDangerLevel(device, command)
+	if _, ok := safeModeAllowedLevels[level]; !ok {
+		return fmt.Errorf("%w: %s command %s is %s, rebuild with `-tags developer` to send it", ErrCommandNotAllowed, device, command.String(), level)
+	}
+	return nil
+}
+
+// CheckRawCommandAllowed is CheckCommandAllowed for commands API consumers send by their type and ID, e.g. with
+// Device.ExecuteRaw: unlike the commands of the driver, which always send a valid payload, those are only sent when
+// known to be safe, or by developer builds.
+func CheckRawCommandAllowed(device string, command *Command) error {
+	if buildMode == BUILD_MODE_DEVELOPER {
+		return nil
+	}
+
+	level := commandDangerLevel(device, command)
+	if level != DANGER_LEVEL_SAFE {
+		return fmt.Errorf("%w: raw %s command %s is %s, rebuild with `-tags developer` to send it", ErrCommandNotAllowed, device, command.String(), level)
+	}
+	return nil
+}
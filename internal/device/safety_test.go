@@ -0,0 +1,56 @@
+//go:build !developer
+
+package device_test
+
+import (
+	"errors"
+	"testing"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestCheckCommandAllowed(t *testing.T) {
+	testCases := []struct {
+		device  string
+		command *device.Command
+		allowed bool
+	}{
+		{"mcu", &device.Command{Type: 0x33, ID: 0x43}, true},  // get serial number
+		{"mcu", &device.Command{Type: 0x31, ID: 0x31}, true},  // set brightness level
+		{"mcu", &device.Command{Type: 0x31, ID: 0x58}, false}, // update display firmware
+		{"mcu", &device.Command{Type: 0x40, ID: 0x52}, false}, // MCU A jump to B
+		{"mcu", &device.Command{Type: 0x39, ID: 0x39}, false}, // not in protocol table
+		{"mcu", &device.Command{Type: 0x31, ID: 0x39}, false}, // set type, but not mapped by the driver
+		{"mcu", &device.Command{Type: 0x40, ID: 0x31}, false}, // set type, but not mapped by the driver
+		{"mcu", &device.Command{Type: 0x33, ID: 0x6b}, false}, // get type, but not mapped by the driver
+		{"ov580", &device.Command{Type: 0x02, ID: 0x19}, true},
+		{"ov580", &device.Command{Type: 0x02, ID: 0x01}, false},
+	}
+
+	for _, tc := range testCases {
+		err := device.CheckCommandAllowed(tc.device, tc.command)
+		if tc.allowed && err != nil {
+			t.Errorf("CheckCommandAllowed(%s, %s) = %v; expected allowed", tc.device, tc.command.String(), err)
+		}
+		if !tc.allowed && !errors.Is(err, device.ErrCommandNotAllowed) {
+			t.Errorf("CheckCommandAllowed(%s, %s) = %v; expected ErrCommandNotAllowed", tc.device, tc.command.String(), err)
+		}
+	}
+}
+
+func TestExecuteRawNotAllowed(t *testing.T) {
+	// refused before anything is sent, so the glass does not need to be connected
+	light := device.NewXREALLight(nil, nil)
+	for _, command := range []device.Command{
+		{Type: 0x31, ID: 0x31}, // set brightness level, state changing
+		{Type: 0x31, ID: 0x58}, // update display firmware, destructive
+		{Type: 0x33, ID: 0x6b}, // not mapped by the driver
+	} {
+		if _, err := light.ExecuteRaw(command, nil); !errors.Is(err, device.ErrCommandNotAllowed) {
+			t.Errorf("ExecuteRaw(%s) = %v; expected ErrCommandNotAllowed", command.String(), err)
+		}
+	}
+	if _, err := light.ExecuteRawOV580(device.Command{Type: 0x02, ID: 0x19}, 1); !errors.Is(err, device.ErrCommandNotAllowed) {
+		t.Errorf("ExecuteRawOV580(enable IMU stream) = %v; expected ErrCommandNotAllowed", err)
+	}
+}
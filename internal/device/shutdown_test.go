@@ -0,0 +1,113 @@
+package device
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"go.uber.org/goleak"
+)
+
+// useControlLockDir keeps the control locks of the test in a fresh directory shared like /tmp.
+func useControlLockDir(t *testing.T) string {
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0o777|os.ModeSticky); err != nil {
+		t.Fatalf("Chmod() failed: %v", err)
+	}
+	original := controlLockDirs
+	controlLockDirs = []string{dir}
+	t.Cleanup(func() { controlLockDirs = original })
+	return dir
+}
+
+func TestDisconnectWithoutConnect(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	light := NewXREALLight(nil, nil)
+	for i := 0; i < 2; i++ {
+		if err := light.Disconnect(); err != nil {
+			t.Errorf("Disconnect() #%d = %v, want nil", i+1, err)
+		}
+	}
+}
+
+func TestDisconnectStopsGoroutines(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	useControlLockDir(t)
+
+	// without a device the read loops only fail, which is enough to run and stop them
+	handlers := &DeviceHandlers{ErrorHandler: func(error) {}}
+	mcu := &xrealLightMCU{observer: true, deviceHandlers: handlers}
+	if err := mcu.initialize(); err != nil {
+		t.Fatalf("mcu initialize() = %v", err)
+	}
+	// observers do not send heart beats, start it like a controller would
+	mcu.waitgroup.Add(1)
+	go mcu.sendHeartBeatPeriodically(mcu.stopHeartBeatChannel)
+
+	ov580 := &xrealLightOV580{observer: true, control: newControlLock("shutdown"), deviceHandlers: handlers}
+	if err := ov580.initialize(); err != nil {
+		t.Fatalf("ov580 initialize() = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := mcu.disconnect(); err != nil {
+			t.Errorf("mcu disconnect() #%d = %v, want nil", i+1, err)
+		}
+		if err := ov580.disconnect(); err != nil {
+			t.Errorf("ov580 disconnect() #%d = %v, want nil", i+1, err)
+		}
+	}
+
+	packet, err := mcu.buildCommandPacket(CMD_HEART_BEAT)
+	if err != nil {
+		t.Fatalf("buildCommandPacket() failed: %v", err)
+	}
+	if _, err := mcu.executeAndWaitForResponse(packet); err == nil {
+		t.Error("executeAndWaitForResponse() after disconnect = nil, want error")
+	}
+}
+
+// TestDisconnectWhileExecuting sends commands while disconnecting, to be run with -race.
+func TestDisconnectWhileExecuting(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	useControlLockDir(t)
+
+	handlers := &DeviceHandlers{ErrorHandler: func(error) {}}
+	mcu := &xrealLightMCU{observer: true, deviceHandlers: handlers}
+	if err := mcu.initialize(); err != nil {
+		t.Fatalf("mcu initialize() = %v", err)
+	}
+	ov580 := &xrealLightOV580{observer: true, control: newControlLock("shutdown"), deviceHandlers: handlers}
+	if err := ov580.initialize(); err != nil {
+		t.Fatalf("ov580 initialize() = %v", err)
+	}
+	packet, err := mcu.buildCommandPacket(CMD_GET_SERIAL_NUMBER)
+	if err != nil {
+		t.Fatalf("buildCommandPacket() failed: %v", err)
+	}
+
+	var commands sync.WaitGroup
+	commands.Add(2)
+	go func() {
+		defer commands.Done()
+		for i := 0; i < 100; i++ {
+			// fails as there is no device, or no longer connected
+			mcu.executeAndWaitForResponse(packet)
+		}
+	}()
+	go func() {
+		defer commands.Done()
+		for i := 0; i < 100; i++ {
+			ov580.executeAndWaitForResponse(GetFirmwareIndependentCommand(OV580_ENABLE_IMU_STREAM), 0)
+		}
+	}()
+
+	if err := mcu.disconnect(); err != nil {
+		t.Errorf("mcu disconnect() = %v, want nil", err)
+	}
+	if err := ov580.disconnect(); err != nil {
+		t.Errorf("ov580 disconnect() = %v, want nil", err)
+	}
+	commands.Wait()
+}
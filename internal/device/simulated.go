@@ -0,0 +1,837 @@
+package device
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"xreal-light-xr-go/logging"
+	"xreal-light-xr-go/storage"
+)
+
+const (
+	SIMULATED_NAME     = "XREAL Light (simulated)"
+	SIMULATED_SERIAL   = "SIMULATED0001"
+	SIMULATED_FIRMWARE = "05.5.08.059_20230518"
+
+	simulatedIMUInterval   = 10 * time.Millisecond
+	simulatedVSyncInterval = time.Second / 60
+	// the head nods by simulatedPitchAmplitude radians every simulatedPitchPeriod and turns by simulatedYawAmplitude
+	// every simulatedYawPeriod
+	simulatedPitchAmplitude = 0.3
+	simulatedPitchPeriod    = 4 * time.Second
+	simulatedYawAmplitude   = 1.0
+	simulatedYawPeriod      = 10 * time.Second
+	// a key is pressed every simulatedKeyInterval, and the glass taken off for simulatedRemovedFor every
+	// simulatedProximityInterval
+	simulatedKeyInterval       = 15 * time.Second
+	simulatedProximityInterval = 60 * time.Second
+	simulatedRemovedFor        = 5 * time.Second
+)
+
+// simulatedDisplayModes are the display modes in the order of the values of the display_mode config key.
+var simulatedDisplayModes = []DisplayMode{
+	DISPLAY_MODE_SAME_ON_BOTH, DISPLAY_MODE_HALF_SBS, DISPLAY_MODE_STEREO, DISPLAY_MODE_HIGH_REFRESH_RATE,
+}
+
+// xrealSimulated is a glass made up on the host, without any hardware, so the tools and integrations can be explored
+// before glasses arrive. Settings are kept in memory, the IMU reports a head nodding and turning sinusoidally, keys
+// are pressed and the glass is taken off and put on periodically, and the SLAM cameras see a pattern moving with the
+// head. Orientation is in the axes of package fusion, gravity along Z while looking straight ahead.
+type xrealSimulated struct {
+	// deviceHandlers contains callback funcs for the events from the glass device
+	deviceHandlers *DeviceHandlers
+
+	framePipeline FramePipeline
+	capturer      capturer
+
+	// mutex for thread safety
+	mutex     sync.Mutex
+	connected bool
+	started   time.Time
+	stop      chan struct{}
+	waitgroup sync.WaitGroup
+
+	brightness  string
+	oled        string
+	displayMode DisplayMode
+	sleepTime   string
+	keySwitch   bool
+	default2D   bool
+	orbit       bool
+	superActive bool
+	config      map[string]string
+	// reporting tells which of the EVENT_REPORTING_INSTRUCTIONS are enabled
+	reporting map[CommandInstruction]bool
+	// enabledBeforeIdle is the reporting Idle disabled, enabled again by the next command or event handler set, nil
+	// if not idle
+	enabledBeforeIdle []CommandInstruction
+	// sbsUntil is when the half SBS mode entered with EnterSBS reverts to sbsPrevious, zero if not entered
+	sbsUntil    time.Time
+	sbsPrevious DisplayMode
+}
+
+func (s *xrealSimulated) Name() string {
+	return SIMULATED_NAME
+}
+
+func (s *xrealSimulated) PID() uint16 {
+	return 0
+}
+
+func (s *xrealSimulated) VID() uint16 {
+	return 0
+}
+
+func (s *xrealSimulated) Connect() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.connected {
+		return nil
+	}
+	s.connected = true
+	s.started = time.Now()
+	s.stop = make(chan struct{})
+	s.waitgroup.Add(1)
+	go s.simulate(s.stop)
+	slog.Info("connected to a simulated glass, no hardware is used")
+	return nil
+}
+
+func (s *xrealSimulated) Disconnect() error {
+	s.mutex.Lock()
+	if !s.connected {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.connected = false
+	s.enabledBeforeIdle = nil
+	close(s.stop)
+	s.mutex.Unlock()
+
+	s.waitgroup.Wait()
+	return nil
+}
+
+// simulate is a goroutine method sending the events until stop is closed.
+func (s *xrealSimulated) simulate(stop chan struct{}) {
+	defer s.waitgroup.Done()
+
+	imu := time.NewTicker(simulatedIMUInterval)
+	defer imu.Stop()
+	vsync := time.NewTicker(simulatedVSyncInterval)
+	defer vsync.Stop()
+	second := time.NewTicker(time.Second)
+	defer second.Stop()
+
+	s.handlers().ProximityEventHandler(PROXIMITY_NEAR)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-imu.C:
+			if s.reportingEnabled(OV580_ENABLE_IMU_STREAM) {
+				s.handlers().IMUEventHandler(s.imuEvent(s.elapsed()))
+			}
+		case <-vsync.C:
+			if s.reportingEnabled(CMD_ENABLE_VSYNC) {
+				s.handlers().VSyncEventHandler("1")
+			}
+		case <-second.C:
+			s.sendPeriodicEvents(s.elapsed().Truncate(time.Second))
+		}
+	}
+}
+
+// sendPeriodicEvents sends the events due at elapsed, once a second.
+func (s *xrealSimulated) sendPeriodicEvents(elapsed time.Duration) {
+	handlers := s.handlers()
+
+	if elapsed%simulatedKeyInterval == 0 {
+		key := KEY_UP_PRESSED
+		if (elapsed/simulatedKeyInterval)%2 == 0 {
+			key = KEY_DOWN_PRESSED
+		}
+		handlers.KeyEventHandler(key)
+	}
+	switch elapsed % simulatedProximityInterval {
+	case simulatedProximityInterval - simulatedRemovedFor:
+		handlers.ProximityEventHandler(PROXIMITY_FAR)
+		s.revertSBS()
+	case 0:
+		handlers.ProximityEventHandler(PROXIMITY_NEAR)
+	}
+
+	seconds := elapsed.Seconds()
+	if s.reportingEnabled(CMD_ENABLE_AMBIENT_LIGHT) {
+		handlers.AmbientLightEventHandler(uint16(200 + 150*math.Sin(2*math.Pi*seconds/60)))
+	}
+	if s.reportingEnabled(CMD_ENABLE_MAGNETOMETER) {
+		// the horizontal field turns against the yaw of the head
+		yaw := simulatedYawAmplitude * math.Sin(2*math.Pi*seconds/simulatedYawPeriod.Seconds())
+		vector := &MagnetometerVector{RawX: int(200 * math.Cos(-yaw)), RawY: int(200 * math.Sin(-yaw)), RawZ: -400}
+		vector.X, vector.Y, vector.Z = float64(vector.RawX), float64(vector.RawY), float64(vector.RawZ)
+		handlers.MagnetometerEventHandler(vector)
+	}
+	if s.reportingEnabled(CMD_ENABLE_TEMPERATURE) && elapsed%(5*time.Second) == 0 {
+		handlers.TemperatureEventHandlder(fmt.Sprintf("%.1f", 38+4*math.Sin(2*math.Pi*seconds/300)))
+	}
+	if s.sbsDue() {
+		s.revertSBS()
+	}
+}
+
+// imuEvent is the IMU reading elapsed after connecting.
+func (s *xrealSimulated) imuEvent(elapsed time.Duration) *IMUEvent {
+	seconds := elapsed.Seconds()
+	pitchRate := 2 * math.Pi / simulatedPitchPeriod.Seconds()
+	yawRate := 2 * math.Pi / simulatedYawPeriod.Seconds()
+	pitch := simulatedPitchAmplitude * math.Sin(pitchRate*seconds)
+
+	return &IMUEvent{
+		Accelerometer: &AccelerometerVector{
+			X: float32(-9.81 * math.Sin(pitch)),
+			Z: float32(9.81 * math.Cos(pitch)),
+		},
+		Gyroscope: &GyroscopeVector{
+			Y: float32(simulatedPitchAmplitude * pitchRate * math.Cos(pitchRate*seconds)),
+			Z: float32(simulatedYawAmplitude * yawRate * math.Cos(yawRate*seconds)),
+		},
+		TimeSinceBoot: uint64(elapsed.Milliseconds()),
+	}
+}
+
+func (s *xrealSimulated) elapsed() time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return time.Since(s.started)
+}
+
+func (s *xrealSimulated) handlers() DeviceHandlers {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return *s.deviceHandlers
+}
+
+func (s *xrealSimulated) reportingEnabled(instruction CommandInstruction) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.reporting[instruction]
+}
+
+func (s *xrealSimulated) sbsDue() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return !s.sbsUntil.IsZero() && time.Now().After(s.sbsUntil)
+}
+
+func (s *xrealSimulated) revertSBS() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.sbsUntil.IsZero() {
+		return
+	}
+	s.displayMode = s.sbsPrevious
+	s.sbsUntil = time.Time{}
+}
+
+// checkConnected fails like the drivers of real glasses do before Connect.
+func (s *xrealSimulated) checkConnected() error {
+	if !s.connected {
+		return fmt.Errorf("glass device is not connected yet")
+	}
+	return nil
+}
+
+// get returns value under the mutex if connected.
+func simulatedGet[T any](s *xrealSimulated, value func() T) (T, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.checkConnected(); err != nil {
+		var zero T
+		return zero, err
+	}
+	s.wake()
+	return value(), nil
+}
+
+// set runs update under the mutex if connected.
+func (s *xrealSimulated) set(update func() error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.checkConnected(); err != nil {
+		return err
+	}
+	s.wake()
+	return update()
+}
+
+// wake enables the reporting disabled by Idle again, like the glass does on the next command, must be called with
+// the mutex held.
+func (s *xrealSimulated) wake() {
+	for _, instruction := range s.enabledBeforeIdle {
+		s.reporting[instruction] = true
+	}
+	s.enabledBeforeIdle = nil
+}
+
+func (s *xrealSimulated) GetRole() (Role, error) {
+	return ROLE_CONTROLLER, nil
+}
+
+// RequestControl succeeds right away, no other process shares the simulated glass.
+func (s *xrealSimulated) RequestControl(timeout time.Duration) error {
+	return nil
+}
+
+func (s *xrealSimulated) ReleaseControl() error {
+	return nil
+}
+
+func (s *xrealSimulated) GetSerial() (string, error) {
+	return simulatedGet(s, func() string { return SIMULATED_SERIAL })
+}
+
+func (s *xrealSimulated) GetFirmwareVersion() (string, error) {
+	return simulatedGet(s, func() string { return SIMULATED_FIRMWARE })
+}
+
+func (s *xrealSimulated) GetStockFirmwareVersion() (string, error) {
+	return simulatedGet(s, func() string { return SIMULATED_FIRMWARE })
+}
+
+func (s *xrealSimulated) GetDisplayFirmware() (*DisplayVersion, error) {
+	return nil, fmt.Errorf("%w: the simulated glass has no display", ErrUnsupportedByFirmware)
+}
+
+func (s *xrealSimulated) GetDisplayHDCPVersion() (*DisplayVersion, error) {
+	return nil, fmt.Errorf("%w: the simulated glass has no display", ErrUnsupportedByFirmware)
+}
+
+func (s *xrealSimulated) GetOV580Info() (*OV580Info, error) {
+	return simulatedGet(s, func() *OV580Info {
+		return &OV580Info{Product: "simulated OV580", SerialNumber: SIMULATED_SERIAL}
+	})
+}
+
+func (s *xrealSimulated) ReadOV580File(id uint8, options OV580FileOptions) (*OV580File, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (s *xrealSimulated) GetMCUInfo() (*ConnectionInfo, error) {
+	return s.connectionInfo(COMPONENT_MCU), nil
+}
+
+func (s *xrealSimulated) GetSensorInfo() (*ConnectionInfo, error) {
+	return s.connectionInfo(COMPONENT_OV580), nil
+}
+
+func (s *xrealSimulated) GetCameraInfo() ([]ConnectionInfo, error) {
+	return []ConnectionInfo{*s.connectionInfo(COMPONENT_SLAM_CAMERA)}, nil
+}
+
+func (s *xrealSimulated) connectionInfo(component string) *ConnectionInfo {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return &ConnectionInfo{Component: component, Path: "simulated", SerialNumber: SIMULATED_SERIAL, Interface: -1, Open: s.connected}
+}
+
+// InvalidateInfoCache does nothing, as nothing is cached.
+func (s *xrealSimulated) InvalidateInfoCache() {}
+
+func (s *xrealSimulated) ResetSensors() error {
+	return s.set(func() error { return nil })
+}
+
+func (s *xrealSimulated) SetRetryPolicy(policy RetryPolicy) error {
+	return nil
+}
+
+// SetStreamWatchdog accepts any policy, the simulated streams never stall.
+func (s *xrealSimulated) SetStreamWatchdog(policy *WatchdogPolicy) error {
+	return nil
+}
+
+func (s *xrealSimulated) GetBrightnessLevel() (string, error) {
+	return simulatedGet(s, func() string { return s.brightness })
+}
+
+func (s *xrealSimulated) SetBrightnessLevel(level string) error {
+	return s.set(func() error {
+		if _, err := parseBrightnessLevel(level); err != nil {
+			return err
+		}
+		s.brightness = level
+		return nil
+	})
+}
+
+func (s *xrealSimulated) GetOLEDBrightnessLevel() (string, error) {
+	return simulatedGet(s, func() string { return s.oled })
+}
+
+func (s *xrealSimulated) SetOLEDBrightnessLevel(level string) error {
+	return s.set(func() error {
+		if level != "0" && level != "1" {
+			return fmt.Errorf("invalid level %s, must be 0 or 1", level)
+		}
+		s.oled = level
+		return nil
+	})
+}
+
+func (s *xrealSimulated) GetOLEDBrightnessBrit() (string, error) {
+	return simulatedGet(s, func() string { return "0" })
+}
+
+func (s *xrealSimulated) GetKeySwitchEnabled() (bool, error) {
+	return simulatedGet(s, func() bool { return s.keySwitch })
+}
+
+func (s *xrealSimulated) SetKeySwitchEnabled(enabled bool) error {
+	return s.set(func() error { s.keySwitch = enabled; return nil })
+}
+
+func (s *xrealSimulated) GetDefault2DEnabled() (bool, error) {
+	return simulatedGet(s, func() bool { return s.default2D })
+}
+
+func (s *xrealSimulated) SetDefault2DEnabled(enabled bool) error {
+	return s.set(func() error { s.default2D = enabled; return nil })
+}
+
+func (s *xrealSimulated) GetOrbitFunction() (string, error) {
+	return simulatedGet(s, func() string { return eventReportingValue(s.orbit) })
+}
+
+func (s *xrealSimulated) SetOrbitFunction(open bool) error {
+	return s.set(func() error { s.orbit = open; return nil })
+}
+
+func (s *xrealSimulated) GetSuperActive() (bool, error) {
+	return simulatedGet(s, func() bool { return s.superActive })
+}
+
+func (s *xrealSimulated) SetSuperActive(enabled bool) error {
+	return s.set(func() error { s.superActive = enabled; return nil })
+}
+
+func (s *xrealSimulated) GetRGBCameraEnabled() (bool, error) {
+	return simulatedGet(s, func() bool { return s.reporting[CMD_ENABLE_RGB_CAMERA] })
+}
+
+func (s *xrealSimulated) GetClockSync() (*ClockSync, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (s *xrealSimulated) Idle() error {
+	return s.set(func() error {
+		for _, instruction := range EVENT_REPORTING_INSTRUCTIONS {
+			// like the Light, the RGB camera stays powered
+			if instruction != CMD_ENABLE_RGB_CAMERA && s.reporting[instruction] {
+				s.enabledBeforeIdle = append(s.enabledBeforeIdle, instruction)
+				s.reporting[instruction] = false
+			}
+		}
+		return nil
+	})
+}
+
+func (s *xrealSimulated) GetCapabilities() (*Capabilities, error) {
+	return simulatedGet(s, func() *Capabilities {
+		return &Capabilities{
+			Firmware:         SIMULATED_FIRMWARE,
+			KnownFirmware:    true,
+			KeySwitch:        true,
+			Default2D:        true,
+			OrbitFunction:    true,
+			SuperActive:      true,
+			RGBCameraEnabled: s.reporting[CMD_ENABLE_RGB_CAMERA],
+			Cameras:          true,
+		}
+	})
+}
+
+func (s *xrealSimulated) GetConfigValue(key string) (string, error) {
+	config, err := findConfigKey(key)
+	if err != nil {
+		return "", err
+	}
+	if !config.Readable() {
+		return "", fmt.Errorf("config key %s cannot be read back from the glass", key)
+	}
+	return simulatedGet(s, func() string {
+		switch {
+		case key == "brightness":
+			return s.brightness
+		case key == "oled_brightness":
+			return s.oled
+		case key == "sleep_time":
+			return s.sleepTime
+		case key == "display_mode":
+			for i, mode := range simulatedDisplayModes {
+				if mode == s.displayMode {
+					return strconv.Itoa(i + 1)
+				}
+			}
+		case slices.Contains(EVENT_REPORTING_INSTRUCTIONS, config.set):
+			return eventReportingValue(s.reporting[config.set])
+		}
+		return s.config[key]
+	})
+}
+
+func (s *xrealSimulated) SetConfigValue(key string, value string) error {
+	config, err := findConfigKey(key)
+	if err != nil {
+		return err
+	}
+	if !config.Writable() {
+		return fmt.Errorf("config key %s is read-only", key)
+	}
+	if config.validate != nil {
+		if err := config.validate(value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+	return s.set(func() error {
+		switch {
+		case key == "brightness":
+			s.brightness = value
+		case key == "oled_brightness":
+			s.oled = value
+		case key == "sleep_time":
+			s.sleepTime = value
+		case key == "display_mode":
+			index, _ := strconv.Atoi(value)
+			s.displayMode = simulatedDisplayModes[index-1]
+			s.sbsUntil = time.Time{}
+		case slices.Contains(EVENT_REPORTING_INSTRUCTIONS, config.set):
+			s.reporting[config.set] = value == "1"
+		default:
+			s.config[key] = value
+		}
+		return nil
+	})
+}
+
+func (s *xrealSimulated) ExecuteAsync(instruction CommandInstruction, payload []byte) *CommandFuture {
+	return resolvedCommandFuture(unimplemented("ExecuteAsync"))
+}
+
+func (s *xrealSimulated) GetSleepTime() (string, error) {
+	return simulatedGet(s, func() string { return s.sleepTime })
+}
+
+func (s *xrealSimulated) SetSleepTime(seconds string) error {
+	if err := validateIntRange(MIN_SLEEP_TIME_SECONDS+1, -1)(seconds); err != nil {
+		return err
+	}
+	return s.set(func() error { s.sleepTime = seconds; return nil })
+}
+
+func (s *xrealSimulated) DisplayOff() error {
+	return s.set(func() error { return nil })
+}
+
+func (s *xrealSimulated) DisplayOn() error {
+	return s.set(func() error { return nil })
+}
+
+func (s *xrealSimulated) GetDisplayMode() (DisplayMode, error) {
+	return simulatedGet(s, func() DisplayMode { return s.displayMode })
+}
+
+func (s *xrealSimulated) SetDisplayMode(mode DisplayMode) error {
+	if _, ok := SupportedDisplayMode[string(mode)]; !ok {
+		return fmt.Errorf("invalid display mode %s", mode)
+	}
+	return s.set(func() error {
+		s.displayMode = mode
+		s.sbsUntil = time.Time{}
+		return nil
+	})
+}
+
+func (s *xrealSimulated) EnterSBS(duration time.Duration) error {
+	return s.set(func() error {
+		if s.sbsUntil.IsZero() {
+			s.sbsPrevious = s.displayMode
+		}
+		s.displayMode = DISPLAY_MODE_HALF_SBS
+		s.sbsUntil = time.Now().Add(duration)
+		return nil
+	})
+}
+
+func (s *xrealSimulated) ExitSBS() error {
+	s.revertSBS()
+	return nil
+}
+
+func (s *xrealSimulated) GetImages(folderpath string) ([]string, error) {
+	return s.CaptureImages(storage.NewLocal(folderpath))
+}
+
+func (s *xrealSimulated) CaptureImages(store storage.Storage) ([]string, error) {
+	frame, err := s.GetSLAMFrameRaw()
+	if err != nil {
+		return nil, err
+	}
+	return s.capturer.capture(store, frame, SIMULATED_SERIAL)
+}
+
+func (s *xrealSimulated) GetSLAMFrame() (image.Image, image.Image, time.Time, error) {
+	frame, err := s.GetSLAMFrameRaw()
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	left, right := frame.Images()
+	return left, right, frame.Timestamp, nil
+}
+
+// GetSLAMFrameRaw returns vertical stripes moving with the yaw of the head, shifted between the left and right
+// images like a close object seen by both cameras.
+func (s *xrealSimulated) GetSLAMFrameRaw() (*SLAMFrame, error) {
+	elapsed, err := simulatedGet(s, func() time.Duration { return time.Since(s.started) })
+	if err != nil {
+		return nil, err
+	}
+	yaw := simulatedYawAmplitude * math.Sin(2*math.Pi*elapsed.Seconds()/simulatedYawPeriod.Seconds())
+	offset := int(yaw * SLAM_FRAME_WIDTH / 2)
+
+	frame := &SLAMFrame{
+		Left:      make([]byte, SLAM_FRAME_WIDTH*SLAM_FRAME_HEIGHT),
+		Right:     make([]byte, SLAM_FRAME_WIDTH*SLAM_FRAME_HEIGHT),
+		Timestamp: time.Now(),
+	}
+	const disparity = 16
+	for y := 0; y < SLAM_FRAME_HEIGHT; y++ {
+		for x := 0; x < SLAM_FRAME_WIDTH; x++ {
+			frame.Left[y*SLAM_FRAME_WIDTH+x] = simulatedStripe(x+offset, y)
+			frame.Right[y*SLAM_FRAME_WIDTH+x] = simulatedStripe(x+offset+disparity, y)
+		}
+	}
+	frame.Statistics = NewFrameStatistics(frame)
+
+	s.mutex.Lock()
+	pipeline := s.framePipeline
+	s.mutex.Unlock()
+	if len(pipeline) > 0 {
+		return pipeline.Process(frame)
+	}
+	return frame, nil
+}
+
+// simulatedStripe is the brightness of the pattern at x, y: stripes 32 pixels wide, darker towards the bottom.
+func simulatedStripe(x int, y int) byte {
+	shade := 200 - 120*y/SLAM_FRAME_HEIGHT
+	if ((x%64)+64)%64 < 32 {
+		shade /= 3
+	}
+	return byte(shade)
+}
+
+func (s *xrealSimulated) SetFramePipeline(pipeline FramePipeline) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.framePipeline = append(FramePipeline(nil), pipeline...)
+	return nil
+}
+
+func (s *xrealSimulated) SetCapturePolicy(policy CapturePolicy) error {
+	return s.capturer.setPolicy(policy)
+}
+
+func (s *xrealSimulated) GetSLAMStreamFormats() ([]StreamFormat, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (s *xrealSimulated) SetSLAMStreamConfig(config StreamConfig) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (s *xrealSimulated) GetRGBStreamFormats() ([]StreamFormat, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (s *xrealSimulated) SetRGBStreamConfig(config StreamConfig) error {
+	return fmt.Errorf("unimplemented")
+}
+
+func (s *xrealSimulated) EnableEventReporting(instruction CommandInstruction, enabled string) error {
+	if err := validateEventReporting(instruction, enabled); err != nil {
+		return err
+	}
+	return s.set(func() error {
+		s.reporting[instruction] = enabled == "1"
+		return nil
+	})
+}
+
+func (s *xrealSimulated) EnableVSync(enabled bool) error {
+	return s.EnableEventReporting(CMD_ENABLE_VSYNC, eventReportingValue(enabled))
+}
+
+func (s *xrealSimulated) EnableAmbientLight(enabled bool) error {
+	return s.EnableEventReporting(CMD_ENABLE_AMBIENT_LIGHT, eventReportingValue(enabled))
+}
+
+func (s *xrealSimulated) EnableMagnetometer(enabled bool) error {
+	return s.EnableEventReporting(CMD_ENABLE_MAGNETOMETER, eventReportingValue(enabled))
+}
+
+func (s *xrealSimulated) EnableTemperature(enabled bool) error {
+	return s.EnableEventReporting(CMD_ENABLE_TEMPERATURE, eventReportingValue(enabled))
+}
+
+func (s *xrealSimulated) EnableIMU(enabled bool) error {
+	return s.EnableEventReporting(OV580_ENABLE_IMU_STREAM, eventReportingValue(enabled))
+}
+
+func (s *xrealSimulated) IsIMUEnabled() bool {
+	return s.reportingEnabled(OV580_ENABLE_IMU_STREAM)
+}
+
+func (s *xrealSimulated) SetAmbientLightEventHandler(handler AmbientLightEventHandler) {
+	s.setHandler(func(h *DeviceHandlers) { h.AmbientLightEventHandler = handler })
+}
+
+func (s *xrealSimulated) SetKeyEventHandler(handler KeyEventHandler) {
+	s.setHandler(func(h *DeviceHandlers) { h.KeyEventHandler = handler })
+}
+
+func (s *xrealSimulated) SetMagnetometerEventHandler(handler MagnetometerEventHandler) {
+	s.setHandler(func(h *DeviceHandlers) { h.MagnetometerEventHandler = handler })
+}
+
+func (s *xrealSimulated) SetProximityEventHandler(handler ProximityEventHandler) {
+	s.setHandler(func(h *DeviceHandlers) { h.ProximityEventHandler = handler })
+}
+
+func (s *xrealSimulated) SetTemperatureEventHandler(handler TemperatureEventHandlder) {
+	s.setHandler(func(h *DeviceHandlers) { h.TemperatureEventHandlder = handler })
+}
+
+func (s *xrealSimulated) SetVSyncEventHandler(handler VSyncEventHandler) {
+	s.setHandler(func(h *DeviceHandlers) { h.VSyncEventHandler = handler })
+}
+
+func (s *xrealSimulated) SetIMUEventHandler(handler IMUEventHandler) {
+	s.setHandler(func(h *DeviceHandlers) { h.IMUEventHandler = handler })
+}
+
+func (s *xrealSimulated) GetIMUEventHandler() IMUEventHandler {
+	return s.handlers().IMUEventHandler
+}
+
+func (s *xrealSimulated) SetResumedEventHandler(handler ResumedEventHandler) {
+	s.setHandler(func(h *DeviceHandlers) { h.ResumedEventHandler = handler })
+}
+
+func (s *xrealSimulated) SetErrorHandler(handler ErrorHandler) {
+	s.setHandler(func(h *DeviceHandlers) { h.ErrorHandler = handler })
+}
+
+// setHandler replaces a handler, nil handlers are replaced by ones ignoring the events.
+func (s *xrealSimulated) setHandler(update func(h *DeviceHandlers)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.wake()
+	update(s.deviceHandlers)
+
+	h := s.deviceHandlers
+	if h.AmbientLightEventHandler == nil {
+		h.AmbientLightEventHandler = func(uint16) {}
+	}
+	if h.KeyEventHandler == nil {
+		h.KeyEventHandler = func(KeyEvent) {}
+	}
+	if h.MagnetometerEventHandler == nil {
+		h.MagnetometerEventHandler = func(*MagnetometerVector) {}
+	}
+	if h.ProximityEventHandler == nil {
+		h.ProximityEventHandler = func(ProximityEvent) {}
+	}
+	if h.TemperatureEventHandlder == nil {
+		h.TemperatureEventHandlder = func(string) {}
+	}
+	if h.VSyncEventHandler == nil {
+		h.VSyncEventHandler = func(string) {}
+	}
+	if h.IMUEventHandler == nil {
+		h.IMUEventHandler = func(*IMUEvent) {}
+	}
+}
+
+func (s *xrealSimulated) ExecuteRaw(command Command, payload []byte) ([]byte, error) {
+	return nil, fmt.Errorf("%w: the simulated glass has no protocol", ErrUnsupportedByFirmware)
+}
+
+func (s *xrealSimulated) ExecuteRawOV580(command Command, value uint8) ([]byte, error) {
+	return nil, fmt.Errorf("%w: the simulated glass has no protocol", ErrUnsupportedByFirmware)
+}
+
+func (s *xrealSimulated) DevExecuteAndRead(device string, input []string) {
+	slog.Error("the simulated glass has no protocol to send raw commands to")
+}
+
+func (s *xrealSimulated) GetImagesDataDev(folderpath string) ([]string, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+// NewSimulatedDevice creates a glass simulated on the host, see `xrealxr simulate`. Like real glasses it logs its
+// events until handlers are set, and reports the IMU and the other toggleable events once enabled.
+func NewSimulatedDevice() Device {
+	s := &xrealSimulated{
+		deviceHandlers: &DeviceHandlers{
+			AmbientLightEventHandler: func(value uint16) {
+				slog.Info(fmt.Sprintf("Ambient light: %d", value))
+			},
+			KeyEventHandler: func(key KeyEvent) {
+				slog.Info(fmt.Sprintf("Key pressed: %s", key.String()))
+			},
+			MagnetometerEventHandler: func(vector *MagnetometerVector) {
+				slog.Info(fmt.Sprintf("Magnetometer: %s", vector.String()))
+			},
+			ProximityEventHandler: func(proximity ProximityEvent) {
+				slog.Info(fmt.Sprintf("Proximity: %s", proximity.String()))
+			},
+			TemperatureEventHandlder: func(value string) {
+				slog.Info(fmt.Sprintf("Temperature: %s", value))
+			},
+			VSyncEventHandler: func(value string) {
+				slog.Info(fmt.Sprintf("VSync: %s", value))
+			},
+			IMUEventHandler: func(imu *IMUEvent) {
+				slog.Info(fmt.Sprintf("IMU: %s", imu.String()), logging.MODULE_KEY, logging.MODULE_IMU)
+			},
+			ResumedEventHandler: func() {
+				slog.Info("Resumed: glass reconnected")
+			},
+		},
+		brightness:  "3",
+		oled:        "0",
+		displayMode: DISPLAY_MODE_SAME_ON_BOTH,
+		sleepTime:   "60",
+		config: map[string]string{
+			"duty":                 "100",
+			"oled_brightness_brit": "0",
+			"display_hdcp":         "simulated",
+			"display_firmware":     "ELLA2_0518_V017",
+			"stock_firmware":       SIMULATED_FIRMWARE,
+			"activated":            "1",
+			"approach_ps":          "0",
+			"distance_ps":          "0",
+			"activation_time":      "0",
+		},
+		reporting: map[CommandInstruction]bool{},
+	}
+	return s
+}
@@ -0,0 +1,88 @@
+package device_test
+
+import (
+	"testing"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestSimulatedDevice(t *testing.T) {
+	d := device.NewSimulatedDevice()
+	if _, err := d.GetSerial(); err == nil {
+		t.Errorf("GetSerial() before Connect() = nil, want error")
+	}
+	if err := d.Connect(); err != nil {
+		t.Fatalf("Connect() = %v", err)
+	}
+	defer d.Disconnect()
+
+	if report := device.RunConformance(d); !report.Passed() {
+		t.Errorf("RunConformance() failed:\n%s", report)
+	}
+
+	if err := d.SetBrightnessLevel("6"); err != nil {
+		t.Fatalf("SetBrightnessLevel() = %v", err)
+	}
+	if level, _ := d.GetConfigValue("brightness"); level != "6" {
+		t.Errorf("GetConfigValue(brightness) = %s, want 6", level)
+	}
+	if err := d.SetBrightnessLevel("9"); err == nil {
+		t.Errorf("SetBrightnessLevel(9) = nil, want error")
+	}
+
+	events := make(chan *device.IMUEvent, 1)
+	d.SetIMUEventHandler(func(event *device.IMUEvent) {
+		select {
+		case events <- event:
+		default:
+		}
+	})
+	if err := d.EnableIMU(true); err != nil {
+		t.Fatalf("EnableIMU() = %v", err)
+	}
+	select {
+	case event := <-events:
+		if event.Accelerometer.Z < 9 {
+			t.Errorf("IMU event %s, want gravity mostly along Z", event)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("no IMU event within 1s of enabling the IMU")
+	}
+
+	frame, err := d.GetSLAMFrameRaw()
+	if err != nil {
+		t.Fatalf("GetSLAMFrameRaw() = %v", err)
+	}
+	if len(frame.Left) != device.SLAM_FRAME_WIDTH*device.SLAM_FRAME_HEIGHT || frame.Statistics == nil {
+		t.Errorf("GetSLAMFrameRaw() = %d bytes with statistics %v, want a full frame", len(frame.Left), frame.Statistics)
+	}
+}
+
+func TestSimulatedDeviceSubscribeAfterIdle(t *testing.T) {
+	d := device.NewSimulatedDevice()
+	if err := d.Connect(); err != nil {
+		t.Fatalf("Connect() = %v", err)
+	}
+	defer d.Disconnect()
+
+	if err := d.EnableIMU(true); err != nil {
+		t.Fatalf("EnableIMU() = %v", err)
+	}
+	if err := d.Idle(); err != nil {
+		t.Fatalf("Idle() = %v", err)
+	}
+
+	events := make(chan *device.IMUEvent, 1)
+	d.SetIMUEventHandler(func(event *device.IMUEvent) {
+		select {
+		case events <- event:
+		default:
+		}
+	})
+	select {
+	case <-events:
+	case <-time.After(time.Second):
+		t.Errorf("no IMU event within 1s of subscribing after idle, want the IMU stream enabled again")
+	}
+}
@@ -0,0 +1,86 @@
+package device
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const protocolTraceSize = 256
+
+// TraceEntry is a packet sent to or received from the glass MCU.
+type TraceEntry struct {
+	Time time.Time
+	// Direction is either "tx" or "rx"
+	Direction string
+	Command   Command
+	Payload   string
+}
+
+func (e TraceEntry) String() string {
+	return fmt.Sprintf("%s %s 0x%02x/0x%02x %q", e.Time.Format(time.RFC3339Nano), e.Direction, e.Command.Type, e.Command.ID, e.Payload)
+}
+
+// protocolTrace keeps the recent packets in a ring buffer plus per command packet counts for bug reports.
+type protocolTrace struct {
+	// mutex for thread safety
+	mutex   sync.Mutex
+	entries []TraceEntry
+	next    int
+	counts  map[string]uint64
+}
+
+var trace = &protocolTrace{counts: make(map[string]uint64)}
+
+func (t *protocolTrace) record(direction string, pkt *Packet) {
+	if pkt == nil || pkt.Command == nil {
+		return
+	}
+
+	entry := TraceEntry{
+		Time:      time.Now(),
+		Direction: direction,
+		Command:   Command{Type: pkt.Command.Type, ID: pkt.Command.ID},
+		Payload:   string(pkt.Payload),
+	}
+	// serial numbers identify the owner, so keep them out of bug reports
+	if entry.Command.ID == 0x43 && (entry.Command.Type == 0x33 || entry.Command.Type == 0x34) {
+		entry.Payload = "<redacted>"
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.counts[fmt.Sprintf("%s 0x%02x/0x%02x", direction, entry.Command.Type, entry.Command.ID)]++
+
+	if len(t.entries) < protocolTraceSize {
+		t.entries = append(t.entries, entry)
+		return
+	}
+	t.entries[t.next] = entry
+	t.next = (t.next + 1) % protocolTraceSize
+}
+
+// GetProtocolTrace returns the recent MCU packets, oldest first, with serial numbers redacted.
+// Polling and heart beat packets are not recorded.
+func GetProtocolTrace() []TraceEntry {
+	trace.mutex.Lock()
+	defer trace.mutex.Unlock()
+
+	entries := make([]TraceEntry, 0, len(trace.entries))
+	entries = append(entries, trace.entries[trace.next:]...)
+	entries = append(entries, trace.entries[:trace.next]...)
+	return entries
+}
+
+// GetProtocolStatistics returns the number of MCU packets seen per direction and command since start.
+func GetProtocolStatistics() map[string]uint64 {
+	trace.mutex.Lock()
+	defer trace.mutex.Unlock()
+
+	counts := make(map[string]uint64, len(trace.counts))
+	for key, count := range trace.counts {
+		counts[key] = count
+	}
+	return counts
+}
@@ -0,0 +1,303 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MAX_BRIGHTNESS_LEVEL is the highest brightness level, levels go from 0 to it.
+const MAX_BRIGHTNESS_LEVEL = 7
+
+// rampStepsPerLevel is how many steps RampBrightness takes from one level to the next when the glass has a duty.
+const rampStepsPerLevel = 4
+
+// errTransitionStopped is returned by rampBrightness when stop was closed before the ramp finished.
+var errTransitionStopped = errors.New("transition stopped")
+
+// RampBrightness changes the brightness level of d to level gradually, spread over duration, instead of jumping there
+// at once. Between levels it steps through display duty values, on the rough model, not measured on the glass, that
+// the brightness is proportional to the level times the duty, e.g. level 3 at 75% duty stands in for level 2.25. The
+// duty is back to where it was at the end. Glasses without a duty step one level at a time. It stops at the first step
+// that fails.
+func RampBrightness(d Device, level string, duration time.Duration) error {
+	return rampBrightness(d, level, duration, nil)
+}
+
+// rampBrightness is RampBrightness, returning errTransitionStopped once stop is closed, with the duty restored and the
+// level reached so far.
+func rampBrightness(d Device, level string, duration time.Duration, stop <-chan struct{}) error {
+	target, err := parseBrightnessLevel(level)
+	if err != nil {
+		return err
+	}
+	queue := &rampQueue{d: d}
+	current, err := d.GetBrightnessLevel()
+	if err != nil {
+		return fmt.Errorf("failed to get brightness level: %w", err)
+	}
+	from, err := parseBrightnessLevel(current)
+	if err != nil {
+		return fmt.Errorf("unexpected brightness level of the glass: %w", err)
+	}
+	if from == target {
+		return nil
+	}
+
+	// a display switched off by its duty is left alone
+	duty, err := queue.getDuty()
+	stepsPerLevel := rampStepsPerLevel
+	if err != nil || duty == 0 {
+		slog.Debug(fmt.Sprintf("ramping brightness one level at a time, as the duty is %d: %v", duty, err))
+		stepsPerLevel = 1
+	}
+
+	state := rampStep{level: from, duty: duty}
+	steps := abs(target-from) * stepsPerLevel
+	// the first step is taken right away and the last one once duration elapsed, commands taking their time included
+	started := time.Now()
+	for i := 1; i <= steps; i++ {
+		if i > 1 {
+			timer := time.NewTimer(time.Until(started.Add(duration * time.Duration(i-1) / time.Duration(steps-1))))
+			select {
+			case <-stop:
+				timer.Stop()
+				if err := queue.send(state, rampStep{level: state.level, duty: duty}); err != nil {
+					return fmt.Errorf("failed to restore duty %d: %w", duty, err)
+				}
+				return errTransitionStopped
+			case <-timer.C:
+			}
+		}
+
+		brightness := float64(from) + float64(target-from)*float64(i)/float64(steps)
+		next := rampStep{level: int(math.Ceil(brightness)), duty: duty}
+		if next.level > 0 && stepsPerLevel > 1 {
+			next.duty = int(math.Round(float64(duty) * brightness / float64(next.level)))
+		}
+		if err := queue.send(state, next); err != nil {
+			return fmt.Errorf("failed to ramp brightness level to %.2f: %w", brightness, err)
+		}
+		state = next
+	}
+	return nil
+}
+
+// rampStep is the brightness level and duty of a step of RampBrightness.
+type rampStep struct {
+	level int
+	duty  int
+}
+
+// rampQueue sends the steps of RampBrightness through the command queue of the glass, see Device.ExecuteAsync, or
+// with the methods of d for commands it cannot queue.
+type rampQueue struct {
+	d Device
+}
+
+// execute tells if the instruction was queued, and its response if so.
+func (q *rampQueue) execute(instruction CommandInstruction, payload []byte) (string, bool, error) {
+	response, err := q.d.ExecuteAsync(instruction, payload).Wait()
+	if errors.Is(err, ErrUnsupportedCommand) {
+		return "", false, nil
+	}
+	return response, true, err
+}
+
+func (q *rampQueue) getDuty() (int, error) {
+	response, queued, err := q.execute(CMD_GET_DUTY, nil)
+	if !queued {
+		response, err = q.d.GetConfigValue("duty")
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(response))
+}
+
+// send changes the glass from one step to the next. The level goes up along with the duty going down, so the duty
+// changes first then, and last otherwise, so the glass dims for a moment rather than flashes.
+func (q *rampQueue) send(from rampStep, to rampStep) error {
+	setLevel := func() error {
+		if to.level == from.level {
+			return nil
+		}
+		level := strconv.Itoa(to.level)
+		if _, queued, err := q.execute(CMD_SET_BRIGHTNESS_LEVEL, []byte(level)); queued {
+			return err
+		}
+		return q.d.SetBrightnessLevel(level)
+	}
+	setDuty := func() error {
+		if to.duty == from.duty {
+			return nil
+		}
+		duty := strconv.Itoa(to.duty)
+		if _, queued, err := q.execute(CMD_SET_DUTY, []byte(duty)); queued {
+			return err
+		}
+		return q.d.SetConfigValue("duty", duty)
+	}
+
+	first, second := setLevel, setDuty
+	if to.level > from.level {
+		first, second = setDuty, setLevel
+	}
+	if err := first(); err != nil {
+		return err
+	}
+	return second()
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// FadeDisplayMode switches d to the display mode behind a fade: the brightness ramps down to 0 over the first half of
+// duration and back to where it was over the second half, hiding the flicker of the switch. The brightness is
+// restored even if the switch fails.
+func FadeDisplayMode(d Device, mode DisplayMode, duration time.Duration) error {
+	if current, err := d.GetDisplayMode(); err == nil && current == mode {
+		return nil
+	}
+	level, err := d.GetBrightnessLevel()
+	if err != nil {
+		return fmt.Errorf("failed to get brightness level: %w", err)
+	}
+
+	if err := RampBrightness(d, "0", duration/2); err != nil {
+		return err
+	}
+	switchErr := d.SetDisplayMode(mode)
+	if err := RampBrightness(d, level, duration/2); err != nil {
+		slog.Warn(fmt.Sprintf("failed to restore brightness level %s: %v", level, err))
+	}
+	if switchErr != nil {
+		return fmt.Errorf("failed to set display mode: %w", switchErr)
+	}
+	return nil
+}
+
+func parseBrightnessLevel(level string) (int, error) {
+	l, err := strconv.Atoi(level)
+	if err != nil || l < 0 || l > MAX_BRIGHTNESS_LEVEL {
+		return 0, fmt.Errorf("invalid brightness level %s, must be 0-%d", level, MAX_BRIGHTNESS_LEVEL)
+	}
+	return l, nil
+}
+
+// BrightnessTransition sets the brightness level at a time of day.
+type BrightnessTransition struct {
+	// At is the time since midnight
+	At    time.Duration
+	Level string
+}
+
+// BrightnessSchedule is a daily schedule of brightness levels, e.g. dimming the glass at night. It is sorted by
+// time, each level holds from its time until the time of the next one, the last one until the first one next day.
+type BrightnessSchedule []BrightnessTransition
+
+// ParseBrightnessSchedule parses comma separated transitions as <hh:mm>=<level>, e.g. "07:00=6,22:30=2".
+func ParseBrightnessSchedule(schedule string) (BrightnessSchedule, error) {
+	var transitions BrightnessSchedule
+	for _, part := range strings.Split(schedule, ",") {
+		at, level, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid brightness transition %s, want <hh:mm>=<level>", part)
+		}
+		clock, err := time.Parse("15:04", at)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time of brightness transition %s, want hh:mm", part)
+		}
+		if _, err := parseBrightnessLevel(level); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, BrightnessTransition{
+			At:    time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute,
+			Level: level,
+		})
+	}
+	sort.Slice(transitions, func(i, j int) bool { return transitions[i].At < transitions[j].At })
+	for i := 1; i < len(transitions); i++ {
+		if transitions[i].At == transitions[i-1].At {
+			return nil, fmt.Errorf("more than one brightness transition at %v", transitions[i].At)
+		}
+	}
+	return transitions, nil
+}
+
+// LevelAt returns the brightness level the schedule sets at t, in the time zone of t. Transitions are at wall clock
+// times, so they hold across daylight saving time changes.
+func (s BrightnessSchedule) LevelAt(t time.Time) string {
+	if len(s) == 0 {
+		return ""
+	}
+	level := s[len(s)-1].Level
+	hour, minute, second := t.Clock()
+	sinceMidnight := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second
+	for _, transition := range s {
+		if transition.At > sinceMidnight {
+			break
+		}
+		level = transition.Level
+	}
+	return level
+}
+
+// Next returns the time of the first transition after t, in the time zone of t.
+func (s BrightnessSchedule) Next(t time.Time) time.Time {
+	if len(s) == 0 {
+		return time.Time{}
+	}
+	for _, transition := range s {
+		if at := transition.on(t, 0); at.After(t) {
+			return at
+		}
+	}
+	return s[0].on(t, 1)
+}
+
+// on returns the time of the transition on the day of t plus days, built from the wall clock as days with a daylight
+// saving time change are not 24 hours long.
+func (transition BrightnessTransition) on(t time.Time, days int) time.Time {
+	year, month, day := t.Date()
+	hour := int(transition.At / time.Hour)
+	minute := int(transition.At % time.Hour / time.Minute)
+	return time.Date(year, month, day+days, hour, minute, 0, 0, t.Location())
+}
+
+// RunBrightnessSchedule sets the brightness level of d to the one the schedule sets now, and then to the one of
+// every transition when it is due, ramping over ramp, until stop is closed, which also interrupts a ramp. Failures are
+// logged and retried at the next transition.
+func RunBrightnessSchedule(d Device, schedule BrightnessSchedule, ramp time.Duration, stop <-chan struct{}) {
+	if len(schedule) == 0 {
+		return
+	}
+	for {
+		now := time.Now()
+		level := schedule.LevelAt(now)
+		if err := rampBrightness(d, level, ramp, stop); errors.Is(err, errTransitionStopped) {
+			return
+		} else if err != nil {
+			slog.Error(fmt.Sprintf("failed to apply scheduled brightness level %s: %v", level, err))
+		} else {
+			slog.Info(fmt.Sprintf("applied scheduled brightness level %s", level))
+		}
+
+		timer := time.NewTimer(time.Until(schedule.Next(now)))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package device
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// brightnessDevice only implements what the transitions call, the embedded nil Device panics on anything else.
+type brightnessDevice struct {
+	Device
+	level string
+	// duty is empty if the glass has none
+	duty string
+	// queued tells if commands go through ExecuteAsync rather than the setters
+	queued bool
+	mode   DisplayMode
+	// calls records the brightness levels, duties prefixed with d, and display modes set, in order
+	calls     []string
+	modeError error
+}
+
+func (d *brightnessDevice) GetBrightnessLevel() (string, error) {
+	return d.level, nil
+}
+
+func (d *brightnessDevice) SetBrightnessLevel(level string) error {
+	if d.queued {
+		return errors.New("set while commands are queued")
+	}
+	d.level = level
+	d.calls = append(d.calls, level)
+	return nil
+}
+
+func (d *brightnessDevice) GetConfigValue(key string) (string, error) {
+	if d.queued || key != "duty" || d.duty == "" {
+		return "", unimplemented("GetConfigValue")
+	}
+	return d.duty, nil
+}
+
+func (d *brightnessDevice) ExecuteAsync(instruction CommandInstruction, payload []byte) *CommandFuture {
+	if !d.queued {
+		return resolvedCommandFuture(unimplemented("ExecuteAsync"))
+	}
+	future := newCommandFuture()
+	switch instruction {
+	case CMD_SET_BRIGHTNESS_LEVEL:
+		d.level = string(payload)
+		d.calls = append(d.calls, d.level)
+		future.resolve(payload, nil)
+	case CMD_GET_DUTY:
+		future.resolve([]byte(d.duty), nil)
+	case CMD_SET_DUTY:
+		d.duty = string(payload)
+		d.calls = append(d.calls, "d"+d.duty)
+		future.resolve(payload, nil)
+	default:
+		future.resolve(nil, unimplemented("ExecuteAsync"))
+	}
+	return future
+}
+
+func (d *brightnessDevice) GetDisplayMode() (DisplayMode, error) {
+	return d.mode, nil
+}
+
+func (d *brightnessDevice) SetDisplayMode(mode DisplayMode) error {
+	if d.modeError != nil {
+		return d.modeError
+	}
+	d.mode = mode
+	d.calls = append(d.calls, string(mode))
+	return nil
+}
+
+func TestRampBrightness(t *testing.T) {
+	d := &brightnessDevice{level: "2"}
+	started := time.Now()
+	if err := RampBrightness(d, "6", 30*time.Millisecond); err != nil {
+		t.Fatalf("RampBrightness() = %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < 30*time.Millisecond {
+		t.Errorf("ramp took %v, want 30ms", elapsed)
+	}
+	if want := []string{"3", "4", "5", "6"}; !reflect.DeepEqual(d.calls, want) {
+		t.Errorf("levels set = %v, want %v", d.calls, want)
+	}
+
+	d.calls = nil
+	if err := RampBrightness(d, "4", 0); err != nil {
+		t.Fatalf("RampBrightness() = %v", err)
+	}
+	if want := []string{"5", "4"}; !reflect.DeepEqual(d.calls, want) {
+		t.Errorf("levels set = %v, want %v", d.calls, want)
+	}
+
+	d.calls = nil
+	if err := RampBrightness(d, "4", time.Hour); err != nil || len(d.calls) != 0 {
+		t.Errorf("RampBrightness() to the current level = %v setting %v, want nothing set", err, d.calls)
+	}
+	if err := RampBrightness(d, "8", 0); err == nil {
+		t.Errorf("RampBrightness(8) = nil, want error")
+	}
+}
+
+func TestRampBrightnessSteppingDuty(t *testing.T) {
+	d := &brightnessDevice{level: "2", duty: "100", queued: true}
+	if err := RampBrightness(d, "3", 0); err != nil {
+		t.Fatalf("RampBrightness() = %v", err)
+	}
+	// the duty goes down before the level goes up, so the glass dims rather than flashes
+	if want := []string{"d75", "3", "d83", "d92", "d100"}; !reflect.DeepEqual(d.calls, want) {
+		t.Errorf("calls up = %v, want %v", d.calls, want)
+	}
+
+	d.calls = nil
+	if err := RampBrightness(d, "2", 0); err != nil {
+		t.Fatalf("RampBrightness() = %v", err)
+	}
+	if want := []string{"d92", "d83", "d75", "2", "d100"}; !reflect.DeepEqual(d.calls, want) {
+		t.Errorf("calls down = %v, want %v", d.calls, want)
+	}
+
+	// stopped ramps leave the level reached with the duty restored
+	d.calls = nil
+	stop := make(chan struct{})
+	close(stop)
+	if err := rampBrightness(d, "4", time.Hour, stop); !errors.Is(err, errTransitionStopped) {
+		t.Fatalf("rampBrightness() stopped = %v, want errTransitionStopped", err)
+	}
+	if want := []string{"d75", "3", "d100"}; !reflect.DeepEqual(d.calls, want) {
+		t.Errorf("calls stopped = %v, want %v", d.calls, want)
+	}
+}
+
+func TestFadeDisplayMode(t *testing.T) {
+	d := &brightnessDevice{level: "2", mode: DISPLAY_MODE_SAME_ON_BOTH}
+	if err := FadeDisplayMode(d, DISPLAY_MODE_STEREO, 0); err != nil {
+		t.Fatalf("FadeDisplayMode() = %v", err)
+	}
+	if want := []string{"1", "0", string(DISPLAY_MODE_STEREO), "1", "2"}; !reflect.DeepEqual(d.calls, want) {
+		t.Errorf("calls = %v, want %v", d.calls, want)
+	}
+
+	// the brightness comes back even if the switch fails
+	d = &brightnessDevice{level: "1", mode: DISPLAY_MODE_SAME_ON_BOTH, modeError: errors.New("nope")}
+	if err := FadeDisplayMode(d, DISPLAY_MODE_STEREO, 0); err == nil {
+		t.Errorf("FadeDisplayMode() = nil, want the error of the switch")
+	}
+	if d.level != "1" {
+		t.Errorf("brightness level = %s, want 1 restored", d.level)
+	}
+}
+
+func TestBrightnessSchedule(t *testing.T) {
+	schedule, err := ParseBrightnessSchedule("22:30=2, 07:00=6")
+	if err != nil {
+		t.Fatalf("ParseBrightnessSchedule() = %v", err)
+	}
+	if schedule[0].At != 7*time.Hour || schedule[1].At != 22*time.Hour+30*time.Minute {
+		t.Errorf("schedule = %v, want it sorted by time", schedule)
+	}
+
+	day := func(hour, minute int) time.Time {
+		return time.Date(2024, 6, 12, hour, minute, 0, 0, time.UTC)
+	}
+	testCases := []struct {
+		at    time.Time
+		level string
+		next  time.Time
+	}{
+		{day(3, 0), "2", day(7, 0)},
+		{day(7, 0), "6", day(22, 30)},
+		{day(12, 0), "6", day(22, 30)},
+		{day(23, 0), "2", day(7, 0).AddDate(0, 0, 1)},
+	}
+	for _, tc := range testCases {
+		if level := schedule.LevelAt(tc.at); level != tc.level {
+			t.Errorf("LevelAt(%v) = %s, want %s", tc.at, level, tc.level)
+		}
+		if next := schedule.Next(tc.at); !next.Equal(tc.next) {
+			t.Errorf("Next(%v) = %v, want %v", tc.at, next, tc.next)
+		}
+	}
+
+	// on the day clocks go forward, transitions stay at their wall clock time
+	if newYork, err := time.LoadLocation("America/New_York"); err != nil {
+		t.Logf("skipping daylight saving time cases: %v", err)
+	} else {
+		springForward := func(hour, minute int) time.Time {
+			return time.Date(2024, 3, 10, hour, minute, 0, 0, newYork)
+		}
+		if level := schedule.LevelAt(springForward(7, 30)); level != "6" {
+			t.Errorf("LevelAt(%v) = %s, want 6", springForward(7, 30), level)
+		}
+		if next := schedule.Next(springForward(3, 0)); !next.Equal(springForward(7, 0)) {
+			t.Errorf("Next(%v) = %v, want %v", springForward(3, 0), next, springForward(7, 0))
+		}
+	}
+
+	for _, invalid := range []string{"", "07:00", "7am=6", "07:00=9", "07:00=6,07:00=2"} {
+		if _, err := ParseBrightnessSchedule(invalid); err == nil {
+			t.Errorf("ParseBrightnessSchedule(%q) = nil, want error", invalid)
+		}
+	}
+}
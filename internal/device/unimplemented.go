@@ -0,0 +1,303 @@
+package device
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+	"time"
+
+	"xreal-light-xr-go/storage"
+)
+
+// UnimplementedDevice is embedded by Device implementations of models registered with RegisterModel, so they only
+// implement Name, PID, VID, Connect and Disconnect, and what their hardware supports on top. Every other method
+// fails with ErrUnsupportedCommand, and keeps compiling as methods are added to Device. Event handlers are ignored.
+type UnimplementedDevice struct{}
+
+func unimplemented(method string) error {
+	return fmt.Errorf("%s: %w, %w", method, ErrUnsupportedCommand, ErrUnsupportedByFirmware)
+}
+
+func (UnimplementedDevice) GetRole() (Role, error) {
+	return "", unimplemented("GetRole")
+}
+
+func (UnimplementedDevice) RequestControl(timeout time.Duration) error {
+	return unimplemented("RequestControl")
+}
+
+func (UnimplementedDevice) ReleaseControl() error {
+	return unimplemented("ReleaseControl")
+}
+
+func (UnimplementedDevice) GetSerial() (string, error) {
+	return "", unimplemented("GetSerial")
+}
+
+func (UnimplementedDevice) GetFirmwareVersion() (string, error) {
+	return "", unimplemented("GetFirmwareVersion")
+}
+
+func (UnimplementedDevice) GetStockFirmwareVersion() (string, error) {
+	return "", unimplemented("GetStockFirmwareVersion")
+}
+
+func (UnimplementedDevice) GetDisplayFirmware() (*DisplayVersion, error) {
+	return nil, unimplemented("GetDisplayFirmware")
+}
+
+func (UnimplementedDevice) GetDisplayHDCPVersion() (*DisplayVersion, error) {
+	return nil, unimplemented("GetDisplayHDCPVersion")
+}
+
+func (UnimplementedDevice) GetOV580Info() (*OV580Info, error) {
+	return nil, unimplemented("GetOV580Info")
+}
+
+func (UnimplementedDevice) ReadOV580File(id uint8, options OV580FileOptions) (*OV580File, error) {
+	return nil, unimplemented("ReadOV580File")
+}
+
+func (UnimplementedDevice) GetMCUInfo() (*ConnectionInfo, error) {
+	return nil, unimplemented("GetMCUInfo")
+}
+
+func (UnimplementedDevice) GetSensorInfo() (*ConnectionInfo, error) {
+	return nil, unimplemented("GetSensorInfo")
+}
+
+func (UnimplementedDevice) GetCameraInfo() ([]ConnectionInfo, error) {
+	return nil, unimplemented("GetCameraInfo")
+}
+
+func (UnimplementedDevice) InvalidateInfoCache() {}
+
+func (UnimplementedDevice) ResetSensors() error {
+	return unimplemented("ResetSensors")
+}
+
+func (UnimplementedDevice) SetRetryPolicy(policy RetryPolicy) error {
+	return unimplemented("SetRetryPolicy")
+}
+
+func (UnimplementedDevice) SetStreamWatchdog(policy *WatchdogPolicy) error {
+	return unimplemented("SetStreamWatchdog")
+}
+
+func (UnimplementedDevice) GetBrightnessLevel() (string, error) {
+	return "", unimplemented("GetBrightnessLevel")
+}
+
+func (UnimplementedDevice) SetBrightnessLevel(level string) error {
+	return unimplemented("SetBrightnessLevel")
+}
+
+func (UnimplementedDevice) GetOLEDBrightnessLevel() (string, error) {
+	return "", unimplemented("GetOLEDBrightnessLevel")
+}
+
+func (UnimplementedDevice) SetOLEDBrightnessLevel(level string) error {
+	return unimplemented("SetOLEDBrightnessLevel")
+}
+
+func (UnimplementedDevice) GetOLEDBrightnessBrit() (string, error) {
+	return "", unimplemented("GetOLEDBrightnessBrit")
+}
+
+func (UnimplementedDevice) GetKeySwitchEnabled() (bool, error) {
+	return false, unimplemented("GetKeySwitchEnabled")
+}
+
+func (UnimplementedDevice) SetKeySwitchEnabled(enabled bool) error {
+	return unimplemented("SetKeySwitchEnabled")
+}
+
+func (UnimplementedDevice) GetDefault2DEnabled() (bool, error) {
+	return false, unimplemented("GetDefault2DEnabled")
+}
+
+func (UnimplementedDevice) SetDefault2DEnabled(enabled bool) error {
+	return unimplemented("SetDefault2DEnabled")
+}
+
+func (UnimplementedDevice) GetOrbitFunction() (string, error) {
+	return "", unimplemented("GetOrbitFunction")
+}
+
+func (UnimplementedDevice) SetOrbitFunction(open bool) error {
+	return unimplemented("SetOrbitFunction")
+}
+
+func (UnimplementedDevice) GetSuperActive() (bool, error) {
+	return false, unimplemented("GetSuperActive")
+}
+
+func (UnimplementedDevice) SetSuperActive(enabled bool) error {
+	return unimplemented("SetSuperActive")
+}
+
+func (UnimplementedDevice) GetRGBCameraEnabled() (bool, error) {
+	return false, unimplemented("GetRGBCameraEnabled")
+}
+
+func (UnimplementedDevice) GetClockSync() (*ClockSync, error) {
+	return nil, unimplemented("GetClockSync")
+}
+
+func (UnimplementedDevice) Idle() error {
+	return unimplemented("Idle")
+}
+
+func (UnimplementedDevice) GetCapabilities() (*Capabilities, error) {
+	return nil, unimplemented("GetCapabilities")
+}
+
+func (UnimplementedDevice) GetConfigValue(key string) (string, error) {
+	return "", unimplemented("GetConfigValue")
+}
+
+func (UnimplementedDevice) SetConfigValue(key string, value string) error {
+	return unimplemented("SetConfigValue")
+}
+
+func (UnimplementedDevice) ExecuteAsync(instruction CommandInstruction, payload []byte) *CommandFuture {
+	return resolvedCommandFuture(unimplemented("ExecuteAsync"))
+}
+
+func (UnimplementedDevice) GetSleepTime() (string, error) {
+	return "", unimplemented("GetSleepTime")
+}
+
+func (UnimplementedDevice) SetSleepTime(seconds string) error {
+	return unimplemented("SetSleepTime")
+}
+
+func (UnimplementedDevice) DisplayOff() error {
+	return unimplemented("DisplayOff")
+}
+
+func (UnimplementedDevice) DisplayOn() error {
+	return unimplemented("DisplayOn")
+}
+
+func (UnimplementedDevice) GetDisplayMode() (DisplayMode, error) {
+	return DISPLAY_MODE_UNKNOWN, unimplemented("GetDisplayMode")
+}
+
+func (UnimplementedDevice) SetDisplayMode(mode DisplayMode) error {
+	return unimplemented("SetDisplayMode")
+}
+
+func (UnimplementedDevice) EnterSBS(duration time.Duration) error {
+	return unimplemented("EnterSBS")
+}
+
+func (UnimplementedDevice) ExitSBS() error {
+	return unimplemented("ExitSBS")
+}
+
+func (UnimplementedDevice) GetImages(folderpath string) ([]string, error) {
+	return nil, unimplemented("GetImages")
+}
+
+func (UnimplementedDevice) CaptureImages(store storage.Storage) ([]string, error) {
+	return nil, unimplemented("CaptureImages")
+}
+
+func (UnimplementedDevice) GetSLAMFrame() (image.Image, image.Image, time.Time, error) {
+	return nil, nil, time.Time{}, unimplemented("GetSLAMFrame")
+}
+
+func (UnimplementedDevice) GetSLAMFrameRaw() (*SLAMFrame, error) {
+	return nil, unimplemented("GetSLAMFrameRaw")
+}
+
+func (UnimplementedDevice) SetFramePipeline(pipeline FramePipeline) error {
+	return unimplemented("SetFramePipeline")
+}
+
+func (UnimplementedDevice) SetCapturePolicy(policy CapturePolicy) error {
+	return unimplemented("SetCapturePolicy")
+}
+
+func (UnimplementedDevice) GetSLAMStreamFormats() ([]StreamFormat, error) {
+	return nil, unimplemented("GetSLAMStreamFormats")
+}
+
+func (UnimplementedDevice) SetSLAMStreamConfig(config StreamConfig) error {
+	return unimplemented("SetSLAMStreamConfig")
+}
+
+func (UnimplementedDevice) GetRGBStreamFormats() ([]StreamFormat, error) {
+	return nil, unimplemented("GetRGBStreamFormats")
+}
+
+func (UnimplementedDevice) SetRGBStreamConfig(config StreamConfig) error {
+	return unimplemented("SetRGBStreamConfig")
+}
+
+func (UnimplementedDevice) EnableEventReporting(event CommandInstruction, enabled string) error {
+	return unimplemented("EnableEventReporting")
+}
+
+func (UnimplementedDevice) EnableVSync(enabled bool) error {
+	return unimplemented("EnableVSync")
+}
+
+func (UnimplementedDevice) EnableAmbientLight(enabled bool) error {
+	return unimplemented("EnableAmbientLight")
+}
+
+func (UnimplementedDevice) EnableMagnetometer(enabled bool) error {
+	return unimplemented("EnableMagnetometer")
+}
+
+func (UnimplementedDevice) EnableTemperature(enabled bool) error {
+	return unimplemented("EnableTemperature")
+}
+
+func (UnimplementedDevice) EnableIMU(enabled bool) error {
+	return unimplemented("EnableIMU")
+}
+
+func (UnimplementedDevice) IsIMUEnabled() bool {
+	return false
+}
+
+func (UnimplementedDevice) SetAmbientLightEventHandler(handler AmbientLightEventHandler) {}
+
+func (UnimplementedDevice) SetKeyEventHandler(handler KeyEventHandler) {}
+
+func (UnimplementedDevice) SetMagnetometerEventHandler(handler MagnetometerEventHandler) {}
+
+func (UnimplementedDevice) SetProximityEventHandler(handler ProximityEventHandler) {}
+
+func (UnimplementedDevice) SetTemperatureEventHandler(handler TemperatureEventHandlder) {}
+
+func (UnimplementedDevice) SetVSyncEventHandler(handler VSyncEventHandler) {}
+
+func (UnimplementedDevice) SetIMUEventHandler(handler IMUEventHandler) {}
+
+func (UnimplementedDevice) GetIMUEventHandler() IMUEventHandler {
+	return nil
+}
+
+func (UnimplementedDevice) SetResumedEventHandler(handler ResumedEventHandler) {}
+
+func (UnimplementedDevice) SetErrorHandler(handler ErrorHandler) {}
+
+func (UnimplementedDevice) ExecuteRaw(command Command, payload []byte) ([]byte, error) {
+	return nil, unimplemented("ExecuteRaw")
+}
+
+func (UnimplementedDevice) ExecuteRawOV580(command Command, value uint8) ([]byte, error) {
+	return nil, unimplemented("ExecuteRawOV580")
+}
+
+func (UnimplementedDevice) DevExecuteAndRead(device string, input []string) {
+	slog.Error(fmt.Sprintf("DevExecuteAndRead: %v", unimplemented("DevExecuteAndRead")))
+}
+
+func (UnimplementedDevice) GetImagesDataDev(folderpath string) ([]string, error) {
+	return nil, unimplemented("GetImagesDataDev")
+}
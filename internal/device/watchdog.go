@@ -0,0 +1,232 @@
+package device
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RecoveryAction is what the stream watchdog does about a stalled stream.
+type RecoveryAction string
+
+const (
+	// RECOVERY_REPORT_ONLY only reports the stall
+	RECOVERY_REPORT_ONLY = RecoveryAction("report")
+	// RECOVERY_REENABLE re-enables the IMU stream, or reopens the cameras
+	RECOVERY_REENABLE = RecoveryAction("reenable")
+	// RECOVERY_RESET_SENSORS resets the OV580, see Device.ResetSensors
+	RECOVERY_RESET_SENSORS = RecoveryAction("reset-sensors")
+	// RECOVERY_RECONNECT reconnects the whole glass
+	RECOVERY_RECONNECT = RecoveryAction("reconnect")
+)
+
+// ErrStreamStalled is reported through the ErrorHandler when the stream watchdog finds a stream stalled,
+// once per recovery attempt.
+var ErrStreamStalled = errors.New("stream stalled")
+
+const (
+	watchdogCheckFrequency = 100 * time.Millisecond
+
+	streamIMU        = "imu"
+	streamSLAMCamera = "slam camera"
+)
+
+// WatchdogPolicy configures the stream watchdog, see Device.SetStreamWatchdog.
+type WatchdogPolicy struct {
+	// IMUTimeout is how long no IMU sample may arrive while the IMU stream is enabled, 0 to not watch the IMU
+	IMUTimeout time.Duration
+	// CameraTimeout is how long a SLAM frame request may go unanswered, 0 to not watch the cameras
+	CameraTimeout time.Duration
+	// Actions are taken in order on consecutive stalls of a stream and the last one repeats until the stream
+	// recovers, so they should escalate. Empty only reports the stall.
+	Actions []RecoveryAction
+}
+
+// DefaultWatchdogPolicy escalates from re-enabling the stream to resetting the OV580 to reconnecting the glass.
+func DefaultWatchdogPolicy() WatchdogPolicy {
+	return WatchdogPolicy{
+		IMUTimeout:    500 * time.Millisecond,
+		CameraTimeout: 2 * time.Second,
+		Actions:       []RecoveryAction{RECOVERY_REENABLE, RECOVERY_RESET_SENSORS, RECOVERY_RECONNECT},
+	}
+}
+
+// ParseRecoveryActions parses comma separated actions, e.g. "reenable,reset-sensors,reconnect".
+func ParseRecoveryActions(input string) ([]RecoveryAction, error) {
+	actions := []RecoveryAction{}
+	for _, name := range strings.Split(input, ",") {
+		action := RecoveryAction(strings.TrimSpace(name))
+		switch action {
+		case "":
+			continue
+		case RECOVERY_REPORT_ONLY, RECOVERY_REENABLE, RECOVERY_RESET_SENSORS, RECOVERY_RECONNECT:
+			actions = append(actions, action)
+		default:
+			return nil, fmt.Errorf(
+				"unknown recovery action: got (%s) want one of (%s %s %s %s)",
+				action, RECOVERY_REPORT_ONLY, RECOVERY_REENABLE, RECOVERY_RESET_SENSORS, RECOVERY_RECONNECT,
+			)
+		}
+	}
+	return actions, nil
+}
+
+// streamActivity tracks when data of a stream is expected and when it last arrived, in unix nanoseconds.
+type streamActivity struct {
+	// expectedSince is zero while no data is expected
+	expectedSince atomic.Int64
+	lastDelivered atomic.Int64
+	// continuous streams stay expected after a recovery, others wait for the next request
+	continuous bool
+}
+
+// expect marks data as expected from now on, unless it already is.
+func (a *streamActivity) expect() {
+	a.expectedSince.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+func (a *streamActivity) stopExpecting() {
+	a.expectedSince.Store(0)
+}
+
+func (a *streamActivity) isExpected() bool {
+	return a.expectedSince.Load() != 0
+}
+
+func (a *streamActivity) deliver() {
+	a.lastDelivered.Store(time.Now().UnixNano())
+	if !a.continuous {
+		a.stopExpecting()
+	}
+}
+
+// stalledFor returns how long expected data has not arrived, 0 if none is expected.
+func (a *streamActivity) stalledFor(now time.Time) time.Duration {
+	since := a.expectedSince.Load()
+	if since == 0 {
+		return 0
+	}
+	return now.Sub(time.Unix(0, max(since, a.lastDelivered.Load())))
+}
+
+// restart gives the stream a full timeout again after a recovery attempt.
+func (a *streamActivity) restart() {
+	if !a.continuous {
+		a.stopExpecting()
+		return
+	}
+	if a.expectedSince.Load() != 0 {
+		a.expectedSince.Store(time.Now().UnixNano())
+	}
+}
+
+// streamWatchdog checks the streams periodically and runs the recovery actions of the policy on stalls.
+type streamWatchdog struct {
+	// policy is nil if disabled
+	policy *WatchdogPolicy
+
+	imu    *streamActivity
+	camera *streamActivity
+
+	// recover runs a recovery action for a stream from the watchdog goroutine
+	recover func(stream string, action RecoveryAction) error
+	// onError receives ErrStreamStalled and failed recoveries
+	onError func(error)
+
+	// waitgroup to wait for the watchdog goroutine to stop
+	waitgroup sync.WaitGroup
+	// channel to signal the watchdog to stop
+	stopChannel chan struct{}
+}
+
+func (w *streamWatchdog) start() {
+	if w.policy == nil || w.stopChannel != nil {
+		return
+	}
+
+	w.stopChannel = make(chan struct{})
+
+	w.waitgroup.Add(1)
+	go w.watchPeriodically()
+}
+
+func (w *streamWatchdog) stop() {
+	if w.stopChannel == nil {
+		return
+	}
+
+	close(w.stopChannel)
+	w.waitgroup.Wait()
+	w.stopChannel = nil
+}
+
+// watchPeriodically is a goroutine method to check the streams for stalls.
+func (w *streamWatchdog) watchPeriodically() {
+	defer w.waitgroup.Done()
+
+	ticker := time.NewTicker(watchdogCheckFrequency)
+	defer ticker.Stop()
+
+	streams := []struct {
+		name     string
+		activity *streamActivity
+		timeout  time.Duration
+		// attempts counts the recovery attempts since the stream last delivered data
+		attempts    int
+		lastAttempt time.Time
+	}{
+		{name: streamIMU, activity: w.imu, timeout: w.policy.IMUTimeout},
+		{name: streamSLAMCamera, activity: w.camera, timeout: w.policy.CameraTimeout},
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			for i := range streams {
+				stream := &streams[i]
+				if stream.timeout <= 0 {
+					continue
+				}
+				if time.Unix(0, stream.activity.lastDelivered.Load()).After(stream.lastAttempt) {
+					stream.attempts = 0
+				}
+
+				stalledFor := stream.activity.stalledFor(now)
+				if stalledFor < stream.timeout {
+					continue
+				}
+
+				action := RECOVERY_REPORT_ONLY
+				if len(w.policy.Actions) > 0 {
+					action = w.policy.Actions[min(stream.attempts, len(w.policy.Actions)-1)]
+				}
+				stream.attempts++
+				stream.lastAttempt = now
+				stream.activity.restart()
+
+				// report only once until the stream recovers when there is nothing to do about it
+				if action == RECOVERY_REPORT_ONLY && stream.attempts > 1 {
+					continue
+				}
+				w.onError(fmt.Errorf("%s: %w for %v, recovering with %s", stream.name, ErrStreamStalled, stalledFor.Round(time.Millisecond), action))
+
+				err := runRecovered("stream watchdog", func() error { return w.recover(stream.name, action) })
+				if err != nil {
+					w.onError(fmt.Errorf("%s: failed to recover with %s: %w", stream.name, action, err))
+					if stream.activity.continuous {
+						// a failed reset or reconnect leaves the stream disabled, keep watching to escalate further
+						stream.activity.expect()
+					}
+				}
+				// recovering may take a while, which must not count as stalled
+				stream.activity.restart()
+			}
+		case <-w.stopChannel:
+			return
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package device
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStreamWatchdogEscalates(t *testing.T) {
+	imu := &streamActivity{continuous: true}
+	camera := &streamActivity{}
+
+	var mutex sync.Mutex
+	actions := []RecoveryAction{}
+	stalls := 0
+	w := &streamWatchdog{
+		policy: &WatchdogPolicy{
+			IMUTimeout:    2 * watchdogCheckFrequency,
+			CameraTimeout: 2 * watchdogCheckFrequency,
+			Actions:       []RecoveryAction{RECOVERY_REENABLE, RECOVERY_RECONNECT},
+		},
+		imu:    imu,
+		camera: camera,
+		recover: func(stream string, action RecoveryAction) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if stream != streamIMU {
+				t.Errorf("recover(%s) while only the IMU is expected", stream)
+			}
+			actions = append(actions, action)
+			return nil
+		},
+		onError: func(err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if errors.Is(err, ErrStreamStalled) {
+				stalls++
+			}
+		},
+	}
+
+	imu.expect()
+	w.start()
+	time.Sleep(10 * watchdogCheckFrequency)
+	w.stop()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(actions) < 3 {
+		t.Fatalf("got %d recoveries, want at least 3: %v", len(actions), actions)
+	}
+	if actions[0] != RECOVERY_REENABLE || actions[1] != RECOVERY_RECONNECT || actions[2] != RECOVERY_RECONNECT {
+		t.Errorf("actions = %v, want reenable then reconnect repeated", actions)
+	}
+	if stalls != len(actions) {
+		t.Errorf("reported %d stalls for %d recoveries", stalls, len(actions))
+	}
+}
+
+func TestStreamActivity(t *testing.T) {
+	imu := &streamActivity{continuous: true}
+	if got := imu.stalledFor(time.Now().Add(time.Hour)); got != 0 {
+		t.Errorf("stalledFor() while not expected = %v, want 0", got)
+	}
+
+	imu.expect()
+	imu.deliver()
+	if got := imu.stalledFor(time.Now().Add(time.Second)); got < time.Second-time.Millisecond {
+		t.Errorf("stalledFor() a second after delivery = %v", got)
+	}
+
+	camera := &streamActivity{}
+	camera.expect()
+	camera.deliver()
+	if got := camera.stalledFor(time.Now().Add(time.Hour)); got != 0 {
+		t.Errorf("stalledFor() after the requested frame arrived = %v, want 0", got)
+	}
+}
+
+func TestParseRecoveryActions(t *testing.T) {
+	actions, err := ParseRecoveryActions("reenable, reset-sensors,reconnect")
+	if err != nil {
+		t.Fatalf("ParseRecoveryActions() = %v", err)
+	}
+	if len(actions) != 3 || actions[1] != RECOVERY_RESET_SENSORS {
+		t.Errorf("ParseRecoveryActions() = %v", actions)
+	}
+	if _, err := ParseRecoveryActions("reboot"); err == nil {
+		t.Error("ParseRecoveryActions(reboot) = nil, want error")
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"log/slog"
 	"strconv"
 	"time"
-	"xreal-light-xr-go/crc"
+	"xreal-light-xr-go/internal/crc"
 )
 
 type Packet struct {
@@ -15,6 +15,8 @@ type Packet struct {
 	Payload   []byte
 	Timestamp []byte
 	Message   string
+	// DeviceTimestamp is the hex timestamp reported by the glass in MCU packets, where Timestamp is when it is received
+	DeviceTimestamp []byte
 }
 
 // PacketType tells the type of the decoded Packet for Light glass communications
@@ -58,6 +60,13 @@ func (pkt *Packet) String() string {
 }
 
 func (pkt *Packet) Deserialize(data []byte) error {
+	// reports are zero padded to their full size
+	data = bytes.TrimRight(data, "\x00")
+	if len(data) == 0 {
+		pkt.Type = PACKET_TYPE_UNKNOWN
+		return fmt.Errorf("empty data")
+	}
+
 	if data[0] == 'C' {
 		// This is a CRC Error packet, e.g. "CAL CRC ERROR:20000614:200152e8"
 		pkt.Type = PACKET_TYPE_CRC_ERROR
@@ -78,7 +87,8 @@ func (pkt *Packet) Deserialize(data []byte) error {
 		}
 	}
 
-	if data[endIdx] != 0x03 {
+	// the shortest packet is "\x02:" followed by ":\x03"
+	if data[endIdx] != 0x03 || endIdx < 3 {
 		return fmt.Errorf("invalid input data not ending with 0x03: %v", data)
 	}
 
@@ -89,9 +99,13 @@ func (pkt *Packet) Deserialize(data []byte) error {
 	if len(parts) < 5 {
 		return fmt.Errorf("input date carries with insufficient information")
 	}
+	if len(parts[0]) != 1 || len(parts[1]) != 1 {
+		return fmt.Errorf("invalid command type %q or id %q", parts[0], parts[1])
+	}
 
 	pkt.Command = &Command{Type: parts[0][0], ID: parts[1][0]}
-	pkt.Payload = parts[2]
+	// the payload may contain ':' itself, only the timestamp and CRC after it are known not to
+	pkt.Payload = bytes.Join(parts[2:len(parts)-2], []byte{':'})
 
 	if pkt.Command.Type == 0x32 || pkt.Command.Type == 0x34 || pkt.Command.Type == 0x41 || pkt.Command.Type == 0x55 {
 		if pkt.Command.Type == 0x41 && pkt.Command.ID == 0x4b {
@@ -104,13 +118,15 @@ func (pkt *Packet) Deserialize(data []byte) error {
 		pkt.Type = PACKET_TYPE_COMMAND
 		pkt.Timestamp = parts[len(parts)-2]
 	} else if pkt.Command.Type == 0x35 {
-		if pkt.Command.ID == 0x4b || pkt.Command.ID == 0x4c || pkt.Command.ID == 0x4d || pkt.Command.ID == 0x50 || pkt.Command.ID == 0x53 {
+		switch pkt.Command.ID {
+		case 0x4b, 0x4c, 0x4d, 0x50, 0x52, 0x53, 0x54:
 			pkt.Type = PACKET_TYPE_MCU
-		} else {
+		default:
 			pkt.Type = PACKET_TYPE_UNKNOWN
 		}
 		pkt.Message = string(data)
 		pkt.Timestamp = getTimestampNow()
+		pkt.DeviceTimestamp = parts[len(parts)-2]
 	} else {
 		pkt.Type = PACKET_TYPE_UNKNOWN
 		pkt.Message = string(data)
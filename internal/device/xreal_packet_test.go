@@ -0,0 +1,212 @@
+package device_test
+
+import (
+	"testing"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+// report pads frame with zeros to the size of the HID reports read from the MCU.
+func report(frame string) []byte {
+	data := make([]byte, 64)
+	copy(data, frame)
+	return data
+}
+
+func TestDeserializeClassifiesPackets(t *testing.T) {
+	testCases := []struct {
+		name        string
+		data        []byte
+		packetType  device.PacketType
+		command     device.Command
+		payload     string
+		instruction device.CommandInstruction
+	}{
+		{
+			name:        "key press up",
+			data:        report("\x02:5:K:UP:18fd37a61db:f505ec70:\x03"),
+			packetType:  device.PACKET_TYPE_MCU,
+			command:     device.Command{Type: 0x35, ID: 0x4b},
+			payload:     "UP",
+			instruction: device.MCU_EVENT_KEY_PRESS,
+		},
+		{
+			name:        "key press down",
+			data:        report("\x02:5:K:DN:18fd37a61db:a5b5c13b:\x03"),
+			packetType:  device.PACKET_TYPE_MCU,
+			command:     device.Command{Type: 0x35, ID: 0x4b},
+			payload:     "DN",
+			instruction: device.MCU_EVENT_KEY_PRESS,
+		},
+		{
+			name:        "proximity away",
+			data:        report("\x02:5:P:away:18fd37a61db:63d35594:\x03"),
+			packetType:  device.PACKET_TYPE_MCU,
+			command:     device.Command{Type: 0x35, ID: 0x50},
+			payload:     "away",
+			instruction: device.MCU_EVENT_PROXIMITY,
+		},
+		{
+			name:        "proximity near",
+			data:        report("\x02:5:P:near:18fd37a61db:8bee4651:\x03"),
+			packetType:  device.PACKET_TYPE_MCU,
+			command:     device.Command{Type: 0x35, ID: 0x50},
+			payload:     "near",
+			instruction: device.MCU_EVENT_PROXIMITY,
+		},
+		{
+			name:        "ambient light",
+			data:        report("\x02:5:L:173:18fd37a61db:907fd1f6:\x03"),
+			packetType:  device.PACKET_TYPE_MCU,
+			command:     device.Command{Type: 0x35, ID: 0x4c},
+			payload:     "173",
+			instruction: device.MCU_EVENT_AMBIENT_LIGHT,
+		},
+		{
+			name:        "vsync",
+			data:        report("\x02:5:S:1:18fd37a61db:8d5dc279:\x03"),
+			packetType:  device.PACKET_TYPE_MCU,
+			command:     device.Command{Type: 0x35, ID: 0x53},
+			payload:     "1",
+			instruction: device.MCU_EVENT_VSYNC,
+		},
+		{
+			name:        "magnetometer",
+			data:        report("\x02:5:M:x-123y45z678:18fd37a61db:c4628003:\x03"),
+			packetType:  device.PACKET_TYPE_MCU,
+			command:     device.Command{Type: 0x35, ID: 0x4d},
+			payload:     "x-123y45z678",
+			instruction: device.MCU_EVENT_MAGNETOMETER,
+		},
+		{
+			name:        "temperature",
+			data:        report("\x02:5:R:36.5:18fd37a61db:fc65867c:\x03"),
+			packetType:  device.PACKET_TYPE_MCU,
+			command:     device.Command{Type: 0x35, ID: 0x52},
+			payload:     "36.5",
+			instruction: device.MCU_EVENT_TEMPERATURE_A,
+		},
+		{
+			name:       "unknown mcu event",
+			data:       report("\x02:5:Z:?:18fd37a61db:9bbd9927:\x03"),
+			packetType: device.PACKET_TYPE_UNKNOWN,
+			command:    device.Command{Type: 0x35, ID: 0x5a},
+			payload:    "?",
+		},
+		{
+			name:       "heart beat response",
+			data:       report("\x02:A:K: :18fd37a61db:2114fdfc:\x03"),
+			packetType: device.PACKET_TYPE_HEART_BEAT_RESPONSE,
+			command:    device.Command{Type: 0x41, ID: 0x4b},
+			payload:    " ",
+		},
+		{
+			name:       "0x32 response",
+			data:       report("\x02:2:L:1:18fd37a61db:04be91b9:\x03"),
+			packetType: device.PACKET_TYPE_RESPONSE,
+			command:    device.Command{Type: 0x32, ID: 0x4c},
+			payload:    "1",
+		},
+		{
+			name:       "0x34 response",
+			data:       report("\x02:4:5:V1.0:18fd37a61db:bc1c390f:\x03"),
+			packetType: device.PACKET_TYPE_RESPONSE,
+			command:    device.Command{Type: 0x34, ID: 0x35},
+			payload:    "V1.0",
+		},
+		{
+			name:       "0x41 response other than heart beat",
+			data:       report("\x02:A:3:1:18fd37a61db:76dc0cb0:\x03"),
+			packetType: device.PACKET_TYPE_RESPONSE,
+			command:    device.Command{Type: 0x41, ID: 0x33},
+			payload:    "1",
+		},
+		{
+			name:       "0x55 response",
+			data:       report("\x02:U:U:7:18fd37a61db:c1c5287b:\x03"),
+			packetType: device.PACKET_TYPE_RESPONSE,
+			command:    device.Command{Type: 0x55, ID: 0x55},
+			payload:    "7",
+		},
+		{
+			name:       "response payload containing colons",
+			data:       report("\x02:4:V:Jun 12 2021:13:45:18fd37a61db:9060dc12:\x03"),
+			packetType: device.PACKET_TYPE_RESPONSE,
+			command:    device.Command{Type: 0x34, ID: 0x56},
+			payload:    "Jun 12 2021:13:45",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			packet := &device.Packet{}
+			if err := packet.Deserialize(tc.data); err != nil {
+				t.Fatalf("Deserialize() = %v, want nil", err)
+			}
+			if packet.Type != tc.packetType {
+				t.Errorf("Type = %d, want %d", packet.Type, tc.packetType)
+			}
+			if packet.Command == nil || *packet.Command != tc.command {
+				t.Fatalf("Command = %v, want %v", packet.Command, tc.command)
+			}
+			if string(packet.Payload) != tc.payload {
+				t.Errorf("Payload = %q, want %q", packet.Payload, tc.payload)
+			}
+			if tc.instruction != 0 && !packet.Command.EqualsInstruction(tc.instruction) {
+				t.Errorf("EqualsInstruction(%v) = false, want true", tc.instruction)
+			}
+
+			switch packet.Type {
+			case device.PACKET_TYPE_RESPONSE, device.PACKET_TYPE_HEART_BEAT_RESPONSE:
+				if string(packet.Timestamp) != "18fd37a61db" {
+					t.Errorf("Timestamp = %q, want the one of the packet", packet.Timestamp)
+				}
+			case device.PACKET_TYPE_MCU, device.PACKET_TYPE_UNKNOWN:
+				if string(packet.DeviceTimestamp) != "18fd37a61db" {
+					t.Errorf("DeviceTimestamp = %q, want the one of the packet", packet.DeviceTimestamp)
+				}
+				if len(packet.Timestamp) == 0 {
+					t.Errorf("Timestamp is empty, want the time it was received")
+				}
+			}
+		})
+	}
+}
+
+func TestDeserializeCRCError(t *testing.T) {
+	packet := &device.Packet{}
+	if err := packet.Deserialize(report("CAL CRC ERROR:20000614:200152e8")); err != nil {
+		t.Fatalf("Deserialize() = %v, want nil", err)
+	}
+	if packet.Type != device.PACKET_TYPE_CRC_ERROR {
+		t.Errorf("Type = %d, want PACKET_TYPE_CRC_ERROR", packet.Type)
+	}
+	if packet.Message != "CAL CRC ERROR:20000614:200152e8" {
+		t.Errorf("Message = %q, want it without the zero padding", packet.Message)
+	}
+}
+
+func TestDeserializeRejectsMalformedData(t *testing.T) {
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", []byte{}},
+		{"zero padding only", report("")},
+		{"not starting with 0x02", report("hello")},
+		{"not ending with 0x03", report("\x02:5:K:UP:18fd37a61db:f505ec70:")},
+		{"markers only", []byte("\x02\x03")},
+		{"too few fields", report("\x02:5:K:UP:\x03")},
+		{"empty command type", report("\x02::K:UP:18fd37a61db:f505ec70:\x03")},
+		{"empty command id", report("\x02:5::UP:18fd37a61db:f505ec70:\x03")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			packet := &device.Packet{}
+			if err := packet.Deserialize(tc.data); err == nil {
+				t.Errorf("Deserialize(%q) = nil, want error", tc.data)
+			}
+		})
+	}
+}
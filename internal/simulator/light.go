@@ -0,0 +1,281 @@
+// Package simulator emulates the HID protocols of XREAL Light glasses, so the driver stack can be exercised
+// without real glasses. On Linux, StartLight exposes the emulated MCU and OV580 as virtual HID devices via uhid.
+//
+// The emulation is deliberately independent from internal/device, so it checks the driver against
+// the protocol rather than against itself.
+package simulator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"xreal-light-xr-go/internal/crc"
+)
+
+const (
+	// MCU and OV580 report sizes, same as what the driver reads and writes
+	MCU_REPORT_SIZE   = 64
+	OV580_REPORT_SIZE = 128
+
+	DEFAULT_SERIAL   = "SIMULATED0001"
+	DEFAULT_FIRMWARE = "05.5.08.059_20230518"
+
+	// imuReportInterval is roughly the IMU report rate of the glass
+	imuReportInterval = 10 * time.Millisecond
+	// calibrationChunkSize is the calibration file bytes sent per report
+	calibrationChunkSize = 56
+)
+
+// ReportSender sends an input report to the host.
+type ReportSender func(report []byte) error
+
+// LightMCU emulates the MCU command protocol `02:Type:ID:payload:timestamp:crc:03`.
+// Get commands (0x33) return what was set with the set command (0x31) of the same ID.
+type LightMCU struct {
+	send ReportSender
+
+	// mutex for thread safety
+	mutex sync.Mutex
+	// values keyed by command ID
+	values map[byte]string
+	// received keeps every command received, for tests to assert on
+	received []string
+}
+
+// NewLightMCU creates an emulated MCU reporting the given serial number.
+func NewLightMCU(serial string, send ReportSender) *LightMCU {
+	return &LightMCU{
+		send: send,
+		values: map[byte]string{
+			0x30: DEFAULT_FIRMWARE, // stock firmware version
+			0x31: "3",              // brightness level
+			0x33: "1",              // display mode
+			0x35: DEFAULT_FIRMWARE, // firmware version
+			0x43: serial,           // serial number
+			0x56: "NrealFW",        // hardcoded string
+			0x61: DEFAULT_FIRMWARE, // firmware version
+			0x62: "0",              // OLED brightness level
+			0x4d: "100",            // display duty
+		},
+	}
+}
+
+// HandleOutput processes a command report written by the host and sends back the response.
+func (m *LightMCU) HandleOutput(report []byte) {
+	commandType, commandID, payload, err := parseMCUReport(report)
+	if err != nil {
+		slog.Debug(fmt.Sprintf("simulator: ignoring MCU report: %v", err))
+		return
+	}
+
+	m.mutex.Lock()
+	var response string
+	switch commandType {
+	case 0x31, 0x40: // set
+		m.values[commandID] = payload
+		response = payload
+	case 0x33: // get
+		response = m.values[commandID]
+	case 0x54:
+		response = "0"
+	default:
+		m.mutex.Unlock()
+		slog.Debug(fmt.Sprintf("simulator: unknown MCU command type %#x", commandType))
+		return
+	}
+	if !(commandType == 0x33 && commandID == 0x56) && !(commandType == 0x40 && commandID == 0x4b) {
+		// skip polling and heart beat
+		m.received = append(m.received, fmt.Sprintf("%c:%c:%s", commandType, commandID, payload))
+	}
+	m.mutex.Unlock()
+
+	if response == "" {
+		response = " "
+	}
+	if err := m.send(buildMCUReport(commandType+1, commandID, response)); err != nil {
+		slog.Debug(fmt.Sprintf("simulator: failed to send MCU response: %v", err))
+	}
+}
+
+// SendEvent sends an MCU event (0x35), e.g. SendEvent(0x50, "away") for proximity.
+func (m *LightMCU) SendEvent(eventID byte, payload string) error {
+	return m.send(buildMCUReport(0x35, eventID, payload))
+}
+
+// Value returns the current value of the command ID.
+func (m *LightMCU) Value(commandID byte) string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.values[commandID]
+}
+
+// Received returns the commands received besides polling and heart beats, formatted as `Type:ID:payload`.
+func (m *LightMCU) Received() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]string(nil), m.received...)
+}
+
+func parseMCUReport(report []byte) (byte, byte, string, error) {
+	end := bytes.LastIndexByte(report, 0x03)
+	if len(report) < 2 || report[0] != 0x02 || end < 3 {
+		return 0, 0, "", fmt.Errorf("unrecognized report %v", report)
+	}
+	parts := bytes.Split(report[2:end-1], []byte{':'})
+	if len(parts) < 5 || len(parts[0]) != 1 || len(parts[1]) != 1 {
+		return 0, 0, "", fmt.Errorf("insufficient parts in report %s", report)
+	}
+	return parts[0][0], parts[1][0], string(parts[2]), nil
+}
+
+func buildMCUReport(commandType, commandID byte, payload string) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(0x02)
+	buf.WriteByte(':')
+	buf.WriteByte(commandType)
+	buf.WriteByte(':')
+	buf.WriteByte(commandID)
+	buf.WriteByte(':')
+	buf.WriteString(payload)
+	buf.WriteByte(':')
+	fmt.Fprintf(&buf, "%x", time.Now().UnixMilli())
+	buf.WriteByte(':')
+	fmt.Fprintf(&buf, "%08x", crc.CRC32(buf.Bytes()))
+	buf.WriteByte(':')
+	buf.WriteByte(0x03)
+
+	report := make([]byte, MCU_REPORT_SIZE)
+	copy(report, buf.Bytes())
+	return report
+}
+
+// LightOV580 emulates the OV580 calibration file download and IMU stream.
+type LightOV580 struct {
+	send ReportSender
+
+	calibration []byte
+
+	// mutex for thread safety
+	mutex sync.Mutex
+	// calibrationOffset is how much of the calibration file was sent
+	calibrationOffset int
+	// stopIMUChannel is set while the IMU stream is enabled
+	stopIMUChannel chan struct{}
+	// waitgroup to wait for the IMU stream to stop
+	waitgroup sync.WaitGroup
+}
+
+// NewLightOV580 creates an emulated OV580 serving a calibration file with zero biases.
+func NewLightOV580(send ReportSender) *LightOV580 {
+	return &LightOV580{
+		send: send,
+		calibration: []byte(`<?xml version="1.0"?><calibration simulated="true"/>` +
+			`{"IMU":{"device_1":{"accel_bias":[0.0,0.0,0.0],"gyro_bias":[0.0,0.0,0.0]}}}`),
+	}
+}
+
+// HandleOutput processes a command report `[Type, ID, value, 0, 0, 0, 0]` written by the host.
+func (o *LightOV580) HandleOutput(report []byte) {
+	if len(report) < 3 || report[0] != 0x02 {
+		slog.Debug(fmt.Sprintf("simulator: ignoring OV580 report: %v", report))
+		return
+	}
+
+	response := make([]byte, OV580_REPORT_SIZE)
+	response[0] = 0x02
+
+	switch report[1] {
+	case 0x14: // calibration file length
+		o.mutex.Lock()
+		o.calibrationOffset = 0
+		o.mutex.Unlock()
+		response[1] = 0x0
+		binary.LittleEndian.PutUint32(response[3:7], uint32(len(o.calibration)))
+	case 0x15: // calibration file part
+		o.mutex.Lock()
+		chunk := o.calibration[o.calibrationOffset:min(o.calibrationOffset+calibrationChunkSize, len(o.calibration))]
+		o.calibrationOffset += len(chunk)
+		o.mutex.Unlock()
+		if len(chunk) == 0 {
+			response[1] = 0x3
+		} else {
+			response[1] = 0x1
+			response[2] = byte(len(chunk))
+			copy(response[3:], chunk)
+		}
+	case 0x19: // IMU stream
+		o.setIMUStream(report[2] == 0x1)
+		response[1] = 0x4
+	default:
+		slog.Debug(fmt.Sprintf("simulator: unknown OV580 command %#x", report[1]))
+		return
+	}
+
+	if err := o.send(response); err != nil {
+		slog.Debug(fmt.Sprintf("simulator: failed to send OV580 response: %v", err))
+	}
+}
+
+func (o *LightOV580) setIMUStream(enabled bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if enabled && o.stopIMUChannel == nil {
+		o.stopIMUChannel = make(chan struct{})
+		o.waitgroup.Add(1)
+		go o.streamIMU(o.stopIMUChannel)
+	} else if !enabled && o.stopIMUChannel != nil {
+		close(o.stopIMUChannel)
+		o.stopIMUChannel = nil
+	}
+}
+
+// Stop stops the IMU stream if enabled.
+func (o *LightOV580) Stop() {
+	o.setIMUStream(false)
+	o.waitgroup.Wait()
+}
+
+// streamIMU is a goroutine method sending IMU reports of a glass lying still, i.e. only gravity on the accelerometer.
+func (o *LightOV580) streamIMU(stop chan struct{}) {
+	defer o.waitgroup.Done()
+
+	ticker := time.NewTicker(imuReportInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for {
+		select {
+		case <-ticker.C:
+			if err := o.send(buildIMUReport(uint64(time.Since(start).Nanoseconds()))); err != nil {
+				slog.Debug(fmt.Sprintf("simulator: failed to send IMU report: %v", err))
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func buildIMUReport(timestamp uint64) []byte {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, 0x2a))
+	buf.Bytes()[0] = 0x1
+
+	// multiplier / divisor scale raw values to deg/s for the gyroscope and g for the accelerometer
+	const divisor = 1000
+	binary.Write(&buf, binary.LittleEndian, uint16(0)) // temperature
+	binary.Write(&buf, binary.LittleEndian, timestamp)
+	binary.Write(&buf, binary.LittleEndian, []uint32{1, divisor})
+	binary.Write(&buf, binary.LittleEndian, []int32{0, 0, 0})
+	binary.Write(&buf, binary.LittleEndian, timestamp)
+	binary.Write(&buf, binary.LittleEndian, []uint32{1, divisor})
+	binary.Write(&buf, binary.LittleEndian, []int32{0, -divisor, 0})
+
+	report := make([]byte, OV580_REPORT_SIZE)
+	copy(report, buf.Bytes())
+	return report
+}
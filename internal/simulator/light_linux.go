@@ -0,0 +1,75 @@
+//go:build linux
+
+package simulator
+
+import (
+	"errors"
+	"fmt"
+
+	"xreal-light-xr-go/internal/uhid"
+)
+
+// Same as the XREAL Light HID devices, repeated here to keep the emulation independent from internal/device.
+const (
+	lightMCUVendorID    = 0x0486
+	lightMCUProductID   = 0x573c
+	lightOV580VendorID  = 0x05a9
+	lightOV580ProductID = 0x0680
+)
+
+// Light is an emulated XREAL Light exposed as virtual MCU and OV580 HID devices.
+// Note that the cameras are not emulated, so only the MCU and OV580 parts of the driver can connect to it.
+type Light struct {
+	MCU   *LightMCU
+	OV580 *LightOV580
+
+	mcuDevice   *uhid.Device
+	ov580Device *uhid.Device
+}
+
+// StartLight creates the virtual HID devices of an XREAL Light, it needs access to /dev/uhid.
+func StartLight(serial string) (*Light, error) {
+	l := &Light{}
+
+	l.MCU = NewLightMCU(serial, func(report []byte) error { return l.mcuDevice.Input(report) })
+	l.OV580 = NewLightOV580(func(report []byte) error { return l.ov580Device.Input(report) })
+
+	var err error
+	l.mcuDevice, err = uhid.Create(uhid.Config{
+		Name:             "Simulated XREAL Light MCU",
+		Serial:           serial,
+		VendorID:         lightMCUVendorID,
+		ProductID:        lightMCUProductID,
+		ReportDescriptor: uhid.VendorReportDescriptor(MCU_REPORT_SIZE, MCU_REPORT_SIZE),
+	}, l.MCU.HandleOutput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MCU: %w", err)
+	}
+
+	l.ov580Device, err = uhid.Create(uhid.Config{
+		Name:             "Simulated XREAL Light OV580",
+		VendorID:         lightOV580VendorID,
+		ProductID:        lightOV580ProductID,
+		ReportDescriptor: uhid.VendorReportDescriptor(OV580_REPORT_SIZE, MCU_REPORT_SIZE),
+	}, l.OV580.HandleOutput)
+	if err != nil {
+		l.mcuDevice.Destroy()
+		return nil, fmt.Errorf("failed to create OV580: %w", err)
+	}
+
+	return l, nil
+}
+
+// Stop removes the virtual HID devices.
+func (l *Light) Stop() error {
+	l.OV580.Stop()
+	errMCU := l.mcuDevice.Destroy()
+	errOV580 := l.ov580Device.Destroy()
+	if errMCU != nil {
+		errMCU = fmt.Errorf("mcu: %w", errMCU)
+	}
+	if errOV580 != nil {
+		errOV580 = fmt.Errorf("ov580: %w", errOV580)
+	}
+	return errors.Join(errMCU, errOV580)
+}
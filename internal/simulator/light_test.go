@@ -0,0 +1,66 @@
+package simulator_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"xreal-light-xr-go/internal/crc"
+	"xreal-light-xr-go/internal/simulator"
+)
+
+func mcuCommand(commandType, commandID byte, payload string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "\x02:%c:%c:%s:18f6a1c2b3d:", commandType, commandID, payload)
+	fmt.Fprintf(&buf, "%08x:\x03", crc.CRC32(buf.Bytes()))
+	return buf.Bytes()
+}
+
+func TestLightMCU(t *testing.T) {
+	var responses [][]byte
+	mcu := simulator.NewLightMCU(simulator.DEFAULT_SERIAL, func(report []byte) error {
+		responses = append(responses, report)
+		return nil
+	})
+
+	mcu.HandleOutput(mcuCommand('1', '1', "5")) // set brightness level
+	mcu.HandleOutput(mcuCommand('3', '1', " ")) // get brightness level
+	mcu.HandleOutput(mcuCommand('3', 'C', " ")) // get serial number
+
+	expected := []string{"\x02:2:1:5:", "\x02:4:1:5:", "\x02:4:C:" + simulator.DEFAULT_SERIAL + ":"}
+	if len(responses) != len(expected) {
+		t.Fatalf("got %d responses; expected %d", len(responses), len(expected))
+	}
+	for i, prefix := range expected {
+		if len(responses[i]) != simulator.MCU_REPORT_SIZE || !bytes.HasPrefix(responses[i], []byte(prefix)) {
+			t.Errorf("response %d = %q; expected prefix %q", i, responses[i], prefix)
+		}
+	}
+
+	if got := mcu.Received(); len(got) != 3 || got[0] != "1:1:5" {
+		t.Errorf("Received() = %v; expected 3 commands starting with 1:1:5", got)
+	}
+}
+
+func TestLightOV580CalibrationFile(t *testing.T) {
+	var responses [][]byte
+	ov580 := simulator.NewLightOV580(func(report []byte) error {
+		responses = append(responses, report)
+		return nil
+	})
+
+	ov580.HandleOutput([]byte{0x02, 0x14, 0x01, 0, 0, 0, 0})
+	var file []byte
+	for i := 0; i < 100; i++ {
+		ov580.HandleOutput([]byte{0x02, 0x15, 0x01, 0, 0, 0, 0})
+		response := responses[len(responses)-1]
+		if response[1] == 0x3 {
+			break
+		}
+		file = append(file, response[3:3+response[2]]...)
+	}
+
+	if !bytes.Contains(file, []byte(`"accel_bias"`)) || !bytes.HasPrefix(file, []byte("<")) {
+		t.Errorf("got calibration file %q", file)
+	}
+}
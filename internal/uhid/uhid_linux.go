@@ -0,0 +1,277 @@
+//go:build linux
+
+// Package uhid creates virtual HID devices through the Linux /dev/uhid interface, so hidapi sees them
+// exactly like devices attached over USB. It needs read/write access to /dev/uhid, usually root.
+package uhid
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+const uhidPath = "/dev/uhid"
+
+// Event types from linux/uhid.h.
+const (
+	uhidDestroy        = 1
+	uhidStart          = 2
+	uhidStop           = 3
+	uhidOpen           = 4
+	uhidClose          = 5
+	uhidOutput         = 6
+	uhidGetReport      = 9
+	uhidGetReportReply = 10
+	uhidCreate2        = 11
+	uhidInput2         = 12
+	uhidSetReport      = 13
+	uhidSetReportReply = 14
+)
+
+const (
+	// uhidDataMax is UHID_DATA_MAX, the max size of a report descriptor or a report
+	uhidDataMax = 4096
+	// uhidEventSize is sizeof(struct uhid_event), the packed union is sized by uhid_create2_req
+	uhidEventSize = 4 + 128 + 64 + 64 + 2 + 2 + 4 + 4 + 4 + 4 + uhidDataMax
+
+	busUSB = 0x03
+)
+
+// OutputHandler is called with every output report written to the device by the host, e.g. via hid_write.
+type OutputHandler func(data []byte)
+
+// Config describes the virtual HID device.
+type Config struct {
+	Name string
+	// Serial is reported as the serial number of the HID device
+	Serial    string
+	VendorID  uint16
+	ProductID uint16
+	// ReportDescriptor is the HID report descriptor, see VendorReportDescriptor
+	ReportDescriptor []byte
+}
+
+// Device is a virtual HID device backed by /dev/uhid.
+type Device struct {
+	file *os.File
+
+	outputHandler OutputHandler
+
+	// mutex to serialize writes to /dev/uhid
+	mutex sync.Mutex
+	// waitgroup to wait for the event reading goroutine to stop
+	waitgroup sync.WaitGroup
+}
+
+// VendorReportDescriptor builds a report descriptor of a vendor defined device without report IDs,
+// carrying input and output reports of the given sizes in bytes.
+func VendorReportDescriptor(inputSize, outputSize uint8) []byte {
+	return []byte{
+		0x06, 0x00, 0xff, // Usage Page (Vendor Defined 0xFF00)
+		0x09, 0x01, // Usage (0x01)
+		0xa1, 0x01, // Collection (Application)
+		0x15, 0x00, //   Logical Minimum (0)
+		0x26, 0xff, 0x00, //   Logical Maximum (255)
+		0x75, 0x08, //   Report Size (8)
+		0x95, inputSize, //   Report Count
+		0x09, 0x01, //   Usage (0x01)
+		0x81, 0x02, //   Input (Data,Var,Abs)
+		0x95, outputSize, //   Report Count
+		0x09, 0x01, //   Usage (0x01)
+		0x91, 0x02, //   Output (Data,Var,Abs)
+		0xc0, // End Collection
+	}
+}
+
+// RelativeMouseReportDescriptor builds a report descriptor of a mouse without report IDs, whose input report is
+// a button byte (bits 0-2) followed by the relative X and Y motion as little endian int16.
+func RelativeMouseReportDescriptor() []byte {
+	return []byte{
+		0x05, 0x01, // Usage Page (Generic Desktop)
+		0x09, 0x02, // Usage (Mouse)
+		0xa1, 0x01, // Collection (Application)
+		0x09, 0x01, //   Usage (Pointer)
+		0xa1, 0x00, //   Collection (Physical)
+		0x05, 0x09, //     Usage Page (Button)
+		0x19, 0x01, //     Usage Minimum (1)
+		0x29, 0x03, //     Usage Maximum (3)
+		0x15, 0x00, //     Logical Minimum (0)
+		0x25, 0x01, //     Logical Maximum (1)
+		0x75, 0x01, //     Report Size (1)
+		0x95, 0x03, //     Report Count (3)
+		0x81, 0x02, //     Input (Data,Var,Abs)
+		0x75, 0x05, //     Report Size (5)
+		0x95, 0x01, //     Report Count (1)
+		0x81, 0x03, //     Input (Const,Var,Abs)
+		0x05, 0x01, //     Usage Page (Generic Desktop)
+		0x09, 0x30, //     Usage (X)
+		0x09, 0x31, //     Usage (Y)
+		0x16, 0x01, 0x80, //     Logical Minimum (-32767)
+		0x26, 0xff, 0x7f, //     Logical Maximum (32767)
+		0x75, 0x10, //     Report Size (16)
+		0x95, 0x02, //     Report Count (2)
+		0x81, 0x06, //     Input (Data,Var,Rel)
+		0xc0, //   End Collection
+		0xc0, // End Collection
+	}
+}
+
+// GamepadReportDescriptor builds a report descriptor of a gamepad without report IDs, whose input report is
+// 16 buttons as a little endian bit field, button 1 in bit 0.
+func GamepadReportDescriptor() []byte {
+	return []byte{
+		0x05, 0x01, // Usage Page (Generic Desktop)
+		0x09, 0x05, // Usage (Gamepad)
+		0xa1, 0x01, // Collection (Application)
+		0x05, 0x09, //   Usage Page (Button)
+		0x19, 0x01, //   Usage Minimum (1)
+		0x29, 0x10, //   Usage Maximum (16)
+		0x15, 0x00, //   Logical Minimum (0)
+		0x25, 0x01, //   Logical Maximum (1)
+		0x75, 0x01, //   Report Size (1)
+		0x95, 0x10, //   Report Count (16)
+		0x81, 0x02, //   Input (Data,Var,Abs)
+		0xc0, // End Collection
+	}
+}
+
+// Create registers a virtual HID device with the kernel, output reports from the host are passed to handler.
+func Create(config Config, handler OutputHandler) (*Device, error) {
+	if len(config.ReportDescriptor) > uhidDataMax {
+		return nil, fmt.Errorf("report descriptor too long: %d", len(config.ReportDescriptor))
+	}
+
+	file, err := os.OpenFile(uhidPath, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", uhidPath, err)
+	}
+
+	d := &Device{file: file, outputHandler: handler}
+
+	var name [128]byte
+	var phys, uniq [64]byte
+	copy(name[:], config.Name)
+	copy(phys[:], "uhid/"+config.Name)
+	copy(uniq[:], config.Serial)
+
+	var event bytes.Buffer
+	binary.Write(&event, binary.NativeEndian, uint32(uhidCreate2))
+	event.Write(name[:])
+	event.Write(phys[:])
+	event.Write(uniq[:])
+	binary.Write(&event, binary.NativeEndian, uint16(len(config.ReportDescriptor)))
+	binary.Write(&event, binary.NativeEndian, uint16(busUSB))
+	binary.Write(&event, binary.NativeEndian, uint32(config.VendorID))
+	binary.Write(&event, binary.NativeEndian, uint32(config.ProductID))
+	binary.Write(&event, binary.NativeEndian, uint32(0)) // version
+	binary.Write(&event, binary.NativeEndian, uint32(0)) // country
+	event.Write(config.ReportDescriptor)
+
+	if err := d.writeEvent(event.Bytes()); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to create %s: %w", config.Name, err)
+	}
+
+	d.waitgroup.Add(1)
+	go d.readEvents()
+
+	return d, nil
+}
+
+// Input sends an input report to the host, which is then returned by e.g. hid_read.
+func (d *Device) Input(data []byte) error {
+	if len(data) > uhidDataMax {
+		return fmt.Errorf("input report too long: %d", len(data))
+	}
+
+	var event bytes.Buffer
+	binary.Write(&event, binary.NativeEndian, uint32(uhidInput2))
+	binary.Write(&event, binary.NativeEndian, uint16(len(data)))
+	event.Write(data)
+
+	return d.writeEvent(event.Bytes())
+}
+
+// Destroy removes the virtual HID device.
+func (d *Device) Destroy() error {
+	var event bytes.Buffer
+	binary.Write(&event, binary.NativeEndian, uint32(uhidDestroy))
+	err := d.writeEvent(event.Bytes())
+
+	// closing the file also destroys the device in case the event failed, and unblocks readEvents
+	if closeErr := d.file.Close(); err == nil {
+		err = closeErr
+	}
+	d.waitgroup.Wait()
+	return err
+}
+
+func (d *Device) writeEvent(event []byte) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var buffer [uhidEventSize]byte
+	copy(buffer[:], event)
+	if _, err := d.file.Write(buffer[:]); err != nil {
+		return fmt.Errorf("failed to write to %s: %w", uhidPath, err)
+	}
+	return nil
+}
+
+// readEvents is a goroutine method to handle events from the kernel until the device is destroyed.
+func (d *Device) readEvents() {
+	defer d.waitgroup.Done()
+
+	var buffer [uhidEventSize]byte
+	for {
+		n, err := d.file.Read(buffer[:])
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) && !errors.Is(err, io.EOF) {
+				slog.Debug(fmt.Sprintf("failed to read from %s: %v", uhidPath, err))
+			}
+			return
+		}
+		if n < 4 {
+			continue
+		}
+
+		eventType := binary.NativeEndian.Uint32(buffer[0:4])
+		payload := buffer[4:n]
+
+		switch eventType {
+		case uhidOutput:
+			// struct uhid_output_req { data[UHID_DATA_MAX]; size u16; rtype u8 }
+			size := int(binary.NativeEndian.Uint16(payload[uhidDataMax : uhidDataMax+2]))
+			if d.outputHandler != nil {
+				d.outputHandler(append([]byte(nil), payload[:size]...))
+			}
+		case uhidGetReport:
+			// no feature reports are emulated, reply with EIO so the host does not wait for the timeout
+			id := binary.NativeEndian.Uint32(payload[0:4])
+			d.replyReport(uhidGetReportReply, id)
+		case uhidSetReport:
+			id := binary.NativeEndian.Uint32(payload[0:4])
+			d.replyReport(uhidSetReportReply, id)
+		case uhidStart, uhidStop, uhidOpen, uhidClose:
+		default:
+			slog.Debug(fmt.Sprintf("unhandled uhid event type %d", eventType))
+		}
+	}
+}
+
+func (d *Device) replyReport(eventType uint32, id uint32) {
+	const eio = 5
+
+	var event bytes.Buffer
+	binary.Write(&event, binary.NativeEndian, eventType)
+	binary.Write(&event, binary.NativeEndian, id)
+	binary.Write(&event, binary.NativeEndian, uint16(eio))
+	if err := d.writeEvent(event.Bytes()); err != nil {
+		slog.Debug(fmt.Sprintf("failed to reply report request: %v", err))
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/internal/device"
+	"xreal-light-xr-go/logging"
+	"xreal-light-xr-go/storage"
+)
+
+const defaultLatencyDuration = 10 * time.Second
+
+// handleLatencyCommand records IMU and VSync events for a while and estimates the motion-to-photon latency,
+// optionally writing the report to a file, which may be on network storage, see storage.Open. Use
+// 'latency <seconds> <optional:path>'.
+func handleLatencyCommand(d device.Device, input string) {
+	parts := strings.Fields(input)
+	duration := defaultLatencyDuration
+	if len(parts) > 1 {
+		seconds, err := time.ParseDuration(parts[1] + "s")
+		if err != nil || seconds <= 0 {
+			slog.Error(fmt.Sprintf("invalid duration %s, use 'latency <seconds> <optional:path>'", parts[1]))
+			return
+		}
+		duration = seconds
+	}
+
+	meter := fusion.NewLatencyMeter(fusion.DEFAULT_MOTION_THRESHOLD)
+	d.SetIMUEventHandler(meter.AddIMU)
+	d.SetVSyncEventHandler(meter.AddVSync)
+	defer func() {
+		// back to logging events like a newly connected glass does
+		d.SetIMUEventHandler(func(imu *device.IMUEvent) {
+			slog.Info(fmt.Sprintf("IMU: %s", imu.String()), logging.MODULE_KEY, logging.MODULE_IMU)
+		})
+		d.SetVSyncEventHandler(func(value string) {
+			slog.Info(fmt.Sprintf("VSync: %s", value))
+		})
+	}()
+
+	if err := d.EnableIMU(true); err != nil {
+		slog.Error(fmt.Sprintf("failed to enable IMU stream: %v", err))
+		return
+	}
+	defer d.EnableIMU(false)
+	if err := d.EnableVSync(true); err != nil {
+		slog.Error(fmt.Sprintf("failed to enable VSync reporting: %v", err))
+		return
+	}
+	defer d.EnableVSync(false)
+
+	slog.Info(fmt.Sprintf("measuring latency for %v, turn your head left and right a few times with pauses in between", duration))
+	time.Sleep(duration)
+
+	report, err := meter.Report()
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to estimate latency: %v", err))
+		return
+	}
+	slog.Info(fmt.Sprintf("latency:\n%s", report.String()))
+
+	if len(parts) > 2 {
+		w, location, err := storage.Create(strings.Join(parts[2:], " "))
+		if err == nil {
+			_, err = io.WriteString(w, report.String())
+			if closeErr := w.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			slog.Error(fmt.Sprintf("failed to write latency report: %v", err))
+			return
+		}
+		slog.Info(fmt.Sprintf("latency report written to %s", location))
+	}
+}
@@ -0,0 +1,220 @@
+// Package logging sets up the slog default logger the driver logs to, as text or JSON, to stderr or a file, with
+// levels per module, e.g. to keep the IMU quiet while debugging the protocol.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	FORMAT_TEXT = "text"
+	FORMAT_JSON = "json"
+
+	// MODULE_PROTOCOL covers the MCU and OV580 packets, MODULE_IMU the IMU, magnetometer and fusion, MODULE_CAMERA the
+	// SLAM and RGB cameras
+	MODULE_PROTOCOL = "protocol"
+	MODULE_IMU      = "imu"
+	MODULE_CAMERA   = "camera"
+
+	// MODULE_KEY is the attribute naming the module of a record, e.g. slog.Info(msg, MODULE_KEY, MODULE_IMU), records
+	// without it are assigned by the source file they are logged from
+	MODULE_KEY = "module"
+)
+
+var MODULES = []string{MODULE_PROTOCOL, MODULE_IMU, MODULE_CAMERA}
+
+// sourceModules assigns the records logged from a source file, keyed by its folder and name, or its folder for a
+// whole package.
+var sourceModules = map[string]string{
+	"device/air_mcu.go":        MODULE_PROTOCOL,
+	"device/command_future.go": MODULE_PROTOCOL,
+	"device/command_stats.go":  MODULE_PROTOCOL,
+	"device/light_command.go":  MODULE_PROTOCOL,
+	"device/light_mcu.go":      MODULE_PROTOCOL,
+	"device/light_ov580.go":    MODULE_PROTOCOL,
+	"device/response_queue.go": MODULE_PROTOCOL,
+	"device/retry.go":          MODULE_PROTOCOL,
+	"device/xreal_packet.go":   MODULE_PROTOCOL,
+
+	"device/imu_bus.go":      MODULE_IMU,
+	"device/magnetometer.go": MODULE_IMU,
+	"fusion":                 MODULE_IMU,
+
+	"device/encoder.go":             MODULE_CAMERA,
+	"device/encoder_turbojpeg.go":   MODULE_CAMERA,
+	"device/light_camera_driver.go": MODULE_CAMERA,
+	"device/light_camera_stream.go": MODULE_CAMERA,
+	"device/light_cameras.go":       MODULE_CAMERA,
+	"camstream":                     MODULE_CAMERA,
+}
+
+// Options configures the logger, the zero value logs info and above as text to stderr.
+type Options struct {
+	// Format is FORMAT_TEXT or FORMAT_JSON, text if empty
+	Format string
+	// Path is the file logs are appended to, stderr if empty
+	Path  string
+	Level slog.Level
+	// ModuleLevels overrides Level for the records of a module, e.g. {MODULE_IMU: slog.LevelWarn}
+	ModuleLevels map[string]slog.Level
+}
+
+// ParseModuleLevels parses comma separated module=level pairs, e.g. "imu=warn,protocol=debug".
+func ParseModuleLevels(s string) (map[string]slog.Level, error) {
+	levels := map[string]slog.Level{}
+	if strings.TrimSpace(s) == "" {
+		return levels, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		module, name, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid module level %s: want module=level", pair)
+		}
+		known := false
+		for _, m := range MODULES {
+			known = known || m == module
+		}
+		if !known {
+			return nil, fmt.Errorf("invalid module %s: want one of %s", module, strings.Join(MODULES, ", "))
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(name)); err != nil {
+			return nil, fmt.Errorf("invalid level of module %s: %w", module, err)
+		}
+		levels[module] = level
+	}
+	return levels, nil
+}
+
+// NewHandler creates a handler writing to w in the format of the options, filtering records by their module level.
+func NewHandler(w io.Writer, options Options) (slog.Handler, error) {
+	minLevel := options.Level
+	for _, level := range options.ModuleLevels {
+		minLevel = min(minLevel, level)
+	}
+	handlerOptions := &slog.HandlerOptions{Level: minLevel}
+
+	var handler slog.Handler
+	switch options.Format {
+	case FORMAT_TEXT, "":
+		handler = slog.NewTextHandler(w, handlerOptions)
+	case FORMAT_JSON:
+		handler = slog.NewJSONHandler(w, handlerOptions)
+	default:
+		return nil, fmt.Errorf("invalid log format %s: want %s or %s", options.Format, FORMAT_TEXT, FORMAT_JSON)
+	}
+	return &moduleHandler{handler: handler, level: options.Level, moduleLevels: options.ModuleLevels, minLevel: minLevel}, nil
+}
+
+// Setup makes the handler of the options the slog default, which the log package also writes to. The returned
+// closer closes the log file, if any.
+func Setup(options Options) (io.Closer, error) {
+	var w io.WriteCloser = nopCloser{os.Stderr}
+	if options.Path != "" {
+		file, err := os.OpenFile(options.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		w = file
+	}
+
+	handler, err := NewHandler(w, options)
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	slog.SetDefault(slog.New(handler))
+	return w, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// moduleHandler drops the records below the level of their module and adds the module to the others.
+type moduleHandler struct {
+	handler      slog.Handler
+	level        slog.Level
+	moduleLevels map[string]slog.Level
+	// minLevel is the lowest of all levels, as Enabled does not know the module yet
+	minLevel slog.Level
+	// module is set by WithAttrs
+	module string
+}
+
+func (h *moduleHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.minLevel
+}
+
+func (h *moduleHandler) Handle(ctx context.Context, record slog.Record) error {
+	module := h.module
+	if module == "" {
+		record.Attrs(func(attr slog.Attr) bool {
+			if attr.Key == MODULE_KEY {
+				module = attr.Value.String()
+				return false
+			}
+			return true
+		})
+		if module == "" {
+			if module = moduleOf(record.PC); module != "" {
+				record = record.Clone()
+				record.AddAttrs(slog.String(MODULE_KEY, module))
+			}
+		}
+	}
+
+	level := h.level
+	if moduleLevel, ok := h.moduleLevels[module]; ok {
+		level = moduleLevel
+	}
+	if record.Level < level {
+		return nil
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *moduleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.handler = h.handler.WithAttrs(attrs)
+	for _, attr := range attrs {
+		if attr.Key == MODULE_KEY {
+			clone.module = attr.Value.String()
+		}
+	}
+	return &clone
+}
+
+func (h *moduleHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.handler = h.handler.WithGroup(name)
+	return &clone
+}
+
+// moduleOf returns the module of the source file of the program counter, empty if it belongs to none.
+func moduleOf(pc uintptr) string {
+	if pc == 0 {
+		return ""
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return moduleOfFile(frame.File)
+}
+
+func moduleOfFile(file string) string {
+	folder := filepath.Base(filepath.Dir(file))
+	if module, ok := sourceModules[folder+"/"+filepath.Base(file)]; ok {
+		return module
+	}
+	return sourceModules[folder]
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestModuleLevels(t *testing.T) {
+	levels, err := ParseModuleLevels("imu=warn, protocol=debug")
+	if err != nil {
+		t.Fatalf("ParseModuleLevels() failed: %v", err)
+	}
+	if _, err := ParseModuleLevels("display=debug"); err == nil {
+		t.Errorf("ParseModuleLevels() of an unknown module succeeded, want error")
+	}
+
+	var buffer bytes.Buffer
+	handler, err := NewHandler(&buffer, Options{Format: FORMAT_JSON, Level: slog.LevelInfo, ModuleLevels: levels})
+	if err != nil {
+		t.Fatalf("NewHandler() failed: %v", err)
+	}
+	logger := slog.New(handler)
+	logger.Info("imu sample", MODULE_KEY, MODULE_IMU)
+	logger.Warn("imu stalled", MODULE_KEY, MODULE_IMU)
+	logger.Debug("packet", MODULE_KEY, MODULE_PROTOCOL)
+	logger.Debug("other")
+	logger.With(MODULE_KEY, MODULE_CAMERA).Info("frame")
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("failed to parse %s: %v", line, err)
+		}
+		messages = append(messages, record["msg"].(string))
+	}
+	if want := "imu stalled,packet,frame"; strings.Join(messages, ",") != want {
+		t.Errorf("logged %v, want %s", messages, want)
+	}
+}
+
+func TestSourceModules(t *testing.T) {
+	for source := range sourceModules {
+		folder, file, _ := strings.Cut(source, "/")
+		path := filepath.Join("..", folder)
+		if folder == "device" {
+			path = filepath.Join("..", "internal", folder)
+		}
+		if _, err := os.Stat(filepath.Join(path, file)); err != nil {
+			t.Errorf("%s is assigned a module but does not exist: %v", source, err)
+		}
+	}
+	if got := moduleOfFile("/src/internal/device/light_mcu.go"); got != MODULE_PROTOCOL {
+		t.Errorf("moduleOfFile(light_mcu.go) = %s, want %s", got, MODULE_PROTOCOL)
+	}
+	if got := moduleOfFile("/src/fusion/compass.go"); got != MODULE_IMU {
+		t.Errorf("moduleOfFile(compass.go) = %s, want %s", got, MODULE_IMU)
+	}
+}
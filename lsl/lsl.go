@@ -0,0 +1,173 @@
+// Package lsl publishes glass sensors and events as Lab Streaming Layer (LSL) outlets, so they can be recorded
+// in sync with other devices, e.g. with LabRecorder. The outlets need liblsl and are only available when built
+// with `-tags lsl`, otherwise NewPublisher returns ErrNotAvailable.
+package lsl
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+// ErrNotAvailable is returned when built without `-tags lsl`.
+var ErrNotAvailable = errors.New("LSL support not built in, rebuild with `-tags lsl` and liblsl installed")
+
+// Channel describes one channel of a stream in the LSL channel metadata convention.
+type Channel struct {
+	Label string
+	Unit  string
+	Type  string
+}
+
+// StreamConfig describes an LSL stream.
+type StreamConfig struct {
+	Name string
+	// Type is the LSL content type, e.g. "Markers"
+	Type     string
+	Channels []Channel
+	// Strings is true for string samples, otherwise samples are float64
+	Strings bool
+}
+
+const manufacturer = "XREAL"
+
+// IMU_STREAM carries the accelerometer and gyroscope of IMUEvent, timestamped by the glass.
+var IMU_STREAM = StreamConfig{
+	Name: "XREAL IMU",
+	Type: "IMU",
+	Channels: []Channel{
+		{Label: "AccX", Unit: "m/s^2", Type: "Acc"},
+		{Label: "AccY", Unit: "m/s^2", Type: "Acc"},
+		{Label: "AccZ", Unit: "m/s^2", Type: "Acc"},
+		{Label: "GyroX", Unit: "rad/s", Type: "Gyro"},
+		{Label: "GyroY", Unit: "rad/s", Type: "Gyro"},
+		{Label: "GyroZ", Unit: "rad/s", Type: "Gyro"},
+	},
+}
+
+// MAGNETOMETER_STREAM carries MagnetometerVector, timestamped on arrival at the host.
+var MAGNETOMETER_STREAM = StreamConfig{
+	Name: "XREAL Magnetometer",
+	Type: "Mag",
+	Channels: []Channel{
+		{Label: "MagX", Unit: "raw", Type: "Mag"},
+		{Label: "MagY", Unit: "raw", Type: "Mag"},
+		{Label: "MagZ", Unit: "raw", Type: "Mag"},
+	},
+}
+
+// MARKER_STREAM carries events like key presses and proximity changes as strings, e.g. "key:UP".
+var MARKER_STREAM = StreamConfig{
+	Name:     "XREAL Markers",
+	Type:     "Markers",
+	Channels: []Channel{{Label: "Marker", Type: "Marker"}},
+	Strings:  true,
+}
+
+// outlet is implemented by the liblsl binding in outlet_lsl.go.
+type outlet interface {
+	pushSample(sample []float64, timestamp float64) error
+	pushMarker(marker string, timestamp float64) error
+	close()
+}
+
+// Publisher pushes glass events to the IMU, magnetometer and marker outlets.
+type Publisher struct {
+	imu          outlet
+	magnetometer outlet
+	markers      outlet
+
+	// mutex for thread safety of the clock
+	mutex sync.Mutex
+	clock clockMapper
+}
+
+// NewPublisher creates the outlets. sourceID should be unique per glass, e.g. its serial number,
+// so recordings can be resumed when the publisher restarts.
+func NewPublisher(sourceID string) (*Publisher, error) {
+	p := &Publisher{}
+	var err error
+	if p.imu, err = newOutlet(IMU_STREAM, sourceID+"-imu"); err != nil {
+		return nil, fmt.Errorf("failed to create IMU outlet: %w", err)
+	}
+	if p.magnetometer, err = newOutlet(MAGNETOMETER_STREAM, sourceID+"-magnetometer"); err != nil {
+		p.imu.close()
+		return nil, fmt.Errorf("failed to create magnetometer outlet: %w", err)
+	}
+	if p.markers, err = newOutlet(MARKER_STREAM, sourceID+"-markers"); err != nil {
+		p.imu.close()
+		p.magnetometer.close()
+		return nil, fmt.Errorf("failed to create marker outlet: %w", err)
+	}
+	return p, nil
+}
+
+// PushIMU can be used as IMUEventHandler.
+func (p *Publisher) PushIMU(event *device.IMUEvent) {
+	if event == nil || event.Accelerometer == nil || event.Gyroscope == nil {
+		return
+	}
+	p.mutex.Lock()
+	timestamp := p.clock.toLocal(event.TimeSinceBoot, localClock())
+	p.mutex.Unlock()
+
+	sample := []float64{
+		float64(event.Accelerometer.X), float64(event.Accelerometer.Y), float64(event.Accelerometer.Z),
+		float64(event.Gyroscope.X), float64(event.Gyroscope.Y), float64(event.Gyroscope.Z),
+	}
+	if err := p.imu.pushSample(sample, timestamp); err != nil {
+		p.logPushError(err)
+	}
+}
+
+// PushMagnetometer can be used as MagnetometerEventHandler.
+func (p *Publisher) PushMagnetometer(vector *device.MagnetometerVector) {
+	if vector == nil {
+		return
+	}
+	if err := p.magnetometer.pushSample([]float64{vector.X, vector.Y, vector.Z}, localClock()); err != nil {
+		p.logPushError(err)
+	}
+}
+
+// PushMarker pushes a marker, e.g. PushMarker("key:" + key.String()).
+func (p *Publisher) PushMarker(marker string) {
+	if err := p.markers.pushMarker(marker, localClock()); err != nil {
+		p.logPushError(err)
+	}
+}
+
+func (p *Publisher) Close() {
+	p.imu.close()
+	p.magnetometer.close()
+	p.markers.close()
+}
+
+func (p *Publisher) logPushError(err error) {
+	slog.Debug(fmt.Sprintf("lsl: %v", err))
+}
+
+// clockMapper maps the glass clock (ms since boot) onto the LSL local clock (seconds). The offset is the smallest
+// host minus glass time seen, as that is the sample with the least transport delay, so timestamps keep the glass
+// spacing without the host read jitter.
+type clockMapper struct {
+	offset      float64
+	initialized bool
+	// lastGlassMs detects the glass restarting, which resets its clock
+	lastGlassMs uint64
+}
+
+// toLocal converts the glass time to the local clock, given the local clock now.
+func (c *clockMapper) toLocal(glassMs uint64, now float64) float64 {
+	glassSeconds := float64(glassMs) / 1000
+	offset := now - glassSeconds
+	if !c.initialized || glassMs < c.lastGlassMs || offset < c.offset {
+		c.offset = offset
+		c.initialized = true
+	}
+	c.lastGlassMs = glassMs
+	return glassSeconds + c.offset
+}
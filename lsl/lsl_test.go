@@ -0,0 +1,32 @@
+package lsl
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClockMapper(t *testing.T) {
+	var clock clockMapper
+
+	testCases := []struct {
+		glassMs  uint64
+		now      float64
+		expected float64
+	}{
+		// the first sample sets the offset
+		{1000, 100.020, 100.020},
+		// a late arrival keeps the glass spacing
+		{1010, 100.050, 100.030},
+		// an earlier arrival than expected lowers the offset
+		{1020, 100.035, 100.035},
+		{1030, 100.060, 100.045},
+		// the glass restarted
+		{10, 200.000, 200.000},
+	}
+
+	for _, tc := range testCases {
+		if actual := clock.toLocal(tc.glassMs, tc.now); math.Abs(actual-tc.expected) > 1e-9 {
+			t.Errorf("toLocal(%d, %f) = %f; expected %f", tc.glassMs, tc.now, actual, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+//go:build lsl
+
+package lsl
+
+// #cgo LDFLAGS: -llsl
+// #include <stdlib.h>
+// #include <lsl_c.h>
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"xreal-light-xr-go/pkg/xreal"
+)
+
+// liblslOutlet is an LSL outlet, samples are pushed with explicit timestamps on the LSL local clock.
+type liblslOutlet struct {
+	handle   C.lsl_outlet
+	channels int
+}
+
+func newOutlet(config StreamConfig, sourceID string) (outlet, error) {
+	name := C.CString(config.Name)
+	defer C.free(unsafe.Pointer(name))
+	contentType := C.CString(config.Type)
+	defer C.free(unsafe.Pointer(contentType))
+	source := C.CString(sourceID)
+	defer C.free(unsafe.Pointer(source))
+
+	format := C.cft_double64
+	if config.Strings {
+		format = C.cft_string
+	}
+	info := C.lsl_create_streaminfo(name, contentType, C.int32_t(len(config.Channels)), C.LSL_IRREGULAR_RATE, C.lsl_channel_format_t(format), source)
+	if info == nil {
+		return nil, fmt.Errorf("failed to create stream info for %s", config.Name)
+	}
+	defer C.lsl_destroy_streaminfo(info)
+
+	desc := C.lsl_get_desc(info)
+	appendChildValue(desc, "manufacturer", manufacturer)
+	appendChildValue(desc, "driver_version", xreal.Version())
+	channels := appendChild(desc, "channels")
+	for _, channel := range config.Channels {
+		element := appendChild(channels, "channel")
+		appendChildValue(element, "label", channel.Label)
+		appendChildValue(element, "type", channel.Type)
+		if channel.Unit != "" {
+			appendChildValue(element, "unit", channel.Unit)
+		}
+	}
+
+	handle := C.lsl_create_outlet(info, 0, 360)
+	if handle == nil {
+		return nil, fmt.Errorf("failed to create outlet for %s", config.Name)
+	}
+	return &liblslOutlet{handle: handle, channels: len(config.Channels)}, nil
+}
+
+func appendChild(parent C.lsl_xml_ptr, name string) C.lsl_xml_ptr {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	return C.lsl_append_child(parent, cName)
+}
+
+func appendChildValue(parent C.lsl_xml_ptr, name, value string) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	cValue := C.CString(value)
+	defer C.free(unsafe.Pointer(cValue))
+	C.lsl_append_child_value(parent, cName, cValue)
+}
+
+func (o *liblslOutlet) pushSample(sample []float64, timestamp float64) error {
+	if len(sample) != o.channels {
+		return fmt.Errorf("got %d channels, want %d", len(sample), o.channels)
+	}
+	if code := C.lsl_push_sample_dt(o.handle, (*C.double)(unsafe.Pointer(&sample[0])), C.double(timestamp)); code != 0 {
+		return fmt.Errorf("failed to push sample: lsl error %d", int(code))
+	}
+	return nil
+}
+
+func (o *liblslOutlet) pushMarker(marker string, timestamp float64) error {
+	value := C.CString(marker)
+	defer C.free(unsafe.Pointer(value))
+	if code := C.lsl_push_sample_strt(o.handle, &value, C.double(timestamp)); code != 0 {
+		return fmt.Errorf("failed to push marker: lsl error %d", int(code))
+	}
+	return nil
+}
+
+func (o *liblslOutlet) close() {
+	C.lsl_destroy_outlet(o.handle)
+}
+
+func localClock() float64 {
+	return float64(C.lsl_local_clock())
+}
@@ -0,0 +1,11 @@
+//go:build !lsl
+
+package lsl
+
+func newOutlet(config StreamConfig, sourceID string) (outlet, error) {
+	return nil, ErrNotAvailable
+}
+
+func localClock() float64 {
+	return 0
+}
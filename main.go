@@ -3,14 +3,19 @@ package main
 import (
 	"flag"
 	"fmt"
-	"log"
 	"log/slog"
 	"os"
 	"strings"
 	"time"
 
 	"xreal-light-xr-go/constant"
-	"xreal-light-xr-go/device"
+	"xreal-light-xr-go/controller"
+	"xreal-light-xr-go/dbus"
+	"xreal-light-xr-go/hooks"
+	"xreal-light-xr-go/internal/device"
+	"xreal-light-xr-go/logging"
+	"xreal-light-xr-go/messages"
+	"xreal-light-xr-go/pkg/xreal"
 
 	"github.com/peterh/liner"
 )
@@ -18,8 +23,47 @@ import (
 func parseFlags() constant.Config {
 	var config constant.Config
 
-	flag.BoolVar(&config.AutoConnect, "auto", false, "if set, connect the first attached glass automatically")
+	flag.BoolVar(&config.Version, "version", false, "if set, print the version and exit")
+	flag.Var(autoConnectFlag{model: &config.AutoConnect}, "auto", "if set, connect the first attached glass of the model as soon as it is attached and again after unplugging it: light, air or any; any if no model is given")
 	flag.BoolVar(&config.Debug, "debug", false, "if set, enable debug logging output")
+	flag.StringVar(&config.LogFormat, "log-format", logging.FORMAT_TEXT, "log format: text or json")
+	flag.StringVar(&config.LogFilePath, "log-file", "", "file to append logs to, empty for stderr")
+	flag.StringVar(&config.MessagesFilePath, "messages", "", "JSON file translating the messages and error hints, see package messages; empty for English")
+	flag.StringVar(&config.LogModuleLevels, "log-modules", "", "comma separated levels of the protocol, imu and camera logs overriding -debug, e.g. imu=warn,protocol=debug")
+	flag.BoolVar(&config.AssumeYes, "yes", false, "if set, assume yes to all confirmations, e.g. for running dev test commands unattended")
+	flag.BoolVar(&config.AssumeYes, "assume-yes", false, "alias of -yes")
+	flag.BoolVar(&config.DBus, "dbus", false, "if set, expose the connected glass on the D-Bus session bus as "+dbus.BusName)
+	flag.StringVar(&config.BrightnessSource, "brightness-source", "none", "ambient light source driving the brightness level: none, glasses or host (iio-sensor-proxy); requires -dbus")
+	flag.StringVar(&config.BrightnessSchedule, "brightness-schedule", "", "comma separated daily brightness levels applied while a glass is connected, as hh:mm=level, e.g. 07:00=6,22:30=2; empty to disable")
+	flag.DurationVar(&config.BrightnessRamp, "brightness-ramp", 2*time.Second, "how long scheduled brightness changes ramp over; 0 to jump")
+	flag.DurationVar(&config.ProximityDebounce, "proximity-debounce", 0, "how long a proximity state must hold before it is reported, e.g. 500ms; 0 to disable")
+	flag.IntVar(&config.AmbientLightWindow, "ambientlight-window", 1, "number of ambient light readings to average before reporting")
+	flag.UintVar(&config.AmbientLightMinDelta, "ambientlight-delta", 0, "min change of the averaged ambient light to report")
+	flag.IntVar(&config.MagnetometerWindow, "magnetometer-window", 1, "number of magnetometer readings to average before reporting")
+	flag.Float64Var(&config.MagnetometerMinDelta, "magnetometer-delta", 0, "min change in raw units on any axis of the averaged magnetometer reading to report")
+	flag.StringVar(&config.HistoryFilePath, "history", defaultHistoryFilePath(), "file to persist command history across sessions, empty to disable")
+	flag.StringVar(&config.AuditLogPath, "audit-log", "", "file to append an audit trail of state-changing commands to, empty to disable")
+	flag.StringVar(&config.StateFilePath, "state-file", "", "file to persist the last applied settings to, empty to disable")
+	flag.BoolVar(&config.RestoreState, "restore-state", false, "if set, restore the settings persisted in -state-file once a glass is connected")
+	flag.DurationVar(&config.IMUWatchdog, "imu-watchdog", 0, "how long the IMU stream may stall before it is recovered, e.g. 500ms; 0 to disable")
+	flag.DurationVar(&config.CameraWatchdog, "camera-watchdog", 0, "how long a SLAM frame request may go unanswered before the cameras are recovered, e.g. 2s; 0 to disable")
+	flag.StringVar(&config.WatchdogRecovery, "watchdog-recovery", "reenable,reset-sensors,reconnect", "comma separated recovery actions escalated through on consecutive stalls: report, reenable, reset-sensors or reconnect")
+	flag.StringVar(&config.MetricsAddress, "metrics", "", "address to serve command statistics at /metrics in the Prometheus text format, e.g. localhost:9100; empty to disable")
+	flag.StringVar(&config.CameraStreamAddress, "camera-stream", "", "address to serve the SLAM cameras at as an MJPEG stream for browsers and VLC, e.g. localhost:8080; empty to disable")
+	flag.StringVar(&config.AuthFilePath, "auth", "", "file of the tokens and client certificates allowed to use -metrics, -camera-stream and visualize imu web, and their scopes, see package auth; empty to only serve them on loopback addresses")
+	flag.StringVar(&config.TLSCertPath, "tls-cert", "", "server certificate to serve -metrics, -camera-stream and visualize imu web over TLS with, empty to serve plain HTTP")
+	flag.StringVar(&config.TLSKeyPath, "tls-key", "", "private key of -tls-cert")
+	flag.StringVar(&config.TLSClientCAPath, "tls-client-ca", "", "if set with -tls-cert, require client certificates signed by this CA")
+	flag.StringVar(&config.MountingTransform, "mounting", "", "rotation in degrees and optional translation in meters of the glass on a rig, e.g. a helmet, as roll,pitch,yaw[,x,y,z]; IMU and magnetometer readings are rotated into the rig axes; empty to disable")
+	flag.StringVar(&config.LightMCUInterface, "light-mcu-interface", "auto", "hid interface of the Light MCU to open when it exposes several, as interface=<number> and/or usagepage=<hex>, e.g. interface=1; auto for the lowest numbered one, see list")
+	flag.BoolVar(&config.RestartOnPanic, "restart-on-panic", false, "if set, restart the read loops of the Light from scratch after a panic, e.g. of a hook, instead of carrying on with the next read")
+	flag.BoolVar(&config.DetachCameraDrivers, "detach-camera-drivers", false, "if set, detach the kernel driver holding the cameras of the Light, usually uvcvideo, while they are connected, taking them away from other applications")
+	flag.StringVar(&config.FramePipeline, "frame-filters", "", "comma separated processors applied in order to the SLAM frames: gamma=<gamma>, flip=h|v, rotate=90|180|270, crop=<x>:<y>:<width>:<height> and downscale=<factor>; empty to disable")
+	flag.StringVar(&config.CaptureNameTemplate, "capture-name", "", "name template of the captured images, followed by _left or _right; {serial}, {timestamp} in unix milliseconds and {index}, one of the latter two required, e.g. {serial}/{index}; empty for {timestamp}")
+	flag.IntVar(&config.CaptureMaxFiles, "capture-max-files", 0, "images kept by the captures of a session, the oldest removed first; 0 to keep all")
+	flag.Uint64Var(&config.CaptureMinFreeMB, "capture-min-free-mb", 0, "megabytes of free disk space below which captures are refused, e.g. on small SBC disks; 0 to disable")
+	flag.StringVar(&config.Gamepad, "gamepad", "", "comma separated mapping of glass keys to virtual gamepad buttons, e.g. "+dbus.DEFAULT_GAMEPAD_MAPPING+"; empty to disable; requires -dbus and access to /dev/uhid")
+	flag.StringVar(&config.HooksFilePath, "hooks", "", "file of rules running shell commands or webhooks when the glass is worn, removed, overheating, disconnected or a key is pressed; empty to disable")
 
 	flag.Parse()
 
@@ -32,23 +76,167 @@ func main() {
 
 	config := parseFlags()
 
-	log.SetFlags(log.Ldate | log.Lmicroseconds)
+	if config.Version {
+		fmt.Printf("%s (%s build)\n", xreal.Version(), xreal.GetBuildMode())
+		return
+	}
+
+	logOptions := logging.Options{Format: config.LogFormat, Path: config.LogFilePath, Level: slog.LevelInfo}
 	if config.Debug {
-		slog.SetLogLoggerLevel(slog.LevelDebug)
+		logOptions.Level = slog.LevelDebug
+	}
+	moduleLevels, err := logging.ParseModuleLevels(config.LogModuleLevels)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logOptions.ModuleLevels = moduleLevels
+	logFile, err := logging.Setup(logOptions)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	defer logFile.Close()
+
+	if config.MessagesFilePath != "" {
+		if err := messages.LoadCatalog(config.MessagesFilePath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
 	}
 
 	slog.Debug(fmt.Sprintf("config: %+v", config))
 
-	var glassDevice device.Device
+	lightMCUInterface, err := device.ParseHIDInterfaceSelector(config.LightMCUInterface)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	device.SetLightMCUInterface(lightMCUInterface)
+	device.SetRestartOnPanic(config.RestartOnPanic)
+	device.SetDetachCameraDrivers(config.DetachCameraDrivers)
+
+	// `xrealxr report [path]` writes a bug report bundle without entering the interactive prompt
+	if flag.Arg(0) == "report" {
+		handleReportCommand(nil, strings.Join(flag.Args(), " "))
+		return
+	}
+
+	// `xrealxr repair cameras` re-attaches the camera kernel drivers left detached by a killed process
+	if flag.Arg(0) == "repair" {
+		if !handleRepairCommand(nil, strings.Join(flag.Args(), " ")) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// `xrealxr verify [path]` connects the first attached glass, runs the conformance checks and exits non-zero on failures
+	if flag.Arg(0) == "verify" {
+		exitOnSignal(func() {})
+		glassDevice := handleDeviceConnection("connect any")
+		if glassDevice == nil {
+			os.Exit(1)
+		}
+		passed := handleVerifyCommand(glassDevice, strings.Join(flag.Args(), " "))
+		glassDevice.Disconnect()
+		if !passed {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if config.MountingTransform != "" {
+		transform, err := device.ParseMountingTransform(config.MountingTransform)
+		if err == nil {
+			err = device.SetMountingTransform(transform.Rotation, transform.Translation)
+		}
+		if err != nil {
+			slog.Error(configurationError(err))
+			return
+		}
+	}
+
+	if config.BrightnessSchedule != "" {
+		if _, err := device.ParseBrightnessSchedule(config.BrightnessSchedule); err != nil {
+			slog.Error(configurationError(err))
+			return
+		}
+	}
+
+	frontends, err := newNetworkFrontends(config)
+	if err != nil {
+		slog.Error(configurationError(err))
+		return
+	}
+
+	if config.MetricsAddress != "" {
+		if err := startMetricsServer(frontends, config.MetricsAddress); err != nil {
+			slog.Error(configurationError(err))
+			return
+		}
+	}
+
+	var auditLog *controller.AuditLog
+	if config.AuditLogPath != "" {
+		var err error
+		if auditLog, err = controller.OpenAuditLog(config.AuditLogPath); err != nil {
+			slog.Error(configurationError(err))
+			return
+		}
+		defer auditLog.Close()
+	}
+
+	var stateStore *controller.StateStore
+	if config.RestoreState && config.StateFilePath == "" {
+		slog.Warn("-restore-state has no effect without -state-file")
+	}
+	if config.StateFilePath != "" {
+		var err error
+		if stateStore, err = controller.OpenStateStore(config.StateFilePath); err != nil {
+			slog.Error(configurationError(err))
+			return
+		}
+	}
+
+	var hookEngine *hooks.Engine
+	if config.HooksFilePath != "" {
+		var err error
+		if hookEngine, err = hooks.LoadEngine(config.HooksFilePath); err != nil {
+			slog.Error(configurationError(err))
+			return
+		}
+		slog.Info(messages.Text(messages.HOOKS_LOADED, len(hookEngine.Rules()), config.HooksFilePath))
+	}
+
+	session := &glassSession{config: config, auditLog: auditLog, stateStore: stateStore, hooks: hookEngine}
+	defer session.close()
+
+	// `xrealxr simulate` starts with a simulated glass connected, to explore the tool without hardware
+	if flag.Arg(0) == "simulate" {
+		if config.AutoConnect != "" {
+			slog.Warn("-auto has no effect with simulate")
+			config.AutoConnect = ""
+		}
+		simulated := device.NewSimulatedDevice()
+		if err := simulated.Connect(); err != nil {
+			slog.Error(messages.Error(err))
+			return
+		}
+		session.use(simulated)
+	}
 
-	defer func() {
-		if glassDevice != nil {
-			glassDevice.Disconnect()
+	if config.CameraStreamAddress != "" {
+		if err := startCameraStreamServer(frontends, config.CameraStreamAddress, session); err != nil {
+			slog.Error(configurationError(err))
+			return
 		}
-	}()
+	}
 
-	if config.AutoConnect {
-		glassDevice = waitAndConnectGlass()
+	if config.AutoConnect != "" {
+		stopAutoConnect := make(chan struct{})
+		// deferred after session.close, so it runs first and close can wait for the auto connect to stop
+		defer close(stopAutoConnect)
+		session.startAutoConnect(config.AutoConnect, stopAutoConnect)
 	}
 
 	line := liner.NewLiner()
@@ -56,6 +244,16 @@ func main() {
 
 	line.SetCtrlCAborts(true)
 
+	policy := &confirmationPolicy{line: line, assumeYes: config.AssumeYes}
+
+	loadHistory(line, config.HistoryFilePath)
+	defer saveHistory(line, config.HistoryFilePath)
+
+	exitOnSignal(func() {
+		saveHistory(line, config.HistoryFilePath)
+		line.Close()
+	})
+
 	for {
 		input, err := line.Prompt(">> ")
 		if err != nil {
@@ -63,10 +261,10 @@ func main() {
 				continue
 			}
 			if err.Error() == "EOF" && input == "" {
-				slog.Info("exiting..")
+				slog.Info(messages.Text(messages.EXITING))
 				return
 			}
-			slog.Error(fmt.Sprintf("error reading input: %v", err))
+			slog.Error(messages.Text(messages.READ_INPUT_FAILED, messages.Error(err)))
 			return
 		}
 
@@ -77,280 +275,345 @@ func main() {
 			continue
 		}
 
+		glassDevice := session.current()
 		switch {
+		case strings.HasPrefix(input, "history"):
+			handleHistoryCommand(line, input)
+		case strings.HasPrefix(input, "repair"):
+			handleRepairCommand(glassDevice, input)
+		case strings.HasPrefix(input, "report"):
+			handleReportCommand(glassDevice, input)
+		case strings.HasPrefix(input, "verify"):
+			if glassDevice == nil {
+				slog.Error(messages.Text(messages.NOT_CONNECTED))
+				continue
+			}
+			handleVerifyCommand(glassDevice, input)
+		case strings.HasPrefix(input, "latency"):
+			if glassDevice == nil {
+				slog.Error(messages.Text(messages.NOT_CONNECTED))
+				continue
+			}
+			handleLatencyCommand(glassDevice, input)
+		case strings.HasPrefix(input, "bench"):
+			if glassDevice == nil {
+				slog.Error(messages.Text(messages.NOT_CONNECTED))
+				continue
+			}
+			handleBenchCommand(glassDevice, input)
+		case strings.HasPrefix(input, "visualize"):
+			if glassDevice == nil {
+				slog.Error(messages.Text(messages.NOT_CONNECTED))
+				continue
+			}
+			handleVisualizeCommand(frontends, glassDevice, input)
 		case strings.HasPrefix(input, "connect"):
 			glassDevice = handleDeviceConnection(input)
 			if glassDevice == nil {
-				slog.Warn("device not connected")
+				slog.Warn(messages.Text(messages.CONNECT_FAILED))
 			}
+			session.use(glassDevice)
 		case strings.HasPrefix(input, "get"):
 			if glassDevice == nil {
-				slog.Error("device not connected, run connect first")
+				slog.Error(messages.Text(messages.NOT_CONNECTED))
 				continue
 			}
 			handleGetCommand(glassDevice, input)
 		case strings.HasPrefix(input, "set"):
 			if glassDevice == nil {
-				slog.Error("device not connected, run connect first")
+				slog.Error(messages.Text(messages.NOT_CONNECTED))
 				continue
 			}
-			handleSetCommand(glassDevice, input)
+			handleSetCommand(glassDevice, input, auditLog, stateStore)
 		case strings.HasPrefix(input, "test"):
 			if glassDevice == nil {
-				slog.Error("device not connected, run connect first")
+				slog.Error(messages.Text(messages.NOT_CONNECTED))
 				continue
 			}
-			handleDevTestCommand(glassDevice, input)
+			handleDevTestCommand(glassDevice, input, policy)
 		default:
 			if input == "list" {
-				devices, err := device.EnumerateDevices(0, 0)
+				glasses, err := device.ListGlasses()
 				if err != nil {
-					slog.Error(fmt.Sprintf("failed to enumerate hid devices: %v\n", err))
+					slog.Error(messages.Text(messages.LIST_FAILED, messages.Error(err)))
 					continue
 				}
-				for _, info := range devices {
-					slog.Info(fmt.Sprintf("- path: %s - serialNumber: %s - vid: %d - pid: %d", info.Path, info.SerialNbr, info.VendorID, info.ProductID))
+				for _, info := range glasses {
+					slog.Info(fmt.Sprintf("- %s", info.String()))
+				}
+				if beams, err := device.ListBeams(); err == nil {
+					for _, info := range beams {
+						slog.Warn(fmt.Sprintf("- %s, glasses attached to it are not supported, attach them to the host directly", info.String()))
+					}
+				}
+				if glassDevice != nil {
+					handleGetCommand(glassDevice, "get connections")
 				}
 				continue
 			}
 			if (input == "exit") || (input == "quit") || (input == "stop") || (input == "q") {
 				return
 			}
-			slog.Error("unknown command")
+			slog.Error(messages.Text(messages.UNKNOWN_COMMAND))
 		}
 	}
 }
 
-func waitAndConnectGlass() device.Device {
-	for {
-		glassDevice := handleDeviceConnection("connect any")
-		if glassDevice == nil {
-			slog.Info("retry in 10s...")
-			time.Sleep(10 * time.Second)
-			continue
-		}
-		return glassDevice
-	}
-}
-
 func handleDeviceConnection(input string) device.Device {
 	parts := strings.Split(input, " ")
-	if len(parts) != 2 {
-		slog.Error(fmt.Sprintf("invalid command format: connect len(%v)=%d. Use 'connect <any>'", parts, len(parts)))
+	if len(parts) < 2 {
+		slog.Error(messages.Usage("connect <any|serial <sn>|path <path>|one <path>>"))
 		return nil
 	}
 
 	var glassDevice device.Device
 	switch parts[1] {
 	case "any":
-		glassDevice = device.NewXREALLight()
+		glassDevice = device.NewXREALLight(nil, nil)
+	case "serial":
+		if len(parts) != 3 {
+			slog.Error(messages.Usage("connect serial <sn>"))
+			return nil
+		}
+		glassDevice = device.NewXREALLight(nil, &parts[2])
+	case "path":
+		if len(parts) < 3 {
+			slog.Error(messages.Usage("connect path <path>"))
+			return nil
+		}
+		// hid paths may contain spaces on some platforms
+		devicePath := strings.Join(parts[2:], " ")
+		glassDevice = device.NewXREALLight(&devicePath, nil)
+	case "one":
+		// the One series support is a skeleton to study its protocol with 'test raw <hex>'
+		if len(parts) < 3 {
+			slog.Error(messages.Usage("connect one <path>"))
+			return nil
+		}
+		devicePath := strings.Join(parts[2:], " ")
+		glassDevice = device.NewXREALOne(&devicePath)
 	default:
+		slog.Error(messages.Usage("connect <any|serial <sn>|path <path>|one <path>>"))
 		return nil
 	}
 
 	err := glassDevice.Connect()
 	if err != nil {
-		slog.Error(fmt.Sprintf("failed to connect: %v", err))
+		slog.Error(fmt.Sprintf("failed to connect: %s", messages.Error(err)))
 		return nil
 	}
 	return glassDevice
 }
 
-func handleGetCommand(d device.Device, input string) {
-	parts := strings.Split(input, " ")
-	if len(parts) < 2 {
-		slog.Error(fmt.Sprintf("invalid command format: get len(%v)=%d. Use 'get <command>'", parts, len(parts)))
-		return
+// restartDBusService exposes the newly connected glass on D-Bus if enabled.
+func restartDBusService(config constant.Config, service *dbus.Service, d device.Device, auditLog *controller.AuditLog, stateStore *controller.StateStore) *dbus.Service {
+	if service != nil {
+		service.Stop()
 	}
 
-	command := parts[1]
-	args := parts[2:]
+	if !config.DBus || d == nil {
+		return nil
+	}
 
-	switch command {
-	case "serial":
-		serial, err := d.GetSerial()
-		if err != nil {
-			slog.Error(fmt.Sprintf("failed to get serial: %v", err))
-			return
-		}
-		slog.Info(fmt.Sprintf("Serial: %s", serial))
-	case "displaymode":
-		mode, err := d.GetDisplayMode()
-		if err != nil {
-			slog.Error(fmt.Sprintf("failed to get display mode: %v", err))
-			return
-		}
-		slog.Info(fmt.Sprintf("Display Mode: %s", mode))
-	case "brightness":
-		brightness, err := d.GetBrightnessLevel()
-		if err != nil {
-			slog.Error(fmt.Sprintf("failed to get brightness level: %v", err))
-			return
-		}
-		slog.Info(fmt.Sprintf("Brightness Level: %s", brightness))
-	case "image", "images":
-		if len(args) == 0 || !isDir(args[0]) {
-			slog.Error(fmt.Sprintf("invalid input: %v", args))
-			return
+	service, err := dbus.Start(d)
+	if err != nil {
+		slog.Error(messages.Text(messages.DBUS_FAILED, messages.Error(err)))
+		return nil
+	}
+	slog.Info(messages.Text(messages.DBUS_STARTED, dbus.BusName))
+
+	if auditLog != nil {
+		service.SetAuditLog(auditLog)
+	}
+	if stateStore != nil {
+		service.SetStateStore(stateStore)
+	}
+
+	if err := service.SetBrightnessSource(dbus.BrightnessSource(config.BrightnessSource)); err != nil {
+		slog.Error(messages.Text(messages.BRIGHTNESS_SOURCE_FAILED, config.BrightnessSource, messages.Error(err)))
+	}
+	if config.Gamepad != "" {
+		mapping, err := dbus.ParseGamepadMapping(config.Gamepad)
+		if err == nil {
+			err = service.SetGamepad(mapping)
 		}
-		filepaths, err := d.GetImages(args[0])
 		if err != nil {
-			slog.Error(fmt.Sprintf("failed to dump images: %v", err))
-			return
+			slog.Error(messages.Text(messages.GAMEPAD_FAILED, config.Gamepad, messages.Error(err)))
 		}
-		slog.Info(fmt.Sprintf("dumped to file location: %v", filepaths))
-	default:
-		slog.Error("unknown command")
 	}
+	return service
 }
 
-func handleSetCommand(d device.Device, input string) {
+func handleGetCommand(d device.Device, input string) {
 	parts := strings.Split(input, " ")
 	if len(parts) < 2 {
-		slog.Error(fmt.Sprintf("invalid command format: get len(%v)=%d. Use 'set <command> <optional:args>'", parts, len(parts)))
+		slog.Error(messages.Usage("get <command>"))
 		return
 	}
 
-	command := parts[1]
-	args := parts[2:]
+	result, err := controller.New(d).Get(parts[1], parts[2:])
+	if err != nil {
+		slog.Error(messages.Error(err))
+		return
+	}
+	slog.Info(result.String())
+}
 
-	switch command {
-	case "displaymode":
-		if len(args) == 0 {
-			slog.Error(fmt.Sprintf("empty display mode input, please specify one of (%v)", device.SupportedDisplayMode))
-			return
-		}
-		if _, ok := device.SupportedDisplayMode[args[0]]; !ok {
-			slog.Error(fmt.Sprintf("invalid display mode: got (%s) want one of (%v)", args[0], device.SupportedDisplayMode))
-			return
-		}
-		err := d.SetDisplayMode(device.DisplayMode(args[0]))
-		if err != nil {
-			slog.Error(fmt.Sprintf("failed to set display mode: %v", err))
-			return
-		}
-		slog.Info("Display mode set successfully")
-	case "brightness":
-		if len(args) == 0 {
-			slog.Error("empty brightness level input, please specify a number")
-			return
-		}
-		if err := d.SetBrightnessLevel(args[0]); err != nil {
-			slog.Error(fmt.Sprintf("failed to set brightness level: %v", err))
-			return
-		}
-		slog.Info("Display mode set successfully")
-	case "vsync", "ambientlight", "magnetometer", "temperature", "imu", "rgbcam", "sleep":
-		if len(args) == 0 || (args[0] != "0" && args[0] != "1") {
-			slog.Error("empty input, please specify 0 (disable) or 1 (enable)")
-			return
-		}
-		var err error
-		switch command {
-		case "vsync":
-			err = d.EnableEventReporting(device.CMD_ENABLE_VSYNC, args[0])
-		case "ambientlight":
-			err = d.EnableEventReporting(device.CMD_ENABLE_AMBIENT_LIGHT, args[0])
-		case "magnetometer":
-			err = d.EnableEventReporting(device.CMD_ENABLE_MAGNETOMETER, args[0])
-		case "temperature":
-			err = d.EnableEventReporting(device.CMD_ENABLE_TEMPERATURE, args[0])
-		case "rgbcam":
-			err = d.EnableEventReporting(device.CMD_ENABLE_RGB_CAMERA, args[0])
-		case "imu":
-			err = d.EnableEventReporting(device.OV580_ENABLE_IMU_STREAM, args[0])
-		case "sleep":
-			err = d.EnableEventReporting(device.CMD_SET_SLEEP_TIME, args[0])
-		}
-		if err != nil {
-			slog.Error(fmt.Sprintf("failed to set %s event: %v", command, err))
-			return
-		}
-		slog.Info(fmt.Sprintf("%s event reporting set successfully", command))
-	default:
-		slog.Error("unknown command")
+// restoreState returns the newly connected glass to the settings persisted before, if enabled.
+func restoreState(config constant.Config, d device.Device, auditLog *controller.AuditLog, stateStore *controller.StateStore) {
+	if !config.RestoreState || d == nil || stateStore == nil {
+		return
 	}
+
+	if err := controller.New(d).WithAuditLog(auditLog, controller.INITIATOR_RESTORE).WithStateStore(stateStore).RestoreLastKnownState(); err != nil {
+		slog.Error(messages.Text(messages.RESTORE_FAILED, messages.Error(err)))
+		return
+	}
+	slog.Info(messages.Text(messages.RESTORED_STATE))
 }
 
-func confirmToContinue() bool {
-	line := liner.NewLiner()
-	defer line.Close()
+// startFramePipeline processes the SLAM frames of the newly connected glass, if enabled.
+func startFramePipeline(config constant.Config, d device.Device) {
+	if config.FramePipeline == "" || d == nil {
+		return
+	}
 
-	line.SetCtrlCAborts(true)
+	pipeline, err := device.ParseFramePipeline(config.FramePipeline)
+	if err == nil {
+		err = d.SetFramePipeline(pipeline)
+	}
+	if err != nil {
+		slog.Error(messages.Text(messages.FRAME_PIPELINE_FAILED, messages.Error(err)))
+	}
+}
+
+// eventFilters are the filters of the proximity, ambient light and magnetometer events configured by the flags.
+func eventFilters(config constant.Config) device.EventFilterConfig {
+	return device.EventFilterConfig{
+		ProximityDebounce:    config.ProximityDebounce,
+		AmbientLightWindow:   config.AmbientLightWindow,
+		AmbientLightMinDelta: uint16(config.AmbientLightMinDelta),
+		MagnetometerWindow:   config.MagnetometerWindow,
+		MagnetometerMinDelta: config.MagnetometerMinDelta,
+	}
+}
+
+// startCapturePolicy sets how the newly connected glass names and keeps captured images, if configured.
+func startCapturePolicy(config constant.Config, d device.Device) {
+	if (config.CaptureNameTemplate == "" && config.CaptureMaxFiles == 0 && config.CaptureMinFreeMB == 0) || d == nil {
+		return
+	}
+
+	policy := device.CapturePolicy{
+		NameTemplate: config.CaptureNameTemplate,
+		MaxFiles:     config.CaptureMaxFiles,
+		MinFreeBytes: config.CaptureMinFreeMB * 1024 * 1024,
+	}
+	if err := d.SetCapturePolicy(policy); err != nil {
+		slog.Error(messages.Text(messages.CAPTURE_POLICY_FAILED, messages.Error(err)))
+	}
+}
+
+// restartBrightnessSchedule stops the brightness schedule of the previous glass and runs it for the newly connected
+// glass, if configured. It returns the channel to close to stop it, nil if not running.
+func restartBrightnessSchedule(config constant.Config, stop chan struct{}, d device.Device) chan struct{} {
+	if stop != nil {
+		close(stop)
+	}
+	if config.BrightnessSchedule == "" || d == nil {
+		return nil
+	}
 
-	input, err := line.Prompt("Please confirm if you want to continue? (y/N) ")
+	schedule, err := device.ParseBrightnessSchedule(config.BrightnessSchedule)
 	if err != nil {
-		if err == liner.ErrPromptAborted {
-			slog.Warn("aborted, taking it as a NO")
-			return false
-		}
-		if err.Error() == "EOF" && input == "" {
-			slog.Warn("EOF, taking it as a NO")
-			return false
-		}
-		slog.Error(fmt.Sprintf("error reading input: %v", err))
-		return false
+		slog.Error(messages.Text(messages.SCHEDULE_FAILED, messages.Error(err)))
+		return nil
 	}
+	stop = make(chan struct{})
+	go device.RunBrightnessSchedule(d, schedule, config.BrightnessRamp, stop)
+	return stop
+}
 
-	input = strings.TrimSpace(input)
+// startStreamWatchdog watches the streams of the newly connected glass, if enabled.
+func startStreamWatchdog(config constant.Config, d device.Device) {
+	if (config.IMUWatchdog <= 0 && config.CameraWatchdog <= 0) || d == nil {
+		return
+	}
 
-	if input != "y" && input != "Y" && input != "Yes" && input != "YES" {
-		return false
+	actions, err := device.ParseRecoveryActions(config.WatchdogRecovery)
+	if err != nil {
+		slog.Error(messages.Text(messages.WATCHDOG_FAILED, messages.Error(err)))
+		return
+	}
+
+	policy := &device.WatchdogPolicy{IMUTimeout: config.IMUWatchdog, CameraTimeout: config.CameraWatchdog, Actions: actions}
+	if err := d.SetStreamWatchdog(policy); err != nil {
+		slog.Error(messages.Text(messages.WATCHDOG_FAILED, messages.Error(err)))
 	}
-	return true
 }
 
-func handleDevTestCommand(d device.Device, input string) {
+func handleSetCommand(d device.Device, input string, auditLog *controller.AuditLog, stateStore *controller.StateStore) {
+	parts := strings.Split(input, " ")
+	if len(parts) < 2 {
+		slog.Error(messages.Usage("set <command> <optional:args>"))
+		return
+	}
+
+	result, err := controller.New(d).WithAuditLog(auditLog, controller.INITIATOR_CLI).WithStateStore(stateStore).Set(parts[1], parts[2:])
+	if err != nil {
+		slog.Error(messages.Error(err))
+		return
+	}
+	slog.Info(result.String())
+}
+
+func handleDevTestCommand(d device.Device, input string, policy *confirmationPolicy) {
 	parts := strings.Split(input, " ")
 	if len(parts) < 3 {
-		slog.Error(fmt.Sprintf("invalid command format: get len(%v)=%d. Use 'test mcu/ov580 <command> <optional:args>'", parts, len(parts)))
+		slog.Error(messages.Usage("test <mcu|ov580|raw|camera> <command> <optional:args>"))
 		return
 	}
 
-	device := parts[1]
+	component := parts[1]
 	command := parts[2]
 	args := parts[3:]
 
-	switch device {
+	switch component {
 	case "mcu", "ov580":
 		if len(command) == 1 { // single char input
-			if confirmToContinue() {
-				d.DevExecuteAndRead(device, parts[2:])
+			level := device.GetDevCommandDangerLevel(component, parts[2:])
+			if policy.confirm(fmt.Sprintf("test %s %v", component, parts[2:]), level) {
+				d.DevExecuteAndRead(component, parts[2:])
 			}
 			return
 		}
-		slog.Error("unknown command")
+		slog.Error(messages.Text(messages.UNKNOWN_COMMAND))
+	case "raw":
+		// raw hid reports are not checked at all, e.g. to study the protocol of the One series
+		if policy.confirm(fmt.Sprintf("test raw %v", parts[2:]), device.DANGER_LEVEL_UNKNOWN) {
+			d.DevExecuteAndRead(component, parts[2:])
+		}
 	case "camera":
 		switch command {
 		case "images":
 			if len(args) == 0 {
-				slog.Error("needs folder path")
+				slog.Error(messages.Usage("test camera images <folder>"))
 				return
 			}
 			if filepaths, err := d.GetImagesDataDev(args[0]); err != nil {
-				slog.Error(err.Error())
+				slog.Error(messages.Text(messages.CAPTURE_FAILED, messages.Error(err)))
 			} else {
 				slog.Info(fmt.Sprintf("dumped to %v", filepaths))
 			}
 		default:
-			slog.Error("unknown device")
+			slog.Error(messages.Usage("test camera images <folder>"))
 		}
 	default:
-		slog.Error("unknown device")
+		slog.Error(messages.Usage("test <mcu|ov580|raw|camera> <command> <optional:args>"))
 	}
 }
 
-func isDir(path string) bool {
-	// Use os.Stat to get file info
-	info, err := os.Stat(path)
-	if err != nil {
-		// Handle error
-		if os.IsNotExist(err) {
-			return false // Path does not exist
-		}
-		return false // Other error, treat as not directory
-	}
-
-	// Check if the path is a directory
-	return info.IsDir()
+// configurationError formats an error of the flags or files xrealxr is started with for users.
+func configurationError(err error) string {
+	return messages.Error(fmt.Errorf("%w: %w", messages.ErrInvalidConfiguration, err))
 }
@@ -0,0 +1,201 @@
+// Package messages is the catalog of the user-facing messages of xrealxr, so they can be translated, and gives every
+// error a stable code, e.g. XR-013, that users can reference in issues and scripts can match in the output.
+//
+// Messages are English unless a translation is loaded with LoadCatalog from a JSON file mapping message IDs and error
+// codes to the translated text, e.g.
+//
+//	{
+//	  "not_connected": "Brille nicht verbunden, zuerst connect ausführen",
+//	  "XR-013": "die Brille hat nicht rechtzeitig geantwortet"
+//	}
+//
+// Messages missing from the translation stay English. Error codes and the error details are never translated.
+package messages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"xreal-light-xr-go/auth"
+	"xreal-light-xr-go/controller"
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/internal/device"
+)
+
+// ID identifies a message of the catalog. The hints of error codes are identified by the code, e.g. "XR-013".
+type ID string
+
+const (
+	NOT_CONNECTED     ID = "not_connected"
+	CONNECT_FAILED    ID = "connect_failed"
+	UNKNOWN_COMMAND   ID = "unknown_command"
+	EXITING           ID = "exiting"
+	RESTORED_STATE    ID = "restored_state"
+	RESTORE_FAILED    ID = "restore_failed"
+	HOOKS_LOADED      ID = "hooks_loaded"
+	AUTO_CONNECTED    ID = "auto_connected"
+	WAITING_FOR_GLASS ID = "waiting_for_glass"
+	GLASS_UNPLUGGED   ID = "glass_unplugged"
+	DBUS_STARTED      ID = "dbus_started"
+
+	INVALID_COMMAND          ID = "invalid_command"
+	READ_INPUT_FAILED        ID = "read_input_failed"
+	LIST_FAILED              ID = "list_failed"
+	DBUS_FAILED              ID = "dbus_failed"
+	BRIGHTNESS_SOURCE_FAILED ID = "brightness_source_failed"
+	GAMEPAD_FAILED           ID = "gamepad_failed"
+	FRAME_PIPELINE_FAILED    ID = "frame_pipeline_failed"
+	CAPTURE_POLICY_FAILED    ID = "capture_policy_failed"
+	SCHEDULE_FAILED          ID = "schedule_failed"
+	WATCHDOG_FAILED          ID = "watchdog_failed"
+	CAPTURE_FAILED           ID = "capture_failed"
+)
+
+// ERROR_CODE_UNKNOWN is the code of errors without a more specific one.
+const ERROR_CODE_UNKNOWN = device.ErrorCode("XR-000")
+
+// ErrInvalidConfiguration is wrapped by errors of the flags and files xrealxr is started with.
+var ErrInvalidConfiguration = errors.New("invalid configuration")
+
+// english is the built-in catalog, as format strings of fmt.Sprintf.
+var english = map[ID]string{
+	NOT_CONNECTED:     "device not connected, run connect first",
+	CONNECT_FAILED:    "device not connected",
+	UNKNOWN_COMMAND:   "unknown command",
+	EXITING:           "exiting..",
+	RESTORED_STATE:    "restored last known state",
+	RESTORE_FAILED:    "failed to restore last known state: %s",
+	HOOKS_LOADED:      "loaded %d hooks from %s",
+	AUTO_CONNECTED:    "auto connected %s",
+	WAITING_FOR_GLASS: "waiting for a glass (%s) to be attached...",
+	GLASS_UNPLUGGED:   "%s unplugged, waiting for it to be attached again...",
+	DBUS_STARTED:      "D-Bus service started as %s",
+
+	INVALID_COMMAND:          "invalid command format, use '%s'",
+	READ_INPUT_FAILED:        "failed to read input: %s",
+	LIST_FAILED:              "failed to list glasses: %s",
+	DBUS_FAILED:              "failed to start D-Bus service: %s",
+	BRIGHTNESS_SOURCE_FAILED: "failed to set brightness source %s: %s",
+	GAMEPAD_FAILED:           "failed to set up virtual gamepad %s: %s",
+	FRAME_PIPELINE_FAILED:    "failed to set frame pipeline: %s",
+	CAPTURE_POLICY_FAILED:    "failed to set capture policy: %s",
+	SCHEDULE_FAILED:          "failed to start brightness schedule: %s",
+	WATCHDOG_FAILED:          "failed to start stream watchdog: %s",
+	CAPTURE_FAILED:           "failed to capture images: %s",
+
+	ID(ERROR_CODE_UNKNOWN): "unexpected error, please report it with the output of `xrealxr report`",
+	"XR-001":               "the glass may have been unplugged",
+	"XR-002":               "the glass sent data this driver does not understand",
+	"XR-003":               "the glass stopped responding, check the cable",
+	"XR-004":               "IMU samples may have been lost",
+	"XR-005":               "this command is not known for the firmware of the glass",
+	"XR-006":               "the firmware of the glass does not have this command",
+	"XR-007":               "libusb could not be initialized, cameras are unavailable",
+	"XR-008":               "reads of the same file differ, try again",
+	"XR-009":               "free some disk space or lower -capture-min-free-mb",
+	"XR-010":               "connect the glass directly instead of through an XREAL Beam",
+	"XR-011":               "internal error, please report it with the output of `xrealxr report`",
+	"XR-012":               "the firmware of the glass is untested, commands may misbehave",
+	"XR-013":               "the glass did not respond in time",
+	"XR-014":               "the glass was disconnected",
+	"XR-015":               "another process controls the glass, run `set role controller` to take over",
+	"XR-016":               "this command is refused in safe builds, see the developer build tag",
+	"XR-017":               "no MCU timestamps received yet, try again in a few seconds",
+	"XR-018":               "the OV580 may be in a bad state, replug the glass",
+	"XR-019":               "a stream stalled, see -imu-watchdog and -camera-watchdog",
+	"XR-020":               "the file transfer lost parts, try again",
+	"XR-101":               "see the README for the commands",
+	"XR-102":               "check the arguments of the command",
+	"XR-103":               "check the flags and files xrealxr is started with, see -help",
+	"XR-201":               "no known token or certificate was presented",
+	"XR-202":               "the client is not granted this scope",
+	"XR-301":               "keep the IMU and VSync events enabled for longer",
+}
+
+// errorCodes are the codes of errors of other packages than device, which has its own. Codes are never reused or
+// renumbered.
+var errorCodes = []struct {
+	err  error
+	code device.ErrorCode
+}{
+	{controller.ErrUnknownCommand, "XR-101"},
+	{controller.ErrInvalidArgument, "XR-102"},
+	{ErrInvalidConfiguration, "XR-103"},
+	{auth.ErrUnauthenticated, "XR-201"},
+	{auth.ErrForbidden, "XR-202"},
+	{fusion.ErrNotEnoughLatencySamples, "XR-301"},
+}
+
+var (
+	// mutex for thread safety
+	mutex       sync.RWMutex
+	translation map[ID]string
+)
+
+// LoadCatalog loads the translation in the JSON file at path, see the package doc for the format.
+func LoadCatalog(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read message catalog %s: %w", path, err)
+	}
+	var loaded map[ID]string
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to parse message catalog %s: %w", path, err)
+	}
+	for id := range loaded {
+		if _, ok := english[id]; !ok {
+			return fmt.Errorf("invalid message catalog %s: unknown message %s", path, id)
+		}
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	translation = loaded
+	return nil
+}
+
+// Text returns the message, translated if loaded, formatted with args.
+func Text(id ID, args ...any) string {
+	mutex.RLock()
+	format, ok := translation[id]
+	mutex.RUnlock()
+	if !ok {
+		format = english[id]
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// CodeOf returns the code of err, ERROR_CODE_UNKNOWN if it has none, empty if err is nil.
+func CodeOf(err error) device.ErrorCode {
+	if err == nil {
+		return ""
+	}
+	if code := device.ErrorCodeOf(err); code != "" {
+		return code
+	}
+	for _, entry := range errorCodes {
+		if errors.Is(err, entry.err) {
+			return entry.code
+		}
+	}
+	return ERROR_CODE_UNKNOWN
+}
+
+// Usage formats an invalid command for users as an error with the code of controller.ErrInvalidArgument and the usage
+// of the command, e.g. "connect serial <sn>".
+func Usage(usage string) string {
+	return Error(fmt.Errorf("%w: %s", controller.ErrInvalidArgument, Text(INVALID_COMMAND, usage)))
+}
+
+// Error formats err for users as its code, the error and the hint of the code, e.g.
+// "XR-013: failed to get serial: timed out waiting for a response (the glass did not respond in time)".
+func Error(err error) string {
+	code := CodeOf(err)
+	return fmt.Sprintf("%s: %v (%s)", code, err, Text(ID(code)))
+}
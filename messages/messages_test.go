@@ -0,0 +1,75 @@
+package messages_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xreal-light-xr-go/controller"
+	"xreal-light-xr-go/internal/device"
+	"xreal-light-xr-go/messages"
+)
+
+func TestCodeOf(t *testing.T) {
+	testCases := []struct {
+		err  error
+		code device.ErrorCode
+	}{
+		{fmt.Errorf("failed to get serial: %w", device.ErrHeartBeatLost), "XR-003"},
+		{fmt.Errorf("%w: get nothing", controller.ErrUnknownCommand), "XR-101"},
+		{fmt.Errorf("%w: -mounting: invalid", messages.ErrInvalidConfiguration), "XR-103"},
+		{errors.New("something else"), messages.ERROR_CODE_UNKNOWN},
+		{nil, ""},
+	}
+	for _, tc := range testCases {
+		if code := messages.CodeOf(tc.err); code != tc.code {
+			t.Errorf("CodeOf(%v) = %q, want %q", tc.err, code, tc.code)
+		}
+	}
+
+	got := messages.Error(fmt.Errorf("failed to get serial: %w", device.ErrHeartBeatLost))
+	if !strings.HasPrefix(got, "XR-003: failed to get serial: heart beat lost (") {
+		t.Errorf("Error() = %q, want the code, the error and the hint", got)
+	}
+
+	got = messages.Usage("get <command>")
+	if !strings.HasPrefix(got, "XR-102: invalid argument: invalid command format, use 'get <command>' (") {
+		t.Errorf("Usage() = %q, want the code of invalid arguments and the usage", got)
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "de.json")
+	if err := os.WriteFile(path, []byte(`{"auto_connected": "%s automatisch verbunden", "XR-003": "Kabel prüfen"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := messages.LoadCatalog(path); err != nil {
+		t.Fatalf("LoadCatalog() = %v", err)
+	}
+	defer func() {
+		os.WriteFile(path, []byte(`{}`), 0o600)
+		messages.LoadCatalog(path)
+	}()
+
+	if got := messages.Text(messages.AUTO_CONNECTED, "XREAL Light"); got != "XREAL Light automatisch verbunden" {
+		t.Errorf("Text() = %q, want the translation", got)
+	}
+	if got := messages.Text(messages.NOT_CONNECTED); got != "device not connected, run connect first" {
+		t.Errorf("Text() = %q, want English for messages missing from the translation", got)
+	}
+	if got := messages.Error(device.ErrHeartBeatLost); got != "XR-003: heart beat lost (Kabel prüfen)" {
+		t.Errorf("Error() = %q, want the translated hint", got)
+	}
+
+	unknown := filepath.Join(dir, "unknown.json")
+	if err := os.WriteFile(unknown, []byte(`{"typo": "x"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := messages.LoadCatalog(unknown); err == nil {
+		t.Errorf("LoadCatalog() = nil, want error for an unknown message")
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"xreal-light-xr-go/auth"
+	"xreal-light-xr-go/camstream"
+	"xreal-light-xr-go/internal/device"
+)
+
+// startMetricsServer serves the command statistics at /metrics in the Prometheus text format in the background, to
+// clients granted auth.SCOPE_READ_METRICS with -auth.
+func startMetricsServer(frontends *networkFrontends, address string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if err := device.GetCommandStats().WritePrometheus(w); err != nil {
+			slog.Debug(fmt.Sprintf("failed to write metrics: %v", err))
+		}
+	})
+	server, listener, url, err := frontends.listen(address, auth.SCOPE_READ_METRICS, mux)
+	if err != nil {
+		return fmt.Errorf("failed to serve metrics: %w", err)
+	}
+
+	go func() {
+		slog.Info(fmt.Sprintf("serving metrics at %s/metrics", url))
+		if err := server.Serve(listener); err != nil {
+			slog.Error(fmt.Sprintf("failed to serve metrics at %s: %v", address, err))
+		}
+	}()
+	return nil
+}
+
+// startCameraStreamServer serves the SLAM cameras of the glass connected in the session as an MJPEG stream in the
+// background, to clients granted auth.SCOPE_READ_SENSORS with -auth.
+func startCameraStreamServer(frontends *networkFrontends, address string, session *glassSession) error {
+	server, listener, url, err := frontends.listen(address, auth.SCOPE_READ_SENSORS, camstream.NewServer(func() camstream.FrameSource {
+		if d := session.current(); d != nil {
+			return d
+		}
+		return nil
+	}, device.DEFAULT_JPEG_QUALITY))
+	if err != nil {
+		return fmt.Errorf("failed to serve SLAM cameras: %w", err)
+	}
+
+	go func() {
+		slog.Info(fmt.Sprintf("serving SLAM cameras at %s/slam", url))
+		if err := server.Serve(listener); err != nil {
+			slog.Error(fmt.Sprintf("failed to serve SLAM cameras at %s: %v", address, err))
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,125 @@
+// Package osc sends glass events as Open Sound Control messages over UDP, so TouchDesigner, Max or Pd patches
+// can react to the glass without writing Go.
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/internal/device"
+)
+
+// Message is an OSC message, arguments can be float32, int32 or string.
+type Message struct {
+	Address   string
+	Arguments []any
+}
+
+// MarshalBinary encodes the message as specified by OSC 1.0.
+func (m Message) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	writePaddedString(&buf, m.Address)
+
+	typeTags := []byte{','}
+	var arguments bytes.Buffer
+	for _, argument := range m.Arguments {
+		switch value := argument.(type) {
+		case float32:
+			typeTags = append(typeTags, 'f')
+			binary.Write(&arguments, binary.BigEndian, value)
+		case int32:
+			typeTags = append(typeTags, 'i')
+			binary.Write(&arguments, binary.BigEndian, value)
+		case string:
+			typeTags = append(typeTags, 's')
+			writePaddedString(&arguments, value)
+		default:
+			return nil, fmt.Errorf("unsupported OSC argument type %T", argument)
+		}
+	}
+	writePaddedString(&buf, string(typeTags))
+	buf.Write(arguments.Bytes())
+	return buf.Bytes(), nil
+}
+
+// writePaddedString writes a null terminated string padded to a multiple of 4 bytes.
+func writePaddedString(buf *bytes.Buffer, s string) {
+	buf.WriteString(s)
+	buf.Write(make([]byte, 4-len(s)%4))
+}
+
+// AddressPatterns are the OSC addresses events are sent to, an empty address disables the event.
+type AddressPatterns struct {
+	// Orientation receives roll, pitch, yaw as floats in degrees
+	Orientation string
+	// AmbientLight receives the ambient light reading as int
+	AmbientLight string
+	// Key receives the key pressed as string, e.g. "UP"
+	Key string
+}
+
+var DEFAULT_ADDRESS_PATTERNS = AddressPatterns{
+	Orientation:  "/xreal/orientation",
+	AmbientLight: "/xreal/ambient",
+	Key:          "/xreal/key",
+}
+
+// Sender sends glass events to an OSC server over UDP.
+type Sender struct {
+	conn     net.Conn
+	patterns AddressPatterns
+}
+
+// NewSender sends to address, e.g. "127.0.0.1:9000", or a broadcast address like "192.168.1.255:9000"
+// to reach every patch on the LAN.
+func NewSender(address string, patterns AddressPatterns) (*Sender, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open UDP socket to %s: %w", address, err)
+	}
+	return &Sender{conn: conn, patterns: patterns}, nil
+}
+
+func (s *Sender) Send(message Message) error {
+	packet, err := message.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if _, err := s.conn.Write(packet); err != nil {
+		return fmt.Errorf("failed to send OSC message %s: %w", message.Address, err)
+	}
+	return nil
+}
+
+func (s *Sender) SendOrientation(attitude fusion.Attitude) error {
+	if s.patterns.Orientation == "" {
+		return nil
+	}
+	const toDegrees = 180 / math.Pi
+	return s.Send(Message{
+		Address:   s.patterns.Orientation,
+		Arguments: []any{float32(attitude.Roll * toDegrees), float32(attitude.Pitch * toDegrees), float32(attitude.Yaw * toDegrees)},
+	})
+}
+
+func (s *Sender) SendAmbientLight(light uint16) error {
+	if s.patterns.AmbientLight == "" {
+		return nil
+	}
+	return s.Send(Message{Address: s.patterns.AmbientLight, Arguments: []any{int32(light)}})
+}
+
+func (s *Sender) SendKey(key device.KeyEvent) error {
+	if s.patterns.Key == "" {
+		return nil
+	}
+	return s.Send(Message{Address: s.patterns.Key, Arguments: []any{key.String()}})
+}
+
+func (s *Sender) Close() error {
+	return s.conn.Close()
+}
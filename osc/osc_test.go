@@ -0,0 +1,31 @@
+package osc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"xreal-light-xr-go/osc"
+)
+
+func TestMessageMarshalBinary(t *testing.T) {
+	message := osc.Message{Address: "/xreal/key", Arguments: []any{"UP", int32(1), float32(0.5)}}
+	expected := []byte{
+		'/', 'x', 'r', 'e', 'a', 'l', '/', 'k', 'e', 'y', 0, 0,
+		',', 's', 'i', 'f', 0, 0, 0, 0,
+		'U', 'P', 0, 0,
+		0, 0, 0, 1,
+		0x3f, 0, 0, 0,
+	}
+
+	actual, err := message.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() failed: %v", err)
+	}
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("MarshalBinary() = %v; expected %v", actual, expected)
+	}
+
+	if _, err := (osc.Message{Address: "/x", Arguments: []any{1.0}}).MarshalBinary(); err == nil {
+		t.Errorf("MarshalBinary() succeeded with float64; expected error")
+	}
+}
@@ -0,0 +1,154 @@
+// Package mobile is a facade of package xreal for `gomobile bind`, so Android and iOS apps can embed the driver: it
+// only uses the types gomobile can bind, i.e. no channels, func values or maps in its signatures, and callbacks are
+// interfaces the app implements.
+//
+// The driver opens the glass through hidapi and libusb as on desktop, so it only connects where the OS lets the app
+// open the USB devices directly, e.g. on rooted or custom Android builds. Opening them from a file descriptor granted
+// by the Android UsbManager is not supported yet.
+package mobile
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/pkg/xreal"
+)
+
+// Display modes of Glass.SetDisplayMode.
+const (
+	DISPLAY_MODE_SAME_ON_BOTH      = string(xreal.DISPLAY_MODE_SAME_ON_BOTH)
+	DISPLAY_MODE_HALF_SBS          = string(xreal.DISPLAY_MODE_HALF_SBS)
+	DISPLAY_MODE_STEREO            = string(xreal.DISPLAY_MODE_STEREO)
+	DISPLAY_MODE_HIGH_REFRESH_RATE = string(xreal.DISPLAY_MODE_HIGH_REFRESH_RATE)
+)
+
+// PoseListener receives the fused head orientation in radians, and the host time it was computed at in nanoseconds
+// since the unix epoch.
+type PoseListener interface {
+	OnPose(roll float64, pitch float64, yaw float64, timestampNanos int64)
+}
+
+// KeyListener receives the keys pressed on the glass, e.g. "UP".
+type KeyListener interface {
+	OnKey(key string)
+}
+
+// Glass is an XREAL Light. Its methods are safe to call from any thread.
+type Glass struct {
+	// mutex for thread safety
+	mutex  sync.Mutex
+	device xreal.Device
+
+	filter       *fusion.ComplementaryFilter
+	poseListener PoseListener
+	// posePeriod is the minimum time between two poses, lastPose the time the last one was sent
+	posePeriod time.Duration
+	lastPose   time.Time
+}
+
+// NewGlass creates an XREAL Light, serial picks one of several attached glasses, empty for the first one.
+func NewGlass(serial string) *Glass {
+	var serialNumber *string
+	if serial != "" {
+		serialNumber = &serial
+	}
+	return &Glass{device: xreal.NewLight(nil, serialNumber)}
+}
+
+func (g *Glass) Connect() error {
+	return g.device.Connect()
+}
+
+func (g *Glass) Disconnect() error {
+	return g.device.Disconnect()
+}
+
+func (g *Glass) Serial() (string, error) {
+	return g.device.GetSerial()
+}
+
+// Brightness returns the brightness level of the display, e.g. "1".
+func (g *Glass) Brightness() (string, error) {
+	return g.device.GetBrightnessLevel()
+}
+
+func (g *Glass) SetBrightness(level string) error {
+	return g.device.SetBrightnessLevel(level)
+}
+
+// DisplayMode returns one of the DISPLAY_MODE constants, or "UNKNOWN".
+func (g *Glass) DisplayMode() (string, error) {
+	mode, err := g.device.GetDisplayMode()
+	return string(mode), err
+}
+
+// SetDisplayMode sets one of the DISPLAY_MODE constants.
+func (g *Glass) SetDisplayMode(mode string) error {
+	switch mode {
+	case DISPLAY_MODE_SAME_ON_BOTH, DISPLAY_MODE_HALF_SBS, DISPLAY_MODE_STEREO, DISPLAY_MODE_HIGH_REFRESH_RATE:
+	default:
+		return fmt.Errorf("unsupported display mode %s", mode)
+	}
+	return g.device.SetDisplayMode(xreal.DisplayMode(mode))
+}
+
+// SetPoseListener enables the IMU and sends the fused head orientation to listener at most maxRateHz times per
+// second, every IMU sample if 0. A nil listener disables the IMU.
+func (g *Glass) SetPoseListener(listener PoseListener, maxRateHz int) error {
+	if maxRateHz < 0 {
+		return fmt.Errorf("invalid pose rate %d: want 0 or more", maxRateHz)
+	}
+
+	g.mutex.Lock()
+	g.poseListener = listener
+	g.posePeriod = 0
+	if maxRateHz > 0 {
+		g.posePeriod = time.Second / time.Duration(maxRateHz)
+	}
+	if listener != nil && g.filter == nil {
+		g.filter = fusion.NewComplementaryFilter(fusion.DEFAULT_GYROSCOPE_WEIGHT)
+	}
+	g.mutex.Unlock()
+
+	if listener == nil {
+		// the driver calls its handlers unchecked, so they are replaced by no-ops instead of nil
+		g.device.SetIMUEventHandler(func(imu *xreal.IMUEvent) {})
+		return g.device.EnableIMU(false)
+	}
+	g.device.SetIMUEventHandler(g.onIMU)
+	return g.device.EnableIMU(true)
+}
+
+func (g *Glass) onIMU(imu *xreal.IMUEvent) {
+	g.mutex.Lock()
+	attitude := g.filter.Update(imu)
+	now := time.Now()
+	listener := g.poseListener
+	if listener == nil || now.Sub(g.lastPose) < g.posePeriod {
+		g.mutex.Unlock()
+		return
+	}
+	g.lastPose = now
+	g.mutex.Unlock()
+
+	// called without the lock, so the listener may call back into the glass
+	listener.OnPose(attitude.Roll, attitude.Pitch, attitude.Yaw, now.UnixNano())
+}
+
+// SetKeyListener sends the keys pressed on the glass to listener, nil to stop.
+func (g *Glass) SetKeyListener(listener KeyListener) {
+	if listener == nil {
+		g.device.SetKeyEventHandler(func(key xreal.KeyEvent) {})
+		return
+	}
+	g.device.SetKeyEventHandler(func(key xreal.KeyEvent) {
+		listener.OnKey(key.String())
+	})
+}
+
+// Version returns the version of the driver.
+func Version() string {
+	return xreal.Version()
+}
@@ -0,0 +1,36 @@
+package mobile
+
+import (
+	"testing"
+	"time"
+
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/pkg/xreal"
+)
+
+type countingListener struct {
+	poses int
+}
+
+func (l *countingListener) OnPose(roll float64, pitch float64, yaw float64, timestampNanos int64) {
+	l.poses++
+}
+
+func TestPoseRateLimit(t *testing.T) {
+	listener := &countingListener{}
+	g := &Glass{filter: fusion.NewComplementaryFilter(fusion.DEFAULT_GYROSCOPE_WEIGHT), poseListener: listener, posePeriod: time.Hour}
+
+	imu := &xreal.IMUEvent{Accelerometer: &xreal.AccelerometerVector{}, Gyroscope: &xreal.GyroscopeVector{}}
+	for i := 0; i < 3; i++ {
+		g.onIMU(imu)
+	}
+	if listener.poses != 1 {
+		t.Errorf("OnPose called %d times within the period, want 1", listener.poses)
+	}
+}
+
+func TestSetDisplayModeRejectsUnknown(t *testing.T) {
+	if err := NewGlass("").SetDisplayMode("UNKNOWN"); err == nil {
+		t.Errorf("SetDisplayMode(UNKNOWN) succeeded, want error")
+	}
+}
@@ -0,0 +1,556 @@
+// Package xreal is the stable API of xreal-light-xr-go for downstream Go users.
+//
+// It re-exports the parts of the internal implementation that are safe to depend on. Anything not exported here,
+// e.g. the HID/USB protocol details, may change without notice.
+package xreal
+
+import (
+	"image"
+	"io"
+	"runtime/debug"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+	"xreal-light-xr-go/logging"
+)
+
+const modulePath = "xreal-light-xr-go"
+
+// version is set at build time, e.g. `go build -ldflags "-X xreal-light-xr-go/pkg/xreal.version=v0.1.0"`.
+var version = ""
+
+// Version returns the version of this module, taken from the build flags or the Go module info,
+// or "devel" if neither is available.
+func Version() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Path == modulePath && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			return info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				return dep.Version
+			}
+		}
+	}
+	return "devel"
+}
+
+type (
+	Device    = device.Device
+	GlassInfo = device.GlassInfo
+
+	DisplayMode        = device.DisplayMode
+	CommandInstruction = device.CommandInstruction
+
+	AmbientLightEventHandler = device.AmbientLightEventHandler
+	KeyEventHandler          = device.KeyEventHandler
+	MagnetometerEventHandler = device.MagnetometerEventHandler
+	ProximityEventHandler    = device.ProximityEventHandler
+	TemperatureEventHandler  = device.TemperatureEventHandlder
+	VSyncEventHandler        = device.VSyncEventHandler
+	IMUEventHandler          = device.IMUEventHandler
+	ResumedEventHandler      = device.ResumedEventHandler
+	ErrorHandler             = device.ErrorHandler
+	ErrorEvent               = device.ErrorEvent
+
+	KeyEvent            = device.KeyEvent
+	ProximityEvent      = device.ProximityEvent
+	MagnetometerVector  = device.MagnetometerVector
+	IMUEvent            = device.IMUEvent
+	AccelerometerVector = device.AccelerometerVector
+	GyroscopeVector     = device.GyroscopeVector
+
+	EventFilterConfig = device.EventFilterConfig
+
+	ProximityGesture         = device.ProximityGesture
+	ProximityGestureConfig   = device.ProximityGestureConfig
+	ProximityGestureDetector = device.ProximityGestureDetector
+
+	BuildMode        = device.BuildMode
+	SLAMFrame        = device.SLAMFrame
+	ImageEncoder     = device.ImageEncoder
+	CapturePolicy    = device.CapturePolicy
+	FramePipeline    = device.FramePipeline
+	FrameProcessor   = device.FrameProcessor
+	FrameStatistics  = device.FrameStatistics
+	ImageStatistics  = device.ImageStatistics
+	Capabilities     = device.Capabilities
+	OV580Info        = device.OV580Info
+	OV580File        = device.OV580File
+	OV580FileOptions = device.OV580FileOptions
+
+	ConnectionInfo      = device.ConnectionInfo
+	ModelFactory        = device.ModelFactory
+	UnimplementedDevice = device.UnimplementedDevice
+	BeamInfo            = device.BeamInfo
+
+	LogOptions = logging.Options
+
+	PowerProfile  = device.PowerProfile
+	PowerSettings = device.PowerSettings
+
+	BrightnessTransition = device.BrightnessTransition
+	BrightnessSchedule   = device.BrightnessSchedule
+
+	Role = device.Role
+
+	WatchdogPolicy = device.WatchdogPolicy
+	RecoveryAction = device.RecoveryAction
+
+	RetryPolicy   = device.RetryPolicy
+	CommandFuture = device.CommandFuture
+	Command       = device.Command
+
+	ErrorCode = device.ErrorCode
+
+	ComponentError = device.ComponentError
+
+	FirmwareVersion = device.FirmwareVersion
+	KnownFirmware   = device.KnownFirmware
+	DisplayVersion  = device.DisplayVersion
+
+	ConformanceReport = device.ConformanceReport
+	ConformanceCheck  = device.ConformanceCheck
+	ConformanceStatus = device.ConformanceStatus
+
+	ClockSync     = device.ClockSync
+	ClockEstimate = device.ClockEstimate
+
+	CommandStat   = device.CommandStat
+	CommandStats  = device.CommandStats
+	CommandTracer = device.CommandTracer
+
+	IMUEventBus            = device.IMUEventBus
+	IMUSubscriptionOptions = device.IMUSubscriptionOptions
+	GyroscopeUnit          = device.GyroscopeUnit
+	AccelerometerUnit      = device.AccelerometerUnit
+
+	MountingTransform = device.MountingTransform
+
+	HIDInterface         = device.HIDInterface
+	HIDInterfaceSelector = device.HIDInterfaceSelector
+)
+
+const (
+	DISPLAY_MODE_UNKNOWN           = device.DISPLAY_MODE_UNKNOWN
+	DISPLAY_MODE_SAME_ON_BOTH      = device.DISPLAY_MODE_SAME_ON_BOTH
+	DISPLAY_MODE_HALF_SBS          = device.DISPLAY_MODE_HALF_SBS
+	DISPLAY_MODE_STEREO            = device.DISPLAY_MODE_STEREO
+	DISPLAY_MODE_HIGH_REFRESH_RATE = device.DISPLAY_MODE_HIGH_REFRESH_RATE
+
+	KEY_UNKNOWN      = device.KEY_UNKNOWN
+	KEY_UP_PRESSED   = device.KEY_UP_PRESSED
+	KEY_DOWN_PRESSED = device.KEY_DOWN_PRESSED
+
+	PROXIMITY_UNKNOWN = device.PROXIMITY_UKNOWN
+	PROXIMITY_NEAR    = device.PROXIMITY_NEAR
+	PROXIMITY_FAR     = device.PROXIMITY_FAR
+
+	IMAGE_ENCODER_JPEG      = device.IMAGE_ENCODER_JPEG
+	IMAGE_ENCODER_PNG       = device.IMAGE_ENCODER_PNG
+	IMAGE_ENCODER_TURBOJPEG = device.IMAGE_ENCODER_TURBOJPEG
+	IMAGE_ENCODER_WEBP      = device.IMAGE_ENCODER_WEBP
+
+	CAPTURE_NAME_SERIAL           = device.CAPTURE_NAME_SERIAL
+	CAPTURE_NAME_TIMESTAMP        = device.CAPTURE_NAME_TIMESTAMP
+	CAPTURE_NAME_INDEX            = device.CAPTURE_NAME_INDEX
+	DEFAULT_CAPTURE_NAME_TEMPLATE = device.DEFAULT_CAPTURE_NAME_TEMPLATE
+
+	POWER_PROFILE_MAX_QUALITY = device.POWER_PROFILE_MAX_QUALITY
+	POWER_PROFILE_BALANCED    = device.POWER_PROFILE_BALANCED
+	POWER_PROFILE_POWER_SAVER = device.POWER_PROFILE_POWER_SAVER
+
+	MAX_BRIGHTNESS_LEVEL = device.MAX_BRIGHTNESS_LEVEL
+
+	HID_INTERFACE_ANY = device.HID_INTERFACE_ANY
+
+	CONFORMANCE_PASS        = device.CONFORMANCE_PASS
+	CONFORMANCE_FAIL        = device.CONFORMANCE_FAIL
+	CONFORMANCE_UNSUPPORTED = device.CONFORMANCE_UNSUPPORTED
+	CONFORMANCE_RECORDED    = device.CONFORMANCE_RECORDED
+
+	COMPONENT_MCU         = device.COMPONENT_MCU
+	COMPONENT_OV580       = device.COMPONENT_OV580
+	COMPONENT_CAMERAS     = device.COMPONENT_CAMERAS
+	COMPONENT_RGB_CAMERA  = device.COMPONENT_RGB_CAMERA
+	COMPONENT_SLAM_CAMERA = device.COMPONENT_SLAM_CAMERA
+
+	ROLE_CONTROLLER = device.ROLE_CONTROLLER
+	ROLE_OBSERVER   = device.ROLE_OBSERVER
+
+	RECOVERY_REPORT_ONLY   = device.RECOVERY_REPORT_ONLY
+	RECOVERY_REENABLE      = device.RECOVERY_REENABLE
+	RECOVERY_RESET_SENSORS = device.RECOVERY_RESET_SENSORS
+	RECOVERY_RECONNECT     = device.RECOVERY_RECONNECT
+
+	GYROSCOPE_UNIT_RAD_PER_SEC        = device.GYROSCOPE_UNIT_RAD_PER_SEC
+	GYROSCOPE_UNIT_DEG_PER_SEC        = device.GYROSCOPE_UNIT_DEG_PER_SEC
+	ACCELEROMETER_UNIT_METER_PER_SEC2 = device.ACCELEROMETER_UNIT_METER_PER_SEC2
+	ACCELEROMETER_UNIT_G              = device.ACCELEROMETER_UNIT_G
+)
+
+const (
+	BUILD_MODE_SAFE      = device.BUILD_MODE_SAFE
+	BUILD_MODE_DEVELOPER = device.BUILD_MODE_DEVELOPER
+)
+
+// Errors passed to ErrorHandler wrap one of these, test with errors.Is.
+var (
+	ErrPanic              = device.ErrPanic
+	ErrReadFailed         = device.ErrReadFailed
+	ErrDeserializeFailed  = device.ErrDeserializeFailed
+	ErrHeartBeatLost      = device.ErrHeartBeatLost
+	ErrUntestedFirmware   = device.ErrUntestedFirmware
+	ErrStreamStalled      = device.ErrStreamStalled
+	ErrIMUStreamRestarted = device.ErrIMUStreamRestarted
+)
+
+// ErrCommandNotAllowed is returned when a command is blocked in BUILD_MODE_SAFE.
+var ErrCommandNotAllowed = device.ErrCommandNotAllowed
+
+// ErrUnsupportedByFirmware is returned by commands the firmware of the connected glass does not have.
+var ErrUnsupportedByFirmware = device.ErrUnsupportedByFirmware
+
+// ErrUnsupportedCommand is returned instead of sending an instruction the firmware has no command for, errors wrapping
+// it also wrap ErrUnsupportedByFirmware.
+var ErrUnsupportedCommand = device.ErrUnsupportedCommand
+
+// ErrOV580BadState is returned by Connect when the OV580 is missing but enumerates with a PID it is known to use in a
+// bad state, even after resetting it.
+var ErrOV580BadState = device.ErrOV580BadState
+
+// ErrBeamUnsupported is returned by Connect when no glass is found but an XREAL Beam is attached, see ListBeams.
+var ErrBeamUnsupported = device.ErrBeamUnsupported
+
+// ErrCamerasUnavailable is returned by camera methods when libusb could not be initialized, see Capabilities.Cameras.
+var ErrCamerasUnavailable = device.ErrCamerasUnavailable
+
+// ErrLowDiskSpace is returned by CaptureImages when less space is left than CapturePolicy.MinFreeBytes.
+var ErrLowDiskSpace = device.ErrLowDiskSpace
+
+// ErrChecksumMismatch is returned by Device.ReadOV580File with OV580FileOptions.Verify when two reads of a file differ.
+var ErrChecksumMismatch = device.ErrChecksumMismatch
+
+// OV580_CALIBRATION_FILE_ID is the file read by Device.ReadOV580File for the IMU and camera calibration.
+const OV580_CALIBRATION_FILE_ID = device.OV580_CALIBRATION_FILE_ID
+
+// ErrNoClockSamples is returned by ClockSync until an MCU event with a timestamp is received.
+var ErrNoClockSamples = device.ErrNoClockSamples
+
+// ErrObserver is returned by commands while another process controls the glass, see Device.RequestControl.
+var ErrObserver = device.ErrObserver
+
+// GetBuildMode tells whether destructive commands can be sent, see `-tags developer`.
+func GetBuildMode() BuildMode {
+	return device.GetBuildMode()
+}
+
+// Instructions accepted by Device.EnableEventReporting, prefer the typed methods like Device.EnableIMU.
+const (
+	CMD_ENABLE_AMBIENT_LIGHT = device.CMD_ENABLE_AMBIENT_LIGHT
+	CMD_ENABLE_MAGNETOMETER  = device.CMD_ENABLE_MAGNETOMETER
+	CMD_ENABLE_VSYNC         = device.CMD_ENABLE_VSYNC
+	CMD_ENABLE_TEMPERATURE   = device.CMD_ENABLE_TEMPERATURE
+	CMD_ENABLE_RGB_CAMERA    = device.CMD_ENABLE_RGB_CAMERA
+	OV580_ENABLE_IMU_STREAM  = device.OV580_ENABLE_IMU_STREAM
+)
+
+// Read-only instructions commonly pipelined with Device.ExecuteAsync.
+const (
+	CMD_GET_BRIGHTNESS_LEVEL       = device.CMD_GET_BRIGHTNESS_LEVEL
+	CMD_GET_DISPLAY_MODE           = device.CMD_GET_DISPLAY_MODE
+	CMD_GET_SERIAL_NUMBER          = device.CMD_GET_SERIAL_NUMBER
+	CMD_GET_STOCK_FIRMWARE_VERSION = device.CMD_GET_STOCK_FIRMWARE_VERSION
+	CMD_GET_SLEEP_TIME             = device.CMD_GET_SLEEP_TIME
+	CMD_GET_GLASS_ACTIVATED        = device.CMD_GET_GLASS_ACTIVATED
+)
+
+// Deprecated: CMD_SET_SLEEP_TIME is rejected by Device.EnableEventReporting as it takes seconds, use
+// Device.SetSleepTime instead.
+const CMD_SET_SLEEP_TIME = device.CMD_SET_SLEEP_TIME
+
+// NewLight creates an XREAL Light Device. devicePath and serialNumber are optional to pick one of multiple glasses.
+func NewLight(devicePath *string, serialNumber *string) Device {
+	return device.NewXREALLight(devicePath, serialNumber)
+}
+
+// NewAir creates an XREAL Air series Device.
+func NewAir() Device {
+	return device.NewXREALAir()
+}
+
+// NewOne creates an XREAL One or One Pro at the hid path devicePath, which is required as the glass is not detected
+// yet, or from the factory of RegisterModel. Its support is a skeleton, only connecting and DevExecuteAndRead("raw",
+// hex) work so far.
+func NewOne(devicePath *string) Device {
+	return device.NewXREALOne(devicePath)
+}
+
+// NewSimulatedDevice creates a glass simulated on the host, with synthetic IMU motion, events and SLAM frames, to
+// develop against before glasses arrive. It is not connected yet, like the other Devices.
+func NewSimulatedDevice() Device {
+	return device.NewSimulatedDevice()
+}
+
+// ListGlasses lists the glasses attached to the host.
+func ListGlasses() ([]*GlassInfo, error) {
+	return device.ListGlasses()
+}
+
+// SetupLogging makes the driver log as text or JSON to stderr or a file, with levels per module, e.g.
+// LogOptions{Format: "json", ModuleLevels: map[string]slog.Level{"imu": slog.LevelWarn}}, see package logging.
+func SetupLogging(options LogOptions) (io.Closer, error) {
+	return logging.Setup(options)
+}
+
+// RegisterModel makes glasses with the VID and PID listed by ListGlasses and connected by NewDevice and
+// ConnectFirstAvailable using factory, e.g. to experiment with new hardware out of tree. It takes precedence over
+// the built-in support of the same IDs. The Device of factory can embed UnimplementedDevice to only implement what the
+// hardware supports.
+func RegisterModel(vid uint16, pid uint16, factory ModelFactory) error {
+	return device.RegisterModel(vid, pid, factory)
+}
+
+// UnregisterModel reverts RegisterModel.
+func UnregisterModel(vid uint16, pid uint16) {
+	device.UnregisterModel(vid, pid)
+}
+
+// NewDevice creates the Device of a glass listed by ListGlasses, of a registered or built-in model.
+func NewDevice(info *GlassInfo) (Device, error) {
+	return device.NewDevice(info)
+}
+
+// ConnectFirstAvailable connects the first attached glass of a built-in or registered model which connects.
+func ConnectFirstAvailable() (Device, error) {
+	return device.ConnectFirstAvailable()
+}
+
+// ListBeams lists the XREAL Beams attached to the host, through which glasses cannot be controlled.
+func ListBeams() ([]*BeamInfo, error) {
+	return device.ListBeams()
+}
+
+// DisconnectAll disconnects every glass connected by this process, e.g. on SIGTERM, as exiting with the cameras
+// connected leaves their kernel drivers detached.
+func DisconnectAll() error {
+	return device.DisconnectAll()
+}
+
+// RepairCameras re-attaches the camera kernel drivers left detached by a process killed with the cameras connected.
+func RepairCameras() error {
+	return device.RepairCameras()
+}
+
+// NewImageEncoder creates an encoder for SLAMFrame.WriteToFolder and SLAMFrame.Store by name, e.g. IMAGE_ENCODER_PNG.
+// IMAGE_ENCODER_TURBOJPEG is only available when built with `-tags turbojpeg`, IMAGE_ENCODER_WEBP with `-tags webp`.
+func NewImageEncoder(name string) (ImageEncoder, error) {
+	return device.NewImageEncoder(name)
+}
+
+// ParseFramePipeline parses comma separated frame processors for Device.SetFramePipeline, e.g.
+// "gamma=2.2,flip=h,rotate=90,crop=0:0:320:240,downscale=2". The processors are also built by GammaCorrection, Flip,
+// Rotate, Crop and Downscale, and custom ones are plain functions.
+func ParseFramePipeline(input string) (FramePipeline, error) {
+	return device.ParseFramePipeline(input)
+}
+
+// NewFrameStatistics computes the exposure statistics of a frame, e.g. after processing it. Frames returned by a
+// Device carry those of the images as captured in SLAMFrame.Statistics.
+func NewFrameStatistics(frame *SLAMFrame) *FrameStatistics {
+	return device.NewFrameStatistics(frame)
+}
+
+// GammaCorrection brightens dark camera images for gamma above 1 and darkens them below.
+func GammaCorrection(gamma float64) (FrameProcessor, error) {
+	return device.GammaCorrection(gamma)
+}
+
+// Flip mirrors camera images horizontally ("h") or vertically ("v").
+func Flip(axis string) (FrameProcessor, error) {
+	return device.Flip(axis)
+}
+
+// Rotate rotates camera images clockwise by 90, 180 or 270 degrees.
+func Rotate(degrees int) (FrameProcessor, error) {
+	return device.Rotate(degrees)
+}
+
+// Crop keeps the part of camera images within rect.
+func Crop(rect image.Rectangle) FrameProcessor {
+	return device.Crop(rect)
+}
+
+// Downscale shrinks camera images by an integer factor.
+func Downscale(factor int) (FrameProcessor, error) {
+	return device.Downscale(factor)
+}
+
+// NewIMUEventBus creates a bus fanning out IMU events to subscribers with their own rate and units,
+// install its Dispatch with Device.SetIMUEventHandler.
+func NewIMUEventBus() *IMUEventBus {
+	return device.NewIMUEventBus()
+}
+
+// FilterEvents returns d delivering the proximity, ambient light and magnetometer events filtered as configured to
+// every handler set through it.
+func FilterEvents(d Device, filters EventFilterConfig) Device {
+	return device.FilterEvents(d, filters)
+}
+
+// ApplyPowerProfile configures brightness, display mode, sleep time, RGB camera and IMU stream
+// of the glass in one call, e.g. POWER_PROFILE_POWER_SAVER when powered by a phone.
+func ApplyPowerProfile(d Device, profile PowerProfile) error {
+	return device.ApplyPowerProfile(d, profile)
+}
+
+// ApplyPowerSettings configures the glass with custom settings, empty fields are left untouched.
+func ApplyPowerSettings(d Device, settings PowerSettings) error {
+	return device.ApplyPowerSettings(d, settings)
+}
+
+// RampBrightness changes the brightness level gradually spread over duration instead of jumping, stepping through
+// display duty values between levels where the glass has a duty and one level at a time otherwise.
+func RampBrightness(d Device, level string, duration time.Duration) error {
+	return device.RampBrightness(d, level, duration)
+}
+
+// FadeDisplayMode switches the display mode while the brightness is faded out and back in over duration.
+func FadeDisplayMode(d Device, mode DisplayMode, duration time.Duration) error {
+	return device.FadeDisplayMode(d, mode, duration)
+}
+
+// ParseBrightnessSchedule parses daily brightness levels as <hh:mm>=<level>, e.g. "07:00=6,22:30=2".
+func ParseBrightnessSchedule(schedule string) (BrightnessSchedule, error) {
+	return device.ParseBrightnessSchedule(schedule)
+}
+
+// RunBrightnessSchedule applies the scheduled brightness levels to the glass, ramping over ramp, until stop is closed.
+func RunBrightnessSchedule(d Device, schedule BrightnessSchedule, ramp time.Duration, stop <-chan struct{}) {
+	device.RunBrightnessSchedule(d, schedule, ramp, stop)
+}
+
+// ParseFirmwareVersion splits a firmware string reported by the glass into the version and the build date.
+func ParseFirmwareVersion(raw string) (*FirmwareVersion, error) {
+	return device.ParseFirmwareVersion(raw)
+}
+
+// ParseDisplayVersion splits a display version string, e.g. "ELLA2_0518_V017", at underscores.
+func ParseDisplayVersion(raw string) (*DisplayVersion, error) {
+	return device.ParseDisplayVersion(raw)
+}
+
+// GetKnownFirmware returns the firmware this driver is tested against, see ErrUntestedFirmware.
+func GetKnownFirmware() []KnownFirmware {
+	return device.GetKnownFirmware()
+}
+
+// ErrorCodeOf returns the stable code of the error of the driver err wraps, e.g. XR-013, empty if none.
+func ErrorCodeOf(err error) ErrorCode {
+	return device.ErrorCodeOf(err)
+}
+
+// IsKnownFirmware tells if the firmware string reported by the glass is tested by this driver.
+func IsKnownFirmware(firmware string) bool {
+	return device.IsKnownFirmware(firmware)
+}
+
+// RunConformance runs every safe get command of the connected glass and checks the responses against the formats
+// expected for its firmware, see `xrealxr verify`.
+func RunConformance(d Device) *ConformanceReport {
+	return device.RunConformance(d)
+}
+
+// DefaultWatchdogPolicy escalates from re-enabling a stalled stream to resetting the OV580 to reconnecting,
+// see Device.SetStreamWatchdog.
+func DefaultWatchdogPolicy() WatchdogPolicy {
+	return device.DefaultWatchdogPolicy()
+}
+
+// DefaultRetryPolicy sends a failed command up to 3 times without waiting, see Device.SetRetryPolicy.
+func DefaultRetryPolicy() RetryPolicy {
+	return device.DefaultRetryPolicy()
+}
+
+// IsRetryable is the default RetryPolicy.Retryable, giving up on errors sending again cannot fix.
+func IsRetryable(err error) bool {
+	return device.IsRetryable(err)
+}
+
+// ParseRecoveryActions parses comma separated recovery actions, e.g. "reenable,reset-sensors,reconnect".
+func ParseRecoveryActions(input string) ([]RecoveryAction, error) {
+	return device.ParseRecoveryActions(input)
+}
+
+// FailedComponents lists the components tagged with ComponentError in an error returned by Connect or Disconnect.
+func FailedComponents(err error) []string {
+	return device.FailedComponents(err)
+}
+
+// GetCommandStats returns the execution counts, latency histograms and timeouts of the commands sent to the glass
+// since start, e.g. to expose them with CommandStats.WritePrometheus.
+func GetCommandStats() CommandStats {
+	return device.GetCommandStats()
+}
+
+// SetCommandTracer calls tracer around every command waiting for a response, e.g. to record OpenTelemetry spans.
+func SetCommandTracer(tracer CommandTracer) {
+	device.SetCommandTracer(tracer)
+}
+
+// SetMountingTransform rotates the IMU and magnetometer events of every glass into the axes of a rig the glass is
+// mounted on, e.g. a helmet, see MountingTransform.
+func SetMountingTransform(rotation [3][3]float64, translation [3]float64) error {
+	return device.SetMountingTransform(rotation, translation)
+}
+
+// GetMountingTransform returns the transform set by SetMountingTransform, the identity by default.
+func GetMountingTransform() MountingTransform {
+	return device.GetMountingTransform()
+}
+
+// SetLightMCUInterface selects the hid interface of the Light MCU opened from now on, for units whose lowest numbered
+// interface does not answer, see GlassInfo.MCUInterfaces. Which interface carries which features is not documented.
+func SetLightMCUInterface(selector HIDInterfaceSelector) {
+	device.SetLightMCUInterface(selector)
+}
+
+// SetRestartOnPanic makes the read loops of the Light start again from scratch once they recovered from a panic,
+// instead of carrying on with the next read. The ErrorEvent tells which they did.
+func SetRestartOnPanic(restart bool) {
+	device.SetRestartOnPanic(restart)
+}
+
+// SetDetachCameraDrivers lets connecting the cameras of the Light detach the kernel driver holding them, usually
+// uvcvideo, until they are disconnected. Off by default.
+func SetDetachCameraDrivers(detach bool) {
+	device.SetDetachCameraDrivers(detach)
+}
+
+// ParseHIDInterfaceSelector parses "auto", or comma separated interface=<number> and usagepage=<hex>.
+func ParseHIDInterfaceSelector(s string) (HIDInterfaceSelector, error) {
+	return device.ParseHIDInterfaceSelector(s)
+}
+
+// RotationFromEuler returns the rotation by roll about X, then pitch about Y, then yaw about Z, in radians.
+func RotationFromEuler(roll float64, pitch float64, yaw float64) [3][3]float64 {
+	return device.RotationFromEuler(roll, pitch, yaw)
+}
+
+// ParseMountingTransform parses "roll,pitch,yaw[,x,y,z]" in degrees and meters, e.g. "0,0,90".
+func ParseMountingTransform(s string) (MountingTransform, error) {
+	return device.ParseMountingTransform(s)
+}
+
+// DefaultProximityGestureConfig counts quick occlusions of the proximity sensor, e.g. hand waves, as gestures.
+func DefaultProximityGestureConfig() ProximityGestureConfig {
+	return device.DefaultProximityGestureConfig()
+}
+
+// NewProximityGestureDetector creates a detector to set as the proximity event handler, reporting gestures to handler.
+func NewProximityGestureDetector(config ProximityGestureConfig, handler func(ProximityGesture)) *ProximityGestureDetector {
+	return device.NewProximityGestureDetector(config, handler)
+}
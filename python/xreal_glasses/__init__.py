@@ -0,0 +1,81 @@
+"""Pythonic client of the glass exposed on the D-Bus session bus by `xrealxr -dbus`.
+
+    from xreal_glasses import Glasses
+
+    with Glasses() as glasses:
+        glasses.brightness = "5"
+        for signal, value in glasses.events("KeyPressed"):
+            print(signal, value)
+
+The low level client in _generated.py is generated from the introspection data of the service by
+`go generate ./dbus`, do not edit it by hand.
+"""
+
+from jeepney import HeaderFields, MatchRule
+from jeepney.bus_messages import message_bus
+from jeepney.io.blocking import Proxy, open_dbus_connection
+
+from ._generated import BUS_NAME, INTERFACE, OBJECT_PATH, SIGNALS, GlassesClient
+
+__all__ = ["BUS_NAME", "INTERFACE", "OBJECT_PATH", "SIGNALS", "Glasses", "GlassesClient"]
+
+
+class Glasses:
+    """The glass of a running `xrealxr -dbus`, on the session bus unless connection is given."""
+
+    def __init__(self, connection=None):
+        self._connection = connection or open_dbus_connection(bus="SESSION")
+        self.client = GlassesClient(self._connection)
+
+    def close(self):
+        self._connection.close()
+
+    def __enter__(self):
+        return self
+
+    def __exit__(self, *exc_info):
+        self.close()
+
+    @property
+    def brightness(self):
+        return self.client.get_brightness()
+
+    @brightness.setter
+    def brightness(self, level):
+        self.client.set_brightness(str(level))
+
+    @property
+    def display_mode(self):
+        """One of SAME_ON_BOTH, HALF_SBS, STEREO or HIGH_REFRESH_RATE."""
+        return self.client.get_display_mode()
+
+    @display_mode.setter
+    def display_mode(self, mode):
+        self.client.set_display_mode(mode)
+
+    @property
+    def wear_status(self):
+        """One of UNKNOWN, WORN or NOT_WORN."""
+        return self.client.get_wear_status()
+
+    def get_config(self, key):
+        return self.client.get_config_value(key)
+
+    def set_config(self, key, value):
+        self.client.set_config_value(key, str(value))
+
+    def events(self, *names):
+        """Yields (signal name, value) of the named signals, all of SIGNALS if none are named, blocking until each."""
+        unknown = set(names) - set(SIGNALS)
+        if unknown:
+            raise ValueError(f"unknown signals {sorted(unknown)}, want some of {sorted(SIGNALS)}")
+        names = set(names or SIGNALS)
+
+        rule = MatchRule(type="signal", interface=INTERFACE, path=OBJECT_PATH)
+        Proxy(message_bus, self._connection).AddMatch(rule)
+        with self._connection.filter(rule) as queue:
+            while True:
+                message = self._connection.recv_until_filtered(queue)
+                name = message.header.fields[HeaderFields.member]
+                if name in names:
+                    yield name, message.body[0]
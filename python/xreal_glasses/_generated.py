@@ -0,0 +1,56 @@
+# Code generated by go generate ./dbus from the introspection data of the D-Bus service; DO NOT EDIT.
+"""Low level client of the org.xreal.Glasses D-Bus interface, see package xreal_glasses for the pythonic one."""
+
+from jeepney import DBusAddress, new_method_call
+from jeepney.wrappers import unwrap_msg
+
+BUS_NAME = "org.xreal.Glasses"
+OBJECT_PATH = "/org/xreal/Glasses"
+INTERFACE = "org.xreal.Glasses"
+
+# signal name -> D-Bus signature of its arguments
+SIGNALS = {
+    "KeyPressed": "s",
+    "ProximityChanged": "s",
+    "AmbientLightChanged": "q",
+}
+
+
+class GlassesClient:
+    """Calls the methods of org.xreal.Glasses over a blocking jeepney connection."""
+
+    def __init__(self, connection):
+        self._connection = connection
+        self._address = DBusAddress(OBJECT_PATH, bus_name=BUS_NAME, interface=INTERFACE)
+
+    def _call(self, method, signature, *args):
+        message = new_method_call(self._address, method, signature, args)
+        return unwrap_msg(self._connection.send_and_get_reply(message))
+
+    def get_brightness(self):
+        """Calls GetBrightness, returns level."""
+        return self._call("GetBrightness", None)[0]
+
+    def set_brightness(self, level):
+        """Calls SetBrightness."""
+        self._call("SetBrightness", "s", level)
+
+    def get_display_mode(self):
+        """Calls GetDisplayMode, returns mode."""
+        return self._call("GetDisplayMode", None)[0]
+
+    def set_display_mode(self, mode):
+        """Calls SetDisplayMode."""
+        self._call("SetDisplayMode", "s", mode)
+
+    def get_config_value(self, key):
+        """Calls GetConfigValue, returns value."""
+        return self._call("GetConfigValue", "s", key)[0]
+
+    def set_config_value(self, key, value):
+        """Calls SetConfigValue."""
+        self._call("SetConfigValue", "ss", key, value)
+
+    def get_wear_status(self):
+        """Calls GetWearStatus, returns status."""
+        return self._call("GetWearStatus", None)[0]
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+	"xreal-light-xr-go/pkg/xreal"
+)
+
+const redacted = "<redacted>"
+
+// reportStreamDuration is how long the IMU stream is sampled for the report, replaced in tests
+var reportStreamDuration = 2 * time.Second
+
+// handleReportCommand writes a zip bundle to attach to bug reports, optionally to the given path.
+func handleReportCommand(d device.Device, input string) {
+	path := strings.TrimSpace(strings.TrimPrefix(input, "report"))
+	if path == "" {
+		path = fmt.Sprintf("xrealxr-report-%s.zip", time.Now().Format("20060102-150405"))
+	}
+
+	if err := writeReport(d, path); err != nil {
+		slog.Error(fmt.Sprintf("failed to write report: %v", err))
+		return
+	}
+	slog.Info(fmt.Sprintf("report written to %s, please review it before attaching to an issue", path))
+}
+
+// writeReport collects what is useful to debug an issue into a zip file. Serial numbers are redacted.
+func writeReport(d device.Device, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+
+	sections := []struct {
+		name    string
+		collect func() string
+	}{
+		{"system.txt", reportSystem},
+		{"glasses.txt", reportGlasses},
+		{"device.txt", func() string { return reportDevice(d) }},
+		{"streams.txt", func() string { return reportStreams(d) }},
+		{"usb.txt", reportUSB},
+		{"trace.txt", reportTrace},
+		{"statistics.txt", reportStatistics},
+	}
+
+	for _, section := range sections {
+		entry, err := w.Create(section.name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", section.name, err)
+		}
+		if _, err := entry.Write([]byte(section.collect())); err != nil {
+			return fmt.Errorf("failed to write %s: %w", section.name, err)
+		}
+	}
+
+	return w.Close()
+}
+
+func reportSystem() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "time: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "xrealxr: %s\n", xreal.Version())
+	fmt.Fprintf(&b, "os/arch: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "go: %s\n", runtime.Version())
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprintf(&b, "module: %s %s\n", info.Main.Path, info.Main.Version)
+		for _, setting := range info.Settings {
+			if strings.HasPrefix(setting.Key, "vcs.") {
+				fmt.Fprintf(&b, "%s: %s\n", setting.Key, setting.Value)
+			}
+		}
+		for _, dep := range info.Deps {
+			fmt.Fprintf(&b, "dep: %s %s\n", dep.Path, dep.Version)
+		}
+	}
+	if kernel, err := os.ReadFile("/proc/version"); err == nil {
+		fmt.Fprintf(&b, "kernel: %s", kernel)
+	}
+	return b.String()
+}
+
+func reportGlasses() string {
+	glasses, err := device.ListGlasses()
+	if err != nil {
+		return fmt.Sprintf("failed to list glasses: %v\n", err)
+	}
+
+	var b strings.Builder
+	for _, info := range glasses {
+		sanitized := *info
+		if sanitized.SerialNumber != "" {
+			sanitized.SerialNumber = redacted
+		}
+		fmt.Fprintf(&b, "%s\n", sanitized.String())
+	}
+
+	beams, err := device.ListBeams()
+	if err != nil {
+		fmt.Fprintf(&b, "failed to list beams: %v\n", err)
+	}
+	for _, info := range beams {
+		sanitized := *info
+		if sanitized.SerialNumber != "" {
+			sanitized.SerialNumber = redacted
+		}
+		fmt.Fprintf(&b, "%s, glasses attached to it are not supported\n", sanitized.String())
+	}
+	return b.String()
+}
+
+func redactConnection(info device.ConnectionInfo) device.ConnectionInfo {
+	if info.SerialNumber != "" {
+		info.SerialNumber = redacted
+	}
+	return info
+}
+
+func reportDevice(d device.Device) string {
+	if d == nil {
+		return "not connected\n"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "name: %s (%04x:%04x)\n", d.Name(), d.VID(), d.PID())
+	if firmware, err := d.GetFirmwareVersion(); err != nil {
+		fmt.Fprintf(&b, "firmware: error %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "firmware: %s\n", firmware)
+	}
+	if firmware, err := d.GetStockFirmwareVersion(); err != nil {
+		fmt.Fprintf(&b, "stock firmware: error %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "stock firmware: %s\n", firmware)
+	}
+	if version, err := d.GetDisplayFirmware(); err != nil {
+		fmt.Fprintf(&b, "display firmware: error %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "display firmware: %s\n", version)
+	}
+	if version, err := d.GetDisplayHDCPVersion(); err != nil {
+		fmt.Fprintf(&b, "display HDCP: error %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "display HDCP: %s\n", version)
+	}
+	if info, err := d.GetOV580Info(); err != nil {
+		fmt.Fprintf(&b, "ov580: error %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "ov580: %s\n", info)
+	}
+	if capabilities, err := d.GetCapabilities(); err != nil {
+		fmt.Fprintf(&b, "capabilities: error %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "capabilities: %s\n", capabilities)
+	}
+	if info, err := d.GetMCUInfo(); err != nil {
+		fmt.Fprintf(&b, "mcu connection: error %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "connection %s\n", redactConnection(*info))
+	}
+	if info, err := d.GetSensorInfo(); err != nil {
+		fmt.Fprintf(&b, "ov580 connection: error %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "connection %s\n", redactConnection(*info))
+	}
+	if cameras, err := d.GetCameraInfo(); err != nil {
+		fmt.Fprintf(&b, "camera connections: error %v\n", err)
+	} else {
+		for _, info := range cameras {
+			fmt.Fprintf(&b, "connection %s\n", redactConnection(info))
+		}
+	}
+	if mode, err := d.GetDisplayMode(); err != nil {
+		fmt.Fprintf(&b, "display mode: error %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "display mode: %s\n", mode)
+	}
+	if level, err := d.GetBrightnessLevel(); err != nil {
+		fmt.Fprintf(&b, "brightness level: error %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "brightness level: %s\n", level)
+	}
+	return b.String()
+}
+
+// reportStreams samples the IMU stream for reportStreamDuration, leaving it enabled or not as it was.
+func reportStreams(d device.Device) string {
+	if d == nil {
+		return "not connected\n"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "imu enabled: %t\n", d.IsIMUEnabled())
+	results, err := benchIMU(d, reportStreamDuration)
+	if err != nil {
+		fmt.Fprintf(&b, "imu over %v: error %v\n", reportStreamDuration, err)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "imu over %v:\n", reportStreamDuration)
+	for _, result := range results {
+		fmt.Fprintf(&b, "%s: %s\n", result.name, result.value)
+	}
+	return b.String()
+}
+
+// reportUSB lists the USB topology with bound drivers from sysfs, which only exists on Linux.
+func reportUSB() string {
+	devices, err := filepath.Glob("/sys/bus/usb/devices/*")
+	if err != nil || len(devices) == 0 {
+		return "USB topology unavailable on this platform\n"
+	}
+
+	readAttribute := func(dir, name string) string {
+		value, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(value))
+	}
+
+	var b strings.Builder
+	for _, dir := range devices {
+		vid := readAttribute(dir, "idVendor")
+		if vid == "" {
+			// an interface, listed below its device
+			continue
+		}
+		fmt.Fprintf(
+			&b, "%s %s:%s %s %s speed=%s\n",
+			filepath.Base(dir), vid, readAttribute(dir, "idProduct"), readAttribute(dir, "manufacturer"), readAttribute(dir, "product"), readAttribute(dir, "speed"),
+		)
+
+		interfaces, _ := filepath.Glob(dir + "/" + filepath.Base(dir) + ":*")
+		for _, intf := range interfaces {
+			driver := "none"
+			if link, err := os.Readlink(filepath.Join(intf, "driver")); err == nil {
+				driver = filepath.Base(link)
+			}
+			fmt.Fprintf(&b, "  %s class=%s driver=%s\n", filepath.Base(intf), readAttribute(intf, "bInterfaceClass"), driver)
+		}
+	}
+	return b.String()
+}
+
+func reportTrace() string {
+	var b strings.Builder
+	for _, entry := range device.GetProtocolTrace() {
+		fmt.Fprintf(&b, "%s\n", entry.String())
+	}
+	return b.String()
+}
+
+func reportStatistics() string {
+	statistics := device.GetProtocolStatistics()
+
+	keys := make([]string, 0, len(statistics))
+	for key := range statistics {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s: %d\n", key, statistics[key])
+	}
+	fmt.Fprintf(&b, "\n%s\n", device.GetCommandStats().String())
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"archive/zip"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+)
+
+func TestWriteReport(t *testing.T) {
+	reportStreamDuration = 200 * time.Millisecond
+	defer func() { reportStreamDuration = 2 * time.Second }()
+
+	d := device.NewSimulatedDevice()
+	if err := d.Connect(); err != nil {
+		t.Fatalf("Connect() = %v", err)
+	}
+	defer d.Disconnect()
+	serial, err := d.GetSerial()
+	if err != nil {
+		t.Fatalf("GetSerial() = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "report.zip")
+	if err := writeReport(d, path); err != nil {
+		t.Fatalf("writeReport() = %v", err)
+	}
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("failed to open the report: %v", err)
+	}
+	defer r.Close()
+	sections := map[string]string{}
+	for _, file := range r.File {
+		entry, err := file.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", file.Name, err)
+		}
+		content, err := io.ReadAll(entry)
+		entry.Close()
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file.Name, err)
+		}
+		sections[file.Name] = string(content)
+	}
+
+	for _, tc := range []struct {
+		section string
+		want    []string
+	}{
+		{"device.txt", []string{"name: ", "firmware: ", "display firmware: ", "ov580: ", "capabilities: firmware "}},
+		{"streams.txt", []string{"imu enabled: false", "samples: ", "host rate: "}},
+	} {
+		content, ok := sections[tc.section]
+		if !ok {
+			t.Errorf("report has no %s", tc.section)
+			continue
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(content, want) {
+				t.Errorf("%s = %q, want it to contain %q", tc.section, content, want)
+			}
+		}
+	}
+	for name, content := range sections {
+		if strings.Contains(content, serial) {
+			t.Errorf("%s contains the serial %s", name, serial)
+		}
+	}
+	if d.IsIMUEnabled() {
+		t.Errorf("IsIMUEnabled() = true after the report, want the stream disabled again")
+	}
+}
@@ -0,0 +1,198 @@
+// Package sbs re-renders a captured host screen as a side-by-side stereo frame for DISPLAY_MODE_HALF_SBS, placing
+// it as a virtual screen of a given size and distance, so the glass works as a minimal standalone AR desktop viewer.
+// Frames come from a Source, e.g. ffmpeg grabbing X11 or GStreamer reading a PipeWire screen cast, and go to a Sink,
+// e.g. a fullscreen player on the output of the glass.
+package sbs
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+)
+
+const (
+	// DEFAULT_FIELD_OF_VIEW is the horizontal field of view of one eye in degrees, derived from the 52 degrees
+	// diagonal advertised for the XREAL Light at 16:9
+	DEFAULT_FIELD_OF_VIEW = 46.0
+	// DEFAULT_EYE_SEPARATION is an average interpupillary distance in meters
+	DEFAULT_EYE_SEPARATION = 0.063
+	// DEFAULT_SCREEN_DISTANCE and DEFAULT_SCREEN_WIDTH in meters make a virtual screen filling about 80% of the view
+	DEFAULT_SCREEN_DISTANCE = 2.0
+	DEFAULT_SCREEN_WIDTH    = 1.36
+
+	// HALF_SBS_WIDTH and HALF_SBS_HEIGHT is the resolution the glass presents in DISPLAY_MODE_HALF_SBS, each eye
+	// gets one half of the frame stretched to full width
+	HALF_SBS_WIDTH  = 1920
+	HALF_SBS_HEIGHT = 1080
+)
+
+// Config places the virtual screen.
+type Config struct {
+	// ScreenDistance is how far the virtual screen is in meters
+	ScreenDistance float64
+	// ScreenWidth is the width of the virtual screen in meters, its height follows the captured aspect ratio
+	ScreenWidth float64
+	// EyeSeparation is the interpupillary distance in meters
+	EyeSeparation float64
+	// FieldOfView is the horizontal field of view of one eye in degrees
+	FieldOfView float64
+	// OutputWidth and OutputHeight is the size of the side-by-side frame
+	OutputWidth  int
+	OutputHeight int
+}
+
+// DefaultConfig returns a Config for DISPLAY_MODE_HALF_SBS of the XREAL Light.
+func DefaultConfig() Config {
+	return Config{
+		ScreenDistance: DEFAULT_SCREEN_DISTANCE,
+		ScreenWidth:    DEFAULT_SCREEN_WIDTH,
+		EyeSeparation:  DEFAULT_EYE_SEPARATION,
+		FieldOfView:    DEFAULT_FIELD_OF_VIEW,
+		OutputWidth:    HALF_SBS_WIDTH,
+		OutputHeight:   HALF_SBS_HEIGHT,
+	}
+}
+
+func (c Config) validate() error {
+	if c.ScreenDistance <= 0 || c.ScreenWidth <= 0 || c.EyeSeparation < 0 {
+		return fmt.Errorf("invalid screen distance %v, width %v or eye separation %v", c.ScreenDistance, c.ScreenWidth, c.EyeSeparation)
+	}
+	if c.FieldOfView <= 0 || c.FieldOfView >= 180 {
+		return fmt.Errorf("invalid field of view %v", c.FieldOfView)
+	}
+	if c.OutputWidth < 2 || c.OutputHeight < 1 {
+		return fmt.Errorf("invalid output size %dx%d", c.OutputWidth, c.OutputHeight)
+	}
+	return nil
+}
+
+// Compositor renders captured frames as side-by-side stereo frames.
+type Compositor struct {
+	config Config
+	output *image.RGBA
+
+	// the layout is cached for the size of the last captured frame
+	sourceSize image.Point
+	// columns maps each column of the output to a column of the source, -1 outside the virtual screen
+	columns []int
+	// rows maps each row of the output to a row of the source, -1 outside the virtual screen
+	rows []int
+	// converted holds frames that are not *image.RGBA
+	converted *image.RGBA
+}
+
+// NewCompositor creates a Compositor for the config, see DefaultConfig.
+func NewCompositor(config Config) (*Compositor, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &Compositor{
+		config: config,
+		output: image.NewRGBA(image.Rect(0, 0, config.OutputWidth, config.OutputHeight)),
+	}, nil
+}
+
+// Layout returns where the virtual screen lands in the left and right half of the output for a captured frame of
+// the given size.
+func (c *Compositor) Layout(source image.Point) (left, right image.Rectangle) {
+	config := c.config
+	eyeWidth := float64(config.OutputWidth / 2)
+	eyeHeight := float64(config.OutputHeight)
+
+	// pixels per meter on the virtual screen plane, assuming each eye has a panel of the output size. The half
+	// frame is stretched to the full width of the panel, so the horizontal scale is half the vertical one.
+	halfExtent := config.ScreenDistance * math.Tan(config.FieldOfView*math.Pi/360)
+	scaleX := eyeWidth / (2 * halfExtent)
+	scaleY := float64(config.OutputWidth) / (2 * halfExtent)
+
+	width := config.ScreenWidth * scaleX
+	height := config.ScreenWidth * float64(source.Y) / float64(max(source.X, 1)) * scaleY
+
+	// the screen centered between the eyes appears shifted inwards for each eye
+	disparity := config.EyeSeparation / 2 * scaleX
+
+	rect := func(centerX float64) image.Rectangle {
+		return image.Rect(
+			int(math.Round(centerX-width/2)), int(math.Round((eyeHeight-height)/2)),
+			int(math.Round(centerX+width/2)), int(math.Round((eyeHeight+height)/2)),
+		)
+	}
+	left = rect(eyeWidth/2 + disparity)
+	right = rect(eyeWidth*3/2 - disparity)
+	return left, right
+}
+
+// Compose renders frame onto the virtual screen for both eyes. The returned image is reused by the next call.
+func (c *Compositor) Compose(frame image.Image) *image.RGBA {
+	source, ok := frame.(*image.RGBA)
+	if !ok {
+		if c.converted == nil || c.converted.Rect.Size() != frame.Bounds().Size() {
+			c.converted = image.NewRGBA(image.Rectangle{Max: frame.Bounds().Size()})
+		}
+		draw.Draw(c.converted, c.converted.Rect, frame, frame.Bounds().Min, draw.Src)
+		source = c.converted
+	}
+	if size := source.Rect.Size(); size != c.sourceSize {
+		c.updateLayout(size)
+	}
+
+	output := c.output
+	for y, sourceY := range c.rows {
+		row := output.Pix[y*output.Stride : y*output.Stride+4*c.config.OutputWidth]
+		if sourceY < 0 {
+			fillBlack(row)
+			continue
+		}
+		sourceRow := source.Pix[sourceY*source.Stride:]
+		for x, sourceX := range c.columns {
+			if sourceX < 0 {
+				copy(row[4*x:4*x+4], black[:])
+				continue
+			}
+			offset := 4 * sourceX
+			copy(row[4*x:4*x+4], sourceRow[offset:offset+4])
+		}
+	}
+	return output
+}
+
+// black is opaque black, which the additive optics of the glass leave see-through.
+var black = [4]byte{0, 0, 0, 0xff}
+
+func fillBlack(row []byte) {
+	for i := 0; i < len(row); i += 4 {
+		copy(row[i:i+4], black[:])
+	}
+}
+
+// updateLayout maps the output pixels to the source pixels, relative to the bounds of the source, with nearest
+// neighbour sampling.
+func (c *Compositor) updateLayout(size image.Point) {
+	c.sourceSize = size
+	left, right := c.Layout(size)
+	eyeWidth := c.config.OutputWidth / 2
+
+	c.columns = make([]int, c.config.OutputWidth)
+	for x := range c.columns {
+		c.columns[x] = -1
+		// each eye only shows its own half, a screen sticking out of it is cut off
+		rect, lo, hi := left, 0, eyeWidth
+		if x >= eyeWidth {
+			rect, lo, hi = right, eyeWidth, c.config.OutputWidth
+		}
+		if x < lo || x >= hi || x < rect.Min.X || x >= rect.Max.X {
+			continue
+		}
+		c.columns[x] = (x - rect.Min.X) * size.X / rect.Dx()
+	}
+
+	c.rows = make([]int, c.config.OutputHeight)
+	for y := range c.rows {
+		c.rows[y] = -1
+		if y < left.Min.Y || y >= left.Max.Y {
+			continue
+		}
+		c.rows[y] = (y - left.Min.Y) * size.Y / left.Dy()
+	}
+}
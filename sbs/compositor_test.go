@@ -0,0 +1,76 @@
+package sbs_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"xreal-light-xr-go/sbs"
+)
+
+func TestCompositorLayout(t *testing.T) {
+	config := sbs.DefaultConfig()
+	compositor, err := sbs.NewCompositor(config)
+	if err != nil {
+		t.Fatalf("NewCompositor() = %v", err)
+	}
+
+	left, right := compositor.Layout(image.Pt(1920, 1080))
+	if left.Dx() != right.Dx() || left.Dy() != right.Dy() {
+		t.Fatalf("Layout() = %v, %v; want equal sizes", left, right)
+	}
+	// both halves are stretched to full width, so the squeezed screen keeps the 16:9 of the capture
+	if aspect := float64(2*left.Dx()) / float64(left.Dy()); aspect < 1.7 || aspect > 1.85 {
+		t.Errorf("aspect ratio on the panel = %f, want 16:9", aspect)
+	}
+	// the screen is shifted inwards for each eye
+	if left.Min.X+left.Max.X <= config.OutputWidth/2 || right.Min.X+right.Max.X >= 3*config.OutputWidth/2 {
+		t.Errorf("Layout() = %v, %v; want both shifted towards the center", left, right)
+	}
+
+	config.ScreenDistance *= 2
+	far, err := sbs.NewCompositor(config)
+	if err != nil {
+		t.Fatalf("NewCompositor() = %v", err)
+	}
+	if farLeft, _ := far.Layout(image.Pt(1920, 1080)); farLeft.Dx() >= left.Dx() {
+		t.Errorf("width at twice the distance = %d, want less than %d", farLeft.Dx(), left.Dx())
+	}
+
+	if _, err := sbs.NewCompositor(sbs.Config{}); err == nil {
+		t.Errorf("NewCompositor(Config{}) = nil, want error")
+	}
+}
+
+func TestCompositorCompose(t *testing.T) {
+	compositor, err := sbs.NewCompositor(sbs.Config{ScreenDistance: 1, ScreenWidth: 0.5, FieldOfView: 90, OutputWidth: 40, OutputHeight: 10})
+	if err != nil {
+		t.Fatalf("NewCompositor() = %v", err)
+	}
+
+	// raw frames of a white screen
+	raw := bytes.Repeat([]byte{0xff}, 2*4*4*2)
+	source := sbs.NewRawVideoSource(bytes.NewReader(raw), 4, 2)
+	for i := 0; i < 2; i++ {
+		frame, err := source.Frame()
+		if err != nil {
+			t.Fatalf("Frame() = %v", err)
+		}
+		output := compositor.Compose(frame)
+
+		left, right := compositor.Layout(image.Pt(4, 2))
+		for _, rect := range []image.Rectangle{left, right} {
+			center := image.Pt((rect.Min.X+rect.Max.X)/2, (rect.Min.Y+rect.Max.Y)/2)
+			if got := output.RGBAAt(center.X, center.Y); got != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+				t.Errorf("pixel at %v = %v, want white", center, got)
+			}
+		}
+		if got := output.RGBAAt(0, 0); got != (color.RGBA{0, 0, 0, 0xff}) {
+			t.Errorf("pixel outside the screen = %v, want black", got)
+		}
+	}
+	if _, err := source.Frame(); err == nil {
+		t.Errorf("Frame() after the last frame = nil, want error")
+	}
+}
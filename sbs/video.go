@@ -0,0 +1,154 @@
+package sbs
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// Source delivers captured frames of the host screen.
+type Source interface {
+	// Frame blocks until the next frame, which is reused by the next call.
+	Frame() (*image.RGBA, error)
+	Close() error
+}
+
+// Sink shows side-by-side frames on the glass.
+type Sink interface {
+	Write(frame *image.RGBA) error
+	Close() error
+}
+
+// RawVideoSource reads raw RGBA frames of a fixed size, e.g. the output of ffmpeg with -f rawvideo -pix_fmt rgba.
+type RawVideoSource struct {
+	reader io.Reader
+	frame  *image.RGBA
+	// cmd is the capturing process, if started by NewCommandSource
+	cmd *exec.Cmd
+}
+
+// NewRawVideoSource reads width x height RGBA frames from reader.
+func NewRawVideoSource(reader io.Reader, width, height int) *RawVideoSource {
+	return &RawVideoSource{reader: reader, frame: image.NewRGBA(image.Rect(0, 0, width, height))}
+}
+
+// NewCommandSource starts a command writing raw width x height RGBA frames to its stdout, see X11GrabCommand and
+// PipeWireCommand.
+func NewCommandSource(width, height int, name string, args ...string) (*RawVideoSource, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to pipe from %s: %w", name, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", name, err)
+	}
+	source := NewRawVideoSource(stdout, width, height)
+	source.cmd = cmd
+	return source, nil
+}
+
+func (s *RawVideoSource) Frame() (*image.RGBA, error) {
+	if _, err := io.ReadFull(s.reader, s.frame.Pix); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("truncated frame, check the capture size %v: %w", s.frame.Rect.Size(), err)
+		}
+		return nil, fmt.Errorf("failed to read frame: %w", err)
+	}
+	return s.frame, nil
+}
+
+// Close stops the capturing process if any.
+func (s *RawVideoSource) Close() error {
+	if s.cmd == nil {
+		return nil
+	}
+	s.cmd.Process.Kill()
+	// killed on purpose, the exit status is not interesting
+	s.cmd.Wait()
+	return nil
+}
+
+// RawVideoSink writes frames as raw RGBA, e.g. to the stdin of ffplay with -f rawvideo -pixel_format rgba.
+type RawVideoSink struct {
+	writer io.Writer
+	// cmd is the showing process, if started by NewCommandSink
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// NewRawVideoSink writes frames to writer.
+func NewRawVideoSink(writer io.Writer) *RawVideoSink {
+	return &RawVideoSink{writer: writer}
+}
+
+// NewCommandSink starts a command reading raw RGBA frames from its stdin, see PlayerCommand.
+func NewCommandSink(name string, args ...string) (*RawVideoSink, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to pipe to %s: %w", name, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", name, err)
+	}
+	return &RawVideoSink{writer: stdin, cmd: cmd, stdin: stdin}, nil
+}
+
+func (s *RawVideoSink) Write(frame *image.RGBA) error {
+	if _, err := s.writer.Write(frame.Pix); err != nil {
+		return fmt.Errorf("failed to write frame: %w", err)
+	}
+	return nil
+}
+
+// Close ends the stream and waits for the showing process if any.
+func (s *RawVideoSink) Close() error {
+	if s.cmd == nil {
+		return nil
+	}
+	s.stdin.Close()
+	if err := s.cmd.Wait(); err != nil {
+		return fmt.Errorf("failed to wait for %s: %w", s.cmd.Path, err)
+	}
+	return nil
+}
+
+// X11GrabCommand returns an ffmpeg command line grabbing width x height of an X11 display, e.g. ":0", as raw
+// RGBA frames for NewCommandSource.
+func X11GrabCommand(display string, width, height, rate int) (string, []string) {
+	return "ffmpeg", []string{
+		"-loglevel", "error",
+		"-f", "x11grab", "-framerate", strconv.Itoa(rate), "-video_size", fmt.Sprintf("%dx%d", width, height),
+		"-i", display,
+		"-f", "rawvideo", "-pix_fmt", "rgba", "-",
+	}
+}
+
+// PipeWireCommand returns a GStreamer command line reading a PipeWire screen cast node, e.g. one shared through
+// the xdg-desktop-portal ScreenCast interface, scaled to width x height raw RGBA frames for NewCommandSource.
+func PipeWireCommand(node string, width, height int) (string, []string) {
+	return "gst-launch-1.0", []string{
+		"-q",
+		"pipewiresrc", "path=" + node, "do-timestamp=true", "!",
+		"videoconvert", "!", "videoscale", "!",
+		fmt.Sprintf("video/x-raw,format=RGBA,width=%d,height=%d", width, height), "!",
+		"fdsink", "fd=1",
+	}
+}
+
+// PlayerCommand returns an ffplay command line showing width x height raw RGBA frames fullscreen, move it to the
+// output of the glass with the window manager.
+func PlayerCommand(width, height int) (string, []string) {
+	return "ffplay", []string{
+		"-loglevel", "error",
+		"-f", "rawvideo", "-pixel_format", "rgba", "-video_size", fmt.Sprintf("%dx%d", width, height),
+		"-fs", "-fflags", "nobuffer", "-",
+	}
+}
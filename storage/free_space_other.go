@@ -0,0 +1,12 @@
+//go:build !linux
+
+package storage
+
+import (
+	"errors"
+)
+
+// freeSpace fails as querying the free space is only supported on Linux yet.
+func freeSpace(dir string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3 uploads files to an S3 bucket, or an S3 compatible one like MinIO, signing the requests with AWS Signature
+// Version 4.
+type S3 struct {
+	Bucket string
+	// Prefix is prepended to the file names, e.g. "captures"
+	Prefix string
+	Region string
+	// Endpoint is e.g. http://minio.local:9000 for S3 compatible storage, which is addressed path-style, empty for
+	// AWS which is addressed virtual-hosted-style
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is only set for temporary credentials
+	SessionToken string
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewS3FromEnv creates the storage for a bucket taking the settings from the environment variables the AWS tools
+// use: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION (us-east-1 by default) and
+// AWS_ENDPOINT_URL for S3 compatible storage.
+func NewS3FromEnv(bucket string, prefix string) (*S3, error) {
+	s := &S3{
+		Bucket:          bucket,
+		Prefix:          prefix,
+		Region:          os.Getenv("AWS_REGION"),
+		Endpoint:        os.Getenv("AWS_ENDPOINT_URL"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if s.Region == "" {
+		s.Region = "us-east-1"
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("want s3://bucket/prefix, got no bucket")
+	}
+	if s.AccessKeyID == "" || s.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set for s3://%s", bucket)
+	}
+	return s, nil
+}
+
+func (s *S3) Create(name string) (io.WriteCloser, error) {
+	return newBufferedWriter(func(content []byte) error {
+		return s.put(name, content)
+	}), nil
+}
+
+func (s *S3) Location(name string) string {
+	return fmt.Sprintf("s3://%s/%s", s.Bucket, s.key(name))
+}
+
+func (s *S3) key(name string) string {
+	return strings.TrimPrefix(path.Join(s.Prefix, name), "/")
+}
+
+// objectURL addresses the object path-style for custom endpoints and virtual-hosted-style on AWS.
+func (s *S3) objectURL(name string) (*url.URL, error) {
+	if s.Endpoint == "" {
+		return url.Parse(fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.Bucket, s.Region, uriEncode(s.key(name))))
+	}
+	return url.Parse(fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(s.Endpoint, "/"), s.Bucket, uriEncode(s.key(name))))
+}
+
+func (s *S3) put(name string, content []byte) error {
+	u, err := s.objectURL(name)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", s.Location(name), err)
+	}
+	request, err := http.NewRequest(http.MethodPut, u.String(), bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", s.Location(name), err)
+	}
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	headers := map[string]string{
+		"content-type":         contentType(name),
+		"x-amz-content-sha256": sha256Hex(content),
+		"x-amz-date":           now().UTC().Format("20060102T150405Z"),
+	}
+	if s.SessionToken != "" {
+		headers["x-amz-security-token"] = s.SessionToken
+	}
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	request.Header.Set("Authorization", s.sign(http.MethodPut, u.Host, u.EscapedPath(), headers))
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", s.Location(name), err)
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("failed to upload %s: %s %s", s.Location(name), response.Status, body)
+	}
+	return nil
+}
+
+// sign returns the Authorization header of a request without query, headers must be lower case and include
+// x-amz-date and x-amz-content-sha256, which is also the hash of the payload.
+func (s *S3) sign(method string, host string, escapedPath string, headers map[string]string) string {
+	signed := map[string]string{"host": host}
+	for name, value := range headers {
+		signed[name] = strings.TrimSpace(value)
+	}
+	names := make([]string, 0, len(signed))
+	for name := range signed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, signed[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		escapedPath,
+		"", // no query
+		canonicalHeaders.String(),
+		signedHeaders,
+		headers["x-amz-content-sha256"],
+	}, "\n")
+
+	timestamp := headers["x-amz-date"]
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", timestamp[:8], s.Region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		timestamp,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), timestamp[:8])
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	return fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.AccessKeyID, scope, signedHeaders, signature)
+}
+
+// uriEncode escapes an object key as S3 expects it, every byte except unreserved characters and slashes.
+func uriEncode(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, content string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(content))
+	return mac.Sum(nil)
+}
+
+// contentType guesses the type of a file by its extension for uploads.
+func contentType(name string) string {
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,206 @@
+// Package storage stores captured images and recordings, on the local file system by default or directly on
+// network storage, e.g. from a headless device without space for them.
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Storage stores files by name, which may contain slashes for sub folders.
+type Storage interface {
+	// Create opens name for writing, the file is only complete once the writer is closed without error
+	Create(name string) (io.WriteCloser, error)
+	// Location tells where name is stored, e.g. a path or URL, as reported to the user
+	Location(name string) string
+}
+
+// Remover is implemented by storages which can delete files, e.g. to rotate captures.
+type Remover interface {
+	Remove(name string) error
+}
+
+// SpaceReporter is implemented by storages which can tell how much space is left, e.g. to stop capturing before a
+// small disk fills up.
+type SpaceReporter interface {
+	// FreeSpace returns the bytes available to this process, errors.ErrUnsupported if the platform cannot tell
+	FreeSpace() (uint64, error)
+}
+
+// Local stores files in a folder of the local file system.
+type Local struct {
+	dir string
+}
+
+// NewLocal stores files in dir, which must exist.
+func NewLocal(dir string) *Local {
+	return &Local{dir: dir}
+}
+
+func (s *Local) Create(name string) (io.WriteCloser, error) {
+	if err := os.MkdirAll(filepath.Dir(s.Location(name)), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create folder of %s: %w", s.Location(name), err)
+	}
+	f, err := os.Create(s.Location(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file %s: %w", s.Location(name), err)
+	}
+	return f, nil
+}
+
+func (s *Local) Location(name string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(name))
+}
+
+func (s *Local) Remove(name string) error {
+	if err := os.Remove(s.Location(name)); err != nil {
+		return fmt.Errorf("failed to remove file %s: %w", s.Location(name), err)
+	}
+	return nil
+}
+
+func (s *Local) FreeSpace() (uint64, error) {
+	return freeSpace(s.dir)
+}
+
+// Memory keeps files in memory, e.g. to process captures without touching the disk.
+type Memory struct {
+	// mutex for thread safety
+	mutex sync.Mutex
+	files map[string][]byte
+}
+
+func NewMemory() *Memory {
+	return &Memory{files: map[string][]byte{}}
+}
+
+func (s *Memory) Create(name string) (io.WriteCloser, error) {
+	return newBufferedWriter(func(content []byte) error {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.files[name] = content
+		return nil
+	}), nil
+}
+
+func (s *Memory) Location(name string) string {
+	return "mem://" + name
+}
+
+func (s *Memory) Remove(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.files[name]; !ok {
+		return fmt.Errorf("failed to remove %s: %w", s.Location(name), os.ErrNotExist)
+	}
+	delete(s.files, name)
+	return nil
+}
+
+// Get returns the content of name, false if it was not stored.
+func (s *Memory) Get(name string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	content, ok := s.files[name]
+	return content, ok
+}
+
+// Names lists the stored files in order.
+func (s *Memory) Names() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var names []string
+	for name := range s.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// bufferedWriter collects a file and hands it over on Close, for storages uploading it in one request.
+type bufferedWriter struct {
+	bytes.Buffer
+	store  func(content []byte) error
+	closed bool
+}
+
+func newBufferedWriter(store func(content []byte) error) *bufferedWriter {
+	return &bufferedWriter{store: store}
+}
+
+func (w *bufferedWriter) Close() error {
+	if w.closed {
+		return fmt.Errorf("already closed")
+	}
+	w.closed = true
+	return w.store(w.Bytes())
+}
+
+// Open creates the storage for a location: a local folder, `mem://`, `webdav://host/path` (`webdavs://` for
+// https) or `s3://bucket/prefix`, see NewS3FromEnv for the S3 settings. User info in WebDAV URLs is used for basic
+// authentication.
+func Open(location string) (Storage, error) {
+	if !strings.Contains(location, "://") {
+		if !isDir(location) {
+			return nil, fmt.Errorf("want an existing folder, got %s", location)
+		}
+		return NewLocal(location), nil
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage location %s: %w", location, err)
+	}
+	switch u.Scheme {
+	case "file":
+		return Open(u.Path)
+	case "mem":
+		return NewMemory(), nil
+	case "webdav", "webdavs":
+		return NewWebDAVFromURL(u), nil
+	case "s3":
+		return NewS3FromEnv(u.Host, strings.Trim(u.Path, "/"))
+	default:
+		return nil, fmt.Errorf("unsupported storage %s: want a folder, mem://, webdav://, webdavs:// or s3://", u.Scheme)
+	}
+}
+
+// Create opens the file at a location given as the location of a storage plus the file name, e.g.
+// `s3://bucket/reports/latency.txt`, and returns where it is stored.
+func Create(location string) (io.WriteCloser, string, error) {
+	var dir, name string
+	if strings.Contains(location, "://") {
+		dir, name = path.Split(location)
+	} else {
+		dir, name = filepath.Split(location)
+	}
+	if name == "" {
+		return nil, "", fmt.Errorf("want a file name, got %s", location)
+	}
+	if dir == "" {
+		dir = "."
+	}
+
+	store, err := Open(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	w, err := store.Create(name)
+	if err != nil {
+		return nil, "", err
+	}
+	return w, store.Location(name), nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WebDAV uploads files to a WebDAV server with PUT requests, so it also works with plain HTTP servers accepting
+// uploads. The folders must exist.
+type WebDAV struct {
+	// BaseURL is the folder to store the files in, e.g. https://nas.local/captures
+	BaseURL string
+	// Username and Password are sent with basic authentication if Username is set
+	Username string
+	Password string
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+}
+
+// NewWebDAVFromURL creates the storage for a webdav:// or webdavs:// URL, taking the credentials from its user info.
+func NewWebDAVFromURL(u *url.URL) *WebDAV {
+	base := *u
+	base.Scheme = "http"
+	if u.Scheme == "webdavs" {
+		base.Scheme = "https"
+	}
+	base.User = nil
+
+	s := &WebDAV{BaseURL: base.String()}
+	if u.User != nil {
+		s.Username = u.User.Username()
+		s.Password, _ = u.User.Password()
+	}
+	return s
+}
+
+func (s *WebDAV) Create(name string) (io.WriteCloser, error) {
+	return newBufferedWriter(func(content []byte) error {
+		return s.put(name, content)
+	}), nil
+}
+
+func (s *WebDAV) Location(name string) string {
+	return strings.TrimSuffix(s.BaseURL, "/") + "/" + strings.TrimPrefix(name, "/")
+}
+
+func (s *WebDAV) put(name string, content []byte) error {
+	request, err := http.NewRequest(http.MethodPut, s.Location(name), bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", s.Location(name), err)
+	}
+	if s.Username != "" {
+		request.SetBasicAuth(s.Username, s.Password)
+	}
+	request.Header.Set("Content-Type", contentType(name))
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", s.Location(name), err)
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("failed to upload %s: %s", s.Location(name), response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+// Package telemetry instruments the driver with OpenTelemetry, so services embedding it see glass operations in
+// their existing observability stack. It is opt-in: nothing is recorded unless New is called, and the tracer and
+// meter providers default to the global ones set up by the application, e.g. with otel.SetTracerProvider.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// INSTRUMENTATION_NAME names the tracer and meter of this package.
+const INSTRUMENTATION_NAME = "xreal-light-xr-go"
+
+// Attribute keys set on spans and metrics.
+const (
+	ATTRIBUTE_COMPONENT = attribute.Key("xreal.component")
+	ATTRIBUTE_COMMAND   = attribute.Key("xreal.command")
+	ATTRIBUTE_EVENT     = attribute.Key("xreal.event")
+	ATTRIBUTE_FAILED    = attribute.Key("xreal.failed")
+)
+
+// Config selects where spans and metrics go, nil providers use the global ones.
+type Config struct {
+	TracerProvider trace.TracerProvider
+	MeterProvider  metric.MeterProvider
+}
+
+// Telemetry records spans and metrics of commands, connections and events.
+type Telemetry struct {
+	tracer trace.Tracer
+
+	commandDuration    metric.Float64Histogram
+	connectionDuration metric.Float64Histogram
+	events             metric.Int64Counter
+	errors             metric.Int64Counter
+}
+
+// New creates the instruments and traces every command sent to a glass waiting for a response, of all devices of
+// the process, see device.SetCommandTracer. Connections and events are recorded for devices passed to Wrap.
+func New(config Config) (*Telemetry, error) {
+	tracerProvider := config.TracerProvider
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+	meterProvider := config.MeterProvider
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+	meter := meterProvider.Meter(INSTRUMENTATION_NAME)
+
+	t := &Telemetry{tracer: tracerProvider.Tracer(INSTRUMENTATION_NAME)}
+	var err, joined error
+	t.commandDuration, err = meter.Float64Histogram("xreal.command.duration", metric.WithUnit("s"), metric.WithDescription("Time until a command sent to the glass got its response or failed."))
+	joined = errors.Join(joined, err)
+	t.connectionDuration, err = meter.Float64Histogram("xreal.connection.duration", metric.WithUnit("s"), metric.WithDescription("Time to connect or disconnect the glass."))
+	joined = errors.Join(joined, err)
+	t.events, err = meter.Int64Counter("xreal.events", metric.WithDescription("Events received from the glass."))
+	joined = errors.Join(joined, err)
+	t.errors, err = meter.Int64Counter("xreal.errors", metric.WithDescription("Errors reported by the background goroutines of the driver."))
+	joined = errors.Join(joined, err)
+	if joined != nil {
+		return nil, fmt.Errorf("failed to create instruments: %w", joined)
+	}
+
+	device.SetCommandTracer(t.traceCommand)
+	return t, nil
+}
+
+// Close stops tracing commands.
+func (t *Telemetry) Close() {
+	device.SetCommandTracer(nil)
+}
+
+func (t *Telemetry) traceCommand(component string, command string) func(err error) {
+	attributes := []attribute.KeyValue{ATTRIBUTE_COMPONENT.String(component), ATTRIBUTE_COMMAND.String(command)}
+	ctx, span := t.tracer.Start(context.Background(), "xreal.command", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+	started := time.Now()
+
+	return func(err error) {
+		t.commandDuration.Record(ctx, time.Since(started).Seconds(), metric.WithAttributes(append(attributes, ATTRIBUTE_FAILED.Bool(err != nil))...))
+		endSpan(span, err)
+	}
+}
+
+// Wrap returns d recording spans of Connect and Disconnect and counting the events passed to the handlers set
+// through the returned Device. Errors are counted once an ErrorHandler is set through it.
+func (t *Telemetry) Wrap(d device.Device) device.Device {
+	return &instrumentedDevice{Device: d, telemetry: t}
+}
+
+// instrumentedDevice overrides the methods of the wrapped Device worth recording.
+type instrumentedDevice struct {
+	device.Device
+	telemetry *Telemetry
+	// imuHandler is the handler set through the wrapper, without counting
+	imuHandler device.IMUEventHandler
+}
+
+func (d *instrumentedDevice) Connect() error {
+	return d.telemetry.traceConnection("xreal.connect", d.Device.Connect)
+}
+
+func (d *instrumentedDevice) Disconnect() error {
+	return d.telemetry.traceConnection("xreal.disconnect", d.Device.Disconnect)
+}
+
+func (t *Telemetry) traceConnection(name string, operation func() error) error {
+	ctx, span := t.tracer.Start(context.Background(), name)
+	started := time.Now()
+	err := operation()
+	for _, component := range device.FailedComponents(err) {
+		span.AddEvent("component failed", trace.WithAttributes(ATTRIBUTE_COMPONENT.String(component)))
+	}
+	t.connectionDuration.Record(ctx, time.Since(started).Seconds(), metric.WithAttributes(attribute.String("xreal.operation", name), ATTRIBUTE_FAILED.Bool(err != nil)))
+	endSpan(span, err)
+	return err
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// countEvent counts an event of the given kind, it runs on the read loops of the driver so it must not block.
+func (t *Telemetry) countEvent(kind string) {
+	t.events.Add(context.Background(), 1, metric.WithAttributes(ATTRIBUTE_EVENT.String(kind)))
+}
+
+func (d *instrumentedDevice) SetAmbientLightEventHandler(handler device.AmbientLightEventHandler) {
+	if handler == nil {
+		d.Device.SetAmbientLightEventHandler(nil)
+		return
+	}
+	d.Device.SetAmbientLightEventHandler(func(value uint16) {
+		d.telemetry.countEvent("ambient_light")
+		handler(value)
+	})
+}
+
+func (d *instrumentedDevice) SetKeyEventHandler(handler device.KeyEventHandler) {
+	if handler == nil {
+		d.Device.SetKeyEventHandler(nil)
+		return
+	}
+	d.Device.SetKeyEventHandler(func(key device.KeyEvent) {
+		d.telemetry.countEvent("key")
+		handler(key)
+	})
+}
+
+func (d *instrumentedDevice) SetMagnetometerEventHandler(handler device.MagnetometerEventHandler) {
+	if handler == nil {
+		d.Device.SetMagnetometerEventHandler(nil)
+		return
+	}
+	d.Device.SetMagnetometerEventHandler(func(vector *device.MagnetometerVector) {
+		d.telemetry.countEvent("magnetometer")
+		handler(vector)
+	})
+}
+
+func (d *instrumentedDevice) SetProximityEventHandler(handler device.ProximityEventHandler) {
+	if handler == nil {
+		d.Device.SetProximityEventHandler(nil)
+		return
+	}
+	d.Device.SetProximityEventHandler(func(proximity device.ProximityEvent) {
+		d.telemetry.countEvent("proximity")
+		handler(proximity)
+	})
+}
+
+func (d *instrumentedDevice) SetTemperatureEventHandler(handler device.TemperatureEventHandlder) {
+	if handler == nil {
+		d.Device.SetTemperatureEventHandler(nil)
+		return
+	}
+	d.Device.SetTemperatureEventHandler(func(value string) {
+		d.telemetry.countEvent("temperature")
+		handler(value)
+	})
+}
+
+func (d *instrumentedDevice) SetVSyncEventHandler(handler device.VSyncEventHandler) {
+	if handler == nil {
+		d.Device.SetVSyncEventHandler(nil)
+		return
+	}
+	d.Device.SetVSyncEventHandler(func(value string) {
+		d.telemetry.countEvent("vsync")
+		handler(value)
+	})
+}
+
+func (d *instrumentedDevice) SetIMUEventHandler(handler device.IMUEventHandler) {
+	d.imuHandler = handler
+	if handler == nil {
+		d.Device.SetIMUEventHandler(nil)
+		return
+	}
+	d.Device.SetIMUEventHandler(func(imu *device.IMUEvent) {
+		d.telemetry.countEvent("imu")
+		handler(imu)
+	})
+}
+
+func (d *instrumentedDevice) GetIMUEventHandler() device.IMUEventHandler {
+	if d.imuHandler == nil {
+		// not set through the wrapper yet, e.g. the default handler of the glass
+		return d.Device.GetIMUEventHandler()
+	}
+	return d.imuHandler
+}
+
+func (d *instrumentedDevice) SetResumedEventHandler(handler device.ResumedEventHandler) {
+	if handler == nil {
+		d.Device.SetResumedEventHandler(nil)
+		return
+	}
+	d.Device.SetResumedEventHandler(func() {
+		d.telemetry.countEvent("resumed")
+		handler()
+	})
+}
+
+func (d *instrumentedDevice) SetErrorHandler(handler device.ErrorHandler) {
+	if handler == nil {
+		d.Device.SetErrorHandler(nil)
+		return
+	}
+	d.Device.SetErrorHandler(func(err error) {
+		d.telemetry.errors.Add(context.Background(), 1, metric.WithAttributes(attribute.String("xreal.error", errorKind(err))))
+		handler(err)
+	})
+}
+
+// errorKinds name the sentinel errors reported to an ErrorHandler, to keep the cardinality of the error counter low.
+var errorKinds = []struct {
+	kind     string
+	sentinel error
+}{
+	{"panic", device.ErrPanic},
+	{"read_failed", device.ErrReadFailed},
+	{"deserialize_failed", device.ErrDeserializeFailed},
+	{"heart_beat_lost", device.ErrHeartBeatLost},
+	{"stream_stalled", device.ErrStreamStalled},
+	{"untested_firmware", device.ErrUntestedFirmware},
+}
+
+func errorKind(err error) string {
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind.sentinel) {
+			return kind.kind
+		}
+	}
+	return "other"
+}
@@ -0,0 +1,91 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"xreal-light-xr-go/internal/device"
+
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type recordingSpan struct {
+	noop.Span
+	name  string
+	err   error
+	ended bool
+}
+
+func (s *recordingSpan) RecordError(err error, _ ...trace.EventOption) { s.err = err }
+func (s *recordingSpan) End(...trace.SpanEndOption)                    { s.ended = true }
+
+type recordingTracer struct {
+	noop.Tracer
+	spans *[]*recordingSpan
+}
+
+func (t recordingTracer) Start(ctx context.Context, name string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := &recordingSpan{name: name}
+	*t.spans = append(*t.spans, span)
+	return ctx, span
+}
+
+type recordingTracerProvider struct {
+	noop.TracerProvider
+	spans []*recordingSpan
+}
+
+func (p *recordingTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return recordingTracer{spans: &p.spans}
+}
+
+// fakeDevice only implements what the test calls, the embedded nil Device panics on anything else.
+type fakeDevice struct {
+	device.Device
+	imuHandler device.IMUEventHandler
+}
+
+func (d *fakeDevice) Connect() error {
+	return errors.New("no glass attached")
+}
+
+func (d *fakeDevice) SetIMUEventHandler(handler device.IMUEventHandler) {
+	d.imuHandler = handler
+}
+
+func TestTelemetry(t *testing.T) {
+	provider := &recordingTracerProvider{}
+	telemetry, err := New(Config{TracerProvider: provider, MeterProvider: metricnoop.NewMeterProvider()})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	defer telemetry.Close()
+
+	telemetry.traceCommand(device.COMPONENT_MCU, "get brightness level")(nil)
+
+	fake := &fakeDevice{}
+	d := telemetry.Wrap(fake)
+	if err := d.Connect(); err == nil {
+		t.Errorf("Connect() = nil, want the error of the device")
+	}
+
+	received := 0
+	d.SetIMUEventHandler(func(*device.IMUEvent) { received++ })
+	fake.imuHandler(&device.IMUEvent{})
+	if received != 1 {
+		t.Errorf("IMU events received = %d, want 1", received)
+	}
+
+	if len(provider.spans) != 2 {
+		t.Fatalf("spans = %d, want 2", len(provider.spans))
+	}
+	if span := provider.spans[0]; span.name != "xreal.command" || span.err != nil || !span.ended {
+		t.Errorf("command span = %+v, want an ended xreal.command span", span)
+	}
+	if span := provider.spans[1]; span.name != "xreal.connect" || span.err == nil || !span.ended {
+		t.Errorf("connect span = %+v, want an ended xreal.connect span with the error", span)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"xreal-light-xr-go/internal/device"
+	"xreal-light-xr-go/pkg/xreal"
+)
+
+// handleVerifyCommand runs the conformance checks against the connected glass and writes the report, optionally
+// to the given path. It returns false if any check failed.
+func handleVerifyCommand(d device.Device, input string) bool {
+	path := strings.TrimSpace(strings.TrimPrefix(input, "verify"))
+	if path == "" {
+		path = fmt.Sprintf("xrealxr-verify-%s.txt", time.Now().Format("20060102-150405"))
+	}
+
+	report := device.RunConformance(d)
+
+	// the report is meant to be shared, so the serial number is redacted like in bug reports
+	for i := range report.Checks {
+		if report.Checks[i].Name == "serial" && report.Checks[i].Value != "" {
+			report.Checks[i].Value = redacted
+		}
+	}
+
+	content := fmt.Sprintf("xrealxr: %s (%s build)\n%s", xreal.Version(), xreal.GetBuildMode(), report.String())
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		slog.Error(fmt.Sprintf("failed to write conformance report: %v", err))
+		return false
+	}
+
+	summary := fmt.Sprintf(
+		"conformance: %d pass, %d fail, %d unsupported, %d recorded; report written to %s",
+		report.Count(device.CONFORMANCE_PASS), report.Count(device.CONFORMANCE_FAIL),
+		report.Count(device.CONFORMANCE_UNSUPPORTED), report.Count(device.CONFORMANCE_RECORDED), path,
+	)
+	if !report.Passed() {
+		slog.Warn(summary)
+		return false
+	}
+	if !report.KnownFirmware {
+		slog.Info(fmt.Sprintf("%s, your firmware is untested by this driver, please consider sharing the report", summary))
+		return true
+	}
+	slog.Info(summary)
+	return true
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"xreal-light-xr-go/auth"
+	"xreal-light-xr-go/fusion"
+	"xreal-light-xr-go/internal/device"
+)
+
+const (
+	defaultVisualizeDuration = 30 * time.Second
+	defaultVisualizeAddress  = "localhost:8090"
+
+	// horizonWidth and horizonHeight are the size of the ASCII artificial horizon in characters, horizonFieldOfView
+	// the pitch in radians from its center to its top or bottom edge
+	horizonWidth       = 61
+	horizonHeight      = 17
+	horizonFieldOfView = math.Pi / 4
+	// characters are about twice as tall as wide, so rows are stretched to keep the roll angle true
+	horizonAspect = 0.5
+
+	visualizeRefreshRate = 20
+)
+
+//go:embed visualize.html
+var visualizePage []byte
+
+// handleVisualizeCommand shows the fused orientation of the glass for a while, as an ASCII artificial horizon in the
+// terminal or as a cube on a local web page. Use 'visualize imu <optional:ascii|web> <optional:seconds>
+// <optional:address>'.
+func handleVisualizeCommand(frontends *networkFrontends, d device.Device, input string) {
+	const usage = "use 'visualize imu <optional:ascii|web> <optional:seconds> <optional:address>'"
+	parts := strings.Fields(input)
+	if len(parts) < 2 || parts[1] != "imu" {
+		slog.Error(fmt.Sprintf("invalid command format: %s", usage))
+		return
+	}
+	mode := "ascii"
+	if len(parts) > 2 {
+		mode = parts[2]
+	}
+	duration := defaultVisualizeDuration
+	if len(parts) > 3 {
+		seconds, err := time.ParseDuration(parts[3] + "s")
+		if err != nil || seconds <= 0 {
+			slog.Error(fmt.Sprintf("invalid duration %s, %s", parts[3], usage))
+			return
+		}
+		duration = seconds
+	}
+	address := defaultVisualizeAddress
+	if len(parts) > 4 {
+		address = parts[4]
+	}
+	if mode != "ascii" && mode != "web" {
+		slog.Error(fmt.Sprintf("unknown visualization %s, %s", mode, usage))
+		return
+	}
+
+	filter := fusion.NewComplementaryFilter(fusion.DEFAULT_GYROSCOPE_WEIGHT)
+	restore := borrowIMU(d, func(imu *device.IMUEvent) {
+		filter.Update(imu)
+	})
+	defer restore()
+	if err := d.EnableIMU(true); err != nil {
+		slog.Error(fmt.Sprintf("failed to enable IMU stream: %v", err))
+		return
+	}
+
+	var err error
+	if mode == "web" {
+		err = serveOrientationPage(frontends, filter, address, duration)
+	} else {
+		renderHorizonFor(filter, duration)
+	}
+	if err != nil {
+		slog.Error(fmt.Sprintf("visualize imu %s failed: %v", mode, err))
+	}
+}
+
+// borrowIMU replaces the IMU event handler of d with handler, the returned restore puts the previous handler back and
+// disables the IMU stream again unless it was enabled before.
+func borrowIMU(d device.Device, handler device.IMUEventHandler) (restore func()) {
+	previous := d.GetIMUEventHandler()
+	wasEnabled := d.IsIMUEnabled()
+	d.SetIMUEventHandler(handler)
+	return func() {
+		if !wasEnabled {
+			if err := d.EnableIMU(false); err != nil {
+				slog.Warn(fmt.Sprintf("failed to disable IMU stream: %v", err))
+			}
+		}
+		d.SetIMUEventHandler(previous)
+	}
+}
+
+// renderHorizonFor redraws the artificial horizon in place until duration elapsed.
+func renderHorizonFor(filter *fusion.ComplementaryFilter, duration time.Duration) {
+	ticker := time.NewTicker(time.Second / visualizeRefreshRate)
+	defer ticker.Stop()
+	deadline := time.After(duration)
+
+	// the horizon and its status line
+	lines := horizonHeight + 1
+	fmt.Fprint(os.Stdout, strings.Repeat("\n", lines))
+	for {
+		select {
+		case <-ticker.C:
+			// moves the cursor back up to overwrite the previous frame
+			fmt.Fprintf(os.Stdout, "\x1b[%dA%s", lines, renderHorizon(filter.Attitude()))
+		case <-deadline:
+			return
+		}
+	}
+}
+
+// renderHorizon draws the attitude as an artificial horizon: the sky blank, the ground dotted, the horizon line
+// dashed and the glass at the center, followed by the angles and a yaw heading strip.
+func renderHorizon(attitude fusion.Attitude) string {
+	var frame strings.Builder
+	centerX := float64(horizonWidth-1) / 2
+	centerY := float64(horizonHeight-1) / 2
+	// pitching up moves the horizon down, rolling right tilts it left
+	offset := attitude.Pitch / horizonFieldOfView * centerY
+	slope := math.Tan(-attitude.Roll) * horizonAspect
+	// the horizon flips over when upside down
+	inverted := math.Cos(attitude.Roll) < 0
+
+	for row := 0; row < horizonHeight; row++ {
+		frame.WriteByte('|')
+		for column := 0; column < horizonWidth; column++ {
+			horizon := centerY + offset + slope*(float64(column)-centerX)
+			distance := float64(row) - horizon
+			switch {
+			case row == int(centerY) && column == int(centerX):
+				frame.WriteByte('+')
+			case row == int(centerY) && math.Abs(float64(column)-centerX) <= 6 && math.Abs(float64(column)-centerX) >= 3:
+				frame.WriteByte('=')
+			case math.Abs(distance) < 0.5:
+				frame.WriteByte('-')
+			case (distance > 0) != inverted:
+				frame.WriteByte('.')
+			default:
+				frame.WriteByte(' ')
+			}
+		}
+		frame.WriteString("|\n")
+	}
+	fmt.Fprintf(&frame, "%s  heading %s\x1b[K\n", attitude.String(), headingStrip(attitude.Yaw))
+	return frame.String()
+}
+
+// headingStrip shows yaw as a scale of compass marks around the current heading, relative to the heading at start.
+func headingStrip(yaw float64) string {
+	const width = 25
+	const degreesPerCharacter = 5
+
+	heading := math.Mod(-yaw*180/math.Pi+360, 360)
+	var strip strings.Builder
+	fmt.Fprintf(&strip, "%3.0f [", heading)
+	for i := -width / 2; i <= width/2; i++ {
+		mark := int(math.Round(heading/degreesPerCharacter)) + i
+		angle := ((mark*degreesPerCharacter)%360 + 360) % 360
+		switch {
+		case i == 0:
+			strip.WriteByte('^')
+		case angle%90 == 0:
+			strip.WriteByte("NESW"[angle/90])
+		case angle%30 == 0:
+			strip.WriteByte('|')
+		default:
+			strip.WriteByte(' ')
+		}
+	}
+	strip.WriteByte(']')
+	return strip.String()
+}
+
+// orientationMessage is sent to the web page, angles in radians.
+type orientationMessage struct {
+	W     float64 `json:"w"`
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Z     float64 `json:"z"`
+	Roll  float64 `json:"roll"`
+	Pitch float64 `json:"pitch"`
+	Yaw   float64 `json:"yaw"`
+}
+
+// serveOrientationPage serves the cube page at / and the orientation at /ws until duration elapsed, to clients
+// granted auth.SCOPE_READ_SENSORS with -auth.
+func serveOrientationPage(frontends *networkFrontends, filter *fusion.ComplementaryFilter, address string, duration time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(visualizePage)
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			slog.Debug(fmt.Sprintf("failed to upgrade: %v", err))
+			return
+		}
+		defer conn.Close()
+
+		ticker := time.NewTicker(time.Second / visualizeRefreshRate)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				attitude := filter.Attitude()
+				q := attitude.Quaternion()
+				message := orientationMessage{W: q.W, X: q.X, Y: q.Y, Z: q.Z, Roll: attitude.Roll, Pitch: attitude.Pitch, Yaw: attitude.Yaw}
+				if err := conn.WriteJSON(message); err != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	server, listener, url, err := frontends.listen(address, auth.SCOPE_READ_SENSORS, mux)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	slog.Info(fmt.Sprintf("open %s to see the orientation of the glass for %v", url, duration))
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}